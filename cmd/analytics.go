@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/analytics"
+)
+
+// ExportAnalytics prints the locally recorded feature usage as JSON, or writes it to output if given.
+func ExportAnalytics(version, output string) error {
+	report, err := analytics.Export(version)
+	if err != nil {
+		return fmt.Errorf("failed to read analytics: %w", err)
+	}
+	if config.Cfg.Analytics != analytics.ModeLocal && len(report.Features) == 0 {
+		fmt.Fprintln(os.Stderr, "Analytics are disabled. Set analytics = \"local\" in the config to count feature usage.")
+	}
+
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		fmt.Println(string(content))
+		return nil
+	}
+	if err := os.WriteFile(output, content, 0o644); err != nil {
+		return err
+	}
+	fmt.Println("Analytics exported to", output)
+	return nil
+}
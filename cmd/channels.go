@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/handlers"
+	"github.com/jiotv-go/jiotv_go/v3/internal/plugins"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ChannelsOptions are the filters and output format of the channels command.
+type ChannelsOptions struct {
+	// Search matches channel names and IDs case-insensitively
+	Search string
+	// Language and Category match the names of the language and category, e.g. "hindi" or "sports"
+	Language string
+	Category string
+	// JSON prints the channels as JSON instead of a table
+	JSON bool
+}
+
+// channelInfo is a channel as printed by the channels command.
+type channelInfo struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Number   int    `json:"number,omitempty"`
+	Category string `json:"category"`
+	Language string `json:"language"`
+	Group    string `json:"group,omitempty"`
+	HD       bool   `json:"hd"`
+	Catchup  bool   `json:"catchup"`
+	Custom   bool   `json:"custom"`
+}
+
+// initChannels loads the login, custom channels and plugins that the channel list is made of,
+// for commands that run without the server.
+func initChannels() error {
+	if store.KVS == nil {
+		if err := store.Init(); err != nil {
+			return fmt.Errorf("failed to initialize store: %w", err)
+		}
+	}
+	if utils.Log == nil {
+		utils.Log = utils.GetLogger()
+	}
+
+	handlers.Init()
+	// Register the plugins so that their channels are listed too
+	plugins.Init(fiber.New(fiber.Config{DisableStartupMessage: true}))
+	return nil
+}
+
+// ListChannels prints the JioTV, custom and plugin channels that match the options,
+// so that channel IDs can be looked up without the web interface.
+func ListChannels(opts ChannelsOptions) error {
+	if err := initChannels(); err != nil {
+		return err
+	}
+	channels, err := handlers.DisplayedChannels()
+	if err != nil {
+		return fmt.Errorf("failed to fetch channels: %w", err)
+	}
+	return printChannels(os.Stdout, filterChannels(channels, opts), opts.JSON)
+}
+
+// filterChannels returns the channels that match the search, language and category of the options.
+func filterChannels(channels []television.Channel, opts ChannelsOptions) []channelInfo {
+	search := strings.ToLower(strings.TrimSpace(opts.Search))
+	language := strings.TrimSpace(opts.Language)
+	category := strings.TrimSpace(opts.Category)
+
+	infos := make([]channelInfo, 0, len(channels))
+	for _, channel := range channels {
+		info := channelInfo{
+			ID:       channel.ID,
+			Name:     channel.Name,
+			Number:   channel.Number,
+			Category: television.CategoryMap[channel.Category],
+			Language: television.LanguageMap[channel.Language],
+			Group:    channel.Group,
+			HD:       channel.IsHD,
+			Catchup:  channel.IsCatchupAvailable,
+			Custom:   channel.IsCustom,
+		}
+		if search != "" && !strings.Contains(strings.ToLower(info.Name), search) && !strings.EqualFold(info.ID, search) {
+			continue
+		}
+		if language != "" && !strings.EqualFold(info.Language, language) {
+			continue
+		}
+		if category != "" && !strings.EqualFold(info.Category, category) {
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// printChannels writes the channels to w as a table, or as JSON.
+func printChannels(w io.Writer, channels []channelInfo, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(channels)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNO.\tNAME\tCATEGORY\tLANGUAGE\tCATCHUP")
+	for _, channel := range channels {
+		number := "-"
+		if channel.Number > 0 {
+			number = fmt.Sprint(channel.Number)
+		}
+		catchup := ""
+		if channel.Catchup {
+			catchup = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", channel.ID, number, channel.Name, channel.Category, channel.Language, catchup)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%d channels\n", len(channels))
+	return err
+}
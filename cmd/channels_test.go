@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+func TestFilterChannels(t *testing.T) {
+	channels := []television.Channel{
+		{ID: "143", Name: "Sony HD", Category: 5, Language: 1},
+		{ID: "144", Name: "Star Sports 1 Hindi", Category: 8, Language: 1, IsCatchupAvailable: true},
+		{ID: "145", Name: "Star Sports 1", Category: 8, Language: 6},
+	}
+	tests := []struct {
+		name string
+		opts ChannelsOptions
+		want []string
+	}{
+		{name: "all channels", opts: ChannelsOptions{}, want: []string{"143", "144", "145"}},
+		{name: "search by name", opts: ChannelsOptions{Search: "star sports"}, want: []string{"144", "145"}},
+		{name: "search by ID", opts: ChannelsOptions{Search: "143"}, want: []string{"143"}},
+		{name: "language", opts: ChannelsOptions{Language: "HINDI"}, want: []string{"143", "144"}},
+		{name: "category", opts: ChannelsOptions{Category: "sports"}, want: []string{"144", "145"}},
+		{name: "language and category", opts: ChannelsOptions{Language: "hindi", Category: "sports"}, want: []string{"144"}},
+		{name: "no match", opts: ChannelsOptions{Search: "news"}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, channel := range filterChannels(channels, tt.opts) {
+				got = append(got, channel.ID)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("filterChannels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintChannels(t *testing.T) {
+	channels := []channelInfo{{ID: "143", Name: "Sony HD", Number: 5, Category: "Entertainment", Language: "Hindi", Catchup: true}}
+
+	var table bytes.Buffer
+	if err := printChannels(&table, channels, false); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(table.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "ID") || !strings.Contains(lines[1], "Sony HD") || lines[2] != "1 channels" {
+		t.Errorf("table = %q", table.String())
+	}
+
+	var out bytes.Buffer
+	if err := printChannels(&out, channels, true); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []channelInfo
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	if len(decoded) != 1 || decoded[0] != channels[0] {
+		t.Errorf("JSON = %+v", decoded)
+	}
+}
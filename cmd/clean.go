@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/janitor"
+)
+
+// Clean removes stale temp files, superseded EPG backups, expired image cache
+// entries and old logs under the path prefix. With dryRun set, the files are only listed.
+func Clean(dryRun bool) error {
+	items, err := janitor.Clean(dryRun)
+	if err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		fmt.Println("Nothing to clean")
+		return nil
+	}
+
+	for _, item := range items {
+		fmt.Printf("%s\t%s\t%s\n", item.Reason, janitor.FormatSize(item.Size), item.Path)
+	}
+
+	size := janitor.FormatSize(janitor.TotalSize(items))
+	if dryRun {
+		fmt.Printf("Would remove %d files (%s)\n", len(items), size)
+	} else {
+		fmt.Printf("Removed %d files (%s)\n", len(items), size)
+	}
+	return nil
+}
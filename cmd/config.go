@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/BurntSushi/toml"
+	"github.com/jiotv-go/jiotv_go/v3/configs"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+// defaultConfigFile is the config file created by config init
+const defaultConfigFile = "jiotv_go.toml"
+
+// ConfigInit writes the example config, with a comment on every option, to output or jiotv_go.toml.
+// Existing files are only replaced with force.
+func ConfigInit(output string, force bool) error {
+	if output == "" {
+		output = defaultConfigFile
+	}
+	if _, err := os.Stat(output); err == nil && !force {
+		return fmt.Errorf("%s already exists, use --force to replace it", output)
+	}
+	if err := os.WriteFile(output, configs.ExampleTOML, 0o644); err != nil {
+		return err
+	}
+	fmt.Println("Config written to", output)
+	return nil
+}
+
+// findConfigFile returns the given config file or the one JioTV Go would load.
+func findConfigFile(filename string) (string, error) {
+	path := config.FindFile(filename)
+	if path == "" {
+		return "", fmt.Errorf("no config file found, create one with the config init command")
+	}
+	return path, nil
+}
+
+// ConfigValidate checks the config file for unknown keys, values of the wrong type or that are
+// not allowed, and files that do not exist. It returns an error if it found problems.
+func ConfigValidate(filename string) error {
+	path, err := findConfigFile(filename)
+	if err != nil {
+		return err
+	}
+	issues, err := config.Validate(path)
+	if err != nil {
+		return fmt.Errorf("%s is not valid: %w", path, err)
+	}
+	if len(issues) == 0 {
+		fmt.Println(path, "is valid")
+		return nil
+	}
+	for _, issue := range issues {
+		fmt.Println(issue)
+	}
+	return fmt.Errorf("%s has problems, see above", path)
+}
+
+// ConfigSet sets a key of the config file to value. Lists are comma separated.
+func ConfigSet(filename, key, value string) error {
+	path, err := findConfigFile(filename)
+	if err != nil {
+		return err
+	}
+	if err := config.SetValue(path, key, value); err != nil {
+		return err
+	}
+	fmt.Printf("Set %s in %s. Restart the server to apply it.\n", key, path)
+	return nil
+}
+
+// ConfigShow prints the config in effect, with defaults and environment variables applied,
+// as TOML. With redact, tokens and passwords are left out so it can be shared.
+func ConfigShow(filename string, redact bool) error {
+	return printConfig(os.Stdout, config.FindFile(filename), config.Cfg, redact)
+}
+
+// printConfig writes the config to w as TOML.
+func printConfig(w io.Writer, path string, cfg config.JioTVConfig, redact bool) error {
+	if redact {
+		cfg = config.Redacted(cfg)
+	}
+	if path == "" {
+		path = "none, environment variables only"
+	}
+	fmt.Fprintf(w, "# Config file: %s\n", path)
+	return toml.NewEncoder(w).Encode(cfg)
+}
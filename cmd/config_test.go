@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/configs"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+func TestConfigInit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jiotv_go.toml")
+
+	if err := ConfigInit(path, false); err != nil {
+		t.Fatalf("ConfigInit() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, configs.ExampleTOML) {
+		t.Errorf("ConfigInit() wrote %d bytes, want the example config", len(data))
+	}
+	if issues, err := config.Validate(path); err != nil || len(issues) > 0 {
+		t.Errorf("Validate() of the example config = %v, %v", issues, err)
+	}
+
+	if err := os.WriteFile(path, []byte("epg = true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ConfigInit(path, false); err == nil {
+		t.Error("ConfigInit() replaced an existing file without force")
+	}
+	if err := ConfigInit(path, true); err != nil {
+		t.Fatalf("ConfigInit() with force error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, configs.ExampleTOML) {
+		t.Error("ConfigInit() with force did not replace the file")
+	}
+}
+
+func TestPrintConfig(t *testing.T) {
+	cfg := config.JioTVConfig{EPG: true, Proxy: "user:secret@proxy.example:8080"}
+
+	tests := []struct {
+		name    string
+		path    string
+		redact  bool
+		want    []string
+		notWant string
+	}{
+		{"plain", "jiotv_go.toml", false, []string{"# Config file: jiotv_go.toml", "epg = true", "user:secret@"}, ""},
+		{"redacted", "", true, []string{"# Config file: none", "user:REDACTED@"}, "secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := printConfig(&buf, tt.path, cfg, tt.redact); err != nil {
+				t.Fatalf("printConfig() error = %v", err)
+			}
+			out := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("printConfig() = %q, missing %q", out, want)
+				}
+			}
+			if tt.notWant != "" && strings.Contains(out, tt.notWant) {
+				t.Errorf("printConfig() = %q, contains %q", out, tt.notWant)
+			}
+		})
+	}
+}
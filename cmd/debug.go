@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/handlers"
+	"github.com/jiotv-go/jiotv_go/v3/internal/plugins"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/capture"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// debugMaxRedirects limits how many local redirects are followed during a capture
+const debugMaxRedirects = 5
+
+// DebugCapture plays the given channel once and writes every upstream request and response
+// made on the way, with credentials and tokens redacted, into a zip bundle.
+// If output is empty, the bundle is written to the current directory.
+func DebugCapture(channelID, output string) error {
+	if channelID == "" {
+		return fmt.Errorf("channel ID is required")
+	}
+	if store.KVS == nil {
+		if err := store.Init(); err != nil {
+			return fmt.Errorf("failed to initialize store: %w", err)
+		}
+	}
+	if utils.Log == nil {
+		utils.Log = utils.GetLogger()
+	}
+
+	// Collect the values to redact before any request is made
+	secrets := []string{utils.GetDeviceID()}
+	if credentials, err := utils.GetJIOTVCredentials(); err == nil {
+		secrets = append(secrets, credentials.SSOToken, credentials.UniqueID, credentials.CRM, credentials.AccessToken, credentials.RefreshToken)
+	}
+	recorder := capture.Start(secrets...)
+	defer recorder.Stop()
+
+	handlers.Init()
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/live/:id", handlers.LiveHandler)
+	app.Get("/live/:quality/:id", handlers.LiveQualityHandler)
+	app.Get("/render.m3u8", handlers.RenderHandler)
+	plugins.Init(app)
+
+	path := "/live/" + channelID + ".m3u8"
+	for _, task := range plugins.RefreshTasks() {
+		if err := task.Run(); err != nil {
+			fmt.Println("WARN:", task.Name, "failed:", err)
+		}
+	}
+	if plugins.HasChannel("zee5", channelID) {
+		path = "/zee5/" + channelID
+	}
+
+	fmt.Println("Capturing upstream requests for channel", channelID)
+	status, body, captureErr := debugFetch(app, path)
+	if captureErr == nil && status == fiber.StatusOK {
+		// Fetch the first variant so the media playlist request is captured too
+		if variant := firstPlaylistURI(body); variant != "" {
+			_, _, captureErr = debugFetch(app, variant)
+		}
+	}
+
+	info := map[string]string{
+		"channel":    channelID,
+		"path":       path,
+		"status":     fmt.Sprint(status),
+		"time":       time.Now().UTC().Format(time.RFC3339),
+		"os":         runtime.GOOS,
+		"arch":       runtime.GOARCH,
+		"go_version": runtime.Version(),
+	}
+	if captureErr != nil {
+		info["error"] = captureErr.Error()
+	}
+
+	if output == "" {
+		output = fmt.Sprintf("jiotv_go-debug-%s-%s.zip", channelID, time.Now().Format("20060102-150405"))
+	}
+	if err := recorder.WriteBundle(output, info); err != nil {
+		return err
+	}
+	fmt.Printf("Captured %d upstream requests into %s\n", len(recorder.Exchanges()), output)
+	fmt.Println("Credentials and stream tokens are redacted, but please review the bundle before sharing it.")
+	return nil
+}
+
+// debugFetch requests path from the local app, following local redirects,
+// and returns the final status code and body.
+func debugFetch(app *fiber.App, path string) (int, string, error) {
+	for i := 0; i <= debugMaxRedirects; i++ {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil), -1)
+		if err != nil {
+			return 0, "", err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return resp.StatusCode, "", err
+		}
+		location := resp.Header.Get("Location")
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 || location == "" {
+			return resp.StatusCode, string(body), nil
+		}
+		parsed, err := url.Parse(location)
+		if err != nil || parsed.IsAbs() {
+			// External redirects are not followed, the upstream requests made so far are enough
+			return resp.StatusCode, string(body), nil
+		}
+		path = parsed.RequestURI()
+	}
+	return 0, "", fmt.Errorf("too many redirects")
+}
+
+// firstPlaylistURI returns the first local URI in an HLS playlist.
+func firstPlaylistURI(playlist string) string {
+	for _, line := range strings.Split(playlist, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") && strings.HasPrefix(line, "/") {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/handlers"
+)
+
+// ANSI colors of the diagnose report
+const (
+	colorReset  = "\033[0m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorRed    = "\033[31m"
+)
+
+// Diagnose checks the login, proxies, DNS, channels API and playback of a channel and prints
+// a report, to find out why channels do not play. It returns an error if a check failed.
+func Diagnose(channelID string) error {
+	if err := initChannels(); err != nil {
+		return err
+	}
+	fmt.Println("Running checks, this can take a few seconds...")
+	checks := handlers.Diagnose(channelID)
+	printDiagnosis(os.Stdout, checks, useColor(os.Stdout))
+
+	failed := 0
+	for _, check := range checks {
+		if check.Status == handlers.DiagnosticFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
+
+// useColor reports whether colors are printed to f: only to terminals, and never with NO_COLOR set.
+func useColor(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// printDiagnosis writes a line per check to w, colored by status if color is set.
+func printDiagnosis(w io.Writer, checks []handlers.DiagnosticCheck, color bool) {
+	width, failed := 0, false
+	for _, check := range checks {
+		width = max(width, len(check.Name))
+		failed = failed || check.Status == handlers.DiagnosticFail
+	}
+	for _, check := range checks {
+		label, code := "PASS", colorGreen
+		switch check.Status {
+		case handlers.DiagnosticWarn:
+			label, code = "WARN", colorYellow
+		case handlers.DiagnosticFail:
+			label, code = "FAIL", colorRed
+		}
+		if color {
+			label = code + label + colorReset
+		}
+		fmt.Fprintf(w, "[%s] %-*s  %s (%s)\n", label, width, check.Name, check.Detail, check.Duration.Round(time.Millisecond))
+	}
+	if failed {
+		fmt.Fprintln(w, "Fix the first failed check first, later checks often fail because of it.")
+		fmt.Fprintln(w, "Include this report when you open an issue.")
+	}
+}
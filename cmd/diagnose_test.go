@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/handlers"
+)
+
+func TestPrintDiagnosis(t *testing.T) {
+	checks := []handlers.DiagnosticCheck{
+		{Name: "Login", Status: handlers.DiagnosticOK, Detail: "Logged in", Duration: time.Millisecond},
+		{Name: "DNS", Status: handlers.DiagnosticFail, Detail: "Could not resolve jiotvapi.media.jio.com"},
+	}
+
+	var plain bytes.Buffer
+	printDiagnosis(&plain, checks, false)
+	out := plain.String()
+	for _, want := range []string{"[PASS] Login  Logged in (1ms)", "[FAIL] DNS    Could not resolve", "Fix the first failed check"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("printDiagnosis() = %q, missing %q", out, want)
+		}
+	}
+	if strings.Contains(out, "\033[") {
+		t.Errorf("printDiagnosis() without color has escape codes: %q", out)
+	}
+
+	var colored bytes.Buffer
+	printDiagnosis(&colored, checks[:1], true)
+	if !strings.Contains(colored.String(), colorGreen+"PASS"+colorReset) {
+		t.Errorf("printDiagnosis() with color = %q", colored.String())
+	}
+	if strings.Contains(colored.String(), "Fix the first failed check") {
+		t.Errorf("printDiagnosis() without failures = %q", colored.String())
+	}
+}
@@ -2,8 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"time"
 
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/plugins"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 )
@@ -25,6 +29,7 @@ func GenEPG() error {
 
 	fmt.Println("Generating new EPG file")
 
+	plugins.RegisterEPGSources()
 	err = epg.GenXMLGz(epgFile)
 	return err
 }
@@ -51,3 +56,65 @@ func DeleteEPG() error {
 
 	return nil
 }
+
+// DownloadEPG downloads the external EPG at epgURL, or at epg_url of the config if it is empty,
+// and saves it to epg.xml.gz, replacing the existing file.
+func DownloadEPG(epgURL string) error {
+	if epgURL == "" {
+		epgURL = config.Cfg.EPGURL
+	}
+	if epgURL == "" {
+		return fmt.Errorf("EPG URL is required")
+	}
+
+	fmt.Println("Downloading EPG from", epgURL)
+	epgFile := utils.GetPathPrefix() + "epg.xml.gz"
+	if err := epg.DownloadExternalEPG(epgURL, epgFile); err != nil {
+		return err
+	}
+	fmt.Println("EPG saved to", epgFile)
+	return nil
+}
+
+// EPGStatus prints the age, size, number of channels and programmes and the covered time
+// of the epg.xml.gz file.
+func EPGStatus() error {
+	epgFile := utils.GetPathPrefix() + "epg.xml.gz"
+	status, err := epg.Status(epgFile)
+	if os.IsNotExist(err) {
+		fmt.Println("EPG file does not exist")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", epgFile, err)
+	}
+	printEPGStatus(os.Stdout, epgFile, status, time.Now())
+	return nil
+}
+
+// printEPGStatus writes the status of the EPG file to w.
+func printEPGStatus(w io.Writer, epgFile string, status epg.FileStatus, now time.Time) {
+	fmt.Fprintf(w, "File:       %s\n", epgFile)
+	fmt.Fprintf(w, "Size:       %s\n", formatFileSize(status.Size))
+	fmt.Fprintf(w, "Updated:    %s (%s ago)\n", status.ModTime.Format(time.RFC1123), now.Sub(status.ModTime).Truncate(time.Minute))
+	fmt.Fprintf(w, "Channels:   %d\n", status.Channels)
+	fmt.Fprintf(w, "Programmes: %d\n", status.Programmes)
+	if status.Programmes > 0 {
+		fmt.Fprintf(w, "Guide:      %s to %s\n", status.Start.Format(time.RFC1123), status.End.Format(time.RFC1123))
+		if status.End.Before(now) {
+			fmt.Fprintln(w, "The guide has no programmes after now. Run the epg generate or download command to update it.")
+		}
+	}
+}
+
+// formatFileSize returns a size in bytes in KB or MB.
+func formatFileSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
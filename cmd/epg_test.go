@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"bytes"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 )
@@ -72,3 +76,38 @@ func TestDeleteEPG(t *testing.T) {
 		t.Errorf("DeleteEPG() should have deleted the file, but it still exists")
 	}
 }
+
+func TestFormatFileSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{size: 512, want: "512 B"},
+		{size: 1536, want: "1.5 KB"},
+		{size: 5 << 20, want: "5.0 MB"},
+	}
+	for _, tt := range tests {
+		if got := formatFileSize(tt.size); got != tt.want {
+			t.Errorf("formatFileSize(%d) = %q, want %q", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestPrintEPGStatus(t *testing.T) {
+	now := time.Date(2024, 1, 12, 12, 0, 0, 0, time.UTC)
+	status := epg.FileStatus{
+		ModTime:    now.Add(-90 * time.Minute),
+		Size:       2048,
+		Channels:   2,
+		Programmes: 3,
+		Start:      now.Add(-48 * time.Hour),
+		End:        now.Add(-time.Hour),
+	}
+	var out bytes.Buffer
+	printEPGStatus(&out, "epg.xml.gz", status, now)
+	for _, want := range []string{"Size:       2.0 KB", "(1h30m0s ago)", "Channels:   2", "Programmes: 3", "no programmes after now"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("printEPGStatus() = %q, missing %q", out.String(), want)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log" // Added import for *log.Logger type
-	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/jiotv-go/jiotv_go/v3/internal/config"
@@ -11,10 +14,19 @@ import (
 	"github.com/jiotv-go/jiotv_go/v3/internal/handlers"
 	"github.com/jiotv-go/jiotv_go/v3/internal/middleware"
 	"github.com/jiotv-go/jiotv_go/v3/internal/plugins"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/accessstats"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/analytics"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/bandwidth"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/devices"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
-	"github.com/jiotv-go/jiotv_go/v3/pkg/plugins/zee5"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/janitor"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/maintenance"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/multicast"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/scheduler"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/timeshift"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 	"github.com/jiotv-go/jiotv_go/v3/web"
 
@@ -22,7 +34,6 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/filesystem"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
-	"github.com/gofiber/template/html/v2"
 )
 
 // LoadConfig loads the application configuration from the given path.
@@ -42,12 +53,23 @@ func Logger() *log.Logger { // Corrected to *log.Logger
 	return utils.Log
 }
 
+// shutdownTimeout is how long open connections may take to finish on shutdown.
+// It leaves time for cleanup within the 10 seconds Docker waits before it kills the container.
+const shutdownTimeout = 5 * time.Second
+
+// customChannelsCheckDelay is how long after startup custom channels are first checked for dead links
+const customChannelsCheckDelay = 5 * time.Minute
+
 type JioTVServerConfig struct {
 	Host        string
 	Port        string
 	TLS         bool
 	TLSCertPath string
 	TLSKeyPath  string
+	// Stop shuts the server down like Ctrl+C when it is closed
+	Stop <-chan struct{}
+	// Quiet hides the startup message, e.g. behind the terminal dashboard
+	Quiet bool
 }
 
 // JioTVServer starts the JioTV server.
@@ -67,6 +89,61 @@ func JioTVServer(jiotvServerConfig JioTVServerConfig) error {
 		utils.Log = utils.GetLogger()
 	}
 
+	// Pick a working proxy before any upstream request is made
+	utils.ProbeProxies()
+
+	// Fall back to cached data if the JioTV API is unreachable
+	television.InitOfflineMode()
+
+	plugins.RegisterEPGSources()
+
+	// Start Scheduler before any task is added
+	scheduler.Init()
+	defer scheduler.Stop()
+
+	analytics.Init()
+	if analytics.Enabled() {
+		scheduler.Add("analytics-save", 10*time.Minute, analytics.Save)
+		defer func() {
+			if err := analytics.Save(); err != nil {
+				utils.Log.Printf("WARN: Failed to save analytics: %v", err)
+			}
+		}()
+	}
+
+	accessstats.Init()
+	if accessstats.Enabled() {
+		scheduler.Add("access-stats-save", 10*time.Minute, accessstats.Save)
+		defer func() {
+			if err := accessstats.Save(); err != nil {
+				utils.Log.Printf("WARN: Failed to save access stats: %v", err)
+			}
+		}()
+	}
+
+	bandwidth.Init()
+	if bandwidth.Enabled() {
+		scheduler.Add("bandwidth-save", 10*time.Minute, bandwidth.Save)
+		defer func() {
+			if err := bandwidth.Save(); err != nil {
+				utils.Log.Printf("WARN: Failed to save bandwidth usage: %v", err)
+			}
+		}()
+	}
+
+	devices.Init()
+	scheduler.Add("devices-save", 10*time.Minute, devices.Save)
+	defer func() {
+		if err := devices.Save(); err != nil {
+			utils.Log.Printf("WARN: Failed to save the devices: %v", err)
+		}
+	}()
+
+	maintenance.Init()
+
+	// Free timeshift buffers, which may be kept in the temporary directory
+	defer timeshift.StopAll()
+
 	// if config EPG is true or file epg.xml.gz exists
 	if (config.Cfg.EPG && config.Cfg.EPGURL == "") || utils.FileExists(utils.GetPathPrefix()+"epg.xml.gz") {
 		go epg.Init()
@@ -76,10 +153,6 @@ func JioTVServer(jiotvServerConfig JioTVServerConfig) error {
 	// 	go epg.Init()
 	// }
 
-	// Start Scheduler
-	scheduler.Init()
-	defer scheduler.Stop()
-
 	if config.Cfg.EPGURL != "" {
 		epgFile := utils.GetPathPrefix() + "epg.xml.gz"
 		if err := epg.DownloadExternalEPG(config.Cfg.EPGURL, epgFile); err != nil {
@@ -90,6 +163,17 @@ func JioTVServer(jiotvServerConfig JioTVServerConfig) error {
 		})
 	}
 
+	epg.InitArtworkPrefetch(config.AllFavoriteChannels())
+	janitor.Init()
+
+	// Rotate the URL encryption key and pick up the keys rotated by other servers of a shared store
+	if !config.Cfg.DisableURLEncryption && (config.Cfg.URLKeyRotation > 0 || store.Shared()) {
+		scheduler.Add("url-key-rotation", time.Minute, secureurl.RotateIfDue)
+	}
+
+	// Release the converted streams of channels that are no longer watched
+	scheduler.Add("idle-stream-reaper", 15*time.Second, handlers.ReapIdleStreams)
+
 	go func() {
 		if err := RefreshCustomChannelsFromM3U(); err != nil {
 			utils.Log.Printf("WARN: Custom channels refresh failed: %v", err)
@@ -97,87 +181,252 @@ func JioTVServer(jiotvServerConfig JioTVServerConfig) error {
 	}()
 	scheduler.Add("custom-channels-refresh", 6*time.Hour, RefreshCustomChannelsFromM3U)
 
-	// Refresh Zee5 data on startup and every 4 hours
-	if config.PluginEnabled("zee5") {
+	// Sony channels are read from their data file, and refreshed from their data URL
+	if config.Cfg.SonyDataFile != "" {
+		go func() {
+			if err := television.RefreshSonyChannels(); err != nil {
+				utils.Log.Printf("WARN: Sony channels refresh failed: %v", err)
+			}
+		}()
+		if config.Cfg.SonyDataURL != "" {
+			scheduler.Add("sony-data-refresh", 6*time.Hour, television.RefreshSonyChannels)
+		}
+	}
+
+	// Check custom channels for dead links, after the refresh above has loaded them
+	if hours := config.Cfg.CustomChannelsCheckHours; hours > 0 && config.Cfg.CustomChannelsFile != "" {
+		scheduler.AddAtWithContext("custom-channels-check", time.Now().Add(customChannelsCheckDelay), time.Duration(hours)*time.Hour, func(ctx context.Context) error {
+			_, err := television.CheckCustomChannels(ctx)
+			return err
+		})
+	}
+
+	// Refresh plugin data, e.g. of Zee5 every 4 hours, on startup and periodically
+	for _, task := range plugins.RefreshTasks() {
 		go func() {
-			if err := zee5.RefreshZee5DataFromURL(); err != nil {
-				utils.Log.Printf("WARN: Zee5 data refresh failed: %v", err)
+			if err := task.Run(); err != nil {
+				utils.Log.Printf("WARN: %s failed: %v", task.Name, err)
 			}
 		}()
-		scheduler.Add("zee5-data-refresh", 4*time.Hour, zee5.RefreshZee5DataFromURL)
+		scheduler.Add(task.Name, task.Interval, task.Run)
 	}
 
-	engine := html.NewFileSystem(http.FS(web.GetViewFiles()), ".html")
-	if config.Cfg.Debug {
-		engine.Reload(true)
+	if !web.Included {
+		utils.Log.Println("INFO: Headless build. The web interface is not included.")
 	}
+	engine := web.Views(config.Cfg.Debug)
 
 	app := fiber.New(fiber.Config{
-		Views:             engine,
-		Network:           fiber.NetworkTCP,
-		StreamRequestBody: true,
-		CaseSensitive:     false,
-		StrictRouting:     false,
-		EnablePrintRoutes: false,
-		ServerHeader:      "JioTV Go",
-		AppName:           fmt.Sprintf("JioTV Go %s", constants.Version),
+		Views:                 engine,
+		Network:               fiber.NetworkTCP,
+		StreamRequestBody:     true,
+		CaseSensitive:         false,
+		StrictRouting:         false,
+		EnablePrintRoutes:     false,
+		ServerHeader:          "JioTV Go",
+		DisableStartupMessage: jiotvServerConfig.Quiet,
+		AppName:               fmt.Sprintf("JioTV Go %s", constants.Version),
+		ErrorHandler:          handlers.ErrorHandler,
 	})
 
 	app.Use(recover.New(recover.Config{
 		EnableStackTrace: true,
 	}))
 
+	// Select the tenant first, as it may remove the tenant prefix from the path
+	app.Use(handlers.TenantHandler)
+
 	app.Use(middleware.CORS())
 
+	if config.Cfg.GuestMode {
+		utils.Log.Println("INFO: Guest mode enabled. Login, logout and admin routes are hidden.")
+	}
+	app.Use(middleware.GuestMode(config.Cfg.GuestMode))
+	if middleware.WebLoginEnabled() {
+		utils.Log.Println("INFO: Web login enabled. Admin actions need a session or the admin token.")
+	}
+	app.Use(middleware.WebLogin(config.Cfg.WebProtectPlayback))
+	app.Use(middleware.Maintenance())
+
+	app.Use(middleware.Stats())
+	// Keep the playback session in the URLs that players request next
+	app.Use(handlers.SessionHandler)
+	app.Use(middleware.Analytics())
+
 	app.Use(logger.New(logger.Config{
+		// Health checks run every few seconds and would flood the log
+		Next: func(c *fiber.Ctx) bool {
+			return c.Path() == "/healthz"
+		},
 		TimeZone: "Asia/Kolkata",
 		Format:   "[${time}] ${status} - ${latency} ${method} ${path} Params:[${queryParams}] ${error}\n",
 		Output:   utils.Log.Writer(),
 	}))
 
 	app.Use("/static", filesystem.New(filesystem.Config{
-		Root:       http.FS(web.GetStaticFiles()),
+		Root:       web.StaticFiles(),
 		PathPrefix: "static",
 		Browse:     false,
 	}))
 
+	// Pass the theme and branding to the web pages
+	app.Use(handlers.ThemeHandler)
+
+	// Pass the viewer profiles to the web pages
+	app.Use(handlers.ProfilesHandler)
+
+	// Refuse streams once the monthly bandwidth cap is reached
+	if config.Cfg.BandwidthCapGB > 0 {
+		app.Use(handlers.BandwidthCapHandler)
+	}
+
 	// Handle all /out/* routes
 	app.Use("/out/", handlers.SLHandler)
 
 	// Initialize the television object
 	handlers.Init()
 
+	// Send the channels of multicast_outputs to the local network until the server stops
+	handlers.StartMulticastOutputs()
+	defer multicast.StopAll()
+
+	app.Get("/healthz", handlers.HealthHandler)
 	app.Get("/", handlers.IndexHandler)
+	app.Get("/session/login", handlers.WebLoginPageHandler)
+	app.Post("/session/login", handlers.WebLoginHandler)
+	app.Post("/session/logout", handlers.WebLogoutHandler)
+	app.Post("/profile", handlers.SelectProfileHandler)
 	app.Post("/login/sendOTP", handlers.LoginSendOTPHandler)
 	app.Post("/login/verifyOTP", handlers.LoginVerifyOTPHandler)
 	app.Get("/logout", handlers.LogoutHandler)
+	app.Use("/live", handlers.LiveLinkHandler)
+	app.Get("/live/:id.mpd", handlers.LiveDASHHandler)
 	app.Get("/live/:id", handlers.LiveHandler)
 	app.Get("/live/:quality/:id", handlers.LiveQualityHandler)
 	app.Get("/render.m3u8", handlers.RenderHandler)
 	app.Get("/render.ts", handlers.RenderTSHandler)
 	app.Get("/render.key", handlers.RenderKeyHandler)
 	app.Get("/channels", handlers.ChannelsHandler)
+	app.Get("/api/v1/channels", handlers.ChannelsHandler)
+	app.Get("/api/v1/channels/changes", handlers.ChannelChangesHandler)
+	app.Get("/api/v1/channels/check", handlers.ChannelCheckHandler)
+	app.Post("/api/v1/channels/check", handlers.RunChannelCheckHandler)
+	app.Get("/api/v1/channels/validate", handlers.ValidateChannelsHandler)
+	app.Post("/api/v1/channels/validate", handlers.ValidateChannelsBodyHandler)
+	app.Get("/api/v1/channels/number/:number", handlers.ChannelNumberHandler)
+	app.Get("/api/v1/channels/hidden", handlers.HiddenChannelsHandler)
+	app.Post("/api/v1/channels/hidden", handlers.SetChannelHiddenHandler)
+	app.Get("/channels/visibility", handlers.ChannelVisibilityHandler)
+	app.Get("/api/v1/androidtv/rows", handlers.AndroidTVRowsHandler)
 	app.Get("/playlist.m3u", handlers.PlaylistHandler)
+	app.Get("/bouquet.tv", handlers.BouquetHandler)
+	app.Get("/library.zip", handlers.LibraryHandler)
+	app.Get("/multicast.m3u", handlers.MulticastPlaylistHandler)
+	app.Get("/guide", handlers.GuideHandler)
+	app.Get("/api/v1/guide", handlers.GuideDataHandler)
 	app.Get("/play/:id", handlers.PlayHandler)
 	app.Get("/player/:id", handlers.PlayerHandler)
 	app.Get("/catchup/:id", handlers.CatchupHandler)
 	app.Get("/catchup/play/:id", handlers.CatchupPlayerHandler)
 	app.Get("/catchup/render/:id", handlers.CatchupRenderPlayerHandler)
 	app.Get("/catchup/stream/:id", handlers.CatchupStreamHandler)
+	app.Get("/catchup/at/:id", handlers.CatchupAtHandler)
+	app.Get("/api/v1/catchup/search", handlers.CatchupSearchHandler)
+	app.Get("/api/v1/catchup/feed", handlers.CatchupFeedHandler)
+	app.Get("/startover/:id", handlers.StartOverHandler)
+	app.Get("/timeshift/:id/index.m3u8", handlers.TimeshiftHandler)
+	app.Get("/timeshift/:id/:segment.ts", handlers.TimeshiftSegmentHandler)
+	app.Get("/api/v1/stream/:id/stats", handlers.StreamStatsHandler)
+	app.Get("/dash/:id/:variant/:segment.ts", handlers.DASHSegmentHandler)
+	app.Get("/hls/:id/index.m3u8", handlers.HLSMasterHandler)
+	app.Get("/hls/:id/media.m3u8", handlers.HLSMediaHandler)
 	app.Get("/favicon.ico", handlers.FaviconHandler)
+	app.Get("/manifest.webmanifest", handlers.WebManifestHandler)
+	app.Get("/sw.js", handlers.ServiceWorkerHandler)
+	app.Get("/offline", handlers.OfflineHandler)
 	app.Get("/jtvimage/:file", handlers.ImageHandler)
+	app.Get("/logo/:id.png", handlers.LogoHandler)
+	app.Get("/preview/:id.jpg", handlers.PreviewHandler)
 	app.Get("/epg.xml.gz", handlers.EPGHandler)
+	app.Get("/epg.json", handlers.EPGJSONHandler)
+	app.Get("/api/v1/epg/:channel/:day", handlers.EPGChannelDayHandler)
+	app.Get("/api/v1/now/:channelID", handlers.NowNextHandler)
 	app.Get("/epg/:channelID/:offset", handlers.WebEPGHandler)
 	app.Get("/jtvposter/:date/:file", handlers.PosterHandler)
 	app.Get("/mpd/:channelID", handlers.LiveMpdHandler)
 	app.Post("/drm", handlers.DRMKeyHandler)
 	app.Get("/dashtime", handlers.DASHTimeHandler)
 
+	// Scheduled maintenance window
+	app.Get("/api/maintenance", handlers.MaintenanceHandler)
+	app.Post("/api/maintenance", handlers.SetMaintenanceHandler)
+	app.Delete("/api/maintenance", handlers.ClearMaintenanceHandler)
+
+	// Casting to Chromecasts and DLNA renderers on the local network
+	app.Get("/api/cast/devices", handlers.CastDevicesHandler)
+	app.Post("/api/cast/play", handlers.CastPlayHandler)
+	app.Post("/api/cast/stop", handlers.CastStopHandler)
+	app.Post("/api/cast/volume", handlers.CastVolumeHandler)
+
+	// Server log for the admin
+	app.Get("/api/logs/stream", handlers.LogStreamHandler)
+
+	// Custom channels import, export and order for the admin
+	app.Get("/admin/channels", handlers.ChannelsAdminHandler)
+	app.Post("/api/admin/channels/import", handlers.ImportChannelsHandler)
+	app.Get("/api/admin/channels/export", handlers.ExportChannelsHandler)
+	app.Get("/api/admin/channels/order", handlers.ChannelOrderHandler)
+	app.Post("/api/admin/channels/order", handlers.SetChannelOrderHandler)
+
+	// Playback tokens of devices for the admin
+	app.Get("/admin/devices", handlers.DevicesAdminHandler)
+	app.Get("/api/admin/devices", handlers.DevicesHandler)
+	app.Post("/api/admin/devices", handlers.CreateDeviceHandler)
+	app.Delete("/api/admin/devices/:id", handlers.RevokeDeviceHandler)
+
+	// Plays of channels and clients for the admin
+	app.Get("/admin/stats", handlers.AccessStatsDashboardHandler)
+	app.Get("/api/v1/stats", handlers.AccessStatsHandler)
+	app.Delete("/api/v1/stats", handlers.ResetAccessStatsHandler)
+
+	// Streams that are playing
+	app.Get("/api/v1/sessions", handlers.SessionsHandler)
+
+	// Bytes streamed by client and channel
+	app.Get("/api/v1/bandwidth", handlers.BandwidthHandler)
+
+	// Keys of the URL encryption
+	app.Get("/api/admin/url-keys", handlers.URLKeysHandler)
+	app.Post("/api/admin/url-keys/rotate", handlers.RotateURLKeysHandler)
+
+	// Grafana JSON datasource
+	app.Get("/api/grafana", handlers.GrafanaTestHandler)
+	app.Post("/api/grafana/metrics", handlers.GrafanaMetricsHandler)
+	app.Post("/api/grafana/search", handlers.GrafanaMetricsHandler)
+	app.Post("/api/grafana/query", handlers.GrafanaQueryHandler)
+
 	app.Get("/render.mpd", handlers.MpdHandler)
 	app.Use("/render.dash", handlers.DashHandler)
 
 	plugins.Init(app)
 
+	// Shut down gracefully on Ctrl+C or SIGTERM, so that the deferred scheduler.Stop
+	// can let running tasks finish or store them to resume after restart.
+	// Streams are cut after shutdownTimeout, as players keep their connections open.
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		select {
+		case <-sig:
+		case <-jiotvServerConfig.Stop:
+		}
+		utils.Log.Println("Shutting down...")
+		handlers.SetShuttingDown()
+		if err := app.ShutdownWithTimeout(shutdownTimeout); err != nil {
+			utils.Log.Printf("WARN: Failed to shut down the server: %v", err)
+		}
+	}()
+
 	if jiotvServerConfig.TLS {
 		if jiotvServerConfig.TLSCertPath == "" || jiotvServerConfig.TLSKeyPath == "" {
 			return fmt.Errorf("TLS cert and key paths are required for HTTPS. Please provide them using --tls-cert and --tls-key flags")
@@ -2,7 +2,10 @@ package cmd
 
 import (
 	"log"
+	"path/filepath"
 	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -102,6 +105,8 @@ func TestJioTVServer(t *testing.T) {
 			wantErr: true, // Should fail with invalid configuration
 		},
 	}
+	// The server refreshes the custom channels in the background, keep it out of the configs of the repo
+	config.Cfg.CustomChannelsFile = filepath.Join(t.TempDir(), "custom-channels.json")
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// This function may panic due to uninitialized dependencies
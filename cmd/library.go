@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/handlers"
+)
+
+// ExportLibrary writes a .strm file and NFO metadata of every channel into dir, so that Kodi and
+// Jellyfin can add the channels as a library. The stream URLs point to the server at serverURL,
+// which has to be running when the channels are played.
+func ExportLibrary(dir, serverURL, quality string) error {
+	if dir == "" {
+		return fmt.Errorf("output directory is required")
+	}
+	if serverURL == "" {
+		return fmt.Errorf("server URL is required")
+	}
+	if err := initChannels(); err != nil {
+		return err
+	}
+
+	count, err := handlers.ExportLibrary(dir, serverURL, quality)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d channels to %s\n", count, dir)
+	return nil
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/handlers"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/record"
+)
+
+// recordTimeLayouts are the layouts of the start and end of a recording, besides Unix times
+var recordTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04"}
+
+// RecordOptions are the options of the record command.
+type RecordOptions struct {
+	Channel string
+	// Start and End are RFC 3339 times, local times like "2024-01-15 21:00" or Unix times.
+	// Start is now if empty, and End may be left out for Duration.
+	Start    string
+	End      string
+	Duration time.Duration
+	Output   string
+	Quality  string
+}
+
+// parseRecordTime parses the start or end of a recording. Times without a time zone are local times.
+func parseRecordTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "now" {
+		return now, nil
+	}
+	if epoch, err := strconv.ParseInt(value, 10, 64); err == nil {
+		// Unix times in milliseconds, as in the catchup URLs of the web interface, or in seconds
+		if epoch >= 100000000000 {
+			return time.UnixMilli(epoch), nil
+		}
+		return time.Unix(epoch, 0), nil
+	}
+	for _, layout := range recordTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use e.g. \"2006-01-02 15:04\", RFC 3339 or a Unix time", value)
+}
+
+// recordWindow returns the start and end of the recording of the options.
+func recordWindow(opts RecordOptions, now time.Time) (time.Time, time.Time, error) {
+	start, err := parseRecordTime(opts.Start, now)
+	if err != nil {
+		return start, start, err
+	}
+	var end time.Time
+	switch {
+	case opts.End != "":
+		if end, err = parseRecordTime(opts.End, now); err != nil {
+			return start, end, err
+		}
+	case opts.Duration > 0:
+		end = start.Add(opts.Duration)
+	default:
+		return start, end, fmt.Errorf("--end or --duration is required")
+	}
+	if !end.After(start) {
+		return start, end, fmt.Errorf("end must be after start")
+	}
+	return start, end, nil
+}
+
+// Record saves a channel from the start to the end of the options to a .ts file, without the server.
+// Programmes that have ended are saved from catchup, others from the live stream.
+// Ctrl+C stops the recording and keeps what was saved.
+func Record(opts RecordOptions) error {
+	if opts.Channel == "" {
+		return fmt.Errorf("channel ID is required")
+	}
+	start, end, err := recordWindow(opts, time.Now())
+	if err != nil {
+		return err
+	}
+	if opts.Output == "" {
+		opts.Output = fmt.Sprintf("jiotv_go-%s-%s.ts", opts.Channel, start.Format("20060102-1504"))
+	}
+	if err := initChannels(); err != nil {
+		return err
+	}
+
+	f, err := os.Create(opts.Output)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("Recording channel %s from %s to %s into %s\n", opts.Channel, start.Format(time.RFC1123), end.Format(time.RFC1123), opts.Output)
+	var saved record.Progress
+	err = handlers.RecordChannel(ctx, opts.Channel, opts.Quality, start, end, f, func(p record.Progress) {
+		saved = p
+		fmt.Printf("\rSaved %s, %s", p.Duration.Truncate(time.Second), formatFileSize(p.Bytes))
+	})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if saved.Segments > 0 {
+		fmt.Println()
+	}
+	if err != nil {
+		return err
+	}
+	if saved.Segments == 0 {
+		os.Remove(opts.Output)
+		fmt.Println("Nothing was recorded")
+		return nil
+	}
+	fmt.Printf("Recorded %s of channel %s into %s\n", saved.Duration.Truncate(time.Second), opts.Channel, opts.Output)
+	return nil
+}
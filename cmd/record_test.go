@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRecordTime(t *testing.T) {
+	now := time.Date(2024, 1, 15, 20, 0, 0, 0, time.Local)
+	want := time.Date(2024, 1, 15, 21, 30, 0, 0, time.Local)
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "empty is now", value: "", want: now},
+		{name: "now", value: "now", want: now},
+		{name: "local time", value: "2024-01-15 21:30", want: want},
+		{name: "local time with seconds", value: "2024-01-15 21:30:00", want: want},
+		{name: "RFC 3339", value: want.Format(time.RFC3339), want: want},
+		{name: "Unix seconds", value: "1705334400", want: time.Unix(1705334400, 0)},
+		{name: "Unix milliseconds", value: "1705334400000", want: time.Unix(1705334400, 0)},
+		{name: "invalid", value: "tonight", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRecordTime(tt.value, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRecordTime(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(tt.want) {
+				t.Errorf("parseRecordTime(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordWindow(t *testing.T) {
+	now := time.Date(2024, 1, 15, 20, 0, 0, 0, time.Local)
+	tests := []struct {
+		name    string
+		opts    RecordOptions
+		wantEnd time.Time
+		wantErr bool
+	}{
+		{name: "end", opts: RecordOptions{End: "2024-01-15 21:00"}, wantEnd: now.Add(time.Hour)},
+		{name: "duration", opts: RecordOptions{Start: "2024-01-15 20:30", Duration: 30 * time.Minute}, wantEnd: now.Add(time.Hour)},
+		{name: "no end", opts: RecordOptions{}, wantErr: true},
+		{name: "end before start", opts: RecordOptions{Start: "2024-01-15 21:00", End: "2024-01-15 20:30"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, end, err := recordWindow(tt.opts, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("recordWindow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !end.Equal(tt.wantEnd) {
+				t.Errorf("recordWindow() end = %v, want %v", end, tt.wantEnd)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
@@ -16,7 +17,6 @@ import (
 	"time"
 
 	"github.com/jiotv-go/jiotv_go/v3/internal/config"
-	"github.com/jiotv-go/jiotv_go/v3/pkg/plugins/zee5"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 )
@@ -34,9 +34,10 @@ const (
 )
 
 // SetupEnvironment performs the startup setup:
-// 1. Downloads config files (overwriting existing ones).
-// 2. Fetches M3U playlists.
-// 3. Adds channels from M3U to custom-channels.json.
+// 1. Downloads jiotv_go.toml and custom-channels.json if they do not exist.
+// 2. Adds missing custom_channels_file and plugins settings to jiotv_go.toml.
+// 3. Downloads the Zee5 data.
+// Existing settings and files are never replaced, so running it again changes nothing the user edited.
 func SetupEnvironment() error {
 	fmt.Println("INFO: Starting environment setup...")
 
@@ -98,7 +99,10 @@ func SetupEnvironment() error {
 	customChPath := filepath.Join(configDir, "custom-channels.json")
 	fmt.Printf("INFO: Custom channels JSON path: %s\n", customChPath)
 	fmt.Printf("INFO: Custom channels alt JSON path: %s\n", filepath.Join(configDir, "custom_channels.json"))
-	if err := downloadFile(CustomChJSONURL, customChPath); err != nil {
+	// Existing files may be edited by hand, they are updated by the server or the setup command
+	if pathExists(customChPath) {
+		fmt.Printf("INFO: custom-channels.json exists, skipping download: %s\n", customChPath)
+	} else if err := downloadFile(CustomChJSONURL, customChPath); err != nil {
 		if !pathExists(customChPath) {
 			altCustomCh := filepath.Join("configs", "custom-channels.json")
 			if pathExists(altCustomCh) {
@@ -139,7 +143,10 @@ func SetupEnvironment() error {
 		if len(data) >= 3 && data[0] == 0xEF && data[1] == 0xBB && data[2] == 0xBF {
 			data = data[3:]
 		}
-		var zee5Data zee5.DataFile
+		// Only the channels are counted, so that setup does not need the Zee5 plugin in headless builds
+		var zee5Data struct {
+			Data []json.RawMessage `json:"data"`
+		}
 		if unmarshalErr := json.Unmarshal(data, &zee5Data); unmarshalErr != nil {
 			fmt.Printf("WARN: Failed to parse zee5-data.json: %v\n", unmarshalErr)
 		} else {
@@ -151,6 +158,8 @@ func SetupEnvironment() error {
 	return nil
 }
 
+// RefreshCustomChannelsFromM3U downloads the custom channels of custom_channels_url into custom_channels_file,
+// then adds the channels of the m3u_sources. The existing file is kept if the download fails.
 func RefreshCustomChannelsFromM3U() error {
 	customChPath := strings.TrimSpace(config.Cfg.CustomChannelsFile)
 	if customChPath == "" {
@@ -168,17 +177,73 @@ func RefreshCustomChannelsFromM3U() error {
 	if err := downloadFile(urlStr, customChPath); err != nil {
 		if pathExists(customChPath) {
 			utils.Log.Printf("WARN: Custom channels download failed (keeping existing file): %v", err)
-			return nil
+		} else {
+			utils.Log.Printf("WARN: Custom channels download failed and no local file exists: %v", err)
+		}
+	} else {
+		utils.Log.Printf("INFO: Refreshed custom channels from URL")
+	}
+
+	if sources := config.Cfg.M3USources; len(sources) > 0 {
+		if err := addM3USourcesToFile(customChPath, sources); err != nil {
+			utils.Log.Printf("WARN: Failed to add the channels of m3u_sources: %v", err)
 		}
-		utils.Log.Printf("WARN: Custom channels download failed and no local file exists: %v", err)
-		return nil
 	}
 
 	television.ReloadCustomChannels()
-	utils.Log.Printf("INFO: Refreshed custom channels from URL")
 	return nil
 }
 
+// addM3USourcesToFile adds the channels of the M3U sources to the JSON custom channels file.
+func addM3USourcesToFile(customChPath string, sources []config.M3USource) error {
+	if !strings.EqualFold(filepath.Ext(customChPath), ".json") {
+		return fmt.Errorf("m3u_sources need a JSON custom_channels_file, %s is not", customChPath)
+	}
+	data, err := os.ReadFile(customChPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	updated, errs := addM3USourceChannels(sources, data)
+	for _, err := range errs {
+		utils.Log.Printf("WARN: %v", err)
+	}
+	if string(updated) == string(data) {
+		return nil
+	}
+	return os.WriteFile(customChPath, updated, 0644)
+}
+
+// addM3USourceChannels adds the channels of the M3U sources to custom channels JSON.
+// Channels with the ID of a channel in data are left out. Sources that fail are skipped and returned as errors.
+func addM3USourceChannels(sources []config.M3USource, data []byte) ([]byte, []error) {
+	var channels []television.CustomChannel
+	var errs []error
+	for _, source := range sources {
+		sourceChannels, err := fetchAndParseM3U(source)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to load M3U source %s: %w", source.URL, err))
+			continue
+		}
+		channels = append(channels, sourceChannels...)
+	}
+	if len(channels) == 0 {
+		return data, errs
+	}
+
+	source, err := marshalCustomChannels(television.CustomChannelsConfig{Channels: dedupeCustomChannels(channels)}, "")
+	if err != nil {
+		return data, append(errs, err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		data = []byte(`{"channels": []}`)
+	}
+	merged, err := mergeCustomChannels(data, source)
+	if err != nil {
+		return data, append(errs, err)
+	}
+	return merged, errs
+}
+
 func dedupeCustomChannels(channels []television.CustomChannel) []television.CustomChannel {
 	seen := make(map[string]struct{}, len(channels))
 	out := make([]television.CustomChannel, 0, len(channels))
@@ -231,87 +296,50 @@ func dirWritable(dir string) bool {
 // ensurePluginsSettingInToml ensures plugins = ["zee5"] is present in the TOML config.
 // If a plugins line already exists, it is left unchanged.
 func ensurePluginsSettingInToml(tomlPath string) error {
-	data, err := os.ReadFile(tomlPath)
-	if err != nil {
-		return err
-	}
-
-	desired := `plugins = ["zee5"]`
-
-	scanner := bufio.NewScanner(strings.NewReader(string(data)))
-	var out strings.Builder
-	out.Grow(len(data) + 32)
-
-	found := false
-	for scanner.Scan() {
-		line := scanner.Text()
-		trimmed := strings.TrimSpace(strings.TrimSuffix(line, "\r"))
-		if strings.HasPrefix(trimmed, "#") {
-			out.WriteString(line)
-			out.WriteByte('\n')
-			continue
-		}
-		if strings.HasPrefix(trimmed, "plugins") {
-			// Line already exists — keep it as-is
-			out.WriteString(line)
-			out.WriteByte('\n')
-			found = true
-			continue
-		}
-		out.WriteString(line)
-		out.WriteByte('\n')
-	}
-	if err := scanner.Err(); err != nil {
-		return err
-	}
-
-	if !found {
-		out.WriteString(desired)
-		out.WriteByte('\n')
-	}
+	return ensureTomlSetting(tomlPath, "plugins", `plugins = ["zee5"]`)
+}
 
-	return os.WriteFile(tomlPath, []byte(out.String()), 0644)
+// ensureCustomChannelsSettingInToml ensures custom_channels_file is set in the TOML config.
+// If a custom_channels_file line already exists, it is left unchanged, so that hand-edited paths are kept.
+func ensureCustomChannelsSettingInToml(tomlPath string) error {
+	return ensureTomlSetting(tomlPath, "custom_channels_file", `custom_channels_file = "configs/custom-channels.json"`)
 }
 
-func ensureCustomChannelsSettingInToml(tomlPath string) error {	data, err := os.ReadFile(tomlPath)
+// ensureTomlSetting adds the line to the TOML config if key is not set. The file is not written if it is.
+func ensureTomlSetting(tomlPath, key, line string) error {
+	data, err := os.ReadFile(tomlPath)
 	if err != nil {
 		return err
 	}
+	updated := addTomlSetting(data, key, line)
+	if string(updated) == string(data) {
+		return nil
+	}
+	return os.WriteFile(tomlPath, updated, 0644)
+}
 
-	desired := `custom_channels_file = "configs/custom-channels.json"`
-
+// addTomlSetting returns data with the line appended if key is not set, or data unchanged if it is.
+// Commented out lines do not count as set.
+func addTomlSetting(data []byte, key, line string) []byte {
 	scanner := bufio.NewScanner(strings.NewReader(string(data)))
-	var out strings.Builder
-	out.Grow(len(data) + 64)
-
-	found := false
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
 	for scanner.Scan() {
-		line := scanner.Text()
-		trimmed := strings.TrimSpace(strings.TrimSuffix(line, "\r"))
+		trimmed := strings.TrimSpace(strings.TrimSuffix(scanner.Text(), "\r"))
 		if strings.HasPrefix(trimmed, "#") {
-			out.WriteString(line)
-			out.WriteByte('\n')
 			continue
 		}
-		if strings.HasPrefix(trimmed, "custom_channels_file") {
-			out.WriteString(desired)
-			out.WriteByte('\n')
-			found = true
-			continue
+		if name, _, ok := strings.Cut(trimmed, "="); ok && strings.TrimSpace(name) == key {
+			return data
 		}
-		out.WriteString(line)
-		out.WriteByte('\n')
-	}
-	if err := scanner.Err(); err != nil {
-		return err
 	}
 
-	if !found {
-		out.WriteString(desired)
-		out.WriteByte('\n')
+	out := make([]byte, 0, len(data)+len(line)+2)
+	out = append(out, data...)
+	if len(out) > 0 && out[len(out)-1] != '\n' {
+		out = append(out, '\n')
 	}
-
-	return os.WriteFile(tomlPath, []byte(out.String()), 0644)
+	out = append(out, line...)
+	return append(out, '\n')
 }
 
 func readTomlCustomChannelsValue(tomlPath string) (string, error) {
@@ -440,16 +468,17 @@ func downloadFileOnce(urlStr, filePath string) error {
 	return nil
 }
 
-func fetchAndParseM3U(urlStr string) ([]television.CustomChannel, error) {
+// fetchAndParseM3U downloads the playlist of the M3U source and returns its channels.
+func fetchAndParseM3U(source config.M3USource) ([]television.CustomChannel, error) {
 	var lastErr error
-	for _, candidate := range fallbackURLs(urlStr) {
+	for _, candidate := range fallbackURLs(strings.TrimSpace(source.URL)) {
 		resp, err := httpGetOK(candidate)
 		if err != nil {
 			lastErr = err
 			continue
 		}
 
-		channels, parseErr := parseM3U(resp.Body)
+		channels, parseErr := television.ParseM3U(resp.Body, source)
 		_ = resp.Body.Close()
 		if parseErr != nil {
 			lastErr = parseErr
@@ -543,151 +572,353 @@ func jsDelivrFallback(urlStr string) string {
 	}
 }
 
-func parseM3U(r io.Reader) ([]television.CustomChannel, error) {
+func GetConfigDir() string {
+	return ConfigDir
+}
 
-	var channels []television.CustomChannel
-	scanner := bufio.NewScanner(r)
-	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+// SetupOptions are the options of the setup command.
+type SetupOptions struct {
+	// SourceURL is the URL of the custom channels JSON. custom_channels_url of the config is used if empty.
+	SourceURL string
+	// MergeOnly adds the channels of the source that are missing and keeps the others of the custom channels file
+	MergeOnly bool
+	// DryRun shows the changes without writing them
+	DryRun bool
+	// SkipTOML leaves jiotv_go.toml alone
+	SkipTOML bool
+	// Yes writes changes of existing files without asking
+	Yes bool
+}
 
-	var currentChannel television.CustomChannel
-	isInfoLine := false
+// setupChange is a file written by the setup command.
+type setupChange struct {
+	Path string
+	// Old is the current content, nil if the file does not exist
+	Old []byte
+	New []byte
+	// Summary describes the change instead of a line diff, for large data files
+	Summary []string
+}
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
+// Setup downloads or updates jiotv_go.toml, custom-channels.json and the Zee5 data in the configs folder.
+// The channels of the m3u_sources of the config are added to custom-channels.json.
+// It shows what changes in existing files and asks before writing them, keeping a .bak copy.
+func Setup(opts SetupOptions, in io.Reader, out io.Writer) error {
+	configDir, err := setupConfigDir()
+	if err != nil {
+		return err
+	}
+	changes, err := planSetup(out, configDir, opts)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Fprintf(out, "Everything in %s is up to date.\n", configDir)
+		return nil
+	}
+
+	replaces := false
+	for _, change := range changes {
+		printSetupChange(out, change)
+		replaces = replaces || change.Old != nil
+	}
+	if opts.DryRun {
+		fmt.Fprintln(out, "Dry run, nothing was written.")
+		return nil
+	}
+	if replaces && !opts.Yes && !confirm(in, out, "Apply these changes?") {
+		fmt.Fprintln(out, "Nothing was written. Run with --yes to apply the changes without asking.")
+		return nil
+	}
+	return applySetup(out, changes)
+}
 
-		if strings.HasPrefix(line, "#EXTINF:") {
-			isInfoLine = true
-			currentChannel = television.CustomChannel{}
-			// Parse metadata
-			// Example: #EXTINF:-1 tvg-id="Sony_HD" tvg-logo="http://..." group-title="Entertainment",Sony HD
+// setupConfigDir returns the configs folder next to the binary, or the one in the working directory
+// if it has a jiotv_go.toml and the one next to the binary does not, as SetupEnvironment does.
+func setupConfigDir() (string, error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to get executable path: %w", err)
+	}
+	configDir := filepath.Join(chooseConfigBaseDir(filepath.Dir(exePath)), ConfigDir)
+	if !pathExists(filepath.Join(configDir, "jiotv_go.toml")) && pathExists(filepath.Join(ConfigDir, "jiotv_go.toml")) {
+		return ConfigDir, nil
+	}
+	return configDir, nil
+}
 
-			// Extract Name (after last comma)
-			lastCommaIdx := strings.LastIndex(line, ",")
-			if lastCommaIdx != -1 {
-				currentChannel.Name = strings.TrimSpace(line[lastCommaIdx+1:])
+// planSetup returns the files of the configs folder that setup creates or changes.
+// The Zee5 data is optional, a failed download is only reported on out.
+func planSetup(out io.Writer, configDir string, opts SetupOptions) ([]setupChange, error) {
+	var changes []setupChange
+	add := func(path string, data []byte, summary []string) {
+		old, err := os.ReadFile(path)
+		if err != nil {
+			old = nil
+		}
+		if old != nil && string(old) == string(data) {
+			return
+		}
+		changes = append(changes, setupChange{Path: path, Old: old, New: data, Summary: summary})
+	}
+
+	if !opts.SkipTOML {
+		tomlPath := filepath.Join(configDir, "jiotv_go.toml")
+		data, err := os.ReadFile(tomlPath)
+		if err != nil {
+			if data, err = downloadBytes(JioTVGoTomlURL); err != nil {
+				return nil, fmt.Errorf("failed to download jiotv_go.toml: %w", err)
 			}
+		}
+		data = addTomlSetting(data, "custom_channels_file", `custom_channels_file = "configs/custom-channels.json"`)
+		data = addTomlSetting(data, "plugins", `plugins = ["zee5"]`)
+		add(tomlPath, data, nil)
+	}
 
-			// Extract Logo
-			currentChannel.LogoURL = extractAttribute(line, "tvg-logo")
+	sourceURL := strings.TrimSpace(opts.SourceURL)
+	if sourceURL == "" {
+		sourceURL = strings.TrimSpace(config.Cfg.CustomChannelsURL)
+	}
+	if sourceURL == "" {
+		sourceURL = CustomChJSONURL
+	}
+	customChPath := filepath.Join(configDir, "custom-channels.json")
+	data, err := downloadBytes(sourceURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download custom channels from %s: %w", sourceURL, err)
+	}
+	var source television.CustomChannelsConfig
+	if err := json.Unmarshal(data, &source); err != nil {
+		return nil, fmt.Errorf("the custom channels of %s are not valid: %w", sourceURL, err)
+	}
+	old, _ := os.ReadFile(customChPath)
+	if opts.MergeOnly && old != nil {
+		if data, err = mergeCustomChannels(old, data); err != nil {
+			return nil, err
+		}
+	}
+	data, errs := addM3USourceChannels(config.Cfg.M3USources, data)
+	for _, err := range errs {
+		fmt.Fprintf(out, "WARN: %v\n", err)
+	}
+	add(customChPath, data, customChannelsSummary(old, data))
 
-			// Extract ID
-			id := extractAttribute(line, "tvg-id")
-			if id == "" {
-				// Generate a random ID or use Name
-				id = strings.ReplaceAll(strings.ToLower(currentChannel.Name), " ", "_")
-			}
-			currentChannel.ID = id
+	zee5DataPath := filepath.Join(configDir, "zee5-data.json")
+	if data, err := downloadBytes(Zee5DataJSONURL); err != nil {
+		fmt.Fprintf(out, "WARN: Failed to download zee5-data.json: %v\n", err)
+	} else {
+		add(zee5DataPath, data, []string{fmt.Sprintf("~ Zee5 data, %d bytes", len(data))})
+	}
+	return changes, nil
+}
 
-			// Map Category (simple mapping or default)
-			// group-title="Entertainment"
-			groupTitle := extractAttribute(line, "group-title")
-			currentChannel.Category = mapCategory(groupTitle)
+// downloadBytes downloads urlStr, trying the fallback URLs if it fails.
+func downloadBytes(urlStr string) ([]byte, error) {
+	var lastErr error
+	for _, candidate := range fallbackURLs(urlStr) {
+		resp, err := httpGetOK(candidate)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		data, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return data, nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no candidate URLs")
+	}
+	return nil, lastErr
+}
 
-			// Set defaults
-			currentChannel.Language = mapLanguage(extractAttribute(line, "tvg-language"))
-			currentChannel.IsHD = strings.Contains(strings.ToUpper(currentChannel.Name), "HD")
+// mergeCustomChannels adds the channels of source whose ID is not in existing to existing.
+// Other fields and the channels of existing are kept as they are.
+func mergeCustomChannels(existing, source []byte) ([]byte, error) {
+	var file map[string]json.RawMessage
+	if err := json.Unmarshal(existing, &file); err != nil {
+		return nil, fmt.Errorf("the custom channels file is not valid JSON: %w", err)
+	}
+	var channels []json.RawMessage
+	if raw, ok := file["channels"]; ok {
+		if err := json.Unmarshal(raw, &channels); err != nil {
+			return nil, fmt.Errorf("the channels of the custom channels file are not valid: %w", err)
+		}
+	}
+	var sourceFile struct {
+		Channels []json.RawMessage `json:"channels"`
+	}
+	if err := json.Unmarshal(source, &sourceFile); err != nil {
+		return nil, err
+	}
 
-		} else if strings.HasPrefix(line, "#") && isInfoLine {
+	ids := map[string]struct{}{}
+	for _, channel := range channels {
+		ids[customChannelID(channel)] = struct{}{}
+	}
+	added := 0
+	for _, channel := range sourceFile.Channels {
+		id := customChannelID(channel)
+		if _, ok := ids[id]; ok || id == "" {
 			continue
-		} else if !strings.HasPrefix(line, "#") && isInfoLine {
-			// This is the URL line
-			currentChannel.URL = line
-			if strings.HasPrefix(strings.ToLower(currentChannel.URL), "https://") {
-				channels = append(channels, currentChannel)
-			}
-			isInfoLine = false
 		}
+		ids[id] = struct{}{}
+		channels = append(channels, channel)
+		added++
+	}
+	if added == 0 {
+		return existing, nil
 	}
 
-	if err := scanner.Err(); err != nil {
+	raw, err := marshalCustomChannels(channels, "")
+	if err != nil {
 		return nil, err
 	}
+	file["channels"] = raw
+	return marshalCustomChannels(file, "  ")
+}
 
-	return channels, nil
+// marshalCustomChannels encodes custom channels JSON with the given indent and a final newline. Unlike
+// json.Marshal, it keeps &, < and > as they are, so that the URLs of the channels are not re-escaped.
+func marshalCustomChannels(v any, indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", indent)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
-func extractAttribute(line, key string) string {
-	keyStr := key + "=\""
-	start := strings.Index(line, keyStr)
-	if start == -1 {
-		return ""
+// customChannelID returns the trimmed ID of a custom channel in JSON.
+func customChannelID(channel json.RawMessage) string {
+	var c struct {
+		ID string `json:"id"`
 	}
-	start += len(keyStr)
-	end := strings.Index(line[start:], "\"")
-	if end == -1 {
-		return ""
+	_ = json.Unmarshal(channel, &c)
+	return strings.TrimSpace(c.ID)
+}
+
+// customChannelsSummary describes the channels added, removed and changed between two custom channels files.
+func customChannelsSummary(old, updated []byte) []string {
+	channelsByID := func(data []byte) map[string]string {
+		var file struct {
+			Channels []json.RawMessage `json:"channels"`
+		}
+		_ = json.Unmarshal(data, &file)
+		byID := make(map[string]string, len(file.Channels))
+		for _, channel := range file.Channels {
+			var compact bytes.Buffer
+			if json.Compact(&compact, channel) == nil {
+				byID[customChannelID(channel)] = compact.String()
+			}
+		}
+		return byID
+	}
+	before, after := channelsByID(old), channelsByID(updated)
+
+	var added, removed, changed int
+	for id, channel := range after {
+		if previous, ok := before[id]; !ok {
+			added++
+		} else if previous != channel {
+			changed++
+		}
+	}
+	for id := range before {
+		if _, ok := after[id]; !ok {
+			removed++
+		}
+	}
+	return []string{
+		fmt.Sprintf("+ %d channels added", added),
+		fmt.Sprintf("- %d channels removed", removed),
+		fmt.Sprintf("~ %d channels changed", changed),
 	}
-	return line[start : start+end]
 }
 
-func mapCategory(group string) int {
-	// Simple mapping based on known categories in pkg/television/types.go
-	// 5: "Entertainment", 6: "Movies", 7: "Kids", 8: "Sports",
-	group = strings.ToLower(group)
-	if strings.Contains(group, "entertainment") {
-		return 5
+// printSetupChange writes the change of a file: a summary, or the changed lines of small text files.
+func printSetupChange(w io.Writer, change setupChange) {
+	if change.Old == nil {
+		fmt.Fprintf(w, "Create %s\n", change.Path)
+	} else {
+		fmt.Fprintf(w, "Update %s\n", change.Path)
 	}
-	if strings.Contains(group, "movie") {
-		return 6
+	lines := change.Summary
+	if lines == nil {
+		lines = lineDiff(strings.Split(string(change.Old), "\n"), strings.Split(string(change.New), "\n"))
+		if change.Old == nil {
+			lines = []string{fmt.Sprintf("+ %d lines", strings.Count(string(change.New), "\n"))}
+		}
 	}
-	if strings.Contains(group, "kid") {
-		return 7
+	for _, line := range lines {
+		fmt.Fprintf(w, "  %s\n", line)
 	}
-	if strings.Contains(group, "sport") {
-		return 8
+}
+
+// lineDiff returns the lines removed from old with "- " and the lines added in updated with "+ ",
+// in the order of the files. Unchanged lines are left out.
+func lineDiff(old, updated []string) []string {
+	// common[i][j] is the length of the longest common subsequence of old[i:] and updated[j:]
+	common := make([][]int, len(old)+1)
+	for i := range common {
+		common[i] = make([]int, len(updated)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(updated) - 1; j >= 0; j-- {
+			if old[i] == updated[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
 	}
-	if strings.Contains(group, "news") {
-		return 12 // Assuming 12 is News, check types.go later if needed, but 12 is common
+
+	var diff []string
+	i, j := 0, 0
+	for i < len(old) || j < len(updated) {
+		switch {
+		case i < len(old) && j < len(updated) && old[i] == updated[j]:
+			i++
+			j++
+		case j < len(updated) && (i == len(old) || common[i][j+1] >= common[i+1][j]):
+			diff = append(diff, "+ "+updated[j])
+			j++
+		default:
+			diff = append(diff, "- "+old[i])
+			i++
+		}
 	}
-	// Default
-	return 0 // All Categories
+	return diff
 }
 
-func GetConfigDir() string {
-	return ConfigDir
+// confirm asks a yes or no question on out and reports whether the answer read from in is yes.
+func confirm(in io.Reader, out io.Writer, question string) bool {
+	fmt.Fprintf(out, "%s [y/N]: ", question)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
 }
 
-func mapLanguage(lang string) int {
-	lang = strings.ToLower(strings.TrimSpace(lang))
-	switch lang {
-	case "hindi":
-		return 1
-	case "marathi":
-		return 2
-	case "punjabi":
-		return 3
-	case "urdu":
-		return 4
-	case "bengali":
-		return 5
-	case "english":
-		return 6
-	case "malayalam":
-		return 7
-	case "tamil":
-		return 8
-	case "gujarati":
-		return 9
-	case "odia", "oriya":
-		return 10
-	case "telugu":
-		return 11
-	case "bhojpuri":
-		return 12
-	case "kannada":
-		return 13
-	case "assamese":
-		return 14
-	case "nepali":
-		return 15
-	case "french":
-		return 16
-	case "":
-		return 0
-	default:
-		return 18
+// applySetup writes the changes. Existing files are copied to a .bak file first.
+func applySetup(out io.Writer, changes []setupChange) error {
+	for _, change := range changes {
+		if err := os.MkdirAll(filepath.Dir(change.Path), 0755); err != nil {
+			return err
+		}
+		if change.Old != nil {
+			if err := os.WriteFile(change.Path+".bak", change.Old, 0644); err != nil {
+				return fmt.Errorf("failed to back up %s: %w", change.Path, err)
+			}
+		}
+		if err := os.WriteFile(change.Path, change.New, 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Wrote %s\n", change.Path)
 	}
+	return nil
 }
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+func TestAddTomlSetting(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"missing", "epg = true\n", "epg = true\nplugins = [\"zee5\"]\n"},
+		{"missing without newline", "epg = true", "epg = true\nplugins = [\"zee5\"]\n"},
+		{"commented out", "# plugins = []\n", "# plugins = []\nplugins = [\"zee5\"]\n"},
+		{"set by the user", "plugins = []\n", "plugins = []\n"},
+		{"set with spaces", "  plugins=[\"x\"]\n", "  plugins=[\"x\"]\n"},
+		{"other key with the prefix", "plugins_dir = \"x\"\n", "plugins_dir = \"x\"\nplugins = [\"zee5\"]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(addTomlSetting([]byte(tt.data), "plugins", `plugins = ["zee5"]`)); got != tt.want {
+				t.Errorf("addTomlSetting() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnsureCustomChannelsSettingKeepsUserValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jiotv_go.toml")
+	content := "custom_channels_file = \"my-channels.yml\"\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ensureCustomChannelsSettingInToml(path); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != content {
+		t.Errorf("ensureCustomChannelsSettingInToml() changed the file to %q", data)
+	}
+}
+
+func TestMergeCustomChannels(t *testing.T) {
+	existing := []byte(`{"channels":[{"id":"a","name":"My A","url":"https://a?x=1&y=2"}],"channel_numbers":{"143":1}}`)
+	source := []byte(`{"channels":[{"id":"a","name":"A","url":"https://a2"},{"id":"b","name":"B","url":"https://b"}]}`)
+
+	merged, err := mergeCustomChannels(existing, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"My A"`, `"https://a?x=1&y=2"`, `"id": "b"`, `"143": 1`} {
+		if !bytes.Contains(merged, []byte(want)) {
+			t.Errorf("mergeCustomChannels() = %s, missing %s", merged, want)
+		}
+	}
+	if bytes.Contains(merged, []byte("https://a2")) {
+		t.Errorf("mergeCustomChannels() replaced an existing channel: %s", merged)
+	}
+
+	// Merging again adds nothing and keeps the file as it is
+	again, err := mergeCustomChannels(merged, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, merged) {
+		t.Errorf("mergeCustomChannels() is not idempotent:\n%s\n%s", merged, again)
+	}
+}
+
+func TestCustomChannelsSummary(t *testing.T) {
+	old := []byte(`{"channels":[{"id":"a","name":"A"},{"id":"b","name":"B"}]}`)
+	updated := []byte(`{"channels":[{"id":"a","name":"A2"},{"id":"c","name":"C"},{"id":"d","name":"D"}]}`)
+	want := []string{"+ 2 channels added", "- 1 channels removed", "~ 1 channels changed"}
+	if got := customChannelsSummary(old, updated); !reflect.DeepEqual(got, want) {
+		t.Errorf("customChannelsSummary() = %q, want %q", got, want)
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	old := []string{"a", "b", "c", "d"}
+	updated := []string{"a", "c", "x", "d", "e"}
+	want := []string{"- b", "+ x", "+ e"}
+	if got := lineDiff(old, updated); !reflect.DeepEqual(got, want) {
+		t.Errorf("lineDiff() = %q, want %q", got, want)
+	}
+	if got := lineDiff(old, old); len(got) != 0 {
+		t.Errorf("lineDiff() of equal files = %q", got)
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   bool
+	}{
+		{"y\n", true},
+		{"Yes\n", true},
+		{"n\n", false},
+		{"\n", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if got := confirm(strings.NewReader(tt.answer), &out, "Apply?"); got != tt.want {
+			t.Errorf("confirm(%q) = %v, want %v", tt.answer, got, tt.want)
+		}
+	}
+}
+
+func TestApplySetupKeepsBackup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "jiotv_go.toml")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	created := filepath.Join(dir, "custom-channels.json")
+	changes := []setupChange{
+		{Path: path, Old: []byte("old"), New: []byte("new")},
+		{Path: created, New: []byte("{}")},
+	}
+	var out bytes.Buffer
+	if err := applySetup(&out, changes); err != nil {
+		t.Fatal(err)
+	}
+	for file, want := range map[string]string{path: "new", path + ".bak": "old", created: "{}"} {
+		if data, _ := os.ReadFile(file); string(data) != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(file), data, want)
+		}
+	}
+	if _, err := os.Stat(created + ".bak"); !os.IsNotExist(err) {
+		t.Errorf("applySetup() backed up a new file")
+	}
+}
+
+const testM3U = `#EXTM3U
+#EXTINF:-1 tvg-id="sports1" tvg-logo="https://logo/1.png" group-title="Cricket" tvg-language="Hin",Sports One HD
+https://example.com/sports1.m3u8?a=1&b=2
+#EXTINF:-1 tvg-id="news1" group-title="News",News One
+https://example.com/news1.m3u8
+#EXTINF:-1 group-title="Misc",Plain Channel
+https://example.com/plain.m3u8
+#EXTINF:-1 tvg-id="insecure" group-title="Misc",Insecure
+http://example.com/insecure.m3u8
+`
+
+func TestAddM3USourceChannels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/list.m3u" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, testM3U)
+	}))
+	defer server.Close()
+
+	sources := []config.M3USource{
+		{URL: server.URL + "/list.m3u", Prefix: "a_"},
+		{URL: server.URL + "/missing.m3u"},
+	}
+
+	existing := []byte(`{"channels":[{"id":"a_news1","name":"My News"}]}`)
+	updated, errs := addM3USourceChannels(sources, existing)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "missing.m3u") {
+		t.Errorf("addM3USourceChannels() errors = %v, want the missing source", errs)
+	}
+	var file television.CustomChannelsConfig
+	if err := json.Unmarshal(updated, &file); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, channel := range file.Channels {
+		ids = append(ids, channel.ID)
+	}
+	if want := []string{"a_news1", "a_sports1", "a_plain_channel"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("addM3USourceChannels() channels = %v, want %v", ids, want)
+	}
+	if file.Channels[0].Name != "My News" {
+		t.Errorf("addM3USourceChannels() replaced an existing channel: %+v", file.Channels[0])
+	}
+
+	// Without a file, the channels of the sources make a new one
+	created, _ := addM3USourceChannels(sources, nil)
+	if !bytes.Contains(created, []byte(`"a_sports1"`)) {
+		t.Errorf("addM3USourceChannels(nil) = %s", created)
+	}
+
+	// The file is written with the URLs as they are
+	path := filepath.Join(t.TempDir(), "custom-channels.json")
+	if err := os.WriteFile(path, existing, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := addM3USourcesToFile(path, sources); err != nil {
+		t.Fatalf("addM3USourcesToFile() error = %v", err)
+	}
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(written, []byte(`"https://example.com/sports1.m3u8?a=1&b=2"`)) {
+		t.Errorf("addM3USourcesToFile() wrote %s, want the URL of sports1 unescaped", written)
+	}
+}
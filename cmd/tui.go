@@ -0,0 +1,311 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants"
+	"github.com/jiotv-go/jiotv_go/v3/internal/handlers"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/logstream"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/stats"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+	"golang.org/x/term"
+)
+
+const (
+	// tuiRefreshInterval is how often the dashboard is redrawn
+	tuiRefreshInterval = time.Second
+	// tuiLogLines is the number of log lines kept for the log tail
+	tuiLogLines = 200
+
+	// Terminal control sequences of the dashboard
+	enterAltScreen = "\033[?1049h"
+	exitAltScreen  = "\033[?1049l"
+	hideCursor     = "\033[?25l"
+	showCursor     = "\033[?25h"
+	clearScreen    = "\033[H\033[2J"
+
+	// keyCtrlC is the byte read for Ctrl+C in raw mode
+	keyCtrlC = 3
+)
+
+// dashboard is the state shown by the terminal UI.
+type dashboard struct {
+	ServerURL  string
+	Uptime     time.Duration
+	LoggedIn   bool
+	Channels   int
+	EPGUpdated time.Time
+	Streams    []dashboardStream
+	Log        []string
+	// Message is the result of the last action
+	Message string
+}
+
+// dashboardStream is an active stream of the dashboard.
+type dashboardStream struct {
+	Channel  string
+	Client   string
+	Duration time.Duration
+}
+
+// TUI starts the server and shows its status, active streams and log in the terminal, with keys
+// to reload channels, regenerate the EPG and log out. It is meant for servers operated over SSH.
+func TUI(serverConfig JioTVServerConfig) error {
+	stdin, stdout := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(stdin) || !term.IsTerminal(stdout) {
+		return fmt.Errorf("the tui command needs a terminal, use the serve command instead")
+	}
+
+	// Log to the log file and the dashboard only, lines on stdout would break the screen
+	config.Cfg.LogToStdout = false
+	InitializeLogger()
+	log.SetOutput(utils.Log.Writer())
+	recent, lines, unsubscribe := logstream.Default.Subscribe(logstream.LevelInfo)
+	defer unsubscribe()
+	logTail := make([]string, 0, tuiLogLines)
+	for _, line := range recent {
+		logTail = appendLogLine(logTail, line.Text)
+	}
+
+	stop := make(chan struct{})
+	serverConfig.Stop = stop
+	serverConfig.Quiet = true
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- JioTVServer(serverConfig)
+	}()
+
+	oldState, err := term.MakeRaw(stdin)
+	if err != nil {
+		close(stop)
+		return err
+	}
+	defer term.Restore(stdin, oldState)
+	fmt.Print(enterAltScreen + hideCursor)
+	defer fmt.Print(showCursor + exitAltScreen)
+
+	keys := make(chan byte)
+	go readKeys(os.Stdin, keys)
+	started := time.Now()
+	state := dashboard{ServerURL: serverURL(serverConfig)}
+
+	// Actions run one at a time, their result is shown as the message
+	messages := make(chan string, 1)
+	busy := false
+	run := func(message string, action func() string) {
+		if busy {
+			state.Message = "Wait for the running action to finish"
+			return
+		}
+		busy = true
+		state.Message = message
+		go func() {
+			messages <- action()
+		}()
+	}
+
+	collectDashboard(&state)
+	ticker := time.NewTicker(tuiRefreshInterval)
+	defer ticker.Stop()
+	for {
+		state.Uptime = time.Since(started)
+		state.Log = logTail
+		width, height, err := term.GetSize(stdout)
+		if err != nil || width <= 0 || height <= 0 {
+			width, height = 80, 24
+		}
+		fmt.Print(clearScreen + strings.Join(renderDashboard(state, width, height), "\r\n"))
+
+		select {
+		case err := <-serverErr:
+			return err
+		case line := <-lines:
+			logTail = appendLogLine(logTail, line.Text)
+		case message := <-messages:
+			busy = false
+			state.Message = message
+		case key := <-keys:
+			switch key {
+			case 'q', 'Q', keyCtrlC:
+				close(stop)
+				// The server may still be starting, e.g. downloading the EPG, and cannot be stopped then
+				select {
+				case err := <-serverErr:
+					return err
+				case <-time.After(shutdownTimeout + time.Second):
+					return nil
+				}
+			case 'r', 'R':
+				run("Reloading channels...", reloadChannelsAction)
+			case 'e', 'E':
+				run("Regenerating EPG, this can take a few minutes...", regenerateEPGAction)
+			case 'l', 'L':
+				run("Logging out...", logoutAction)
+			}
+		case <-ticker.C:
+			collectDashboard(&state)
+		}
+	}
+}
+
+// readKeys sends the bytes read from r to keys.
+func readKeys(r io.Reader, keys chan<- byte) {
+	buf := make([]byte, 1)
+	for {
+		if _, err := r.Read(buf); err != nil {
+			return
+		}
+		keys <- buf[0]
+	}
+}
+
+// appendLogLine adds a line to the log tail, dropping the oldest line if it is full.
+func appendLogLine(tail []string, line string) []string {
+	if len(tail) >= tuiLogLines {
+		tail = append(tail[:0], tail[1:]...)
+	}
+	return append(tail, line)
+}
+
+// serverURL returns the URL the server listens on.
+func serverURL(serverConfig JioTVServerConfig) string {
+	scheme := "http"
+	if serverConfig.TLS {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%s", scheme, serverConfig.Host, serverConfig.Port)
+}
+
+// collectDashboard updates the login, channel, EPG and stream status of the dashboard.
+// It does not call the JioTV API, as failed calls would log a line on every refresh.
+func collectDashboard(state *dashboard) {
+	credentials, err := utils.GetJIOTVCredentials()
+	state.LoggedIn = err == nil && credentials != nil && (credentials.AccessToken != "" || credentials.SSOToken != "")
+
+	names := map[string]string{}
+	if apiResponse, ok := television.CachedChannels(); ok {
+		state.Channels = len(apiResponse.Result)
+		for _, channel := range apiResponse.Result {
+			names[channel.ID] = channel.Name
+		}
+	}
+
+	state.EPGUpdated = time.Time{}
+	if info, err := os.Stat(utils.GetPathPrefix() + "epg.xml.gz"); err == nil {
+		state.EPGUpdated = info.ModTime()
+	}
+
+	state.Streams = state.Streams[:0]
+	for _, stream := range stats.ActiveStreams() {
+		channel := stream.ChannelID
+		if name, ok := names[stream.ChannelID]; ok {
+			channel = fmt.Sprintf("%s (%s)", name, stream.ChannelID)
+		}
+		state.Streams = append(state.Streams, dashboardStream{
+			Channel:  channel,
+			Client:   stream.Client,
+			Duration: stream.LastSeen.Sub(stream.Started),
+		})
+	}
+}
+
+// renderDashboard returns the lines of the dashboard for a terminal of the size.
+// The log tail takes the lines left by the status and the active streams.
+func renderDashboard(state dashboard, width, height int) []string {
+	epgStatus := "not generated"
+	if !state.EPGUpdated.IsZero() {
+		epgStatus = "updated " + formatAge(time.Since(state.EPGUpdated)) + " ago"
+	}
+	login := "not logged in"
+	if state.LoggedIn {
+		login = "logged in"
+	}
+
+	lines := []string{
+		fmt.Sprintf("JioTV Go %s  %s  up %s", strings.TrimSpace(constants.Version), state.ServerURL, formatAge(state.Uptime)),
+		fmt.Sprintf("Login: %s | Channels: %d | EPG: %s", login, state.Channels, epgStatus),
+		"",
+		fmt.Sprintf("Active streams (%d)", len(state.Streams)),
+	}
+	if len(state.Streams) == 0 {
+		lines = append(lines, "  none")
+	}
+	for _, stream := range state.Streams {
+		lines = append(lines, fmt.Sprintf("  %-30s %-20s %s", stream.Channel, stream.Client, formatAge(stream.Duration)))
+	}
+	lines = append(lines, "", "Log")
+
+	footer := []string{"", "[r] reload channels  [e] regenerate EPG  [l] logout  [q] quit", state.Message}
+	logLines := max(height-len(lines)-len(footer), 0)
+	tail := state.Log[max(len(state.Log)-logLines, 0):]
+	for _, line := range tail {
+		lines = append(lines, "  "+line)
+	}
+	for i := len(tail); i < logLines; i++ {
+		lines = append(lines, "")
+	}
+	lines = append(lines, footer...)
+
+	for i, line := range lines {
+		if runes := []rune(line); len(runes) > width {
+			lines[i] = string(runes[:width])
+		}
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return lines
+}
+
+// formatAge formats a duration in the largest unit, e.g. "5s", "12m" or "3h20m".
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
+
+// reloadChannelsAction reloads the custom channels and the JioTV channel list.
+func reloadChannelsAction() string {
+	apiResponse, err := television.ReloadChannels()
+	if err != nil {
+		return fmt.Sprintf("Failed to reload channels: %v", err)
+	}
+	return fmt.Sprintf("Reloaded %d channels", len(apiResponse.Result))
+}
+
+// regenerateEPGAction downloads the external EPG of epg_url, or generates the EPG from JioTV.
+func regenerateEPGAction() string {
+	epgFile := utils.GetPathPrefix() + "epg.xml.gz"
+	var err error
+	if config.Cfg.EPGURL != "" {
+		err = epg.DownloadExternalEPG(config.Cfg.EPGURL, epgFile)
+	} else {
+		err = epg.GenXMLGz(epgFile)
+	}
+	if err != nil {
+		return fmt.Sprintf("Failed to regenerate EPG: %v", err)
+	}
+	return "EPG regenerated"
+}
+
+// logoutAction logs the account out.
+func logoutAction() string {
+	if err := handlers.Logout(); err != nil {
+		return fmt.Sprintf("Failed to log out: %v", err)
+	}
+	return "Logged out. Log in on the web interface or with the login command."
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderDashboard(t *testing.T) {
+	state := dashboard{
+		ServerURL:  "http://localhost:5001",
+		Uptime:     90 * time.Minute,
+		LoggedIn:   true,
+		Channels:   1024,
+		EPGUpdated: time.Now().Add(-2 * time.Hour),
+		Streams:    []dashboardStream{{Channel: "Aaj Tak (143)", Client: "192.168.1.5", Duration: 12 * time.Minute}},
+		Message:    "Reloaded 1024 channels",
+	}
+	for i := 1; i <= 50; i++ {
+		state.Log = append(state.Log, fmt.Sprintf("log line %d", i))
+	}
+
+	lines := renderDashboard(state, 80, 20)
+	if len(lines) != 20 {
+		t.Fatalf("renderDashboard() returned %d lines, want 20", len(lines))
+	}
+	out := strings.Join(lines, "\n")
+	for _, want := range []string{
+		"http://localhost:5001  up 1h30m",
+		"Login: logged in | Channels: 1024 | EPG: updated 2h0m ago",
+		"Active streams (1)",
+		"Aaj Tak (143)",
+		"12m",
+		"log line 50",
+		"[q] quit",
+		"Reloaded 1024 channels",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("renderDashboard() missing %q in\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "log line 40\n") {
+		t.Errorf("renderDashboard() shows more log lines than fit:\n%s", out)
+	}
+
+	narrow := renderDashboard(dashboard{Log: []string{strings.Repeat("x", 100)}}, 30, 10)
+	for _, line := range narrow {
+		if len([]rune(line)) > 30 {
+			t.Errorf("renderDashboard() line %q is wider than the terminal", line)
+		}
+	}
+	if empty := strings.Join(renderDashboard(dashboard{}, 80, 10), "\n"); !strings.Contains(empty, "not logged in | Channels: 0 | EPG: not generated") || !strings.Contains(empty, "  none") {
+		t.Errorf("renderDashboard() of an empty dashboard =\n%s", empty)
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{5 * time.Second, "5s"},
+		{12*time.Minute + 30*time.Second, "12m"},
+		{3*time.Hour + 20*time.Minute, "3h20m"},
+		{72 * time.Hour, "3d"},
+	}
+	for _, tt := range tests {
+		if got := formatAge(tt.d); got != tt.want {
+			t.Errorf("formatAge(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestAppendLogLine(t *testing.T) {
+	var tail []string
+	for i := 0; i < tuiLogLines+5; i++ {
+		tail = appendLogLine(tail, fmt.Sprint(i))
+	}
+	if len(tail) != tuiLogLines || tail[0] != "5" || tail[len(tail)-1] != fmt.Sprint(tuiLogLines+4) {
+		t.Errorf("appendLogLine() kept %d lines from %s to %s", len(tail), tail[0], tail[len(tail)-1])
+	}
+}
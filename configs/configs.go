@@ -0,0 +1,9 @@
+// Package configs holds the example config files of JioTV Go.
+package configs
+
+import _ "embed"
+
+// ExampleTOML is the example TOML config file, with a comment on every option.
+//
+//go:embed jiotv-config.toml
+var ExampleTOML []byte
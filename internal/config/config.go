@@ -1,10 +1,13 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"strings"
 
 	"github.com/ilyakaznacheev/cleanenv"
@@ -18,30 +21,114 @@ type JioTVConfig struct {
 	EPG bool `yaml:"epg" env:"JIOTV_EPG" json:"epg" toml:"epg"`
 	// External EPG URL to serve from /epg.xml.gz when local generation is unavailable.
 	EPGURL string `yaml:"epg_url" env:"JIOTV_EPG_URL" json:"epg_url" toml:"epg_url"`
+	// EPGChannelMapFile is the path to a JSON or YAML file mapping JioTV channel IDs to the channel IDs of the external EPG. Default: ""
+	EPGChannelMapFile string `yaml:"epg_channel_map_file" env:"JIOTV_EPG_CHANNEL_MAP_FILE" json:"epg_channel_map_file" toml:"epg_channel_map_file"`
+	// Update the existing EPG file with only the missing days instead of regenerating it. Default: false
+	EPGIncremental bool `yaml:"epg_incremental" env:"JIOTV_EPG_INCREMENTAL" json:"epg_incremental" toml:"epg_incremental"`
+	// EPGDaysPast is the number of days before today to include in the EPG, up to 7. Default: 0
+	EPGDaysPast int `yaml:"epg_days_past" env:"JIOTV_EPG_DAYS_PAST" json:"epg_days_past" toml:"epg_days_past"`
+	// EPGDaysFuture is the number of days after today to include in the EPG, up to 7. Default: 1
+	EPGDaysFuture int `yaml:"epg_days_future" env:"JIOTV_EPG_DAYS_FUTURE" json:"epg_days_future" toml:"epg_days_future"`
+	// EPGWorkers is the number of channels whose EPG is fetched concurrently. Default: 20
+	EPGWorkers int `yaml:"epg_workers" env:"JIOTV_EPG_WORKERS" json:"epg_workers" toml:"epg_workers"`
+	// EPGChannels limits the EPG to the given channel IDs. IDs prefixed with "-" are excluded instead. Default: []
+	EPGChannels []string `yaml:"epg_channels" env:"JIOTV_EPG_CHANNELS" json:"epg_channels" toml:"epg_channels"`
 	// Enable Or Disable Debug Mode. Default: false
 	Debug bool `yaml:"debug" env:"JIOTV_DEBUG" json:"debug" toml:"debug"`
 	// Enable Or Disable TS Handler. While TS Handler is enabled, the server will serve the TS files directly from JioTV API. Default: false
 	DisableTSHandler bool `yaml:"disable_ts_handler" env:"JIOTV_DISABLE_TS_HANDLER" json:"disable_ts_handler" toml:"disable_ts_handler"`
 	// Enable Or Disable Logout feature. Default: true
 	DisableLogout bool `yaml:"disable_logout" env:"JIOTV_DISABLE_LOGOUT" json:"disable_logout" toml:"disable_logout"`
+	// Enable Or Disable read-only guest mode. Guest mode only exposes playback and playlist endpoints and hides login, logout and admin APIs. Default: false
+	GuestMode bool `yaml:"guest_mode" env:"JIOTV_GUEST_MODE" json:"guest_mode" toml:"guest_mode"`
+	// AdminToken is the secret required by admin APIs like the log stream. Admin APIs are disabled while it is empty. Default: ""
+	AdminToken string `yaml:"admin_token" env:"JIOTV_ADMIN_TOKEN" json:"admin_token" toml:"admin_token"`
+	// WebPassword enables the web login. Admin actions like login and logout of Jio and the admin pages then need a session or the admin token. Default: ""
+	WebPassword string `yaml:"web_password" env:"JIOTV_WEB_PASSWORD" json:"web_password" toml:"web_password"`
+	// WebProtectPlayback also requires the web login for the pages, playlists and streams, not only for admin actions. Default: false
+	WebProtectPlayback bool `yaml:"web_protect_playback" env:"JIOTV_WEB_PROTECT_PLAYBACK" json:"web_protect_playback" toml:"web_protect_playback"`
 	// Enable Or Disable DRM. As DRM is not supported by most of the players, it is disabled by default. Default: false
 	DRM bool `yaml:"drm" env:"JIOTV_DRM" json:"drm" toml:"drm"`
 	// Title of the webpage. Default: JioTV Go
 	Title string `yaml:"title" env:"JIOTV_TITLE" json:"title" toml:"title"`
+	// LogoURL is an image shown before the title of the webpage instead of the TV icon. Default: ""
+	LogoURL string `yaml:"logo_url" env:"JIOTV_LOGO_URL" json:"logo_url" toml:"logo_url"`
+	// FaviconURL is the favicon of the webpage. Default: "/static/favicon.ico"
+	FaviconURL string `yaml:"favicon_url" env:"JIOTV_FAVICON_URL" json:"favicon_url" toml:"favicon_url"`
+	// Theme of the web interface: "auto" follows the system theme, "dark" or "light". The theme toggle of the web interface overrides it per browser. Default: "auto"
+	Theme string `yaml:"theme" env:"JIOTV_THEME" json:"theme" toml:"theme"`
+	// AccentColor is the primary color of the web interface as a hex color like "#e11d48". Default: "" (the color of the theme)
+	AccentColor string `yaml:"accent_color" env:"JIOTV_ACCENT_COLOR" json:"accent_color" toml:"accent_color"`
 	// Enable Or Disable URL Encryption. URL Encryption prevents hackers from injecting URLs into the server. Default: true
 	DisableURLEncryption bool `yaml:"disable_url_encryption" env:"JIOTV_DISABLE_URL_ENCRYPTION" json:"disable_url_encryption" toml:"disable_url_encryption"`
+	// URLKeyRotation is the number of hours after which the key of encrypted URLs is replaced. 0 keeps the key until restart. Default: 0
+	URLKeyRotation int `yaml:"url_key_rotation" env:"JIOTV_URL_KEY_ROTATION" json:"url_key_rotation" toml:"url_key_rotation"`
+	// URLKeyGrace is the number of hours URLs encrypted with a replaced key still work. Default: 24
+	URLKeyGrace int `yaml:"url_key_grace" env:"JIOTV_URL_KEY_GRACE" json:"url_key_grace" toml:"url_key_grace"`
+	// URLTTL is the number of hours after which encrypted stream URLs stop working, so shared links die. 0 keeps them working. Default: 0
+	URLTTL int `yaml:"url_ttl" env:"JIOTV_URL_TTL" json:"url_ttl" toml:"url_ttl"`
 	// Proxy URL. Proxy is useful to bypass geo-restrictions and ip-restrictions for JioTV API. Default: ""
 	Proxy string `yaml:"proxy" env:"JIOTV_PROXY" json:"proxy" toml:"proxy"`
+	// IPPreference selects the address family for upstream connections: "auto", "v4" or "v6". "auto" races IPv6 and IPv4 (happy eyeballs). Default: "auto"
+	IPPreference string `yaml:"ip_preference" env:"JIOTV_IP_PREFERENCE" json:"ip_preference" toml:"ip_preference"`
+	// Enable Or Disable the circuit breaker that pauses requests to the JioTV API after repeated failures. Default: false
+	DisableCircuitBreaker bool `yaml:"disable_circuit_breaker" env:"JIOTV_DISABLE_CIRCUIT_BREAKER" json:"disable_circuit_breaker" toml:"disable_circuit_breaker"`
+	// CircuitBreakerCooldown is how many seconds requests to a failing JioTV API endpoint are paused. Default: 30
+	CircuitBreakerCooldown int `yaml:"circuit_breaker_cooldown" env:"JIOTV_CIRCUIT_BREAKER_COOLDOWN" json:"circuit_breaker_cooldown" toml:"circuit_breaker_cooldown"`
+	// UpstreamRateLimit is the number of calls per minute to the JioTV playback and channels APIs. Further calls are served from cache or queued. 0 disables the limit. Default: 0
+	UpstreamRateLimit int `yaml:"upstream_rate_limit" env:"JIOTV_UPSTREAM_RATE_LIMIT" json:"upstream_rate_limit" toml:"upstream_rate_limit"`
+	// UpstreamRateBurst is the number of calls to the JioTV playback and channels APIs that may be made at once within UpstreamRateLimit. Default: 10
+	UpstreamRateBurst int `yaml:"upstream_rate_burst" env:"JIOTV_UPSTREAM_RATE_BURST" json:"upstream_rate_burst" toml:"upstream_rate_burst"`
+	// ChannelsCacheTTL is how many seconds the channel list is served from cache before it is refreshed in the background. A negative value disables the cache. Default: 300
+	ChannelsCacheTTL int `yaml:"channels_cache_ttl" env:"JIOTV_CHANNELS_CACHE_TTL" json:"channels_cache_ttl" toml:"channels_cache_ttl"`
+	// Enable Or Disable storing the cached channel list under the path prefix, so that it survives restarts. Default: false
+	ChannelsCacheOnDisk bool `yaml:"channels_cache_on_disk" env:"JIOTV_CHANNELS_CACHE_ON_DISK" json:"channels_cache_on_disk" toml:"channels_cache_on_disk"`
+	// Enable Or Disable starting from cached data when the JioTV API is unreachable. Implies ChannelsCacheOnDisk. Default: false
+	OfflineMode bool `yaml:"offline_mode" env:"JIOTV_OFFLINE_MODE" json:"offline_mode" toml:"offline_mode"`
+	// ProxyUsername is the username for proxy authentication. It is used when the proxy URL has no credentials. Default: ""
+	ProxyUsername string `yaml:"proxy_username" env:"JIOTV_PROXY_USERNAME" json:"proxy_username" toml:"proxy_username"`
+	// ProxyPassword is the password for proxy authentication. Default: ""
+	ProxyPassword string `yaml:"proxy_password" env:"JIOTV_PROXY_PASSWORD" json:"proxy_password" toml:"proxy_password"`
+	// ProxyRules routes requests for matching domains through a different proxy or directly. Default: []
+	ProxyRules JSONList[ProxyRule] `yaml:"proxy_rules" env:"JIOTV_PROXY_RULES" json:"proxy_rules" toml:"proxy_rules"`
+	// DNSRules resolves the hosts of matching domains with a DNS-over-HTTPS or DNS-over-TLS server instead of the system resolver. Default: []
+	DNSRules JSONList[DNSRule] `yaml:"dns_rules" env:"JIOTV_DNS_RULES" json:"dns_rules" toml:"dns_rules"`
+	// CABundle is a PEM file of CA certificates trusted for upstream requests in addition to the system roots. Default: ""
+	CABundle string `yaml:"ca_bundle" env:"JIOTV_CA_BUNDLE" json:"ca_bundle" toml:"ca_bundle"`
+	// TLSPins only accepts the certificates of matching domains whose chain has one of the pinned public keys. Default: []
+	TLSPins JSONList[TLSPin] `yaml:"tls_pins" env:"JIOTV_TLS_PINS" json:"tls_pins" toml:"tls_pins"`
+	// TLSFingerprint is the TLS ClientHello of upstream requests: "go" for the Go defaults or "android" for the cipher suites and curves of Android, matching the Android user agents sent to Jio. Default: "go"
+	TLSFingerprint string `yaml:"tls_fingerprint" env:"JIOTV_TLS_FINGERPRINT" json:"tls_fingerprint" toml:"tls_fingerprint"`
 	// PathPrefix is the prefix for all file paths managed by JioTV Go. Default: "$HOME/.jiotv_go"
 	PathPrefix string `yaml:"path_prefix" env:"JIOTV_PATH_PREFIX" json:"path_prefix" toml:"path_prefix"`
+	// StoreBackend is where the login, caches and other state under the path prefix are kept: "toml" for TOML and JSON files, "sqlite" for an SQLite database, "redis" for a Redis server shared by several JioTV Go servers. Default: "toml"
+	StoreBackend string `yaml:"store_backend" env:"JIOTV_STORE_BACKEND" json:"store_backend" toml:"store_backend"`
+	// RedisURL is the URL of the Redis server of the "redis" store backend, e.g. "redis://:password@localhost:6379/0". Default: ""
+	RedisURL string `yaml:"redis_url" env:"JIOTV_REDIS_URL" json:"redis_url" toml:"redis_url"`
+	// StoreEncryptionKey encrypts the login and tokens in the store when set. "file:<path>" reads the key from a file. Default: ""
+	StoreEncryptionKey string `yaml:"store_encryption_key" env:"JIOTV_STORE_ENCRYPTION_KEY" json:"store_encryption_key" toml:"store_encryption_key"`
 	// LogPath is the directory for log files. Default: ""
 	LogPath string `yaml:"log_path" env:"JIOTV_LOG_PATH" json:"log_path" toml:"log_path"`
 	// LogToStdout controls logging to stdout/stderr. Default: true
 	LogToStdout bool `yaml:"log_to_stdout" env:"JIOTV_LOG_TO_STDOUT" json:"log_to_stdout" toml:"log_to_stdout"`
 	// CustomChannelsURL is an optional remote JSON URL for custom channels.
 	CustomChannelsURL string `yaml:"custom_channels_url" env:"JIOTV_CUSTOM_CHANNELS_URL" json:"custom_channels_url" toml:"custom_channels_url"`
+	// M3USources is the list of M3U playlists whose channels are added to the custom channels file, each with its own mapping rules. Default: []
+	M3USources JSONList[M3USource] `yaml:"m3u_sources" env:"JIOTV_M3U_SOURCES" json:"m3u_sources" toml:"m3u_sources"`
+	// CustomChannelsCheckHours is how often, in hours, the stream URLs of custom channels are checked for dead links. 0 disables scheduled checks. Default: 0
+	CustomChannelsCheckHours int `yaml:"custom_channels_check_hours" env:"JIOTV_CUSTOM_CHANNELS_CHECK_HOURS" json:"custom_channels_check_hours" toml:"custom_channels_check_hours"`
+	// Enable Or Disable removing custom channels found dead by the last check from the channel list and playlists. Default: false
+	HideDeadChannels bool `yaml:"hide_dead_channels" env:"JIOTV_HIDE_DEAD_CHANNELS" json:"hide_dead_channels" toml:"hide_dead_channels"`
 	// CustomChannelsFile is the path to custom channels configuration file. Default: ""
 	CustomChannelsFile string `yaml:"custom_channels_file" env:"JIOTV_CUSTOM_CHANNELS_FILE" json:"custom_channels_file" toml:"custom_channels_file"`
+	// ChannelOverridesFile is the path to a JSON or YAML file that renames, changes or hides channels by channel ID. Default: ""
+	ChannelOverridesFile string `yaml:"channel_overrides_file" env:"JIOTV_CHANNEL_OVERRIDES_FILE" json:"channel_overrides_file" toml:"channel_overrides_file"`
+	// SonyDataFile is the path to a JSON file with the Sony channels, whose IDs start with "sl". Default: ""
+	SonyDataFile string `yaml:"sony_data_file" env:"JIOTV_SONY_DATA_FILE" json:"sony_data_file" toml:"sony_data_file"`
+	// SonyDataURL is the URL that the Sony data file is refreshed from every 6 hours. Default: ""
+	SonyDataURL string `yaml:"sony_data_url" env:"JIOTV_SONY_DATA_URL" json:"sony_data_url" toml:"sony_data_url"`
+	// ChannelNumbers is "auto" to number all channels in list order, otherwise only channels numbered in the custom channels file have a number. Default: ""
+	ChannelNumbers string `yaml:"channel_numbers" env:"JIOTV_CHANNEL_NUMBERS" json:"channel_numbers" toml:"channel_numbers"`
 	// Zee5DataURL is the URL to download Zee5 channels data dynamically. Default: "https://raw.githubusercontent.com/atanuroy22/zee5/refs/heads/main/data.json"
 	Zee5DataURL string `yaml:"zee5_data_url" env:"JIOTV_ZEE5_DATA_URL" json:"zee5_data_url" toml:"zee5_data_url"`
 	// Zee5DataFile is the path to Zee5 data configuration file. Default: "configs/zee5-data.json"
@@ -50,7 +137,196 @@ type JioTVConfig struct {
 	DefaultCategories []int `yaml:"default_categories" env:"JIOTV_DEFAULT_CATEGORIES" json:"default_categories" toml:"default_categories"`
 	// DefaultLanguages is the list of language IDs to display on the default web page. Default: []
 	DefaultLanguages []int `yaml:"default_languages" env:"JIOTV_DEFAULT_LANGUAGES" json:"default_languages" toml:"default_languages"`
-	Plugins          []string `yaml:"plugins" env:"JIOTV_PLUGINS" json:"plugins" toml:"plugins"`
+	// IndexPageSize is the number of channels on a page of the channel list on the index page. 0 shows all channels on one page. Default: 0
+	IndexPageSize int      `yaml:"index_page_size" env:"JIOTV_INDEX_PAGE_SIZE" json:"index_page_size" toml:"index_page_size"`
+	Plugins       []string `yaml:"plugins" env:"JIOTV_PLUGINS" json:"plugins" toml:"plugins"`
+	// FavoriteChannels is the list of channel IDs whose upcoming programme posters are pre-fetched overnight. Default: []
+	FavoriteChannels []string `yaml:"favorite_channels" env:"JIOTV_FAVORITE_CHANNELS" json:"favorite_channels" toml:"favorite_channels"`
+	// ChannelRules is the list of transformation rules applied to the merged channel list. Default: []
+	ChannelRules JSONList[ChannelRule] `yaml:"channel_rules" env:"JIOTV_CHANNEL_RULES" json:"channel_rules" toml:"channel_rules"`
+	// ManifestFilters is the list of filters applied to rewritten HLS manifests. Default: []
+	ManifestFilters JSONList[ManifestFilter] `yaml:"manifest_filters" env:"JIOTV_MANIFEST_FILTERS" json:"manifest_filters" toml:"manifest_filters"`
+	// Enable Or Disable serving all bitrates of the upstream master playlist on /live/:id, so that adaptive players can switch between them. Default: false
+	LiveABR bool `yaml:"live_abr" env:"JIOTV_LIVE_ABR" json:"live_abr" toml:"live_abr"`
+	// Enable Or Disable serving live channels in the other streaming format: HLS channels as DASH on /live/:id.mpd, and DASH channels without HLS on /live/:id.m3u8. Segments are not transcoded. Default: false
+	ManifestConversion bool `yaml:"manifest_conversion" env:"JIOTV_MANIFEST_CONVERSION" json:"manifest_conversion" toml:"manifest_conversion"`
+	// CatchupMaxQuality caps the quality of catchup streams: "low", "medium" or "high". Channel rules can override it per channel. Default: ""
+	CatchupMaxQuality string `yaml:"catchup_max_quality" env:"JIOTV_CATCHUP_MAX_QUALITY" json:"catchup_max_quality" toml:"catchup_max_quality"`
+	// TimeshiftMinutes is how many minutes of live TV are buffered per watched channel, so that the web player can pause and seek back. 0 disables timeshift. Default: 0
+	TimeshiftMinutes int `yaml:"timeshift_minutes" env:"JIOTV_TIMESHIFT_MINUTES" json:"timeshift_minutes" toml:"timeshift_minutes"`
+	// TimeshiftStorage is where timeshift buffers are kept: "memory" or "disk". "disk" uses the system temporary directory. Default: "memory"
+	TimeshiftStorage string `yaml:"timeshift_storage" env:"JIOTV_TIMESHIFT_STORAGE" json:"timeshift_storage" toml:"timeshift_storage"`
+	// PreviewInterval is how many seconds a preview frame of a channel is served before a new one is captured. 0 disables previews. Default: 0
+	PreviewInterval int `yaml:"preview_interval" env:"JIOTV_PREVIEW_INTERVAL" json:"preview_interval" toml:"preview_interval"`
+	// FFmpegPath is the ffmpeg executable used to capture preview frames. Default: "ffmpeg" from the PATH
+	FFmpegPath string `yaml:"ffmpeg_path" env:"JIOTV_FFMPEG_PATH" json:"ffmpeg_path" toml:"ffmpeg_path"`
+	// FFmpegHWAccel is the hardware acceleration ffmpeg decodes video with: "auto" detects one, "none" decodes in software, or a name of `ffmpeg -hwaccels` like "vaapi" or "cuda". Default: "auto"
+	FFmpegHWAccel string `yaml:"ffmpeg_hwaccel" env:"JIOTV_FFMPEG_HWACCEL" json:"ffmpeg_hwaccel" toml:"ffmpeg_hwaccel"`
+	// FFmpegHWAccelDevice is the device of the hardware acceleration, like "/dev/dri/renderD128" for VA-API or "0" for the first NVIDIA GPU. Default: "" (the default device)
+	FFmpegHWAccelDevice string `yaml:"ffmpeg_hwaccel_device" env:"JIOTV_FFMPEG_HWACCEL_DEVICE" json:"ffmpeg_hwaccel_device" toml:"ffmpeg_hwaccel_device"`
+	// LogoCache serves channel logos from a local cache on /logo/:id.png instead of linking to the JioTV CDN. Default: false
+	LogoCache bool `yaml:"logo_cache" env:"JIOTV_LOGO_CACHE" json:"logo_cache" toml:"logo_cache"`
+	// LogoSize is the width and height in pixels cached logos are resized to fit in. 0 keeps their size. Default: 0
+	LogoSize int `yaml:"logo_size" env:"JIOTV_LOGO_SIZE" json:"logo_size" toml:"logo_size"`
+	// MulticastOutputs is the list of channels sent as MPEG-TS streams over UDP or RTP, e.g. to set-top boxes on the local network. Default: []
+	MulticastOutputs JSONList[MulticastOutput] `yaml:"multicast_outputs" env:"JIOTV_MULTICAST_OUTPUTS" json:"multicast_outputs" toml:"multicast_outputs"`
+	// PreferredAudioLanguages is the list of audio languages selected by default when a stream has several audio tracks, most preferred first, e.g. ["hi", "en"]. Default: []
+	PreferredAudioLanguages []string `yaml:"preferred_audio_languages" env:"JIOTV_PREFERRED_AUDIO_LANGUAGES" json:"preferred_audio_languages" toml:"preferred_audio_languages"`
+	// Enable Or Disable selecting audio description tracks by default when a stream has them. Default: false
+	PreferAudioDescription bool `yaml:"prefer_audio_description" env:"JIOTV_PREFER_AUDIO_DESCRIPTION" json:"prefer_audio_description" toml:"prefer_audio_description"`
+	// Enable Or Disable selecting subtitles for the deaf and hard of hearing (SDH) by default when a stream has them. Default: false
+	PreferSDHSubtitles bool `yaml:"prefer_sdh_subtitles" env:"JIOTV_PREFER_SDH_SUBTITLES" json:"prefer_sdh_subtitles" toml:"prefer_sdh_subtitles"`
+	// Analytics is the analytics mode: "off" or "local". "local" counts feature usage on this machine only, nothing is sent anywhere. Default: "off"
+	Analytics string `yaml:"analytics" env:"JIOTV_ANALYTICS" json:"analytics" toml:"analytics"`
+	// Enable Or Disable aggregating the plays of channels with the IP address and user agent of the clients, for the stats dashboard. Default: false
+	AccessStats bool `yaml:"access_stats" env:"JIOTV_ACCESS_STATS" json:"access_stats" toml:"access_stats"`
+	// Enable Or Disable counting the bytes of streams proxied to each client and for each channel, month by month. Default: false
+	BandwidthAccounting bool `yaml:"bandwidth_accounting" env:"JIOTV_BANDWIDTH_ACCOUNTING" json:"bandwidth_accounting" toml:"bandwidth_accounting"`
+	// BandwidthCapGB is the number of gigabytes that can be streamed in a calendar month, after which streams are refused until the next month. 0 disables the cap. A cap enables bandwidth accounting. Default: 0
+	BandwidthCapGB float64 `yaml:"bandwidth_cap_gb" env:"JIOTV_BANDWIDTH_CAP_GB" json:"bandwidth_cap_gb" toml:"bandwidth_cap_gb"`
+	// MaxConcurrentStreams is the number of streams that can play at once, over all clients. Further streams are refused until one stops. 0 disables the limit. Default: 0
+	MaxConcurrentStreams int `yaml:"max_concurrent_streams" env:"JIOTV_MAX_CONCURRENT_STREAMS" json:"max_concurrent_streams" toml:"max_concurrent_streams"`
+	// StreamIdleTimeout is how many seconds a stream stays active after its last playlist or segment request. Converted streams of channels nobody watches are released after it. 0 uses the default. Default: 60
+	StreamIdleTimeout int `yaml:"stream_idle_timeout" env:"JIOTV_STREAM_IDLE_TIMEOUT" json:"stream_idle_timeout" toml:"stream_idle_timeout"`
+	// Tenants is the list of tenants, each with its own JioTV login, selected by hostname or path segment. Default: []
+	Tenants JSONList[Tenant] `yaml:"tenants" env:"JIOTV_TENANTS" json:"tenants" toml:"tenants"`
+	// Profiles is the list of viewer profiles, each with its own favorites, default filters and blocked categories. Default: []
+	Profiles JSONList[Profile] `yaml:"profiles" env:"JIOTV_PROFILES" json:"profiles" toml:"profiles"`
+}
+
+// JSONList is a list config option. Config files give it as a list of tables,
+// and environment variables as a JSON array, e.g. JIOTV_TENANTS='[{"name":"family"}]'.
+type JSONList[T any] []T
+
+// SetValue parses the JSON array of an environment variable. It implements cleanenv.Setter.
+func (l *JSONList[T]) SetValue(value string) error {
+	var list []T
+	if err := json.Unmarshal([]byte(value), &list); err != nil {
+		return fmt.Errorf("expected a JSON array: %w", err)
+	}
+	*l = list
+	return nil
+}
+
+// Tenant describes a logical JioTV Go instance with its own JioTV login and favorites.
+// Requests select a tenant by hostname, or by a "/t/<name>" path prefix.
+type Tenant struct {
+	// Name identifies the tenant in "/t/<name>" paths and names its data directory. Only letters, digits, "-" and "_" are allowed.
+	Name string `yaml:"name" json:"name" toml:"name"`
+	// Host selects the tenant for requests to this hostname, e.g. "family.example.com".
+	Host string `yaml:"host" json:"host" toml:"host"`
+	// FavoriteChannels replaces favorite_channels for the tenant.
+	FavoriteChannels []string `yaml:"favorite_channels" json:"favorite_channels" toml:"favorite_channels"`
+}
+
+// Profile describes a viewer of the channel list, like "kids" or "parents".
+// Profiles are picked in the web interface, or with the "profile" query param of the playlist.
+type Profile struct {
+	// Name identifies the profile in the web interface and in the "profile" query param.
+	Name string `yaml:"name" json:"name" toml:"name"`
+	// FavoriteChannels is the list of channel IDs shown as favorites of the profile.
+	FavoriteChannels []string `yaml:"favorite_channels" json:"favorite_channels" toml:"favorite_channels"`
+	// DefaultLanguages replaces default_languages for the profile.
+	DefaultLanguages []int `yaml:"default_languages" json:"default_languages" toml:"default_languages"`
+	// DefaultCategories replaces default_categories for the profile.
+	DefaultCategories []int `yaml:"default_categories" json:"default_categories" toml:"default_categories"`
+	// BlockedCategories is the list of category IDs whose channels are hidden from the profile and cannot be played.
+	BlockedCategories []int `yaml:"blocked_categories" json:"blocked_categories" toml:"blocked_categories"`
+}
+
+// ChannelRule describes a declarative transformation applied to matching channels.
+// All non-empty match patterns are regular expressions and must match for the rule to apply.
+// Rules are applied in order, so later rules override earlier ones.
+type ChannelRule struct {
+	// MatchName is matched against the channel name.
+	MatchName string `yaml:"match_name" json:"match_name" toml:"match_name"`
+	// MatchCategory is matched against the channel category name, e.g. "Sports".
+	MatchCategory string `yaml:"match_category" json:"match_category" toml:"match_category"`
+	// MatchProvider is matched against the channel provider: "jiotv", "custom" or "zee5".
+	MatchProvider string `yaml:"match_provider" json:"match_provider" toml:"match_provider"`
+	// SetCategory overrides the category ID of matching channels.
+	SetCategory int `yaml:"set_category" json:"set_category" toml:"set_category"`
+	// SetLanguage overrides the language ID of matching channels.
+	SetLanguage int `yaml:"set_language" json:"set_language" toml:"set_language"`
+	// SetGroup overrides the playlist group title of matching channels.
+	SetGroup string `yaml:"set_group" json:"set_group" toml:"set_group"`
+	// Hide removes matching channels from the web page and playlists.
+	Hide bool `yaml:"hide" json:"hide" toml:"hide"`
+	// MaxQuality caps the stream quality of matching channels: "low", "medium" or "high".
+	MaxQuality string `yaml:"max_quality" json:"max_quality" toml:"max_quality"`
+	// MaxCatchupQuality caps the catchup quality of matching channels: "low", "medium" or "high". It overrides CatchupMaxQuality.
+	MaxCatchupQuality string `yaml:"max_catchup_quality" json:"max_catchup_quality" toml:"max_catchup_quality"`
+}
+
+// ManifestFilter describes a filter applied to rewritten HLS manifests.
+// Filters are applied in order.
+type ManifestFilter struct {
+	// Name is the filter to apply: "drop_iframes", "strip_daterange", "drop_tag" or "start_offset".
+	Name string `yaml:"name" json:"name" toml:"name"`
+	// Value is the filter argument, e.g. the tag for "drop_tag" or the offset in seconds for "start_offset".
+	Value string `yaml:"value" json:"value" toml:"value"`
+	// Channels limits the filter to the given channel IDs. All channels are filtered if empty.
+	Channels []string `yaml:"channels" json:"channels" toml:"channels"`
+}
+
+// ProxyRule routes requests for a domain through a specific proxy.
+// Rules are checked in order and the first matching rule wins.
+// Requests that match no rule use the default proxy.
+type ProxyRule struct {
+	// Domain matches the request host and all of its subdomains, e.g. "jio.com".
+	Domain string `yaml:"domain" json:"domain" toml:"domain"`
+	// Proxy is the proxy URL for matching requests. Use "direct" to bypass the proxy.
+	Proxy string `yaml:"proxy" json:"proxy" toml:"proxy"`
+}
+
+// DNSRule resolves the hosts of a domain with a specific DNS server.
+// Rules are checked in order and the first matching rule wins.
+// Hosts that match no rule are resolved by the system resolver.
+type DNSRule struct {
+	// Domain matches the request host and all of its subdomains, e.g. "jio.com". "*" matches every host.
+	Domain string `yaml:"domain" json:"domain" toml:"domain"`
+	// Resolver is a DNS-over-HTTPS URL, e.g. "https://1.1.1.1/dns-query", or a DNS-over-TLS server, e.g. "tls://1.1.1.1".
+	Resolver string `yaml:"resolver" json:"resolver" toml:"resolver"`
+}
+
+// TLSPin pins the certificates of a domain to public keys.
+// Rules are checked in order and the first matching rule wins.
+type TLSPin struct {
+	// Domain matches the request host and all of its subdomains, e.g. "jio.com".
+	Domain string `yaml:"domain" json:"domain" toml:"domain"`
+	// Pins are the base64 SHA-256 hashes of the public keys (SubjectPublicKeyInfo) of a leaf, intermediate or root certificate, optionally prefixed with "sha256/".
+	Pins []string `yaml:"pins" json:"pins" toml:"pins"`
+}
+
+// M3USource is an M3U playlist whose channels are added to the custom channels file.
+// Only channels with HTTPS stream URLs are added.
+type M3USource struct {
+	// URL is the URL of the M3U playlist.
+	URL string `yaml:"url" json:"url" toml:"url"`
+	// Prefix is put before the IDs of the channels, so that the channels of several sources do not collide, e.g. "iptv_".
+	Prefix string `yaml:"prefix" json:"prefix" toml:"prefix"`
+	// Categories maps group titles of the playlist to category IDs, e.g. {"Cricket" = 8}. Titles are matched ignoring case.
+	Categories map[string]int `yaml:"categories" json:"categories" toml:"categories"`
+	// Languages maps tvg-language values of the playlist to language IDs, e.g. {"Hin" = 1}. Values are matched ignoring case.
+	Languages map[string]int `yaml:"languages" json:"languages" toml:"languages"`
+	// Category is the category ID of channels whose group title is not mapped. Default: 0
+	Category int `yaml:"category" json:"category" toml:"category"`
+	// Language is the language ID of channels without a tvg-language. Default: 0
+	Language int `yaml:"language" json:"language" toml:"language"`
+}
+
+// MulticastOutput sends a live channel as an MPEG-TS stream to a UDP or RTP address for as long as JioTV Go runs.
+type MulticastOutput struct {
+	// Channel is the ID of the JioTV channel to send.
+	Channel string `yaml:"channel" json:"channel" toml:"channel"`
+	// Address is where the stream is sent, e.g. "udp://239.255.0.1:5000" or "rtp://239.255.0.1:5000". Unicast addresses work too.
+	Address string `yaml:"address" json:"address" toml:"address"`
+	// Quality is the quality of the stream: "low", "medium", "high" or "auto". Default: "auto"
+	Quality string `yaml:"quality" json:"quality" toml:"quality"`
+	// Interface is the network interface multicast packets are sent from, e.g. "eth0". Default: the interface of the default route
+	Interface string `yaml:"interface" json:"interface" toml:"interface"`
+	// TTL is how many routers multicast packets may pass. Default: 1, i.e. the local network only
+	TTL int `yaml:"ttl" json:"ttl" toml:"ttl"`
 }
 
 // Cfg is the global config variable
@@ -66,16 +342,23 @@ func (c *JioTVConfig) Load(filename string) error {
 	}
 	if filename == "" {
 		log.Println("INFO: No config file found, using environment variables")
+		logLintIssues(LintEnv(os.Environ()))
 		if err := cleanenv.ReadEnv(c); err != nil {
 			return err
 		}
 		c.applyDefaults()
-		return nil
+		return c.validateChannelRules()
 	}
 	log.Println("INFO: Using config file:", filename)
 	if err := cleanenv.ReadConfig(filename, c); err != nil {
 		return err
 	}
+	if issues, err := Lint(filename); err != nil {
+		log.Println("WARN: Could not check config file for unknown keys:", err)
+	} else {
+		logLintIssues(issues)
+	}
+	logLintIssues(LintEnv(os.Environ()))
 	rawCustomChannels := strings.TrimSpace(c.CustomChannelsFile)
 	if rawCustomChannels != "" {
 		log.Println("INFO: Custom channels file (raw):", rawCustomChannels)
@@ -104,6 +387,23 @@ func (c *JioTVConfig) Load(filename string) error {
 	if strings.TrimSpace(c.Zee5DataFile) == "" {
 		c.Zee5DataFile = filepath.Join("configs", "zee5-data.json")
 	}
+	return c.validateChannelRules()
+}
+
+// validateChannelRules reports the first invalid match pattern of the channel rules.
+func (c *JioTVConfig) validateChannelRules() error {
+	for i, rule := range c.ChannelRules {
+		patterns := []struct{ key, pattern string }{
+			{"match_name", rule.MatchName},
+			{"match_category", rule.MatchCategory},
+			{"match_provider", rule.MatchProvider},
+		}
+		for _, p := range patterns {
+			if _, err := regexp.Compile(p.pattern); err != nil {
+				return fmt.Errorf("channel_rules[%d].%s: invalid pattern: %w", i, p.key, err)
+			}
+		}
+	}
 	return nil
 }
 
@@ -195,6 +495,33 @@ func (c *JioTVConfig) normalizePaths(configFilePath string) {
 			}
 		}
 	}
+
+	// Normalize EPGChannelMapFile, relative to the config file if not found
+	rawEPGMap := strings.TrimSpace(c.EPGChannelMapFile)
+	if rawEPGMap != "" && !filepath.IsAbs(rawEPGMap) && !fileExists(rawEPGMap) {
+		candidate := filepath.Join(filepath.Dir(configFilePath), filepath.Clean(filepath.FromSlash(rawEPGMap)))
+		if fileExists(candidate) {
+			c.EPGChannelMapFile = candidate
+		}
+	}
+
+	// Normalize ChannelOverridesFile, relative to the config file if not found
+	rawOverrides := strings.TrimSpace(c.ChannelOverridesFile)
+	if rawOverrides != "" && !filepath.IsAbs(rawOverrides) && !fileExists(rawOverrides) {
+		candidate := filepath.Join(filepath.Dir(configFilePath), filepath.Clean(filepath.FromSlash(rawOverrides)))
+		if fileExists(candidate) {
+			c.ChannelOverridesFile = candidate
+		}
+	}
+
+	// Normalize SonyDataFile, relative to the config file if not found
+	rawSony := strings.TrimSpace(c.SonyDataFile)
+	if rawSony != "" && !filepath.IsAbs(rawSony) && !fileExists(rawSony) {
+		candidate := filepath.Join(filepath.Dir(configFilePath), filepath.Clean(filepath.FromSlash(rawSony)))
+		if fileExists(candidate) {
+			c.SonyDataFile = candidate
+		}
+	}
 }
 
 func fileExists(path string) bool {
@@ -226,6 +553,28 @@ func PluginEnabled(name string) bool {
 	return false
 }
 
+// AllFavoriteChannels returns the favorite channels of the config and of all tenants and profiles, without duplicates.
+func AllFavoriteChannels() []string {
+	seen := make(map[string]bool)
+	var channels []string
+	add := func(ids []string) {
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				channels = append(channels, id)
+			}
+		}
+	}
+	add(Cfg.FavoriteChannels)
+	for _, tenant := range Cfg.Tenants {
+		add(tenant.FavoriteChannels)
+	}
+	for _, profile := range Cfg.Profiles {
+		add(profile.FavoriteChannels)
+	}
+	return channels
+}
+
 // commonFileExists checks for the existence of common config
 // file names and returns the first one found. It searches
 // for config files in the following formats:
@@ -141,6 +141,73 @@ func TestJioTVConfig_Load_EnvOnly_SetsDefaultCustomChannelsFile(t *testing.T) {
 	}
 }
 
+func TestJioTVConfig_EveryFieldHasEnv(t *testing.T) {
+	configType := reflect.TypeOf(JioTVConfig{})
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		if field.Tag.Get("env") == "" {
+			t.Errorf("config field %s cannot be set with an environment variable", field.Name)
+		}
+	}
+}
+
+func TestJioTVConfig_Load_EnvOnly_JSONLists(t *testing.T) {
+	t.Setenv("JIOTV_CHANNEL_RULES", `[{"match_category": "Sports", "set_group": "Sports"}, {"match_name": "Shopping", "hide": true}]`)
+	t.Setenv("JIOTV_TENANTS", `[{"name": "family", "host": "family.example.com"}]`)
+
+	var cfg JioTVConfig
+	if err := cfg.Load(""); err != nil {
+		t.Fatalf("failed to load env-only config: %v", err)
+	}
+	wantRules := []ChannelRule{{MatchCategory: "Sports", SetGroup: "Sports"}, {MatchName: "Shopping", Hide: true}}
+	if !reflect.DeepEqual([]ChannelRule(cfg.ChannelRules), wantRules) {
+		t.Errorf("ChannelRules = %+v, want %+v", cfg.ChannelRules, wantRules)
+	}
+	if len(cfg.Tenants) != 1 || cfg.Tenants[0].Name != "family" || cfg.Tenants[0].Host != "family.example.com" {
+		t.Errorf("Tenants = %+v", cfg.Tenants)
+	}
+
+	t.Setenv("JIOTV_CHANNEL_RULES", `[{"match_name": "News"}, {"match_category": "(Sports"}]`)
+	if err := (&JioTVConfig{}).Load(""); err == nil || !strings.Contains(err.Error(), "channel_rules[1].match_category") {
+		t.Errorf("Load() with an invalid rule pattern error = %v, want the key of the pattern", err)
+	}
+	t.Setenv("JIOTV_CHANNEL_RULES", "")
+
+	t.Setenv("JIOTV_PROXY_RULES", `{"domain": "jio.com"}`)
+	if err := (&JioTVConfig{}).Load(""); err == nil {
+		t.Error("Load() with a JSON object instead of an array succeeded")
+	}
+}
+
+func TestJioTVConfig_Load_M3USources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jiotv_go.toml")
+	content := `
+[[m3u_sources]]
+url = "https://example.com/a.m3u"
+prefix = "a_"
+categories = { "Cricket" = 8, "Kids Zone" = 7 }
+languages = { "Hin" = 1 }
+language = 6
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var cfg JioTVConfig
+	if err := cfg.Load(path); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []M3USource{{
+		URL:        "https://example.com/a.m3u",
+		Prefix:     "a_",
+		Categories: map[string]int{"Cricket": 8, "Kids Zone": 7},
+		Languages:  map[string]int{"Hin": 1},
+		Language:   6,
+	}}
+	if !reflect.DeepEqual([]M3USource(cfg.M3USources), want) {
+		t.Errorf("M3USources = %+v, want %+v", cfg.M3USources, want)
+	}
+}
+
 func TestJioTVConfig_Get(t *testing.T) {
 	// Set the global Cfg for Get to work as intended
 	Cfg = JioTVConfig{
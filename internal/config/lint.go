@@ -0,0 +1,202 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix is the prefix of the environment variables read by JioTV Go
+const envPrefix = "JIOTV_"
+
+// deprecatedKeys maps config keys that are no longer read to their replacement.
+// Add an entry here whenever a key is renamed, so users get told how to migrate.
+var deprecatedKeys = map[string]string{}
+
+// LintIssue is a problem found in a config file or in the environment
+type LintIssue struct {
+	// Key is the config key or environment variable, e.g. "channel_rules[0].match_nam"
+	Key string
+	// Message describes the problem and how to fix it
+	Message string
+}
+
+// String returns the issue in a form suitable for logging.
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s: %s", i.Key, i.Message)
+}
+
+// logLintIssues logs each issue as a warning.
+func logLintIssues(issues []LintIssue) {
+	for _, issue := range issues {
+		log.Println("WARN: Config:", issue)
+	}
+}
+
+// Lint reports unknown, deprecated and misspelled keys in a config file.
+// Files with an unsupported extension are not checked.
+func Lint(filename string) ([]LintIssue, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	var tag string
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		tag, err = "json", json.Unmarshal(content, &raw)
+	case ".yml", ".yaml":
+		tag, err = "yaml", yaml.Unmarshal(content, &raw)
+	case ".toml":
+		tag, err = "toml", toml.Unmarshal(content, &raw)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lintKeys(raw, reflect.TypeOf(JioTVConfig{}), tag, ""), nil
+}
+
+// lintKeys checks the keys of a decoded config table against the fields of t.
+// Arrays of tables, like channel_rules, are checked against their element type.
+func lintKeys(raw map[string]interface{}, t reflect.Type, tag, prefix string) []LintIssue {
+	fields := make(map[string]reflect.Type)
+	known := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get(tag), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = t.Field(i).Type
+		known = append(known, name)
+	}
+
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var issues []LintIssue
+	for _, key := range keys {
+		fieldType, ok := fields[key]
+		if !ok {
+			message := "unknown key, it is ignored"
+			if replacement, deprecated := deprecatedKeys[key]; deprecated && prefix == "" {
+				message = fmt.Sprintf("deprecated key, it is ignored. Use %q instead", replacement)
+			} else if suggestion := suggestKey(key, known); suggestion != "" {
+				message = fmt.Sprintf("unknown key, it is ignored. Did you mean %q?", suggestion)
+			}
+			issues = append(issues, LintIssue{Key: prefix + key, Message: message})
+			continue
+		}
+
+		// Check the tables in arrays of structs
+		if fieldType.Kind() != reflect.Slice || fieldType.Elem().Kind() != reflect.Struct {
+			continue
+		}
+		for i, table := range tablesOf(raw[key]) {
+			issues = append(issues, lintKeys(table, fieldType.Elem(), tag, fmt.Sprintf("%s%s[%d].", prefix, key, i))...)
+		}
+	}
+	return issues
+}
+
+// tablesOf returns the tables in a decoded array, as decoded by any of the supported formats.
+func tablesOf(value interface{}) []map[string]interface{} {
+	switch v := value.(type) {
+	case []map[string]interface{}:
+		return v
+	case []interface{}:
+		tables := make([]map[string]interface{}, 0, len(v))
+		for _, item := range v {
+			if table, ok := item.(map[string]interface{}); ok {
+				tables = append(tables, table)
+			}
+		}
+		return tables
+	}
+	return nil
+}
+
+// LintEnv reports environment variables with the JioTV Go prefix that are not read by JioTV Go,
+// typically because of a typo. environ is in the form returned by os.Environ.
+func LintEnv(environ []string) []LintIssue {
+	t := reflect.TypeOf(JioTVConfig{})
+	known := make([]string, 0, t.NumField())
+	knownSet := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		if name := t.Field(i).Tag.Get("env"); name != "" {
+			known = append(known, name)
+			knownSet[name] = true
+		}
+	}
+
+	var issues []LintIssue
+	for _, entry := range environ {
+		name, _, _ := strings.Cut(entry, "=")
+		if !strings.HasPrefix(name, envPrefix) || knownSet[name] {
+			continue
+		}
+		message := "unknown environment variable, it is ignored"
+		if suggestion := suggestKey(name, known); suggestion != "" {
+			message = fmt.Sprintf("unknown environment variable, it is ignored. Did you mean %s?", suggestion)
+		}
+		issues = append(issues, LintIssue{Key: name, Message: message})
+	}
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Key < issues[j].Key })
+	return issues
+}
+
+// suggestKey returns the known key closest to key, or "" if none is close enough to be a typo.
+func suggestKey(key string, known []string) string {
+	normalized := normalizeKey(key)
+	best, bestDistance := "", -1
+	for _, candidate := range known {
+		distance := levenshtein(normalized, normalizeKey(candidate))
+		if bestDistance == -1 || distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	// Allow about one typo per four characters, and at least two
+	if bestDistance == -1 || bestDistance > max(2, len(normalized)/4) {
+		return ""
+	}
+	return best
+}
+
+// normalizeKey ignores case and the difference between "-" and "_".
+func normalizeKey(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "-", "_")
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
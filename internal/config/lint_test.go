@@ -0,0 +1,107 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	deprecatedKeys["old_title"] = "title"
+	defer delete(deprecatedKeys, "old_title")
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []LintIssue
+	}{
+		{
+			name:    "valid yaml",
+			file:    "config.yml",
+			content: "epg: true\ntitle: Test\nchannel_rules:\n  - match_name: Sony\n",
+			want:    nil,
+		},
+		{
+			name:    "typo in toml",
+			file:    "config.toml",
+			content: "custom_channel_file = \"custom.json\"\n",
+			want:    []LintIssue{{Key: "custom_channel_file", Message: `unknown key, it is ignored. Did you mean "custom_channels_file"?`}},
+		},
+		{
+			name:    "typo in nested json table",
+			file:    "config.json",
+			content: `{"channel_rules": [{"match_name": "Sony"}, {"match_nam": "Star"}]}`,
+			want:    []LintIssue{{Key: "channel_rules[1].match_nam", Message: `unknown key, it is ignored. Did you mean "match_name"?`}},
+		},
+		{
+			name:    "unknown key without suggestion",
+			file:    "config.yml",
+			content: "completely_unrelated: 1\n",
+			want:    []LintIssue{{Key: "completely_unrelated", Message: "unknown key, it is ignored"}},
+		},
+		{
+			name:    "deprecated key",
+			file:    "config.yml",
+			content: "old_title: Test\n",
+			want:    []LintIssue{{Key: "old_title", Message: `deprecated key, it is ignored. Use "title" instead`}},
+		},
+		{
+			name:    "unsupported extension",
+			file:    "config.env",
+			content: "whatever",
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(filename, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+			got, err := Lint(filename)
+			if err != nil {
+				t.Fatalf("Lint() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintEnv(t *testing.T) {
+	environ := []string{
+		"PATH=/usr/bin",
+		"JIOTV_EPG=true",
+		"JIOTV_CUSTOM_CHANNEL_FILE=custom.json",
+		"JIOTV_SOMETHING_ELSE_ENTIRELY=1",
+	}
+	want := []LintIssue{
+		{Key: "JIOTV_CUSTOM_CHANNEL_FILE", Message: "unknown environment variable, it is ignored. Did you mean JIOTV_CUSTOM_CHANNELS_FILE?"},
+		{Key: "JIOTV_SOMETHING_ELSE_ENTIRELY", Message: "unknown environment variable, it is ignored"},
+	}
+	if got := LintEnv(environ); !reflect.DeepEqual(got, want) {
+		t.Errorf("LintEnv() = %v, want %v", got, want)
+	}
+}
+
+func TestSuggestKey(t *testing.T) {
+	known := []string{"epg", "debug", "custom_channels_file", "path_prefix"}
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"custom-channels-file", "custom_channels_file"},
+		{"Debug", "debug"},
+		{"pathprefix", "path_prefix"},
+		{"title", ""},
+	}
+	for _, tt := range tests {
+		if got := suggestKey(tt.key, known); got != tt.want {
+			t.Errorf("suggestKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
@@ -13,4 +13,7 @@ const (
 
 	// Limits and thresholds
 	MaxRecommendedChannels = 5000
+
+	// TenantBaseLocal is the fiber local holding the path prefix of the tenant of a request, e.g. "/t/family"
+	TenantBaseLocal = "tenant_base"
 )
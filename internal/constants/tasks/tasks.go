@@ -8,5 +8,9 @@ const (
 	HealthCheckTaskID     = "jiotv_token_health_check"
 
 	// EPG-related tasks
-	EPGTaskID = "jiotv_epg"
+	EPGTaskID        = "jiotv_epg"
+	EPGArtworkTaskID = "jiotv_epg_artwork"
+
+	// Maintenance tasks
+	JanitorTaskID = "jiotv_janitor"
 )
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/accessstats"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/stats"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+// errAccessStatsDisabled is the error of the stats routes while access_stats is disabled
+const errAccessStatsDisabled = "Access stats are disabled. Set access_stats in the config to enable them."
+
+// recordPlay records that the client of the request started playing a channel. The address and user
+// agent of the client are only recorded while access stats are enabled. The values are kept after
+// the request, so they are copied out of the request buffers, which fiber reuses.
+func recordPlay(c *fiber.Ctx, channelID string) {
+	var client stats.Client
+	if accessstats.Enabled() {
+		client = stats.Client{IP: strings.Clone(c.IP()), UserAgent: strings.Clone(c.Get(fiber.HeaderUserAgent))}
+	}
+	stats.RecordClientPlay(strings.Clone(channelID), sessionID(c), client)
+}
+
+// statsBar is a bar of a chart on the stats dashboard
+type statsBar struct {
+	Label   string
+	Plays   int64
+	Percent int
+}
+
+// statsBars returns the bars of a chart, sized relative to the largest value.
+func statsBars(labels []string, plays []int64) []statsBar {
+	var largest int64
+	for _, value := range plays {
+		largest = max(largest, value)
+	}
+	bars := make([]statsBar, len(plays))
+	for i, value := range plays {
+		bars[i] = statsBar{Label: labels[i], Plays: value}
+		if largest > 0 {
+			bars[i].Percent = int(value * 100 / largest)
+		}
+	}
+	return bars
+}
+
+// statsChannelName returns the name of a channel for the stats dashboard, or its ID if it is unknown.
+func statsChannelName(id string) string {
+	if channel, ok := television.ChannelByID(id); ok && channel.Name != "" {
+		return channel.Name
+	}
+	return id
+}
+
+// AccessStatsHandler returns the aggregated plays of channels and clients on `/api/v1/stats`.
+func AccessStatsHandler(c *fiber.Ctx) error {
+	if ok, err := checkAdminToken(c); !ok {
+		return err
+	}
+	if !accessstats.Enabled() {
+		return internalUtils.NotFoundError(c, errAccessStatsDisabled)
+	}
+	return c.JSON(stats.Default.Usage())
+}
+
+// ResetAccessStatsHandler clears the aggregated plays on `DELETE /api/v1/stats`.
+func ResetAccessStatsHandler(c *fiber.Ctx) error {
+	if ok, err := checkAdminToken(c); !ok {
+		return err
+	}
+	if !accessstats.Enabled() {
+		return internalUtils.NotFoundError(c, errAccessStatsDisabled)
+	}
+	stats.Default.ResetPlays()
+	if err := accessstats.Save(); err != nil {
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// AccessStatsDashboardHandler renders the stats dashboard on `/admin/stats`.
+func AccessStatsDashboardHandler(c *fiber.Ctx) error {
+	if ok, err := checkAdminToken(c); !ok {
+		return err
+	}
+	if !accessstats.Enabled() {
+		return c.Render("views/access_stats", fiber.Map{"Title": Title})
+	}
+	usage := stats.Default.Usage()
+
+	hourLabels := make([]string, len(usage.Hours))
+	for hour := range usage.Hours {
+		hourLabels[hour] = time.Date(0, 1, 1, hour, 0, 0, 0, time.Local).Format("15:04")
+	}
+	dayLabels := make([]string, len(usage.Days))
+	dayPlays := make([]int64, len(usage.Days))
+	for i, day := range usage.Days {
+		dayLabels[i], dayPlays[i] = day.Day, day.Plays
+	}
+	names := make(map[string]string, len(usage.Channels))
+	for _, channel := range usage.Channels {
+		names[channel.ChannelID] = statsChannelName(channel.ChannelID)
+	}
+
+	return c.Render("views/access_stats", fiber.Map{
+		"Title":        Title,
+		"Enabled":      true,
+		"Usage":        usage,
+		"ChannelNames": names,
+		"Hours":        statsBars(hourLabels, usage.Hours[:]),
+		"Days":         statsBars(dayLabels, dayPlays),
+	})
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/accessstats"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/stats"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+	"github.com/jiotv-go/jiotv_go/v3/web"
+)
+
+func TestStatsBars(t *testing.T) {
+	got := statsBars([]string{"a", "b", "c"}, []int64{0, 5, 20})
+	want := []statsBar{{Label: "a", Plays: 0, Percent: 0}, {Label: "b", Plays: 5, Percent: 25}, {Label: "c", Plays: 20, Percent: 100}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("statsBars() = %+v, want %+v", got, want)
+	}
+	if got := statsBars([]string{"a"}, []int64{0}); got[0].Percent != 0 {
+		t.Errorf("statsBars() without plays = %+v, want empty bars", got)
+	}
+}
+
+func TestAccessStatsHandler(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, originalStats := config.Cfg, stats.Default
+	t.Cleanup(func() {
+		config.Cfg, stats.Default = original, originalStats
+		accessstats.Init()
+		cleanup()
+	})
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	config.Cfg.AdminToken = "admin"
+
+	app := fiber.New()
+	app.Get("/api/v1/stats", AccessStatsHandler)
+	app.Delete("/api/v1/stats", ResetAccessStatsHandler)
+	app.Get("/play/:id", func(c *fiber.Ctx) error {
+		recordPlay(c, c.Params("id"))
+		return nil
+	})
+	request := func(method, path string) *http.Response {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(fiber.HeaderUserAgent, "VLC/3.0")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s %s error = %v", method, path, err)
+		}
+		return resp
+	}
+
+	stats.Default = stats.NewRecorder()
+	config.Cfg.AccessStats = false
+	accessstats.Init()
+	request(http.MethodGet, "/play/143?sid=phone-1234")
+	if usage := stats.Default.Usage(); usage.Plays != 1 || len(usage.Clients) != 0 {
+		t.Errorf("usage while disabled = %+v, want the play without its client", usage)
+	}
+	if resp := request(http.MethodGet, "/api/v1/stats?token=admin"); resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("disabled status = %d, want 404", resp.StatusCode)
+	}
+
+	config.Cfg.AccessStats = true
+	accessstats.Init()
+	stats.Default.ResetPlays()
+	request(http.MethodGet, "/play/143?sid=phone-1234")
+
+	if resp := request(http.MethodGet, "/api/v1/stats"); resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("status without the admin token = %d, want 401", resp.StatusCode)
+	}
+	resp := request(http.MethodGet, "/api/v1/stats?token=admin")
+	var usage stats.Usage
+	if err := json.NewDecoder(resp.Body).Decode(&usage); err != nil {
+		t.Fatalf("decoding the stats: %v", err)
+	}
+	// A play is recorded once, by the playback session with its client
+	if usage.Plays != 1 || len(usage.Clients) != 1 || usage.Clients[0].Viewer != "phone-1234" || usage.Clients[0].UserAgent != "VLC/3.0" {
+		t.Errorf("stats = %+v, want the recorded play", usage)
+	}
+
+	// The dashboard is not compiled into headless builds
+	if web.Included {
+		views := fiber.New(fiber.Config{Views: web.Views(false)})
+		views.Get("/admin/stats", AccessStatsDashboardHandler)
+		resp, err := views.Test(httptest.NewRequest(http.MethodGet, "/admin/stats?token=admin", nil), -1)
+		if err != nil {
+			t.Fatalf("GET /admin/stats error = %v", err)
+		}
+		if body, _ := io.ReadAll(resp.Body); resp.StatusCode != fiber.StatusOK || !strings.Contains(string(body), "VLC/3.0") {
+			t.Errorf("dashboard status = %d, want 200 with the client", resp.StatusCode)
+		}
+	}
+
+	if resp := request(http.MethodDelete, "/api/v1/stats?token=admin"); resp.StatusCode != fiber.StatusNoContent {
+		t.Errorf("reset status = %d, want 204", resp.StatusCode)
+	}
+	if plays := stats.Default.Usage().Plays; plays != 0 {
+		t.Errorf("plays after the reset = %d, want 0", plays)
+	}
+}
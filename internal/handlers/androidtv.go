@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/plugins"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/stats"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+const (
+	// androidTVWatchNextLimit is the number of channels in the Watch Next row
+	androidTVWatchNextLimit = 10
+	// androidTVWatchNextWindow is how far back plays are considered for the Watch Next row
+	androidTVWatchNextWindow = 24 * time.Hour
+)
+
+// androidTVProgramme is a programme shown on an Android TV launcher card
+type androidTVProgramme struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Poster      string `json:"poster,omitempty"`
+	StartTime   int64  `json:"start_time"`
+	EndTime     int64  `json:"end_time"`
+}
+
+// androidTVItem is a channel card of an Android TV launcher row
+type androidTVItem struct {
+	ChannelID string              `json:"channel_id"`
+	Title     string              `json:"title"`
+	Logo      string              `json:"logo"`
+	DeepLink  string              `json:"deep_link"`
+	StreamURL string              `json:"stream_url"`
+	Now       *androidTVProgramme `json:"now,omitempty"`
+	Next      *androidTVProgramme `json:"next,omitempty"`
+}
+
+// androidTVRow is a row of channel cards on an Android TV launcher
+type androidTVRow struct {
+	ID    string          `json:"id"`
+	Title string          `json:"title"`
+	Items []androidTVItem `json:"items"`
+}
+
+// toAndroidTVProgramme converts a guide programme to a launcher programme.
+func toAndroidTVProgramme(programme *epg.EPGObject, hostURL string) *androidTVProgramme {
+	if programme == nil {
+		return nil
+	}
+	result := &androidTVProgramme{
+		Title:       programme.Title,
+		Description: programme.Description,
+		StartTime:   programme.StartEpoch,
+		EndTime:     programme.EndEpoch,
+	}
+	if programme.Poster != "" {
+		result.Poster = hostURL + "/jtvposter/" + programme.Poster
+	}
+	return result
+}
+
+// androidTVItems builds launcher cards for the given channel IDs in order.
+// Unknown channels are skipped. Now/next data is looked up concurrently.
+func androidTVItems(channelIDs []string, channels map[string]television.Channel, hostURL string) []androidTVItem {
+	items := make([]androidTVItem, 0, len(channelIDs))
+	for _, id := range channelIDs {
+		channel, ok := channels[id]
+		if !ok {
+			continue
+		}
+		items = append(items, androidTVItem{
+			ChannelID: id,
+			Title:     channel.Name,
+			Logo:      channelLogoURL(hostURL, channel),
+			DeepLink:  hostURL + "/play/" + id,
+			StreamURL: hostURL + signLiveLink("/live/"+id+".m3u8"),
+		})
+	}
+
+	now := time.Now()
+	var wg sync.WaitGroup
+	for i := range items {
+		if isCustomChannel(items[i].ChannelID) || isZee5Channel(items[i].ChannelID) {
+			continue
+		}
+		wg.Add(1)
+		go func(item *androidTVItem) {
+			defer wg.Done()
+			current, next, err := epg.NowNext(item.ChannelID, now)
+			if err != nil {
+				return
+			}
+			item.Now = toAndroidTVProgramme(current, hostURL)
+			item.Next = toAndroidTVProgramme(next, hostURL)
+		}(&items[i])
+	}
+	wg.Wait()
+	return items
+}
+
+// AndroidTVRowsHandler returns channel rows for an Android TV launcher on `/api/v1/androidtv/rows`.
+// The Favorites row lists the configured favorite channels and the Watch Next row the most
+// watched channels of the last day, each with the programme airing now and next.
+func AndroidTVRowsHandler(c *fiber.Ctx) error {
+	apiResponse, err := television.Channels()
+	if err != nil {
+		return ErrorMessageHandler(c, err)
+	}
+	if len(config.Cfg.Plugins) > 0 {
+		apiResponse.Result = append(apiResponse.Result, plugins.GetChannels()...)
+	}
+	apiResponse.Result = television.ApplyChannelRules(apiResponse.Result, config.Cfg.ChannelRules)
+
+	channels := make(map[string]television.Channel, len(apiResponse.Result))
+	for _, channel := range apiResponse.Result {
+		channels[channel.ID] = channel
+	}
+
+	hostURL := requestHostURL(c)
+
+	now := time.Now()
+	var watchNext []string
+	for _, channel := range stats.Default.TopChannels(now.Add(-androidTVWatchNextWindow), now, androidTVWatchNextLimit) {
+		watchNext = append(watchNext, channel.ChannelID)
+	}
+
+	rows := []androidTVRow{
+		{ID: "favorites", Title: "Favorites", Items: androidTVItems(tenantOf(c).favoriteChannels(), channels, hostURL)},
+		{ID: "watch_next", Title: "Watch Next", Items: androidTVItems(watchNext, channels, hostURL)},
+	}
+	return c.JSON(fiber.Map{"rows": rows})
+}
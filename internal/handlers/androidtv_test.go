@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+func TestAndroidTVItems(t *testing.T) {
+	channels := map[string]television.Channel{
+		"custom_news": {ID: "custom_news", Name: "News", LogoURL: "https://example.com/news.png"},
+		"custom_film": {ID: "custom_film", Name: "Films", LogoURL: "film.png"},
+	}
+
+	items := androidTVItems([]string{"custom_film", "missing", "custom_news"}, channels, "http://localhost:5001")
+	if len(items) != 2 {
+		t.Fatalf("androidTVItems() returned %d items, want 2", len(items))
+	}
+
+	want := []androidTVItem{
+		{
+			ChannelID: "custom_film",
+			Title:     "Films",
+			Logo:      "http://localhost:5001/jtvimage/film.png",
+			DeepLink:  "http://localhost:5001/play/custom_film",
+			StreamURL: "http://localhost:5001/live/custom_film.m3u8",
+		},
+		{
+			ChannelID: "custom_news",
+			Title:     "News",
+			Logo:      "https://example.com/news.png",
+			DeepLink:  "http://localhost:5001/play/custom_news",
+			StreamURL: "http://localhost:5001/live/custom_news.m3u8",
+		},
+	}
+	for i := range want {
+		if items[i] != want[i] {
+			t.Errorf("items[%d] = %+v, want %+v", i, items[i], want[i])
+		}
+	}
+}
+
+func TestToAndroidTVProgramme(t *testing.T) {
+	if got := toAndroidTVProgramme(nil, "http://localhost:5001"); got != nil {
+		t.Errorf("toAndroidTVProgramme(nil) = %+v, want nil", got)
+	}
+
+	got := toAndroidTVProgramme(&epg.EPGObject{
+		Title:      "Show",
+		Poster:     "2024-01-01/show.jpg",
+		StartEpoch: 1,
+		EndEpoch:   2,
+	}, "http://localhost:5001")
+	if got.Poster != "http://localhost:5001/jtvposter/2024-01-01/show.jpg" {
+		t.Errorf("Poster = %q", got.Poster)
+	}
+	if got.Title != "Show" || got.StartTime != 1 || got.EndTime != 2 {
+		t.Errorf("toAndroidTVProgramme() = %+v", got)
+	}
+}
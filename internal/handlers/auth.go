@@ -4,23 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
-	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/headers"
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/urls"
 	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
-	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 	"github.com/valyala/fasthttp"
 )
 
-var (
-	// tokenRefreshMutex prevents concurrent token refreshes
-	tokenRefreshMutex sync.Mutex
-)
-
 // IsAccessTokenExpired checks if the AccessToken needs refreshing
 // Returns true if the token is expired or will expire within the next 10 minutes
 func IsAccessTokenExpired(credentials *utils.JIOTV_CREDENTIALS) bool {
@@ -58,10 +51,15 @@ func IsSSOTokenExpired(credentials *utils.JIOTV_CREDENTIALS) bool {
 // EnsureFreshTokens checks and refreshes tokens if needed
 // This is the main function that should be called before making API requests
 func EnsureFreshTokens() error {
-	tokenRefreshMutex.Lock()
-	defer tokenRefreshMutex.Unlock()
+	return defaultTenant.ensureFreshTokens()
+}
+
+// ensureFreshTokens checks and refreshes the tokens of the tenant if needed
+func (t *tenant) ensureFreshTokens() error {
+	t.tokenRefreshMutex.Lock()
+	defer t.tokenRefreshMutex.Unlock()
 
-	credentials, err := utils.GetJIOTVCredentials()
+	credentials, err := t.account.Credentials()
 	if err != nil {
 		return fmt.Errorf("failed to get credentials: %v", err)
 	}
@@ -75,7 +73,7 @@ func EnsureFreshTokens() error {
 	if credentials.AccessToken != "" && credentials.RefreshToken != "" {
 		if IsAccessTokenExpired(credentials) {
 			utils.Log.Println("AccessToken is expired, refreshing...")
-			err := LoginRefreshAccessToken()
+			err := t.refreshAccessToken()
 			if err != nil {
 				utils.Log.Printf("AccessToken refresh failed: %v", err)
 				return err
@@ -88,7 +86,7 @@ func EnsureFreshTokens() error {
 	if credentials.SSOToken != "" && credentials.UniqueID != "" {
 		if IsSSOTokenExpired(credentials) {
 			utils.Log.Println("SSOToken is expired, refreshing...")
-			err := LoginRefreshSSOToken()
+			err := t.refreshSSOToken()
 			if err != nil {
 				utils.Log.Printf("SSOToken refresh failed: %v", err)
 				return err
@@ -99,11 +97,11 @@ func EnsureFreshTokens() error {
 
 	if refreshed {
 		// Update the TV object with fresh credentials
-		freshCreds, err := utils.GetJIOTVCredentials()
+		freshCreds, err := t.account.Credentials()
 		if err != nil {
 			return fmt.Errorf("failed to get fresh credentials: %v", err)
 		}
-		TV = television.New(freshCreds)
+		t.setTV(freshCreds)
 	}
 
 	return nil
@@ -151,32 +149,48 @@ func LoginVerifyOTPHandler(c *fiber.Ctx) error {
 		return err
 	}
 
-	result, err := utils.LoginVerifyOTP(mobileNumber, otp)
+	t := tenantOf(c)
+	result, err := t.account.LoginVerifyOTP(mobileNumber, otp)
 	if err != nil {
 		utils.Log.Println(err)
 		return internalUtils.InternalServerError(c, "Internal server error")
 	}
-	Init()
+	t.reload()
 	return c.JSON(result)
 }
 
 // LogoutHandler is used to logout
 func LogoutHandler(c *fiber.Ctx) error {
 	if !isLogoutDisabled {
-		err := utils.Logout()
+		t := tenantOf(c)
+		err := t.account.Logout()
 		if err != nil {
 			utils.Log.Println(err)
 			return internalUtils.InternalServerError(c, "Internal server error")
 		}
-		Init()
+		t.reload()
 	}
-	return c.Redirect("/", fiber.StatusFound)
+	return c.Redirect(tenantBase(c)+"/", fiber.StatusFound)
+}
+
+// Logout logs the default account out, like `/logout` does.
+func Logout() error {
+	if err := defaultTenant.account.Logout(); err != nil {
+		return err
+	}
+	defaultTenant.reload()
+	return nil
 }
 
 // LoginRefreshAccessToken Function is used to refresh AccessToken
 func LoginRefreshAccessToken() error {
+	return defaultTenant.refreshAccessToken()
+}
+
+// refreshAccessToken refreshes the AccessToken of the tenant
+func (t *tenant) refreshAccessToken() error {
 	utils.Log.Println("Refreshing AccessToken...")
-	tokenData, err := utils.GetJIOTVCredentials()
+	tokenData, err := t.account.Credentials()
 	if err != nil {
 		utils.Log.Printf("Error getting credentials for AccessToken refresh: %v", err)
 		return err
@@ -192,7 +206,7 @@ func LoginRefreshAccessToken() error {
 	// Prepare the request body
 	requestBody := map[string]string{
 		"appName":      "RJIL_JioTV",
-		"deviceId":     utils.GetDeviceID(),
+		"deviceId":     t.account.DeviceID(),
 		"refreshToken": tokenData.RefreshToken,
 	}
 
@@ -245,12 +259,12 @@ func LoginRefreshAccessToken() error {
 	if response.AccessToken != "" {
 		tokenData.AccessToken = response.AccessToken
 		tokenData.LastTokenRefreshTime = strconv.FormatInt(time.Now().Unix(), 10)
-		err := utils.WriteJIOTVCredentials(tokenData)
+		err := t.account.WriteCredentials(tokenData)
 		if err != nil {
 			utils.Log.Printf("Error saving refreshed credentials: %v", err)
 			return err
 		}
-		TV = television.New(tokenData)
+		t.setTV(tokenData)
 		utils.Log.Println("AccessToken refreshed successfully")
 		return nil
 	} else {
@@ -262,8 +276,13 @@ func LoginRefreshAccessToken() error {
 
 // LoginRefreshSSOToken Function is used to refresh SSOToken
 func LoginRefreshSSOToken() error {
+	return defaultTenant.refreshSSOToken()
+}
+
+// refreshSSOToken refreshes the SSOToken of the tenant
+func (t *tenant) refreshSSOToken() error {
 	utils.Log.Println("Refreshing SsoToken...")
-	tokenData, err := utils.GetJIOTVCredentials()
+	tokenData, err := t.account.Credentials()
 	if err != nil {
 		utils.Log.Printf("Error getting credentials for SSOToken refresh: %v", err)
 		return err
@@ -281,7 +300,7 @@ func LoginRefreshSSOToken() error {
 		return err
 	}
 
-	deviceID := utils.GetDeviceID()
+	deviceID := t.account.DeviceID()
 	if deviceID == "" {
 		err := fmt.Errorf("DeviceID is empty, cannot refresh SSOToken")
 		utils.Log.Printf("Error: %v", err)
@@ -331,12 +350,12 @@ func LoginRefreshSSOToken() error {
 	if response.SSOToken != "" {
 		tokenData.SSOToken = response.SSOToken
 		tokenData.LastSSOTokenRefreshTime = strconv.FormatInt(time.Now().Unix(), 10)
-		err := utils.WriteJIOTVCredentials(tokenData)
+		err := t.account.WriteCredentials(tokenData)
 		if err != nil {
 			utils.Log.Printf("Error saving refreshed SSOToken credentials: %v", err)
 			return err
 		}
-		TV = television.New(tokenData)
+		t.setTV(tokenData)
 		utils.Log.Println("SSOToken refreshed successfully")
 		return nil
 	} else {
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/bandwidth"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/stats"
+	"github.com/jiotv-go/jiotv_go/v3/web"
+)
+
+// bandwidthCapPrefixes lists the route prefixes of streams, which are refused once the monthly cap is reached.
+// Playlists, the EPG and the web pages other than the players stay reachable.
+var bandwidthCapPrefixes = []string{
+	"/live",
+	"/render.m3u8",
+	"/render.ts",
+	"/render.key",
+	"/render.mpd",
+	"/render.dash",
+	"/play",
+	"/player",
+	"/catchup",
+	"/startover",
+	"/timeshift",
+	"/dash",
+	"/hls",
+	"/mpd",
+	"/drm",
+	"/out",
+	"/zee5",
+}
+
+// bandwidthEntry is the usage of a client or channel on `/api/v1/bandwidth`
+type bandwidthEntry struct {
+	IP        string `json:"ip,omitempty"`
+	ChannelID string `json:"channel_id,omitempty"`
+	Bytes     int64  `json:"bytes"`
+}
+
+// bandwidthResponse is the response of `/api/v1/bandwidth`
+type bandwidthResponse struct {
+	Month    string           `json:"month"`
+	Bytes    int64            `json:"bytes"`
+	Cap      int64            `json:"cap"`
+	Exceeded bool             `json:"exceeded"`
+	Months   []string         `json:"months"`
+	Clients  []bandwidthEntry `json:"clients"`
+	Channels []bandwidthEntry `json:"channels"`
+}
+
+// responseBytes returns the size of the response body, or 0 if it is streamed without a length.
+func responseBytes(c *fiber.Ctx) int64 {
+	if c.Method() == fiber.MethodHead {
+		return 0
+	}
+	if !c.Response().IsBodyStream() {
+		return int64(len(c.Response().Body()))
+	}
+	if length := c.Response().Header.ContentLength(); length > 0 {
+		return int64(length)
+	}
+	return 0
+}
+
+// meterStream counts the response body of a proxied segment for the client and the channel.
+// The channel is the one the playback session of the client played last if it is not known.
+func meterStream(c *fiber.Ctx, channelID string) {
+	bytes := responseBytes(c)
+	if bytes == 0 {
+		return
+	}
+	stats.RecordBytes(bytes)
+	if !bandwidth.Enabled() {
+		return
+	}
+	if channelID == "" {
+		channelID = stats.Default.ActiveChannel(sessionID(c))
+	}
+	bandwidth.Add(c.IP(), channelID, bytes)
+}
+
+// isBandwidthCapPath reports whether the path streams a channel.
+func isBandwidthCapPath(path string) bool {
+	path = strings.ToLower(path)
+	for _, prefix := range bandwidthCapPrefixes {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// BandwidthCapHandler refuses streams once the monthly bandwidth cap is reached. Browsers get a
+// page that explains why, players get `429 Too Many Requests`.
+func BandwidthCapHandler(c *fiber.Ctx) error {
+	if !isBandwidthCapPath(c.Path()) || !bandwidth.Exceeded() {
+		return c.Next()
+	}
+	now := time.Now()
+	resets := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())
+	message := fmt.Sprintf("The monthly data cap of %s is reached. Streams are available again on %s.",
+		formatBytes(bandwidth.Cap()), resets.Format("2 January"))
+	if web.Included && c.Method() == fiber.MethodGet && strings.Contains(c.Get(fiber.HeaderAccept), fiber.MIMETextHTML) {
+		return c.Status(fiber.StatusTooManyRequests).Render("views/quota_exceeded", fiber.Map{
+			"Title":   Title,
+			"Message": message,
+			"Used":    formatBytes(bandwidth.Default.CurrentBytes()),
+			"Cap":     formatBytes(bandwidth.Cap()),
+		})
+	}
+	return internalUtils.ErrorResponse(c, fiber.StatusTooManyRequests, message)
+}
+
+// formatBytes returns a byte count in GB or MB, like ISPs count them.
+func formatBytes(bytes int64) string {
+	if bytes >= 1e9 {
+		return fmt.Sprintf("%.1f GB", float64(bytes)/1e9)
+	}
+	return fmt.Sprintf("%.1f MB", float64(bytes)/1e6)
+}
+
+// bandwidthEntries returns the entries of a usage map, the largest first.
+func bandwidthEntries(usage map[string]int64, entry func(key string, bytes int64) bandwidthEntry) []bandwidthEntry {
+	keys := make([]string, 0, len(usage))
+	for key := range usage {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if usage[keys[i]] != usage[keys[j]] {
+			return usage[keys[i]] > usage[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	entries := make([]bandwidthEntry, 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, entry(key, usage[key]))
+	}
+	return entries
+}
+
+// BandwidthHandler returns the bytes streamed in a month by client and by channel on `/api/v1/bandwidth`.
+// The `month` query param selects the month as YYYY-MM, the current month by default.
+func BandwidthHandler(c *fiber.Ctx) error {
+	if ok, err := checkAdminToken(c); !ok {
+		return err
+	}
+	if !bandwidth.Enabled() {
+		return internalUtils.NotFoundError(c, "Bandwidth accounting is disabled. Set bandwidth_accounting or bandwidth_cap_gb in the config to enable it.")
+	}
+	usage := bandwidth.Default.Current()
+	if month := c.Query("month"); month != "" {
+		if _, err := time.Parse(bandwidth.MonthFormat, month); err != nil {
+			return internalUtils.BadRequestError(c, "month must be like 2006-01")
+		}
+		usage = bandwidth.Default.Month(month)
+	}
+	return c.JSON(bandwidthResponse{
+		Month:    usage.Month,
+		Bytes:    usage.Bytes,
+		Cap:      bandwidth.Cap(),
+		Exceeded: bandwidth.Exceeded(),
+		Months:   bandwidth.Default.Months(),
+		Clients: bandwidthEntries(usage.Clients, func(ip string, bytes int64) bandwidthEntry {
+			return bandwidthEntry{IP: ip, Bytes: bytes}
+		}),
+		Channels: bandwidthEntries(usage.Channels, func(id string, bytes int64) bandwidthEntry {
+			return bandwidthEntry{ChannelID: id, Bytes: bytes}
+		}),
+	})
+}
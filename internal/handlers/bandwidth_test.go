@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/bandwidth"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+	"github.com/jiotv-go/jiotv_go/v3/web"
+)
+
+func setupBandwidth(t *testing.T, accounting bool, capGB float64) {
+	t.Helper()
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, originalMeter := config.Cfg, bandwidth.Default
+	t.Cleanup(func() {
+		config.Cfg, bandwidth.Default = original, originalMeter
+		bandwidth.Init()
+		cleanup()
+	})
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	config.Cfg.AdminToken = "admin"
+	config.Cfg.BandwidthAccounting = accounting
+	config.Cfg.BandwidthCapGB = capGB
+	bandwidth.Default = bandwidth.NewMeter()
+	bandwidth.Init()
+}
+
+func TestIsBandwidthCapPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/live/143.m3u8", true},
+		{"/live/high/143.m3u8", true},
+		{"/render.ts", true},
+		{"/render.dash/host/abc/path/def/seg.m4s", true},
+		{"/play/143", true},
+		{"/player/143", true},
+		{"/catchup/stream/143", true},
+		{"/playlist.m3u", false},
+		{"/channels", false},
+		{"/epg.xml.gz", false},
+		{"/", false},
+		{"/api/v1/bandwidth", false},
+	}
+	for _, tt := range tests {
+		if got := isBandwidthCapPath(tt.path); got != tt.want {
+			t.Errorf("isBandwidthCapPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMeterStream(t *testing.T) {
+	setupBandwidth(t, true, 0)
+
+	app := fiber.New()
+	app.Get("/render.ts", func(c *fiber.Ctx) error {
+		c.Response().SetBodyString(strings.Repeat("x", 1500))
+		meterStream(c, c.Query("channel_key_id"))
+		return nil
+	})
+	if _, err := app.Test(httptest.NewRequest(http.MethodGet, "/render.ts?channel_key_id=143", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.Test(httptest.NewRequest(http.MethodHead, "/render.ts?channel_key_id=143", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	usage := bandwidth.Default.Current()
+	if usage.Bytes != 1500 || usage.Channels["143"] != 1500 || len(usage.Clients) != 1 {
+		t.Errorf("usage = %+v, want 1500 bytes of channel 143 for one client", usage)
+	}
+}
+
+func TestBandwidthCapHandler(t *testing.T) {
+	setupBandwidth(t, false, 1)
+
+	app := fiber.New(fiber.Config{Views: web.Views(false)})
+	app.Use(BandwidthCapHandler)
+	app.Get("/*", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	request := func(path, accept string) *http.Response {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if accept != "" {
+			req.Header.Set(fiber.HeaderAccept, accept)
+		}
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", path, err)
+		}
+		return resp
+	}
+
+	if resp := request("/live/143.m3u8", ""); resp.StatusCode != fiber.StatusOK {
+		t.Errorf("status under the cap = %d, want 200", resp.StatusCode)
+	}
+
+	bandwidth.Add("10.0.0.1", "143", 1e9)
+	if resp := request("/live/143.m3u8", ""); resp.StatusCode != fiber.StatusTooManyRequests {
+		t.Errorf("status over the cap = %d, want 429", resp.StatusCode)
+	}
+	if resp := request("/playlist.m3u", ""); resp.StatusCode != fiber.StatusOK {
+		t.Errorf("playlist status over the cap = %d, want 200", resp.StatusCode)
+	}
+	if !web.Included {
+		return
+	}
+	resp := request("/play/143", "text/html,application/xhtml+xml")
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusTooManyRequests || !strings.Contains(string(body), "Monthly data cap reached") {
+		t.Errorf("browser status over the cap = %d, want 429 with the quota page", resp.StatusCode)
+	}
+}
+
+func TestBandwidthHandler(t *testing.T) {
+	setupBandwidth(t, false, 0)
+
+	app := fiber.New()
+	app.Get("/api/v1/bandwidth", BandwidthHandler)
+	request := func(path string) *http.Response {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		if err != nil {
+			t.Fatalf("GET %s error = %v", path, err)
+		}
+		return resp
+	}
+
+	if resp := request("/api/v1/bandwidth?token=admin"); resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("disabled status = %d, want 404", resp.StatusCode)
+	}
+
+	config.Cfg.BandwidthAccounting = true
+	bandwidth.Init()
+	bandwidth.Add("10.0.0.1", "143", 300)
+	bandwidth.Add("10.0.0.2", "144", 700)
+
+	if resp := request("/api/v1/bandwidth"); resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("status without the admin token = %d, want 401", resp.StatusCode)
+	}
+	if resp := request("/api/v1/bandwidth?token=admin&month=october"); resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("status with an invalid month = %d, want 400", resp.StatusCode)
+	}
+
+	var result bandwidthResponse
+	if err := json.NewDecoder(request("/api/v1/bandwidth?token=admin").Body).Decode(&result); err != nil {
+		t.Fatalf("decoding the usage: %v", err)
+	}
+	if result.Bytes != 1000 || len(result.Clients) != 2 || result.Clients[0].IP != "10.0.0.2" || result.Channels[0].ChannelID != "144" {
+		t.Errorf("usage = %+v, want 1000 bytes with 10.0.0.2 and 144 first", result)
+	}
+
+	if err := json.NewDecoder(request("/api/v1/bandwidth?token=admin&month=2020-01").Body).Decode(&result); err != nil {
+		t.Fatalf("decoding the usage: %v", err)
+	}
+	if result.Month != "2020-01" || result.Bytes != 0 || len(result.Clients) != 0 {
+		t.Errorf("usage of 2020-01 = %+v, want none", result)
+	}
+}
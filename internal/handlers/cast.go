@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/cast"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// castDiscoveryTimeout is how long a search for cast devices waits for answers
+	castDiscoveryTimeout = 3 * time.Second
+	// castDevicesTTL is how long found cast devices are remembered before searching again
+	castDevicesTTL = time.Minute
+)
+
+// Cast device control, replaced in tests
+var (
+	discoverCastDevices = cast.Discover
+	castPlay            = cast.Play
+	castStop            = cast.Stop
+	castSetVolume       = cast.SetVolume
+)
+
+var (
+	castDevicesMu sync.Mutex
+	castDevices   []cast.Device
+	castFound     time.Time
+)
+
+// castRequest is the body of the cast control APIs
+type castRequest struct {
+	Device    string `json:"device"`
+	ChannelID string `json:"channel_id"`
+	Quality   string `json:"quality"`
+	Level     *int   `json:"level"`
+}
+
+// findCastDevices returns the cast devices on the network, searching again when refresh is set or the last search is old.
+func findCastDevices(refresh bool) ([]cast.Device, error) {
+	castDevicesMu.Lock()
+	defer castDevicesMu.Unlock()
+	if !refresh && castDevices != nil && time.Since(castFound) < castDevicesTTL {
+		return castDevices, nil
+	}
+	devices, err := discoverCastDevices(castDiscoveryTimeout)
+	if err != nil {
+		return nil, err
+	}
+	castDevices, castFound = devices, time.Now()
+	return devices, nil
+}
+
+// parseCastRequest reads the body of a cast control request and finds its device among the devices
+// found by the last search. It returns false after responding with an error.
+func parseCastRequest(c *fiber.Ctx, req *castRequest) (cast.Device, bool, error) {
+	if err := c.BodyParser(req); err != nil {
+		return cast.Device{}, false, internalUtils.BadRequestError(c, "Invalid request: "+err.Error())
+	}
+	if req.Device == "" {
+		return cast.Device{}, false, internalUtils.BadRequestError(c, "device is required")
+	}
+	devices, err := findCastDevices(false)
+	if err != nil {
+		return cast.Device{}, false, internalUtils.InternalServerError(c, "Failed to search for cast devices: "+err.Error())
+	}
+	for _, d := range devices {
+		if d.ID == req.Device {
+			return d, true, nil
+		}
+	}
+	return cast.Device{}, false, internalUtils.NotFoundError(c, "Cast device not found, search for devices again")
+}
+
+// isLoopbackURL reports whether the host of a URL is only reachable from this machine.
+func isLoopbackURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsUnspecified())
+}
+
+// CastDevicesHandler lists the Chromecasts and DLNA renderers on the local network on `GET /api/cast/devices`.
+// Found devices are remembered for a minute, `?refresh=1` searches again.
+func CastDevicesHandler(c *fiber.Ctx) error {
+	devices, err := findCastDevices(c.QueryBool("refresh"))
+	if err != nil {
+		utils.Log.Printf("WARN: Failed to search for cast devices: %v", err)
+		return internalUtils.InternalServerError(c, "Failed to search for cast devices: "+err.Error())
+	}
+	return c.JSON(fiber.Map{"devices": devices})
+}
+
+// CastPlayHandler plays a channel on a cast device on `POST /api/cast/play`.
+// The device fetches the HLS stream from the address this server was opened with.
+func CastPlayHandler(c *fiber.Ctx) error {
+	var req castRequest
+	d, ok, err := parseCastRequest(c, &req)
+	if !ok {
+		return err
+	}
+	id := strings.TrimSpace(req.ChannelID)
+	if id == "" {
+		return internalUtils.BadRequestError(c, "channel_id is required")
+	}
+	streamURL := requestHostURL(c) + signLiveLink(utils.BuildHLSPlayURL(url.PathEscape(strings.TrimSpace(req.Quality)), url.PathEscape(id)))
+	if isLoopbackURL(streamURL) {
+		return internalUtils.BadRequestError(c, "Cast devices cannot reach "+c.Hostname()+", open JioTV Go with its LAN address to cast")
+	}
+
+	title := id
+	if channel, ok := television.ChannelByID(id); ok {
+		title = channel.Name
+	}
+	if err := castPlay(d, streamURL, title); err != nil {
+		utils.Log.Printf("WARN: Failed to cast channel %s to %s: %v", id, d.Name, err)
+		return internalUtils.UpstreamError(c, err)
+	}
+	return c.JSON(fiber.Map{"device": d, "url": streamURL})
+}
+
+// CastStopHandler stops playback on a cast device on `POST /api/cast/stop`.
+func CastStopHandler(c *fiber.Ctx) error {
+	var req castRequest
+	d, ok, err := parseCastRequest(c, &req)
+	if !ok {
+		return err
+	}
+	if err := castStop(d); err != nil {
+		utils.Log.Printf("WARN: Failed to stop casting to %s: %v", d.Name, err)
+		return internalUtils.UpstreamError(c, err)
+	}
+	return c.JSON(fiber.Map{"device": d})
+}
+
+// CastVolumeHandler sets the volume of a cast device, from 0 to 100, on `POST /api/cast/volume`.
+func CastVolumeHandler(c *fiber.Ctx) error {
+	var req castRequest
+	d, ok, err := parseCastRequest(c, &req)
+	if !ok {
+		return err
+	}
+	if req.Level == nil || *req.Level < 0 || *req.Level > 100 {
+		return internalUtils.BadRequestError(c, "level must be from 0 to 100")
+	}
+	if err := castSetVolume(d, *req.Level); err != nil {
+		if err == cast.ErrNoVolume {
+			return internalUtils.BadRequestError(c, err.Error())
+		}
+		utils.Log.Printf("WARN: Failed to set the volume of %s: %v", d.Name, err)
+		return internalUtils.UpstreamError(c, err)
+	}
+	return c.JSON(fiber.Map{"device": d, "level": *req.Level})
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/cast"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+func TestCastHandlers(t *testing.T) {
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	searches := 0
+	played := ""
+	volume := -1
+	originalDiscover, originalPlay, originalSetVolume := discoverCastDevices, castPlay, castSetVolume
+	defer func() {
+		discoverCastDevices, castPlay, castSetVolume = originalDiscover, originalPlay, originalSetVolume
+		castDevices = nil
+	}()
+	discoverCastDevices = func(time.Duration) ([]cast.Device, error) {
+		searches++
+		return []cast.Device{{ID: "chromecast-1", Name: "Living Room", Kind: cast.KindChromecast}}, nil
+	}
+	castPlay = func(d cast.Device, streamURL, title string) error {
+		played = streamURL
+		return nil
+	}
+	castSetVolume = func(d cast.Device, level int) error {
+		volume = level
+		return errors.New("connection refused")
+	}
+	castDevices = nil
+
+	app := fiber.New()
+	app.Get("/api/cast/devices", CastDevicesHandler)
+	app.Post("/api/cast/play", CastPlayHandler)
+	app.Post("/api/cast/volume", CastVolumeHandler)
+
+	do := func(method, path, host, body string) (int, string) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Host = host
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s %s error = %v", method, path, err)
+		}
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+
+	status, body := do("GET", "/api/cast/devices", "192.168.1.2:5001", "")
+	var devices struct {
+		Devices []cast.Device `json:"devices"`
+	}
+	json.Unmarshal([]byte(body), &devices)
+	if status != fiber.StatusOK || len(devices.Devices) != 1 || devices.Devices[0].ID != "chromecast-1" {
+		t.Fatalf("GET /api/cast/devices = %d %s", status, body)
+	}
+	do("GET", "/api/cast/devices", "192.168.1.2:5001", "")
+	if searches != 1 {
+		t.Errorf("devices were searched %d times, want the second request to use the found devices", searches)
+	}
+	do("GET", "/api/cast/devices?refresh=1", "192.168.1.2:5001", "")
+	if searches != 2 {
+		t.Errorf("devices were searched %d times, want refresh to search again", searches)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		host       string
+		body       string
+		wantStatus int
+	}{
+		{"play", "/api/cast/play", "192.168.1.2:5001", `{"device": "chromecast-1", "channel_id": "143", "quality": "high"}`, fiber.StatusOK},
+		{"play from localhost", "/api/cast/play", "localhost:5001", `{"device": "chromecast-1", "channel_id": "143"}`, fiber.StatusBadRequest},
+		{"play from loopback", "/api/cast/play", "127.0.0.1:5001", `{"device": "chromecast-1", "channel_id": "143"}`, fiber.StatusBadRequest},
+		{"unknown device", "/api/cast/play", "192.168.1.2:5001", `{"device": "dlna-2", "channel_id": "143"}`, fiber.StatusNotFound},
+		{"no channel", "/api/cast/play", "192.168.1.2:5001", `{"device": "chromecast-1"}`, fiber.StatusBadRequest},
+		{"invalid JSON", "/api/cast/play", "192.168.1.2:5001", `{`, fiber.StatusBadRequest},
+		{"no volume", "/api/cast/volume", "192.168.1.2:5001", `{"device": "chromecast-1"}`, fiber.StatusBadRequest},
+		{"volume out of range", "/api/cast/volume", "192.168.1.2:5001", `{"device": "chromecast-1", "level": 101}`, fiber.StatusBadRequest},
+		{"device error", "/api/cast/volume", "192.168.1.2:5001", `{"device": "chromecast-1", "level": 40}`, fiber.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, body := do("POST", tt.path, tt.host, tt.body); status != tt.wantStatus {
+				t.Errorf("POST %s = %d %s, want %d", tt.path, status, body, tt.wantStatus)
+			}
+		})
+	}
+
+	if played != "http://192.168.1.2:5001/live/high/143.m3u8" {
+		t.Errorf("cast stream URL = %q", played)
+	}
+	if volume != 40 {
+		t.Errorf("cast volume = %d, want 40", volume)
+	}
+}
@@ -10,8 +10,11 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/plugins"
 	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/cdntoken"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
 	pkgUtils "github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 	"github.com/valyala/fasthttp"
 )
@@ -32,7 +35,12 @@ func CatchupHandler(c *fiber.Ctx) error {
 		pkgUtils.Log.Printf("Invalid offset query parameter, defaulting to 0: %v", err)
 	}
 
-	epgData, err := getCatchupEPG(id, offset)
+	var epgData []map[string]interface{}
+	if isZee5Channel(id) {
+		epgData, err = plugins.CatchupEPG("zee5", id, offset)
+	} else {
+		epgData, err = getCatchupEPG(id, offset)
+	}
 	if err != nil {
 		pkgUtils.Log.Println("Error fetching catchup EPG:", err)
 		return c.Render("views/catchup", fiber.Map{
@@ -92,6 +100,7 @@ func CatchupHandler(c *fiber.Ctx) error {
 }
 
 func CatchupStreamHandler(c *fiber.Ctx) error {
+	t := tenantOf(c)
 	id := c.Params("id")
 	start := c.Query("start")
 	end := c.Query("end")
@@ -99,8 +108,13 @@ func CatchupStreamHandler(c *fiber.Ctx) error {
 	if start == "" || end == "" {
 		return fiber.NewError(fiber.StatusBadRequest, "Missing start or end time")
 	}
+	recordPlay(c, id)
+
+	if isZee5Channel(id) {
+		return c.Redirect(zee5CatchupURL(c, id, start, end), fiber.StatusFound)
+	}
 
-	if err := EnsureFreshTokens(); err != nil {
+	if err := t.ensureFreshTokens(); err != nil {
 		pkgUtils.Log.Printf("Failed to ensure fresh tokens: %v", err)
 	}
 
@@ -112,24 +126,35 @@ func CatchupStreamHandler(c *fiber.Ctx) error {
 	if _, err := strconv.ParseInt(start, 10, 64); err == nil {
 		startInt, _ := strconv.ParseInt(start, 10, 64)
 		endInt, _ := strconv.ParseInt(end, 10, 64)
-		start = time.UnixMilli(startInt).UTC().Format("20060102T150405")
-		end = time.UnixMilli(endInt).UTC().Format("20060102T150405")
+		start = catchupEpochTime(startInt).Format("20060102T150405")
+		end = catchupEpochTime(endInt).Format("20060102T150405")
 	}
 
 	pkgUtils.Log.Printf("Fetching catchup URL for channel %s, start: %s, end: %s, srno: %s", id, start, end, srno)
-	catchupResult, err := TV.GetCatchupURL(id, srno, start, end)
+	catchupResult, err := t.TV().GetCatchupURL(id, srno, start, end)
 	if err != nil {
 		pkgUtils.Log.Printf("Error fetching catchup URL: %v", err)
-		return internalUtils.InternalServerError(c, err)
+		return internalUtils.UpstreamError(c, err)
 	}
 
-	targetURL := catchupResult.Bitrates.Auto
-	if targetURL == "" {
-		targetURL = catchupResult.Result
-	}
+	maxQuality := television.ChannelCatchupQualityCap(id)
+	targetURL := cappedCatchupHLSURL(catchupResult, maxQuality)
 	pkgUtils.Log.Printf("Catchup Target URL: %s", targetURL)
 
 	if targetURL == "" {
+		// Some catchup results only carry MPD URLs. Proxy them directly when they are not DRM protected.
+		if mpdURL := selectCatchupMPDURL(catchupResult, television.CapQuality(c.Query("q", "high"), maxQuality)); mpdURL != "" && !catchupResult.IsDRM {
+			pkgUtils.Log.Printf("Catchup has no HLS URL, serving MPD: %s", mpdURL)
+			encMpdURL, err := secureurl.EncryptURL(mpdURL)
+			if err != nil {
+				return internalUtils.InternalServerError(c, err)
+			}
+			redirectURL := tenantBase(c) + "/render.mpd?auth=" + encMpdURL
+			if catchupResult.Hdnea != "" && cdntoken.HDNEA.FromURL(mpdURL) == "" {
+				redirectURL += "&" + cdntoken.HDNEA.Param + "=" + url.QueryEscape(catchupResult.Hdnea)
+			}
+			return c.Redirect(redirectURL, fiber.StatusFound)
+		}
 		return internalUtils.InternalServerError(c, fmt.Errorf("failed to get catchup URL from API"))
 	}
 
@@ -138,10 +163,10 @@ func CatchupStreamHandler(c *fiber.Ctx) error {
 		return internalUtils.InternalServerError(c, err)
 	}
 
-	redirectURL := fmt.Sprintf("/render.m3u8?auth=%s&channel_key_id=%s", codedUrl, id)
+	redirectURL := fmt.Sprintf("%s/render.m3u8?auth=%s&channel_key_id=%s", tenantBase(c), codedUrl, id)
 	// Ensure we don't double-append hdnea if it's already in the URL
-	if catchupResult.Hdnea != "" && !strings.Contains(targetURL, "hdnea=") {
-		redirectURL += "&hdnea=" + catchupResult.Hdnea
+	if catchupResult.Hdnea != "" && cdntoken.HDNEA.FromURL(targetURL) == "" {
+		redirectURL += "&" + cdntoken.HDNEA.Param + "=" + url.QueryEscape(catchupResult.Hdnea)
 	}
 	return c.Redirect(redirectURL, fiber.StatusFound)
 }
@@ -170,6 +195,7 @@ func CatchupPlayerHandler(c *fiber.Ctx) error {
 }
 
 func CatchupRenderPlayerHandler(c *fiber.Ctx) error {
+	t := tenantOf(c)
 	id := c.Params("id")
 	start := c.Query("start")
 	end := c.Query("end")
@@ -179,12 +205,20 @@ func CatchupRenderPlayerHandler(c *fiber.Ctx) error {
 	if qualityForDrm == "" {
 		qualityForDrm = "high"
 	}
+	qualityForDrm = television.CapQuality(qualityForDrm, television.ChannelCatchupQualityCap(id))
 
 	playURL := fmt.Sprintf("/catchup/stream/%s?start=%s&end=%s&srno=%s", id, start, end, srno)
 	if quality != "" {
 		playURL += "&q=" + quality
 	}
 
+	if isZee5Channel(id) {
+		return c.Render("views/player_hls", fiber.Map{
+			"play_url":   zee5CatchupURL(c, id, start, end),
+			"is_catchup": true,
+		})
+	}
+
 	startFmt := start
 	endFmt := end
 	if _, err := strconv.ParseInt(start, 10, 64); err == nil {
@@ -194,33 +228,20 @@ func CatchupRenderPlayerHandler(c *fiber.Ctx) error {
 		endFmt = time.UnixMilli(endInt).UTC().Format("20060102T150405")
 	}
 
-	if err := EnsureFreshTokens(); err != nil {
+	if err := t.ensureFreshTokens(); err != nil {
 		pkgUtils.Log.Printf("Failed to ensure fresh tokens: %v", err)
 	}
 
-	catchupResult, err := TV.GetCatchupURL(id, srno, startFmt, endFmt)
-	if err == nil && catchupResult != nil && catchupResult.IsDRM {
-		mpdURL := internalUtils.SelectQuality(qualityForDrm, catchupResult.Mpd.Bitrates.Auto, catchupResult.Mpd.Bitrates.High, catchupResult.Mpd.Bitrates.Medium, catchupResult.Mpd.Bitrates.Low)
-		if mpdURL == "" {
-			if catchupResult.Mpd.Bitrates.High != "" {
-				mpdURL = catchupResult.Mpd.Bitrates.High
-			} else if catchupResult.Mpd.Bitrates.Auto != "" {
-				mpdURL = catchupResult.Mpd.Bitrates.Auto
-			} else if catchupResult.Mpd.Bitrates.Medium != "" {
-				mpdURL = catchupResult.Mpd.Bitrates.Medium
-			} else if catchupResult.Mpd.Bitrates.Low != "" {
-				mpdURL = catchupResult.Mpd.Bitrates.Low
-			}
-		}
-		if mpdURL == "" {
-			mpdURL = catchupResult.Mpd.Result
-		}
+	catchupResult, err := t.TV().GetCatchupURL(id, srno, startFmt, endFmt)
+	// Use the DASH player for DRM catchup, and for clear catchup that only has MPD URLs
+	if err == nil && catchupResult != nil && (catchupResult.IsDRM || catchupHLSURL(catchupResult) == "") {
+		mpdURL := selectCatchupMPDURL(catchupResult, qualityForDrm)
 
 		if mpdURL != "" {
 			encMpdUrl, encErr := secureurl.EncryptURL(mpdURL)
 			if encErr == nil {
 				licenseUrl := ""
-				if catchupResult.Mpd.Key != "" {
+				if catchupResult.IsDRM && catchupResult.Mpd.Key != "" {
 					encKey, keyErr := secureurl.EncryptURL(catchupResult.Mpd.Key)
 					if keyErr == nil {
 						licenseUrl = "/drm?auth=" + encKey + "&channel_id=" + id + "&channel=" + encMpdUrl
@@ -262,6 +283,69 @@ func CatchupRenderPlayerHandler(c *fiber.Ctx) error {
 	})
 }
 
+// isLikelyMPDURL reports whether the URL points to a DASH manifest.
+func isLikelyMPDURL(streamURL string) bool {
+	return strings.Contains(strings.ToLower(streamURL), ".mpd")
+}
+
+// catchupHLSURL returns the HLS URL of a catchup result, or an empty string if there is none.
+func catchupHLSURL(catchupResult *television.LiveURLOutput) string {
+	if catchupResult.Bitrates.Auto != "" {
+		return catchupResult.Bitrates.Auto
+	}
+	if isLikelyMPDURL(catchupResult.Result) {
+		return ""
+	}
+	return catchupResult.Result
+}
+
+// cappedCatchupHLSURL returns the HLS URL of a catchup result with the highest quality up to maxQuality.
+// Without a cap, or if the result has no such quality, it returns the same URL as catchupHLSURL.
+func cappedCatchupHLSURL(catchupResult *television.LiveURLOutput, maxQuality string) string {
+	if maxQuality != "" {
+		bitrates := catchupResult.Bitrates
+		for _, quality := range []string{"high", "medium", "low"} {
+			if television.CapQuality(quality, maxQuality) != quality {
+				continue
+			}
+			if hlsURL := internalUtils.SelectQuality(quality, bitrates.Auto, bitrates.High, bitrates.Medium, bitrates.Low); hlsURL != "" {
+				return hlsURL
+			}
+		}
+	}
+	return catchupHLSURL(catchupResult)
+}
+
+// selectCatchupMPDURL returns the MPD URL of a catchup result for the given quality,
+// falling back to any available quality.
+func selectCatchupMPDURL(catchupResult *television.LiveURLOutput, quality string) string {
+	bitrates := catchupResult.Mpd.Bitrates
+	mpdURL := internalUtils.SelectQuality(quality, bitrates.Auto, bitrates.High, bitrates.Medium, bitrates.Low)
+	if mpdURL != "" {
+		return mpdURL
+	}
+	for _, candidate := range []string{bitrates.High, bitrates.Auto, bitrates.Medium, bitrates.Low} {
+		if candidate != "" {
+			return candidate
+		}
+	}
+	if catchupResult.Mpd.Result != "" {
+		return catchupResult.Mpd.Result
+	}
+	if isLikelyMPDURL(catchupResult.Result) {
+		return catchupResult.Result
+	}
+	return ""
+}
+
+// zee5CatchupURL returns the time-shifted playlist URL of a Zee5 channel for the tenant of the request.
+func zee5CatchupURL(c *fiber.Ctx, id, start, end string) string {
+	params := url.Values{}
+	params.Set("start", start)
+	params.Set("end", end)
+	return tenantBase(c) + "/zee5/catchup/" + url.PathEscape(id) + "?" + params.Encode()
+}
+
 func getCatchupEPG(id string, offset int) ([]map[string]interface{}, error) {
 	url := fmt.Sprintf(catchupEPGURL, offset, id, defaultLangID)
 
@@ -276,7 +360,7 @@ func getCatchupEPG(id string, offset int) ([]map[string]interface{}, error) {
 	resp := fasthttp.AcquireResponse()
 	defer fasthttp.ReleaseResponse(resp)
 
-	client := &fasthttp.Client{}
+	client := &fasthttp.Client{TLSConfig: pkgUtils.UpstreamTLSConfig()}
 	if err := client.Do(req, resp); err != nil {
 		return nil, err
 	}
@@ -320,3 +404,12 @@ func getCatchupEPG(id string, offset int) ([]map[string]interface{}, error) {
 
 	return nil, fmt.Errorf("epg field not found or not a list")
 }
+
+// catchupEpochTime returns the UTC time of a Unix timestamp in milliseconds, as used by the web
+// interface, or in seconds, as used by the catchup URLs of IPTV players.
+func catchupEpochTime(epoch int64) time.Time {
+	if epoch < epochThreshold {
+		epoch = epoch * 1000
+	}
+	return time.UnixMilli(epoch).UTC()
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+// catchupFeedCacheAge is how long podcast apps may reuse a catchup feed before checking for new episodes
+const catchupFeedCacheAge = 15 * time.Minute
+
+// rssFeed is an RSS 2.0 document with the iTunes podcast extensions
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	ITunes  string     `xml:"xmlns:itunes,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+// rssChannel is the podcast of a catchup feed
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Language    string    `xml:"language"`
+	Image       *rssImage `xml:"itunes:image,omitempty"`
+	Items       []rssItem `xml:"item"`
+}
+
+// rssImage is the artwork of a podcast or an episode
+type rssImage struct {
+	Href string `xml:"href,attr"`
+}
+
+// rssItem is an episode of a catchup feed
+type rssItem struct {
+	Title       string       `xml:"title"`
+	Link        string       `xml:"link"`
+	Description string       `xml:"description,omitempty"`
+	GUID        rssGUID      `xml:"guid"`
+	PubDate     string       `xml:"pubDate"`
+	Enclosure   rssEnclosure `xml:"enclosure"`
+	Duration    int          `xml:"itunes:duration,omitempty"`
+	Image       *rssImage    `xml:"itunes:image,omitempty"`
+}
+
+// rssGUID is the unique ID of an episode
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// rssEnclosure is the media of an episode
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int    `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// catchupFeedTitle names a feed after the search query or the channels it contains.
+func catchupFeedTitle(query string, channels []television.Channel) string {
+	names := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		names = append(names, channel.Name)
+	}
+	switch {
+	case query != "" && len(channels) == 1:
+		return fmt.Sprintf("%s on %s", query, names[0])
+	case query != "":
+		return query
+	default:
+		return strings.Join(names, ", ")
+	}
+}
+
+// buildCatchupFeed returns the podcast feed of catchup programmes, with episodes pointing at their catchup streams.
+func buildCatchupFeed(title, link string, results []catchupSearchResult) rssFeed {
+	feed := rssFeed{
+		Version: "2.0",
+		ITunes:  "http://www.itunes.com/dtds/podcast-1.0.dtd",
+		Channel: rssChannel{
+			Title:       title + " - JioTV Go Catchup",
+			Link:        link,
+			Description: "Programmes recorded from TV by JioTV Go catchup: " + title,
+			Language:    "en-in",
+		},
+	}
+	for _, result := range results {
+		item := rssItem{
+			// Daily shows have the same title, so the air date tells episodes apart
+			Title:       fmt.Sprintf("%s (%s)", result.Title, result.Start.In(istLocation()).Format("02 Jan 2006 03:04 PM")),
+			Link:        result.URL,
+			Description: result.Description,
+			GUID:        rssGUID{Value: result.ChannelID + "-" + strconv.FormatInt(result.Start.UnixMilli(), 10)},
+			PubDate:     result.Stop.In(istLocation()).Format(time.RFC1123Z),
+			Enclosure:   rssEnclosure{URL: result.StreamURL, Type: "application/vnd.apple.mpegurl"},
+			Duration:    int(result.Stop.Sub(result.Start).Seconds()),
+		}
+		if result.Poster != "" {
+			item.Image = &rssImage{Href: result.Poster}
+			if feed.Channel.Image == nil {
+				feed.Channel.Image = &rssImage{Href: result.Poster}
+			}
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+	return feed
+}
+
+// CatchupFeedHandler serves catchup programmes as a podcast feed on `/api/v1/catchup/feed`,
+// so that podcast apps fetch new episodes of daily shows by themselves.
+// channels is a comma-separated list of channel IDs and q filters programmes by title. At least one is needed.
+// days is the number of past days in the feed, from 1 to 7, 7 by default.
+func CatchupFeedHandler(c *fiber.Ctx) error {
+	title := strings.TrimSpace(c.Query("q"))
+	query := strings.ToLower(title)
+	channelsParam := c.Query("channels")
+	if query == "" && channelsParam == "" {
+		return internalUtils.BadRequestError(c, "Set channels, q or both to choose the programmes of the feed")
+	}
+	if query != "" && len([]rune(query)) < 2 {
+		return internalUtils.BadRequestError(c, "Search query q must have at least 2 characters")
+	}
+	days, err := strconv.Atoi(c.Query("days", strconv.Itoa(catchupSearchMaxDays)))
+	if err != nil || days < 1 || days > catchupSearchMaxDays {
+		return internalUtils.BadRequestError(c, fmt.Sprintf("Invalid days, use a number from 1 to %d", catchupSearchMaxDays))
+	}
+
+	apiResponse, err := television.Channels()
+	if err != nil {
+		return ErrorMessageHandler(c, err)
+	}
+	var ids []string
+	if channelsParam != "" {
+		ids = strings.Split(channelsParam, ",")
+	}
+	channels := catchupSearchChannels(apiResponse.Result, ids)
+	if len(channels) == 0 {
+		return internalUtils.NotFoundError(c, "No channels with catchup found")
+	}
+
+	hostURL := requestHostURL(c)
+	results := searchCatchup(channels, query, days, time.Now(), hostURL)
+	link := hostURL + "/"
+	if len(channels) == 1 {
+		link = hostURL + "/catchup/" + url.PathEscape(channels[0].ID)
+	}
+	feed := buildCatchupFeed(catchupFeedTitle(title, channels), link, results)
+
+	body, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return internalUtils.InternalServerError(c, "Failed to build the catchup feed")
+	}
+	c.Set(fiber.HeaderContentType, "application/rss+xml; charset=utf-8")
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", int(catchupFeedCacheAge.Seconds())))
+	return c.Send(append([]byte(xml.Header), body...))
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+func TestCatchupFeedTitle(t *testing.T) {
+	news := television.Channel{ID: "143", Name: "News"}
+	sports := television.Channel{ID: "144", Name: "Sports"}
+
+	tests := []struct {
+		name     string
+		query    string
+		channels []television.Channel
+		want     string
+	}{
+		{"channel", "", []television.Channel{news}, "News"},
+		{"channels", "", []television.Channel{news, sports}, "News, Sports"},
+		{"query on channel", "Nightly Bulletin", []television.Channel{news}, "Nightly Bulletin on News"},
+		{"query on all channels", "Nightly Bulletin", []television.Channel{news, sports}, "Nightly Bulletin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := catchupFeedTitle(tt.query, tt.channels); got != tt.want {
+				t.Errorf("catchupFeedTitle() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildCatchupFeed(t *testing.T) {
+	start := time.Date(2024, 1, 11, 15, 30, 0, 0, time.UTC)
+	results := []catchupSearchResult{
+		{
+			ChannelID:   "143",
+			ChannelName: "News",
+			Title:       "Nightly Bulletin",
+			Description: "The news of the day",
+			Start:       start,
+			Stop:        start.Add(30 * time.Minute),
+			Poster:      "http://localhost:5001/jtvposter/bulletin.jpg",
+			URL:         "http://localhost:5001/catchup/play/143?start=1704987000000",
+			StreamURL:   "http://localhost:5001/catchup/stream/143?end=1704988800000&srno=1&start=1704987000000",
+		},
+		{
+			ChannelID: "143",
+			Title:     "Nightly Bulletin",
+			Start:     start.AddDate(0, 0, -1),
+			Stop:      start.AddDate(0, 0, -1).Add(30 * time.Minute),
+			StreamURL: "http://localhost:5001/catchup/stream/143?end=1704902400000&srno=1&start=1704900600000",
+		},
+	}
+
+	body, err := xml.Marshal(buildCatchupFeed("News", "http://localhost:5001/catchup/143", results))
+	if err != nil {
+		t.Fatalf("xml.Marshal() error = %v", err)
+	}
+	feed := string(body)
+	for _, want := range []string{
+		`<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">`,
+		`<title>News - JioTV Go Catchup</title>`,
+		`<title>Nightly Bulletin (11 Jan 2024 09:00 PM)</title>`,
+		`<title>Nightly Bulletin (10 Jan 2024 09:00 PM)</title>`,
+		`<guid isPermaLink="false">143-1704987000000</guid>`,
+		`<pubDate>Thu, 11 Jan 2024 21:30:00 +0530</pubDate>`,
+		`<enclosure url="http://localhost:5001/catchup/stream/143?end=1704988800000&amp;srno=1&amp;start=1704987000000" length="0" type="application/vnd.apple.mpegurl">`,
+		`<itunes:duration>1800</itunes:duration>`,
+		`<itunes:image href="http://localhost:5001/jtvposter/bulletin.jpg">`,
+	} {
+		if !strings.Contains(feed, want) {
+			t.Errorf("feed does not contain %s:\n%s", want, feed)
+		}
+	}
+	if strings.Count(feed, "<itunes:image") != 2 {
+		t.Errorf("feed should have the poster as podcast and first episode artwork only:\n%s", feed)
+	}
+}
+
+func TestCatchupFeedHandlerValidation(t *testing.T) {
+	app := fiber.New()
+	app.Get("/api/v1/catchup/feed", CatchupFeedHandler)
+
+	for _, target := range []string{
+		"/api/v1/catchup/feed",
+		"/api/v1/catchup/feed?q=a",
+		"/api/v1/catchup/feed?channels=143&days=0",
+		"/api/v1/catchup/feed?channels=143&days=8",
+		"/api/v1/catchup/feed?q=news&days=week",
+	} {
+		resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+		if err != nil {
+			t.Fatalf("app.Test(%s) error = %v", target, err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("GET %s status = %d, want %d", target, resp.StatusCode, fiber.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	pkgUtils "github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// catchupSearchMaxDays is the number of past days JioTV keeps catchup for
+	catchupSearchMaxDays = 7
+	// catchupSearchWorkers is the number of catchup EPG requests made concurrently
+	catchupSearchWorkers = 20
+	// catchupSearchLimit is the maximum number of programmes returned by a search
+	catchupSearchLimit = 100
+	// catchupEPGCacheAge is how long the catchup EPG of a channel and day is reused for searches
+	catchupEPGCacheAge = 15 * time.Minute
+)
+
+// catchupSearchResult is a programme found by CatchupSearchHandler
+type catchupSearchResult struct {
+	ChannelID   string    `json:"channel_id"`
+	ChannelName string    `json:"channel_name"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Start       time.Time `json:"start"`
+	Stop        time.Time `json:"stop"`
+	Poster      string    `json:"poster,omitempty"`
+	URL         string    `json:"url"`
+	StreamURL   string    `json:"stream_url"`
+}
+
+// cachedCatchupEPG is the catchup EPG of a channel and day with the time it was fetched
+type cachedCatchupEPG struct {
+	programmes []map[string]interface{}
+	fetchedAt  time.Time
+}
+
+var (
+	// catchupEPGCache holds the catchup EPG by "<channel ID>/<offset>"
+	catchupEPGCache sync.Map
+	// fetchCatchupEPG fetches the catchup EPG of a channel, replaced in tests
+	fetchCatchupEPG = getCatchupEPG
+)
+
+// cachedCatchupEPGFor returns the catchup EPG of a channel on a day, from cache if it is recent.
+func cachedCatchupEPGFor(id string, offset int) ([]map[string]interface{}, error) {
+	key := id + "/" + strconv.Itoa(offset)
+	if value, ok := catchupEPGCache.Load(key); ok && time.Since(value.(cachedCatchupEPG).fetchedAt) < catchupEPGCacheAge {
+		return value.(cachedCatchupEPG).programmes, nil
+	}
+	programmes, err := fetchCatchupEPG(id, offset)
+	if err != nil {
+		return nil, err
+	}
+	catchupEPGCache.Store(key, cachedCatchupEPG{programmes: programmes, fetchedAt: time.Now()})
+	return programmes, nil
+}
+
+// catchupEpoch returns the time of an epoch field of a catchup EPG programme.
+func catchupEpoch(programme map[string]interface{}, key string) (time.Time, bool) {
+	epoch, ok := programme[key].(int64)
+	if !ok || epoch <= 0 {
+		return time.Time{}, false
+	}
+	if epoch < epochThreshold {
+		epoch *= 1000
+	}
+	return time.UnixMilli(epoch), true
+}
+
+// catchupString returns a string field of a catchup EPG programme.
+func catchupString(programme map[string]interface{}, key string) string {
+	value, _ := programme[key].(string)
+	return value
+}
+
+// matchCatchupProgrammes returns the programmes that started before now and whose title contains query.
+// query must be lower case.
+func matchCatchupProgrammes(channel television.Channel, programmes []map[string]interface{}, query string, now time.Time, hostURL string) []catchupSearchResult {
+	var results []catchupSearchResult
+	for _, programme := range programmes {
+		title := catchupString(programme, "showname")
+		if !strings.Contains(strings.ToLower(title), query) {
+			continue
+		}
+		start, okStart := catchupEpoch(programme, "startEpoch")
+		stop, okStop := catchupEpoch(programme, "endEpoch")
+		if !okStart || !okStop || start.After(now) {
+			continue
+		}
+
+		poster := catchupString(programme, "episodePoster")
+		result := catchupSearchResult{
+			ChannelID:   channel.ID,
+			ChannelName: channel.Name,
+			Title:       title,
+			Description: catchupString(programme, "description"),
+			Start:       start,
+			Stop:        stop,
+			URL:         hostURL + catchupPlayPath(channel.ID, programme, start, stop),
+			StreamURL:   hostURL + catchupStreamPath(channel.ID, programme, start, stop),
+		}
+		if poster != "" {
+			result.Poster = hostURL + "/jtvposter/" + poster
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// catchupPlayPath returns the path of the catchup player for a catchup EPG programme between start and stop.
+func catchupPlayPath(id string, programme map[string]interface{}, start, stop time.Time) string {
+	poster := catchupString(programme, "posterURL")
+	if poster == "" {
+		poster = catchupString(programme, "episodePoster")
+	}
+	params := url.Values{}
+	params.Set("start", strconv.FormatInt(start.UnixMilli(), 10))
+	params.Set("end", strconv.FormatInt(stop.UnixMilli(), 10))
+	params.Set("srno", catchupString(programme, "srno"))
+	params.Set("showname", catchupString(programme, "showname"))
+	params.Set("description", catchupString(programme, "description"))
+	params.Set("poster", poster)
+	params.Set("showtime", start.In(istLocation()).Format("03:04 PM"))
+	return "/catchup/play/" + url.PathEscape(id) + "?" + params.Encode()
+}
+
+// catchupStreamPath returns the path of the HLS stream of a catchup EPG programme between start and stop.
+func catchupStreamPath(id string, programme map[string]interface{}, start, stop time.Time) string {
+	params := url.Values{}
+	params.Set("start", strconv.FormatInt(start.UnixMilli(), 10))
+	params.Set("end", strconv.FormatInt(stop.UnixMilli(), 10))
+	params.Set("srno", catchupString(programme, "srno"))
+	return "/catchup/stream/" + url.PathEscape(id) + "?" + params.Encode()
+}
+
+// istLocation returns the Indian Standard Time zone that JioTV schedules use.
+func istLocation() *time.Location {
+	loc, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		return time.FixedZone("IST", 5*3600+30*60)
+	}
+	return loc
+}
+
+// catchupSearchChannels returns the channels with catchup, limited to the given IDs if any.
+func catchupSearchChannels(channels []television.Channel, ids []string) []television.Channel {
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if id = strings.TrimSpace(id); id != "" {
+			wanted[id] = true
+		}
+	}
+	var result []television.Channel
+	for _, channel := range channels {
+		if !channel.IsCatchupAvailable || channel.IsCustom {
+			continue
+		}
+		if len(wanted) > 0 && !wanted[channel.ID] {
+			continue
+		}
+		result = append(result, channel)
+	}
+	return result
+}
+
+// searchCatchup searches the catchup EPG of the channels for the last days, most recent programmes first.
+func searchCatchup(channels []television.Channel, query string, days int, now time.Time, hostURL string) []catchupSearchResult {
+	type job struct {
+		channel television.Channel
+		offset  int
+	}
+	jobs := make(chan job)
+	var (
+		mu       sync.Mutex
+		results  []catchupSearchResult
+		failures int
+		wg       sync.WaitGroup
+	)
+	for i := 0; i < catchupSearchWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				programmes, err := cachedCatchupEPGFor(j.channel.ID, j.offset)
+				mu.Lock()
+				if err != nil {
+					failures++
+				} else {
+					results = append(results, matchCatchupProgrammes(j.channel, programmes, query, now, hostURL)...)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, channel := range channels {
+		for offset := 0; offset > -days; offset-- {
+			jobs <- job{channel: channel, offset: offset}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if failures > 0 {
+		pkgUtils.Log.Printf("WARN: Catchup search could not fetch %d channel days", failures)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if !results[i].Start.Equal(results[j].Start) {
+			return results[i].Start.After(results[j].Start)
+		}
+		return results[i].ChannelID < results[j].ChannelID
+	})
+	if len(results) > catchupSearchLimit {
+		results = results[:catchupSearchLimit]
+	}
+	return results
+}
+
+// CatchupSearchHandler searches the catchup programmes of all channels on `/api/v1/catchup/search`.
+// q is matched against programme titles, days is the number of past days to search, from 1 to 7,
+// and channels optionally limits the search to comma-separated channel IDs.
+func CatchupSearchHandler(c *fiber.Ctx) error {
+	query := strings.ToLower(strings.TrimSpace(c.Query("q")))
+	if len([]rune(query)) < 2 {
+		return internalUtils.BadRequestError(c, "Search query q must have at least 2 characters")
+	}
+	days, err := strconv.Atoi(c.Query("days", "1"))
+	if err != nil || days < 1 || days > catchupSearchMaxDays {
+		return internalUtils.BadRequestError(c, fmt.Sprintf("Invalid days, use a number from 1 to %d", catchupSearchMaxDays))
+	}
+
+	apiResponse, err := television.Channels()
+	if err != nil {
+		return ErrorMessageHandler(c, err)
+	}
+	var ids []string
+	if channelsParam := c.Query("channels"); channelsParam != "" {
+		ids = strings.Split(channelsParam, ",")
+	}
+	channels := catchupSearchChannels(apiResponse.Result, ids)
+
+	results := searchCatchup(channels, query, days, time.Now(), requestHostURL(c))
+	if results == nil {
+		results = []catchupSearchResult{}
+	}
+	return c.JSON(fiber.Map{
+		"query":   query,
+		"days":    days,
+		"results": results,
+	})
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+func TestCatchupSearchChannels(t *testing.T) {
+	channels := []television.Channel{
+		{ID: "143", IsCatchupAvailable: true},
+		{ID: "144"},
+		{ID: "145", IsCatchupAvailable: true},
+		{ID: "custom", IsCatchupAvailable: true, IsCustom: true},
+	}
+
+	tests := []struct {
+		name string
+		ids  []string
+		want []string
+	}{
+		{name: "all with catchup", want: []string{"143", "145"}},
+		{name: "selected", ids: []string{" 145", "144", ""}, want: []string{"145"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := catchupSearchChannels(channels, tt.ids)
+			if len(got) != len(tt.want) {
+				t.Fatalf("catchupSearchChannels() = %+v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].ID != tt.want[i] {
+					t.Errorf("channel %d = %s, want %s", i, got[i].ID, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSearchCatchup(t *testing.T) {
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	now := time.Date(2024, 1, 11, 12, 0, 0, 0, time.UTC)
+	programme := func(title string, start time.Time) map[string]interface{} {
+		return map[string]interface{}{
+			"showname":      title,
+			"description":   title + " description",
+			"episodePoster": "2024-01-11/" + title + ".jpg",
+			"srno":          "1",
+			"startEpoch":    start.UnixMilli(),
+			"endEpoch":      start.Add(time.Hour).UnixMilli(),
+		}
+	}
+
+	var mu sync.Mutex
+	var fetched []string
+	originalFetch := fetchCatchupEPG
+	defer func() { fetchCatchupEPG = originalFetch }()
+	fetchCatchupEPG = func(id string, offset int) ([]map[string]interface{}, error) {
+		mu.Lock()
+		fetched = append(fetched, id)
+		mu.Unlock()
+		day := now.AddDate(0, 0, offset)
+		switch id {
+		case "search_news":
+			return []map[string]interface{}{
+				programme("Morning News", day.Add(-4*time.Hour)),
+				programme("Sports", day.Add(-2*time.Hour)),
+				// Only yesterday's has aired
+				programme("Evening News", day.Add(2*time.Hour)),
+			}, nil
+		case "search_sports":
+			return []map[string]interface{}{programme("Sports News", day.Add(-time.Hour))}, nil
+		}
+		return nil, errors.New("no EPG")
+	}
+
+	channels := []television.Channel{
+		{ID: "search_news", Name: "News"},
+		{ID: "search_sports", Name: "Sports"},
+		{ID: "search_broken", Name: "Broken"},
+	}
+	results := searchCatchup(channels, "news", 2, now, "http://localhost:5001")
+
+	wantTitles := []string{"Sports News", "Morning News", "Evening News", "Sports News", "Morning News"}
+	if len(results) != len(wantTitles) {
+		t.Fatalf("searchCatchup() = %+v, want %d results", results, len(wantTitles))
+	}
+	for i, title := range wantTitles {
+		if results[i].Title != title {
+			t.Errorf("results[%d].Title = %q, want %q", i, results[i].Title, title)
+		}
+	}
+	if len(fetched) != 6 {
+		t.Errorf("fetched %d channel days, want 6", len(fetched))
+	}
+
+	morning := results[1]
+	if morning.ChannelID != "search_news" || morning.ChannelName != "News" || morning.Poster != "http://localhost:5001/jtvposter/2024-01-11/Morning News.jpg" {
+		t.Errorf("result = %+v", morning)
+	}
+	link, err := url.Parse(morning.URL)
+	if err != nil {
+		t.Fatalf("invalid URL %q: %v", morning.URL, err)
+	}
+	if link.Path != "/catchup/play/search_news" || link.Query().Get("showname") != "Morning News" ||
+		link.Query().Get("start") != "1704960000000" || link.Query().Get("srno") != "1" {
+		t.Errorf("URL = %s", morning.URL)
+	}
+	if want := "http://localhost:5001/catchup/stream/search_news?end=1704963600000&srno=1&start=1704960000000"; morning.StreamURL != want {
+		t.Errorf("StreamURL = %s, want %s", morning.StreamURL, want)
+	}
+
+	// Repeated searches use the cache
+	fetched = nil
+	searchCatchup(channels[:2], "news", 1, now, "")
+	if len(fetched) != 0 {
+		t.Errorf("fetched %v again, want cached EPG", fetched)
+	}
+}
+
+func TestCatchupSearchHandlerValidation(t *testing.T) {
+	app := fiber.New()
+	app.Get("/api/v1/catchup/search", CatchupSearchHandler)
+
+	for _, target := range []string{
+		"/api/v1/catchup/search",
+		"/api/v1/catchup/search?q=a",
+		"/api/v1/catchup/search?q=news&days=0",
+		"/api/v1/catchup/search?q=news&days=8",
+		"/api/v1/catchup/search?q=news&days=week",
+	} {
+		resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+		if err != nil {
+			t.Fatalf("app.Test(%s) error = %v", target, err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("GET %s status = %d, want %d", target, resp.StatusCode, fiber.StatusBadRequest)
+		}
+	}
+}
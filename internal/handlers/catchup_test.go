@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+func TestCatchupHLSURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    *television.LiveURLOutput
+		expected string
+	}{
+		{
+			name: "prefers auto bitrate",
+			input: &television.LiveURLOutput{
+				Bitrates: television.Bitrates{Auto: "https://cdn.example.com/auto.m3u8"},
+				Result:   "https://cdn.example.com/result.m3u8",
+			},
+			expected: "https://cdn.example.com/auto.m3u8",
+		},
+		{
+			name:     "falls back to result",
+			input:    &television.LiveURLOutput{Result: "https://cdn.example.com/result.m3u8"},
+			expected: "https://cdn.example.com/result.m3u8",
+		},
+		{
+			name:     "ignores mpd result",
+			input:    &television.LiveURLOutput{Result: "https://cdn.example.com/manifest.mpd"},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := catchupHLSURL(tt.input); got != tt.expected {
+				t.Errorf("catchupHLSURL() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCappedCatchupHLSURL(t *testing.T) {
+	all := &television.LiveURLOutput{Bitrates: television.Bitrates{
+		Auto: "auto.m3u8", High: "high.m3u8", Medium: "medium.m3u8", Low: "low.m3u8",
+	}}
+	noMedium := &television.LiveURLOutput{Bitrates: television.Bitrates{Auto: "auto.m3u8", High: "high.m3u8", Low: "low.m3u8"}}
+	autoOnly := &television.LiveURLOutput{Bitrates: television.Bitrates{Auto: "auto.m3u8"}}
+
+	tests := []struct {
+		name       string
+		input      *television.LiveURLOutput
+		maxQuality string
+		expected   string
+	}{
+		{"no cap uses auto", all, "", "auto.m3u8"},
+		{"caps to medium", all, "medium", "medium.m3u8"},
+		{"falls back to lower quality", noMedium, "medium", "low.m3u8"},
+		{"high cap uses high", all, "high", "high.m3u8"},
+		{"falls back to auto when nothing else", autoOnly, "low", "auto.m3u8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cappedCatchupHLSURL(tt.input, tt.maxQuality); got != tt.expected {
+				t.Errorf("cappedCatchupHLSURL() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSelectCatchupMPDURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		quality  string
+		input    *television.LiveURLOutput
+		expected string
+	}{
+		{
+			name:    "returns requested quality",
+			quality: "medium",
+			input: &television.LiveURLOutput{
+				Mpd: television.MPD{Bitrates: television.Bitrates{High: "high.mpd", Medium: "medium.mpd"}},
+			},
+			expected: "medium.mpd",
+		},
+		{
+			name:    "falls back to another quality",
+			quality: "low",
+			input: &television.LiveURLOutput{
+				Mpd: television.MPD{Bitrates: television.Bitrates{Auto: "auto.mpd"}},
+			},
+			expected: "auto.mpd",
+		},
+		{
+			name:     "falls back to mpd result",
+			quality:  "high",
+			input:    &television.LiveURLOutput{Mpd: television.MPD{Result: "result.mpd"}},
+			expected: "result.mpd",
+		},
+		{
+			name:     "falls back to mpd in result",
+			quality:  "high",
+			input:    &television.LiveURLOutput{Result: "https://cdn.example.com/manifest.mpd"},
+			expected: "https://cdn.example.com/manifest.mpd",
+		},
+		{
+			name:     "returns empty when there is no mpd",
+			quality:  "high",
+			input:    &television.LiveURLOutput{Result: "https://cdn.example.com/master.m3u8"},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectCatchupMPDURL(tt.input, tt.quality); got != tt.expected {
+				t.Errorf("selectCatchupMPDURL() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCatchupEpochTime(t *testing.T) {
+	want := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		epoch int64
+	}{
+		{name: "milliseconds", epoch: want.UnixMilli()},
+		{name: "seconds from IPTV players", epoch: want.Unix()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := catchupEpochTime(tt.epoch); !got.Equal(want) {
+				t.Errorf("catchupEpochTime(%d) = %v, want %v", tt.epoch, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+// ChannelCheckHandler returns the result of the last dead-link check of the custom channels
+// on `GET /api/v1/channels/check`.
+func ChannelCheckHandler(c *fiber.Ctx) error {
+	report, ok := television.GetChannelCheckReport()
+	if !ok {
+		return internalUtils.NotFoundError(c, "No custom channels check has run yet. Send a POST request to run one.")
+	}
+	return c.JSON(report)
+}
+
+// RunChannelCheckHandler checks the custom channels for dead links on `POST /api/v1/channels/check`
+// and returns the result. It needs the admin token, as it requests the URL of every custom channel.
+func RunChannelCheckHandler(c *fiber.Ctx) error {
+	if ok, err := checkAdminToken(c); !ok {
+		return err
+	}
+	report, err := television.CheckCustomChannels(context.Background())
+	if errors.Is(err, television.ErrChannelCheckRunning) {
+		return internalUtils.ErrorResponse(c, fiber.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	return c.JSON(report)
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+func TestChannelCheckHandlers(t *testing.T) {
+	originalToken := config.Cfg.AdminToken
+	defer func() { config.Cfg.AdminToken = originalToken }()
+
+	app := fiber.New()
+	app.Get("/api/v1/channels/check", ChannelCheckHandler)
+	app.Post("/api/v1/channels/check", RunChannelCheckHandler)
+
+	tests := []struct {
+		name       string
+		method     string
+		adminToken string
+		header     string
+		wantStatus int
+	}{
+		{"no check has run", "GET", "", "", fiber.StatusNotFound},
+		{"run disabled without admin token", "POST", "", "", fiber.StatusForbidden},
+		{"run with wrong token", "POST", "secret", "Bearer guess", fiber.StatusUnauthorized},
+		{"run", "POST", "secret", "Bearer secret", fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Cfg.AdminToken = tt.adminToken
+			req := httptest.NewRequest(tt.method, "/api/v1/channels/check", nil)
+			if tt.header != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.header)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("%s error = %v", tt.method, err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("%s /api/v1/channels/check = %d, want %d", tt.method, resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// importPreview is the mode of the import API that only parses the upload
+const importPreview = "preview"
+
+// channelImportResponse is the response of the custom channels import API
+type channelImportResponse struct {
+	Mode     string                     `json:"mode"`
+	Format   string                     `json:"format"`
+	Channels []television.CustomChannel `json:"channels"`
+	Issues   []television.ChannelIssue  `json:"issues"`
+	// Result is set when the channels were written to the custom channels file
+	Result *television.ImportResult `json:"result,omitempty"`
+}
+
+// exportContentTypes are the content types of the formats of the export API
+var exportContentTypes = map[string]string{
+	television.FormatJSON: fiber.MIMEApplicationJSONCharsetUTF8,
+	television.FormatYAML: "application/yaml; charset=utf-8",
+	television.FormatM3U:  "audio/x-mpegurl; charset=utf-8",
+}
+
+// ChannelsAdminHandler renders the page on `/admin/channels` to import and export custom channels
+// from the browser, e.g. on Android where the config folder is hard to reach.
+// The page asks for the admin token, which the import and export APIs need.
+func ChannelsAdminHandler(c *fiber.Ctx) error {
+	return c.Render("views/admin_channels", fiber.Map{
+		"Title":              Title,
+		"CustomChannelsFile": config.Cfg.CustomChannelsFile,
+	})
+}
+
+// ImportChannelsHandler imports a custom channels JSON, YAML or M3U file sent in the body on
+// `POST /api/admin/channels/import`. The mode query parameter is "preview" (the default) to only
+// parse the file, "merge" to add the channels that are missing, or "replace" to replace all custom
+// channels. The format is taken from the format or name query parameters, else from the content.
+func ImportChannelsHandler(c *fiber.Ctx) error {
+	if ok, err := checkAdminToken(c); !ok {
+		return err
+	}
+	mode := strings.ToLower(c.Query("mode", importPreview))
+	if mode != importPreview && mode != television.ImportMerge && mode != television.ImportReplace {
+		return internalUtils.BadRequestError(c, "Invalid mode. Use preview, merge or replace.")
+	}
+	body := c.Body()
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return internalUtils.BadRequestError(c, "Send the custom channels file in the request body")
+	}
+	format, err := television.DetectImportFormat(body, c.Query("format"), c.Query("name"))
+	if err != nil {
+		return internalUtils.BadRequestError(c, err.Error())
+	}
+	channels, issues, err := television.ParseImport(body, format)
+	if err != nil {
+		return internalUtils.BadRequestError(c, "The file cannot be read: "+err.Error())
+	}
+
+	response := channelImportResponse{Mode: mode, Format: format, Channels: channels, Issues: issues}
+	if response.Channels == nil {
+		response.Channels = []television.CustomChannel{}
+	}
+	if response.Issues == nil {
+		response.Issues = []television.ChannelIssue{}
+	}
+	if mode == importPreview {
+		return c.JSON(response)
+	}
+	if len(channels) == 0 {
+		return internalUtils.BadRequestError(c, "The file has no channels to import")
+	}
+	result, err := television.ImportCustomChannels(channels, mode)
+	if err != nil {
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	utils.Log.Printf("INFO: Imported custom channels (%s): %d added, %d skipped, %d in total", mode, result.Added, result.Skipped, result.Total)
+	response.Result = &result
+	return c.JSON(response)
+}
+
+// ExportChannelsHandler downloads the current custom channels on `GET /api/admin/channels/export`,
+// as a custom channels JSON or YAML file, or as an M3U playlist, set by the format query parameter.
+func ExportChannelsHandler(c *fiber.Ctx) error {
+	if ok, err := checkAdminToken(c); !ok {
+		return err
+	}
+	format := strings.ToLower(c.Query("format", television.FormatJSON))
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		return internalUtils.BadRequestError(c, "Invalid format. Use json, yaml or m3u.")
+	}
+	customConfig, err := television.CurrentCustomChannels()
+	if err != nil {
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	data, err := television.ExportCustomChannels(customConfig, format)
+	if err != nil {
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="custom-channels.`+format+`"`)
+	return c.Send(data)
+}
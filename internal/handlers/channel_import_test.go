@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+func TestChannelImportExportHandlers(t *testing.T) {
+	originalToken := config.Cfg.AdminToken
+	originalFile := config.Cfg.CustomChannelsFile
+	defer func() {
+		config.Cfg.AdminToken = originalToken
+		config.Cfg.CustomChannelsFile = originalFile
+		television.ReloadCustomChannels()
+	}()
+
+	config.Cfg.AdminToken = "secret"
+	config.Cfg.CustomChannelsFile = filepath.Join(t.TempDir(), "custom-channels.json")
+	if err := os.WriteFile(config.Cfg.CustomChannelsFile, []byte(`{"channels": [{"id": "a", "name": "A", "url": "https://example.com/a.m3u8"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Post("/api/admin/channels/import", ImportChannelsHandler)
+	app.Get("/api/admin/channels/export", ExportChannelsHandler)
+
+	m3u := "#EXTM3U\n#EXTINF:-1 tvg-id=\"b\",B\nhttps://example.com/b.m3u8\n"
+	tests := []struct {
+		name         string
+		method       string
+		target       string
+		header       string
+		body         string
+		wantStatus   int
+		wantChannels int
+		wantResult   *television.ImportResult
+		wantBody     string
+	}{
+		{name: "import without token", method: "POST", target: "/api/admin/channels/import", body: m3u, wantStatus: fiber.StatusUnauthorized},
+		{name: "invalid mode", method: "POST", target: "/api/admin/channels/import?mode=append", header: "Bearer secret", body: m3u, wantStatus: fiber.StatusBadRequest},
+		{name: "empty body", method: "POST", target: "/api/admin/channels/import", header: "Bearer secret", wantStatus: fiber.StatusBadRequest},
+		{name: "invalid JSON", method: "POST", target: "/api/admin/channels/import?name=channels.json", header: "Bearer secret", body: "{", wantStatus: fiber.StatusBadRequest},
+		{name: "preview", method: "POST", target: "/api/admin/channels/import", header: "Bearer secret", body: m3u, wantStatus: fiber.StatusOK, wantChannels: 1},
+		{
+			name: "merge", method: "POST", target: "/api/admin/channels/import?mode=merge", header: "Bearer secret", body: m3u,
+			wantStatus: fiber.StatusOK, wantChannels: 1, wantResult: &television.ImportResult{Added: 1, Total: 2},
+		},
+		{name: "export without token", method: "GET", target: "/api/admin/channels/export", wantStatus: fiber.StatusUnauthorized},
+		{name: "export invalid format", method: "GET", target: "/api/admin/channels/export?format=xml", header: "Bearer secret", wantStatus: fiber.StatusBadRequest},
+		{name: "export M3U", method: "GET", target: "/api/admin/channels/export?format=m3u&token=secret", wantStatus: fiber.StatusOK, wantBody: "https://example.com/b.m3u8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.header != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.header)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("%s %s error = %v", tt.method, tt.target, err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("%s %s = %d, want %d", tt.method, tt.target, resp.StatusCode, tt.wantStatus)
+			}
+			if resp.StatusCode != fiber.StatusOK {
+				return
+			}
+			if tt.method == "GET" {
+				body, err := io.ReadAll(resp.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.Contains(string(body), tt.wantBody) {
+					t.Errorf("export = %q, want it to contain %q", body, tt.wantBody)
+				}
+				if got := resp.Header.Get(fiber.HeaderContentDisposition); !strings.Contains(got, "custom-channels.m3u") {
+					t.Errorf("Content-Disposition = %q, want an attachment", got)
+				}
+				return
+			}
+			var result channelImportResponse
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(result.Channels) != tt.wantChannels {
+				t.Errorf("channels = %v, want %d", result.Channels, tt.wantChannels)
+			}
+			if (result.Result == nil) != (tt.wantResult == nil) || (tt.wantResult != nil && *result.Result != *tt.wantResult) {
+				t.Errorf("result = %+v, want %+v", result.Result, tt.wantResult)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// orderedChannel is a custom channel of the channel order API
+type orderedChannel struct {
+	ID     string `json:"channel_id"`
+	Name   string `json:"channel_name"`
+	Order  int    `json:"order"`
+	Pinned bool   `json:"pinned"`
+}
+
+// channelOrderRequest is the body of the channel order API. A list that is left out is not changed.
+type channelOrderRequest struct {
+	Order  []string `json:"order"`
+	Pinned []string `json:"pinned"`
+}
+
+// customChannelOrder returns the custom channels in their order.
+func customChannelOrder() fiber.Map {
+	channels := television.CustomChannelsInOrder()
+	ordered := make([]orderedChannel, 0, len(channels))
+	for _, channel := range channels {
+		ordered = append(ordered, orderedChannel{ID: channel.ID, Name: channel.Name, Order: channel.Order, Pinned: channel.Pinned})
+	}
+	return fiber.Map{"channels": ordered}
+}
+
+// ChannelOrderHandler lists the custom channels in their order on `GET /api/admin/channels/order`.
+func ChannelOrderHandler(c *fiber.Ctx) error {
+	if ok, err := checkAdminToken(c); !ok {
+		return err
+	}
+	return c.JSON(customChannelOrder())
+}
+
+// SetChannelOrderHandler reorders and pins custom channels on `POST /api/admin/channels/order`.
+// The body is a JSON object like {"order": ["cc_news", "cc_sports"], "pinned": ["cc_news"]}.
+func SetChannelOrderHandler(c *fiber.Ctx) error {
+	if ok, err := checkAdminToken(c); !ok {
+		return err
+	}
+	var body channelOrderRequest
+	if err := c.BodyParser(&body); err != nil {
+		return internalUtils.BadRequestError(c, "Invalid request body: "+err.Error())
+	}
+	if body.Order == nil && body.Pinned == nil {
+		return internalUtils.BadRequestError(c, "Set order or pinned")
+	}
+	if err := television.SetCustomChannelOrder(body.Order, body.Pinned); err != nil {
+		if errors.Is(err, television.ErrInvalidChannelOrder) {
+			return internalUtils.BadRequestError(c, err.Error())
+		}
+		utils.Log.Printf("ERROR: Failed to save the custom channel order: %v", err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	return c.JSON(customChannelOrder())
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+func TestChannelOrderHandlers(t *testing.T) {
+	originalToken := config.Cfg.AdminToken
+	originalFile := config.Cfg.CustomChannelsFile
+	defer func() {
+		config.Cfg.AdminToken = originalToken
+		config.Cfg.CustomChannelsFile = originalFile
+		television.ReloadCustomChannels()
+	}()
+
+	config.Cfg.AdminToken = "secret"
+	config.Cfg.CustomChannelsFile = filepath.Join(t.TempDir(), "custom-channels.json")
+	data := `{"channels": [{"id": "a", "name": "A", "url": "https://example.com/a.m3u8"}, {"id": "b", "name": "B", "url": "https://example.com/b.m3u8"}]}`
+	if err := os.WriteFile(config.Cfg.CustomChannelsFile, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	television.ReloadCustomChannels()
+
+	app := fiber.New()
+	app.Get("/api/admin/channels/order", ChannelOrderHandler)
+	app.Post("/api/admin/channels/order", SetChannelOrderHandler)
+
+	tests := []struct {
+		name       string
+		method     string
+		header     string
+		body       string
+		wantStatus int
+		wantOrder  []orderedChannel
+	}{
+		{name: "without token", method: "GET", wantStatus: fiber.StatusUnauthorized},
+		{
+			name: "list", method: "GET", header: "Bearer secret", wantStatus: fiber.StatusOK,
+			wantOrder: []orderedChannel{{ID: "cc_a", Name: "A"}, {ID: "cc_b", Name: "B"}},
+		},
+		{name: "empty body", method: "POST", header: "Bearer secret", body: `{}`, wantStatus: fiber.StatusBadRequest},
+		{name: "unknown channel", method: "POST", header: "Bearer secret", body: `{"order": ["x"]}`, wantStatus: fiber.StatusBadRequest},
+		{
+			name: "reorder and pin", method: "POST", header: "Bearer secret", body: `{"order": ["b", "a"], "pinned": ["cc_a"]}`, wantStatus: fiber.StatusOK,
+			wantOrder: []orderedChannel{{ID: "cc_b", Name: "B", Order: 1}, {ID: "cc_a", Name: "A", Order: 2, Pinned: true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/admin/channels/order", strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			if tt.header != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.header)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("%s error = %v", tt.method, err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("%s /api/admin/channels/order = %d, want %d", tt.method, resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantOrder == nil {
+				return
+			}
+			var result struct {
+				Channels []orderedChannel `json:"channels"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(result.Channels) != len(tt.wantOrder) {
+				t.Fatalf("channels = %+v, want %+v", result.Channels, tt.wantOrder)
+			}
+			for i := range tt.wantOrder {
+				if result.Channels[i] != tt.wantOrder[i] {
+					t.Errorf("channels = %+v, want %+v", result.Channels, tt.wantOrder)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestReorderChannelsForDisplayPinned(t *testing.T) {
+	originalFile := config.Cfg.CustomChannelsFile
+	defer func() {
+		config.Cfg.CustomChannelsFile = originalFile
+		television.ReloadCustomChannels()
+	}()
+
+	config.Cfg.CustomChannelsFile = filepath.Join(t.TempDir(), "custom-channels.json")
+	data := `{"channels": [{"id": "a", "name": "A", "url": "https://example.com/a.m3u8", "pinned": true}, {"id": "b", "name": "B", "url": "https://example.com/b.m3u8"}]}`
+	if err := os.WriteFile(config.Cfg.CustomChannelsFile, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	television.ReloadCustomChannels()
+
+	channels := []television.Channel{{ID: "143"}, {ID: "cc_b"}, {ID: "cc_a", Pinned: true}, {ID: "144"}}
+	var ids []string
+	for _, channel := range reorderChannelsForDisplay(channels) {
+		ids = append(ids, channel.ID)
+	}
+	if got, want := strings.Join(ids, ","), "cc_a,143,144,cc_b"; got != want {
+		t.Errorf("reorderChannelsForDisplay() = %s, want %s", got, want)
+	}
+}
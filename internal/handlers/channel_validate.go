@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+// channelValidation is the response of the custom channels validation API
+type channelValidation struct {
+	Valid    bool   `json:"valid"`
+	File     string `json:"file,omitempty"`
+	Channels int    `json:"channels"`
+	Errors   int    `json:"errors"`
+	Warnings int    `json:"warnings"`
+	// Error is set if the file cannot be parsed at all, e.g. invalid JSON
+	Error  string                    `json:"error,omitempty"`
+	Issues []television.ChannelIssue `json:"issues"`
+}
+
+// newChannelValidation validates custom channels data. name picks the format by its extension.
+func newChannelValidation(data []byte, name string) channelValidation {
+	result := channelValidation{Issues: []television.ChannelIssue{}}
+	customConfig, issues, err := television.ValidateCustomChannels(data, name)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Channels = len(customConfig.Channels)
+	for _, issue := range issues {
+		if issue.Level == television.IssueError {
+			result.Errors++
+		} else {
+			result.Warnings++
+		}
+	}
+	result.Valid = result.Errors == 0
+	if issues != nil {
+		result.Issues = issues
+	}
+	return result
+}
+
+// ValidateChannelsHandler checks the custom channels file on `GET /api/v1/channels/validate`.
+// Channels with errors are left out of the channel list, warnings are only reported.
+func ValidateChannelsHandler(c *fiber.Ctx) error {
+	filePath := config.Cfg.CustomChannelsFile
+	if filePath == "" {
+		return internalUtils.NotFoundError(c, "custom_channels_file is not set in the config")
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return internalUtils.NotFoundError(c, "The custom channels file does not exist: "+filepath.Base(filePath))
+		}
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	result := newChannelValidation(data, filePath)
+	result.File = filepath.Base(filePath)
+	return c.JSON(result)
+}
+
+// ValidateChannelsBodyHandler checks custom channels sent in the body on `POST /api/v1/channels/validate`,
+// so that a file can be checked before it is used. The format is JSON or YAML, as set by the format
+// query parameter or the Content-Type header, and else detected from the content.
+func ValidateChannelsBodyHandler(c *fiber.Ctx) error {
+	body := c.Body()
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return internalUtils.BadRequestError(c, "Send the custom channels JSON or YAML in the request body")
+	}
+	name := "request"
+	format := strings.ToLower(c.Query("format"))
+	contentType := strings.ToLower(c.Get(fiber.HeaderContentType))
+	switch {
+	case format == "json" || (format == "" && strings.Contains(contentType, "json")):
+		name += ".json"
+	case format == "yaml" || format == "yml" || (format == "" && strings.Contains(contentType, "yaml")):
+		name += ".yml"
+	case format != "":
+		return internalUtils.BadRequestError(c, "Invalid format. Use json or yaml.")
+	}
+	return c.JSON(newChannelValidation(body, name))
+}
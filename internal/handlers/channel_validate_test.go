@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+func TestValidateChannelsHandlers(t *testing.T) {
+	originalFile := config.Cfg.CustomChannelsFile
+	defer func() { config.Cfg.CustomChannelsFile = originalFile }()
+
+	file := filepath.Join(t.TempDir(), "custom-channels.json")
+	if err := os.WriteFile(file, []byte(`{"channels": [{"id": "a", "name": "A", "url": ""}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Get("/api/v1/channels/validate", ValidateChannelsHandler)
+	app.Post("/api/v1/channels/validate", ValidateChannelsBodyHandler)
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		file        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantValid   bool
+		wantErrors  int
+		wantError   bool
+	}{
+		{name: "no file configured", method: "GET", target: "/api/v1/channels/validate", wantStatus: fiber.StatusNotFound},
+		{name: "missing file", method: "GET", target: "/api/v1/channels/validate", file: file + ".missing", wantStatus: fiber.StatusNotFound},
+		{name: "configured file", method: "GET", target: "/api/v1/channels/validate", file: file, wantStatus: fiber.StatusOK, wantErrors: 1},
+		{
+			name: "valid YAML body", method: "POST", target: "/api/v1/channels/validate", contentType: "application/yaml",
+			body: "channels:\n  - id: a\n    name: A\n    url: https://example.com/a.m3u8\n", wantStatus: fiber.StatusOK, wantValid: true,
+		},
+		{
+			name: "invalid JSON body", method: "POST", target: "/api/v1/channels/validate?format=json",
+			body: `{"channels": [}`, wantStatus: fiber.StatusOK, wantError: true,
+		},
+		{name: "empty body", method: "POST", target: "/api/v1/channels/validate", wantStatus: fiber.StatusBadRequest},
+		{name: "invalid format", method: "POST", target: "/api/v1/channels/validate?format=xml", body: "<channels/>", wantStatus: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Cfg.CustomChannelsFile = tt.file
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set(fiber.HeaderContentType, tt.contentType)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("%s %s error = %v", tt.method, tt.target, err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("%s %s = %d, want %d", tt.method, tt.target, resp.StatusCode, tt.wantStatus)
+			}
+			if resp.StatusCode != fiber.StatusOK {
+				return
+			}
+			var result channelValidation
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if result.Valid != tt.wantValid || result.Errors != tt.wantErrors || (result.Error != "") != tt.wantError {
+				t.Errorf("response = %+v, want valid %v, %d errors, error %v", result, tt.wantValid, tt.wantErrors, tt.wantError)
+			}
+		})
+	}
+}
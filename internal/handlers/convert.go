@@ -0,0 +1,505 @@
+package handlers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/cdntoken"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/convert"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/dash"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/hls"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// conversionRefreshInterval is how long the upstream URL and the variants of a converted stream are reused
+	conversionRefreshInterval = 5 * time.Minute
+	// conversionIdleTimeout is how long a converted stream is kept after its last request
+	conversionIdleTimeout = 10 * time.Minute
+	// conversionSegmentTimeout is the timeout for downloading a segment of a converted stream
+	conversionSegmentTimeout = 30 * time.Second
+)
+
+// errConversionDisabled is returned when manifest conversion is not enabled in the config
+var errConversionDisabled = errors.New("manifest conversion is disabled")
+
+// conversion is a live channel of a tenant served in the other streaming format.
+type conversion struct {
+	mu       sync.Mutex
+	t        *tenant
+	id       string
+	lastUsed time.Time
+
+	// masterURL, variants and anchor are the state of an HLS stream served as DASH.
+	// The master playlist is resolved again after conversionRefreshInterval.
+	masterURL      string
+	masterResolved time.Time
+	variants       []hls.Variant
+	anchor         convert.Anchor
+	playlists      map[int]cachedPlaylist
+	keys           map[string][]byte
+
+	// manifestURL and manifest are the state of a DASH stream served as HLS
+	manifestURL      string
+	manifestResolved time.Time
+	manifest         *dash.MPD
+	manifestFetched  time.Time
+}
+
+// cachedPlaylist is a media playlist of a variant, reused for half its target duration.
+type cachedPlaylist struct {
+	segments       []hls.Segment
+	targetDuration int
+	fetched        time.Time
+}
+
+var (
+	conversions   = map[string]*conversion{}
+	conversionsMu sync.Mutex
+)
+
+// getConversion returns the converted stream of a channel, dropping streams that are no longer watched.
+func getConversion(t *tenant, id string) *conversion {
+	conversionsMu.Lock()
+	defer conversionsMu.Unlock()
+	now := time.Now()
+	for key, conv := range conversions {
+		conv.mu.Lock()
+		idle := now.Sub(conv.lastUsed) > conversionIdleTimeout
+		conv.mu.Unlock()
+		if idle {
+			delete(conversions, key)
+		}
+	}
+	key := t.name + "/" + id
+	conv, ok := conversions[key]
+	if !ok {
+		conv = &conversion{t: t, id: id, playlists: map[int]cachedPlaylist{}, keys: map[string][]byte{}}
+		conversions[key] = conv
+	}
+	conv.mu.Lock()
+	conv.lastUsed = now
+	conv.mu.Unlock()
+	return conv
+}
+
+// resolveHLS finds the master playlist of the channel and its variants. The caller holds c.mu.
+func (c *conversion) resolveHLS() error {
+	if c.masterURL != "" && time.Since(c.masterResolved) < conversionRefreshInterval {
+		return nil
+	}
+	c.t.ensureFreshCredentials()
+	liveResult, err := c.t.TV().Live(c.id)
+	if err != nil {
+		return err
+	}
+	liveURL := toAbsoluteStreamURL(selectLiveHLSURL(liveResult, liveQualityABR), liveResult)
+	if liveURL == "" {
+		return fmt.Errorf("no HLS stream found for channel %s", c.id)
+	}
+	if token := extractLiveResultHDNEA(liveResult); token != "" {
+		c.t.hdnea.Set(c.id, token)
+	}
+
+	playlist, err := c.fetchPlaylist(liveURL)
+	if err != nil {
+		return err
+	}
+	base, err := url.Parse(liveURL)
+	if err != nil {
+		return err
+	}
+	variants := []hls.Variant{{URL: liveURL}}
+	if playlist.Master {
+		variants = nil
+		for _, variant := range playlist.Variants(base) {
+			// Alternate audio renditions cannot be listed with the muxed MPEG-TS segments
+			if variant.Audio == "" {
+				variants = append(variants, variant)
+			}
+		}
+		if len(variants) == 0 {
+			return fmt.Errorf("channel %s has no variants with muxed audio", c.id)
+		}
+	}
+	c.masterURL, c.variants, c.masterResolved = liveURL, variants, time.Now()
+	c.playlists = map[int]cachedPlaylist{}
+	return nil
+}
+
+// fetchPlaylist fetches and parses an upstream HLS playlist.
+func (c *conversion) fetchPlaylist(playlistURL string) (*hls.Playlist, error) {
+	body, statusCode, newHdnea := c.t.TV().Render(playlistURL, c.t.hdnea.Get(c.id))
+	if newHdnea != "" {
+		c.t.hdnea.Set(c.id, newHdnea)
+	}
+	if statusCode != fiber.StatusOK {
+		return nil, fmt.Errorf("playlist of channel %s returned status %d", c.id, statusCode)
+	}
+	return hls.Parse(body)
+}
+
+// mediaPlaylist returns the segments of a variant. The caller holds c.mu.
+func (c *conversion) mediaPlaylist(variant int, refresh bool) (cachedPlaylist, error) {
+	if err := c.resolveHLS(); err != nil {
+		return cachedPlaylist{}, err
+	}
+	if variant < 0 || variant >= len(c.variants) {
+		return cachedPlaylist{}, fmt.Errorf("channel %s has no variant %d", c.id, variant)
+	}
+	cached, ok := c.playlists[variant]
+	if ok && !refresh && time.Since(cached.fetched) < time.Duration(cached.targetDuration)*time.Second/2 {
+		return cached, nil
+	}
+	variantURL := c.variants[variant].URL
+	playlist, err := c.fetchPlaylist(variantURL)
+	if err != nil {
+		// The token or the variant may have expired, so start again from the live URL
+		c.masterURL = ""
+		return cachedPlaylist{}, err
+	}
+	base, err := url.Parse(variantURL)
+	if err != nil {
+		return cachedPlaylist{}, err
+	}
+	cached = cachedPlaylist{segments: playlist.Segments(base), targetDuration: playlist.TargetDuration(), fetched: time.Now()}
+	c.playlists[variant] = cached
+	return cached, nil
+}
+
+// fetchChannelResource downloads an upstream segment or key of a channel with its cookies.
+func fetchChannelResource(t *tenant, id, uri string, setHeaders func(req *fasthttp.Request)) ([]byte, error) {
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	req.SetRequestURI(uri)
+	req.Header.Set("User-Agent", PLAYER_USER_AGENT)
+	cdntoken.HDNEA.SetCookie(&req.Header, t.hdnea.Get(id))
+	if setHeaders != nil {
+		setHeaders(req)
+	}
+
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+	if err := t.TV().Client.DoTimeout(req, resp, conversionSegmentTimeout); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", uri, resp.StatusCode())
+	}
+	return append([]byte(nil), resp.Body()...), nil
+}
+
+// fetchChannelKey fetches an AES-128 key of a channel with the headers JioTV authenticates key requests with.
+func fetchChannelKey(t *tenant, id, keyURL string) ([]byte, error) {
+	key, err := fetchChannelResource(t, id, keyURL, func(req *fasthttp.Request) {
+		for name, value := range t.TV().Headers {
+			req.Header.Set(name, value)
+		}
+		req.Header.Set("User-Agent", PLAYER_USER_AGENT)
+		req.Header.Set("srno", "230203144000")
+		req.Header.Set("ssotoken", t.TV().SsoToken)
+		req.Header.Set("channelId", id)
+		if parsed, err := url.Parse(keyURL); err == nil {
+			for name, values := range parsed.Query() {
+				req.Header.SetCookie(name, values[0])
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 16 {
+		return nil, fmt.Errorf("key of channel %s has %d bytes", id, len(key))
+	}
+	return key, nil
+}
+
+// key returns an AES-128 key of the channel. The caller holds c.mu.
+func (c *conversion) key(keyURL string) ([]byte, error) {
+	if key, ok := c.keys[keyURL]; ok {
+		return key, nil
+	}
+	key, err := fetchChannelKey(c.t, c.id, keyURL)
+	if err != nil {
+		return nil, err
+	}
+	c.keys[keyURL] = key
+	return key, nil
+}
+
+// decryptSegment decrypts an AES-128 segment, for players that cannot decrypt HLS segments themselves.
+func decryptSegment(data, key []byte, iv string) ([]byte, error) {
+	ivBytes, err := hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(iv, "0x"), "0X"))
+	if err != nil || len(ivBytes) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid IV %q", iv)
+	}
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, errors.New("encrypted segment is not a multiple of the block size")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	decrypted := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, ivBytes).CryptBlocks(decrypted, data)
+	// Remove the PKCS#7 padding
+	padding := int(decrypted[len(decrypted)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		return nil, errors.New("invalid padding")
+	}
+	return decrypted[:len(decrypted)-padding], nil
+}
+
+// conversionError writes the response for errors of converted streams.
+func conversionError(c *fiber.Ctx, id string, err error) error {
+	switch {
+	case errors.Is(err, errConversionDisabled):
+		return internalUtils.NotFoundError(c, err.Error())
+	case errors.Is(err, convert.ErrEncrypted):
+		return internalUtils.BadRequestError(c, "Channel "+id+" is DRM protected and cannot be converted")
+	case errors.Is(err, convert.ErrNoRepresentation):
+		return internalUtils.NotFoundError(c, err.Error())
+	}
+	utils.Log.Printf("WARN: Manifest conversion of channel %s failed: %v", id, err)
+	return internalUtils.UpstreamError(c, err)
+}
+
+// LiveDASHHandler serves a live channel as MPEG-DASH on `/live/:id.mpd`. HLS channels are described
+// by a manifest listing their MPEG-TS segments, and DASH channels are proxied through /render.mpd.
+func LiveDASHHandler(c *fiber.Ctx) error {
+	t := tenantOf(c)
+	id := c.Params("id")
+	if !config.Cfg.ManifestConversion {
+		return conversionError(c, id, errConversionDisabled)
+	}
+	if isCustomChannel(id) || isZee5Channel(id) {
+		return internalUtils.NotFoundError(c, "Channel "+id+" cannot be converted to DASH")
+	}
+	recordPlay(c, id)
+
+	conv := getConversion(t, id)
+	conv.mu.Lock()
+	defer conv.mu.Unlock()
+	if err := conv.resolveHLS(); err != nil {
+		// Channels without HLS play from their upstream manifest
+		if liveResult, liveErr := t.TV().Live(id); liveErr == nil && !liveResult.IsDRM {
+			if mpdURL := selectBestLiveMPDURL(liveResult, "auto"); mpdURL != "" {
+				encURL, encErr := secureurl.EncryptURL(mpdURL)
+				if encErr == nil {
+					return c.Redirect(tenantBase(c)+"/render.mpd?auth="+encURL+"&channel_id="+id, fiber.StatusFound)
+				}
+			}
+		}
+		return conversionError(c, id, err)
+	}
+	playlist, err := conv.mediaPlaylist(0, false)
+	if err != nil {
+		return conversionError(c, id, err)
+	}
+
+	manifest, anchor := convert.DASHFromHLS(conv.variants, playlist.segments, playlist.targetDuration,
+		requestHostURL(c)+"/dash/"+id+"/", conv.anchor, time.Now())
+	conv.anchor = anchor
+
+	internalUtils.SetMustRevalidateHeader(c, 1)
+	c.Response().Header.Set("Content-Type", "application/dash+xml")
+	c.Response().Header.Set("Access-Control-Allow-Origin", "*")
+	return c.Send(manifest)
+}
+
+// DASHSegmentHandler serves a segment of a live channel converted by LiveDASHHandler on
+// `/dash/:id/:variant/:segment.ts`. AES-128 segments are decrypted.
+func DASHSegmentHandler(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if !config.Cfg.ManifestConversion {
+		return conversionError(c, id, errConversionDisabled)
+	}
+	variant, err := strconv.Atoi(c.Params("variant"))
+	if err != nil {
+		return internalUtils.BadRequestError(c, "Invalid variant")
+	}
+	sequence, err := strconv.ParseInt(strings.TrimSuffix(c.Params("segment"), ".ts"), 10, 64)
+	if err != nil {
+		return internalUtils.BadRequestError(c, "Invalid segment")
+	}
+
+	conv := getConversion(tenantOf(c), id)
+	conv.mu.Lock()
+	segment, err := conv.findSegment(variant, sequence)
+	var key []byte
+	if err == nil && segment.Key != nil {
+		if segment.Key.Method != "AES-128" {
+			err = fmt.Errorf("segments encrypted with %s cannot be converted", segment.Key.Method)
+		} else {
+			key, err = conv.key(segment.Key.URL)
+		}
+	}
+	conv.mu.Unlock()
+	if err != nil {
+		return conversionError(c, id, err)
+	}
+
+	data, err := fetchChannelResource(conv.t, conv.id, segment.URL, nil)
+	if err == nil && key != nil {
+		data, err = decryptSegment(data, key, segment.Key.IV)
+	}
+	if err != nil {
+		return conversionError(c, id, err)
+	}
+	// Segments never change, only leave the live window
+	internalUtils.SetCacheHeader(c, 60)
+	c.Response().Header.Set("Content-Type", "video/mp2t")
+	c.Response().Header.Set("Access-Control-Allow-Origin", "*")
+	return c.Send(data)
+}
+
+// findSegment returns a segment of a variant, fetching the playlist again if the segment is newer
+// than the cached one. The caller holds c.mu.
+func (c *conversion) findSegment(variant int, sequence int64) (hls.Segment, error) {
+	for _, refresh := range []bool{false, true} {
+		playlist, err := c.mediaPlaylist(variant, refresh)
+		if err != nil {
+			return hls.Segment{}, err
+		}
+		for _, segment := range playlist.segments {
+			if segment.Sequence == sequence {
+				return segment, nil
+			}
+		}
+	}
+	return hls.Segment{}, fmt.Errorf("%w: segment %d of channel %s is not in the live window", convert.ErrNoRepresentation, sequence, c.id)
+}
+
+// resolveDASH finds the manifest of the channel and fetches it, at most once per second. The caller holds c.mu.
+func (c *conversion) resolveDASH() (*dash.MPD, *url.URL, error) {
+	if c.manifestURL == "" || time.Since(c.manifestResolved) >= conversionRefreshInterval {
+		c.t.ensureFreshCredentials()
+		liveResult, err := c.t.TV().Live(c.id)
+		if err != nil {
+			return nil, nil, err
+		}
+		if liveResult.IsDRM {
+			return nil, nil, convert.ErrEncrypted
+		}
+		mpdURL := selectBestLiveMPDURL(liveResult, "auto")
+		if mpdURL == "" {
+			return nil, nil, fmt.Errorf("no DASH stream found for channel %s", c.id)
+		}
+		if token := extractLiveResultHDNEA(liveResult); token != "" {
+			c.t.hdnea.Set(c.id, token)
+		}
+		c.manifestURL, c.manifestResolved, c.manifest = mpdURL, time.Now(), nil
+	}
+	manifestURL, err := url.Parse(c.manifestURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.manifest != nil && time.Since(c.manifestFetched) < time.Second {
+		return c.manifest, manifestURL, nil
+	}
+	body, statusCode, newHdnea := c.t.TV().Render(c.manifestURL, c.t.hdnea.Get(c.id))
+	if newHdnea != "" {
+		c.t.hdnea.Set(c.id, newHdnea)
+	}
+	if statusCode != fiber.StatusOK {
+		c.manifestURL = ""
+		return nil, nil, fmt.Errorf("manifest of channel %s returned status %d", c.id, statusCode)
+	}
+	manifest, err := dash.Decode(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.manifest, c.manifestFetched = manifest, time.Now()
+	return manifest, manifestURL, nil
+}
+
+// hlsConversionURL returns the path of the converted HLS playlist of a channel without an HLS stream,
+// or an empty string if manifest conversion is disabled or the channel cannot be converted.
+func hlsConversionURL(c *fiber.Ctx, id string, liveResult *television.LiveURLOutput) string {
+	if !config.Cfg.ManifestConversion || liveResult == nil || liveResult.IsDRM || selectBestLiveMPDURL(liveResult, "auto") == "" {
+		return ""
+	}
+	return tenantBase(c) + "/hls/" + id + "/index.m3u8"
+}
+
+// HLSMasterHandler serves a live DASH channel as HLS on `/hls/:id/index.m3u8`.
+// /live/:id.m3u8 redirects here for channels without an HLS stream.
+func HLSMasterHandler(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if !config.Cfg.ManifestConversion {
+		return conversionError(c, id, errConversionDisabled)
+	}
+	conv := getConversion(tenantOf(c), id)
+	conv.mu.Lock()
+	manifest, _, err := conv.resolveDASH()
+	conv.mu.Unlock()
+	if err != nil {
+		return conversionError(c, id, err)
+	}
+
+	hostURL := requestHostURL(c)
+	playlist, err := convert.HLSMasterFromDASH(manifest, time.Now(), func(representationID string) string {
+		return hostURL + "/hls/" + id + "/media.m3u8?rep=" + url.QueryEscape(representationID)
+	})
+	if err != nil {
+		return conversionError(c, id, err)
+	}
+	internalUtils.SetMustRevalidateHeader(c, 3)
+	c.Response().Header.Set("Content-Type", "application/vnd.apple.mpegurl")
+	c.Response().Header.Set("Access-Control-Allow-Origin", "*")
+	return c.Send(playlist)
+}
+
+// HLSMediaHandler serves the media playlist of a representation of a live DASH channel on
+// `/hls/:id/media.m3u8?rep=<representation id>`. Segments play through /render.dash.
+func HLSMediaHandler(c *fiber.Ctx) error {
+	t := tenantOf(c)
+	id := c.Params("id")
+	if !config.Cfg.ManifestConversion {
+		return conversionError(c, id, errConversionDisabled)
+	}
+	representationID := c.Query("rep")
+	if representationID == "" {
+		return internalUtils.BadRequestError(c, "rep query param is required")
+	}
+	if ok, err := admitStream(c, id); !ok {
+		return err
+	}
+	conv := getConversion(t, id)
+	conv.mu.Lock()
+	manifest, manifestURL, err := conv.resolveDASH()
+	conv.mu.Unlock()
+	if err != nil {
+		return conversionError(c, id, err)
+	}
+
+	hostURL := requestHostURL(c)
+	segments := newDashProxy(manifestURL, t.hdnea.Get(id))
+	playlist, err := convert.HLSMediaFromDASH(manifest, manifestURL, representationID, time.Now(), func(upstream string) string {
+		if proxied, ok := segments.url(upstream); ok {
+			return hostURL + proxied
+		}
+		return upstream
+	})
+	if err != nil {
+		return conversionError(c, id, err)
+	}
+	internalUtils.SetMustRevalidateHeader(c, 1)
+	c.Response().Header.Set("Content-Type", "application/vnd.apple.mpegurl")
+	c.Response().Header.Set("Access-Control-Allow-Origin", "*")
+	return c.Send(playlist)
+}
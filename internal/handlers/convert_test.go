@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+)
+
+func TestDecryptSegment(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	iv := []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 7}
+	plain := []byte("MPEG-TS segment data")
+
+	// Encrypt with PKCS#7 padding like HLS packagers do
+	padding := aes.BlockSize - len(plain)%aes.BlockSize
+	padded := append(append([]byte{}, plain...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	block, _ := aes.NewCipher(key)
+	encrypted := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, padded)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		key     []byte
+		iv      string
+		want    []byte
+		wantErr bool
+	}{
+		{name: "valid", data: encrypted, key: key, iv: "0x00000000000000000000000000000007", want: plain},
+		{name: "upper case prefix", data: encrypted, key: key, iv: "0X00000000000000000000000000000007", want: plain},
+		{name: "short IV", data: encrypted, key: key, iv: "0x07", wantErr: true},
+		{name: "not block aligned", data: encrypted[:len(encrypted)-1], key: key, iv: "0x00000000000000000000000000000007", wantErr: true},
+		{name: "empty", data: nil, key: key, iv: "0x00000000000000000000000000000007", wantErr: true},
+		{name: "invalid key", data: encrypted, key: key[:5], iv: "0x00000000000000000000000000000007", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decryptSegment(tt.data, tt.key, tt.iv)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decryptSegment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !bytes.Equal(got, tt.want) {
+				t.Errorf("decryptSegment() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/middleware"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/devices"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// deviceResponse is a device on `/api/admin/devices`, with the playlist URL that carries its token
+type deviceResponse struct {
+	devices.Device
+	PlaylistURL string `json:"playlist_url"`
+}
+
+// devicesResponse is the response of `/api/admin/devices`
+type devicesResponse struct {
+	// Enforced reports whether playback needs a token, without which device tokens have no effect
+	Enforced bool             `json:"enforced"`
+	Devices  []deviceResponse `json:"devices"`
+}
+
+// createDeviceRequest is the body of `POST /api/admin/devices`
+type createDeviceRequest struct {
+	Name string `json:"name" form:"name"`
+}
+
+// deviceTokensEnforced reports whether playback needs the web login or a device token.
+func deviceTokensEnforced() bool {
+	return middleware.WebLoginEnabled() && config.Cfg.WebProtectPlayback
+}
+
+// newDeviceResponse returns a device with the URL of its playlist.
+func newDeviceResponse(c *fiber.Ctx, device devices.Device) deviceResponse {
+	return deviceResponse{
+		Device:      device,
+		PlaylistURL: requestHostURL(c) + "/playlist.m3u?" + devices.TokenParam + "=" + url.QueryEscape(device.Token),
+	}
+}
+
+// DevicesAdminHandler renders the devices page on `/admin/devices`. The page loads the devices from
+// `/api/admin/devices` with the admin token or the web login session.
+func DevicesAdminHandler(c *fiber.Ctx) error {
+	return c.Render("views/admin_devices", fiber.Map{
+		"Title":    Title,
+		"Enforced": deviceTokensEnforced(),
+	})
+}
+
+// DevicesHandler lists the devices with playback tokens on `/api/admin/devices`.
+func DevicesHandler(c *fiber.Ctx) error {
+	if ok, err := checkAdminToken(c); !ok {
+		return err
+	}
+	response := devicesResponse{Enforced: deviceTokensEnforced(), Devices: []deviceResponse{}}
+	for _, device := range devices.Default.List() {
+		response.Devices = append(response.Devices, newDeviceResponse(c, device))
+	}
+	return c.JSON(response)
+}
+
+// CreateDeviceHandler adds a device with a new playback token on `POST /api/admin/devices`.
+func CreateDeviceHandler(c *fiber.Ctx) error {
+	if ok, err := checkAdminToken(c); !ok {
+		return err
+	}
+	var req createDeviceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return internalUtils.BadRequestError(c, "Invalid request: "+err.Error())
+	}
+	device, err := devices.Default.Create(req.Name)
+	if errors.Is(err, devices.ErrInvalidName) {
+		return internalUtils.BadRequestError(c, err.Error())
+	}
+	if err != nil {
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	if err := devices.Save(); err != nil {
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	utils.Log.Printf("INFO: Created a playback token for the device %q", device.Name)
+	return c.Status(fiber.StatusCreated).JSON(newDeviceResponse(c, device))
+}
+
+// RevokeDeviceHandler removes a device on `DELETE /api/admin/devices/:id`, so that its token and
+// the playlists with it stop working.
+func RevokeDeviceHandler(c *fiber.Ctx) error {
+	if ok, err := checkAdminToken(c); !ok {
+		return err
+	}
+	if !devices.Default.Revoke(c.Params("id")) {
+		return internalUtils.NotFoundError(c, "Device not found")
+	}
+	if err := devices.Save(); err != nil {
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	utils.Log.Printf("INFO: Revoked the playback token of the device %s", c.Params("id"))
+	return c.SendStatus(fiber.StatusNoContent)
+}
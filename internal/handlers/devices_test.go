@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/devices"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+func TestDevicesHandlers(t *testing.T) {
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, originalDevices := config.Cfg, devices.Default
+	defer func() {
+		config.Cfg, devices.Default = original, originalDevices
+		cleanup()
+	}()
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	config.Cfg.AdminToken = "admin"
+	devices.Default = devices.NewRegistry()
+
+	app := fiber.New()
+	app.Get("/api/admin/devices", DevicesHandler)
+	app.Post("/api/admin/devices", CreateDeviceHandler)
+	app.Delete("/api/admin/devices/:id", RevokeDeviceHandler)
+
+	request := func(method, target, body string, wantStatus int) *http.Response {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != wantStatus {
+			t.Fatalf("%s %s status = %d, want %d", method, target, resp.StatusCode, wantStatus)
+		}
+		return resp
+	}
+
+	request(http.MethodGet, "/api/admin/devices", "", fiber.StatusUnauthorized)
+	request(http.MethodPost, "/api/admin/devices?token=admin", `{"name":""}`, fiber.StatusBadRequest)
+
+	var created deviceResponse
+	resp := request(http.MethodPost, "/api/admin/devices?token=admin", `{"name":"TV"}`, fiber.StatusCreated)
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("decoding the device: %v", err)
+	}
+	if want := "/playlist.m3u?device_token=" + created.Token; !strings.HasSuffix(created.PlaylistURL, want) {
+		t.Errorf("playlist URL = %q, want a suffix %q", created.PlaylistURL, want)
+	}
+
+	var list devicesResponse
+	resp = request(http.MethodGet, "/api/admin/devices?token=admin", "", fiber.StatusOK)
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatalf("decoding the devices: %v", err)
+	}
+	if len(list.Devices) != 1 || list.Devices[0].ID != created.ID {
+		t.Errorf("devices = %+v, want the created device", list.Devices)
+	}
+
+	request(http.MethodDelete, "/api/admin/devices/"+created.ID+"?token=admin", "", fiber.StatusNoContent)
+	request(http.MethodDelete, "/api/admin/devices/"+created.ID+"?token=admin", "", fiber.StatusNotFound)
+	if devices.Default.Valid(created.Token) {
+		t.Error("the token of a revoked device should not be valid")
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants/urls"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// Statuses of a diagnostic check
+const (
+	DiagnosticOK   = "ok"
+	DiagnosticWarn = "warn"
+	DiagnosticFail = "fail"
+)
+
+// diagnosticHosts are the JioTV API and CDN hosts that must resolve for channels to play
+var diagnosticHosts = []string{
+	urls.JioTVAPIDomain,
+	urls.TVMediaDomain,
+	urls.AuthMediaDomain,
+	urls.JioTVCDNDomain,
+	urls.JioTVDataCDNDomain,
+	urls.JioTVCatchupCDNDomain,
+	"jiotvmblive.cdn.jio.com",
+}
+
+// lookupHost resolves a host name, replaced in tests
+var lookupHost = net.LookupHost
+
+// DiagnosticCheck is the result of a check of the diagnose command.
+type DiagnosticCheck struct {
+	Name   string
+	Status string
+	// Detail explains the status, and how to fix a failure
+	Detail   string
+	Duration time.Duration
+}
+
+// Diagnose checks the login, proxies, DNS, channels API and playback of the channel, in the
+// order a channel is played, so that the first failure points to the cause of playback issues.
+func Diagnose(channelID string) []DiagnosticCheck {
+	checks := []struct {
+		name string
+		run  func() (string, string)
+	}{
+		{"Login", diagnoseLogin},
+		{"Proxy", diagnoseProxy},
+		{"DNS", func() (string, string) { return diagnoseDNS(diagnosticHosts) }},
+		{"Channels API", diagnoseChannels},
+		{"Playback", func() (string, string) { return diagnosePlayback(channelID) }},
+	}
+	results := make([]DiagnosticCheck, 0, len(checks))
+	for _, check := range checks {
+		started := time.Now()
+		status, detail := check.run()
+		results = append(results, DiagnosticCheck{Name: check.name, Status: status, Detail: detail, Duration: time.Since(started)})
+	}
+	return results
+}
+
+// diagnoseLogin checks that credentials are saved and that expired tokens can be refreshed.
+func diagnoseLogin() (string, string) {
+	credentials, err := utils.GetJIOTVCredentials()
+	if err != nil || credentials == nil || (credentials.AccessToken == "" && credentials.SSOToken == "") {
+		return DiagnosticFail, "Not logged in. Log in on the web interface or with `jiotv_go login otp`."
+	}
+	if err := defaultTenant.ensureFreshTokens(); err != nil {
+		return DiagnosticFail, fmt.Sprintf("Tokens could not be refreshed: %v. Log in again.", err)
+	}
+	return DiagnosticOK, "Logged in"
+}
+
+// diagnoseProxy validates the configured proxies and checks that they reach the JioTV API.
+func diagnoseProxy() (string, string) {
+	checks := utils.CheckProxies()
+	if len(checks) == 0 {
+		return DiagnosticOK, "No proxy configured"
+	}
+	var failed, details []string
+	for _, check := range checks {
+		if check.Err != nil {
+			failed = append(failed, check.Proxy)
+			details = append(details, fmt.Sprintf("%s: %v", check.Proxy, check.Err))
+		} else {
+			details = append(details, check.Proxy+": ok")
+		}
+	}
+	switch {
+	case len(failed) == len(checks):
+		return DiagnosticFail, strings.Join(details, "; ")
+	case len(failed) > 0:
+		return DiagnosticWarn, strings.Join(details, "; ")
+	}
+	return DiagnosticOK, strings.Join(details, "; ")
+}
+
+// diagnoseDNS resolves the hosts, to find DNS blocking by the network or an ad blocker.
+func diagnoseDNS(hosts []string) (string, string) {
+	var failed []string
+	for _, host := range hosts {
+		if _, err := lookupHost(host); err != nil {
+			failed = append(failed, host)
+		}
+	}
+	if len(failed) > 0 {
+		return DiagnosticFail, fmt.Sprintf("Could not resolve %s. Check your DNS server or ad blocker.", strings.Join(failed, ", "))
+	}
+	return DiagnosticOK, fmt.Sprintf("Resolved %d JioTV hosts", len(hosts))
+}
+
+// diagnoseChannels fetches the channel list.
+func diagnoseChannels() (string, string) {
+	apiResponse, err := television.Channels()
+	if err != nil {
+		return DiagnosticFail, fmt.Sprintf("Failed to fetch channels: %v", err)
+	}
+	if len(apiResponse.Result) == 0 {
+		return DiagnosticFail, "The channels API returned no channels"
+	}
+	return DiagnosticOK, fmt.Sprintf("%d channels", len(apiResponse.Result))
+}
+
+// diagnosePlayback fetches the live URL and the playlist of the channel, like a player does.
+func diagnosePlayback(channelID string) (string, string) {
+	if channelID == "" || isCustomChannel(channelID) || isZee5Channel(channelID) {
+		return DiagnosticWarn, fmt.Sprintf("Skipped, %q is not a JioTV channel", channelID)
+	}
+	t := defaultTenant
+	liveResult, err := t.TV().Live(channelID)
+	if err != nil {
+		return DiagnosticFail, fmt.Sprintf("Failed to get the stream of channel %s: %v", channelID, err)
+	}
+	liveURL := toAbsoluteStreamURL(selectBestLiveHLSURL(liveResult, "auto"), liveResult)
+	if liveURL == "" {
+		return DiagnosticFail, fmt.Sprintf("Channel %s has no HLS stream", channelID)
+	}
+	hdnea := extractLiveResultHDNEA(liveResult)
+	if hdnea == "" {
+		hdnea = t.hdnea.Get(channelID)
+	}
+	if _, statusCode, _ := t.TV().Render(liveURL, hdnea); statusCode != fiber.StatusOK {
+		return DiagnosticFail, fmt.Sprintf("The stream of channel %s returned status %d", channelID, statusCode)
+	}
+	return DiagnosticOK, fmt.Sprintf("Channel %s plays", channelID)
+}
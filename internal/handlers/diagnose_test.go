@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDiagnoseDNS(t *testing.T) {
+	original := lookupHost
+	defer func() { lookupHost = original }()
+	lookupHost = func(host string) ([]string, error) {
+		if host == "blocked.jio.com" {
+			return nil, errors.New("no such host")
+		}
+		return []string{"127.0.0.1"}, nil
+	}
+
+	if status, detail := diagnoseDNS([]string{"a.jio.com", "b.jio.com"}); status != DiagnosticOK {
+		t.Errorf("diagnoseDNS() = %s, %q, want ok", status, detail)
+	}
+	status, detail := diagnoseDNS([]string{"a.jio.com", "blocked.jio.com"})
+	if status != DiagnosticFail || !strings.Contains(detail, "blocked.jio.com") {
+		t.Errorf("diagnoseDNS() = %s, %q, want a failure naming blocked.jio.com", status, detail)
+	}
+}
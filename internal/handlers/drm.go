@@ -2,52 +2,51 @@ package handlers
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
-	"regexp"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/proxy"
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/headers"
 	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/cdntoken"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/dash"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 	"github.com/valyala/fasthttp"
 )
 
-var (
-	// tokenRefreshLock prevents concurrent TV object modifications from race conditions
-	tokenRefreshLock sync.Mutex
-	// nextCredentialValidationTime tracks when token validity should be checked next.
-	// This prevents rechecking/re-refreshing on every request.
-	nextCredentialValidationTime time.Time
-)
-
 // EnsureFreshCredentials refreshes tokens proactively before they expire
 // This function prevents 403 errors by keeping credentials always fresh
 // Returns true if tokens are fresh (either just refreshed or cached)
 func EnsureFreshCredentials() bool {
-	tokenRefreshLock.Lock()
-	defer tokenRefreshLock.Unlock()
+	return defaultTenant.ensureFreshCredentials()
+}
+
+// ensureFreshCredentials refreshes the tokens of the tenant proactively before they expire
+func (t *tenant) ensureFreshCredentials() bool {
+	t.tokenRefreshLock.Lock()
+	defer t.tokenRefreshLock.Unlock()
 
 	now := time.Now()
-	if !nextCredentialValidationTime.IsZero() && now.Before(nextCredentialValidationTime) {
+	if !t.nextCredentialValidationTime.IsZero() && now.Before(t.nextCredentialValidationTime) {
 		return true
 	}
 
-	credentials, err := utils.GetJIOTVCredentials()
+	credentials, err := t.account.Credentials()
 	if err != nil || credentials == nil {
-		nextCredentialValidationTime = now.Add(credentialRefreshRetryBackoff)
+		t.nextCredentialValidationTime = now.Add(credentialRefreshRetryBackoff)
 		if os.Getenv("JIOTV_DEBUG") == "true" && err != nil {
 			utils.Log.Printf("[DEBUG] EnsureFreshCredentials: failed to load credentials: %v", err)
 		}
 		return true
 	}
+	t.adoptStoredTokens(credentials)
 
 	refreshAccessToken := credentials.AccessToken != "" && credentials.RefreshToken != "" && shouldRefreshToken(
 		credentials.AccessToken,
@@ -68,27 +67,42 @@ func EnsureFreshCredentials() bool {
 	)
 
 	if !refreshAccessToken && !refreshSSOToken {
-		nextCredentialValidationTime = calculateNextCredentialValidationTime(credentials, now)
+		t.nextCredentialValidationTime = calculateNextCredentialValidationTime(credentials, now)
 		return true
 	}
 
-	return performTokenRefresh(refreshAccessToken, refreshSSOToken, now)
+	return t.performTokenRefresh(refreshAccessToken, refreshSSOToken, now)
+}
+
+// adoptStoredTokens switches the JioTV client to the tokens in the store when they differ, as
+// when another server sharing the store refreshed them.
+func (t *tenant) adoptStoredTokens(credentials *utils.JIOTV_CREDENTIALS) {
+	tv := t.TV()
+	if tv != nil && tv.AccessToken == credentials.AccessToken && tv.SsoToken == credentials.SSOToken {
+		return
+	}
+	t.setTV(credentials)
 }
 
 // ForceRefreshCredentials bypasses proactive validity checks and forces immediate refresh
 // Use this only in error recovery paths when we know tokens have failed
 func ForceRefreshCredentials() bool {
-	tokenRefreshLock.Lock()
-	defer tokenRefreshLock.Unlock()
+	return defaultTenant.forceRefreshCredentials()
+}
+
+// forceRefreshCredentials forces an immediate refresh of the tokens of the tenant
+func (t *tenant) forceRefreshCredentials() bool {
+	t.tokenRefreshLock.Lock()
+	defer t.tokenRefreshLock.Unlock()
 	now := time.Now()
 
 	if os.Getenv("JIOTV_DEBUG") == "true" {
 		utils.Log.Printf("[DEBUG] FORCED token refresh (bypassing expiry checks)")
 	}
 
-	credentials, err := utils.GetJIOTVCredentials()
+	credentials, err := t.account.Credentials()
 	if err != nil || credentials == nil {
-		nextCredentialValidationTime = now.Add(credentialRefreshRetryBackoff)
+		t.nextCredentialValidationTime = now.Add(credentialRefreshRetryBackoff)
 		if os.Getenv("JIOTV_DEBUG") == "true" && err != nil {
 			utils.Log.Printf("[DEBUG] ForceRefreshCredentials: failed to load credentials: %v", err)
 		}
@@ -99,22 +113,22 @@ func ForceRefreshCredentials() bool {
 	refreshSSOToken := credentials.SSOToken != "" && credentials.UniqueID != ""
 
 	if !refreshAccessToken && !refreshSSOToken {
-		nextCredentialValidationTime = now.Add(credentialRefreshRetryBackoff)
+		t.nextCredentialValidationTime = now.Add(credentialRefreshRetryBackoff)
 		return false
 	}
 
-	return performTokenRefresh(refreshAccessToken, refreshSSOToken, now)
+	return t.performTokenRefresh(refreshAccessToken, refreshSSOToken, now)
 }
 
 // performTokenRefresh does the actual token refresh work (must be called with lock held)
-func performTokenRefresh(refreshAccessToken, refreshSSOToken bool, now time.Time) bool {
+func (t *tenant) performTokenRefresh(refreshAccessToken, refreshSSOToken bool, now time.Time) bool {
 	var accessTokenErr error
 	var ssoTokenErr error
 	var refreshed bool
 
 	// CRITICAL REFRESH #1: Refresh AccessToken
 	if refreshAccessToken {
-		accessTokenErr = LoginRefreshAccessToken()
+		accessTokenErr = t.refreshAccessToken()
 		if accessTokenErr != nil {
 			if os.Getenv("JIOTV_DEBUG") == "true" {
 				utils.Log.Printf("[DEBUG] AccessToken refresh error: %v", accessTokenErr)
@@ -129,7 +143,7 @@ func performTokenRefresh(refreshAccessToken, refreshSSOToken bool, now time.Time
 
 	// CRITICAL REFRESH #2: Refresh SSOToken
 	if refreshSSOToken {
-		ssoTokenErr = LoginRefreshSSOToken()
+		ssoTokenErr = t.refreshSSOToken()
 		if ssoTokenErr != nil {
 			if os.Getenv("JIOTV_DEBUG") == "true" {
 				utils.Log.Printf("[DEBUG] SSOToken refresh error: %v", ssoTokenErr)
@@ -143,19 +157,19 @@ func performTokenRefresh(refreshAccessToken, refreshSSOToken bool, now time.Time
 	}
 
 	if refreshed {
-		freshCreds, freshErr := utils.GetJIOTVCredentials()
+		freshCreds, freshErr := t.account.Credentials()
 		if freshErr == nil && freshCreds != nil {
-			nextCredentialValidationTime = calculateNextCredentialValidationTime(freshCreds, now)
+			t.nextCredentialValidationTime = calculateNextCredentialValidationTime(freshCreds, now)
 		} else {
-			nextCredentialValidationTime = now.Add(minCredentialValidationInterval)
+			t.nextCredentialValidationTime = now.Add(minCredentialValidationInterval)
 		}
 		if os.Getenv("JIOTV_DEBUG") == "true" {
-			utils.Log.Printf("[DEBUG] Token refresh cycle completed. Next validation at %s", nextCredentialValidationTime.Format(time.RFC3339))
+			utils.Log.Printf("[DEBUG] Token refresh cycle completed. Next validation at %s", t.nextCredentialValidationTime.Format(time.RFC3339))
 		}
 		return true
 	}
 
-	nextCredentialValidationTime = now.Add(credentialRefreshRetryBackoff)
+	t.nextCredentialValidationTime = now.Add(credentialRefreshRetryBackoff)
 
 	// Both refreshes failed - log comprehensive error
 	if os.Getenv("JIOTV_DEBUG") == "true" {
@@ -205,13 +219,13 @@ func calculateNextCredentialValidationTime(credentials *utils.JIOTV_CREDENTIALS,
 }
 
 // getDrmMpd returns required properties for rendering DRM MPD
-func getDrmMpd(channelID, quality string) (*DrmMpdOutput, error) {
+func getDrmMpd(t *tenant, channelID, quality string) (*DrmMpdOutput, error) {
 	// Get live stream URL from JioTV API
-	liveResult, err := TV.Live(channelID)
+	liveResult, err := t.TV().Live(channelID)
 	if err != nil {
 		return nil, err
 	}
-	if refreshedResult, refreshErr := refreshLiveResultIfNeeded(channelID, liveResult); refreshErr == nil && refreshedResult != nil {
+	if refreshedResult, refreshErr := refreshLiveResultIfNeeded(t, channelID, liveResult); refreshErr == nil && refreshedResult != nil {
 		liveResult = refreshedResult
 	}
 
@@ -299,6 +313,7 @@ func getDrmMpd(channelID, quality string) (*DrmMpdOutput, error) {
 
 // LiveMpdHandler handles live stream routes /mpd/:channelID
 func LiveMpdHandler(c *fiber.Ctx) error {
+	t := tenantOf(c)
 	// Get channel ID from URL
 	channelID := c.Params("channelID")
 	quality := c.Query("q")
@@ -306,6 +321,7 @@ func LiveMpdHandler(c *fiber.Ctx) error {
 	if quality == "" {
 		quality = "auto"
 	}
+	quality = television.CapQuality(quality, television.ChannelQualityCap(channelID))
 	if playerMode == "" {
 		playerMode = "auto" // Default to auto mode
 	}
@@ -323,7 +339,7 @@ func LiveMpdHandler(c *fiber.Ctx) error {
 	}
 
 	if !isTrustedPlaybackOrigin(c) {
-		playURL := utils.BuildHLSPlayURL(quality, channelID)
+		playURL := signLiveLink(utils.BuildHLSPlayURL(quality, channelID))
 		internalUtils.SetCacheHeader(c, 3600)
 		return c.Render("views/player_hls", fiber.Map{
 			"play_url":          playURL,
@@ -332,18 +348,18 @@ func LiveMpdHandler(c *fiber.Ctx) error {
 	}
 
 	// Ensure tokens are fresh before requesting MPD
-	EnsureFreshCredentials()
+	t.ensureFreshCredentials()
 
-	drmMpdOutput, err := getDrmMpd(channelID, quality)
+	drmMpdOutput, err := getDrmMpd(t, channelID, quality)
 
 	// If getting DRM MPD failed, try refreshing tokens forcefully and retry with multiple attempts
-	if err != nil {
+	if err != nil && !errors.Is(err, utils.ErrCircuitOpen) && !errors.Is(err, utils.ErrRateLimited) {
 		utils.Log.Printf("First attempt to get DRM MPD failed: %v. Attempting recovery with forced credentials refresh...", err)
 
 		// Force refresh credentials (bypasses 30-second interval for error recovery)
-		if ForceRefreshCredentials() {
+		if t.forceRefreshCredentials() {
 			// Retry getDrmMpd with fresh tokens
-			drmMpdOutput, err = getDrmMpd(channelID, quality)
+			drmMpdOutput, err = getDrmMpd(t, channelID, quality)
 			if err == nil {
 				utils.Log.Println("Retry successful after forced token refresh")
 				return nil // Early return - success
@@ -369,14 +385,14 @@ func LiveMpdHandler(c *fiber.Ctx) error {
 
 	if err != nil || drmMpdOutput == nil || drmMpdOutput.PlayUrl == "" {
 		// Use requested quality (default high) for HLS fallback to ensure best available quality first
-		play_url := utils.BuildHLSPlayURL(quality, channelID)
+		play_url := signLiveLink(utils.BuildHLSPlayURL(quality, channelID))
 		internalUtils.SetCacheHeader(c, 3600)
 		return c.Render("views/player_hls", fiber.Map{
 			"play_url": play_url,
 		})
 	}
 
-	hlsFallbackURL := utils.BuildHLSPlayURL(quality, channelID)
+	hlsFallbackURL := signLiveLink(utils.BuildHLSPlayURL(quality, channelID))
 	hlsPlayerFallbackURL := "/player/" + channelID + "?q=" + quality + "&af=1"
 
 	return c.Render("views/player_drm", fiber.Map{
@@ -401,6 +417,7 @@ func generateDateTime() string {
 
 // DRMKeyHandler handles DRM key routes /drm?auth=xxx
 func DRMKeyHandler(c *fiber.Ctx) error {
+	t := tenantOf(c)
 	// Get auth token from URL
 	auth := c.Query("auth")
 	channel := c.Query("channel")
@@ -408,7 +425,7 @@ func DRMKeyHandler(c *fiber.Ctx) error {
 
 	decoded_channel, err := internalUtils.DecryptURLParam("channel", channel)
 	if err != nil {
-		utils.Log.Panicln(err)
+		utils.Log.Println(err)
 		return internalUtils.ForbiddenError(c, err)
 	}
 
@@ -436,36 +453,36 @@ func DRMKeyHandler(c *fiber.Ctx) error {
 
 	decoded_url, err := internalUtils.DecryptURLParam("auth", auth)
 	if err != nil {
-		utils.Log.Panicln(err)
+		utils.Log.Println(err)
 		return internalUtils.ForbiddenError(c, err)
 	}
 
 	// Add headers to the request
-	c.Request().Header.Set("accesstoken", TV.AccessToken)
+	c.Request().Header.Set("accesstoken", t.TV().AccessToken)
 	c.Request().Header.Set("Connection", "keep-alive")
 	c.Request().Header.Set("os", "android")
 	c.Request().Header.Set("appName", "RJIL_JioTV")
-	c.Request().Header.Set("subscriberId", TV.Crm)
+	c.Request().Header.Set("subscriberId", t.TV().Crm)
 	c.Request().Header.Set("User-Agent", PLAYER_USER_AGENT)
-	c.Request().Header.Set("ssotoken", TV.SsoToken)
+	c.Request().Header.Set("ssotoken", t.TV().SsoToken)
 	c.Request().Header.Set("x-platform", "android")
 	c.Request().Header.Set("srno", generateDateTime())
-	c.Request().Header.Set("crmid", TV.Crm)
+	c.Request().Header.Set("crmid", t.TV().Crm)
 	c.Request().Header.Set("channelid", channel_id)
-	c.Request().Header.Set("uniqueId", TV.UniqueID)
+	c.Request().Header.Set("uniqueId", t.TV().UniqueID)
 	c.Request().Header.Set("versionCode", headers.VersionCode389)
 	c.Request().Header.Set("usergroup", "tvYR7NSNn7rymo3F")
 	c.Request().Header.Set("devicetype", "phone")
 	c.Request().Header.Set("Accept-Encoding", "gzip, deflate")
 	c.Request().Header.Set("osVersion", "13")
-	c.Request().Header.Set("deviceId", utils.GetDeviceID())
+	c.Request().Header.Set("deviceId", t.account.DeviceID())
 	c.Request().Header.Set("Content-Type", "application/octet-stream")
 
 	// Remove headers
 	c.Request().Header.Del("Accept")
 	c.Request().Header.Del("Origin")
 
-	if err := proxy.Do(c, decoded_url, TV.Client); err != nil {
+	if err := proxy.Do(c, decoded_url, t.TV().Client); err != nil {
 		return err
 	}
 
@@ -475,8 +492,9 @@ func DRMKeyHandler(c *fiber.Ctx) error {
 
 // MpdHandler handles BPK proxy routes /bpk/:channelID
 func MpdHandler(c *fiber.Ctx) error {
+	t := tenantOf(c)
 	// CRITICAL: Refresh credentials before proxying MPD
-	EnsureFreshCredentials()
+	t.ensureFreshCredentials()
 
 	channelID := c.Query("channel_id")
 	quality := c.Query("q")
@@ -486,9 +504,10 @@ func MpdHandler(c *fiber.Ctx) error {
 		return fmt.Errorf("auth query param is required")
 	}
 
-	decryptedUrl, err := secureurl.DecryptURL(proxyUrl)
+	// Players reload live manifests for as long as the channel plays, so the URL works past url_ttl while it is reloaded
+	decryptedUrl, err := secureurl.DecryptReloadURL(proxyUrl)
 	if err != nil {
-		utils.Log.Panicln(err)
+		utils.Log.Println(err)
 		return err
 	}
 	parsedUrl, err := url.Parse(decryptedUrl)
@@ -498,7 +517,10 @@ func MpdHandler(c *fiber.Ctx) error {
 	}
 
 	if channelID != "" {
-		if liveResult, liveErr := TV.Live(channelID); liveErr == nil && liveResult != nil {
+		if ok, err := admitStream(c, channelID); !ok {
+			return err
+		}
+		if liveResult, liveErr := t.TV().Live(channelID); liveErr == nil && liveResult != nil {
 			if freshUrl := selectBestLiveMPDURL(liveResult, quality); freshUrl != "" {
 				decryptedUrl = freshUrl
 				parsedUrl, err = url.Parse(decryptedUrl)
@@ -511,33 +533,12 @@ func MpdHandler(c *fiber.Ctx) error {
 	}
 
 	proxyHost := parsedUrl.Host
-	pathParts := strings.Split(parsedUrl.Path, "/")
-	basePath := strings.Join(pathParts[:len(pathParts)-1], "/") + "/"
-	encProxyHost, err := secureurl.EncryptURL(proxyHost)
-	if err != nil {
-		utils.Log.Panicln(err)
-		return err
-	}
-	encProxyPath, err := secureurl.EncryptURL(basePath)
-	if err != nil {
-		utils.Log.Panicln(err)
-		return err
-	}
 
-	// Extract channel_id and cached HDNEA if available from query params
+	// Extract cached HDNEA if available from query params
 	// This allows DashHandler to use the same auth context
-	var cachedHDNEA string
-	if chd := c.Query("hdnea"); chd != "" {
-		cachedHDNEA = chd
-	}
-
-	dashBaseURL := fmt.Sprintf("/render.dash/host/%s/path/%s", encProxyHost, encProxyPath)
-	if cachedHDNEA != "" {
-		encHDNEA, encErr := secureurl.EncryptURL("__hdnea__=" + cachedHDNEA)
-		if encErr == nil {
-			dashBaseURL = fmt.Sprintf("/render.dash/host/%s/path/%s/hdnea/%s", encProxyHost, encProxyPath, encHDNEA)
-		}
-	}
+	hdnea := c.Query(cdntoken.HDNEA.Param)
+	// The proxy replaces the request URI, so the query is kept for the Location of the manifest
+	query, _ := url.ParseQuery(string(c.Request().URI().QueryString()))
 
 	// proxyQuery := parsedUrl.RawQuery
 
@@ -552,7 +553,7 @@ func MpdHandler(c *fiber.Ctx) error {
 	c.Request().Header.Del("Accept-Encoding")
 
 	// AGGRESSIVE REFRESH: Make initial proxy request
-	if err := proxy.Do(c, requestUrl, TV.Client); err != nil {
+	if err := proxy.Do(c, requestUrl, t.TV().Client); err != nil {
 		return err
 	}
 
@@ -565,12 +566,12 @@ func MpdHandler(c *fiber.Ctx) error {
 
 		// Reset response to allow retry
 		c.Response().Reset()
-		ForceRefreshCredentials()
+		t.forceRefreshCredentials()
 
 		// Strip HDNEA token and retry - CDN will provide fresh auth
 		// HDNEA tokens are CDN-managed and expire, so requesting without them
 		// forces CDN to issue fresh auth
-		strippedUrl := stripHDNEAFromURL(decryptedUrl)
+		strippedUrl := cdntoken.HDNEA.Strip(decryptedUrl)
 
 		if os.Getenv("JIOTV_DEBUG") == "true" {
 			if strippedUrl != requestUrl {
@@ -580,7 +581,7 @@ func MpdHandler(c *fiber.Ctx) error {
 			}
 		}
 
-		if err := proxy.Do(c, strippedUrl, TV.Client); err != nil {
+		if err := proxy.Do(c, strippedUrl, t.TV().Client); err != nil {
 			if os.Getenv("JIOTV_DEBUG") == "true" {
 				utils.Log.Printf("[DEBUG] MpdHandler retry failed: %v", err)
 			}
@@ -594,40 +595,19 @@ func MpdHandler(c *fiber.Ctx) error {
 
 	c.Response().Header.Del(fiber.HeaderServer)
 
-	// Extract __hdnea__ from upstream response for injecting into dashBaseURL
-	upstreamHDNEA := ""
-
-	// Try to extract from Set-Cookie header first
-	setCookie := c.Response().Header.Peek("Set-Cookie")
-	if setCookie != nil {
-		setCookieStr := string(setCookie)
-		// Parse Set-Cookie: name=value; attributes...
-		// Look for __hdnea__=value
-		if strings.Contains(setCookieStr, "__hdnea__=") {
-			parts := strings.Split(setCookieStr, ";")
-			for _, part := range parts {
-				trimmed := strings.TrimSpace(part)
-				if strings.HasPrefix(trimmed, "__hdnea__=") {
-					upstreamHDNEA = strings.TrimPrefix(trimmed, "__hdnea__=")
-					break
-				}
-			}
-		}
-	}
+	// Extract __hdnea__ from upstream response for injecting into the segment URLs
+	upstreamHDNEA := cdntoken.HDNEA.FromResponse(&c.Response().Header)
 
-	// If we got a fresh __hdnea__ from upstream, update dashBaseURL with it
+	// If we got a fresh __hdnea__ from upstream, pass it on to the segment URLs
 	if upstreamHDNEA != "" {
 		// Update the cache with fresh HDNEA token for subsequent requests
 		if channelID != "" {
-			setCachedHDNEA(channelID, upstreamHDNEA)
+			t.hdnea.Set(channelID, upstreamHDNEA)
 			if os.Getenv("JIOTV_DEBUG") == "true" {
 				utils.Log.Printf("[DEBUG] Updated HDNEA cache for channel %s with fresh token from Set-Cookie", channelID)
 			}
 		}
-		encHDNEA, encErr := secureurl.EncryptURL("__hdnea__=" + upstreamHDNEA)
-		if encErr == nil {
-			dashBaseURL = fmt.Sprintf("/render.dash/host/%s/path/%s/hdnea/%s", encProxyHost, encProxyPath, encHDNEA)
-		}
+		hdnea = upstreamHDNEA
 	}
 
 	// Delete Domain from cookies
@@ -642,29 +622,110 @@ func MpdHandler(c *fiber.Ctx) error {
 		// Modify Set-Cookie header
 		c.Response().Header.SetBytesV("Set-Cookie", cookies)
 	}
-	resBody := c.Response().Body()
-	basePathPattern := `<BaseURL>(.*)<\/BaseURL>`
-	re := regexp.MustCompile(basePathPattern)
-	// check for match
-	if re.Match(resBody) {
-		resBody = re.ReplaceAllFunc(resBody, func(match []byte) []byte {
-			return []byte(fmt.Sprintf("<BaseURL>%s/dash/</BaseURL>", dashBaseURL))
-		})
-	} else {
-		pattern := `<Period(\s+[^>]*?)?\s*\/?>`
-		re = regexp.MustCompile(pattern)
-		resBody = re.ReplaceAllFunc(resBody, func(match []byte) []byte {
-			return []byte(fmt.Sprintf("%s\n<BaseURL>%s/</BaseURL>", match, dashBaseURL))
-		})
+	c.Response().SetBody(rewriteDashManifest(c.Response().Body(), parsedUrl, hdnea, query))
+
+	return nil
+}
+
+// rewriteDashManifest rewrites the URLs of a DASH manifest fetched from manifestURL, so that players fetch
+// segments through /render.dash and reload live manifests through /render.mpd with the given query.
+// Relative segment URLs are kept, they resolve against the rewritten BaseURLs.
+// Data that is not a manifest, e.g. an error page, is returned unchanged.
+func rewriteDashManifest(manifest []byte, manifestURL *url.URL, hdnea string, query url.Values) []byte {
+	segments := newDashProxy(manifestURL, hdnea)
+	result, err := dash.Rewrite(manifest, manifestURL, func(ref dash.Reference) (string, bool) {
+		switch ref.Kind {
+		case dash.ManifestURI:
+			encURL, err := secureurl.EncryptURL(ref.URL)
+			if err != nil {
+				return "", false
+			}
+			location := url.Values{}
+			for key, values := range query {
+				location[key] = values
+			}
+			location.Set("auth", encURL)
+			return "/render.mpd?" + location.Encode(), true
+		case dash.SegmentURI:
+			if !strings.HasPrefix(ref.URI, "/") && !strings.HasPrefix(ref.URI, "http://") && !strings.HasPrefix(ref.URI, "https://") {
+				return "", false
+			}
+		}
+		return segments.url(ref.URL)
+	})
+	if err != nil {
+		return manifest
 	}
+	return result
+}
 
-	c.Response().SetBody(resBody)
+// dashProxy builds the /render.dash URLs of the segments of a manifest. URLs below the directory of the
+// manifest keep their path relative to it, like DashHandler expects from the BaseURLs of JioTV manifests.
+type dashProxy struct {
+	host  string
+	dir   string
+	hdnea string
+	// prefixes are the encrypted /render.dash prefixes by host and directory
+	prefixes map[[2]string]string
+}
 
-	return nil
+// newDashProxy returns a dashProxy for the manifest at manifestURL. hdnea is the __hdnea__ cookie to send
+// with the segment requests, if any.
+func newDashProxy(manifestURL *url.URL, hdnea string) *dashProxy {
+	p := &dashProxy{hdnea: hdnea, prefixes: map[[2]string]string{}}
+	if manifestURL != nil {
+		p.host = manifestURL.Host
+		p.dir = manifestURL.Path[:strings.LastIndex(manifestURL.Path, "/")+1]
+	}
+	return p
+}
+
+// url returns the /render.dash URL of an upstream URL, which may be a segment template.
+func (p *dashProxy) url(upstream string) (string, bool) {
+	rest, ok := strings.CutPrefix(upstream, "https://")
+	if !ok {
+		if rest, ok = strings.CutPrefix(upstream, "http://"); !ok {
+			return "", false
+		}
+	}
+	host, path := rest, "/"
+	if i := strings.IndexAny(rest, "/?"); i >= 0 {
+		host, path = rest[:i], rest[i:]
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+	}
+	dir := "/"
+	if host == p.host && p.dir != "" && strings.HasPrefix(path, p.dir) {
+		dir = p.dir
+	}
+
+	key := [2]string{host, dir}
+	prefix, ok := p.prefixes[key]
+	if !ok {
+		encHost, err := secureurl.EncryptURL(host)
+		if err != nil {
+			return "", false
+		}
+		encDir, err := secureurl.EncryptURL(dir)
+		if err != nil {
+			return "", false
+		}
+		prefix = fmt.Sprintf("/render.dash/host/%s/path/%s", encHost, encDir)
+		if p.hdnea != "" {
+			if encHDNEA, err := secureurl.EncryptURL(cdntoken.HDNEA.Cookie + "=" + p.hdnea); err == nil {
+				prefix += "/hdnea/" + encHDNEA
+			}
+		}
+		p.prefixes[key] = prefix
+	}
+	return prefix + "/" + strings.TrimPrefix(path, dir), true
 }
 
 // DashHandler
 func DashHandler(c *fiber.Ctx) error {
+	t := tenantOf(c)
+	keepStreamAlive(c)
 	proxyHost := c.Query("host")
 	proxyPath := c.Query("path")
 	requestPath := string(c.Request().URI().Path())
@@ -692,8 +753,8 @@ func DashHandler(c *fiber.Ctx) error {
 
 					// Decrypt HDNEA
 					decHdnea, decErr := secureurl.DecryptURL(encHdnea)
-					if decErr == nil && strings.HasPrefix(decHdnea, "__hdnea__=") {
-						hdneaToken = strings.TrimPrefix(decHdnea, "__hdnea__=")
+					if decErr == nil && strings.HasPrefix(decHdnea, cdntoken.HDNEA.Cookie+"=") {
+						hdneaToken = strings.TrimPrefix(decHdnea, cdntoken.HDNEA.Cookie+"=")
 					}
 
 					// Set request path to the remaining part after hdnea
@@ -724,12 +785,12 @@ func DashHandler(c *fiber.Ctx) error {
 	// decode the URL
 	proxyHost, err := secureurl.DecryptURL(proxyHost)
 	if err != nil {
-		utils.Log.Panicln(err)
+		utils.Log.Println(err)
 		return err
 	}
 	proxyPath, err = secureurl.DecryptURL(proxyPath)
 	if err != nil {
-		utils.Log.Panicln(err)
+		utils.Log.Println(err)
 		return err
 	}
 
@@ -751,22 +812,22 @@ func DashHandler(c *fiber.Ctx) error {
 
 	// Set HDNEA cookie if we have it
 	if hdneaToken != "" {
-		if remaining, ok := hdneaRemainingLifetime(hdneaToken); ok && remaining <= hdneaRefreshLeadTime {
+		if remaining, ok := cdntoken.Remaining(hdneaToken); ok && remaining <= hdneaRefreshLeadTime {
 			// Avoid sending near-expired token; let upstream issue a fresh cookie instead.
 			if os.Getenv("JIOTV_DEBUG") == "true" {
 				utils.Log.Printf("[DEBUG] DashHandler skipping near-expired HDNEA token (remaining=%s)", remaining)
 			}
 			hdneaToken = ""
 		} else {
-			c.Request().Header.SetCookie("__hdnea__", hdneaToken)
+			cdntoken.HDNEA.SetCookie(&c.Request().Header, hdneaToken)
 		}
 	}
 
 	// CRITICAL: Refresh credentials before proxying segments
-	EnsureFreshCredentials()
+	t.ensureFreshCredentials()
 
 	// AGGRESSIVE REFRESH: Make initial proxy request
-	if err := proxy.Do(c, proxyUrl, TV.Client); err != nil {
+	if err := proxy.Do(c, proxyUrl, t.TV().Client); err != nil {
 		return err
 	}
 
@@ -779,13 +840,13 @@ func DashHandler(c *fiber.Ctx) error {
 
 		// Reset response to allow retry
 		c.Response().Reset()
-		ForceRefreshCredentials()
+		t.forceRefreshCredentials()
 
 		// Clear HDNEA cookie - expired token causes 403
 		// CDN will provide fresh HDNEA in the response
-		c.Request().Header.DelCookie("__hdnea__")
+		c.Request().Header.DelCookie(cdntoken.HDNEA.Cookie)
 
-		if err := proxy.Do(c, proxyUrl, TV.Client); err != nil {
+		if err := proxy.Do(c, proxyUrl, t.TV().Client); err != nil {
 			if os.Getenv("JIOTV_DEBUG") == "true" {
 				utils.Log.Printf("[DEBUG] DashHandler retry failed: %v", err)
 			}
@@ -802,6 +863,7 @@ func DashHandler(c *fiber.Ctx) error {
 	}
 
 	c.Response().Header.Del(fiber.HeaderServer)
+	meterStream(c, "")
 
 	return nil
 }
@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"net/url"
 	"reflect"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
 )
 
 func TestGetDrmMpd(t *testing.T) {
@@ -46,7 +50,7 @@ func TestGetDrmMpd(t *testing.T) {
 				}
 			}()
 
-			got, err := getDrmMpd(tt.args.channelID, tt.args.quality)
+			got, err := getDrmMpd(defaultTenant, tt.args.channelID, tt.args.quality)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("getDrmMpd() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -179,35 +183,89 @@ func TestDashHandler(t *testing.T) {
 	}
 }
 
-func TestStripHDNEAFromURL(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    string
-		want     string
-	}{
-		{
-			name:  "removes hdnea query parameter",
-			input: "https://example.com/video.mpd?foo=bar&hdnea=abc123&baz=qux",
-			want:  "https://example.com/video.mpd?baz=qux&foo=bar",
-		},
-		{
-			name:  "removes __hdnea__ query parameter",
-			input: "https://example.com/video.mpd?foo=bar&__hdnea__=abc123",
-			want:  "https://example.com/video.mpd?foo=bar",
-		},
-		{
-			name:  "returns original url without hdnea",
-			input: "https://example.com/video.mpd?foo=bar",
-			want:  "https://example.com/video.mpd?foo=bar",
-		},
+// decodeDashURL returns the upstream URL and the __hdnea__ cookie of a /render.dash URL.
+func decodeDashURL(t *testing.T, dashURL string) (string, string) {
+	t.Helper()
+	parts := regexp.MustCompile(`^/render\.dash/host/([^/]+)/path/([^/]+)(?:/hdnea/([^/]+))?/(.*)$`).FindStringSubmatch(dashURL)
+	if parts == nil {
+		t.Fatalf("%q is not a /render.dash URL", dashURL)
+	}
+	host, _ := secureurl.DecryptURL(parts[1])
+	path, _ := secureurl.DecryptURL(parts[2])
+	hdnea := ""
+	if parts[3] != "" {
+		hdnea, _ = secureurl.DecryptURL(parts[3])
 	}
+	return "https://" + host + strings.TrimSuffix(path, "/") + "/" + parts[4], hdnea
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := stripHDNEAFromURL(tt.input)
-			if got != tt.want {
-				t.Fatalf("stripHDNEAFromURL() = %q, want %q", got, tt.want)
-			}
-		})
+func TestRewriteDashManifest(t *testing.T) {
+	secureurl.Init()
+	manifest := `<?xml version="1.0"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" type="dynamic">
+  <Location>https://jiotvmblive.cdn.jio.com/bpk-tv/Colors_HD/output/index.mpd?t=2</Location>
+  <Period id="1">
+    <BaseURL>dash/</BaseURL>
+    <AdaptationSet mimeType="video/mp4">
+      <SegmentTemplate media="Colors_HD-$RepresentationID$-$Number$.m4s" initialization="https://other.cdn.jio.com/init/$RepresentationID$.mp4"/>
+      <Representation id="video=400000" bandwidth="400000"/>
+    </AdaptationSet>
+  </Period>
+  <Period id="2">
+    <AdaptationSet mimeType="video/mp4">
+      <SegmentTemplate media="/ads/$Number$.m4s"/>
+    </AdaptationSet>
+  </Period>
+</MPD>`
+	manifestURL, _ := url.Parse("https://jiotvmblive.cdn.jio.com/bpk-tv/Colors_HD/output/index.mpd?hdnea=old")
+	query := url.Values{"auth": {"old"}, "channel_id": {"143"}, "q": {"high"}}
+	result := string(rewriteDashManifest([]byte(manifest), manifestURL, "st=1~exp=2", query))
+
+	find := func(pattern string) []string {
+		matches := regexp.MustCompile(pattern).FindAllStringSubmatch(result, -1)
+		values := make([]string, len(matches))
+		for i, match := range matches {
+			values[i] = match[1]
+		}
+		return values
+	}
+
+	baseURLs := find(`<BaseURL>([^<]*)</BaseURL>`)
+	wantBaseURLs := []string{
+		// The BaseURL added for the manifest and the BaseURL of the first period
+		"https://jiotvmblive.cdn.jio.com/bpk-tv/Colors_HD/output/",
+		"https://jiotvmblive.cdn.jio.com/bpk-tv/Colors_HD/output/dash/",
+	}
+	if len(baseURLs) != len(wantBaseURLs) {
+		t.Fatalf("rewritten manifest has BaseURLs %v, want %d:\n%s", baseURLs, len(wantBaseURLs), result)
+	}
+	for i, want := range wantBaseURLs {
+		got, hdnea := decodeDashURL(t, baseURLs[i])
+		if got != want || hdnea != "__hdnea__=st=1~exp=2" {
+			t.Errorf("BaseURL %d points to %s with cookie %s, want %s", i, got, hdnea, want)
+		}
+	}
+
+	// Relative templates resolve against the BaseURLs, absolute ones are proxied with their identifiers
+	if media := find(`media="([^"]*)"`); media[0] != "Colors_HD-$RepresentationID$-$Number$.m4s" {
+		t.Errorf("relative media template = %s, want it unchanged", media[0])
+	} else if got, _ := decodeDashURL(t, media[1]); got != "https://jiotvmblive.cdn.jio.com/ads/$Number$.m4s" {
+		t.Errorf("absolute path media template points to %s", got)
+	}
+	if got, _ := decodeDashURL(t, find(`initialization="([^"]*)"`)[0]); got != "https://other.cdn.jio.com/init/$RepresentationID$.mp4" {
+		t.Errorf("absolute initialization template points to %s", got)
+	}
+
+	location, err := url.Parse(strings.ReplaceAll(find(`<Location>([^<]*)</Location>`)[0], "&amp;", "&"))
+	if err != nil || location.Path != "/render.mpd" || location.Query().Get("channel_id") != "143" || location.Query().Get("q") != "high" {
+		t.Fatalf("Location = %v, want /render.mpd with the query of the request", location)
+	}
+	if upstream, _ := secureurl.DecryptURL(location.Query().Get("auth")); upstream != "https://jiotvmblive.cdn.jio.com/bpk-tv/Colors_HD/output/index.mpd?t=2" {
+		t.Errorf("Location points to %s", upstream)
+	}
+
+	errorPage := []byte("<html>403 Forbidden</html>")
+	if got := rewriteDashManifest(errorPage, manifestURL, "", nil); string(got) != string(errorPage) {
+		t.Errorf("rewriteDashManifest() of an error page = %s, want it unchanged", got)
 	}
 }
@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"compress/gzip"
 	"fmt"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/proxy"
@@ -14,6 +17,7 @@ import (
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/urls"
 	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/imagecache"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 )
 
@@ -24,12 +28,112 @@ const (
 var externalEPGMu sync.Mutex
 var localEPGMu sync.Mutex
 
-// EPGHandler handles EPG requests
+// EPGHandler handles EPG requests.
+// Clients that revalidate with If-None-Match or If-Modified-Since get 304 Not Modified until the
+// EPG file changes. The file is sent gzipped as is, unless the client's Accept-Encoding excludes gzip.
 func EPGHandler(c *fiber.Ctx) error {
+	epgFilePath, ferr := ensureEPGFile()
+	if ferr != nil {
+		return internalUtils.ErrorResponse(c, ferr.Code, ferr.Message)
+	}
+	info, err := os.Stat(epgFilePath)
+	if err != nil {
+		return internalUtils.InternalServerError(c, "Failed to read EPG file")
+	}
+
+	etag := epgETag(info)
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderLastModified, info.ModTime().UTC().Format(http.TimeFormat))
+	c.Vary(fiber.HeaderAcceptEncoding)
+	if epgNotModified(c, etag, info.ModTime()) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	if acceptsGzip(c.Get(fiber.HeaderAcceptEncoding)) {
+		// The freshness check is done above, SendFile would otherwise ignore If-None-Match
+		c.Request().Header.Del(fiber.HeaderIfModifiedSince)
+		return c.SendFile(epgFilePath, true)
+	}
+	f, err := os.Open(epgFilePath)
+	if err != nil {
+		return internalUtils.InternalServerError(c, "Failed to read EPG file")
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return internalUtils.InternalServerError(c, "Failed to read EPG file")
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationXMLCharsetUTF8)
+	return c.SendStream(&gzipFileReader{Reader: gz, file: f})
+}
+
+// gzipFileReader decompresses a file and closes it once the response has been sent.
+type gzipFileReader struct {
+	*gzip.Reader
+	file *os.File
+}
+
+// Close closes the gzip reader and the underlying file.
+func (r *gzipFileReader) Close() error {
+	r.Reader.Close()
+	return r.file.Close()
+}
+
+// epgETag returns a weak ETag for the EPG file. It is weak because the same file may be sent
+// gzipped or decompressed.
+func epgETag(info os.FileInfo) string {
+	return fmt.Sprintf(`W/"%x-%x"`, info.ModTime().UnixNano(), info.Size())
+}
+
+// epgNotModified reports whether the client's cached copy of the EPG is still fresh.
+// If-None-Match takes precedence over If-Modified-Since, as in RFC 9110.
+func epgNotModified(c *fiber.Ctx, etag string, modTime time.Time) bool {
+	if ifNoneMatch := c.Get(fiber.HeaderIfNoneMatch); ifNoneMatch != "" {
+		for _, tag := range strings.Split(ifNoneMatch, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	ifModifiedSince, err := http.ParseTime(c.Get(fiber.HeaderIfModifiedSince))
+	if err != nil {
+		return false
+	}
+	return !modTime.Truncate(time.Second).After(ifModifiedSince)
+}
+
+// acceptsGzip reports whether a client with the given Accept-Encoding header accepts gzip.
+// Clients that send no Accept-Encoding get the gzipped file, as they always have.
+func acceptsGzip(acceptEncoding string) bool {
+	if strings.TrimSpace(acceptEncoding) == "" {
+		return true
+	}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		q := strings.TrimSpace(params)
+		if strings.HasPrefix(q, "q=") {
+			if weight, err := strconv.ParseFloat(q[2:], 64); err == nil && weight == 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// ensureEPGFile returns the path of the EPG file. If the file does not exist, it is downloaded
+// from the external EPG URL or generated, depending on the config.
+func ensureEPGFile() (string, *fiber.Error) {
 	epgFilePath := utils.GetPathPrefix() + "epg.xml.gz"
 	// if epg.xml.gz exists, return it
 	if _, err := os.Stat(epgFilePath); err == nil {
-		return c.SendFile(epgFilePath, true)
+		return epgFilePath, nil
 	}
 
 	if config.Cfg.EPGURL != "" {
@@ -38,10 +142,10 @@ func EPGHandler(c *fiber.Ctx) error {
 		externalEPGMu.Unlock()
 		if err == nil {
 			if _, statErr := os.Stat(epgFilePath); statErr == nil {
-				return c.SendFile(epgFilePath, true)
+				return epgFilePath, nil
 			}
 		}
-		return internalUtils.InternalServerError(c, err.Error())
+		return "", fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
 	if config.Cfg.EPG {
@@ -49,25 +153,104 @@ func EPGHandler(c *fiber.Ctx) error {
 		defer localEPGMu.Unlock()
 
 		if _, err := os.Stat(epgFilePath); err == nil {
-			return c.SendFile(epgFilePath, true)
+			return epgFilePath, nil
 		}
 
 		if err := epg.GenXMLGz(epgFilePath); err != nil {
-			return internalUtils.InternalServerError(c, err.Error())
+			return "", fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
 
 		if _, err := os.Stat(epgFilePath); err == nil {
-			return c.SendFile(epgFilePath, true)
+			return epgFilePath, nil
 		}
 	}
 
 	errMessage := "EPG not found. Enable JIOTV_EPG or set JIOTV_EPG_URL to an external guide."
 	utils.Log.Println(errMessage)
-	return internalUtils.NotFoundError(c, errMessage)
+	return "", fiber.NewError(fiber.StatusNotFound, errMessage)
+}
+
+// readJSONGuide returns the JSON guide of the EPG file.
+func readJSONGuide() (*epg.JSONGuide, *fiber.Error) {
+	epgFilePath, ferr := ensureEPGFile()
+	if ferr != nil {
+		return nil, ferr
+	}
+	guide, err := epg.ReadJSONGuide(epgFilePath)
+	if err != nil {
+		utils.Log.Printf("ERROR: Failed to read EPG file: %v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to read EPG file")
+	}
+	return guide, nil
+}
+
+// EPGJSONHandler serves the whole EPG in JSON format on `/epg.json`.
+func EPGJSONHandler(c *fiber.Ctx) error {
+	guide, ferr := readJSONGuide()
+	if ferr != nil {
+		return internalUtils.ErrorResponse(c, ferr.Code, ferr.Message)
+	}
+	return c.JSON(guide)
+}
+
+// EPGChannelDayHandler serves the programmes of a channel on a day in JSON format
+// on `/api/v1/epg/:channel/:day`, where day is 0 for today, -1 for yesterday and 1 for tomorrow.
+func EPGChannelDayHandler(c *fiber.Ctx) error {
+	day, err := strconv.Atoi(c.Params("day"))
+	if err != nil || day < -7 || day > 7 {
+		return internalUtils.BadRequestError(c, "Invalid day, use a number from -7 to 7")
+	}
+
+	guide, ferr := readJSONGuide()
+	if ferr != nil {
+		return internalUtils.ErrorResponse(c, ferr.Code, ferr.Message)
+	}
+	channel, ok := guide.Channel(c.Params("channel"), day, time.Now())
+	if !ok {
+		return internalUtils.NotFoundError(c, "Channel not found in EPG")
+	}
+	return c.JSON(channel)
+}
+
+// nowNextResponse is the response of NowNextHandler
+type nowNextResponse struct {
+	ChannelID string             `json:"channel_id"`
+	Now       *epg.JSONProgramme `json:"now"`
+	Next      *epg.JSONProgramme `json:"next"`
+}
+
+// NowNextHandler serves the programme airing now on a channel and the one after it on
+// `/api/v1/now/:channelID`. It uses the generated EPG when there is one, and the live
+// JioTV EPG otherwise. It never starts EPG generation.
+func NowNextHandler(c *fiber.Ctx) error {
+	channelID := c.Params("channelID")
+	now := time.Now()
+
+	epgFilePath := utils.GetPathPrefix() + "epg.xml.gz"
+	if utils.FileExists(epgFilePath) {
+		guide, err := epg.ReadJSONGuide(epgFilePath)
+		if err != nil {
+			utils.Log.Printf("WARN: Failed to read EPG file: %v", err)
+		} else if current, next, ok := guide.NowNext(channelID, now); ok {
+			return c.JSON(nowNextResponse{ChannelID: channelID, Now: current, Next: next})
+		}
+	}
+
+	current, next, err := epg.NowNext(channelID, now)
+	if err != nil {
+		return internalUtils.NotFoundError(c, "Channel not found in EPG")
+	}
+	posterURL := requestHostURL(c) + "/jtvposter/"
+	return c.JSON(nowNextResponse{
+		ChannelID: channelID,
+		Now:       epg.NewJSONProgramme(current, posterURL),
+		Next:      epg.NewJSONProgramme(next, posterURL),
+	})
 }
 
 // WebEPGHandler responds to requests for EPG data for individual channels.
 func WebEPGHandler(c *fiber.Ctx) error {
+	t := tenantOf(c)
 	// Get channel ID from URL
 	channelID := c.Params("channelID")
 
@@ -88,7 +271,7 @@ func WebEPGHandler(c *fiber.Ctx) error {
 
 	url := fmt.Sprintf(epg.EPG_URL, offset, channelIntID)
 	internalUtils.SetCommonHeaders(c, headers.UserAgentOkHttp)
-	if err := proxy.Do(c, url, TV.Client); err != nil {
+	if err := proxy.Do(c, url, t.TV().Client); err != nil {
 		return err
 	}
 
@@ -96,10 +279,30 @@ func WebEPGHandler(c *fiber.Ctx) error {
 	return nil
 }
 
-// PosterHandler loads image from JioTV server
+// PosterHandler loads image from JioTV server, resized with the `w`, `h` and `format` query params
 func PosterHandler(c *fiber.Ctx) error {
+	t := tenantOf(c)
+	poster := c.Params("date") + "/" + c.Params("file")
+	transform, err := imageTransform(c)
+	if err != nil {
+		return internalUtils.BadRequestError(c, err.Error())
+	}
+	if !transform.IsZero() {
+		data, err := transformedImage(epg.PosterCacheKey(poster), EPG_POSTER_URL+poster, t.TV().Client, transform)
+		if err == nil {
+			return sendImage(c, data)
+		}
+		utils.Log.Printf("WARN: Failed to resize poster %s: %v", poster, err)
+	}
+
+	// serve pre-fetched artwork from the image cache
+	if cachedPath, ok := imagecache.Path(epg.PosterCacheKey(poster)); ok {
+		internalUtils.SetCacheHeader(c, 86400)
+		return c.SendFile(cachedPath)
+	}
+
 	// catch all params
-	url := EPG_POSTER_URL + c.Params("date") + "/" + c.Params("file")
-	_, err := internalUtils.ProxyRequest(c, url, TV.Client, "")
+	url := EPG_POSTER_URL + poster
+	_, err = internalUtils.ProxyRequest(c, url, t.TV().Client, "")
 	return err
 }
@@ -1,9 +1,20 @@
 package handlers
 
 import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 )
 
 func TestWebEPGHandler(t *testing.T) {
@@ -45,3 +56,190 @@ func TestPosterHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestEPGJSONHandlers(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	originalEPGURL, originalEPG := config.Cfg.EPGURL, config.Cfg.EPG
+	config.Cfg.EPGURL, config.Cfg.EPG = "", false
+	defer func() { config.Cfg.EPGURL, config.Cfg.EPG = originalEPGURL, originalEPG }()
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+
+	app := fiber.New()
+	app.Get("/epg.json", EPGJSONHandler)
+	app.Get("/api/v1/epg/:channel/:day", EPGChannelDayHandler)
+	app.Get("/api/v1/now/:channelID", NowNextHandler)
+
+	get := func(target string) (int, []byte) {
+		resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+		if err != nil {
+			t.Fatalf("app.Test(%s) error = %v", target, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, body
+	}
+
+	if status, _ := get("/epg.json"); status != fiber.StatusNotFound {
+		t.Errorf("GET /epg.json without EPG file status = %d, want %d", status, fiber.StatusNotFound)
+	}
+
+	// Write an EPG file with a programme airing now
+	now := time.Now()
+	f, err := os.Create(utils.GetPathPrefix() + "epg.xml.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	io.WriteString(gz, `<tv><channel id="143"><display-name>News</display-name></channel>`+
+		`<programme channel="143" start="`+now.Add(-time.Minute).Format("20060102150405 -0700")+`" stop="`+now.Add(time.Minute).Format("20060102150405 -0700")+`">`+
+		`<title lang="en">Headlines</title><desc lang="en"></desc><category lang="en"></category><icon src=""></icon></programme></tv>`)
+	gz.Close()
+	f.Close()
+
+	status, body := get("/epg.json")
+	if status != fiber.StatusOK {
+		t.Fatalf("GET /epg.json status = %d, body = %s", status, body)
+	}
+	var guide epg.JSONGuide
+	if err := json.Unmarshal(body, &guide); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(guide.Channels) != 1 || guide.Channels[0].Name != "News" || len(guide.Channels[0].Programmes) != 1 {
+		t.Errorf("guide = %+v", guide)
+	}
+
+	status, body = get("/api/v1/now/143")
+	if status != fiber.StatusOK {
+		t.Fatalf("GET /api/v1/now/143 status = %d, body = %s", status, body)
+	}
+	var nowNext nowNextResponse
+	if err := json.Unmarshal(body, &nowNext); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if nowNext.ChannelID != "143" || nowNext.Now == nil || nowNext.Now.Title != "Headlines" || nowNext.Next != nil {
+		t.Errorf("now/next = %+v", nowNext)
+	}
+
+	tests := []struct {
+		target     string
+		wantStatus int
+	}{
+		{target: "/api/v1/epg/143/0", wantStatus: fiber.StatusOK},
+		{target: "/api/v1/epg/1/0", wantStatus: fiber.StatusNotFound},
+		{target: "/api/v1/epg/143/today", wantStatus: fiber.StatusBadRequest},
+		{target: "/api/v1/epg/143/8", wantStatus: fiber.StatusBadRequest},
+		// Not in the generated EPG and not a JioTV channel
+		{target: "/api/v1/now/custom", wantStatus: fiber.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			status, body := get(tt.target)
+			if status != tt.wantStatus {
+				t.Errorf("GET %s status = %d, want %d, body = %s", tt.target, status, tt.wantStatus, body)
+			}
+		})
+	}
+}
+
+func TestEPGHandlerCaching(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+
+	const xml = `<tv><channel id="143"><display-name>News</display-name></channel></tv>`
+	f, err := os.Create(utils.GetPathPrefix() + "epg.xml.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	io.WriteString(gz, xml)
+	gz.Close()
+	f.Close()
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(utils.GetPathPrefix()+"epg.xml.gz", modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Get("/epg.xml.gz", EPGHandler)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/epg.xml.gz", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag := resp.Header.Get(fiber.HeaderETag)
+	if resp.StatusCode != fiber.StatusOK || etag == "" {
+		t.Fatalf("GET /epg.xml.gz status = %d, ETag = %q", resp.StatusCode, etag)
+	}
+	if got := resp.Header.Get(fiber.HeaderLastModified); got != "Tue, 02 Jan 2024 03:04:05 GMT" {
+		t.Errorf("Last-Modified = %q", got)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if len(body) < 2 || body[0] != 0x1f || body[1] != 0x8b {
+		t.Errorf("body is not gzipped")
+	}
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "matching ETag", headers: map[string]string{"If-None-Match": etag}, wantStatus: fiber.StatusNotModified},
+		{name: "strong form of ETag", headers: map[string]string{"If-None-Match": `"other", ` + etag[2:]}, wantStatus: fiber.StatusNotModified},
+		{name: "stale ETag", headers: map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": "Tue, 02 Jan 2024 03:04:05 GMT"}, wantStatus: fiber.StatusOK},
+		{name: "not modified since", headers: map[string]string{"If-Modified-Since": "Tue, 02 Jan 2024 03:04:05 GMT"}, wantStatus: fiber.StatusNotModified},
+		{name: "modified since", headers: map[string]string{"If-Modified-Since": "Mon, 01 Jan 2024 00:00:00 GMT"}, wantStatus: fiber.StatusOK},
+		{name: "gzip not accepted", headers: map[string]string{"Accept-Encoding": "identity"}, wantStatus: fiber.StatusOK, wantBody: xml},
+		{name: "gzip refused", headers: map[string]string{"Accept-Encoding": "gzip;q=0, deflate"}, wantStatus: fiber.StatusOK, wantBody: xml},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/epg.xml.gz", nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if tt.wantBody != "" && string(body) != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		want           bool
+	}{
+		{"", true},
+		{"gzip, deflate, br", true},
+		{"GZIP", true},
+		{"*", true},
+		{"identity", false},
+		{"gzip;q=0", false},
+		{"gzip;q=0.5", true},
+	}
+	for _, tt := range tests {
+		if got := acceptsGzip(tt.acceptEncoding); got != tt.want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", tt.acceptEncoding, got, tt.want)
+		}
+	}
+}
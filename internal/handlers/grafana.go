@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/stats"
+)
+
+// Metrics served to the Grafana JSON datasource
+const (
+	grafanaViewersPerHour = "viewers_per_hour"
+	grafanaPlaysPerHour   = "plays_per_hour"
+	grafanaBytesPerHour   = "bytes_per_hour"
+	grafanaErrorRate      = "error_rate"
+	grafanaTopChannels    = "top_channels"
+	// grafanaTopChannelsLimit is the number of rows in the top channels table
+	grafanaTopChannelsLimit = 20
+	// grafanaDefaultRange is the time range used when a query has none
+	grafanaDefaultRange = 24 * time.Hour
+)
+
+// grafanaMetrics lists the metrics offered to Grafana
+var grafanaMetrics = []string{
+	grafanaViewersPerHour,
+	grafanaPlaysPerHour,
+	grafanaBytesPerHour,
+	grafanaErrorRate,
+	grafanaTopChannels,
+}
+
+// grafanaQueryRequest is the body of a Grafana JSON datasource query
+type grafanaQueryRequest struct {
+	Range struct {
+		From time.Time `json:"from"`
+		To   time.Time `json:"to"`
+	} `json:"range"`
+	Targets []struct {
+		Target string `json:"target"`
+	} `json:"targets"`
+}
+
+// grafanaTimeSeries is a time series response for the Grafana JSON datasource
+type grafanaTimeSeries struct {
+	Target     string      `json:"target"`
+	Datapoints [][]float64 `json:"datapoints"`
+}
+
+// grafanaColumn is a column of a Grafana table response
+type grafanaColumn struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
+
+// grafanaTable is a table response for the Grafana JSON datasource
+type grafanaTable struct {
+	Type    string          `json:"type"`
+	Columns []grafanaColumn `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+// GrafanaTestHandler answers the Grafana JSON datasource connection test on `/api/grafana`.
+func GrafanaTestHandler(c *fiber.Ctx) error {
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// GrafanaMetricsHandler lists the available metrics on `/api/grafana/metrics` and `/api/grafana/search`.
+func GrafanaMetricsHandler(c *fiber.Ctx) error {
+	if c.Path() == "/api/grafana/search" {
+		return c.JSON(grafanaMetrics)
+	}
+	metrics := make([]fiber.Map, 0, len(grafanaMetrics))
+	for _, metric := range grafanaMetrics {
+		metrics = append(metrics, fiber.Map{"label": metric, "value": metric})
+	}
+	return c.JSON(metrics)
+}
+
+// GrafanaQueryHandler returns pre-aggregated statistics on `/api/grafana/query`.
+// Hourly metrics are returned as time series and top channels as a table.
+func GrafanaQueryHandler(c *fiber.Ctx) error {
+	var query grafanaQueryRequest
+	if err := c.BodyParser(&query); err != nil {
+		return internalUtils.BadRequestError(c, "Invalid query")
+	}
+
+	to := query.Range.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	from := query.Range.From
+	if from.IsZero() || !from.Before(to) {
+		from = to.Add(-grafanaDefaultRange)
+	}
+
+	response := make([]interface{}, 0, len(query.Targets))
+	for _, target := range query.Targets {
+		switch target.Target {
+		case grafanaViewersPerHour:
+			response = append(response, toGrafanaTimeSeries(target.Target, stats.Default.ViewersPerHour(from, to)))
+		case grafanaPlaysPerHour:
+			response = append(response, toGrafanaTimeSeries(target.Target, stats.Default.PlaysPerHour(from, to)))
+		case grafanaBytesPerHour:
+			response = append(response, toGrafanaTimeSeries(target.Target, stats.Default.BytesPerHour(from, to)))
+		case grafanaErrorRate:
+			response = append(response, toGrafanaTimeSeries(target.Target, stats.Default.ErrorRatePerHour(from, to)))
+		case grafanaTopChannels:
+			response = append(response, toGrafanaTable(stats.Default.TopChannels(from, to, grafanaTopChannelsLimit)))
+		}
+	}
+	return c.JSON(response)
+}
+
+// toGrafanaTimeSeries converts hourly points to Grafana datapoints of [value, unix ms].
+func toGrafanaTimeSeries(target string, points []stats.Point) grafanaTimeSeries {
+	series := grafanaTimeSeries{Target: target, Datapoints: make([][]float64, 0, len(points))}
+	for _, point := range points {
+		series.Datapoints = append(series.Datapoints, []float64{point.Value, float64(point.Time.UnixMilli())})
+	}
+	return series
+}
+
+// toGrafanaTable converts channel counts to a Grafana table.
+func toGrafanaTable(channels []stats.ChannelCount) grafanaTable {
+	table := grafanaTable{
+		Type: "table",
+		Columns: []grafanaColumn{
+			{Text: "Channel", Type: "string"},
+			{Text: "Plays", Type: "number"},
+			{Text: "Viewers", Type: "number"},
+		},
+		Rows: make([][]interface{}, 0, len(channels)),
+	}
+	for _, channel := range channels {
+		table.Rows = append(table.Rows, []interface{}{channel.ChannelID, channel.Plays, channel.Viewers})
+	}
+	return table
+}
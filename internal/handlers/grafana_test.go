@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/stats"
+)
+
+func TestGrafanaQueryHandler(t *testing.T) {
+	stats.Default = stats.NewRecorder()
+	stats.RecordPlay("143", "10.0.0.1")
+	stats.RecordPlay("143", "10.0.0.2")
+	stats.RecordPlay("144", "10.0.0.1")
+
+	app := fiber.New()
+	app.Post("/api/grafana/query", GrafanaQueryHandler)
+
+	now := time.Now().UTC()
+	body := `{"range":{"from":"` + now.Add(-time.Hour).Format(time.RFC3339) + `","to":"` + now.Format(time.RFC3339) + `"},` +
+		`"targets":[{"target":"plays_per_hour"},{"target":"top_channels"},{"target":"unknown"}]}`
+	req := httptest.NewRequest("POST", "/api/grafana/query", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, fiber.StatusOK)
+	}
+
+	data, _ := io.ReadAll(resp.Body)
+	var result []map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("invalid response %s: %v", data, err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("got %d results, want 2: %s", len(result), data)
+	}
+
+	if result[0]["target"] != "plays_per_hour" {
+		t.Errorf("first target = %v, want plays_per_hour", result[0]["target"])
+	}
+	var plays float64
+	for _, dp := range result[0]["datapoints"].([]interface{}) {
+		plays += dp.([]interface{})[0].(float64)
+	}
+	if plays != 3 {
+		t.Errorf("total plays = %v, want 3", plays)
+	}
+
+	if result[1]["type"] != "table" {
+		t.Errorf("second result type = %v, want table", result[1]["type"])
+	}
+	rows := result[1]["rows"].([]interface{})
+	if len(rows) != 2 || rows[0].([]interface{})[0] != "143" {
+		t.Errorf("top channels rows = %v, want 143 first", rows)
+	}
+}
+
+func TestGrafanaQueryHandlerInvalidBody(t *testing.T) {
+	app := fiber.New()
+	app.Post("/api/grafana/query", GrafanaQueryHandler)
+
+	req := httptest.NewRequest("POST", "/api/grafana/query", strings.NewReader("{"))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("status = %d, want %d", resp.StatusCode, fiber.StatusBadRequest)
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+const (
+	// guideDefaultHours and guideMaxHours are the default and largest timeline of the guide API
+	guideDefaultHours = 3
+	guideMaxHours     = 24
+	// guideMaxDays is how many days before and after today the guide can show, as far as the EPG goes
+	guideMaxDays = 7
+	// guideSlot is what the default start of the timeline is rounded down to
+	guideSlot = 30 * time.Minute
+)
+
+// guideChannel is a channel of the guide API with its programmes on the timeline
+type guideChannel struct {
+	ID         string              `json:"id"`
+	Name       string              `json:"name"`
+	Logo       string              `json:"logo"`
+	Catchup    bool                `json:"catchup"`
+	Programmes []epg.JSONProgramme `json:"programmes"`
+}
+
+// guideResponse is the response of the guide API
+type guideResponse struct {
+	Start    time.Time      `json:"start"`
+	End      time.Time      `json:"end"`
+	Channels []guideChannel `json:"channels"`
+}
+
+// guideChannels returns the channels in the order of the channel list with their programmes between from and to.
+// Channels that are not in the EPG are skipped.
+func guideChannels(guide *epg.JSONGuide, channels []television.Channel, from, to time.Time, hostURL string) []guideChannel {
+	result := []guideChannel{}
+	for _, channel := range channels {
+		programmes, ok := guide.Window(channel.ID, from, to)
+		if !ok {
+			continue
+		}
+		result = append(result, guideChannel{
+			ID:         channel.ID,
+			Name:       channel.Name,
+			Logo:       channelLogoURL(hostURL, channel),
+			Catchup:    channel.IsCatchupAvailable && !isCustomChannel(channel.ID),
+			Programmes: programmes,
+		})
+	}
+	return result
+}
+
+// guideQueryInt parses an optional integer query parameter.
+func guideQueryInt(c *fiber.Ctx, key string) (int, error) {
+	value := c.Query(key)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// GuideDataHandler serves the programmes of all channels on a timeline on `/api/v1/guide`.
+// start is a Unix time in seconds, by default the last half hour, and hours is the length of the timeline.
+// language and category filter the channels like the channel list.
+func GuideDataHandler(c *fiber.Ctx) error {
+	now := time.Now()
+	start := now.Add(-guideSlot / 2).Truncate(guideSlot)
+	if value := c.Query("start"); value != "" {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return internalUtils.BadRequestError(c, "Invalid start, use a Unix time in seconds")
+		}
+		start = time.Unix(seconds, 0)
+		if start.Before(now.AddDate(0, 0, -guideMaxDays)) || start.After(now.AddDate(0, 0, guideMaxDays)) {
+			return internalUtils.BadRequestError(c, fmt.Sprintf("start must be within %d days of now", guideMaxDays))
+		}
+	}
+	hours, err := strconv.Atoi(c.Query("hours", strconv.Itoa(guideDefaultHours)))
+	if err != nil || hours < 1 || hours > guideMaxHours {
+		return internalUtils.BadRequestError(c, fmt.Sprintf("Invalid hours, use a number from 1 to %d", guideMaxHours))
+	}
+	language, err := guideQueryInt(c, "language")
+	if err != nil {
+		return internalUtils.BadRequestError(c, "Invalid language")
+	}
+	category, err := guideQueryInt(c, "category")
+	if err != nil {
+		return internalUtils.BadRequestError(c, "Invalid category")
+	}
+
+	guide, ferr := readJSONGuide()
+	if ferr != nil {
+		return internalUtils.ErrorResponse(c, ferr.Code, ferr.Message)
+	}
+	index, err := television.ChannelsIndex()
+	if err != nil {
+		return internalUtils.UpstreamError(c, err)
+	}
+	var channels []television.Channel
+	if language != 0 || category != 0 {
+		channels = index.Filter(language, category)
+	} else {
+		channels = television.FilterChannelsByDefaults(index.Filter(0, 0), config.Cfg.DefaultCategories, config.Cfg.DefaultLanguages)
+	}
+
+	end := start.Add(time.Duration(hours) * time.Hour)
+	return c.JSON(guideResponse{
+		Start:    start,
+		End:      end,
+		Channels: guideChannels(guide, channels, start, end, requestHostURL(c)),
+	})
+}
+
+// GuideHandler renders the TV guide page on `/guide`, a timeline of the programmes of all channels.
+func GuideHandler(c *fiber.Ctx) error {
+	return c.Render("views/guide", fiber.Map{
+		"Title":      Title,
+		"Categories": television.CategoryMap,
+		"Languages":  television.LanguageMap,
+	})
+}
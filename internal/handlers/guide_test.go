@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+func TestGuideChannels(t *testing.T) {
+	from := time.Date(2024, 1, 11, 10, 0, 0, 0, time.UTC)
+	programme := func(channel, title string, start time.Time) string {
+		return `<programme channel="` + channel + `" start="` + start.Format("20060102150405 -0700") + `" stop="` +
+			start.Add(time.Hour).Format("20060102150405 -0700") + `"><title lang="en">` + title + `</title></programme>`
+	}
+	filename := filepath.Join(t.TempDir(), "epg.xml.gz")
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	io.WriteString(gz, `<tv>`+
+		programme("143", "Before", from.Add(-2*time.Hour))+
+		programme("143", "Headlines", from.Add(-30*time.Minute))+
+		programme("143", "Sports", from.Add(30*time.Minute))+
+		programme("144", "Movie", from.Add(time.Hour))+
+		`</tv>`)
+	gz.Close()
+	f.Close()
+	guide, err := epg.ReadJSONGuide(filename)
+	if err != nil {
+		t.Fatalf("ReadJSONGuide() error = %v", err)
+	}
+
+	channels := []television.Channel{
+		{ID: "144", Name: "Movies", LogoURL: "Movies.png"},
+		{ID: "145", Name: "Not in EPG"},
+		{ID: "143", Name: "News", LogoURL: "https://example.com/news.png", IsCatchupAvailable: true},
+	}
+	got := guideChannels(guide, channels, from, from.Add(2*time.Hour), "http://localhost:5001")
+
+	var summary []string
+	for _, channel := range got {
+		titles := []string{}
+		for _, programme := range channel.Programmes {
+			titles = append(titles, programme.Title)
+		}
+		summary = append(summary, channel.ID+" "+channel.Logo+" "+strconv.FormatBool(channel.Catchup)+" "+strings.Join(titles, ","))
+	}
+	want := []string{
+		"144 http://localhost:5001/jtvimage/Movies.png false Movie",
+		"143 https://example.com/news.png true Headlines,Sports",
+	}
+	if strings.Join(summary, "\n") != strings.Join(want, "\n") {
+		t.Errorf("guideChannels() =\n%s\nwant\n%s", strings.Join(summary, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestGuideDataHandlerInvalidQuery(t *testing.T) {
+	app := fiber.New()
+	app.Get("/api/v1/guide", GuideDataHandler)
+
+	tooEarly := strconv.FormatInt(time.Now().AddDate(0, 0, -8).Unix(), 10)
+	for _, query := range []string{"hours=0", "hours=25", "hours=x", "start=x", "start=" + tooEarly, "language=x", "category=x"} {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/guide?"+query, nil))
+		if err != nil {
+			t.Fatalf("GET /api/v1/guide?%s error = %v", query, err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("GET /api/v1/guide?%s status = %d, want %d", query, resp.StatusCode, fiber.StatusBadRequest)
+		}
+	}
+}
@@ -2,14 +2,13 @@ package handlers
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"net"
 	"net/url"
 	"os"
-	"regexp"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/jiotv-go/jiotv_go/v3/internal/config"
@@ -17,9 +16,14 @@ import (
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/urls"
 	"github.com/jiotv-go/jiotv_go/v3/internal/plugins"
 	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
-	"github.com/jiotv-go/jiotv_go/v3/pkg/plugins/zee5"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/cdntoken"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/maintenance"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/manifest"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/preview"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/stats"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/timeshift"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 
 	"github.com/gofiber/fiber/v2"
@@ -33,7 +37,6 @@ var (
 	Title            string
 	EnableDRM        bool
 	SONY_LIST        = []string{"154", "155", "162", "289", "291", "471", "474", "476", "483", "514", "524", "525", "697", "872", "873", "874", "891", "892", "1146", "1393", "1772", "1773", "1774", "1775"}
-	renderHDNEACache sync.Map
 )
 
 const (
@@ -41,15 +44,9 @@ const (
 	REFRESH_SSO_TOKEN_URL = urls.RefreshSSOTokenURL
 	PLAYER_USER_AGENT     = headers.UserAgentPlayTV
 	REQUEST_USER_AGENT    = headers.UserAgentOkHttp
-	hdneaCacheTTL         = 20 * time.Second // Short TTL to avoid reusing stale signed URLs during playback
 	hdneaRefreshLeadTime  = 20 * time.Second
 )
 
-type hdneaCacheEntry struct {
-	Token     string
-	UpdatedAt time.Time
-}
-
 // truncateToken returns first 10 and last 10 chars of token for logging
 func truncateToken(token string) string {
 	if len(token) == 0 {
@@ -68,8 +65,10 @@ func Init() {
 	} else {
 		Title = "JioTV Go"
 	}
+	initTheme()
+	stats.Default.SetIdleTimeout(streamIdleTimeout())
 	DisableTSHandler = config.Cfg.DisableTSHandler
-	isLogoutDisabled = config.Cfg.DisableLogout
+	isLogoutDisabled = config.Cfg.DisableLogout || config.Cfg.GuestMode
 	EnableDRM = true // DRM is enabled by default, only channels that support DRM will use it
 	if DisableTSHandler {
 		utils.Log.Println("TS Handler disabled!. All TS video requests will be served directly from JioTV servers.")
@@ -98,24 +97,46 @@ func Init() {
 		TV = television.New(credentials)
 	}
 
+	// Load the tenants, each with its own credentials
+	initTenants()
+
 	// Initialize custom channels at startup if configured
 	television.InitCustomChannels()
 
-	// Initialize Zee5 data at startup if configured
-	if config.PluginEnabled("zee5") {
-		zee5.InitZee5Data()
-	}
+	// Load the channel overrides file at startup if configured
+	television.InitChannelOverrides()
+
+	// Load the channels hidden from the web interface
+	television.InitHiddenChannels()
+
+	// Initialize plugin data, e.g. Zee5, at startup if configured
+	plugins.LoadData()
 }
 
 // ErrorMessageHandler handles error messages
 // Responds with 500 status code and error message
 func ErrorMessageHandler(c *fiber.Ctx, err error) error {
+	if errors.Is(err, utils.ErrCircuitOpen) || errors.Is(err, utils.ErrRateLimited) {
+		return internalUtils.UpstreamError(c, err)
+	}
 	if err != nil {
 		return internalUtils.InternalServerError(c, err.Error())
 	}
 	return nil
 }
 
+// ErrorHandler responds to the errors returned by handlers. Encrypted URLs that expired or whose key was
+// rotated out get 410 Gone and modified ones 403 Forbidden, other errors are handled by Fiber.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, secureurl.ErrURLExpired), errors.Is(err, secureurl.ErrKeyExpired):
+		return internalUtils.ErrorResponse(c, fiber.StatusGone, "This link has expired. Reload the playlist or reopen the channel.")
+	case errors.Is(err, secureurl.ErrInvalidSignature):
+		return internalUtils.ForbiddenError(c, err.Error())
+	}
+	return fiber.DefaultErrorHandler(c, err)
+}
+
 // isCustomChannel checks if a given channel ID is a custom channel
 func isCustomChannel(channelID string) bool {
 	if config.Cfg.CustomChannelsFile == "" {
@@ -131,38 +152,38 @@ func isCustomChannel(channelID string) bool {
 }
 
 func isZee5Channel(channelID string) bool {
-	if !config.PluginEnabled("zee5") {
-		return false
-	}
-	for _, channel := range zee5.GetChannels() {
-		if channel.ID == channelID {
-			return true
-		}
-	}
-	return false
+	return plugins.HasChannel("zee5", channelID)
 }
 
+// reorderChannelsForDisplay lists pinned custom channels first, then JioTV, Zee5 and the other custom
+// channels, and numbers them.
 func reorderChannelsForDisplay(channels []television.Channel) []television.Channel {
 	if len(channels) == 0 {
 		return channels
 	}
 	jioChannels := make([]television.Channel, 0, len(channels))
 	zee5Channels := make([]television.Channel, 0)
+	pinnedChannels := make([]television.Channel, 0)
 	customChannels := make([]television.Channel, 0)
 	for _, channel := range channels {
 		if isCustomChannel(channel.ID) {
-			customChannels = append(customChannels, channel)
+			if channel.Pinned {
+				pinnedChannels = append(pinnedChannels, channel)
+			} else {
+				customChannels = append(customChannels, channel)
+			}
 		} else if isZee5Channel(channel.ID) {
 			zee5Channels = append(zee5Channels, channel)
 		} else {
 			jioChannels = append(jioChannels, channel)
 		}
 	}
-	ordered := make([]television.Channel, 0, len(jioChannels)+len(zee5Channels)+len(customChannels))
+	ordered := make([]television.Channel, 0, len(channels))
+	ordered = append(ordered, pinnedChannels...)
 	ordered = append(ordered, jioChannels...)
 	ordered = append(ordered, zee5Channels...)
 	ordered = append(ordered, customChannels...)
-	return ordered
+	return television.NumberChannels(ordered, config.Cfg.ChannelNumbers)
 }
 
 // IndexHandler handles the index page for `/` route
@@ -177,22 +198,30 @@ func IndexHandler(c *fiber.Ctx) error {
 		pluginChannels := plugins.GetChannels()
 		channels.Result = append(channels.Result, pluginChannels...)
 	}
+	channels.Result = television.ApplyChannelRules(channels.Result, config.Cfg.ChannelRules)
 
-	channels.Result = reorderChannelsForDisplay(channels.Result)
+	profile, err := requestProfile(c)
+	if err != nil {
+		return internalUtils.BadRequestError(c, err.Error())
+	}
+	channels.Result = profileChannels(reorderChannelsForDisplay(channels.Result), profile)
+	groups := television.ChannelGroups(channels.Result)
 
-	// Get language and category from query params
+	// Get language, category and group from query params
 	language := c.Query("language")
 	category := c.Query("category")
+	group := c.Query("group")
+	if group != "" {
+		channels.Result = television.FilterChannelsByGroups(channels.Result, []string{group})
+	}
 
 	// Process logo URLs for all channels
 	hostURL := requestHostURL(c)
 	for i, channel := range channels.Result {
-		if strings.HasPrefix(channel.LogoURL, "http://") || strings.HasPrefix(channel.LogoURL, "https://") {
-			// Custom channel with full URL, use as-is
-			channels.Result[i].LogoURL = channel.LogoURL
-		} else {
-			// Regular channel with relative path, add proxy prefix
-			channels.Result[i].LogoURL = hostURL + "/jtvimage/" + channel.LogoURL
+		channels.Result[i].LogoURL = channelLogoURL(hostURL, channel)
+		// The grid shows logos at most 80 pixels wide, so a smaller image is enough
+		if strings.HasPrefix(channels.Result[i].LogoURL, hostURL+"/jtvimage/") {
+			channels.Result[i].LogoURL += "?w=160&format=auto"
 		}
 	}
 
@@ -200,9 +229,13 @@ func IndexHandler(c *fiber.Ctx) error {
 	indexContext := fiber.Map{
 		"Title":         Title,
 		"Channels":      nil,
-		"IsNotLoggedIn": !utils.CheckLoggedIn(),
-		"Categories":    television.CategoryMap,
+		"IsNotLoggedIn": !tenantOf(c).account.CheckLoggedIn(),
+		"GuestMode":     config.Cfg.GuestMode,
+		"Offline":       television.IsOffline(),
+		"Previews":      preview.Enabled(),
+		"Categories":    profileCategories(profile),
 		"Languages":     television.LanguageMap,
+		"Groups":        groups,
 		"Qualities": map[string]string{
 			"auto":   "Quality (Auto)",
 			"high":   "High",
@@ -211,7 +244,12 @@ func IndexHandler(c *fiber.Ctx) error {
 		},
 	}
 
-	// Filter channels by query params if provided
+	if profile != nil {
+		indexContext["ProfileFavorites"] = strings.Join(profile.FavoriteChannels, ",")
+	}
+
+	// Filter channels by query params if provided, else by the default config filtering
+	channelsList := channels.Result
 	if language != "" || category != "" {
 		language_int, err := strconv.Atoi(language)
 		if err != nil {
@@ -221,20 +259,26 @@ func IndexHandler(c *fiber.Ctx) error {
 		if err != nil {
 			return ErrorMessageHandler(c, err)
 		}
-		channels_list := television.FilterChannels(channels.Result, language_int, category_int)
-		indexContext["Channels"] = channels_list
-		return c.Render("views/index", indexContext)
+		channelsList = television.FilterChannels(channels.Result, language_int, category_int)
+	} else if languages, categories := defaultFilters(profile); len(categories) > 0 || len(languages) > 0 {
+		channelsList = television.FilterChannelsByDefaults(channels.Result, categories, languages)
 	}
 
-	// If no query parameters are provided, use default config filtering
-	if len(config.Cfg.DefaultCategories) > 0 || len(config.Cfg.DefaultLanguages) > 0 {
-		channels_list := television.FilterChannelsByDefaults(channels.Result, config.Cfg.DefaultCategories, config.Cfg.DefaultLanguages)
-		indexContext["Channels"] = channels_list
-		return c.Render("views/index", indexContext)
+	// Large channel lists are split in pages, so the search has to happen on the server
+	if config.Cfg.IndexPageSize > 0 {
+		if search := strings.TrimSpace(c.Query("search")); search != "" {
+			channelsList = searchChannels(channelsList, search)
+		}
+		page, err := strconv.Atoi(c.Query("page", "1"))
+		if err != nil || page < 1 {
+			page = 1
+		}
+		var info channelsPage
+		channelsList, info = paginateChannels(channelsList, page, config.Cfg.IndexPageSize)
+		indexContext["Pagination"] = newIndexPagination(c, info)
 	}
 
-	// If no query params and no default config, return all channels
-	indexContext["Channels"] = channels.Result
+	indexContext["Channels"] = channelsList
 	return c.Render("views/index", indexContext)
 }
 
@@ -264,6 +308,7 @@ func isAbsoluteHTTPURL(streamURL string) bool {
 	return err == nil && parsed.Scheme != "" && parsed.Host != ""
 }
 
+// requestHostURL returns the base URL of the server for the request, including the path prefix of its tenant.
 func requestHostURL(c *fiber.Ctx) string {
 	host := strings.TrimSpace(c.Get(fiber.HeaderHost))
 	if host == "" {
@@ -272,7 +317,7 @@ func requestHostURL(c *fiber.Ctx) string {
 	if host == "" {
 		return ""
 	}
-	return strings.ToLower(c.Protocol()) + "://" + host
+	return strings.ToLower(c.Protocol()) + "://" + host + tenantBase(c)
 }
 
 // isTrustedPlaybackOrigin allows DRM playback only on secure origins or loopback hosts.
@@ -358,63 +403,6 @@ func toAbsoluteStreamURL(streamURL string, liveResult *television.LiveURLOutput)
 	return base + streamURL
 }
 
-func stripHDNEAFromURL(streamURL string) string {
-	if streamURL == "" {
-		return streamURL
-	}
-	parsed, err := url.Parse(streamURL)
-	if err != nil {
-		return streamURL
-	}
-	query := parsed.Query()
-	query.Del("hdnea")
-	query.Del("__hdnea__")
-	parsed.RawQuery = query.Encode()
-	return parsed.String()
-}
-
-func extractHDNEAFromURL(streamURL string) string {
-	if streamURL == "" {
-		return ""
-	}
-	parsed, err := url.Parse(streamURL)
-	if err != nil {
-		return ""
-	}
-	query := parsed.Query()
-	if token := query.Get("__hdnea__"); token != "" {
-		return token
-	}
-	if token := query.Get("hdnea"); token != "" {
-		return token
-	}
-	return ""
-}
-
-func hdneaRemainingLifetime(token string) (time.Duration, bool) {
-	if token == "" {
-		return 0, false
-	}
-
-	decodedToken, err := url.QueryUnescape(token)
-	if err == nil && decodedToken != "" {
-		token = decodedToken
-	}
-
-	expiryPattern := regexp.MustCompile(`exp=([0-9]+)`)
-	matches := expiryPattern.FindStringSubmatch(token)
-	if len(matches) != 2 {
-		return 0, false
-	}
-
-	expiryUnix, err := strconv.ParseInt(matches[1], 10, 64)
-	if err != nil {
-		return 0, false
-	}
-
-	return time.Until(time.Unix(expiryUnix, 0)), true
-}
-
 func extractLiveResultHDNEA(liveResult *television.LiveURLOutput) string {
 	if liveResult == nil {
 		return ""
@@ -438,7 +426,7 @@ func extractLiveResultHDNEA(liveResult *television.LiveURLOutput) string {
 	}
 
 	for _, candidate := range candidates {
-		if token := extractHDNEAFromURL(candidate); token != "" {
+		if token := cdntoken.HDNEA.FromURL(candidate); token != "" {
 			return token
 		}
 	}
@@ -448,17 +436,17 @@ func extractLiveResultHDNEA(liveResult *television.LiveURLOutput) string {
 
 func liveResultNeedsRefresh(liveResult *television.LiveURLOutput) bool {
 	token := extractLiveResultHDNEA(liveResult)
-	remaining, ok := hdneaRemainingLifetime(token)
+	remaining, ok := cdntoken.Remaining(token)
 	return ok && remaining <= hdneaRefreshLeadTime
 }
 
-func refreshLiveResultIfNeeded(channelID string, liveResult *television.LiveURLOutput) (*television.LiveURLOutput, error) {
+func refreshLiveResultIfNeeded(t *tenant, channelID string, liveResult *television.LiveURLOutput) (*television.LiveURLOutput, error) {
 	if channelID == "" || liveResult == nil || !liveResultNeedsRefresh(liveResult) {
 		return liveResult, nil
 	}
 
 	utils.Log.Printf("HDNEA token is near expiry for channel %s; refreshing live URL", channelID)
-	refreshedResult, err := TV.Live(channelID)
+	refreshedResult, err := t.TV().Live(channelID)
 	if err != nil {
 		return liveResult, err
 	}
@@ -470,31 +458,32 @@ func refreshLiveResultIfNeeded(channelID string, liveResult *television.LiveURLO
 	return refreshedResult, nil
 }
 
-func getCachedHDNEA(channelID string) string {
+// renewHDNEA mints a fresh HDNEA token of a channel with Television.Live after the CDN refused the
+// given token, e.g. because it expired during a live session. Requests that were refused at the same
+// time get the token renewed for the first of them instead of calling Live again.
+func (t *tenant) renewHDNEA(channelID, refused string) (string, error) {
 	if channelID == "" {
-		return ""
-	}
-	entryRaw, ok := renderHDNEACache.Load(channelID)
-	if !ok {
-		return ""
-	}
-	entry, ok := entryRaw.(hdneaCacheEntry)
-	if !ok {
-		renderHDNEACache.Delete(channelID)
-		return ""
+		return "", fmt.Errorf("no channel to renew the HDNEA token of")
 	}
-	if entry.Token == "" || time.Since(entry.UpdatedAt) > hdneaCacheTTL {
-		renderHDNEACache.Delete(channelID)
-		return ""
-	}
-	return entry.Token
-}
-
-func setCachedHDNEA(channelID, token string) {
-	if channelID == "" || token == "" {
-		return
+	token, minted, err := t.hdnea.Renew(channelID, refused, func() (string, error) {
+		liveResult, err := t.TV().Live(channelID)
+		if err != nil {
+			return "", err
+		}
+		token := extractLiveResultHDNEA(liveResult)
+		if token == "" {
+			return "", fmt.Errorf("live URL of channel %s has no HDNEA token", channelID)
+		}
+		return token, nil
+	})
+	if err == nil && minted {
+		reason := "refused"
+		if cdntoken.Expired(refused) {
+			reason = "expired"
+		}
+		utils.Log.Printf("INFO: HDNEA token of channel %s was %s by the CDN, renewed it", channelID, reason)
 	}
-	renderHDNEACache.Store(channelID, hdneaCacheEntry{Token: token, UpdatedAt: time.Now()})
+	return token, err
 }
 
 func selectBestLiveHLSURL(liveResult *television.LiveURLOutput, quality string) string {
@@ -528,6 +517,22 @@ func selectBestLiveHLSURL(liveResult *television.LiveURLOutput, quality string)
 	return ""
 }
 
+// liveQualityABR selects the full upstream master playlist with all bitrates,
+// so that adaptive players can switch between them instead of being pinned to one.
+const liveQualityABR = "abr"
+
+// selectLiveHLSURL selects the HLS URL for the quality like selectBestLiveHLSURL, and
+// the upstream master playlist for liveQualityABR.
+func selectLiveHLSURL(liveResult *television.LiveURLOutput, quality string) string {
+	if quality == liveQualityABR {
+		if liveResult != nil && isLikelyHLSURL(liveResult.Result) {
+			return liveResult.Result
+		}
+		quality = "auto"
+	}
+	return selectBestLiveHLSURL(liveResult, quality)
+}
+
 func selectBestLiveMPDURL(liveResult *television.LiveURLOutput, quality string) string {
 	if liveResult == nil {
 		return ""
@@ -549,12 +554,17 @@ func selectBestLiveMPDURL(liveResult *television.LiveURLOutput, quality string)
 
 // LiveHandler handles the live channel stream route `/live/:id.m3u8`.
 func LiveHandler(c *fiber.Ctx) error {
+	t := tenantOf(c)
 	// Ensure tokens are fresh before requesting Live stream
-	EnsureFreshCredentials()
+	t.ensureFreshCredentials()
 
 	id := c.Params("id")
 	// remove suffix .m3u8 if exists
 	id = strings.Replace(id, ".m3u8", "", 1)
+	if blockedForProfile(c, id) {
+		return internalUtils.ForbiddenError(c, errBlockedForProfile)
+	}
+	recordPlay(c, id)
 
 	// Check if this is a custom channel - serve directly for custom channels
 	if isCustomChannel(id) {
@@ -573,16 +583,16 @@ func LiveHandler(c *fiber.Ctx) error {
 	// 	// Continue with the request - tokens might still work
 	// }
 
-	liveResult, err := TV.Live(id)
+	liveResult, err := t.TV().Live(id)
 
 	// If getting Live stream failed, try refreshing tokens forcefully and retry once
-	if err != nil {
+	if err != nil && !errors.Is(err, utils.ErrCircuitOpen) && !errors.Is(err, utils.ErrRateLimited) {
 		utils.Log.Printf("First attempt to get Live stream failed: %v. Retrying after forced token refresh...", err)
 
 		// Force token refresh (bypasses 30-second interval for error recovery)
-		if ForceRefreshCredentials() {
+		if t.forceRefreshCredentials() {
 			// Retry TV.Live with fresh tokens
-			liveResult, err = TV.Live(id)
+			liveResult, err = t.TV().Live(id)
 			if err == nil {
 				utils.Log.Println("Retry successful after forced token refresh")
 			} else {
@@ -594,14 +604,22 @@ func LiveHandler(c *fiber.Ctx) error {
 	}
 	if err != nil {
 		utils.Log.Println(err)
-		return internalUtils.InternalServerError(c, err)
+		return internalUtils.UpstreamError(c, err)
 	}
-	if refreshedResult, refreshErr := refreshLiveResultIfNeeded(id, liveResult); refreshErr == nil && refreshedResult != nil {
+	if refreshedResult, refreshErr := refreshLiveResultIfNeeded(t, id, liveResult); refreshErr == nil && refreshedResult != nil {
 		liveResult = refreshedResult
 	}
 
-	liveURL := selectBestLiveHLSURL(liveResult, "auto")
+	quality := "auto"
+	if config.Cfg.LiveABR {
+		quality = liveQualityABR
+	}
+	quality = television.CapQuality(quality, television.ChannelQualityCap(id))
+	liveURL := selectLiveHLSURL(liveResult, quality)
 	if liveURL == "" {
+		if conversionURL := hlsConversionURL(c, id, liveResult); conversionURL != "" {
+			return c.Redirect(conversionURL, fiber.StatusFound)
+		}
 		error_message := "No stream found for channel id: " + id + "Status: " + liveResult.Message
 		utils.Log.Println(error_message)
 		utils.Log.Println(liveResult)
@@ -609,7 +627,7 @@ func LiveHandler(c *fiber.Ctx) error {
 	}
 	liveURL = toAbsoluteStreamURL(liveURL, liveResult)
 	if liveResult.Hdnea != "" {
-		setCachedHDNEA(id, liveResult.Hdnea)
+		t.hdnea.Set(id, liveResult.Hdnea)
 	}
 	// quote url as it will be passed as a query parameter
 	// It is required to quote the url as it may contain special characters like ? and &
@@ -619,19 +637,27 @@ func LiveHandler(c *fiber.Ctx) error {
 		utils.Log.Println(err)
 		return internalUtils.ForbiddenError(c, err)
 	}
-	redirectURL := "/render.m3u8?auth=" + coded_url + "&channel_key_id=" + id
+	redirectURL := tenantBase(c) + "/render.m3u8?auth=" + coded_url + "&channel_key_id=" + id
+	if quality == liveQualityABR {
+		redirectURL += "&q=" + quality
+	}
 	return c.Redirect(redirectURL, fiber.StatusFound)
 }
 
 // LiveQualityHandler handles the live channel stream route `/live/:quality/:id.m3u8`.
 func LiveQualityHandler(c *fiber.Ctx) error {
+	t := tenantOf(c)
 	// Ensure tokens are fresh before requesting Live stream with quality
-	EnsureFreshCredentials()
+	t.ensureFreshCredentials()
 
 	quality := c.Params("quality")
 	id := c.Params("id")
 	// remove suffix .m3u8 if exists
 	id = strings.Replace(id, ".m3u8", "", 1)
+	if blockedForProfile(c, id) {
+		return internalUtils.ForbiddenError(c, errBlockedForProfile)
+	}
+	recordPlay(c, id)
 
 	// Check if this is a custom channel - serve directly for custom channels
 	if isCustomChannel(id) {
@@ -650,16 +676,16 @@ func LiveQualityHandler(c *fiber.Ctx) error {
 	// 	// Continue with the request - tokens might still work
 	// }
 
-	liveResult, err := TV.Live(id)
+	liveResult, err := t.TV().Live(id)
 
 	// If getting Live stream failed, try refreshing tokens forcefully and retry once
-	if err != nil {
+	if err != nil && !errors.Is(err, utils.ErrCircuitOpen) && !errors.Is(err, utils.ErrRateLimited) {
 		utils.Log.Printf("First attempt to get Live stream failed: %v. Retrying after forced token refresh...", err)
 
 		// Force token refresh (bypasses 30-second interval for error recovery)
-		if ForceRefreshCredentials() {
+		if t.forceRefreshCredentials() {
 			// Retry TV.Live with fresh tokens
-			liveResult, err = TV.Live(id)
+			liveResult, err = t.TV().Live(id)
 			if err == nil {
 				utils.Log.Println("Retry successful after forced token refresh")
 			} else {
@@ -671,19 +697,24 @@ func LiveQualityHandler(c *fiber.Ctx) error {
 	}
 	if err != nil {
 		utils.Log.Println(err)
-		return internalUtils.InternalServerError(c, err)
+		return internalUtils.UpstreamError(c, err)
 	}
-	if refreshedResult, refreshErr := refreshLiveResultIfNeeded(id, liveResult); refreshErr == nil && refreshedResult != nil {
+	if refreshedResult, refreshErr := refreshLiveResultIfNeeded(t, id, liveResult); refreshErr == nil && refreshedResult != nil {
 		liveResult = refreshedResult
 	}
 	// Channels with following IDs output audio only m3u8 when quality level is enforced
 	if id == "1349" || id == "1322" {
 		quality = "auto"
+	} else {
+		quality = television.CapQuality(quality, television.ChannelQualityCap(id))
 	}
 
 	// select quality level based on query parameter and API fallbacks.
-	liveURL := selectBestLiveHLSURL(liveResult, quality)
+	liveURL := selectLiveHLSURL(liveResult, quality)
 	if liveURL == "" {
+		if conversionURL := hlsConversionURL(c, id, liveResult); conversionURL != "" {
+			return c.Redirect(conversionURL, fiber.StatusFound)
+		}
 		error_message := "No stream found for channel id: " + id + "Status: " + liveResult.Message
 		utils.Log.Println(error_message)
 		utils.Log.Println(liveResult)
@@ -691,7 +722,7 @@ func LiveQualityHandler(c *fiber.Ctx) error {
 	}
 	liveURL = toAbsoluteStreamURL(liveURL, liveResult)
 	if liveResult.Hdnea != "" {
-		setCachedHDNEA(id, liveResult.Hdnea)
+		t.hdnea.Set(id, liveResult.Hdnea)
 	}
 
 	// quote url as it will be passed as a query parameter
@@ -700,15 +731,16 @@ func LiveQualityHandler(c *fiber.Ctx) error {
 		utils.Log.Println(err)
 		return internalUtils.ForbiddenError(c, err)
 	}
-	redirectURL := "/render.m3u8?auth=" + coded_url + "&channel_key_id=" + id + "&q=" + quality
+	redirectURL := tenantBase(c) + "/render.m3u8?auth=" + coded_url + "&channel_key_id=" + id + "&q=" + quality
 	return c.Redirect(redirectURL, fiber.StatusFound)
 }
 
 // RenderHandler handles M3U8 file for modification
 // This handler shall replace JioTV server URLs with our own server URLs
 func RenderHandler(c *fiber.Ctx) error {
+	t := tenantOf(c)
 	// Ensure tokens are fresh before rendering M3U8
-	EnsureFreshCredentials()
+	t.ensureFreshCredentials()
 
 	// URL to be rendered
 	auth := c.Query("auth")
@@ -720,8 +752,11 @@ func RenderHandler(c *fiber.Ctx) error {
 	if err := internalUtils.ValidateRequiredParam("channel_key_id", channel_id); err != nil {
 		return err
 	}
-	// decrypt url
-	decoded_url, err := secureurl.DecryptURL(auth)
+	if ok, err := admitStream(c, channel_id); !ok {
+		return err
+	}
+	// decrypt url. Players reload it for as long as the channel plays, so it works past url_ttl while it is reloaded
+	decoded_url, err := secureurl.DecryptReloadURL(auth)
 	if err != nil {
 		utils.Log.Println(err)
 		return err
@@ -730,23 +765,23 @@ func RenderHandler(c *fiber.Ctx) error {
 	decoded_url = toAbsoluteStreamURL(decoded_url, nil)
 
 	// Extract fresh token from URL if present (primary source, always fresh)
-	urlToken := extractHDNEAFromURL(decoded_url)
+	urlToken := cdntoken.HDNEA.FromURL(decoded_url)
 	var cachedHDNEA string
 
 	// AGGRESSIVE REFRESH: Always prefer fresh URL token over cache to prevent 403 errors from stale tokens
 	if urlToken != "" {
 		cachedHDNEA = urlToken
 	} else {
-		cachedHDNEA = getCachedHDNEA(channel_id)
+		cachedHDNEA = t.hdnea.Get(channel_id)
 	}
 
-	if remaining, ok := hdneaRemainingLifetime(cachedHDNEA); ok && remaining <= hdneaRefreshLeadTime {
-		if refreshedResult, refreshErr := TV.Live(channel_id); refreshErr == nil && refreshedResult != nil {
-			if refreshedURL := selectBestLiveHLSURL(refreshedResult, c.Query("q")); refreshedURL != "" {
+	if remaining, ok := cdntoken.Remaining(cachedHDNEA); ok && remaining <= hdneaRefreshLeadTime {
+		if refreshedResult, refreshErr := t.TV().Live(channel_id); refreshErr == nil && refreshedResult != nil {
+			if refreshedURL := selectLiveHLSURL(refreshedResult, c.Query("q")); refreshedURL != "" {
 				decoded_url = toAbsoluteStreamURL(refreshedURL, refreshedResult)
 				cachedHDNEA = extractLiveResultHDNEA(refreshedResult)
 				if cachedHDNEA != "" {
-					setCachedHDNEA(channel_id, cachedHDNEA)
+					t.hdnea.Set(channel_id, cachedHDNEA)
 				}
 			}
 		}
@@ -762,11 +797,11 @@ func RenderHandler(c *fiber.Ctx) error {
 			sourceStr = "none"
 		}
 		utils.Log.Printf("[DEBUG] Token selection - URL token: %s | Cached token: %s | Using: %s (source: %s)",
-			truncateToken(urlToken), truncateToken(getCachedHDNEA(channel_id)), truncateToken(cachedHDNEA), sourceStr)
+			truncateToken(urlToken), truncateToken(t.hdnea.Get(channel_id)), truncateToken(cachedHDNEA), sourceStr)
 	}
 
 	renderURL := decoded_url
-	renderResult, statusCode, newHdnea := TV.Render(renderURL, cachedHDNEA)
+	renderResult, statusCode, newHdnea := t.TV().Render(withDeliveryDirectives(c, renderURL), cachedHDNEA)
 
 	// DEBUG: Log token extraction and response
 	if os.Getenv("JIOTV_DEBUG") == "true" {
@@ -775,7 +810,7 @@ func RenderHandler(c *fiber.Ctx) error {
 
 	// Always cache fresh token from response for fallback on next request
 	if newHdnea != "" {
-		setCachedHDNEA(channel_id, newHdnea)
+		t.hdnea.Set(channel_id, newHdnea)
 		cachedHDNEA = newHdnea
 	}
 
@@ -787,16 +822,22 @@ func RenderHandler(c *fiber.Ctx) error {
 		}
 
 		// Force refresh credentials again because the upstream already rejected the request
-		ForceRefreshCredentials()
-
-		// Clear cache first
-		renderHDNEACache.Delete(channel_id)
-
-		// Retry the render call with no cached token (forces CDN to provide fresh)
-		renderResult, statusCode, newHdnea = TV.Render(renderURL, "")
+		t.forceRefreshCredentials()
+
+		// Retry with a fresh token of the channel, or without a token if none can be minted
+		// (forces CDN to provide fresh)
+		if freshHDNEA, renewErr := t.renewHDNEA(channel_id, cachedHDNEA); renewErr == nil {
+			renderURL = cdntoken.HDNEA.Strip(renderURL)
+			cachedHDNEA = freshHDNEA
+			renderResult, statusCode, newHdnea = t.TV().Render(withDeliveryDirectives(c, renderURL), freshHDNEA)
+		} else {
+			utils.Log.Printf("WARN: Failed to renew the HDNEA token of channel %s: %v", channel_id, renewErr)
+			t.hdnea.Delete(channel_id)
+			renderResult, statusCode, newHdnea = t.TV().Render(withDeliveryDirectives(c, renderURL), "")
+		}
 
 		if newHdnea != "" {
-			setCachedHDNEA(channel_id, newHdnea)
+			t.hdnea.Set(channel_id, newHdnea)
 			cachedHDNEA = newHdnea
 			if os.Getenv("JIOTV_DEBUG") == "true" {
 				utils.Log.Printf("[DEBUG] RenderHandler retry: Got fresh token")
@@ -808,12 +849,12 @@ func RenderHandler(c *fiber.Ctx) error {
 		}
 	} else if statusCode == fiber.StatusNotFound {
 		wasNotFound := true
-		strippedURL := stripHDNEAFromURL(decoded_url)
+		strippedURL := cdntoken.HDNEA.Strip(decoded_url)
 		if strippedURL != renderURL {
 			renderURL = strippedURL
-			renderResult, statusCode, newHdnea = TV.Render(renderURL, cachedHDNEA)
+			renderResult, statusCode, newHdnea = t.TV().Render(withDeliveryDirectives(c, renderURL), cachedHDNEA)
 			if newHdnea != "" {
-				setCachedHDNEA(channel_id, newHdnea)
+				t.hdnea.Set(channel_id, newHdnea)
 				cachedHDNEA = newHdnea
 			}
 		}
@@ -827,9 +868,9 @@ func RenderHandler(c *fiber.Ctx) error {
 				retryQuality = "auto"
 			}
 
-			if refreshedLiveResult, refreshErr := TV.Live(channel_id); refreshErr == nil && refreshedLiveResult != nil {
+			if refreshedLiveResult, refreshErr := t.TV().Live(channel_id); refreshErr == nil && refreshedLiveResult != nil {
 				if freshToken := extractLiveResultHDNEA(refreshedLiveResult); freshToken != "" {
-					setCachedHDNEA(channel_id, freshToken)
+					t.hdnea.Set(channel_id, freshToken)
 					cachedHDNEA = freshToken
 				}
 
@@ -837,7 +878,7 @@ func RenderHandler(c *fiber.Ctx) error {
 				triedURL := map[string]bool{renderURL: true}
 
 				for _, candidateQuality := range qualityCandidates {
-					candidateURL := selectBestLiveHLSURL(refreshedLiveResult, candidateQuality)
+					candidateURL := selectLiveHLSURL(refreshedLiveResult, candidateQuality)
 					candidateURL = toAbsoluteStreamURL(candidateURL, refreshedLiveResult)
 					if candidateURL == "" || triedURL[candidateURL] {
 						continue
@@ -849,9 +890,9 @@ func RenderHandler(c *fiber.Ctx) error {
 					}
 
 					renderURL = candidateURL
-					renderResult, statusCode, newHdnea = TV.Render(renderURL, cachedHDNEA)
+					renderResult, statusCode, newHdnea = t.TV().Render(renderURL, cachedHDNEA)
 					if newHdnea != "" {
-						setCachedHDNEA(channel_id, newHdnea)
+						t.hdnea.Set(channel_id, newHdnea)
 						cachedHDNEA = newHdnea
 					}
 
@@ -864,74 +905,12 @@ func RenderHandler(c *fiber.Ctx) error {
 	}
 	// No client cookie: if upstream rotated __hdnea__, we'll embed the fresh token into rewritten URLs below
 
-	// baseUrl is the part of the url excluding suffix file.m3u8 and params is the part of the url after the suffix
-	split_url_by_params := strings.Split(renderURL, "?")
-	baseStringUrl := split_url_by_params[0]
-	// Pattern to match file names ending with .m3u8
-	pattern := `[a-z0-9=\_\-A-Z\.]*\.m3u8`
-	re := regexp.MustCompile(pattern)
-	// Add baseUrl to all the file names ending with .m3u8
-	baseUrl := []byte(re.ReplaceAllString(baseStringUrl, ""))
-	params := ""
-	if len(split_url_by_params) > 1 {
-		params = split_url_by_params[1]
-	}
-	if params != "" {
-		if parsedParams, parseErr := url.ParseQuery(params); parseErr == nil {
-			parsedParams.Del("hdnea")
-			parsedParams.Del("__hdnea__")
-			encodedParams := parsedParams.Encode()
-			if cachedHDNEA != "" {
-				if encodedParams != "" {
-					params = encodedParams + "&__hdnea__=" + cachedHDNEA
-				} else {
-					params = "__hdnea__=" + cachedHDNEA
-				}
-			} else {
-				params = encodedParams
-			}
-		}
-	} else if cachedHDNEA != "" {
-		params = "__hdnea__=" + cachedHDNEA
-	}
-
-	// replacer replaces all the file names ending with .m3u8 and .ts with our own server URLs
-	// More info: https://golang.org/pkg/regexp/#Regexp.ReplaceAllFunc
-	replacer := func(match []byte) []byte {
-		switch {
-		case bytes.HasSuffix(match, []byte(".m3u8")):
-			return television.ReplaceM3U8(baseUrl, match, params, channel_id, c.Query("q"))
-		case bytes.HasSuffix(match, []byte(".ts")):
-			return television.ReplaceTS(baseUrl, match, params, channel_id)
-		case bytes.HasSuffix(match, []byte(".aac")):
-			return television.ReplaceAAC(baseUrl, match, params, channel_id)
-		default:
-			return match
-		}
-	}
-
-	// Pattern to match file names ending with .m3u8 and .ts
-	pattern = `[a-z0-9=\_\-A-Z\/\.]*\.(m3u8|ts|aac)`
-	re = regexp.MustCompile(pattern)
-	// Execute replacer function on renderResult
-	renderResult = re.ReplaceAllFunc(renderResult, replacer)
-
-	// replacer_key replaces all the URLs ending with .key and .pkey with our own server URLs
-	replacer_key := func(match []byte) []byte {
-		switch {
-		case bytes.HasSuffix(match, []byte(".key")) || bytes.HasSuffix(match, []byte(".pkey")):
-			return television.ReplaceKey(match, params, channel_id)
-		default:
-			return match
-		}
-	}
+	// params is the part of the url after the playlist path, added to all upstream URLs of the playlist
+	_, params, _ := strings.Cut(renderURL, "?")
+	params = cdntoken.HDNEA.SetQuery(params, cachedHDNEA)
 
-	// Pattern to match URLs ending with .key and .pkey
-	pattern_key := `http[\S]+\.(pkey|key)`
-	re_key := regexp.MustCompile(pattern_key)
-
-	// Execute replacer_key function on renderResult
-	renderResult = re_key.ReplaceAllFunc(renderResult, replacer_key)
+	// Point all playlists, segments and keys of the playlist at our own server URLs
+	renderResult = television.RewritePlaylist(renderResult, renderURL, params, channel_id, c.Query("q"))
 
 	if hostURL := requestHostURL(c); hostURL != "" {
 		prefix := []byte("/render.")
@@ -940,11 +919,14 @@ func RenderHandler(c *fiber.Ctx) error {
 			renderResult = append(absolutePrefix, renderResult[len(prefix):]...)
 		}
 		renderResult = bytes.ReplaceAll(renderResult, []byte("\n/render."), []byte("\n"+hostURL+"/render."))
+		renderResult = bytes.ReplaceAll(renderResult, []byte(`URI="/render.`), []byte(`URI="`+hostURL+"/render."))
 	}
 
 	if statusCode != fiber.StatusOK {
 		utils.Log.Println("Error rendering M3U8 file")
 		utils.Log.Println(string(renderResult))
+	} else {
+		renderResult = manifest.Apply(channel_id, renderResult)
 	}
 	internalUtils.SetMustRevalidateHeader(c, 3)
 
@@ -955,8 +937,33 @@ func RenderHandler(c *fiber.Ctx) error {
 	return c.Status(statusCode).Send(renderResult)
 }
 
+// hlsDeliveryDirectives are the query parameters low-latency HLS players add to playlist requests
+// for blocking playlist reloads and playlist delta updates
+var hlsDeliveryDirectives = []string{"_HLS_msn", "_HLS_part", "_HLS_skip"}
+
+// withDeliveryDirectives adds the delivery directives of the player request to the upstream playlist URL,
+// so that the CDN holds the playlist until the segment or part the player waits for is available.
+// Without them, players of channels with EXT-X-SERVER-CONTROL get the same playlist again and stall.
+func withDeliveryDirectives(c *fiber.Ctx, playlistURL string) string {
+	directives := url.Values{}
+	for _, name := range hlsDeliveryDirectives {
+		if value := c.Query(name); value != "" {
+			directives.Set(name, value)
+		}
+	}
+	if len(directives) == 0 {
+		return playlistURL
+	}
+	sep := "?"
+	if strings.Contains(playlistURL, "?") {
+		sep = "&"
+	}
+	return playlistURL + sep + directives.Encode()
+}
+
 // SLHandler proxies requests to SonyLiv CDN
 func SLHandler(c *fiber.Ctx) error {
+	t := tenantOf(c)
 	// Request path with query params
 	url := "https://lin-gd-001-cf.slivcdn.com" + c.Path() + "?" + string(c.Request().URI().QueryString())
 	if url[len(url)-1:] == "?" {
@@ -964,7 +971,7 @@ func SLHandler(c *fiber.Ctx) error {
 	}
 	// Delete all browser headers
 	internalUtils.SetPlayerHeaders(c, PLAYER_USER_AGENT)
-	if err := proxy.Do(c, url, TV.Client); err != nil {
+	if err := proxy.Do(c, url, t.TV().Client); err != nil {
 		return err
 	}
 
@@ -975,15 +982,14 @@ func SLHandler(c *fiber.Ctx) error {
 
 // RenderKeyHandler requests m3u8 key from JioTV server
 func RenderKeyHandler(c *fiber.Ctx) error {
+	t := tenantOf(c)
 	// Ensure tokens are fresh before requesting DRM key
-	EnsureFreshCredentials()
+	t.ensureFreshCredentials()
 
 	channel_id := c.Query("channel_key_id")
 	auth := c.Query("auth")
 	// parse incoming hdnea query and set as request cookie only for upstream call (no client cookie)
-	if hdnea := c.Query("hdnea"); hdnea != "" {
-		c.Request().Header.SetCookie("__hdnea__", hdnea)
-	}
+	cdntoken.HDNEA.SetCookie(&c.Request().Header, c.Query(cdntoken.HDNEA.Param))
 	// decode url
 	decoded_url, err := internalUtils.DecryptURLParam("auth", auth)
 	if err != nil {
@@ -1000,30 +1006,20 @@ func RenderKeyHandler(c *fiber.Ctx) error {
 		c.Request().Header.SetCookie(key, value)
 	}
 	// ensure __hdnea__ cookie exists if available from params
-	if strings.Contains(params, "hdnea=") {
-		for _, p := range strings.Split(params, "&") {
-			if strings.HasPrefix(p, "hdnea=") {
-				c.Request().Header.SetCookie("__hdnea__", strings.TrimPrefix(p, "hdnea="))
-				break
-			} else if strings.HasPrefix(p, "__hdnea__=") {
-				c.Request().Header.SetCookie("__hdnea__", strings.TrimPrefix(p, "__hdnea__="))
-				break
-			}
-		}
-	}
+	cdntoken.HDNEA.SetCookie(&c.Request().Header, cdntoken.HDNEA.FromQuery(params))
 
 	// Copy headers from the Television headers map to the request
-	for key, value := range TV.Headers {
+	for key, value := range t.TV().Headers {
 		c.Request().Header.Set(key, value) // Assuming only one value for each header
 	}
 	c.Request().Header.Set("srno", "230203144000")
-	c.Request().Header.Set("ssotoken", TV.SsoToken)
+	c.Request().Header.Set("ssotoken", t.TV().SsoToken)
 	c.Request().Header.Set("channelId", channel_id)
 	c.Request().Header.Set("User-Agent", PLAYER_USER_AGENT)
-	if newHdnea, err := internalUtils.ProxyRequest(c, decoded_url, TV.Client, PLAYER_USER_AGENT); err != nil {
+	if newHdnea, err := internalUtils.ProxyRequest(c, decoded_url, t.TV().Client, PLAYER_USER_AGENT); err != nil {
 		return err
 	} else if newHdnea != "" && channel_id != "" {
-		setCachedHDNEA(channel_id, newHdnea)
+		t.hdnea.Set(channel_id, newHdnea)
 	}
 
 	statusCode := c.Response().StatusCode()
@@ -1032,10 +1028,11 @@ func RenderKeyHandler(c *fiber.Ctx) error {
 			utils.Log.Printf("[DEBUG] RenderKeyHandler got %d response - forcing refresh and retrying", statusCode)
 		}
 
+		refusedHDNEA := string(c.Request().Header.Cookie(cdntoken.HDNEA.Cookie))
 		c.Response().Reset()
-		c.Request().Header.DelCookie("__hdnea__")
-		retryUrl := stripHDNEAFromURL(decoded_url)
-		ForceRefreshCredentials()
+		c.Request().Header.DelCookie(cdntoken.HDNEA.Cookie)
+		retryUrl := cdntoken.HDNEA.Strip(decoded_url)
+		t.forceRefreshCredentials()
 
 		// Rebuild the request cookies from the stripped URL for a clean retry
 		if retryParams := strings.Split(retryUrl, "?"); len(retryParams) > 1 {
@@ -1047,18 +1044,21 @@ func RenderKeyHandler(c *fiber.Ctx) error {
 			}
 		}
 
-		for key, value := range TV.Headers {
+		for key, value := range t.TV().Headers {
 			c.Request().Header.Set(key, value)
 		}
 		c.Request().Header.Set("srno", "230203144000")
-		c.Request().Header.Set("ssotoken", TV.SsoToken)
+		c.Request().Header.Set("ssotoken", t.TV().SsoToken)
 		c.Request().Header.Set("channelId", channel_id)
 		c.Request().Header.Set("User-Agent", PLAYER_USER_AGENT)
+		if freshHDNEA, renewErr := t.renewHDNEA(channel_id, refusedHDNEA); renewErr == nil {
+			cdntoken.HDNEA.SetCookie(&c.Request().Header, freshHDNEA)
+		}
 
-		if retryHdnea, err := internalUtils.ProxyRequest(c, retryUrl, TV.Client, PLAYER_USER_AGENT); err != nil {
+		if retryHdnea, err := internalUtils.ProxyRequest(c, retryUrl, t.TV().Client, PLAYER_USER_AGENT); err != nil {
 			return err
 		} else if retryHdnea != "" && channel_id != "" {
-			setCachedHDNEA(channel_id, retryHdnea)
+			t.hdnea.Set(channel_id, retryHdnea)
 		}
 	}
 	c.Response().Header.Del(fiber.HeaderServer)
@@ -1067,45 +1067,36 @@ func RenderKeyHandler(c *fiber.Ctx) error {
 
 // RenderTSHandler loads TS file from JioTV server
 func RenderTSHandler(c *fiber.Ctx) error {
+	t := tenantOf(c)
 	// Ensure tokens are fresh before proxying TS segments
-	EnsureFreshCredentials()
+	t.ensureFreshCredentials()
 
 	channelID := c.Query("channel_key_id")
+	if channelID != "" {
+		if ok, err := admitStream(c, channelID); !ok {
+			return err
+		}
+	}
 	auth := c.Query("auth")
 	// parse incoming hdnea query and set as request cookie only for upstream call (no client cookie)
-	if hdnea := c.Query("hdnea"); hdnea != "" {
-		c.Request().Header.SetCookie("__hdnea__", hdnea)
-	}
+	cdntoken.HDNEA.SetCookie(&c.Request().Header, c.Query(cdntoken.HDNEA.Param))
 	// decode url
 	decoded_url, err := internalUtils.DecryptURLParam("auth", auth)
 	if err != nil {
-		utils.Log.Panicln(err)
+		utils.Log.Println(err)
 		return err
 	}
 
 	// Check if decoded_url has hdnea or __hdnea__ and set cookie if not already set
 	// This is crucial when hdnea is embedded in the encrypted auth URL but not in the request query params
-	if len(c.Request().Header.Cookie("__hdnea__")) == 0 && strings.Contains(decoded_url, "hdnea=") {
-		qIdx := strings.Index(decoded_url, "?")
-		if qIdx != -1 {
-			params := decoded_url[qIdx+1:]
-			for _, p := range strings.Split(params, "&") {
-				if strings.HasPrefix(p, "hdnea=") {
-					c.Request().Header.SetCookie("__hdnea__", strings.TrimPrefix(p, "hdnea="))
-					break
-				}
-				if strings.HasPrefix(p, "__hdnea__=") {
-					c.Request().Header.SetCookie("__hdnea__", strings.TrimPrefix(p, "__hdnea__="))
-					break
-				}
-			}
-		}
+	if len(c.Request().Header.Cookie(cdntoken.HDNEA.Cookie)) == 0 {
+		cdntoken.HDNEA.SetCookie(&c.Request().Header, cdntoken.HDNEA.FromURL(decoded_url))
 	}
 
-	if newHdnea, err := internalUtils.ProxyRequest(c, decoded_url, TV.Client, PLAYER_USER_AGENT); err != nil {
+	if newHdnea, err := internalUtils.ProxyRequest(c, decoded_url, t.TV().Client, PLAYER_USER_AGENT); err != nil {
 		return err
 	} else if newHdnea != "" && channelID != "" {
-		setCachedHDNEA(channelID, newHdnea)
+		t.hdnea.Set(channelID, newHdnea)
 	}
 
 	statusCode := c.Response().StatusCode()
@@ -1114,32 +1105,31 @@ func RenderTSHandler(c *fiber.Ctx) error {
 			utils.Log.Printf("[DEBUG] RenderTSHandler got %d response - forcing refresh and retrying", statusCode)
 		}
 
+		refusedHDNEA := string(c.Request().Header.Cookie(cdntoken.HDNEA.Cookie))
 		c.Response().Reset()
-		c.Request().Header.DelCookie("__hdnea__")
-		ForceRefreshCredentials()
+		c.Request().Header.DelCookie(cdntoken.HDNEA.Cookie)
+		t.forceRefreshCredentials()
 
-		retryUrl := stripHDNEAFromURL(decoded_url)
+		retryUrl := cdntoken.HDNEA.Strip(decoded_url)
 		if channelID != "" {
-			if refreshedResult, refreshErr := TV.Live(channelID); refreshErr == nil && refreshedResult != nil {
-				if refreshedHDNEA := extractLiveResultHDNEA(refreshedResult); refreshedHDNEA != "" {
-					setCachedHDNEA(channelID, refreshedHDNEA)
-					c.Request().Header.SetCookie("__hdnea__", refreshedHDNEA)
-				}
+			if freshHDNEA, renewErr := t.renewHDNEA(channelID, refusedHDNEA); renewErr == nil {
+				cdntoken.HDNEA.SetCookie(&c.Request().Header, freshHDNEA)
+			} else {
+				utils.Log.Printf("WARN: Failed to renew the HDNEA token of channel %s: %v", channelID, renewErr)
 			}
 
-			if len(c.Request().Header.Cookie("__hdnea__")) == 0 {
-				if cachedHDNEA := getCachedHDNEA(channelID); cachedHDNEA != "" {
-					c.Request().Header.SetCookie("__hdnea__", cachedHDNEA)
-				}
+			if len(c.Request().Header.Cookie(cdntoken.HDNEA.Cookie)) == 0 {
+				cdntoken.HDNEA.SetCookie(&c.Request().Header, t.hdnea.Get(channelID))
 			}
 		}
 
-		if newHdnea, err := internalUtils.ProxyRequest(c, retryUrl, TV.Client, PLAYER_USER_AGENT); err != nil {
+		if newHdnea, err := internalUtils.ProxyRequest(c, retryUrl, t.TV().Client, PLAYER_USER_AGENT); err != nil {
 			return err
 		} else if newHdnea != "" && channelID != "" {
-			setCachedHDNEA(channelID, newHdnea)
+			t.hdnea.Set(channelID, newHdnea)
 		}
 	}
+	meterStream(c, channelID)
 
 	return nil
 }
@@ -1148,81 +1138,40 @@ func RenderTSHandler(c *fiber.Ctx) error {
 // Also to generate M3U playlist
 func ChannelsHandler(c *fiber.Ctx) error {
 
-	quality := strings.TrimSpace(c.Query("q"))
-	splitCategory := strings.TrimSpace(c.Query("c"))
-	languages := strings.TrimSpace(c.Query("l"))
-	skipGenres := strings.TrimSpace(c.Query("sg"))
-	apiResponse, err := television.Channels()
+	apiResponse, err := listedChannels(strings.TrimSpace(c.Query("g")))
 	if err != nil {
 		return ErrorMessageHandler(c, err)
 	}
 
-	if len(config.Cfg.Plugins) > 0 {
-		pluginChannels := plugins.GetChannels()
-		apiResponse.Result = append(apiResponse.Result, pluginChannels...)
-	}
-
 	// hostUrl should be request URL like http://localhost:5001
 	hostURL := requestHostURL(c)
 
 	// Check if the query parameter "type" is set to "m3u"
 	if c.Query("type") == "m3u" {
-		// Create an M3U playlist
-		m3uContent := "#EXTM3U x-tvg-url=\"" + hostURL + "/epg.xml.gz\"\n"
-		logoURL := hostURL + "/jtvimage"
-		allChannels := reorderChannelsForDisplay(apiResponse.Result)
-		for _, channel := range allChannels {
-
-			if languages != "" && !utils.ContainsString(television.LanguageMap[channel.Language], strings.Split(languages, ",")) {
-				continue
-			}
-
-			if skipGenres != "" && utils.ContainsString(television.CategoryMap[channel.Category], strings.Split(skipGenres, ",")) {
-				continue
-			}
+		opts, err := parsePlaylistOptions(c)
+		if err != nil {
+			return internalUtils.BadRequestError(c, err.Error())
+		}
+		channels := filterPlaylistChannels(reorderChannelsForDisplay(apiResponse.Result), opts)
 
-			var channelURL string
-			if channel.IsCustom && channel.URL != "" {
-				if quality != "" {
-					channelURL = fmt.Sprintf("%s/%s?q=%s", hostURL, channel.URL, quality)
-				} else {
-					channelURL = fmt.Sprintf("%s/%s", hostURL, channel.URL)
-				}
-			} else {
-				if quality != "" {
-					channelURL = fmt.Sprintf("%s/live/%s/%s.m3u8", hostURL, quality, channel.ID)
-				} else {
-					channelURL = fmt.Sprintf("%s/live/%s.m3u8", hostURL, channel.ID)
-				}
-			}
-			var channelLogoURL string
-			if strings.HasPrefix(channel.LogoURL, "http://") || strings.HasPrefix(channel.LogoURL, "https://") {
-				// Custom channel with full URL
-				channelLogoURL = channel.LogoURL
-			} else {
-				// Regular channel with relative path
-				channelLogoURL = fmt.Sprintf("%s/%s", logoURL, channel.LogoURL)
-			}
-			var groupTitle string
-			switch splitCategory {
-			case "split":
-				groupTitle = fmt.Sprintf("%s - %s", television.CategoryMap[channel.Category], television.LanguageMap[channel.Language])
-			case "language":
-				groupTitle = television.LanguageMap[channel.Language]
-			default:
-				groupTitle = television.CategoryMap[channel.Category]
-			}
-			m3uContent += fmt.Sprintf("#EXTINF:-1 tvg-id=%q tvg-name=%q tvg-logo=%q tvg-language=%q tvg-type=%q group-title=%q, %s\n%s\n",
-				channel.ID, channel.Name, channelLogoURL, television.LanguageMap[channel.Language], television.CategoryMap[channel.Category], groupTitle, channel.Name, channelURL)
+		if opts.Format == playlistFormatEnigma2 {
+			return sendEnigma2Bouquet(c, channels, hostURL, opts)
 		}
 
+		// Create an M3U playlist
+		m3uContent := buildM3UPlaylist(channels, hostURL, maintenance.Announcement(time.Now()), opts)
+
 		// Set the Content-Disposition header for file download
 		c.Set("Content-Disposition", "attachment; filename=jiotv_playlist.m3u")
 		c.Set("Content-Type", "application/vnd.apple.mpegurl") // Set the video M3U MIME type
 		return c.SendStream(strings.NewReader(m3uContent))
 	}
 
-	apiResponse.Result = reorderChannelsForDisplay(apiResponse.Result)
+	profile, err := requestProfile(c)
+	if err != nil {
+		return internalUtils.BadRequestError(c, err.Error())
+	}
+	apiResponse.Result = profileChannels(reorderChannelsForDisplay(apiResponse.Result), profile)
 	for i, channel := range apiResponse.Result {
 		if isZee5Channel(channel.ID) {
 			apiResponse.Result[i].URL = fmt.Sprintf("%s/%s", hostURL, channel.URL)
@@ -1231,13 +1180,92 @@ func ChannelsHandler(c *fiber.Ctx) error {
 		}
 	}
 
+	if page, limit, paged, err := parseChannelsPage(c); err != nil {
+		return internalUtils.BadRequestError(c, err.Error())
+	} else if paged {
+		response := pagedChannelsResponse{ChannelsResponse: apiResponse}
+		response.Result, response.channelsPage = paginateChannels(apiResponse.Result, page, limit)
+		return c.JSON(response)
+	}
+
 	return c.JSON(apiResponse)
 }
 
+// listedChannels returns the JioTV, custom and plugin channels with the channel rules applied,
+// only those in one of the comma separated groups if any are given.
+func listedChannels(groups string) (television.ChannelsResponse, error) {
+	apiResponse, err := television.Channels()
+	if err != nil {
+		return apiResponse, err
+	}
+
+	if len(config.Cfg.Plugins) > 0 {
+		pluginChannels := plugins.GetChannels()
+		apiResponse.Result = append(apiResponse.Result, pluginChannels...)
+	}
+	apiResponse.Result = television.ApplyChannelRules(apiResponse.Result, config.Cfg.ChannelRules)
+	if groups != "" {
+		apiResponse.Result = television.FilterChannelsByGroups(apiResponse.Result, strings.Split(groups, ","))
+	}
+	return apiResponse, nil
+}
+
+// DisplayedChannels returns the JioTV, custom and plugin channels after the channel rules,
+// in the order of the channel list.
+func DisplayedChannels() ([]television.Channel, error) {
+	apiResponse, err := listedChannels("")
+	if err != nil {
+		return nil, err
+	}
+	return reorderChannelsForDisplay(apiResponse.Result), nil
+}
+
+// ChannelChangesHandler returns the recent changes of the JioTV channel list on `/api/v1/channels/changes`.
+// Changes are recorded when the cached channel list is refreshed.
+func ChannelChangesHandler(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"changes": television.GetChannelChanges(),
+	})
+}
+
+// ChannelNumberHandler looks up the channel with a channel number on `/api/v1/channels/number/:number`,
+// so that the web interface can switch to a channel by typing its number.
+func ChannelNumberHandler(c *fiber.Ctx) error {
+	number, err := strconv.Atoi(c.Params("number"))
+	if err != nil || number <= 0 {
+		return internalUtils.BadRequestError(c, "Invalid channel number")
+	}
+	apiResponse, err := television.Channels()
+	if err != nil {
+		return internalUtils.UpstreamError(c, err)
+	}
+	if len(config.Cfg.Plugins) > 0 {
+		apiResponse.Result = append(apiResponse.Result, plugins.GetChannels()...)
+	}
+	apiResponse.Result = television.ApplyChannelRules(apiResponse.Result, config.Cfg.ChannelRules)
+	for _, channel := range reorderChannelsForDisplay(apiResponse.Result) {
+		if channel.Number == number {
+			return c.JSON(fiber.Map{
+				"channel_id":     channel.ID,
+				"channel_name":   channel.Name,
+				"channel_number": channel.Number,
+			})
+		}
+	}
+	return internalUtils.NotFoundError(c, fmt.Sprintf("No channel has the number %d", number))
+}
+
 // PlayHandler loads HTML Page with video player iframe embedded with video URL
 // URL is generated from the channel ID
 func PlayHandler(c *fiber.Ctx) error {
+	t := tenantOf(c)
 	id := c.Params("id")
+	if blockedForProfile(c, id) {
+		return internalUtils.ForbiddenError(c, errBlockedForProfile)
+	}
+	if !canStartStream(c, id) {
+		return streamLimitResponse(c)
+	}
 	quality := c.Query("q")
 	requestedQuality := quality
 	if quality == "" {
@@ -1261,11 +1289,12 @@ func PlayHandler(c *fiber.Ctx) error {
 			"Title":      Title,
 			"player_url": player_url,
 			"ChannelID":  id,
+			"StartOver":  true,
 		})
 	}
 
 	// Ensure tokens are fresh before making API call for DRM channels
-	if err := EnsureFreshTokens(); err != nil {
+	if err := t.ensureFreshTokens(); err != nil {
 		utils.Log.Printf("Failed to ensure fresh tokens: %v", err)
 		// Continue with the request - tokens might still work or it might be a custom channel
 	}
@@ -1290,6 +1319,7 @@ func PlayHandler(c *fiber.Ctx) error {
 		"player_url":             player_url,
 		"ChannelID":              id,
 		"force_auto_player_mode": forceAutoPlayerMode,
+		"StartOver":              true,
 	})
 }
 
@@ -1298,33 +1328,66 @@ func PlayerHandler(c *fiber.Ctx) error {
 	id := c.Params("id")
 	quality := c.Query("q")
 	autoplayFallback := c.Query("af") == "1"
-	play_url := utils.BuildHLSPlayURL(quality, id)
+	play_url := signLiveLink(utils.BuildHLSPlayURL(quality, id))
+	// Play JioTV channels from the timeshift buffer, so that they can be paused and rewound
+	isTimeshift := timeshift.Enabled() && !isCustomChannel(id) && !isZee5Channel(id)
+	statsURL := ""
+	if isTimeshift {
+		play_url = "/timeshift/" + id + "/index.m3u8"
+		// The player shows the health of the timeshift buffer in its stats
+		statsURL = "/api/v1/stream/" + id + "/stats"
+		if quality != "" {
+			play_url += "?q=" + quality
+			statsURL += "?q=" + quality
+		}
+		recordPlay(c, id)
+	}
 	internalUtils.SetCacheHeader(c, 3600)
 	return c.Render("views/player_hls", fiber.Map{
 		"play_url":          play_url,
 		"autoplay_fallback": autoplayFallback,
+		"is_timeshift":      isTimeshift,
+		"stats_url":         statsURL,
 	})
 }
 
 // FaviconHandler Responds for favicon.ico request
 func FaviconHandler(c *fiber.Ctx) error {
-	return c.Redirect("/static/favicon.ico", fiber.StatusMovedPermanently)
+	if FaviconURL != "" && FaviconURL != defaultFaviconURL {
+		// Not permanent, as the favicon may be changed in the config
+		return c.Redirect(FaviconURL, fiber.StatusFound)
+	}
+	return c.Redirect(defaultFaviconURL, fiber.StatusMovedPermanently)
 }
 
 // PlaylistHandler is the route for generating M3U playlist only
 // For user convenience, redirect to /channels?type=m3u
 func PlaylistHandler(c *fiber.Ctx) error {
-	quality := c.Query("q")
-	splitCategory := c.Query("c")
-	languages := c.Query("l")
-	skipGenres := c.Query("sg")
-	return c.Redirect("/channels?type=m3u&q="+quality+"&c="+splitCategory+"&l="+languages+"&sg="+skipGenres, fiber.StatusMovedPermanently)
+	// Pass on all playlist options, like the quality, filters and format
+	query, err := url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return internalUtils.BadRequestError(c, "Invalid query")
+	}
+	query.Set("type", "m3u")
+	return c.Redirect(tenantBase(c)+"/channels?"+query.Encode(), fiber.StatusMovedPermanently)
 }
 
-// ImageHandler loads image from JioTV server
+// ImageHandler loads image from JioTV server, resized with the `w`, `h` and `format` query params
 func ImageHandler(c *fiber.Ctx) error {
-	url := "https://jiotv.catchup.cdn.jio.com/dare_images/images/" + c.Params("file")
-	_, err := internalUtils.ProxyRequest(c, url, TV.Client, REQUEST_USER_AGENT)
+	t := tenantOf(c)
+	url := urls.ChannelImageURLSlash + c.Params("file")
+	transform, err := imageTransform(c)
+	if err != nil {
+		return internalUtils.BadRequestError(c, err.Error())
+	}
+	if !transform.IsZero() {
+		data, err := transformedImage("images/"+c.Params("file"), url, t.TV().Client, transform)
+		if err == nil {
+			return sendImage(c, data)
+		}
+		utils.Log.Printf("WARN: Failed to resize image %s: %v", c.Params("file"), err)
+	}
+	_, err = internalUtils.ProxyRequest(c, url, t.TV().Client, REQUEST_USER_AGENT)
 	return err
 }
 
@@ -2,13 +2,15 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
-	"strings"
 	"testing"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
 	"github.com/valyala/fasthttp"
 )
@@ -324,6 +326,21 @@ func TestChannelsHandler(t *testing.T) {
 	}
 }
 
+func TestChannelNumberHandlerInvalidNumber(t *testing.T) {
+	app := fiber.New()
+	app.Get("/api/v1/channels/number/:number", ChannelNumberHandler)
+
+	for _, number := range []string{"abc", "0", "-3"} {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/channels/number/"+number, nil))
+		if err != nil {
+			t.Fatalf("GET number %s error = %v", number, err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("GET number %s = %d, want %d", number, resp.StatusCode, fiber.StatusBadRequest)
+		}
+	}
+}
+
 func TestPlayHandler(t *testing.T) {
 	type args struct {
 		c *fiber.Ctx
@@ -497,15 +514,8 @@ func TestCustomChannelLogoURL(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			// Test the logo URL handling logic from IndexHandler
-			var result string
-			if strings.HasPrefix(tc.logoURL, "http://") || strings.HasPrefix(tc.logoURL, "https://") {
-				// Custom channel with full URL, use as-is
-				result = tc.logoURL
-			} else {
-				// Regular channel with relative path, add proxy prefix
-				result = hostURL + "/jtvimage/" + tc.logoURL
-			}
+			// Test the logo URL handling of IndexHandler
+			result := channelLogoURL(hostURL, television.Channel{ID: "1", LogoURL: tc.logoURL})
 
 			if result != tc.expected {
 				t.Errorf("Expected '%s', got '%s'", tc.expected, result)
@@ -540,24 +550,16 @@ func TestChannelsHandlerM3ULogoURL(t *testing.T) {
 	}
 
 	hostURL := "http://localhost:5001"
-	logoURL := hostURL + "/jtvimage"
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			// Test M3U logo URL handling logic from ChannelsHandler
-			var channelLogoURL string
-			if strings.HasPrefix(tc.logoURL, "http://") || strings.HasPrefix(tc.logoURL, "https://") {
-				// Custom channel with full URL
-				channelLogoURL = tc.logoURL
-			} else {
-				// Regular channel with relative path
-				channelLogoURL = logoURL + "/" + tc.logoURL
-			}
+			// Test M3U logo URL handling of ChannelsHandler
+			logo := channelLogoURL(hostURL, television.Channel{ID: "1", LogoURL: tc.logoURL})
 
-			if channelLogoURL != tc.expected {
-				t.Errorf("Expected '%s', got '%s'", tc.expected, channelLogoURL)
+			if logo != tc.expected {
+				t.Errorf("Expected '%s', got '%s'", tc.expected, logo)
 			}
-			t.Logf("✓ M3U Logo URL: %s -> %s", tc.logoURL, channelLogoURL)
+			t.Logf("✓ M3U Logo URL: %s -> %s", tc.logoURL, logo)
 		})
 	}
 }
@@ -627,3 +629,54 @@ func TestIsCustomChannel(t *testing.T) {
 	// Clean up
 	config.Cfg.CustomChannelsFile = ""
 }
+
+func TestWithDeliveryDirectives(t *testing.T) {
+	app := fiber.New()
+	defer app.Shutdown()
+
+	tests := []struct {
+		name        string
+		query       string
+		playlistURL string
+		want        string
+	}{
+		{"no directives", "auth=x&channel_key_id=143", "https://cdn.jio.com/index.m3u8?hdnea=abc", "https://cdn.jio.com/index.m3u8?hdnea=abc"},
+		{"blocking reload", "auth=x&_HLS_msn=1234&_HLS_part=2", "https://cdn.jio.com/index.m3u8?hdnea=abc", "https://cdn.jio.com/index.m3u8?hdnea=abc&_HLS_msn=1234&_HLS_part=2"},
+		{"delta update", "_HLS_skip=YES", "https://cdn.jio.com/index.m3u8", "https://cdn.jio.com/index.m3u8?_HLS_skip=YES"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.SetRequestURI("/render.m3u8?" + tt.query)
+			c := app.AcquireCtx(ctx)
+			defer app.ReleaseCtx(c)
+			if got := withDeliveryDirectives(c, tt.playlistURL); got != tt.want {
+				t.Errorf("withDeliveryDirectives() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{secureurl.ErrURLExpired, fiber.StatusGone},
+		{secureurl.ErrKeyExpired, fiber.StatusGone},
+		{secureurl.ErrInvalidSignature, fiber.StatusForbidden},
+		{fiber.ErrNotFound, fiber.StatusNotFound},
+		{errors.New("upstream failed"), fiber.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Get("/render.m3u8", func(c *fiber.Ctx) error { return tt.err })
+		resp, err := app.Test(httptest.NewRequest("GET", "/render.m3u8", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tt.want {
+			t.Errorf("status for %v = %d, want %d", tt.err, resp.StatusCode, tt.want)
+		}
+	}
+}
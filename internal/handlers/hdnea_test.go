@@ -8,26 +8,6 @@ import (
 	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
 )
 
-func TestHDNEARemainingLifetime(t *testing.T) {
-	futureToken := fmt.Sprintf("exp=%d~acl=/*~data=hdntl~hmac=test", time.Now().Add(5*time.Minute).Unix())
-	remaining, ok := hdneaRemainingLifetime(futureToken)
-	if !ok {
-		t.Fatalf("expected token expiry to be parsed")
-	}
-	if remaining <= 4*time.Minute {
-		t.Fatalf("expected token to have more than 4 minutes remaining, got %s", remaining)
-	}
-
-	soonToken := fmt.Sprintf("exp=%d~acl=/*~data=hdntl~hmac=test", time.Now().Add(10*time.Second).Unix())
-	remaining, ok = hdneaRemainingLifetime(soonToken)
-	if !ok {
-		t.Fatalf("expected near-expiry token to be parsed")
-	}
-	if remaining > hdneaRefreshLeadTime {
-		t.Fatalf("expected near-expiry token to be within refresh window, got %s", remaining)
-	}
-}
-
 func TestLiveResultNeedsRefresh(t *testing.T) {
 	liveResult := &television.LiveURLOutput{
 		Hdnea: fmt.Sprintf("exp=%d~acl=/*~data=hdntl~hmac=test", time.Now().Add(10*time.Second).Unix()),
@@ -68,3 +48,23 @@ func TestSelectBestLiveMPDURL(t *testing.T) {
 		t.Fatalf("expected MPD result fallback, got %s", got)
 	}
 }
+
+func TestRenewHDNEASharesFreshToken(t *testing.T) {
+	tn := &tenant{}
+	refused := fmt.Sprintf("exp=%d~acl=/*~hmac=old", time.Now().Add(-time.Minute).Unix())
+	fresh := fmt.Sprintf("exp=%d~acl=/*~hmac=new", time.Now().Add(time.Hour).Unix())
+
+	// Another request of the channel already renewed the token, so no call to Live is needed
+	tn.hdnea.Set("143", fresh)
+	got, err := tn.renewHDNEA("143", refused)
+	if err != nil {
+		t.Fatalf("renewHDNEA() error = %v", err)
+	}
+	if got != fresh {
+		t.Errorf("renewHDNEA() = %q, want the token renewed by the other request %q", got, fresh)
+	}
+
+	if _, err := tn.renewHDNEA("", refused); err == nil {
+		t.Error("renewHDNEA() without a channel should fail")
+	}
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants"
+)
+
+// shuttingDown is set once the server received a shutdown signal
+var shuttingDown atomic.Bool
+
+// SetShuttingDown makes the health check fail, so that load balancers stop sending new requests
+// while running streams are finished.
+func SetShuttingDown() {
+	shuttingDown.Store(true)
+}
+
+// HealthHandler answers health checks of container orchestrators on `/healthz`.
+// It does not call the JioTV API, so it stays healthy while logged out or while JioTV is unreachable.
+func HealthHandler(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	if shuttingDown.Load() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"status": "shutting down",
+		})
+	}
+	return c.JSON(fiber.Map{
+		"status":  "ok",
+		"version": strings.TrimSpace(constants.Version),
+	})
+}
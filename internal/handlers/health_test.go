@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestHealthHandler(t *testing.T) {
+	defer shuttingDown.Store(false)
+
+	app := fiber.New()
+	app.Get("/healthz", HealthHandler)
+
+	check := func(method string) (int, map[string]string) {
+		resp, err := app.Test(httptest.NewRequest(method, "/healthz", nil))
+		if err != nil {
+			t.Fatalf("%s /healthz error = %v", method, err)
+		}
+		var body map[string]string
+		data, _ := io.ReadAll(resp.Body)
+		json.Unmarshal(data, &body)
+		return resp.StatusCode, body
+	}
+
+	if status, body := check("GET"); status != fiber.StatusOK || body["status"] != "ok" {
+		t.Errorf("GET /healthz = %d, %v", status, body)
+	}
+	if status, _ := check("HEAD"); status != fiber.StatusOK {
+		t.Errorf("HEAD /healthz = %d, want %d", status, fiber.StatusOK)
+	}
+
+	SetShuttingDown()
+	if status, body := check("GET"); status != fiber.StatusServiceUnavailable || body["status"] != "shutting down" {
+		t.Errorf("GET /healthz while shutting down = %d, %v", status, body)
+	}
+}
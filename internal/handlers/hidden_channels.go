@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// hiddenChannel is a channel of the hidden channels API
+type hiddenChannel struct {
+	ID     string `json:"channel_id"`
+	Name   string `json:"channel_name"`
+	Hidden bool   `json:"hidden"`
+}
+
+// sortedHiddenChannels returns the hidden channels sorted by name.
+func sortedHiddenChannels() []hiddenChannel {
+	hidden := television.HiddenChannels()
+	channels := make([]hiddenChannel, 0, len(hidden))
+	for id, name := range hidden {
+		channels = append(channels, hiddenChannel{ID: id, Name: name, Hidden: true})
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		if !strings.EqualFold(channels[i].Name, channels[j].Name) {
+			return strings.ToLower(channels[i].Name) < strings.ToLower(channels[j].Name)
+		}
+		return channels[i].ID < channels[j].ID
+	})
+	return channels
+}
+
+// ChannelVisibilityHandler renders the page on `/channels/visibility` with a checkbox for every JioTV
+// and custom channel, to hide channels from the channel list, the playlists and the EPG.
+func ChannelVisibilityHandler(c *fiber.Ctx) error {
+	apiResponse, err := television.Channels()
+	if err != nil {
+		return ErrorMessageHandler(c, err)
+	}
+	// Hidden channels are not in the channel list, so they are listed first
+	channels := sortedHiddenChannels()
+	for _, channel := range apiResponse.Result {
+		channels = append(channels, hiddenChannel{ID: channel.ID, Name: channel.Name})
+	}
+	return c.Render("views/channel_visibility", fiber.Map{
+		"Title":    Title,
+		"Channels": channels,
+	})
+}
+
+// HiddenChannelsHandler lists the channels hidden from the web interface on `GET /api/v1/channels/hidden`.
+func HiddenChannelsHandler(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"channels": sortedHiddenChannels(),
+	})
+}
+
+// SetChannelHiddenHandler hides or shows a channel on `POST /api/v1/channels/hidden`.
+// The body is a JSON object like {"channel_id": "143", "hidden": true}. Needs the admin token or the web login.
+func SetChannelHiddenHandler(c *fiber.Ctx) error {
+	if ok, err := checkAdminToken(c); !ok {
+		return err
+	}
+	var body hiddenChannel
+	if err := c.BodyParser(&body); err != nil {
+		return internalUtils.BadRequestError(c, "Invalid request body: "+err.Error())
+	}
+	body.ID = strings.TrimSpace(body.ID)
+	if body.ID == "" {
+		return internalUtils.BadRequestError(c, "channel_id is required")
+	}
+
+	if body.Hidden && !television.IsHiddenChannel(body.ID) {
+		index, err := television.ChannelsIndex()
+		if err != nil {
+			return ErrorMessageHandler(c, err)
+		}
+		channel, ok := index.Channel(body.ID)
+		if !ok {
+			return internalUtils.NotFoundError(c, "Channel not found: "+body.ID)
+		}
+		body.Name = channel.Name
+	} else {
+		body.Name = television.HiddenChannels()[body.ID]
+	}
+
+	if err := television.SetChannelHidden(body.ID, body.Name, body.Hidden); err != nil {
+		utils.Log.Printf("ERROR: Failed to save hidden channels: %v", err)
+		return internalUtils.InternalServerError(c, "Failed to save hidden channels")
+	}
+	return c.JSON(body)
+}
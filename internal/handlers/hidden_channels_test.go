@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+func TestHiddenChannelsHandlers(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	television.InitHiddenChannels()
+	defer television.InitHiddenChannels()
+	original := config.Cfg
+	defer func() { config.Cfg = original }()
+	config.Cfg.AdminToken = "admin"
+
+	for id, name := range map[string]string{"143": "News", "144": "Sports"} {
+		if err := television.SetChannelHidden(id, name, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	app := fiber.New()
+	app.Get("/api/v1/channels/hidden", HiddenChannelsHandler)
+	app.Post("/api/v1/channels/hidden", SetChannelHiddenHandler)
+
+	tests := []struct {
+		name       string
+		token      string
+		body       string
+		wantStatus int
+	}{
+		{"no admin token", "", `{"channel_id": "143", "hidden": false}`, fiber.StatusUnauthorized},
+		{"wrong admin token", "other", `{"channel_id": "143", "hidden": false}`, fiber.StatusUnauthorized},
+		{"invalid body", "admin", `{"channel_id":`, fiber.StatusBadRequest},
+		{"missing channel ID", "admin", `{"hidden": false}`, fiber.StatusBadRequest},
+		{"show a hidden channel", "admin", `{"channel_id": "143", "hidden": false}`, fiber.StatusOK},
+		{"hide a hidden channel again", "admin", `{"channel_id": "144", "hidden": true}`, fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/channels/hidden?token="+tt.token, strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("POST error = %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("POST /api/v1/channels/hidden = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/channels/hidden", nil))
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	var result struct {
+		Channels []hiddenChannel `json:"channels"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(result.Channels) != 1 || result.Channels[0] != (hiddenChannel{ID: "144", Name: "Sports", Hidden: true}) {
+		t.Errorf("hidden channels = %+v, want only 144", result.Channels)
+	}
+}
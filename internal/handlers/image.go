@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/imagecache"
+	"github.com/valyala/fasthttp"
+)
+
+// imageCacheAge is how long clients may cache images, in seconds
+const imageCacheAge = 86400
+
+// imageTransform returns the resize and format of the `w`, `h` and `format` query params of an image.
+// With `format=auto`, WebP is served to clients that accept it.
+func imageTransform(c *fiber.Ctx) (imagecache.Transform, error) {
+	var transform imagecache.Transform
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{{"w", &transform.Width}, {"h", &transform.Height}} {
+		raw := c.Query(param.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 || value > imagecache.MaxDimension {
+			return transform, fmt.Errorf("%s must be a number from 0 to %d", param.name, imagecache.MaxDimension)
+		}
+		*param.value = value
+	}
+
+	switch format := strings.ToLower(c.Query("format")); format {
+	case "":
+	case "jpg", imagecache.FormatJPEG:
+		transform.Format = imagecache.FormatJPEG
+	case imagecache.FormatPNG, imagecache.FormatWebP:
+		transform.Format = format
+	case "auto":
+		c.Vary(fiber.HeaderAccept)
+		if strings.Contains(c.Get(fiber.HeaderAccept), "image/webp") {
+			transform.Format = imagecache.FormatWebP
+		}
+	default:
+		return transform, fmt.Errorf("format must be webp, jpeg, png or auto")
+	}
+	return transform, nil
+}
+
+// transformedImage returns the image of the cache key after the transform, cached with the key of
+// the transform. Images that are not cached are downloaded from upstreamURL.
+func transformedImage(key, upstreamURL string, client *fasthttp.Client, transform imagecache.Transform) ([]byte, error) {
+	if data, ok := imagecache.Get(transform.Key(key)); ok {
+		return data, nil
+	}
+	original, ok := imagecache.Get(key)
+	if !ok {
+		var err error
+		if original, err = imagecache.Download(upstreamURL, client); err != nil {
+			return nil, err
+		}
+	}
+	data, err := transform.Apply(original)
+	if err != nil {
+		return nil, err
+	}
+	if err := imagecache.Put(transform.Key(key), data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// sendImage sends an image with its content type and cache header.
+func sendImage(c *fiber.Ctx, data []byte) error {
+	internalUtils.SetCacheHeader(c, imageCacheAge)
+	c.Set(fiber.HeaderContentType, http.DetectContentType(data))
+	return c.Send(data)
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/imagecache"
+)
+
+func TestImageTransform(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		accept  string
+		want    imagecache.Transform
+		wantErr bool
+	}{
+		{name: "none", query: ""},
+		{name: "size", query: "?w=160&h=90", want: imagecache.Transform{Width: 160, Height: 90}},
+		{name: "jpg", query: "?format=JPG", want: imagecache.Transform{Format: imagecache.FormatJPEG}},
+		{name: "auto with webp", query: "?w=160&format=auto", accept: "image/avif,image/webp,*/*", want: imagecache.Transform{Width: 160, Format: imagecache.FormatWebP}},
+		{name: "auto without webp", query: "?w=160&format=auto", accept: "image/png,*/*", want: imagecache.Transform{Width: 160}},
+		{name: "invalid width", query: "?w=abc", wantErr: true},
+		{name: "too large", query: "?h=5000", wantErr: true},
+		{name: "unknown format", query: "?format=bmp", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got imagecache.Transform
+			var gotErr error
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				got, gotErr = imageTransform(c)
+				return nil
+			})
+			req := httptest.NewRequest("GET", "/"+tt.query, nil)
+			if tt.accept != "" {
+				req.Header.Set(fiber.HeaderAccept, tt.accept)
+			}
+			if _, err := app.Test(req); err != nil {
+				t.Fatal(err)
+			}
+			if (gotErr != nil) != tt.wantErr {
+				t.Fatalf("imageTransform() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("imageTransform() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
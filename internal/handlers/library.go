@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/library"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+// libraryFileName is the file name the library export is downloaded as
+const libraryFileName = "jiotv_go-library.zip"
+
+// libraryEntries returns the library entries of the channels, with stream and logo URLs on hostURL.
+func libraryEntries(channels []television.Channel, hostURL, quality string) []library.Entry {
+	entries := make([]library.Entry, 0, len(channels))
+	for _, channel := range channels {
+		entries = append(entries, library.Entry{
+			ID:        channel.ID,
+			Name:      channel.Name,
+			Number:    channel.Number,
+			StreamURL: playlistChannelURL(hostURL, channel, quality),
+			LogoURL:   channelLogoURL(hostURL, channel),
+			Category:  television.CategoryMap[channel.Category],
+			Language:  television.LanguageMap[channel.Language],
+		})
+	}
+	return entries
+}
+
+// ExportLibrary writes a .strm file and NFO metadata of every channel into dir, for Kodi and
+// Jellyfin libraries. The stream URLs point to the server at hostURL. It returns the number of channels.
+func ExportLibrary(dir, hostURL, quality string) (int, error) {
+	channels, err := DisplayedChannels()
+	if err != nil {
+		return 0, err
+	}
+	return library.Write(dir, libraryEntries(channels, strings.TrimRight(hostURL, "/"), quality))
+}
+
+// LibraryHandler serves the channels as a zip of .strm files with NFO metadata on `/library.zip`,
+// to extract into a Kodi or Jellyfin library folder. It takes the quality and filter options of M3U playlists.
+func LibraryHandler(c *fiber.Ctx) error {
+	opts, err := parsePlaylistOptions(c)
+	if err != nil {
+		return internalUtils.BadRequestError(c, err.Error())
+	}
+	apiResponse, err := listedChannels(strings.TrimSpace(c.Query("g")))
+	if err != nil {
+		return ErrorMessageHandler(c, err)
+	}
+	channels := filterPlaylistChannels(reorderChannelsForDisplay(apiResponse.Result), opts)
+
+	var buf bytes.Buffer
+	if err := library.WriteZip(&buf, libraryEntries(channels, requestHostURL(c), opts.Quality)); err != nil {
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	c.Set("Content-Disposition", "attachment; filename="+libraryFileName)
+	c.Set(fiber.HeaderContentType, "application/zip")
+	return c.Send(buf.Bytes())
+}
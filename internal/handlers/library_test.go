@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+func TestLibraryEntries(t *testing.T) {
+	channels := []television.Channel{{ID: "143", Name: "Sony HD", Number: 5, LogoURL: "Sony_HD.png", Category: 5, Language: 1}}
+	entries := libraryEntries(channels, "http://localhost:5001", "high")
+	if len(entries) != 1 {
+		t.Fatalf("libraryEntries() returned %d entries, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry.StreamURL != "http://localhost:5001/live/high/143.m3u8" {
+		t.Errorf("StreamURL = %q", entry.StreamURL)
+	}
+	if entry.LogoURL != "http://localhost:5001/jtvimage/Sony_HD.png" {
+		t.Errorf("LogoURL = %q", entry.LogoURL)
+	}
+	if entry.Category != television.CategoryMap[5] || entry.Language != television.LanguageMap[1] || entry.Number != 5 {
+		t.Errorf("entry = %+v", entry)
+	}
+}
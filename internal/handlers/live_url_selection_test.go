@@ -77,6 +77,35 @@ func TestSelectBestLiveHLSURL(t *testing.T) {
 	}
 }
 
+func TestSelectLiveHLSURL(t *testing.T) {
+	master := &television.LiveURLOutput{
+		Result:   "https://edge.example.com/channel/master.m3u8?token=abc",
+		Bitrates: television.Bitrates{Auto: "https://cdn.example.com/auto.m3u8", Low: "https://cdn.example.com/low.m3u8"},
+	}
+	noMaster := &television.LiveURLOutput{
+		Result:   "https://edge.example.com/channel/manifest.mpd",
+		Bitrates: television.Bitrates{Auto: "https://cdn.example.com/auto.m3u8"},
+	}
+	tests := []struct {
+		name     string
+		quality  string
+		input    *television.LiveURLOutput
+		expected string
+	}{
+		{"abr selects the master playlist", liveQualityABR, master, "https://edge.example.com/channel/master.m3u8?token=abc"},
+		{"abr falls back to auto without master playlist", liveQualityABR, noMaster, "https://cdn.example.com/auto.m3u8"},
+		{"other qualities select bitrates", "low", master, "https://cdn.example.com/low.m3u8"},
+		{"abr with nil input", liveQualityABR, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectLiveHLSURL(tt.input, tt.quality); got != tt.expected {
+				t.Fatalf("selectLiveHLSURL() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestIsLikelyHLSURL(t *testing.T) {
 	tests := []struct {
 		url      string
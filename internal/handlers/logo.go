@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants/urls"
+	"github.com/jiotv-go/jiotv_go/v3/internal/plugins"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/logocache"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// channelLogoURL returns the URL clients load the logo of the channel from: the logo cache if it
+// is enabled, otherwise the logo of a custom channel as is and JioTV logos through `/jtvimage`.
+func channelLogoURL(hostURL string, channel television.Channel) string {
+	if logocache.Enabled() && channel.LogoURL != "" {
+		return hostURL + "/logo/" + url.PathEscape(channel.ID) + ".png"
+	}
+	if isAbsoluteHTTPURL(channel.LogoURL) {
+		return channel.LogoURL
+	}
+	return hostURL + "/jtvimage/" + channel.LogoURL
+}
+
+// upstreamLogoURL returns the URL the logo of the channel is downloaded from.
+func upstreamLogoURL(channel television.Channel) string {
+	if isAbsoluteHTTPURL(channel.LogoURL) {
+		return channel.LogoURL
+	}
+	return urls.ChannelImageURLSlash + strings.TrimPrefix(channel.LogoURL, "/")
+}
+
+// findChannel returns the channel with the ID among the JioTV, custom and plugin channels.
+func findChannel(id string) (television.Channel, bool) {
+	if channel, ok := television.ChannelByID(id); ok {
+		return channel, true
+	}
+	if len(config.Cfg.Plugins) > 0 {
+		return plugins.ChannelByID(id)
+	}
+	return television.Channel{}, false
+}
+
+// LogoHandler serves the logo of a channel as a PNG on `/logo/:id.png`, from the logo cache if it is
+// enabled. Logos that cannot be cached are served from upstream.
+func LogoHandler(c *fiber.Ctx) error {
+	id := strings.TrimSpace(c.Params("id"))
+	channel, ok := findChannel(id)
+	if !ok || channel.LogoURL == "" {
+		return internalUtils.NotFoundError(c, "Channel has no logo")
+	}
+
+	t := tenantOf(c)
+	upstream := upstreamLogoURL(channel)
+	if logocache.Enabled() {
+		logo, err := logocache.Get(upstream, t.TV().Client)
+		if err == nil {
+			internalUtils.SetCacheHeader(c, 86400)
+			c.Set(fiber.HeaderContentType, "image/png")
+			return c.Send(logo)
+		}
+		utils.Log.Printf("WARN: Failed to cache the logo of channel %s: %v", id, err)
+	}
+
+	if isAbsoluteHTTPURL(channel.LogoURL) {
+		return c.Redirect(upstream, fiber.StatusFound)
+	}
+	_, err := internalUtils.ProxyRequest(c, upstream, t.TV().Client, REQUEST_USER_AGENT)
+	return err
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+func TestChannelLogoURLWithLogoCache(t *testing.T) {
+	original := config.Cfg.LogoCache
+	defer func() { config.Cfg.LogoCache = original }()
+	config.Cfg.LogoCache = true
+
+	hostURL := "http://localhost:5001"
+	tests := []struct {
+		name    string
+		channel television.Channel
+		want    string
+	}{
+		{"JioTV logo", television.Channel{ID: "143", LogoURL: "Aaj_Tak.png"}, "http://localhost:5001/logo/143.png"},
+		{"custom logo", television.Channel{ID: "cc_my channel", LogoURL: "https://example.com/logo.png"}, "http://localhost:5001/logo/cc_my%20channel.png"},
+		{"no logo", television.Channel{ID: "144"}, "http://localhost:5001/jtvimage/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := channelLogoURL(hostURL, tt.channel); got != tt.want {
+				t.Errorf("channelLogoURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpstreamLogoURL(t *testing.T) {
+	if got, want := upstreamLogoURL(television.Channel{LogoURL: "Aaj_Tak.png"}), "https://jiotv.catchup.cdn.jio.com/dare_images/images/Aaj_Tak.png"; got != want {
+		t.Errorf("upstreamLogoURL() = %q, want %q", got, want)
+	}
+	if got, want := upstreamLogoURL(television.Channel{LogoURL: "https://example.com/logo.png"}), "https://example.com/logo.png"; got != want {
+		t.Errorf("upstreamLogoURL() = %q, want %q", got, want)
+	}
+}
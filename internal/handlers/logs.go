@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/middleware"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/logstream"
+)
+
+// logStreamKeepAlive is how often a comment is sent on idle log streams, so that proxies keep them open
+const logStreamKeepAlive = 15 * time.Second
+
+// checkAdminToken reports whether the request has the configured admin token, either as a
+// bearer token or, for browsers, in the token query parameter, or a web login session.
+// Otherwise it sends an error response.
+func checkAdminToken(c *fiber.Ctx) (bool, error) {
+	if middleware.Authenticated(c) {
+		return true, nil
+	}
+	if config.Cfg.AdminToken == "" && !middleware.WebLoginEnabled() {
+		return false, internalUtils.ForbiddenError(c, "Admin APIs are disabled. Set admin_token or web_password in the config to enable them.")
+	}
+	return false, internalUtils.ErrorResponse(c, fiber.StatusUnauthorized, "Invalid admin token")
+}
+
+// LogStreamHandler streams the server log on `/api/logs/stream` as server-sent events.
+// It starts with the recent lines and then sends new lines as they are logged.
+// The level query parameter sets the minimum level, and format=text streams plain text for browsers.
+func LogStreamHandler(c *fiber.Ctx) error {
+	if ok, err := checkAdminToken(c); !ok {
+		return err
+	}
+	level := strings.ToLower(c.Query("level", logstream.LevelInfo))
+	if !logstream.ValidLevel(level) {
+		return internalUtils.BadRequestError(c, "Invalid level. Use debug, info, warn or error.")
+	}
+	plainText := c.Query("format") == "text"
+
+	recent, lines, unsubscribe := logstream.Default.Subscribe(level)
+
+	write := func(w *bufio.Writer, line logstream.Line) {
+		if plainText {
+			fmt.Fprintf(w, "%s\n", line.Text)
+		} else {
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", line.Level, line.Text)
+		}
+	}
+
+	if plainText {
+		c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+		c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	} else {
+		c.Set(fiber.HeaderContentType, "text/event-stream")
+	}
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set("X-Accel-Buffering", "no")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+		for _, line := range recent {
+			write(w, line)
+		}
+		if w.Flush() != nil {
+			return
+		}
+
+		keepAlive := time.NewTicker(logStreamKeepAlive)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case line := <-lines:
+				write(w, line)
+			case <-keepAlive.C:
+				if plainText {
+					continue
+				}
+				fmt.Fprint(w, ": keep-alive\n\n")
+			}
+			// Flushing fails once the client is gone
+			if w.Flush() != nil {
+				return
+			}
+		}
+	})
+	return nil
+}
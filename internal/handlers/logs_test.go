@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+func TestLogStreamHandlerAuth(t *testing.T) {
+	originalToken := config.Cfg.AdminToken
+	defer func() { config.Cfg.AdminToken = originalToken }()
+
+	app := fiber.New()
+	app.Get("/api/logs/stream", LogStreamHandler)
+
+	tests := []struct {
+		name       string
+		adminToken string
+		target     string
+		header     string
+		wantStatus int
+	}{
+		{"disabled without admin token", "", "/api/logs/stream?token=", "", fiber.StatusForbidden},
+		{"missing token", "secret", "/api/logs/stream", "", fiber.StatusUnauthorized},
+		{"wrong query token", "secret", "/api/logs/stream?token=guess", "", fiber.StatusUnauthorized},
+		{"wrong bearer token", "secret", "/api/logs/stream?token=secret", "Bearer guess", fiber.StatusUnauthorized},
+		{"invalid level", "secret", "/api/logs/stream?level=verbose", "Bearer secret", fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Cfg.AdminToken = tt.adminToken
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.header)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("GET %s error = %v", tt.target, err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("GET %s = %d, want %d", tt.target, resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/maintenance"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// maintenanceResponse is the response of the maintenance API
+type maintenanceResponse struct {
+	Scheduled    bool                `json:"scheduled"`
+	Active       bool                `json:"active"`
+	Announcement string              `json:"announcement,omitempty"`
+	Window       *maintenance.Window `json:"window,omitempty"`
+}
+
+// newMaintenanceResponse describes the scheduled maintenance window at now.
+func newMaintenanceResponse(now time.Time) maintenanceResponse {
+	window, ok := maintenance.Get(now)
+	if !ok {
+		return maintenanceResponse{}
+	}
+	return maintenanceResponse{
+		Scheduled:    true,
+		Active:       window.Active(now),
+		Announcement: window.Announcement(now),
+		Window:       &window,
+	}
+}
+
+// MaintenanceHandler returns the scheduled maintenance window on `GET /api/maintenance`.
+func MaintenanceHandler(c *fiber.Ctx) error {
+	return c.JSON(newMaintenanceResponse(time.Now()))
+}
+
+// SetMaintenanceHandler schedules a maintenance window on `POST /api/maintenance`.
+// The body is a JSON window with RFC 3339 start and end times. Without start, the window starts now.
+// Needs the admin token or the web login.
+func SetMaintenanceHandler(c *fiber.Ctx) error {
+	if ok, err := checkAdminToken(c); !ok {
+		return err
+	}
+	var window maintenance.Window
+	if err := c.BodyParser(&window); err != nil {
+		return internalUtils.BadRequestError(c, "Invalid maintenance window: "+err.Error())
+	}
+	now := time.Now()
+	if window.Start.IsZero() {
+		window.Start = now
+	}
+	if err := window.Validate(now); err != nil {
+		return internalUtils.BadRequestError(c, "Invalid maintenance window: "+err.Error())
+	}
+	if _, err := maintenance.Set(window, now); err != nil {
+		utils.Log.Printf("ERROR: Failed to save maintenance window: %v", err)
+		return internalUtils.InternalServerError(c, "Failed to save maintenance window")
+	}
+	utils.Log.Printf("INFO: %s", newMaintenanceResponse(now).Announcement)
+	return c.JSON(newMaintenanceResponse(now))
+}
+
+// ClearMaintenanceHandler cancels the scheduled maintenance window on `DELETE /api/maintenance`.
+// Needs the admin token or the web login.
+func ClearMaintenanceHandler(c *fiber.Ctx) error {
+	if ok, err := checkAdminToken(c); !ok {
+		return err
+	}
+	if err := maintenance.Clear(); err != nil {
+		utils.Log.Printf("ERROR: Failed to clear maintenance window: %v", err)
+		return internalUtils.InternalServerError(c, "Failed to clear maintenance window")
+	}
+	return c.JSON(newMaintenanceResponse(time.Now()))
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/maintenance"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+func TestMaintenanceHandlers(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	maintenance.Init()
+	defer maintenance.Clear()
+	original := config.Cfg
+	defer func() { config.Cfg = original }()
+	config.Cfg.AdminToken = "admin"
+
+	app := fiber.New()
+	app.Get("/api/maintenance", MaintenanceHandler)
+	app.Post("/api/maintenance", SetMaintenanceHandler)
+	app.Delete("/api/maintenance", ClearMaintenanceHandler)
+
+	do := func(method, body string) (int, maintenanceResponse) {
+		target := "/api/maintenance"
+		if method != "GET" {
+			target += "?token=admin"
+		}
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s /api/maintenance error = %v", method, err)
+		}
+		var result maintenanceResponse
+		data, _ := io.ReadAll(resp.Body)
+		json.Unmarshal(data, &result)
+		return resp.StatusCode, result
+	}
+
+	if status, got := do("GET", ""); status != fiber.StatusOK || got.Scheduled {
+		t.Errorf("GET without window = %d, %+v", status, got)
+	}
+
+	end := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	for _, method := range []string{"POST", "DELETE"} {
+		req := httptest.NewRequest(method, "/api/maintenance", strings.NewReader(`{"end": "`+end+`"}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusUnauthorized {
+			t.Errorf("%s /api/maintenance without the admin token = %d, want %d", method, resp.StatusCode, fiber.StatusUnauthorized)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"invalid JSON", `{`, fiber.StatusBadRequest},
+		{"no end", `{"message": "Restart"}`, fiber.StatusBadRequest},
+		{"ended", `{"end": "2020-01-01T00:00:00Z"}`, fiber.StatusBadRequest},
+		{"starts now", `{"end": "` + end + `", "message": "Restart", "block_new_streams": true}`, fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, _ := do("POST", tt.body); status != tt.wantStatus {
+				t.Errorf("POST %s status = %d, want %d", tt.body, status, tt.wantStatus)
+			}
+		})
+	}
+
+	status, got := do("GET", "")
+	if status != fiber.StatusOK || !got.Scheduled || !got.Active || got.Window == nil || !got.Window.BlockNewStreams ||
+		!strings.HasSuffix(got.Announcement, ": Restart") {
+		t.Errorf("GET with window = %d, %+v", status, got)
+	}
+
+	if status, got := do("DELETE", ""); status != fiber.StatusOK || got.Scheduled {
+		t.Errorf("DELETE = %d, %+v", status, got)
+	}
+}
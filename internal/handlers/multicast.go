@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/hls"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/multicast"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// liveSource is a live JioTV channel read segment by segment, for multicast outputs and previews.
+// It resolves the media playlist like a timeshift source, and decrypts the segments.
+type liveSource struct {
+	timeshiftSource
+	keys map[string][]byte
+}
+
+// Playlist implements multicast.Source.
+func (s *liveSource) Playlist() ([]hls.Segment, int, error) {
+	if s.mediaURL == "" {
+		if err := s.resolveMediaURL(); err != nil {
+			return nil, 0, err
+		}
+	}
+	body, statusCode, newHdnea := s.t.TV().Render(s.mediaURL, s.t.hdnea.Get(s.id))
+	if newHdnea != "" {
+		s.t.hdnea.Set(s.id, newHdnea)
+	}
+	if statusCode != fiber.StatusOK {
+		// The token or the variant may have expired, so start again from the live URL
+		s.mediaURL = ""
+		return nil, 0, fmt.Errorf("playlist of channel %s returned status %d", s.id, statusCode)
+	}
+	base, err := url.Parse(s.mediaURL)
+	if err != nil {
+		return nil, 0, err
+	}
+	playlist, err := hls.Parse(body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return playlist.Segments(base), playlist.TargetDuration(), nil
+}
+
+// Segment implements multicast.Source.
+func (s *liveSource) Segment(segment hls.Segment) ([]byte, error) {
+	data, err := fetchChannelResource(s.t, s.id, segment.URL, nil)
+	if err != nil || segment.Key == nil {
+		return data, err
+	}
+	if segment.Key.Method != "AES-128" {
+		return nil, fmt.Errorf("channel %s uses unsupported encryption %s", s.id, segment.Key.Method)
+	}
+	key, ok := s.keys[segment.Key.URL]
+	if !ok {
+		if key, err = fetchChannelKey(s.t, s.id, segment.Key.URL); err != nil {
+			return nil, err
+		}
+		s.keys[segment.Key.URL] = key
+	}
+	return decryptSegment(data, key, segment.Key.IV)
+}
+
+// StartMulticastOutputs starts sending the channels of multicast_outputs. Outputs with an invalid
+// config are skipped with a warning.
+func StartMulticastOutputs() {
+	for _, out := range config.Cfg.MulticastOutputs {
+		id := strings.TrimSpace(out.Channel)
+		if id == "" || isCustomChannel(id) || isZee5Channel(id) {
+			utils.Log.Printf("WARN: Multicast output to %s: %q is not a JioTV channel", out.Address, out.Channel)
+			continue
+		}
+		dest, err := multicast.ParseDestination(out.Address)
+		if err != nil {
+			utils.Log.Printf("WARN: Multicast output of channel %s: %v", id, err)
+			continue
+		}
+		quality := strings.TrimSpace(out.Quality)
+		if quality == "" {
+			quality = "auto"
+		}
+		quality = television.CapQuality(quality, television.ChannelQualityCap(id))
+		source := &liveSource{
+			timeshiftSource: timeshiftSource{t: defaultTenant, id: id, quality: quality},
+			keys:            map[string][]byte{},
+		}
+		if err := multicast.Start(id, dest, multicast.Options{TTL: out.TTL, Interface: out.Interface}, source); err != nil {
+			utils.Log.Printf("WARN: Multicast output of channel %s to %s: %v", id, dest, err)
+			continue
+		}
+		utils.Log.Printf("INFO: Sending channel %s to %s", id, dest)
+	}
+}
+
+// MulticastPlaylistHandler serves an M3U playlist of the multicast outputs on `/multicast.m3u`,
+// so that IPTV players like VLC can open them.
+func MulticastPlaylistHandler(c *fiber.Ctx) error {
+	hostURL := requestHostURL(c)
+
+	var m3u strings.Builder
+	m3u.WriteString("#EXTM3U\n")
+	for _, status := range multicast.Outputs() {
+		name, logo, group := status.Channel, "", ""
+		if channel, ok := television.ChannelByID(status.Channel); ok {
+			name = channel.Name
+			logo = channelLogoURL(hostURL, channel)
+			group = television.CategoryMap[channel.Category]
+		}
+		fmt.Fprintf(&m3u, "#EXTINF:-1 tvg-id=%q tvg-name=%q tvg-logo=%q group-title=%q, %s\n%s\n",
+			status.Channel, name, logo, group, name, status.Destination)
+	}
+	c.Set("Content-Disposition", "attachment; filename=jiotv_multicast.m3u")
+	c.Set("Content-Type", "application/vnd.apple.mpegurl")
+	return c.SendString(m3u.String())
+}
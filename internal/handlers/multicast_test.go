@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/multicast"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+func TestStartMulticastOutputsSkipsInvalid(t *testing.T) {
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	original := config.Cfg.MulticastOutputs
+	defer func() { config.Cfg.MulticastOutputs = original }()
+	config.Cfg.MulticastOutputs = config.JSONList[config.MulticastOutput]{
+		{Channel: "", Address: "udp://239.255.0.1:5000"},
+		{Channel: "143", Address: "http://239.255.0.3:5000"},
+		{Channel: "144", Address: "udp://239.255.0.4"},
+	}
+
+	StartMulticastOutputs()
+	defer multicast.StopAll()
+	if outputs := multicast.Outputs(); len(outputs) != 0 {
+		t.Errorf("StartMulticastOutputs() started %+v, want no outputs", outputs)
+	}
+}
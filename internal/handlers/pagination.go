@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// defaultChannelsPageLimit is the number of channels of a page of the channels API without limit
+	defaultChannelsPageLimit = 100
+	// maxChannelsPageLimit is the largest page of the channels API
+	maxChannelsPageLimit = 1000
+	// pageLinksAround is the number of page links shown before and after the current page
+	pageLinksAround = 2
+)
+
+// channelsPage describes a page of channels
+type channelsPage struct {
+	Page  int `json:"page"`
+	Limit int `json:"limit"`
+	// Total is the number of channels of all pages
+	Total int `json:"total"`
+	Pages int `json:"pages"`
+}
+
+// pagedChannelsResponse is the response of the channels API when a page is requested
+type pagedChannelsResponse struct {
+	television.ChannelsResponse
+	channelsPage
+}
+
+// pageLink is a link of the pagination of the index page. A link without number is a gap.
+type pageLink struct {
+	Number  int
+	URL     string
+	Current bool
+}
+
+// indexPagination is the pagination of the index page
+type indexPagination struct {
+	channelsPage
+	// First and Last are the positions of the first and last channels of the page, from 1
+	First, Last int
+	Prev, Next  string
+	Links       []pageLink
+}
+
+// parseChannelsPage reads the page and limit query parameters of the channels API.
+// paged is false if neither is set, then all channels are returned.
+func parseChannelsPage(c *fiber.Ctx) (page, limit int, paged bool, err error) {
+	pageQuery, limitQuery := strings.TrimSpace(c.Query("page")), strings.TrimSpace(c.Query("limit"))
+	if pageQuery == "" && limitQuery == "" {
+		return 0, 0, false, nil
+	}
+	page, limit = 1, defaultChannelsPageLimit
+	if pageQuery != "" {
+		if page, err = strconv.Atoi(pageQuery); err != nil || page < 1 {
+			return 0, 0, false, errors.New("page must be a number from 1")
+		}
+	}
+	if limitQuery != "" {
+		if limit, err = strconv.Atoi(limitQuery); err != nil || limit < 1 || limit > maxChannelsPageLimit {
+			return 0, 0, false, errors.New("limit must be a number from 1 to " + strconv.Itoa(maxChannelsPageLimit))
+		}
+	}
+	return page, limit, true, nil
+}
+
+// paginateChannels returns the channels of a page. A page after the last one has no channels.
+func paginateChannels(channels []television.Channel, page, limit int) ([]television.Channel, channelsPage) {
+	info := channelsPage{Page: page, Limit: limit, Total: len(channels), Pages: (len(channels) + limit - 1) / limit}
+	start := (page - 1) * limit
+	if start >= len(channels) {
+		return []television.Channel{}, info
+	}
+	return channels[start:min(start+limit, len(channels))], info
+}
+
+// searchChannels returns the channels whose name contains the search text, ignoring case.
+func searchChannels(channels []television.Channel, search string) []television.Channel {
+	search = strings.ToLower(search)
+	found := make([]television.Channel, 0)
+	for _, channel := range channels {
+		if strings.Contains(strings.ToLower(channel.Name), search) {
+			found = append(found, channel)
+		}
+	}
+	return found
+}
+
+// newIndexPagination returns the links to the pages of the index page, which keep the other
+// query parameters. Only the first, the last and the pages around the current page are linked.
+func newIndexPagination(c *fiber.Ctx, info channelsPage) indexPagination {
+	args := fasthttp.AcquireArgs()
+	defer fasthttp.ReleaseArgs(args)
+	c.Request().URI().QueryArgs().CopyTo(args)
+	pageURL := func(page int) string {
+		args.Set("page", strconv.Itoa(page))
+		return "?" + args.String()
+	}
+
+	pagination := indexPagination{channelsPage: info}
+	if info.Page <= info.Pages {
+		pagination.First = (info.Page-1)*info.Limit + 1
+		pagination.Last = min(info.Page*info.Limit, info.Total)
+	}
+	if info.Page > 1 {
+		pagination.Prev = pageURL(info.Page - 1)
+	}
+	if info.Page < info.Pages {
+		pagination.Next = pageURL(info.Page + 1)
+	}
+	link := func(page int) pageLink {
+		return pageLink{Number: page, URL: pageURL(page), Current: page == info.Page}
+	}
+
+	// Pages after the last one link to the last pages
+	current := min(info.Page, info.Pages)
+	from, to := current-pageLinksAround, current+pageLinksAround
+	// A gap would hide a single page, so the page is linked instead
+	if from <= 3 {
+		from = 1
+	}
+	if to >= info.Pages-2 {
+		to = info.Pages
+	}
+	if from > 1 {
+		pagination.Links = append(pagination.Links, link(1), pageLink{})
+	}
+	for page := from; page <= to; page++ {
+		pagination.Links = append(pagination.Links, link(page))
+	}
+	if to < info.Pages {
+		pagination.Links = append(pagination.Links, pageLink{}, link(info.Pages))
+	}
+	return pagination
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/valyala/fasthttp"
+)
+
+// newQueryCtx returns a fiber context of a GET request with the query string.
+func newQueryCtx(t *testing.T, query string) *fiber.Ctx {
+	t.Helper()
+	app := fiber.New()
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod("GET")
+	ctx.Request.SetRequestURI("/?" + query)
+	c := app.AcquireCtx(ctx)
+	t.Cleanup(func() { app.ReleaseCtx(c) })
+	return c
+}
+
+func TestParseChannelsPage(t *testing.T) {
+	tests := []struct {
+		query     string
+		wantPage  int
+		wantLimit int
+		wantPaged bool
+		wantErr   bool
+	}{
+		{query: ""},
+		{query: "g=news"},
+		{query: "page=2", wantPage: 2, wantLimit: defaultChannelsPageLimit, wantPaged: true},
+		{query: "limit=50", wantPage: 1, wantLimit: 50, wantPaged: true},
+		{query: "page=3&limit=20", wantPage: 3, wantLimit: 20, wantPaged: true},
+		{query: "page=0", wantErr: true},
+		{query: "page=abc", wantErr: true},
+		{query: "limit=0", wantErr: true},
+		{query: fmt.Sprintf("limit=%d", maxChannelsPageLimit+1), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			page, limit, paged, err := parseChannelsPage(newQueryCtx(t, tt.query))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseChannelsPage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if page != tt.wantPage || limit != tt.wantLimit || paged != tt.wantPaged {
+				t.Errorf("parseChannelsPage() = %d, %d, %v, want %d, %d, %v", page, limit, paged, tt.wantPage, tt.wantLimit, tt.wantPaged)
+			}
+		})
+	}
+}
+
+func TestPaginateChannels(t *testing.T) {
+	channels := []television.Channel{{ID: "1"}, {ID: "2"}, {ID: "3"}, {ID: "4"}, {ID: "5"}}
+	tests := []struct {
+		name     string
+		page     int
+		limit    int
+		wantIDs  []string
+		wantInfo channelsPage
+	}{
+		{name: "first page", page: 1, limit: 2, wantIDs: []string{"1", "2"}, wantInfo: channelsPage{Page: 1, Limit: 2, Total: 5, Pages: 3}},
+		{name: "last page", page: 3, limit: 2, wantIDs: []string{"5"}, wantInfo: channelsPage{Page: 3, Limit: 2, Total: 5, Pages: 3}},
+		{name: "after the last page", page: 4, limit: 2, wantIDs: []string{}, wantInfo: channelsPage{Page: 4, Limit: 2, Total: 5, Pages: 3}},
+		{name: "one page", page: 1, limit: 10, wantIDs: []string{"1", "2", "3", "4", "5"}, wantInfo: channelsPage{Page: 1, Limit: 10, Total: 5, Pages: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, info := paginateChannels(channels, tt.page, tt.limit)
+			ids := make([]string, 0, len(got))
+			for _, channel := range got {
+				ids = append(ids, channel.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("paginateChannels() channels = %v, want %v", ids, tt.wantIDs)
+			}
+			if info != tt.wantInfo {
+				t.Errorf("paginateChannels() page = %+v, want %+v", info, tt.wantInfo)
+			}
+		})
+	}
+}
+
+func TestSearchChannels(t *testing.T) {
+	channels := []television.Channel{{ID: "1", Name: "News18 India"}, {ID: "2", Name: "Sports HD"}, {ID: "3", Name: "City news"}}
+	got := searchChannels(channels, "NEWS")
+	if len(got) != 2 || got[0].ID != "1" || got[1].ID != "3" {
+		t.Errorf("searchChannels() = %+v, want channels 1 and 3", got)
+	}
+	if got := searchChannels(channels, "movies"); len(got) != 0 {
+		t.Errorf("searchChannels() = %+v, want no channels", got)
+	}
+}
+
+func TestNewIndexPagination(t *testing.T) {
+	tests := []struct {
+		name      string
+		info      channelsPage
+		wantFirst int
+		wantLast  int
+		wantPrev  string
+		wantNext  string
+		wantLinks []int
+	}{
+		{
+			name:      "first of few pages",
+			info:      channelsPage{Page: 1, Limit: 10, Total: 25, Pages: 3},
+			wantFirst: 1, wantLast: 10,
+			wantNext:  "?language=1&page=2",
+			wantLinks: []int{1, 2, 3},
+		},
+		{
+			name:      "middle of many pages",
+			info:      channelsPage{Page: 10, Limit: 10, Total: 200, Pages: 20},
+			wantFirst: 91, wantLast: 100,
+			wantPrev:  "?language=1&page=9",
+			wantNext:  "?language=1&page=11",
+			wantLinks: []int{1, 0, 8, 9, 10, 11, 12, 0, 20},
+		},
+		{
+			name:      "last page",
+			info:      channelsPage{Page: 5, Limit: 10, Total: 45, Pages: 5},
+			wantFirst: 41, wantLast: 45,
+			wantPrev:  "?language=1&page=4",
+			wantLinks: []int{1, 2, 3, 4, 5},
+		},
+		{
+			name:      "after the last page",
+			info:      channelsPage{Page: 9, Limit: 10, Total: 45, Pages: 5},
+			wantPrev:  "?language=1&page=8",
+			wantLinks: []int{1, 2, 3, 4, 5},
+		},
+		{
+			name: "no channels",
+			info: channelsPage{Page: 1, Limit: 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newIndexPagination(newQueryCtx(t, "language=1&page=7"), tt.info)
+			if got.First != tt.wantFirst || got.Last != tt.wantLast {
+				t.Errorf("newIndexPagination() shows %d-%d, want %d-%d", got.First, got.Last, tt.wantFirst, tt.wantLast)
+			}
+			if got.Prev != tt.wantPrev || got.Next != tt.wantNext {
+				t.Errorf("newIndexPagination() prev, next = %q, %q, want %q, %q", got.Prev, got.Next, tt.wantPrev, tt.wantNext)
+			}
+			var links []int
+			for _, link := range got.Links {
+				links = append(links, link.Number)
+				if link.Current != (link.Number == tt.info.Page) {
+					t.Errorf("newIndexPagination() link %d current = %v", link.Number, link.Current)
+				}
+			}
+			if !reflect.DeepEqual(links, tt.wantLinks) {
+				t.Errorf("newIndexPagination() links = %v, want %v", links, tt.wantLinks)
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package handlers
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/middleware"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/devices"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// Playlist formats of the `format` query param
+const (
+	// playlistFormatM3UPlus is an M3U playlist with tvg attributes and groups, the default
+	playlistFormatM3UPlus = "m3u_plus"
+	// playlistFormatM3U is a plain M3U playlist with only channel names, for simple players
+	playlistFormatM3U = "m3u"
+	// playlistFormatEnigma2 is an Enigma2 user bouquet, for set-top boxes like Dreambox and Vu+
+	playlistFormatEnigma2 = "enigma2"
+	// bouquetFileName is the file name Enigma2 user bouquets are downloaded as
+	bouquetFileName = "userbouquet.jiotv_go.tv"
+	// catchupDays is the number of past days of catchup offered to IPTV players
+	catchupDays = 7
+)
+
+// playlistOptions are the query params of an M3U playlist
+type playlistOptions struct {
+	// Quality is the quality of the stream URLs, or empty for the default
+	Quality string
+	// SplitCategory is "split" for groups of category and language, or "language" for groups of language
+	SplitCategory string
+	// LanguageNames and SkipGenres filter channels by the names of their language and category
+	LanguageNames []string
+	SkipGenres    []string
+	// Languages and Categories filter channels by the IDs of their language and category
+	Languages  []int
+	Categories []int
+	// SkipCustom leaves out the channels of the custom channels file
+	SkipCustom bool
+	Format     string
+	// Profile hides the blocked categories of a profile and lists its favorites first, if not nil
+	Profile *config.Profile
+	// DeviceToken is the token of the device the playlist is for, added to its URLs, if valid
+	DeviceToken string
+}
+
+// splitList returns the non-empty entries of a comma separated list.
+func splitList(value string) []string {
+	var entries []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// splitIDs returns the IDs of a comma separated list.
+func splitIDs(name, value string) ([]int, error) {
+	var ids []int
+	for _, entry := range splitList(value) {
+		id, err := strconv.Atoi(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a comma separated list of IDs", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parsePlaylistOptions returns the playlist options of the query params of the request.
+func parsePlaylistOptions(c *fiber.Ctx) (playlistOptions, error) {
+	opts := playlistOptions{
+		Quality:       strings.TrimSpace(c.Query("q")),
+		SplitCategory: strings.TrimSpace(c.Query("c")),
+		LanguageNames: splitList(c.Query("l")),
+		SkipGenres:    splitList(c.Query("sg")),
+		Format:        strings.ToLower(strings.TrimSpace(c.Query("format", playlistFormatM3UPlus))),
+	}
+	var err error
+	if opts.Languages, err = splitIDs("languages", c.Query("languages")); err != nil {
+		return opts, err
+	}
+	if opts.Categories, err = splitIDs("categories", c.Query("categories")); err != nil {
+		return opts, err
+	}
+	if opts.Profile, err = requestProfile(c); err != nil {
+		return opts, err
+	}
+	if token := c.Query(devices.TokenParam); devices.Default.Valid(token) {
+		opts.DeviceToken = token
+	}
+	if skipCustom := c.Query("skip_custom"); skipCustom != "" {
+		if opts.SkipCustom, err = strconv.ParseBool(skipCustom); err != nil {
+			return opts, fmt.Errorf("skip_custom must be true or false")
+		}
+	}
+	switch opts.Format {
+	case "", playlistFormatM3UPlus:
+		opts.Format = playlistFormatM3UPlus
+	case playlistFormatM3U, playlistFormatEnigma2:
+	default:
+		return opts, fmt.Errorf("format must be m3u, m3u_plus or enigma2")
+	}
+	return opts, nil
+}
+
+// containsInt reports whether ids contains id.
+func containsInt(ids []int, id int) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// filterPlaylistChannels returns the channels selected by the playlist options.
+func filterPlaylistChannels(channels []television.Channel, opts playlistOptions) []television.Channel {
+	filtered := make([]television.Channel, 0, len(channels))
+	for _, channel := range profileChannels(channels, opts.Profile) {
+		if len(opts.LanguageNames) > 0 && !utils.ContainsString(television.LanguageMap[channel.Language], opts.LanguageNames) {
+			continue
+		}
+		if len(opts.SkipGenres) > 0 && utils.ContainsString(television.CategoryMap[channel.Category], opts.SkipGenres) {
+			continue
+		}
+		if len(opts.Languages) > 0 && !containsInt(opts.Languages, channel.Language) {
+			continue
+		}
+		if len(opts.Categories) > 0 && !containsInt(opts.Categories, channel.Category) {
+			continue
+		}
+		if opts.SkipCustom && isCustomChannel(channel.ID) {
+			continue
+		}
+		filtered = append(filtered, channel)
+	}
+	return filtered
+}
+
+// playlistChannelURL returns the stream URL of a channel in the playlist.
+func playlistChannelURL(hostURL string, channel television.Channel, quality string) string {
+	if channel.IsCustom && channel.URL != "" {
+		if quality != "" {
+			return fmt.Sprintf("%s/%s?q=%s", hostURL, channel.URL, quality)
+		}
+		return fmt.Sprintf("%s/%s", hostURL, channel.URL)
+	}
+	if quality != "" {
+		return fmt.Sprintf("%s/live/%s/%s.m3u8", hostURL, quality, channel.ID)
+	}
+	return fmt.Sprintf("%s/live/%s.m3u8", hostURL, channel.ID)
+}
+
+// playlistLink returns the stream URL of a channel in the playlist, with the device token of the playlist.
+// While url_ttl is set, /live links are signed to expire with it, so that the links of a shared playlist
+// stop working. Players that reload the playlist get fresh links.
+func playlistLink(hostURL string, channel television.Channel, opts playlistOptions) string {
+	link := playlistChannelURL(hostURL, channel, opts.Quality)
+	if path := strings.TrimPrefix(link, hostURL); strings.HasPrefix(path, "/live/") {
+		link = hostURL + signLiveLink(path)
+	}
+	return devices.WithToken(link, opts.DeviceToken)
+}
+
+// signLiveLink adds the signature to the path of a /live link while url_ttl is set, see LiveLinkHandler.
+func signLiveLink(path string) string {
+	if !secureurl.LinksExpire() {
+		return path
+	}
+	signature, err := secureurl.SignLink(path)
+	if err != nil {
+		utils.Log.Printf("WARN: Failed to sign the link %s: %v", path, err)
+		return path
+	}
+	return path + "?" + secureurl.LinkSignatureParam + "=" + url.QueryEscape(signature)
+}
+
+// LiveLinkHandler checks the signatures of /live links while url_ttl is set. Expired links and links
+// without a signature, like those of a playlist made before url_ttl was set, get 410 Gone, and modified
+// ones 403 Forbidden. Requests with the web login or the admin token need no signature.
+func LiveLinkHandler(c *fiber.Ctx) error {
+	if !secureurl.LinksExpire() || middleware.Authenticated(c) {
+		return c.Next()
+	}
+	signature := c.Query(secureurl.LinkSignatureParam)
+	if signature == "" {
+		return secureurl.ErrURLExpired
+	}
+	if err := secureurl.VerifyLink(c.Path(), signature); err != nil {
+		return err
+	}
+	return c.Next()
+}
+
+// playlistGroup returns the group title of a channel in the playlist.
+func playlistGroup(channel television.Channel, splitCategory string) string {
+	if channel.Group != "" {
+		return channel.Group
+	}
+	switch splitCategory {
+	case "split":
+		return fmt.Sprintf("%s - %s", television.CategoryMap[channel.Category], television.LanguageMap[channel.Language])
+	case "language":
+		return television.LanguageMap[channel.Language]
+	default:
+		return television.CategoryMap[channel.Category]
+	}
+}
+
+// buildM3UPlaylist returns an M3U playlist of the channels, with tvg attributes unless the format is plain m3u.
+func buildM3UPlaylist(channels []television.Channel, hostURL, header string, opts playlistOptions) string {
+	var m3u strings.Builder
+	m3u.WriteString("#EXTM3U x-tvg-url=\"" + devices.WithToken(hostURL+"/epg.xml.gz", opts.DeviceToken) + "\"\n")
+	if header != "" {
+		m3u.WriteString("# " + header + "\n")
+	}
+	for _, channel := range channels {
+		channelURL := playlistLink(hostURL, channel, opts)
+		if opts.Format == playlistFormatM3U {
+			fmt.Fprintf(&m3u, "#EXTINF:-1,%s\n%s\n", channel.Name, channelURL)
+			continue
+		}
+		var channelNumber string
+		if channel.Number > 0 {
+			channelNumber = fmt.Sprintf(" tvg-chno=\"%d\"", channel.Number)
+		}
+		fmt.Fprintf(&m3u, "#EXTINF:-1 tvg-id=%q%s tvg-name=%q tvg-logo=%q tvg-language=%q tvg-type=%q group-title=%q%s, %s\n%s\n",
+			channel.ID, channelNumber, channel.Name, channelLogoURL(hostURL, channel), television.LanguageMap[channel.Language],
+			television.CategoryMap[channel.Category], playlistGroup(channel, opts.SplitCategory), catchupAttributes(hostURL, channel, opts),
+			channel.Name, channelURL)
+	}
+	return m3u.String()
+}
+
+// catchupAttributes returns the catchup attributes of a channel with catchup, so that players like
+// TiviMate and Kodi can play past programmes. {utc} and {utcend} are replaced by the player with the
+// start and end of the programme in Unix seconds.
+func catchupAttributes(hostURL string, channel television.Channel, opts playlistOptions) string {
+	if !channel.IsCatchupAvailable || isCustomChannel(channel.ID) {
+		return ""
+	}
+	source := hostURL + "/catchup/stream/" + url.PathEscape(channel.ID) + "?start={utc}&end={utcend}"
+	if opts.Quality != "" {
+		source += "&q=" + url.QueryEscape(opts.Quality)
+	}
+	source = devices.WithToken(source, opts.DeviceToken)
+	return fmt.Sprintf(" catchup=\"default\" catchup-days=\"%d\" catchup-source=%q", catchupDays, source)
+}
+
+// enigma2ServiceID returns the service ID of a channel in its Enigma2 service reference, in hex.
+// Numeric JioTV channel IDs are used as they are, so that references stay the same when channels
+// are added or removed. Other channels get an ID from a hash of their channel ID.
+func enigma2ServiceID(channelID string) string {
+	if id, err := strconv.ParseUint(channelID, 10, 16); err == nil && id > 0 {
+		return strconv.FormatUint(id, 16)
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(channelID))
+	// Keep clear of the IDs of JioTV channels, which fit in 16 bits
+	return strconv.FormatUint(uint64(hash.Sum32()&0xFFFF)|0x10000, 16)
+}
+
+// enigma2Escape escapes the colons of a stream URL, which separate the fields of an Enigma2 service.
+func enigma2Escape(value string) string {
+	return strings.ReplaceAll(value, ":", "%3a")
+}
+
+// buildEnigma2Bouquet returns an Enigma2 user bouquet of the channels.
+func buildEnigma2Bouquet(channels []television.Channel, hostURL, name string, opts playlistOptions) string {
+	var bouquet strings.Builder
+	bouquet.WriteString("#NAME " + name + "\n")
+	for _, channel := range channels {
+		// 4097 is the service type of streams played by the GStreamer player of Enigma2
+		fmt.Fprintf(&bouquet, "#SERVICE 4097:0:1:%s:0:0:0:0:0:0:%s:%s\n#DESCRIPTION %s\n",
+			enigma2ServiceID(channel.ID), enigma2Escape(playlistLink(hostURL, channel, opts)), channel.Name, channel.Name)
+	}
+	return bouquet.String()
+}
+
+// sendEnigma2Bouquet sends an Enigma2 user bouquet of the channels as a file download.
+func sendEnigma2Bouquet(c *fiber.Ctx, channels []television.Channel, hostURL string, opts playlistOptions) error {
+	c.Set("Content-Disposition", "attachment; filename="+bouquetFileName)
+	c.Set("Content-Type", "text/plain; charset=utf-8")
+	return c.SendString(buildEnigma2Bouquet(channels, hostURL, Title, opts))
+}
+
+// BouquetHandler serves the channels as an Enigma2 user bouquet on `/bouquet.tv`, for satellite boxes
+// like Dreambox and Vu+. It takes the quality and filter options of M3U playlists.
+func BouquetHandler(c *fiber.Ctx) error {
+	opts, err := parsePlaylistOptions(c)
+	if err != nil {
+		return internalUtils.BadRequestError(c, err.Error())
+	}
+	apiResponse, err := listedChannels(strings.TrimSpace(c.Query("g")))
+	if err != nil {
+		return ErrorMessageHandler(c, err)
+	}
+	channels := filterPlaylistChannels(reorderChannelsForDisplay(apiResponse.Result), opts)
+	return sendEnigma2Bouquet(c, channels, requestHostURL(c), opts)
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/devices"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+func TestParsePlaylistOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    playlistOptions
+		wantErr bool
+	}{
+		{name: "defaults", query: "", want: playlistOptions{Format: playlistFormatM3UPlus}},
+		{
+			name:  "all options",
+			query: "?q=high&c=split&l=Hindi,English&sg=News&languages=1,%206&categories=8&skip_custom=true&format=ENIGMA2",
+			want: playlistOptions{
+				Quality: "high", SplitCategory: "split", LanguageNames: []string{"Hindi", "English"}, SkipGenres: []string{"News"},
+				Languages: []int{1, 6}, Categories: []int{8}, SkipCustom: true, Format: playlistFormatEnigma2,
+			},
+		},
+		{name: "plain m3u", query: "?format=m3u", want: playlistOptions{Format: playlistFormatM3U}},
+		{name: "invalid language", query: "?languages=hindi", wantErr: true},
+		{name: "invalid skip_custom", query: "?skip_custom=maybe", wantErr: true},
+		{name: "unknown format", query: "?format=xspf", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got playlistOptions
+			var gotErr error
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				got, gotErr = parsePlaylistOptions(c)
+				return nil
+			})
+			if _, err := app.Test(httptest.NewRequest("GET", "/"+tt.query, nil)); err != nil {
+				t.Fatal(err)
+			}
+			if (gotErr != nil) != tt.wantErr {
+				t.Fatalf("parsePlaylistOptions() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePlaylistOptions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterPlaylistChannels(t *testing.T) {
+	channels := []television.Channel{
+		{ID: "143", Language: 1, Category: 12},
+		{ID: "144", Language: 6, Category: 8},
+		{ID: "145", Language: 6, Category: 5},
+	}
+	tests := []struct {
+		name string
+		opts playlistOptions
+		want []string
+	}{
+		{"no filters", playlistOptions{}, []string{"143", "144", "145"}},
+		{"language IDs", playlistOptions{Languages: []int{6}}, []string{"144", "145"}},
+		{"category IDs", playlistOptions{Categories: []int{8, 12}}, []string{"143", "144"}},
+		{"both", playlistOptions{Languages: []int{6}, Categories: []int{8}}, []string{"144"}},
+		{"language names", playlistOptions{LanguageNames: []string{"Hindi"}}, []string{"143"}},
+		{"skipped genres", playlistOptions{SkipGenres: []string{"Sports"}}, []string{"143", "145"}},
+		{"profile", playlistOptions{Profile: &config.Profile{FavoriteChannels: []string{"145"}, BlockedCategories: []int{12}}}, []string{"145", "144"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, channel := range filterPlaylistChannels(channels, tt.opts) {
+				got = append(got, channel.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterPlaylistChannels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildPlaylistFormats(t *testing.T) {
+	channels := []television.Channel{{ID: "143", Name: "Aaj Tak", Language: 1, Category: 12, LogoURL: "Aaj_Tak.png"}}
+	hostURL := "http://localhost:5001"
+
+	plain := buildM3UPlaylist(channels, hostURL, "", playlistOptions{Format: playlistFormatM3U, Quality: "high"})
+	if want := "#EXTINF:-1,Aaj Tak\nhttp://localhost:5001/live/high/143.m3u8\n"; !strings.HasSuffix(plain, want) {
+		t.Errorf("buildM3UPlaylist(m3u) = %q, want it to end with %q", plain, want)
+	}
+
+	plus := buildM3UPlaylist(channels, hostURL, "Maintenance tonight", playlistOptions{Format: playlistFormatM3UPlus})
+	for _, want := range []string{"# Maintenance tonight\n", `tvg-id="143"`, `group-title="News"`, "http://localhost:5001/live/143.m3u8"} {
+		if !strings.Contains(plus, want) {
+			t.Errorf("buildM3UPlaylist(m3u_plus) = %q, want it to contain %q", plus, want)
+		}
+	}
+
+	bouquet := buildEnigma2Bouquet(channels, hostURL, "JioTV Go", playlistOptions{})
+	want := "#NAME JioTV Go\n#SERVICE 4097:0:1:8f:0:0:0:0:0:0:http%3a//localhost%3a5001/live/143.m3u8:Aaj Tak\n#DESCRIPTION Aaj Tak\n"
+	if bouquet != want {
+		t.Errorf("buildEnigma2Bouquet() = %q, want %q", bouquet, want)
+	}
+}
+
+func TestPlaylistHandlerKeepsOptions(t *testing.T) {
+	app := fiber.New()
+	app.Get("/playlist.m3u", PlaylistHandler)
+	resp, err := app.Test(httptest.NewRequest("GET", "/playlist.m3u?q=high&languages=1,6&format=enigma2", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusMovedPermanently {
+		t.Fatalf("GET /playlist.m3u = %d, want %d", resp.StatusCode, fiber.StatusMovedPermanently)
+	}
+	if got, want := resp.Header.Get(fiber.HeaderLocation), "/channels?format=enigma2&languages=1%2C6&q=high&type=m3u"; got != want {
+		t.Errorf("GET /playlist.m3u redirects to %q, want %q", got, want)
+	}
+}
+
+func TestEnigma2ServiceID(t *testing.T) {
+	if got := enigma2ServiceID("1146"); got != "47a" {
+		t.Errorf("enigma2ServiceID(1146) = %q, want 47a", got)
+	}
+	custom := enigma2ServiceID("cc_news")
+	if custom != enigma2ServiceID("cc_news") {
+		t.Error("enigma2ServiceID() changes between calls")
+	}
+	if len(custom) != 5 || custom[0] != '1' {
+		t.Errorf("enigma2ServiceID(cc_news) = %q, want an ID above those of JioTV channels", custom)
+	}
+}
+
+func TestCatchupAttributes(t *testing.T) {
+	hostURL := "http://localhost:5001"
+	if got := catchupAttributes(hostURL, television.Channel{ID: "143"}, playlistOptions{}); got != "" {
+		t.Errorf("catchupAttributes() = %q for a channel without catchup, want none", got)
+	}
+	got := catchupAttributes(hostURL, television.Channel{ID: "143", IsCatchupAvailable: true}, playlistOptions{Quality: "high"})
+	want := ` catchup="default" catchup-days="7" catchup-source="http://localhost:5001/catchup/stream/143?start={utc}&end={utcend}&q=high"`
+	if got != want {
+		t.Errorf("catchupAttributes() = %q, want %q", got, want)
+	}
+	got = catchupAttributes(hostURL, television.Channel{ID: "143", IsCatchupAvailable: true}, playlistOptions{DeviceToken: "abc"})
+	want = ` catchup="default" catchup-days="7" catchup-source="http://localhost:5001/catchup/stream/143?start={utc}&end={utcend}&device_token=abc"`
+	if got != want {
+		t.Errorf("catchupAttributes() with a device token = %q, want %q", got, want)
+	}
+}
+
+func TestPlaylistLinkSignature(t *testing.T) {
+	original := config.Cfg
+	defer func() { config.Cfg = original }()
+	config.Cfg.DisableURLEncryption = false
+	config.Cfg.URLTTL = 0
+	secureurl.Init()
+
+	hostURL := "http://localhost:5001"
+	channel := television.Channel{ID: "143"}
+	if got := playlistLink(hostURL, channel, playlistOptions{}); got != hostURL+"/live/143.m3u8" {
+		t.Errorf("playlistLink() without url_ttl = %q, want an unsigned link", got)
+	}
+
+	config.Cfg.URLTTL = 2
+	link := playlistLink(hostURL, channel, playlistOptions{DeviceToken: "abc"})
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("playlistLink() = %q: %v", link, err)
+	}
+	if parsed.Query().Get(devices.TokenParam) != "abc" {
+		t.Errorf("playlistLink() = %q, want the device token", link)
+	}
+	if err := secureurl.VerifyLink(parsed.Path, parsed.Query().Get(secureurl.LinkSignatureParam)); err != nil {
+		t.Errorf("playlistLink() = %q, signature error = %v", link, err)
+	}
+	custom := television.Channel{ID: "0-9-zeetv", URL: "zee5/0-9-zeetv", IsCustom: true}
+	if got := playlistLink(hostURL, custom, playlistOptions{}); strings.Contains(got, secureurl.LinkSignatureParam+"=") {
+		t.Errorf("playlistLink() of a plugin channel = %q, want no signature", got)
+	}
+}
+
+func TestLiveLinkHandler(t *testing.T) {
+	original := config.Cfg
+	defer func() { config.Cfg = original }()
+	config.Cfg.DisableURLEncryption = false
+	config.Cfg.URLTTL = 2
+	config.Cfg.AdminToken = "admin"
+	secureurl.Init()
+
+	signature, err := secureurl.SignLink("/live/143.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use("/live", LiveLinkHandler)
+	app.Get("/live/:id", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	if got := signLiveLink("/live/143.m3u8"); !strings.HasPrefix(got, "/live/143.m3u8?sig=") {
+		t.Errorf("signLiveLink() = %q, want a signed link", got)
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		want   int
+	}{
+		{"signed", "/live/143.m3u8?sig=" + url.QueryEscape(signature), fiber.StatusOK},
+		{"signature of another channel", "/live/144.m3u8?sig=" + url.QueryEscape(signature), fiber.StatusForbidden},
+		{"no signature", "/live/143.m3u8", fiber.StatusGone},
+		{"device token without signature", "/live/143.m3u8?device_token=abc", fiber.StatusGone},
+		{"admin token without signature", "/live/143.m3u8?token=admin", fiber.StatusOK},
+		{"wrong admin token without signature", "/live/143.m3u8?token=other", fiber.StatusGone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", tt.target, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Errorf("GET %s = %d, want %d", tt.target, resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/preview"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// previewQuality is the quality of the segments frames are captured from, the smallest download is enough for a thumbnail
+const previewQuality = "low"
+
+// previewSegment downloads the newest segment of a live channel, decrypted.
+func previewSegment(t *tenant, id string) ([]byte, error) {
+	source := &liveSource{
+		timeshiftSource: timeshiftSource{t: t, id: id, quality: previewQuality},
+		keys:            map[string][]byte{},
+	}
+	segments, _, err := source.Playlist()
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("playlist of channel %s has no segments", id)
+	}
+	return source.Segment(segments[len(segments)-1])
+}
+
+// PreviewHandler serves a recent frame of a live channel as a JPEG on `/preview/:id.jpg`,
+// captured again once it is older than preview_interval.
+func PreviewHandler(c *fiber.Ctx) error {
+	if !preview.Enabled() {
+		return internalUtils.NotFoundError(c, "Previews are disabled")
+	}
+	id := strings.TrimSpace(c.Params("id"))
+	if id == "" || isCustomChannel(id) || isZee5Channel(id) {
+		return internalUtils.NotFoundError(c, "Channel has no preview")
+	}
+	t := tenantOf(c)
+	frame, err := preview.Get(t.name+"/"+id, func() ([]byte, error) {
+		return previewSegment(t, id)
+	})
+	if err != nil {
+		if errors.Is(err, preview.ErrNoFFmpeg) {
+			return internalUtils.InternalServerError(c, err.Error())
+		}
+		utils.Log.Printf("WARN: Failed to capture a preview of channel %s: %v", id, err)
+		return internalUtils.UpstreamError(c, err)
+	}
+
+	maxAge := max(int((preview.Interval()-time.Since(frame.Captured))/time.Second), 1)
+	c.Set(fiber.HeaderCacheControl, "public, max-age="+strconv.Itoa(maxAge))
+	c.Set(fiber.HeaderLastModified, frame.Captured.UTC().Format(http.TimeFormat))
+	c.Set(fiber.HeaderContentType, "image/jpeg")
+	return c.Send(frame.JPEG)
+}
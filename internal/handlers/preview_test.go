@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+func TestPreviewHandlerDisabled(t *testing.T) {
+	original := config.Cfg.PreviewInterval
+	defer func() { config.Cfg.PreviewInterval = original }()
+	config.Cfg.PreviewInterval = 0
+
+	app := fiber.New()
+	app.Get("/preview/:id.jpg", PreviewHandler)
+	resp, err := app.Test(httptest.NewRequest("GET", "/preview/143.jpg", nil))
+	if err != nil {
+		t.Fatalf("GET /preview/143.jpg error = %v", err)
+	}
+	if resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("GET /preview/143.jpg with previews disabled = %d, want %d", resp.StatusCode, fiber.StatusNotFound)
+	}
+}
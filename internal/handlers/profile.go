@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// profileCookie is the cookie that keeps the profile picked in the web interface
+	profileCookie = "jiotv_profile"
+	// profileQuery is the query param that selects a profile, for playlists of IPTV players
+	profileQuery = "profile"
+	// profileMaxAge is how long the picked profile is kept by the browser
+	profileMaxAge = 365 * 24 * time.Hour
+	// errBlockedForProfile is the error of playing a channel of a blocked category
+	errBlockedForProfile = "This channel is blocked for your profile"
+)
+
+// findProfile returns the configured profile with the given name, ignoring case.
+func findProfile(name string) (*config.Profile, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, false
+	}
+	for i := range config.Cfg.Profiles {
+		if strings.EqualFold(config.Cfg.Profiles[i].Name, name) {
+			return &config.Cfg.Profiles[i], true
+		}
+	}
+	return nil, false
+}
+
+// requestProfile returns the profile of the request, selected by the `profile` query param or
+// else by the profile cookie. It is nil when no profile is selected. An unknown profile in the
+// query param is an error, while an unknown profile in the cookie, e.g. one removed from the
+// config, is ignored.
+func requestProfile(c *fiber.Ctx) (*config.Profile, error) {
+	if name := c.Query(profileQuery); name != "" {
+		profile, ok := findProfile(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown profile %q", name)
+		}
+		return profile, nil
+	}
+	profile, _ := findProfile(c.Cookies(profileCookie))
+	return profile, nil
+}
+
+// profileBlocks reports whether the profile may not see channels of the category.
+func profileBlocks(profile *config.Profile, category int) bool {
+	return profile != nil && containsInt(profile.BlockedCategories, category)
+}
+
+// defaultFilters returns the default languages and categories of the channel list for the profile.
+func defaultFilters(profile *config.Profile) (languages, categories []int) {
+	if profile != nil && (len(profile.DefaultLanguages) > 0 || len(profile.DefaultCategories) > 0) {
+		return profile.DefaultLanguages, profile.DefaultCategories
+	}
+	return config.Cfg.DefaultLanguages, config.Cfg.DefaultCategories
+}
+
+// profileChannels returns the channels the profile may see, with its favorite channels first.
+// Without a profile, the channels are returned unchanged.
+func profileChannels(channels []television.Channel, profile *config.Profile) []television.Channel {
+	if profile == nil {
+		return channels
+	}
+	favorites := make([]television.Channel, 0, len(profile.FavoriteChannels))
+	others := make([]television.Channel, 0, len(channels))
+	for _, channel := range channels {
+		switch {
+		case profileBlocks(profile, channel.Category):
+		case utils.ContainsString(channel.ID, profile.FavoriteChannels):
+			favorites = append(favorites, channel)
+		default:
+			others = append(others, channel)
+		}
+	}
+	return append(favorites, others...)
+}
+
+// profileCategories returns the categories of the channel list filter, without those blocked for the profile.
+func profileCategories(profile *config.Profile) map[int]string {
+	if profile == nil || len(profile.BlockedCategories) == 0 {
+		return television.CategoryMap
+	}
+	categories := make(map[int]string, len(television.CategoryMap))
+	for id, name := range television.CategoryMap {
+		if !profileBlocks(profile, id) {
+			categories[id] = name
+		}
+	}
+	return categories
+}
+
+// blockedForProfile reports whether the channel is in a category blocked for the profile of the request.
+func blockedForProfile(c *fiber.Ctx, channelID string) bool {
+	profile, _ := requestProfile(c)
+	if profile == nil || len(profile.BlockedCategories) == 0 {
+		return false
+	}
+	channel, ok := television.ChannelByID(channelID)
+	return ok && profileBlocks(profile, channel.Category)
+}
+
+// ProfilesHandler passes the configured profiles and the picked one to all web pages.
+func ProfilesHandler(c *fiber.Ctx) error {
+	if len(config.Cfg.Profiles) == 0 {
+		return c.Next()
+	}
+	name := ""
+	if profile, _ := requestProfile(c); profile != nil {
+		name = profile.Name
+	}
+	if err := c.Bind(fiber.Map{
+		"Profiles": config.Cfg.Profiles,
+		"Profile":  name,
+	}); err != nil {
+		return err
+	}
+	return c.Next()
+}
+
+// SelectProfileHandler sets the profile posted to `/profile` for the web interface, or clears it
+// when the name is empty, and redirects back to the page of the `next` form value.
+func SelectProfileHandler(c *fiber.Ctx) error {
+	next := safeNextPath(c.FormValue("next"))
+	name := strings.TrimSpace(c.FormValue("name"))
+	if name == "" {
+		c.ClearCookie(profileCookie)
+		return c.Redirect(next, fiber.StatusSeeOther)
+	}
+	profile, ok := findProfile(name)
+	if !ok {
+		return internalUtils.NotFoundError(c, "Unknown profile")
+	}
+	c.Cookie(&fiber.Cookie{
+		Name:     profileCookie,
+		Value:    profile.Name,
+		Path:     "/",
+		Expires:  time.Now().Add(profileMaxAge),
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return c.Redirect(next, fiber.StatusSeeOther)
+}
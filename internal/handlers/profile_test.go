@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+// useProfiles sets the configured profiles until the test ends.
+func useProfiles(t *testing.T, profiles ...config.Profile) {
+	t.Helper()
+	original := config.Cfg.Profiles
+	config.Cfg.Profiles = profiles
+	t.Cleanup(func() { config.Cfg.Profiles = original })
+}
+
+func TestRequestProfile(t *testing.T) {
+	useProfiles(t, config.Profile{Name: "kids"}, config.Profile{Name: "parents"})
+
+	tests := []struct {
+		name    string
+		query   string
+		cookie  string
+		want    string
+		wantErr bool
+	}{
+		{name: "No profile", want: ""},
+		{name: "Query", query: "?profile=Kids", want: "kids"},
+		{name: "Cookie", cookie: "parents", want: "parents"},
+		{name: "Query before cookie", query: "?profile=kids", cookie: "parents", want: "kids"},
+		{name: "Unknown query", query: "?profile=guests", wantErr: true},
+		{name: "Unknown cookie is ignored", cookie: "guests", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *config.Profile
+			var gotErr error
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				got, gotErr = requestProfile(c)
+				return nil
+			})
+			req := httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: profileCookie, Value: tt.cookie})
+			}
+			if _, err := app.Test(req); err != nil {
+				t.Fatal(err)
+			}
+			if (gotErr != nil) != tt.wantErr {
+				t.Fatalf("requestProfile() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+			name := ""
+			if got != nil {
+				name = got.Name
+			}
+			if name != tt.want {
+				t.Errorf("requestProfile() = %q, want %q", name, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileChannels(t *testing.T) {
+	channels := []television.Channel{
+		{ID: "143", Category: 5},
+		{ID: "144", Category: 8},
+		{ID: "145", Category: 7},
+		{ID: "146", Category: 5},
+	}
+	ids := func(channels []television.Channel) []string {
+		var ids []string
+		for _, channel := range channels {
+			ids = append(ids, channel.ID)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name    string
+		profile *config.Profile
+		want    []string
+	}{
+		{name: "No profile", want: []string{"143", "144", "145", "146"}},
+		{name: "Favorites first", profile: &config.Profile{FavoriteChannels: []string{"146", "144"}}, want: []string{"144", "146", "143", "145"}},
+		{name: "Blocked categories", profile: &config.Profile{BlockedCategories: []int{5}}, want: []string{"144", "145"}},
+		{
+			name:    "Blocked favorite",
+			profile: &config.Profile{FavoriteChannels: []string{"143", "145"}, BlockedCategories: []int{5}},
+			want:    []string{"145", "144"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ids(profileChannels(channels, tt.profile)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("profileChannels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultFilters(t *testing.T) {
+	originalLanguages, originalCategories := config.Cfg.DefaultLanguages, config.Cfg.DefaultCategories
+	defer func() {
+		config.Cfg.DefaultLanguages, config.Cfg.DefaultCategories = originalLanguages, originalCategories
+	}()
+	config.Cfg.DefaultLanguages, config.Cfg.DefaultCategories = []int{6}, []int{5}
+
+	if languages, categories := defaultFilters(nil); !reflect.DeepEqual(languages, []int{6}) || !reflect.DeepEqual(categories, []int{5}) {
+		t.Errorf("defaultFilters(nil) = %v, %v, want the config defaults", languages, categories)
+	}
+	kids := &config.Profile{DefaultCategories: []int{7}}
+	if languages, categories := defaultFilters(kids); languages != nil || !reflect.DeepEqual(categories, []int{7}) {
+		t.Errorf("defaultFilters(kids) = %v, %v, want the profile defaults", languages, categories)
+	}
+	if languages, categories := defaultFilters(&config.Profile{}); !reflect.DeepEqual(languages, []int{6}) || !reflect.DeepEqual(categories, []int{5}) {
+		t.Errorf("defaultFilters() of a profile without defaults = %v, %v, want the config defaults", languages, categories)
+	}
+}
+
+func TestSelectProfileHandler(t *testing.T) {
+	useProfiles(t, config.Profile{Name: "kids"})
+	app := fiber.New()
+	app.Post("/profile", SelectProfileHandler)
+
+	post := func(name string) *http.Response {
+		form := url.Values{"name": {name}, "next": {"/guide"}}
+		req := httptest.NewRequest(http.MethodPost, "/profile", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("POST /profile error = %v", err)
+		}
+		return resp
+	}
+	profileCookieOf := func(resp *http.Response) *http.Cookie {
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == profileCookie {
+				return cookie
+			}
+		}
+		return nil
+	}
+
+	resp := post("KIDS")
+	if resp.StatusCode != fiber.StatusSeeOther || resp.Header.Get("Location") != "/guide" {
+		t.Errorf("select = %d to %q, want 303 to /guide", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if cookie := profileCookieOf(resp); cookie == nil || cookie.Value != "kids" {
+		t.Errorf("select should set the profile cookie to kids, got %+v", cookie)
+	}
+
+	if resp := post("guests"); resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("unknown profile status = %d, want 404", resp.StatusCode)
+	}
+
+	resp = post("")
+	if cookie := profileCookieOf(resp); cookie == nil || cookie.Value != "" {
+		t.Errorf("an empty name should clear the profile cookie, got %+v", cookie)
+	}
+}
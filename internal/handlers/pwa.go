@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/web"
+)
+
+const (
+	// serviceWorkerFile is the service worker in the static files of the web interface
+	serviceWorkerFile = "static/internal/sw.js"
+	// serviceWorkerCacheVersion is replaced with the version in the service worker, so that an
+	// update of JioTV Go replaces the cached files
+	serviceWorkerCacheVersion = "__CACHE_VERSION__"
+	// manifestDarkColor and manifestLightColor are the base colors of the daisyUI themes
+	manifestDarkColor  = "#1d232a"
+	manifestLightColor = "#ffffff"
+)
+
+// webManifestIcon is an icon of the web app manifest
+type webManifestIcon struct {
+	Src   string `json:"src"`
+	Sizes string `json:"sizes"`
+	Type  string `json:"type,omitempty"`
+}
+
+// webManifest is the web app manifest that lets browsers install the web interface as an app
+type webManifest struct {
+	Name            string            `json:"name"`
+	ShortName       string            `json:"short_name"`
+	ID              string            `json:"id"`
+	StartURL        string            `json:"start_url"`
+	Scope           string            `json:"scope"`
+	Display         string            `json:"display"`
+	Orientation     string            `json:"orientation"`
+	BackgroundColor string            `json:"background_color"`
+	ThemeColor      string            `json:"theme_color"`
+	Categories      []string          `json:"categories"`
+	Icons           []webManifestIcon `json:"icons"`
+}
+
+// buildWebManifest returns the web app manifest with the configured title, logo and colors.
+func buildWebManifest() webManifest {
+	background := manifestDarkColor
+	if Theme == themeLight {
+		background = manifestLightColor
+	}
+	themeColor := background
+	if accentColor != "" {
+		themeColor = accentColor
+	}
+	icons := []webManifestIcon{
+		{Src: "/static/icons/icon-small.webp", Sizes: "48x48", Type: "image/webp"},
+		{Src: "/static/icons/icon-medium.png", Sizes: "72x72 96x96 128x128 256x256", Type: "image/png"},
+		{Src: "/static/icons/icon-high.svg", Sizes: "any", Type: "image/svg+xml"},
+	}
+	if LogoURL != "" {
+		icons = append([]webManifestIcon{{Src: LogoURL, Sizes: "any"}}, icons...)
+	}
+	return webManifest{
+		Name:            Title,
+		ShortName:       Title,
+		ID:              "/",
+		StartURL:        "/",
+		Scope:           "/",
+		Display:         "standalone",
+		Orientation:     "any",
+		BackgroundColor: background,
+		ThemeColor:      themeColor,
+		Categories:      []string{"entertainment", "lifestyle", "news", "audio-video"},
+		Icons:           icons,
+	}
+}
+
+// WebManifestHandler serves the web app manifest on `/manifest.webmanifest`.
+func WebManifestHandler(c *fiber.Ctx) error {
+	if !web.Included {
+		return internalUtils.NotFoundError(c, "The web interface is not included in this build")
+	}
+	manifest, err := json.Marshal(buildWebManifest())
+	if err != nil {
+		return internalUtils.InternalServerError(c, err)
+	}
+	c.Set(fiber.HeaderContentType, "application/manifest+json")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	return c.Send(manifest)
+}
+
+// ServiceWorkerHandler serves the service worker on `/sw.js`. It is served from the root rather
+// than from /static, so that it can cache and serve all pages of the web interface.
+func ServiceWorkerHandler(c *fiber.Ctx) error {
+	file, err := web.StaticFiles().Open(serviceWorkerFile)
+	if err != nil {
+		return internalUtils.NotFoundError(c, "The web interface is not included in this build")
+	}
+	defer file.Close()
+	script, err := io.ReadAll(file)
+	if err != nil {
+		return internalUtils.InternalServerError(c, err)
+	}
+	version := strings.TrimSpace(constants.Version)
+	c.Set(fiber.HeaderContentType, "text/javascript; charset=utf-8")
+	// Browsers check for a new service worker on every visit
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	return c.SendString(strings.ReplaceAll(string(script), serviceWorkerCacheVersion, version))
+}
+
+// OfflineHandler renders the page the service worker shows on `/offline` when the server
+// cannot be reached and the requested page is not cached.
+func OfflineHandler(c *fiber.Ctx) error {
+	return c.Render("views/offline", fiber.Map{
+		"Title": Title,
+	})
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/web"
+)
+
+func TestBuildWebManifest(t *testing.T) {
+	originalTitle, originalTheme, originalAccent, originalLogo := Title, Theme, accentColor, LogoURL
+	defer func() {
+		Title, Theme, accentColor, LogoURL = originalTitle, originalTheme, originalAccent, originalLogo
+	}()
+
+	tests := []struct {
+		name           string
+		theme          string
+		accent         string
+		logo           string
+		wantBackground string
+		wantThemeColor string
+		wantIcons      int
+	}{
+		{"follows the system", "", "", "", manifestDarkColor, manifestDarkColor, 3},
+		{"light theme", themeLight, "", "", manifestLightColor, manifestLightColor, 3},
+		{"accent color and logo", themeDark, "#e11d48", "https://example.com/logo.png", manifestDarkColor, "#e11d48", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Title, Theme, accentColor, LogoURL = "My TV", tt.theme, tt.accent, tt.logo
+			manifest := buildWebManifest()
+			if manifest.Name != "My TV" || manifest.StartURL != "/" || manifest.Display != "standalone" {
+				t.Errorf("buildWebManifest() = %+v, want the title, start URL / and standalone display", manifest)
+			}
+			if manifest.BackgroundColor != tt.wantBackground || manifest.ThemeColor != tt.wantThemeColor {
+				t.Errorf("buildWebManifest() colors = %q %q, want %q %q", manifest.BackgroundColor, manifest.ThemeColor, tt.wantBackground, tt.wantThemeColor)
+			}
+			if len(manifest.Icons) != tt.wantIcons {
+				t.Fatalf("buildWebManifest() has %d icons, want %d", len(manifest.Icons), tt.wantIcons)
+			}
+			if tt.logo != "" && manifest.Icons[0].Src != tt.logo {
+				t.Errorf("buildWebManifest() first icon = %q, want the logo %q", manifest.Icons[0].Src, tt.logo)
+			}
+		})
+	}
+}
+
+func TestWebManifestHandler(t *testing.T) {
+	if !web.Included {
+		t.Skip("the web interface is not compiled into headless builds")
+	}
+	app := fiber.New()
+	app.Get("/manifest.webmanifest", WebManifestHandler)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/manifest.webmanifest", nil))
+	if err != nil {
+		t.Fatalf("GET /manifest.webmanifest error = %v", err)
+	}
+	if got := resp.Header.Get(fiber.HeaderContentType); got != "application/manifest+json" {
+		t.Errorf("Content-Type = %q, want application/manifest+json", got)
+	}
+	var manifest webManifest
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		t.Fatalf("decoding the manifest: %v", err)
+	}
+	if manifest.Scope != "/" || len(manifest.Icons) == 0 {
+		t.Errorf("manifest = %+v, want scope / and icons", manifest)
+	}
+}
+
+func TestServiceWorkerHandler(t *testing.T) {
+	if !web.Included {
+		t.Skip("the web interface is not compiled into headless builds")
+	}
+	app := fiber.New()
+	app.Get("/sw.js", ServiceWorkerHandler)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/sw.js", nil))
+	if err != nil {
+		t.Fatalf("GET /sw.js error = %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("GET /sw.js = %d, want %d", resp.StatusCode, fiber.StatusOK)
+	}
+	if got := resp.Header.Get(fiber.HeaderContentType); !strings.HasPrefix(got, "text/javascript") {
+		t.Errorf("Content-Type = %q, want text/javascript", got)
+	}
+	if got := resp.Header.Get(fiber.HeaderCacheControl); got != "no-cache" {
+		t.Errorf("Cache-Control = %q, want no-cache", got)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if strings.Contains(string(body), serviceWorkerCacheVersion) {
+		t.Error("GET /sw.js still contains the cache version placeholder")
+	}
+	if !strings.Contains(string(body), `addEventListener("fetch"`) {
+		t.Error("GET /sw.js does not look like the service worker")
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/hls"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/record"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/timeshift"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// catchupSource is a catchup programme of a JioTV channel read segment by segment, for recordings.
+type catchupSource struct {
+	liveSource
+	// playlistURL is the catchup playlist, a master or media playlist
+	playlistURL string
+}
+
+// Playlist implements record.Source. The variant of the quality of the source is picked from
+// the catchup playlist, and picked again when its playlist fails.
+func (s *catchupSource) Playlist() ([]hls.Segment, int, error) {
+	if s.mediaURL == "" {
+		body, statusCode, newHdnea := s.t.TV().Render(s.playlistURL, s.t.hdnea.Get(s.id))
+		if newHdnea != "" {
+			s.t.hdnea.Set(s.id, newHdnea)
+		}
+		if statusCode != fiber.StatusOK {
+			return nil, 0, fmt.Errorf("catchup playlist of channel %s returned status %d", s.id, statusCode)
+		}
+		s.mediaURL = s.playlistURL
+		if timeshift.IsMasterPlaylist(body) {
+			base, err := url.Parse(s.playlistURL)
+			if err != nil {
+				return nil, 0, err
+			}
+			variants, err := timeshift.ParseMasterPlaylist(body, base)
+			if err != nil {
+				return nil, 0, err
+			}
+			s.mediaURL = timeshift.SelectVariant(variants, s.quality).URI
+		}
+	}
+	return s.liveSource.Playlist()
+}
+
+// newCatchupSource returns a source of the catchup programme of the channel from start to end.
+func newCatchupSource(t *tenant, id, quality string, start, end time.Time) (*catchupSource, error) {
+	if err := t.ensureFreshTokens(); err != nil {
+		utils.Log.Printf("Failed to ensure fresh tokens: %v", err)
+	}
+	catchupResult, err := t.TV().GetCatchupURL(id, "",
+		catchupEpochTime(start.UnixMilli()).Format("20060102T150405"),
+		catchupEpochTime(end.UnixMilli()).Format("20060102T150405"))
+	if err != nil {
+		return nil, err
+	}
+	playlistURL := cappedCatchupHLSURL(catchupResult, television.ChannelCatchupQualityCap(id))
+	if playlistURL == "" {
+		return nil, fmt.Errorf("no catchup HLS stream found for channel %s", id)
+	}
+	if catchupResult.Hdnea != "" {
+		t.hdnea.Set(id, catchupResult.Hdnea)
+	}
+	return &catchupSource{
+		liveSource:  liveSource{timeshiftSource: timeshiftSource{t: t, id: id, quality: quality}, keys: map[string][]byte{}},
+		playlistURL: playlistURL,
+	}, nil
+}
+
+// RecordChannel saves the JioTV channel from start to end to w as MPEG-TS. Programmes that have
+// ended are saved from catchup. Otherwise the live stream is saved from start, or from now if start
+// has passed, until end. It stops early when ctx is done, keeping what was saved.
+func RecordChannel(ctx context.Context, id, quality string, start, end time.Time, w io.Writer, progress func(record.Progress)) error {
+	if id == "" || isCustomChannel(id) || isZee5Channel(id) {
+		return fmt.Errorf("%q is not a JioTV channel", id)
+	}
+	if !end.After(start) {
+		return fmt.Errorf("end must be after start")
+	}
+	if quality == "" {
+		quality = "auto"
+	}
+
+	var err error
+	if end.Before(time.Now()) {
+		var source *catchupSource
+		if source, err = newCatchupSource(defaultTenant, id, quality, start, end); err != nil {
+			return err
+		}
+		_, err = record.Record(ctx, source, w, record.Options{Progress: progress})
+		return err
+	}
+
+	if wait := time.Until(start); wait > 0 {
+		utils.Log.Printf("INFO: Waiting until %s to record channel %s", start.Format(time.RFC1123), id)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+	}
+	source := &liveSource{
+		timeshiftSource: timeshiftSource{t: defaultTenant, id: id, quality: television.CapQuality(quality, television.ChannelQualityCap(id))},
+		keys:            map[string][]byte{},
+	}
+	_, err = record.Record(ctx, source, w, record.Options{Live: true, Until: end, Progress: progress})
+	return err
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/middleware"
+)
+
+const (
+	// sessionCookie is the cookie that identifies a playback session
+	sessionCookie = "jiotv_session"
+	// sessionQuery is the query parameter that identifies a playback session, for players without cookies
+	sessionQuery = "sid"
+	// sessionMaxAge is how long a playback session cookie is kept by the client
+	sessionMaxAge = 30 * 24 * time.Hour
+	// sessionLocal is the fiber local holding the playback session of a request
+	sessionLocal = "session"
+)
+
+// validSessionID matches session IDs sent by clients, so arbitrary values do not end up in the stats
+var validSessionID = regexp.MustCompile(`^[A-Za-z0-9_-]{8,64}$`)
+
+// sessionID returns the playback session of the client. Sessions are identified by the `sid`
+// query parameter or the session cookie rather than the client IP, so a phone switching between
+// mobile data and Wi-Fi keeps its session. If the client has no session, a new one is started
+// and the session cookie is set.
+func sessionID(c *fiber.Ctx) string {
+	if sid, ok := c.Locals(sessionLocal).(string); ok {
+		return sid
+	}
+	sid := newSessionID(c)
+	c.Locals(sessionLocal, sid)
+	return sid
+}
+
+// newSessionID returns the session of the request, or starts a new one. Sessions outlive the request
+// in the stats, so they are copied out of the request buffers, which fiber reuses.
+func newSessionID(c *fiber.Ctx) string {
+	if sid := c.Query(sessionQuery); validSessionID.MatchString(sid) {
+		return strings.Clone(sid)
+	}
+	if sid := c.Cookies(sessionCookie); validSessionID.MatchString(sid) {
+		return strings.Clone(sid)
+	}
+
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		// Fall back to the IP, which still identifies the client until it switches networks
+		return c.IP()
+	}
+	sid := hex.EncodeToString(bytes)
+	c.Cookie(&fiber.Cookie{
+		Name:     sessionCookie,
+		Value:    sid,
+		Expires:  time.Now().Add(sessionMaxAge),
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return sid
+}
+
+// SessionHandler adds the playback session to the redirects and playlists of this server, like the
+// redirect of `/live` to `/render.m3u8` and the segments of the rendered playlist. IPTV players
+// without cookies then keep one session for as long as they play, instead of starting one per request.
+func SessionHandler(c *fiber.Ctx) error {
+	if err := c.Next(); err != nil {
+		return err
+	}
+	sid, ok := c.Locals(sessionLocal).(string)
+	if !ok {
+		sid = c.Query(sessionQuery)
+	}
+	if validSessionID.MatchString(sid) {
+		middleware.CarryQuery(c, sessionQuery, sid)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSessionID(t *testing.T) {
+	app := fiber.New()
+	app.Get("/session", func(c *fiber.Ctx) error {
+		return c.SendString(sessionID(c))
+	})
+
+	tests := []struct {
+		name       string
+		target     string
+		cookie     string
+		want       string
+		wantCookie bool
+	}{
+		{name: "query parameter", target: "/session?sid=phone-1234", want: "phone-1234"},
+		{name: "cookie", target: "/session", cookie: "abcdef0123456789", want: "abcdef0123456789"},
+		{name: "query parameter wins over cookie", target: "/session?sid=phone-1234", cookie: "abcdef0123456789", want: "phone-1234"},
+		{name: "new session", target: "/session", wantCookie: true},
+		{name: "invalid value starts a new session", target: "/session?sid=x", cookie: "<script>", wantCookie: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.cookie != "" {
+				req.Header.Set("Cookie", sessionCookie+"="+tt.cookie)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			got := string(body)
+
+			if tt.want != "" && got != tt.want {
+				t.Errorf("sessionID() = %q, want %q", got, tt.want)
+			}
+			var setCookie string
+			for _, cookie := range resp.Cookies() {
+				if cookie.Name == sessionCookie {
+					setCookie = cookie.Value
+				}
+			}
+			if tt.wantCookie {
+				if !validSessionID.MatchString(got) || setCookie != got {
+					t.Errorf("sessionID() = %q, cookie = %q, want a new session in the cookie", got, setCookie)
+				}
+			} else if setCookie != "" {
+				t.Errorf("cookie set to %q for an existing session", setCookie)
+			}
+		})
+	}
+}
+
+func TestSessionHandler(t *testing.T) {
+	app := fiber.New()
+	app.Use(SessionHandler)
+	app.Get("/live/:id", func(c *fiber.Ctx) error {
+		sessionID(c)
+		return c.Redirect("/render.m3u8?auth=abc&channel_key_id="+c.Params("id"), fiber.StatusFound)
+	})
+	app.Get("/render.m3u8", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, "application/vnd.apple.mpegurl")
+		return c.SendString("#EXTM3U\n#EXTINF:4,\n/render.ts?auth=seg\n#EXTINF:4,\nhttp://cdn.example.com/seg.ts\n")
+	})
+	app.Get("/other", func(c *fiber.Ctx) error {
+		return c.Redirect("/render.m3u8?auth=abc", fiber.StatusFound)
+	})
+
+	tests := []struct {
+		name         string
+		target       string
+		wantLocation string
+		wantBody     string
+	}{
+		{
+			name:         "redirect of a session from the query",
+			target:       "/live/143?sid=phone-1234",
+			wantLocation: "/render.m3u8?auth=abc&channel_key_id=143&sid=phone-1234",
+		},
+		{
+			name:     "playlist of a session",
+			target:   "/render.m3u8?auth=abc&sid=phone-1234",
+			wantBody: "#EXTM3U\n#EXTINF:4,\n/render.ts?auth=seg&sid=phone-1234\n#EXTINF:4,\nhttp://cdn.example.com/seg.ts\n",
+		},
+		{
+			name:         "no session",
+			target:       "/other",
+			wantLocation: "/render.m3u8?auth=abc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", tt.target, nil))
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			if got := resp.Header.Get(fiber.HeaderLocation); got != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", got, tt.wantLocation)
+			}
+			if tt.wantBody != "" {
+				body, _ := io.ReadAll(resp.Body)
+				if string(body) != tt.wantBody {
+					t.Errorf("body = %q, want %q", body, tt.wantBody)
+				}
+			}
+		})
+	}
+
+	// A new session is carried as well, for players that drop the cookie
+	resp, err := app.Test(httptest.NewRequest("GET", "/live/143", nil))
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	var cookie string
+	for _, c := range resp.Cookies() {
+		if c.Name == sessionCookie {
+			cookie = c.Value
+		}
+	}
+	if want := "/render.m3u8?auth=abc&channel_key_id=143&sid=" + cookie; cookie == "" || resp.Header.Get(fiber.HeaderLocation) != want {
+		t.Errorf("Location = %q, want %q", resp.Header.Get(fiber.HeaderLocation), want)
+	}
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/stats"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/timeshift"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// Resources of a stream listed on `/api/v1/sessions`
+const (
+	sessionResourceConversion = "conversion"
+	sessionResourceTimeshift  = "timeshift"
+)
+
+// streamSession is an active stream on `/api/v1/sessions`
+type streamSession struct {
+	ChannelID   string    `json:"channel_id"`
+	ChannelName string    `json:"channel_name"`
+	Client      string    `json:"client"`
+	Started     time.Time `json:"started"`
+	LastSeen    time.Time `json:"last_seen"`
+	IdleSeconds int       `json:"idle_seconds"`
+	// Resources are what the server keeps for the channel, e.g. a converted stream or a timeshift buffer
+	Resources []string `json:"resources"`
+}
+
+// sessionsResponse is the response of `/api/v1/sessions`
+type sessionsResponse struct {
+	IdleTimeout int             `json:"idle_timeout"`
+	Sessions    []streamSession `json:"sessions"`
+}
+
+// streamIdleTimeout returns how long a stream stays active without playlist or segment requests.
+func streamIdleTimeout() time.Duration {
+	return time.Duration(config.Cfg.StreamIdleTimeout) * time.Second
+}
+
+// channelOfKey returns the channel ID of a key like "tenant/id" or "tenant/id/quality".
+func channelOfKey(key string) string {
+	parts := strings.Split(key, "/")
+	if len(parts) < 2 {
+		return key
+	}
+	return parts[1]
+}
+
+// channelResources returns the resources kept for each channel.
+func channelResources() map[string][]string {
+	resources := map[string][]string{}
+	conversionsMu.Lock()
+	for key := range conversions {
+		id := channelOfKey(key)
+		resources[id] = append(resources[id], sessionResourceConversion)
+	}
+	conversionsMu.Unlock()
+	for _, key := range timeshift.Keys() {
+		id := channelOfKey(key)
+		resources[id] = append(resources[id], sessionResourceTimeshift)
+	}
+	return resources
+}
+
+// releaseIdleConversions drops the converted streams of channels that no client is watching and that
+// were not requested for idle, and returns their keys.
+func releaseIdleConversions(watched map[string]bool, idle time.Duration, now time.Time) []string {
+	conversionsMu.Lock()
+	defer conversionsMu.Unlock()
+	released := []string{}
+	for key, conv := range conversions {
+		conv.mu.Lock()
+		unused := now.Sub(conv.lastUsed) > idle
+		conv.mu.Unlock()
+		if unused && !watched[conv.id] {
+			delete(conversions, key)
+			released = append(released, key)
+		}
+	}
+	return released
+}
+
+// ReapIdleStreams releases the converted streams of channels whose clients stopped requesting
+// playlists and segments for the stream idle timeout. Timeshift buffers are not released, as a
+// paused player may resume within the timeshift window.
+func ReapIdleStreams() error {
+	watched := map[string]bool{}
+	for _, stream := range stats.ActiveStreams() {
+		watched[stream.ChannelID] = true
+	}
+	released := releaseIdleConversions(watched, stats.Default.IdleTimeout(), time.Now())
+	if len(released) > 0 && os.Getenv("JIOTV_DEBUG") == "true" {
+		utils.Log.Printf("[DEBUG] Released idle converted streams: %v", released)
+	}
+	return nil
+}
+
+// keepStreamAlive records the activity of the stream the playback session played last, for segments
+// that carry no channel ID.
+func keepStreamAlive(c *fiber.Ctx) {
+	sid := sessionID(c)
+	if id := stats.Default.ActiveChannel(sid); id != "" {
+		stats.RecordActivity(id, sid)
+	}
+}
+
+// SessionsHandler returns the active streams and the resources kept for them on `/api/v1/sessions`.
+func SessionsHandler(c *fiber.Ctx) error {
+	if ok, err := checkAdminToken(c); !ok {
+		return err
+	}
+	now := time.Now()
+	resources := channelResources()
+	response := sessionsResponse{
+		IdleTimeout: int(stats.Default.IdleTimeout().Seconds()),
+		Sessions:    []streamSession{},
+	}
+	for _, stream := range stats.ActiveStreams() {
+		session := streamSession{
+			ChannelID:   stream.ChannelID,
+			ChannelName: statsChannelName(stream.ChannelID),
+			Client:      stream.Client,
+			Started:     stream.Started,
+			LastSeen:    stream.LastSeen,
+			IdleSeconds: int(now.Sub(stream.LastSeen).Seconds()),
+			Resources:   resources[stream.ChannelID],
+		}
+		if session.Resources == nil {
+			session.Resources = []string{}
+		}
+		response.Sessions = append(response.Sessions, session)
+	}
+	return c.JSON(response)
+}
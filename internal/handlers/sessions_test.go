@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/stats"
+)
+
+func TestChannelOfKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"/143", "143"},
+		{"family/143", "143"},
+		{"/143/high", "143"},
+		{"143", "143"},
+	}
+	for _, tt := range tests {
+		if got := channelOfKey(tt.key); got != tt.want {
+			t.Errorf("channelOfKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestReleaseIdleConversions(t *testing.T) {
+	conversionsMu.Lock()
+	original := conversions
+	now := time.Now()
+	conversions = map[string]*conversion{
+		"/143":       {id: "143", lastUsed: now.Add(-5 * time.Minute)},
+		"/144":       {id: "144", lastUsed: now.Add(-5 * time.Minute)},
+		"/145":       {id: "145", lastUsed: now.Add(-10 * time.Second)},
+		"family/143": {id: "143", lastUsed: now.Add(-time.Hour)},
+	}
+	conversionsMu.Unlock()
+	defer func() {
+		conversionsMu.Lock()
+		conversions = original
+		conversionsMu.Unlock()
+	}()
+
+	released := releaseIdleConversions(map[string]bool{"143": true}, time.Minute, now)
+	if !reflect.DeepEqual(released, []string{"/144"}) {
+		t.Errorf("released = %v, want only the idle conversion of an unwatched channel", released)
+	}
+	keys := []string{}
+	for key := range conversions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if want := []string{"/143", "/145", "family/143"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("conversions = %v, want %v", keys, want)
+	}
+}
+
+func TestSessionsHandler(t *testing.T) {
+	original, originalStats := config.Cfg, stats.Default
+	defer func() { config.Cfg, stats.Default = original, originalStats }()
+	config.Cfg.AdminToken = "admin"
+	stats.Default = stats.NewRecorder()
+	stats.Default.SetIdleTimeout(90 * time.Second)
+	stats.RecordActivity("143", "tv-abcdefgh")
+
+	app := fiber.New()
+	app.Get("/api/v1/sessions", SessionsHandler)
+	app.Get("/render.dash/*", func(c *fiber.Ctx) error {
+		keepStreamAlive(c)
+		return nil
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/sessions", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("status without the admin token = %d, want 401", resp.StatusCode)
+	}
+
+	// The stream of a playback session is kept alive by DASH segments
+	stats.RecordActivity("144", "phone-1234")
+	if _, err := app.Test(httptest.NewRequest(http.MethodGet, "/render.dash/seg.m4s?sid=phone-1234", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/sessions?token=admin", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result sessionsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("decoding the sessions: %v", err)
+	}
+	if result.IdleTimeout != 90 {
+		t.Errorf("IdleTimeout = %d, want 90", result.IdleTimeout)
+	}
+	if len(result.Sessions) != 2 || result.Sessions[0].ChannelID != "143" || result.Sessions[0].Client != "tv-abcdefgh" || result.Sessions[0].Resources == nil {
+		t.Errorf("Sessions = %+v, want the streams of tv-abcdefgh and phone-1234", result.Sessions)
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/plugins"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	pkgUtils "github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// currentCatchupProgramme returns the programme of a catchup EPG that is airing at now and its start time.
+func currentCatchupProgramme(programmes []map[string]interface{}, now time.Time) (map[string]interface{}, time.Time, bool) {
+	for _, programme := range programmes {
+		start, okStart := catchupEpoch(programme, "startEpoch")
+		stop, okStop := catchupEpoch(programme, "endEpoch")
+		if okStart && okStop && !now.Before(start) && now.Before(stop) {
+			return programme, start, true
+		}
+	}
+	return nil, time.Time{}, false
+}
+
+// StartOverHandler restarts the programme airing on a channel on `/startover/:id`.
+// It redirects to the catchup player for the programme from its beginning up to now.
+func StartOverHandler(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if isCustomChannel(id) {
+		return internalUtils.BadRequestError(c, "Start-over is not available for custom channels")
+	}
+
+	var programmes []map[string]interface{}
+	var err error
+	if isZee5Channel(id) {
+		programmes, err = plugins.CatchupEPG("zee5", id, 0)
+	} else {
+		programmes, err = cachedCatchupEPGFor(id, 0)
+	}
+	if err != nil {
+		pkgUtils.Log.Println("Error fetching catchup EPG for start-over:", err)
+		return internalUtils.UpstreamError(c, err)
+	}
+
+	now := time.Now()
+	programme, start, ok := currentCatchupProgramme(programmes, now)
+	if !ok {
+		return internalUtils.NotFoundError(c, "No programme is airing on this channel")
+	}
+	return c.Redirect(tenantBase(c)+catchupPlayPath(id, programme, start, now), fiber.StatusFound)
+}
+
+// catchupDayOffset returns the catchup EPG day of t relative to now, where 0 is today and -1 is yesterday.
+// Days follow Indian Standard Time, like the JioTV guide.
+func catchupDayOffset(t, now time.Time) int {
+	loc := istLocation()
+	day := func(t time.Time) time.Time {
+		y, m, d := t.In(loc).Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	return int(day(t).Sub(day(now)) / (24 * time.Hour))
+}
+
+// CatchupAtHandler plays the programme that aired on a channel at a time on `/catchup/at/:id?t=<unix_ms>`.
+// It redirects to the catchup player for the programme, so that a guide only needs the time to link to it.
+func CatchupAtHandler(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if isCustomChannel(id) {
+		return internalUtils.BadRequestError(c, "Catchup is not available for custom channels")
+	}
+	millis, err := strconv.ParseInt(c.Query("t"), 10, 64)
+	if err != nil {
+		return internalUtils.BadRequestError(c, "Invalid t, use a Unix time in milliseconds")
+	}
+	at, now := time.UnixMilli(millis), time.Now()
+	offset := catchupDayOffset(at, now)
+	if at.After(now) || offset < -catchupSearchMaxDays+1 {
+		return internalUtils.BadRequestError(c, "Catchup is only available for the last 7 days")
+	}
+
+	var programmes []map[string]interface{}
+	if isZee5Channel(id) {
+		programmes, err = plugins.CatchupEPG("zee5", id, offset)
+	} else {
+		programmes, err = cachedCatchupEPGFor(id, offset)
+	}
+	if err != nil {
+		pkgUtils.Log.Println("Error fetching catchup EPG:", err)
+		return internalUtils.UpstreamError(c, err)
+	}
+
+	programme, start, ok := currentCatchupProgramme(programmes, at)
+	if !ok {
+		return internalUtils.NotFoundError(c, "No programme aired on this channel at that time")
+	}
+	// A programme that is still airing plays up to now, like start-over
+	stop, _ := catchupEpoch(programme, "endEpoch")
+	if stop.After(now) {
+		stop = now
+	}
+	return c.Redirect(tenantBase(c)+catchupPlayPath(id, programme, start, stop), fiber.StatusFound)
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+func TestCurrentCatchupProgramme(t *testing.T) {
+	now := time.Date(2024, 1, 11, 12, 30, 0, 0, time.UTC)
+	programme := func(title string, start time.Time) map[string]interface{} {
+		return map[string]interface{}{
+			"showname":   title,
+			"startEpoch": start.UnixMilli(),
+			"endEpoch":   start.Add(time.Hour).UnixMilli(),
+		}
+	}
+
+	tests := []struct {
+		name       string
+		programmes []map[string]interface{}
+		want       string
+		wantStart  time.Time
+	}{
+		{
+			name: "airing",
+			programmes: []map[string]interface{}{
+				programme("Earlier", now.Add(-2*time.Hour)),
+				programme("Current", now.Add(-30*time.Minute)),
+				programme("Later", now.Add(30*time.Minute)),
+			},
+			want:      "Current",
+			wantStart: now.Add(-30 * time.Minute),
+		},
+		{
+			name:       "starting now",
+			programmes: []map[string]interface{}{programme("Current", now)},
+			want:       "Current",
+			wantStart:  now,
+		},
+		{
+			name: "gap in schedule",
+			programmes: []map[string]interface{}{
+				programme("Earlier", now.Add(-2*time.Hour)),
+				{"showname": "No times"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, start, ok := currentCatchupProgramme(tt.programmes, now)
+			if ok != (tt.want != "") {
+				t.Fatalf("currentCatchupProgramme() ok = %v, want %v", ok, tt.want != "")
+			}
+			if !ok {
+				return
+			}
+			if catchupString(got, "showname") != tt.want || !start.Equal(tt.wantStart) {
+				t.Errorf("currentCatchupProgramme() = %v, %v, want %s, %v", got["showname"], start, tt.want, tt.wantStart)
+			}
+		})
+	}
+}
+
+func TestStartOverHandler(t *testing.T) {
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	start := time.Now().Add(-20 * time.Minute).Truncate(time.Second)
+
+	originalFetch := fetchCatchupEPG
+	defer func() { fetchCatchupEPG = originalFetch }()
+	fetchCatchupEPG = func(id string, offset int) ([]map[string]interface{}, error) {
+		if id != "startover_live" {
+			return nil, errors.New("no EPG")
+		}
+		return []map[string]interface{}{{
+			"showname":      "Live Show",
+			"episodePoster": "2024-01-11/show.jpg",
+			"srno":          "7",
+			"startEpoch":    start.UnixMilli(),
+			"endEpoch":      start.Add(time.Hour).UnixMilli(),
+		}}, nil
+	}
+
+	app := fiber.New()
+	app.Get("/startover/:id", StartOverHandler)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/startover/startover_live", nil))
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	if resp.StatusCode != fiber.StatusFound {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, fiber.StatusFound)
+	}
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("invalid Location %q: %v", resp.Header.Get("Location"), err)
+	}
+	query := location.Query()
+	if location.Path != "/catchup/play/startover_live" || query.Get("showname") != "Live Show" ||
+		query.Get("srno") != "7" || query.Get("poster") != "2024-01-11/show.jpg" ||
+		query.Get("start") != strconv.FormatInt(start.UnixMilli(), 10) {
+		t.Errorf("Location = %s", location)
+	}
+	end, _ := strconv.ParseInt(query.Get("end"), 10, 64)
+	if end <= start.UnixMilli() || end > time.Now().UnixMilli() {
+		t.Errorf("end = %d, want the time of the request", end)
+	}
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/startover/startover_missing", nil))
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	if resp.StatusCode == fiber.StatusFound {
+		t.Errorf("start-over without EPG redirected to %s", resp.Header.Get("Location"))
+	}
+}
+
+func TestCatchupDayOffset(t *testing.T) {
+	ist := istLocation()
+	now := time.Date(2024, 1, 11, 1, 0, 0, 0, ist)
+	tests := []struct {
+		at   time.Time
+		want int
+	}{
+		{at: now.Add(-30 * time.Minute), want: 0},
+		{at: time.Date(2024, 1, 10, 23, 59, 0, 0, ist), want: -1},
+		// 20:00 UTC on January 10 is already January 11 in India
+		{at: time.Date(2024, 1, 10, 20, 0, 0, 0, time.UTC), want: 0},
+		{at: time.Date(2024, 1, 4, 12, 0, 0, 0, ist), want: -7},
+	}
+	for _, tt := range tests {
+		if got := catchupDayOffset(tt.at, now); got != tt.want {
+			t.Errorf("catchupDayOffset(%v) = %d, want %d", tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestCatchupAtHandler(t *testing.T) {
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	start := time.Now().Add(-3 * time.Hour).Truncate(time.Second)
+
+	originalFetch := fetchCatchupEPG
+	defer func() { fetchCatchupEPG = originalFetch }()
+	fetchCatchupEPG = func(id string, offset int) ([]map[string]interface{}, error) {
+		return []map[string]interface{}{{
+			"showname":   "Past Show",
+			"srno":       "3",
+			"startEpoch": start.UnixMilli(),
+			"endEpoch":   start.Add(time.Hour).UnixMilli(),
+		}}, nil
+	}
+
+	app := fiber.New()
+	app.Get("/catchup/at/:id", CatchupAtHandler)
+	get := func(target string) *http.Response {
+		resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+		if err != nil {
+			t.Fatalf("GET %s error = %v", target, err)
+		}
+		return resp
+	}
+
+	at := start.Add(10 * time.Minute).UnixMilli()
+	resp := get("/catchup/at/catchup_at?t=" + strconv.FormatInt(at, 10))
+	if resp.StatusCode != fiber.StatusFound {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, fiber.StatusFound)
+	}
+	location, _ := url.Parse(resp.Header.Get("Location"))
+	query := location.Query()
+	if location.Path != "/catchup/play/catchup_at" || query.Get("srno") != "3" ||
+		query.Get("start") != strconv.FormatInt(start.UnixMilli(), 10) ||
+		query.Get("end") != strconv.FormatInt(start.Add(time.Hour).UnixMilli(), 10) {
+		t.Errorf("Location = %s", location)
+	}
+
+	tests := []struct {
+		target     string
+		wantStatus int
+	}{
+		{"/catchup/at/catchup_at?t=x", fiber.StatusBadRequest},
+		{"/catchup/at/catchup_at?t=" + strconv.FormatInt(time.Now().Add(time.Hour).UnixMilli(), 10), fiber.StatusBadRequest},
+		{"/catchup/at/catchup_at?t=" + strconv.FormatInt(time.Now().AddDate(0, 0, -8).UnixMilli(), 10), fiber.StatusBadRequest},
+		{"/catchup/at/catchup_at?t=" + strconv.FormatInt(start.Add(-time.Hour).UnixMilli(), 10), fiber.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if resp := get(tt.target); resp.StatusCode != tt.wantStatus {
+			t.Errorf("GET %s status = %d, want %d", tt.target, resp.StatusCode, tt.wantStatus)
+		}
+	}
+}
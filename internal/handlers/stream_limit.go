@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/stats"
+	"github.com/jiotv-go/jiotv_go/v3/web"
+)
+
+// streamLimitMessage returns why a stream is refused with max_concurrent_streams.
+func streamLimitMessage() string {
+	streams := "streams are"
+	if config.Cfg.MaxConcurrentStreams == 1 {
+		streams = "stream is"
+	}
+	return fmt.Sprintf("%d %s already playing, the most this server allows at once. Stop another stream and try again in %d seconds.",
+		config.Cfg.MaxConcurrentStreams, streams, int(stats.Default.IdleTimeout().Seconds()))
+}
+
+// admitStream records the activity of a stream of the playback session of the client, and refuses
+// the stream if max_concurrent_streams other streams are playing.
+func admitStream(c *fiber.Ctx, channelID string) (bool, error) {
+	if stats.AdmitStream(channelID, sessionID(c), config.Cfg.MaxConcurrentStreams) {
+		return true, nil
+	}
+	return false, streamLimitResponse(c)
+}
+
+// canStartStream reports whether a stream of the client would be admitted, without recording it.
+// The players check it to show the limit page instead of a player that fails to load.
+func canStartStream(c *fiber.Ctx, channelID string) bool {
+	return stats.Default.CanStart(channelID, sessionID(c), config.Cfg.MaxConcurrentStreams)
+}
+
+// streamLimitResponse responds with `429 Too Many Requests`. Browsers get a page with the channels
+// that are playing, without the clients that play them.
+func streamLimitResponse(c *fiber.Ctx) error {
+	message := streamLimitMessage()
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(stats.Default.IdleTimeout().Seconds())))
+	if web.Included && c.Method() == fiber.MethodGet && strings.Contains(c.Get(fiber.HeaderAccept), fiber.MIMETextHTML) {
+		playing := []string{}
+		for _, stream := range stats.ActiveStreams() {
+			playing = append(playing, statsChannelName(stream.ChannelID))
+		}
+		return c.Status(fiber.StatusTooManyRequests).Render("views/stream_limit", fiber.Map{
+			"Title":   Title,
+			"Message": message,
+			"Playing": playing,
+		})
+	}
+	return internalUtils.ErrorResponse(c, fiber.StatusTooManyRequests, message)
+}
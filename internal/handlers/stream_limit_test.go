@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/stats"
+	"github.com/jiotv-go/jiotv_go/v3/web"
+)
+
+func TestStreamLimitMessage(t *testing.T) {
+	original := config.Cfg
+	defer func() { config.Cfg = original }()
+
+	tests := []struct {
+		limit int
+		want  string
+	}{
+		{1, "1 stream is already playing"},
+		{3, "3 streams are already playing"},
+	}
+	for _, tt := range tests {
+		config.Cfg.MaxConcurrentStreams = tt.limit
+		if got := streamLimitMessage(); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("streamLimitMessage() with %d = %q, want it to start with %q", tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestAdmitStream(t *testing.T) {
+	original, originalStats := config.Cfg, stats.Default
+	defer func() { config.Cfg, stats.Default = original, originalStats }()
+	config.Cfg.MaxConcurrentStreams = 1
+	stats.Default = stats.NewRecorder()
+	stats.Default.RecordActivity("144", "10.0.0.2")
+
+	app := fiber.New(fiber.Config{Views: web.Views(false)})
+	app.Get("/render.m3u8", func(c *fiber.Ctx) error {
+		if ok, err := admitStream(c, c.Query("channel_key_id")); !ok {
+			return err
+		}
+		return c.SendString("#EXTM3U")
+	})
+	request := func(accept string) *http.Response {
+		req := httptest.NewRequest(http.MethodGet, "/render.m3u8?channel_key_id=143&sid=phone-1234", nil)
+		if accept != "" {
+			req.Header.Set(fiber.HeaderAccept, accept)
+		}
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := request("")
+	if resp.StatusCode != fiber.StatusTooManyRequests || resp.Header.Get(fiber.HeaderRetryAfter) != "60" {
+		t.Errorf("status over the limit = %d, want 429 with Retry-After", resp.StatusCode)
+	}
+	if web.Included {
+		resp := request("text/html")
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != fiber.StatusTooManyRequests || !strings.Contains(string(body), "Too many streams") || !strings.Contains(string(body), "144") {
+			t.Errorf("browser status over the limit = %d, want 429 with the limit page and the playing channel", resp.StatusCode)
+		}
+	}
+
+	config.Cfg.MaxConcurrentStreams = 2
+	if resp := request(""); resp.StatusCode != fiber.StatusOK {
+		t.Errorf("status under the limit = %d, want 200", resp.StatusCode)
+	}
+
+	// Streams are counted by playback session, not by IP, so a session switching channels keeps its place
+	config.Cfg.MaxConcurrentStreams = 1
+	stats.Default = stats.NewRecorder()
+	stats.Default.RecordActivity("144", "phone-1234")
+	if resp := request(""); resp.StatusCode != fiber.StatusOK {
+		t.Errorf("status of a session switching channels = %d, want 200", resp.StatusCode)
+	}
+	stats.Default = stats.NewRecorder()
+	stats.Default.RecordActivity("144", "tv-abcdefgh")
+	if resp := request(""); resp.StatusCode != fiber.StatusTooManyRequests {
+		t.Errorf("status with another session on the same IP = %d, want 429", resp.StatusCode)
+	}
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/cdntoken"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// tenantPathPrefix selects a tenant when followed by the tenant name, e.g. "/t/family/playlist.m3u"
+	tenantPathPrefix = "/t/"
+	// tenantCookie remembers the tenant selected by path, so the web pages keep using it
+	tenantCookie = "jiotv_tenant"
+	// tenantLocal is the fiber local holding the tenant of a request
+	tenantLocal = "tenant"
+	// tenantBaseLocal is the fiber local holding the path prefix of the tenant of a request
+	tenantBaseLocal = constants.TenantBaseLocal
+)
+
+// validTenantName matches tenant names, which are also used as directory names
+var validTenantName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// tenant is a logical JioTV Go instance within the process, with its own JioTV login.
+// The default tenant uses the global store and TV.
+type tenant struct {
+	name      string
+	host      string
+	favorites []string
+	account   *utils.Account
+
+	// tv is the JioTV client of the tenant. The default tenant uses TV instead.
+	tv   *television.Television
+	tvMu sync.RWMutex
+
+	// tokenRefreshMutex prevents concurrent token refreshes
+	tokenRefreshMutex sync.Mutex
+	// tokenRefreshLock prevents concurrent TV object modifications from race conditions
+	tokenRefreshLock sync.Mutex
+	// nextCredentialValidationTime tracks when token validity should be checked next.
+	// This prevents rechecking/re-refreshing on every request.
+	nextCredentialValidationTime time.Time
+
+	// hdnea holds the latest HDNEA token of each channel
+	hdnea cdntoken.Cache
+}
+
+var (
+	// defaultTenant serves requests that select no tenant
+	defaultTenant = &tenant{account: utils.DefaultAccount}
+	// tenants holds the configured tenants, indexed by name
+	tenants   = map[string]*tenant{}
+	tenantsMu sync.RWMutex
+)
+
+// initTenants loads the tenants from the config, each with the credentials in its own store.
+func initTenants() {
+	next := make(map[string]*tenant, len(config.Cfg.Tenants))
+	for _, tc := range config.Cfg.Tenants {
+		if !validTenantName.MatchString(tc.Name) {
+			utils.Log.Printf("WARN: Ignoring tenant %q: names may only have letters, digits, \"-\" and \"_\"", tc.Name)
+			continue
+		}
+		if _, ok := next[tc.Name]; ok {
+			utils.Log.Printf("WARN: Ignoring duplicate tenant %q", tc.Name)
+			continue
+		}
+		kvs, err := store.ForTenant(tc.Name)
+		if err != nil {
+			utils.Log.Printf("WARN: Ignoring tenant %q: %v", tc.Name, err)
+			continue
+		}
+		t := &tenant{
+			name:      tc.Name,
+			host:      strings.ToLower(strings.TrimSpace(tc.Host)),
+			favorites: tc.FavoriteChannels,
+			account:   utils.NewAccount(kvs),
+		}
+		t.reload()
+		next[tc.Name] = t
+	}
+
+	tenantsMu.Lock()
+	tenants = next
+	tenantsMu.Unlock()
+}
+
+// TV returns the JioTV client of the tenant.
+func (t *tenant) TV() *television.Television {
+	if t == defaultTenant {
+		return TV
+	}
+	t.tvMu.RLock()
+	defer t.tvMu.RUnlock()
+	return t.tv
+}
+
+// setTV replaces the JioTV client of the tenant with one using the given credentials.
+func (t *tenant) setTV(credentials *utils.JIOTV_CREDENTIALS) {
+	if t == defaultTenant {
+		TV = television.New(credentials)
+		return
+	}
+	tv := television.NewForAccount(credentials, t.account)
+	t.tvMu.Lock()
+	t.tv = tv
+	t.tvMu.Unlock()
+}
+
+// reload reloads the credentials of the tenant after a login or logout.
+func (t *tenant) reload() {
+	if t == defaultTenant {
+		Init()
+		return
+	}
+	credentials, err := t.account.Credentials()
+	if err != nil {
+		utils.Log.Printf("Tenant %s is not logged in: %v", t.name, err)
+		credentials = nil
+	}
+	t.setTV(credentials)
+}
+
+// favoriteChannels returns the favorite channels of the tenant.
+func (t *tenant) favoriteChannels() []string {
+	if t == defaultTenant || len(t.favorites) == 0 {
+		return config.Cfg.FavoriteChannels
+	}
+	return t.favorites
+}
+
+// tenantOf returns the tenant selected by TenantHandler for the request.
+func tenantOf(c *fiber.Ctx) *tenant {
+	if t, ok := c.Locals(tenantLocal).(*tenant); ok {
+		return t
+	}
+	return defaultTenant
+}
+
+// tenantBase returns the path prefix to prepend to URLs of the request's tenant, e.g. "/t/family".
+// It is empty for the default tenant and for tenants selected by hostname.
+func tenantBase(c *fiber.Ctx) string {
+	base, _ := c.Locals(tenantBaseLocal).(string)
+	return base
+}
+
+// lookupTenant returns the tenant with the given name.
+func lookupTenant(name string) (*tenant, bool) {
+	tenantsMu.RLock()
+	defer tenantsMu.RUnlock()
+	t, ok := tenants[name]
+	return t, ok
+}
+
+// tenantForHost returns the tenant serving the given hostname.
+func tenantForHost(host string) (*tenant, bool) {
+	if parsedHost, _, err := net.SplitHostPort(host); err == nil {
+		host = parsedHost
+	}
+	host = strings.ToLower(host)
+	tenantsMu.RLock()
+	defer tenantsMu.RUnlock()
+	for _, t := range tenants {
+		if t.host != "" && t.host == host {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// TenantHandler selects the tenant of a request, by the "/t/<name>" path prefix, the hostname or
+// the tenant cookie, in that order. The path prefix is removed before routing, and the tenant
+// cookie is set so that the links of the web pages keep using the tenant.
+// It must be registered before all routes.
+func TenantHandler(c *fiber.Ctx) error {
+	tenantsMu.RLock()
+	enabled := len(tenants) > 0
+	tenantsMu.RUnlock()
+	if !enabled {
+		return c.Next()
+	}
+
+	// "/t/" goes back to the default tenant
+	if c.Path() == tenantPathPrefix || c.Path() == strings.TrimSuffix(tenantPathPrefix, "/") {
+		c.ClearCookie(tenantCookie)
+		return c.Redirect("/", fiber.StatusFound)
+	}
+
+	if rest, ok := strings.CutPrefix(c.Path(), tenantPathPrefix); ok {
+		name, path, _ := strings.Cut(rest, "/")
+		t, ok := lookupTenant(name)
+		if !ok {
+			return fiber.NewError(fiber.StatusNotFound, "Unknown tenant")
+		}
+		c.Locals(tenantLocal, t)
+		c.Locals(tenantBaseLocal, tenantPathPrefix+name)
+		c.Cookie(&fiber.Cookie{
+			Name:     tenantCookie,
+			Value:    name,
+			HTTPOnly: true,
+			SameSite: fiber.CookieSameSiteLaxMode,
+		})
+		c.Path("/" + path)
+		return c.Next()
+	}
+
+	if t, ok := tenantForHost(c.Hostname()); ok {
+		c.Locals(tenantLocal, t)
+		return c.Next()
+	}
+
+	if t, ok := lookupTenant(c.Cookies(tenantCookie)); ok {
+		c.Locals(tenantLocal, t)
+		c.Locals(tenantBaseLocal, tenantPathPrefix+t.name)
+	}
+	return c.Next()
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+func TestTenantHandler(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	originalTenants := config.Cfg.Tenants
+	config.Cfg.Tenants = []config.Tenant{
+		{Name: "family", Host: "family.example.com", FavoriteChannels: []string{"143"}},
+		{Name: "bad name"},
+	}
+	defer func() {
+		config.Cfg.Tenants = originalTenants
+		initTenants()
+	}()
+	initTenants()
+
+	if _, ok := lookupTenant("bad name"); ok {
+		t.Error("tenant with an invalid name should be ignored")
+	}
+	family, ok := lookupTenant("family")
+	if !ok {
+		t.Fatal("tenant family not loaded")
+	}
+	if family.account.DeviceID() == utils.GetDeviceID() {
+		t.Error("tenant should have its own device ID")
+	}
+	if got := family.favoriteChannels(); len(got) != 1 || got[0] != "143" {
+		t.Errorf("favoriteChannels() = %v", got)
+	}
+
+	app := fiber.New()
+	app.Use(TenantHandler)
+	app.Get("/whoami", func(c *fiber.Ctx) error {
+		return c.SendString(tenantOf(c).name + " " + requestHostURL(c))
+	})
+
+	tests := []struct {
+		name       string
+		target     string
+		host       string
+		cookie     string
+		wantStatus int
+		wantBody   string
+		wantCookie string
+	}{
+		{name: "default tenant", target: "/whoami", wantStatus: fiber.StatusOK, wantBody: " http://example.com"},
+		{name: "path prefix", target: "/t/family/whoami", wantStatus: fiber.StatusOK, wantBody: "family http://example.com/t/family", wantCookie: "family"},
+		{name: "hostname", target: "/whoami", host: "family.example.com:5001", wantStatus: fiber.StatusOK, wantBody: "family http://family.example.com:5001"},
+		{name: "cookie", target: "/whoami", cookie: "family", wantStatus: fiber.StatusOK, wantBody: "family http://example.com/t/family"},
+		{name: "unknown cookie", target: "/whoami", cookie: "other", wantStatus: fiber.StatusOK, wantBody: " http://example.com"},
+		{name: "unknown tenant", target: "/t/other/whoami", wantStatus: fiber.StatusNotFound},
+		{name: "back to default tenant", target: "/t/", cookie: "family", wantStatus: fiber.StatusFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			if tt.cookie != "" {
+				req.Header.Set("Cookie", tenantCookie+"="+tt.cookie)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if tt.wantBody != "" && string(body) != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+			var setCookie string
+			for _, cookie := range resp.Cookies() {
+				if cookie.Name == tenantCookie {
+					setCookie = cookie.Value
+				}
+			}
+			if tt.wantCookie != "" && setCookie != tt.wantCookie {
+				t.Errorf("tenant cookie = %q, want %q", setCookie, tt.wantCookie)
+			}
+		})
+	}
+}
+
+func TestTenantCredentialsAreIsolated(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	originalTenants := config.Cfg.Tenants
+	config.Cfg.Tenants = []config.Tenant{{Name: "family"}}
+	defer func() {
+		config.Cfg.Tenants = originalTenants
+		initTenants()
+	}()
+	initTenants()
+	family, _ := lookupTenant("family")
+
+	if err := family.account.WriteCredentials(&utils.JIOTV_CREDENTIALS{SSOToken: "sso", CRM: "family-crm", UniqueID: "unique", AccessToken: "access", RefreshToken: "refresh"}); err != nil {
+		t.Fatal(err)
+	}
+	family.reload()
+
+	if got := family.TV().Crm; got != "family-crm" {
+		t.Errorf("tenant TV Crm = %q, want family-crm", got)
+	}
+	if utils.CheckLoggedIn() {
+		t.Error("logging in a tenant should not log in the default tenant")
+	}
+
+	family.hdnea.Set("143", "token")
+	if defaultTenant.hdnea.Get("143") != "" {
+		t.Error("HDNEA tokens should not be shared between tenants")
+	}
+}
+
+func TestEnsureFreshCredentialsAdoptsStoredTokens(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	originalTenants := config.Cfg.Tenants
+	config.Cfg.Tenants = []config.Tenant{{Name: "family"}}
+	defer func() {
+		config.Cfg.Tenants = originalTenants
+		initTenants()
+	}()
+	initTenants()
+	family, _ := lookupTenant("family")
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	credentials := &utils.JIOTV_CREDENTIALS{SSOToken: "sso", CRM: "crm", UniqueID: "unique", AccessToken: "access", RefreshToken: "refresh", LastTokenRefreshTime: now, LastSSOTokenRefreshTime: now}
+	if err := family.account.WriteCredentials(credentials); err != nil {
+		t.Fatal(err)
+	}
+	family.reload()
+
+	// Another server sharing the store refreshed the tokens
+	credentials.AccessToken, credentials.SSOToken = "new-access", "new-sso"
+	if err := family.account.WriteCredentials(credentials); err != nil {
+		t.Fatal(err)
+	}
+	family.nextCredentialValidationTime = time.Time{}
+
+	if !family.ensureFreshCredentials() {
+		t.Fatal("ensureFreshCredentials() = false")
+	}
+	if tv := family.TV(); tv.AccessToken != "new-access" || tv.SsoToken != "new-sso" {
+		t.Errorf("TV tokens = %q, %q, want the tokens in the store", tv.AccessToken, tv.SsoToken)
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// themeCookie remembers the theme picked with the theme toggle of the web pages
+	themeCookie = "jiotv_theme"
+	themeAuto   = "auto"
+	themeDark   = "dark"
+	themeLight  = "light"
+	// defaultFaviconURL is the favicon shipped with the web interface
+	defaultFaviconURL = "/static/favicon.ico"
+)
+
+var (
+	// Theme is the configured theme, "dark" or "light", or empty to follow the system
+	Theme string
+	// accentStyle overrides the primary color of daisyUI with the configured accent color
+	accentStyle template.CSS
+	// accentColor is the configured accent color as "#rrggbb", or empty
+	accentColor string
+	// LogoURL is the logo shown next to the title, or empty for the default icon
+	LogoURL string
+	// FaviconURL is the favicon of the web pages
+	FaviconURL string
+)
+
+// initTheme reads the theme and branding options from the config.
+func initTheme() {
+	Theme = ""
+	switch theme := strings.ToLower(strings.TrimSpace(config.Cfg.Theme)); theme {
+	case "", themeAuto:
+	case themeDark, themeLight:
+		Theme = theme
+	default:
+		utils.Log.Printf("WARN: Unknown theme %q, following the system theme", config.Cfg.Theme)
+	}
+
+	accentStyle, accentColor = "", ""
+	if accent := strings.TrimSpace(config.Cfg.AccentColor); accent != "" {
+		style, err := accentColorStyle(accent)
+		if err != nil {
+			utils.Log.Printf("WARN: Ignoring accent_color: %v", err)
+		} else {
+			accentStyle = style
+			r, g, b, _ := parseHexColor(accent)
+			accentColor = hexColor(r, g, b)
+		}
+	}
+
+	LogoURL = strings.TrimSpace(config.Cfg.LogoURL)
+	FaviconURL = strings.TrimSpace(config.Cfg.FaviconURL)
+	if FaviconURL == "" {
+		FaviconURL = defaultFaviconURL
+	}
+}
+
+// parseHexColor parses a color like "#e11d48" or "#e14" into its red, green and blue components from 0 to 1.
+func parseHexColor(hex string) (r, g, b float64, err error) {
+	value := strings.TrimPrefix(hex, "#")
+	if len(value) == 3 {
+		value = string([]byte{value[0], value[0], value[1], value[1], value[2], value[2]})
+	}
+	if len(value) != 6 {
+		return 0, 0, 0, fmt.Errorf("invalid color %q, use a hex color like #e11d48", hex)
+	}
+	rgb, err := strconv.ParseUint(value, 16, 32)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid color %q, use a hex color like #e11d48", hex)
+	}
+	return float64(rgb>>16&0xff) / 255, float64(rgb>>8&0xff) / 255, float64(rgb&0xff) / 255, nil
+}
+
+// hexColor formats red, green and blue components from 0 to 1 as "#rrggbb".
+func hexColor(r, g, b float64) string {
+	return fmt.Sprintf("#%02x%02x%02x", int(math.Round(r*255)), int(math.Round(g*255)), int(math.Round(b*255)))
+}
+
+// oklch converts a sRGB color to OKLCH, the color space daisyUI themes are defined in.
+// Lightness is from 0 to 1 and hue is in degrees.
+func oklch(r, g, b float64) (lightness, chroma, hue float64) {
+	linear := func(v float64) float64 {
+		if v <= 0.04045 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	r, g, b = linear(r), linear(g), linear(b)
+
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+
+	lightness = 0.2104542553*l + 0.7936177850*m - 0.0040720468*s
+	labA := 1.9779984951*l - 2.4285922050*m + 0.4505937099*s
+	labB := 0.0259040371*l + 0.7827717662*m - 0.8086757660*s
+	chroma = math.Hypot(labA, labB)
+	hue = math.Atan2(labB, labA) * 180 / math.Pi
+	if hue < 0 {
+		hue += 360
+	}
+	return lightness, chroma, hue
+}
+
+// accentColorStyle returns the CSS variables that make a hex color the primary color of daisyUI,
+// with black or white text on it, whichever is easier to read.
+func accentColorStyle(hex string) (template.CSS, error) {
+	r, g, b, err := parseHexColor(hex)
+	if err != nil {
+		return "", err
+	}
+	lightness, chroma, hue := oklch(r, g, b)
+	content, fallbackContent := "100% 0 0", "#fff"
+	if lightness > 0.6 {
+		content, fallbackContent = "0% 0 0", "#000"
+	}
+	return template.CSS(fmt.Sprintf("--p:%.2f%% %.4f %.2f;--pc:%s;--fallback-p:%s;--fallback-pc:%s;",
+		lightness*100, chroma, hue, content, hexColor(r, g, b), fallbackContent)), nil
+}
+
+// themeOf returns the theme of the web pages for a request: the one picked with the theme toggle,
+// otherwise the configured one. It is empty when the pages follow the system theme.
+func themeOf(c *fiber.Ctx) string {
+	switch theme := c.Cookies(themeCookie); theme {
+	case themeDark, themeLight:
+		return theme
+	}
+	return Theme
+}
+
+// ThemeHandler passes the theme and branding to all web pages.
+func ThemeHandler(c *fiber.Ctx) error {
+	if err := c.Bind(fiber.Map{
+		"Theme":       themeOf(c),
+		"AccentStyle": accentStyle,
+		"LogoURL":     LogoURL,
+		"FaviconURL":  FaviconURL,
+	}); err != nil {
+		return err
+	}
+	return c.Next()
+}
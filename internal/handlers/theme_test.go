@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+	"github.com/jiotv-go/jiotv_go/v3/web"
+)
+
+func TestOKLCH(t *testing.T) {
+	tests := []struct {
+		name                   string
+		hex                    string
+		lightness, chroma, hue float64
+	}{
+		{"white", "#ffffff", 1, 0, -1},
+		{"black", "#000", 0, 0, -1},
+		{"red", "#ff0000", 0.6280, 0.2577, 29.23},
+		{"blue", "#0000ff", 0.4520, 0.3132, 264.05},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, g, b, err := parseHexColor(tt.hex)
+			if err != nil {
+				t.Fatalf("parseHexColor(%q) error = %v", tt.hex, err)
+			}
+			lightness, chroma, hue := oklch(r, g, b)
+			if math.Abs(lightness-tt.lightness) > 0.001 || math.Abs(chroma-tt.chroma) > 0.001 {
+				t.Errorf("oklch(%s) = %.4f %.4f, want %.4f %.4f", tt.hex, lightness, chroma, tt.lightness, tt.chroma)
+			}
+			if tt.hue >= 0 && math.Abs(hue-tt.hue) > 0.1 {
+				t.Errorf("oklch(%s) hue = %.2f, want %.2f", tt.hex, hue, tt.hue)
+			}
+		})
+	}
+}
+
+func TestAccentColorStyle(t *testing.T) {
+	tests := []struct {
+		name    string
+		hex     string
+		want    []string
+		wantErr bool
+	}{
+		{"dark accent has white text", "#1d4ed8", []string{"--p:", "--pc:100% 0 0;", "--fallback-p:#1d4ed8;", "--fallback-pc:#fff;"}, false},
+		{"light accent has black text", "#FACC15", []string{"--pc:0% 0 0;", "--fallback-p:#facc15;", "--fallback-pc:#000;"}, false},
+		{"short hex", "#f00", []string{"--fallback-p:#ff0000;"}, false},
+		{"not hex", "#zzzzzz", nil, true},
+		{"color name", "red", nil, true},
+		{"css injection", "#fff;}body{", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			style, err := accentColorStyle(tt.hex)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("accentColorStyle(%q) error = %v, wantErr %v", tt.hex, err, tt.wantErr)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(style), want) {
+					t.Errorf("accentColorStyle(%q) = %q, want it to contain %q", tt.hex, style, want)
+				}
+			}
+		})
+	}
+}
+
+func TestThemeOf(t *testing.T) {
+	original := Theme
+	defer func() { Theme = original }()
+	Theme = themeDark
+
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(themeOf(c))
+	})
+
+	tests := []struct {
+		name   string
+		cookie string
+		want   string
+	}{
+		{"configured theme without cookie", "", "dark"},
+		{"cookie overrides the config", "light", "light"},
+		{"unknown cookie is ignored", "purple", "dark"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.cookie != "" {
+				req.Header.Set("Cookie", themeCookie+"="+tt.cookie)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("GET / error = %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tt.want {
+				t.Errorf("themeOf() = %q, want %q", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitTheme(t *testing.T) {
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	original := config.Cfg
+	defer func() {
+		config.Cfg = original
+		initTheme()
+	}()
+
+	config.Cfg.Theme = "Light"
+	config.Cfg.AccentColor = "not a color"
+	config.Cfg.FaviconURL = ""
+	initTheme()
+	if Theme != themeLight {
+		t.Errorf("Theme = %q, want %q", Theme, themeLight)
+	}
+	if accentStyle != "" {
+		t.Errorf("accentStyle = %q for an invalid color, want empty", accentStyle)
+	}
+	if FaviconURL != defaultFaviconURL {
+		t.Errorf("FaviconURL = %q, want %q", FaviconURL, defaultFaviconURL)
+	}
+
+	config.Cfg.Theme = "auto"
+	initTheme()
+	if Theme != "" {
+		t.Errorf("Theme = %q for auto, want empty", Theme)
+	}
+}
+
+func TestThemeHandlerRendersBranding(t *testing.T) {
+	if !web.Included {
+		t.Skip("the web interface is not compiled into headless builds")
+	}
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	original := config.Cfg
+	defer func() {
+		config.Cfg = original
+		initTheme()
+	}()
+	config.Cfg.Theme = "dark"
+	config.Cfg.AccentColor = "#e11d48"
+	config.Cfg.LogoURL = "https://example.com/logo.png"
+	config.Cfg.FaviconURL = "https://example.com/favicon.png"
+	initTheme()
+
+	app := fiber.New(fiber.Config{Views: web.Views(false)})
+	app.Use(ThemeHandler)
+	app.Get("/guide", GuideHandler)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/guide", nil))
+	if err != nil {
+		t.Fatalf("GET /guide error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{
+		`data-theme="dark"`,
+		`<link rel="icon" href="https://example.com/favicon.png" />`,
+		`<img src="https://example.com/logo.png"`,
+		"--fallback-p:#e11d48;",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("GET /guide body does not contain %q", want)
+		}
+	}
+}
+
+func TestFaviconHandlerRedirect(t *testing.T) {
+	original := FaviconURL
+	defer func() { FaviconURL = original }()
+
+	app := fiber.New()
+	app.Get("/favicon.ico", FaviconHandler)
+
+	tests := []struct {
+		name       string
+		favicon    string
+		wantStatus int
+	}{
+		{"default favicon", defaultFaviconURL, fiber.StatusMovedPermanently},
+		{"custom favicon", "https://example.com/favicon.png", fiber.StatusFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			FaviconURL = tt.favicon
+			resp, err := app.Test(httptest.NewRequest("GET", "/favicon.ico", nil))
+			if err != nil {
+				t.Fatalf("GET /favicon.ico error = %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus || resp.Header.Get("Location") != tt.favicon {
+				t.Errorf("GET /favicon.ico = %d %q, want %d %q", resp.StatusCode, resp.Header.Get("Location"), tt.wantStatus, tt.favicon)
+			}
+		})
+	}
+}
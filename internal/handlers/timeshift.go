@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/cdntoken"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/timeshift"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// timeshiftStartTimeout is how long a player waits for the first segments of a new timeshift buffer
+	timeshiftStartTimeout = 15 * time.Second
+	// timeshiftSegmentTimeout is the timeout for downloading a segment into a timeshift buffer
+	timeshiftSegmentTimeout = 30 * time.Second
+)
+
+// timeshiftSource records a live JioTV channel of a tenant into a timeshift buffer
+type timeshiftSource struct {
+	t       *tenant
+	id      string
+	quality string
+	// mediaURL is the media playlist of the stream, resolved again after failures
+	mediaURL string
+}
+
+// resolveMediaURL finds the media playlist of the channel for the quality of the source.
+func (s *timeshiftSource) resolveMediaURL() error {
+	s.t.ensureFreshCredentials()
+	liveResult, err := s.t.TV().Live(s.id)
+	if err != nil {
+		return err
+	}
+	liveURL := toAbsoluteStreamURL(selectBestLiveHLSURL(liveResult, s.quality), liveResult)
+	if liveURL == "" {
+		return fmt.Errorf("no HLS stream found for channel %s", s.id)
+	}
+	if token := extractLiveResultHDNEA(liveResult); token != "" {
+		s.t.hdnea.Set(s.id, token)
+	}
+
+	body, statusCode, newHdnea := s.t.TV().Render(liveURL, s.t.hdnea.Get(s.id))
+	if newHdnea != "" {
+		s.t.hdnea.Set(s.id, newHdnea)
+	}
+	if statusCode != fiber.StatusOK {
+		return fmt.Errorf("playlist of channel %s returned status %d", s.id, statusCode)
+	}
+	if !timeshift.IsMasterPlaylist(body) {
+		s.mediaURL = liveURL
+		return nil
+	}
+	base, err := url.Parse(liveURL)
+	if err != nil {
+		return err
+	}
+	variants, err := timeshift.ParseMasterPlaylist(body, base)
+	if err != nil {
+		return err
+	}
+	s.mediaURL = timeshift.SelectVariant(variants, s.quality).URI
+	return nil
+}
+
+// Playlist implements timeshift.Source.
+func (s *timeshiftSource) Playlist() (*timeshift.MediaPlaylist, error) {
+	if s.mediaURL == "" {
+		if err := s.resolveMediaURL(); err != nil {
+			return nil, err
+		}
+	}
+	body, statusCode, newHdnea := s.t.TV().Render(s.mediaURL, s.t.hdnea.Get(s.id))
+	if newHdnea != "" {
+		s.t.hdnea.Set(s.id, newHdnea)
+	}
+	if statusCode != fiber.StatusOK {
+		// The token or the variant may have expired, so start again from the live URL
+		s.mediaURL = ""
+		return nil, fmt.Errorf("playlist of channel %s returned status %d", s.id, statusCode)
+	}
+	base, err := url.Parse(s.mediaURL)
+	if err != nil {
+		return nil, err
+	}
+	return timeshift.ParseMediaPlaylist(body, base)
+}
+
+// Segment implements timeshift.Source.
+func (s *timeshiftSource) Segment(uri string) ([]byte, error) {
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	req.SetRequestURI(uri)
+	req.Header.Set("User-Agent", PLAYER_USER_AGENT)
+	cdntoken.HDNEA.SetCookie(&req.Header, s.t.hdnea.Get(s.id))
+
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+	if err := s.t.TV().Client.DoTimeout(req, resp, timeshiftSegmentTimeout); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return nil, fmt.Errorf("segment returned status %d", resp.StatusCode())
+	}
+	return append([]byte(nil), resp.Body()...), nil
+}
+
+// timeshiftStream returns the channel ID and quality of a timeshift request and the key of its buffer.
+func timeshiftStream(c *fiber.Ctx) (id, quality, key string) {
+	id = c.Params("id")
+	quality = c.Query("q", "auto")
+	// Channels with following IDs output audio only m3u8 when quality level is enforced
+	if id == "1349" || id == "1322" {
+		quality = "auto"
+	} else {
+		quality = television.CapQuality(quality, television.ChannelQualityCap(id))
+	}
+	return id, quality, tenantOf(c).name + "/" + id + "/" + quality
+}
+
+// TimeshiftHandler serves the timeshift playlist of a live channel on `/timeshift/:id/index.m3u8`.
+// The first request starts buffering the channel. Channels that cannot be buffered are redirected
+// to their live stream.
+func TimeshiftHandler(c *fiber.Ctx) error {
+	t := tenantOf(c)
+	id, quality, key := timeshiftStream(c)
+	if ok, err := admitStream(c, id); !ok {
+		return err
+	}
+	liveURL := tenantBase(c) + signLiveLink(utils.BuildHLSPlayURL(c.Query("q"), id))
+	if !timeshift.Enabled() || isCustomChannel(id) || isZee5Channel(id) {
+		return c.Redirect(liveURL, fiber.StatusFound)
+	}
+
+	buffer, err := timeshift.Get(key, func() (timeshift.Source, error) {
+		return &timeshiftSource{t: t, id: id, quality: quality}, nil
+	})
+	if err == nil {
+		if err = buffer.Wait(timeshiftStartTimeout); err != nil {
+			timeshift.Stop(key)
+		}
+	}
+	if err != nil {
+		if !errors.Is(err, timeshift.ErrTooManyBuffers) {
+			utils.Log.Printf("WARN: Timeshift is not available for channel %s: %v", id, err)
+		}
+		return c.Redirect(liveURL, fiber.StatusFound)
+	}
+
+	hostURL := requestHostURL(c)
+	keyURL := func(uri string) string {
+		params := cdntoken.HDNEA.SetQuery("", t.hdnea.Get(id))
+		return hostURL + string(television.ReplaceKey([]byte(uri), params, id))
+	}
+	segmentURL := func(segment int64) string {
+		return strconv.FormatInt(segment, 10) + ".ts?q=" + quality
+	}
+
+	internalUtils.SetMustRevalidateHeader(c, 3)
+	c.Response().Header.Set("Content-Type", "application/vnd.apple.mpegurl")
+	c.Response().Header.Set("Access-Control-Allow-Origin", "*")
+	return c.Send(buffer.Playlist(segmentURL, keyURL))
+}
+
+// TimeshiftSegmentHandler serves a segment of a timeshift buffer on `/timeshift/:id/:segment.ts`.
+func TimeshiftSegmentHandler(c *fiber.Ctx) error {
+	_, _, key := timeshiftStream(c)
+	segment, err := strconv.ParseInt(c.Params("segment"), 10, 64)
+	if err != nil {
+		return internalUtils.BadRequestError(c, "Invalid segment")
+	}
+	buffer, ok := timeshift.Lookup(key)
+	if !ok {
+		return internalUtils.NotFoundError(c, "Channel is not in timeshift")
+	}
+	data, err := buffer.Segment(segment)
+	if err != nil {
+		return internalUtils.NotFoundError(c, err.Error())
+	}
+
+	// Segments never change, only leave the buffer
+	internalUtils.SetCacheHeader(c, int(timeshift.Window().Seconds()))
+	c.Response().Header.Set("Content-Type", "video/mp2t")
+	c.Response().Header.Set("Access-Control-Allow-Origin", "*")
+	return c.Send(data)
+}
+
+// streamStats is the response of the stream stats API
+type streamStats struct {
+	ChannelID string `json:"channel_id"`
+	Quality   string `json:"quality"`
+	// Timeshift reports whether the server buffers the stream
+	Timeshift     bool    `json:"timeshift"`
+	BufferSeconds float64 `json:"buffer_seconds"`
+	Segments      int     `json:"segments"`
+	// LastSegmentAge is how many seconds ago the server recorded the last segment
+	LastSegmentAge *float64 `json:"last_segment_age_seconds,omitempty"`
+	Error          string   `json:"error,omitempty"`
+}
+
+// StreamStatsHandler reports the health of the server's buffer of a live channel on
+// `/api/v1/stream/:id/stats`, for the stats of the web players. q is the quality of the stream.
+func StreamStatsHandler(c *fiber.Ctx) error {
+	id, quality, key := timeshiftStream(c)
+	result := streamStats{ChannelID: id, Quality: quality}
+	if buffer, ok := timeshift.Lookup(key); ok {
+		stats := buffer.Stats()
+		result.Timeshift = true
+		result.BufferSeconds = stats.Duration.Seconds()
+		result.Segments = stats.Segments
+		if !stats.LastSegment.IsZero() {
+			age := time.Since(stats.LastSegment).Seconds()
+			result.LastSegmentAge = &age
+		}
+		if stats.Err != nil {
+			result.Error = stats.Err.Error()
+		}
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(result)
+}
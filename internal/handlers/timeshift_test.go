@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/timeshift"
+)
+
+// fakeTimeshiftSource is a live stream of two segments
+type fakeTimeshiftSource struct{}
+
+func (fakeTimeshiftSource) Playlist() (*timeshift.MediaPlaylist, error) {
+	return &timeshift.MediaPlaylist{TargetDuration: 6, Segments: []timeshift.RemoteSegment{
+		{Sequence: 1, Duration: 6, URI: "https://cdn.example.com/1.ts"},
+		{Sequence: 2, Duration: 6, URI: "https://cdn.example.com/2.ts"},
+	}}, nil
+}
+
+func (fakeTimeshiftSource) Segment(uri string) ([]byte, error) {
+	return []byte(uri), nil
+}
+
+func TestTimeshiftHandlers(t *testing.T) {
+	originalCfg := config.Cfg
+	defer func() { config.Cfg = originalCfg }()
+	config.Cfg.TimeshiftMinutes = 0
+
+	app := fiber.New()
+	app.Get("/timeshift/:id/index.m3u8", TimeshiftHandler)
+	app.Get("/timeshift/:id/:segment.ts", TimeshiftSegmentHandler)
+
+	tests := []struct {
+		name         string
+		target       string
+		wantStatus   int
+		wantLocation string
+	}{
+		{"disabled", "/timeshift/143/index.m3u8", fiber.StatusFound, "/live/143.m3u8"},
+		{"disabled with quality", "/timeshift/143/index.m3u8?q=high", fiber.StatusFound, "/live/high/143.m3u8"},
+		{"segment of unknown buffer", "/timeshift/143/1700000000.ts?q=high", fiber.StatusNotFound, ""},
+		{"invalid segment", "/timeshift/143/first.ts", fiber.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", tt.target, nil))
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if location := resp.Header.Get("Location"); location != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", location, tt.wantLocation)
+			}
+		})
+	}
+}
+
+func TestStreamStatsHandler(t *testing.T) {
+	originalCfg := config.Cfg
+	defer func() { config.Cfg = originalCfg }()
+	config.Cfg.TimeshiftMinutes = 5
+
+	key := defaultTenant.name + "/143/auto"
+	buffer, err := timeshift.Get(key, func() (timeshift.Source, error) {
+		return fakeTimeshiftSource{}, nil
+	})
+	if err != nil {
+		t.Fatalf("timeshift.Get() error = %v", err)
+	}
+	defer timeshift.Stop(key)
+	if err := buffer.Wait(time.Second); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	app := fiber.New()
+	app.Get("/api/v1/stream/:id/stats", StreamStatsHandler)
+
+	tests := []struct {
+		name          string
+		target        string
+		wantTimeshift bool
+		wantSegments  int
+	}{
+		{"buffered channel", "/api/v1/stream/143/stats", true, 2},
+		{"other quality", "/api/v1/stream/143/stats?q=high", false, 0},
+		{"channel without buffer", "/api/v1/stream/144/stats", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", tt.target, nil))
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			var stats streamStats
+			if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+				t.Fatalf("decoding stats: %v", err)
+			}
+			if stats.Timeshift != tt.wantTimeshift || stats.Segments != tt.wantSegments {
+				t.Errorf("stats = %+v, want timeshift %v with %d segments", stats, tt.wantTimeshift, tt.wantSegments)
+			}
+			if tt.wantTimeshift && (stats.BufferSeconds != 12 || stats.LastSegmentAge == nil) {
+				t.Errorf("stats = %+v, want 12 buffered seconds and the age of the last segment", stats)
+			}
+		})
+	}
+}
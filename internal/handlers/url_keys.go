@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// urlKeysResponse is the response of `/api/admin/url-keys`
+type urlKeysResponse struct {
+	Keys []secureurl.KeyInfo `json:"keys"`
+}
+
+// URLKeysHandler returns the keys of the URL encryption on `/api/admin/url-keys`, without their secrets.
+func URLKeysHandler(c *fiber.Ctx) error {
+	if ok, err := checkAdminToken(c); !ok {
+		return err
+	}
+	return c.JSON(urlKeysResponse{Keys: secureurl.Keys()})
+}
+
+// RotateURLKeysHandler replaces the key of the URL encryption on `/api/admin/url-keys/rotate`.
+// With revoke=true, URLs encrypted with the previous keys stop working right away.
+func RotateURLKeysHandler(c *fiber.Ctx) error {
+	if ok, err := checkAdminToken(c); !ok {
+		return err
+	}
+	revoke := c.QueryBool("revoke")
+	if err := secureurl.Rotate(revoke); err != nil {
+		if errors.Is(err, secureurl.ErrEncryptionDisabled) {
+			return internalUtils.ErrorResponse(c, fiber.StatusConflict, err.Error())
+		}
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	utils.Log.Printf("INFO: Rotated the URL encryption key (revoke=%t) from %s", revoke, c.IP())
+	return c.JSON(urlKeysResponse{Keys: secureurl.Keys()})
+}
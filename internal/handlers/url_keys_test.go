@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+func TestRotateURLKeysHandler(t *testing.T) {
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	original := config.Cfg
+	defer func() {
+		config.Cfg = original
+		secureurl.Init()
+	}()
+	config.Cfg.AdminToken = "admin"
+	config.Cfg.DisableURLEncryption = false
+	secureurl.Init()
+
+	app := fiber.New()
+	app.Get("/api/admin/url-keys", URLKeysHandler)
+	app.Post("/api/admin/url-keys/rotate", RotateURLKeysHandler)
+
+	keysOf := func(method, target string, wantStatus int) []secureurl.KeyInfo {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest(method, target, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != wantStatus {
+			t.Fatalf("%s %s status = %d, want %d", method, target, resp.StatusCode, wantStatus)
+		}
+		var result urlKeysResponse
+		if wantStatus == fiber.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				t.Fatalf("decoding the keys: %v", err)
+			}
+		}
+		return result.Keys
+	}
+
+	keysOf(http.MethodPost, "/api/admin/url-keys/rotate", fiber.StatusUnauthorized)
+	if got := keysOf(http.MethodGet, "/api/admin/url-keys?token=admin", fiber.StatusOK); len(got) != 1 {
+		t.Errorf("keys = %+v, want the key created on Init", got)
+	}
+	if got := keysOf(http.MethodPost, "/api/admin/url-keys/rotate?token=admin", fiber.StatusOK); len(got) != 2 || got[1].Expires == nil {
+		t.Errorf("keys after rotating = %+v, want the new and the previous key", got)
+	}
+	if got := keysOf(http.MethodPost, "/api/admin/url-keys/rotate?token=admin&revoke=true", fiber.StatusOK); len(got) != 1 {
+		t.Errorf("keys after revoking = %+v, want only the new key", got)
+	}
+
+	config.Cfg.DisableURLEncryption = true
+	secureurl.Init()
+	keysOf(http.MethodPost, "/api/admin/url-keys/rotate?token=admin", fiber.StatusConflict)
+}
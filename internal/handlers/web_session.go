@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/middleware"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/web"
+)
+
+// webLoginFailureDelay slows down guessing of the web password
+var webLoginFailureDelay = time.Second
+
+// safeNextPath returns next if it is a path on this server, else "/", so that the login cannot
+// redirect to another site.
+func safeNextPath(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+// WebLoginPageHandler renders the web login page on `/session/login`.
+func WebLoginPageHandler(c *fiber.Ctx) error {
+	if !middleware.WebLoginEnabled() {
+		return c.Redirect("/")
+	}
+	return c.Render("views/session_login", fiber.Map{
+		"Title": Title,
+		"Next":  safeNextPath(c.Query("next")),
+	})
+}
+
+// WebLoginHandler checks the password posted to `/session/login` and sets the session cookie.
+func WebLoginHandler(c *fiber.Ctx) error {
+	if !middleware.WebLoginEnabled() {
+		return internalUtils.NotFoundError(c, "The web login is disabled. Set web_password in the config to enable it.")
+	}
+	next := safeNextPath(c.FormValue("next"))
+	if !middleware.ValidWebPassword(c.FormValue("password")) {
+		time.Sleep(webLoginFailureDelay)
+		// Headless builds have no login page to show the error on
+		if !web.Included {
+			return internalUtils.ErrorResponse(c, fiber.StatusUnauthorized, "Wrong password")
+		}
+		return c.Status(fiber.StatusUnauthorized).Render("views/session_login", fiber.Map{
+			"Title": Title,
+			"Next":  next,
+			"Error": "Wrong password",
+		})
+	}
+
+	expires := time.Now().Add(middleware.WebSessionTTL)
+	session, err := middleware.NewWebSession(expires)
+	if err != nil {
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	c.Cookie(&fiber.Cookie{
+		Name:     middleware.WebSessionCookie,
+		Value:    session,
+		Path:     "/",
+		Expires:  expires,
+		Secure:   c.Protocol() == "https",
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return c.Redirect(next, fiber.StatusSeeOther)
+}
+
+// WebLogoutHandler removes the session cookie on `/session/logout` and returns to the login page.
+func WebLogoutHandler(c *fiber.Ctx) error {
+	c.ClearCookie(middleware.WebSessionCookie)
+	return c.Redirect(middleware.WebLoginPath, fiber.StatusSeeOther)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/middleware"
+	"github.com/jiotv-go/jiotv_go/v3/web"
+)
+
+func TestSafeNextPath(t *testing.T) {
+	tests := []struct {
+		next string
+		want string
+	}{
+		{next: "/guide?x=1", want: "/guide?x=1"},
+		{next: "", want: "/"},
+		{next: "https://example.com", want: "/"},
+		{next: "//example.com", want: "/"},
+		{next: "/\\example.com", want: "/"},
+	}
+	for _, tt := range tests {
+		if got := safeNextPath(tt.next); got != tt.want {
+			t.Errorf("safeNextPath(%q) = %q, want %q", tt.next, got, tt.want)
+		}
+	}
+}
+
+func TestWebLoginHandler(t *testing.T) {
+	originalPassword, originalToken, originalDelay := config.Cfg.WebPassword, config.Cfg.AdminToken, webLoginFailureDelay
+	defer func() {
+		config.Cfg.WebPassword, config.Cfg.AdminToken, webLoginFailureDelay = originalPassword, originalToken, originalDelay
+	}()
+	config.Cfg.WebPassword = "password"
+	config.Cfg.AdminToken = ""
+	webLoginFailureDelay = 0
+
+	app := fiber.New(fiber.Config{Views: web.Views(false)})
+	app.Use(middleware.WebLogin(false))
+	app.Get("/session/login", WebLoginPageHandler)
+	app.Post("/session/login", WebLoginHandler)
+	app.Post("/session/logout", WebLogoutHandler)
+	app.Get("/api/logs/stream", func(c *fiber.Ctx) error {
+		if ok, err := checkAdminToken(c); !ok {
+			return err
+		}
+		return c.SendString("ok")
+	})
+
+	login := func(password string) *http.Response {
+		form := url.Values{"password": {password}, "next": {"/guide"}}
+		req := httptest.NewRequest(http.MethodPost, "/session/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("POST /session/login error = %v", err)
+		}
+		return resp
+	}
+
+	resp := login("wrong")
+	if resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("wrong password status = %d, want 401", resp.StatusCode)
+	}
+	if body, _ := io.ReadAll(resp.Body); !strings.Contains(string(body), "Wrong password") {
+		t.Error("the login page should show the error")
+	}
+
+	resp = login("password")
+	if resp.StatusCode != fiber.StatusSeeOther || resp.Header.Get("Location") != "/guide" {
+		t.Fatalf("login = %d to %q, want 303 to /guide", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	var session *http.Cookie
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == middleware.WebSessionCookie {
+			session = cookie
+		}
+	}
+	if session == nil || !session.HttpOnly {
+		t.Fatalf("login should set an HttpOnly session cookie, got %+v", session)
+	}
+
+	// The session opens the admin APIs, even without an admin token
+	req := httptest.NewRequest(http.MethodGet, "/api/logs/stream", nil)
+	req.AddCookie(session)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("GET /api/logs/stream error = %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Errorf("admin API with a session status = %d, want 200", resp.StatusCode)
+	}
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodPost, "/session/logout", nil))
+	if err != nil {
+		t.Fatalf("POST /session/logout error = %v", err)
+	}
+	if resp.StatusCode != fiber.StatusSeeOther || resp.Header.Get("Location") != middleware.WebLoginPath {
+		t.Errorf("logout = %d to %q, want 303 to the login page", resp.StatusCode, resp.Header.Get("Location"))
+	}
+}
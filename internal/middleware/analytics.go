@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/analytics"
+)
+
+// analyticsFeatures maps route patterns to the features counted by local analytics.
+var analyticsFeatures = map[string]string{
+	"/live/:id":                 "live",
+	"/live/:quality/:id":        "live",
+	"/live/:id.mpd":             "manifest_conversion",
+	"/hls/:id/index.m3u8":       "manifest_conversion",
+	"/play/:id":                 "web_player",
+	"/guide":                    "guide",
+	"/mpd/:channelID":           "drm",
+	"/catchup/stream/:id":       "catchup",
+	"/api/v1/catchup/search":    "catchup_search",
+	"/api/v1/catchup/feed":      "catchup_feed",
+	"/startover/:id":            "startover",
+	"/zee5/catchup/:id":         "catchup",
+	"/zee5/:id":                 "plugin_zee5",
+	"/playlist.m3u":             "playlist",
+	"/multicast.m3u":            "multicast",
+	"/bouquet.tv":               "bouquet",
+	"/library.zip":              "library",
+	"/preview/:id.jpg":          "preview",
+	"/epg.xml.gz":               "epg_xmltv",
+	"/epg.json":                 "epg_json",
+	"/api/v1/epg/:channel/:day": "epg_json",
+	"/api/v1/now/:channelID":    "now_next",
+	"/api/v1/androidtv/rows":    "androidtv_rows",
+	"/api/v1/channels/changes":  "channel_changes",
+	"/api/cast/play":            "cast",
+	"/api/grafana/query":        "grafana",
+	"/api/logs/stream":          "log_stream",
+}
+
+// Analytics middleware counts successful uses of features when local analytics are enabled.
+func Analytics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		if err != nil || c.Response().StatusCode() >= fiber.StatusBadRequest || !analytics.Enabled() {
+			return err
+		}
+		if feature, ok := analyticsFeatures[c.Route().Path]; ok {
+			analytics.Record(feature)
+		}
+		return err
+	}
+}
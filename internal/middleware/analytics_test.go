@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/analytics"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+func TestAnalytics(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	original := config.Cfg.Analytics
+	defer func() {
+		config.Cfg.Analytics = original
+		analytics.Init()
+	}()
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	config.Cfg.Analytics = analytics.ModeLocal
+	analytics.Init()
+
+	app := fiber.New()
+	app.Use(Analytics())
+	ok := func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	}
+	app.Get("/live/:id", ok)
+	app.Get("/live/:quality/:id", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNotFound)
+	})
+	app.Get("/catchup/stream/:id", ok)
+	app.Get("/", ok)
+
+	for _, path := range []string{"/live/143.m3u8", "/live/144.m3u8", "/live/high/143.m3u8", "/catchup/stream/143", "/"} {
+		if _, err := app.Test(httptest.NewRequest("GET", path, nil)); err != nil {
+			t.Fatalf("app.Test(%s) error = %v", path, err)
+		}
+	}
+	if err := analytics.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	report, err := analytics.Export("")
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	want := map[string]int64{"live": 2, "catchup": 1}
+	if len(report.Features) != len(want) {
+		t.Errorf("features = %v, want %v", report.Features, want)
+	}
+	for feature, count := range want {
+		if got := report.Features[feature].Count; got != count {
+			t.Errorf("%s count = %d, want %d", feature, got, count)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// guestBlockedPrefixes lists the route prefixes that are hidden in guest mode.
+// Only playback and playlist endpoints stay reachable.
+var guestBlockedPrefixes = []string{
+	"/login",
+	"/logout",
+	"/admin",
+	"/api/admin",
+	"/api/v1/admin",
+	"/api/v1/config",
+	"/api/v1/stats",
+	"/api/v1/bandwidth",
+	"/api/v1/sessions",
+	"/api/grafana",
+	"/api/logs",
+	"/api/cast",
+	"/channels/visibility",
+}
+
+// guestReadOnlyPrefixes lists the route prefixes that can only be read in guest mode.
+var guestReadOnlyPrefixes = []string{
+	"/api/maintenance",
+	"/api/v1/channels/check",
+	"/api/v1/channels/hidden",
+}
+
+// IsGuestBlockedPath reports whether the given path is hidden in guest mode.
+func IsGuestBlockedPath(path string) bool {
+	path = strings.ToLower(path)
+	for _, prefix := range guestBlockedPrefixes {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// IsGuestBlockedRequest reports whether a request with the given method and path is refused in guest mode.
+func IsGuestBlockedRequest(method, path string) bool {
+	if IsGuestBlockedPath(path) {
+		return true
+	}
+	if method == fiber.MethodGet || method == fiber.MethodHead {
+		return false
+	}
+	path = strings.ToLower(path)
+	for _, prefix := range guestReadOnlyPrefixes {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// GuestMode middleware hides administrative routes when enabled.
+// Blocked routes respond with 404 so that their existence is not revealed.
+func GuestMode(enabled bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if enabled && IsGuestBlockedRequest(c.Method(), c.Path()) {
+			return c.SendStatus(fiber.StatusNotFound)
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestGuestMode(t *testing.T) {
+	newApp := func(enabled bool) *fiber.App {
+		app := fiber.New()
+		app.Use(GuestMode(enabled))
+		ok := func(c *fiber.Ctx) error {
+			return c.SendString("ok")
+		}
+		app.Get("/logout", ok)
+		app.Post("/login/sendOTP", ok)
+		app.Get("/playlist.m3u", ok)
+		app.Get("/live/:id", ok)
+		app.Get("/api/maintenance", ok)
+		app.Post("/api/maintenance", ok)
+		app.Post("/api/v1/channels/check", ok)
+		app.Post("/api/v1/channels/hidden", ok)
+		app.Get("/api/cast/devices", ok)
+		app.Post("/api/cast/play", ok)
+		return app
+	}
+
+	tests := []struct {
+		name       string
+		enabled    bool
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{
+			name:       "Disabled allows logout",
+			enabled:    false,
+			method:     http.MethodGet,
+			path:       "/logout",
+			wantStatus: 200,
+		},
+		{
+			name:       "Enabled hides logout",
+			enabled:    true,
+			method:     http.MethodGet,
+			path:       "/logout",
+			wantStatus: 404,
+		},
+		{
+			name:       "Enabled hides login",
+			enabled:    true,
+			method:     http.MethodPost,
+			path:       "/login/sendOTP",
+			wantStatus: 404,
+		},
+		{
+			name:       "Enabled allows playlist",
+			enabled:    true,
+			method:     http.MethodGet,
+			path:       "/playlist.m3u",
+			wantStatus: 200,
+		},
+		{
+			name:       "Enabled allows live playback",
+			enabled:    true,
+			method:     http.MethodGet,
+			path:       "/live/143",
+			wantStatus: 200,
+		},
+		{
+			name:       "Enabled allows reading maintenance window",
+			enabled:    true,
+			method:     http.MethodGet,
+			path:       "/api/maintenance",
+			wantStatus: 200,
+		},
+		{
+			name:       "Enabled hides changing maintenance window",
+			enabled:    true,
+			method:     http.MethodPost,
+			path:       "/api/maintenance",
+			wantStatus: 404,
+		},
+		{
+			name:       "Enabled hides running a custom channels check",
+			enabled:    true,
+			method:     http.MethodPost,
+			path:       "/api/v1/channels/check",
+			wantStatus: 404,
+		},
+		{
+			name:       "Enabled hides hiding channels",
+			enabled:    true,
+			method:     http.MethodPost,
+			path:       "/api/v1/channels/hidden",
+			wantStatus: 404,
+		},
+		{
+			name:       "Enabled hides finding cast devices",
+			enabled:    true,
+			method:     http.MethodGet,
+			path:       "/api/cast/devices",
+			wantStatus: 404,
+		},
+		{
+			name:       "Enabled hides casting",
+			enabled:    true,
+			method:     http.MethodPost,
+			path:       "/api/cast/play",
+			wantStatus: 404,
+		},
+		{
+			name:       "Disabled allows casting",
+			enabled:    false,
+			method:     http.MethodPost,
+			path:       "/api/cast/play",
+			wantStatus: 200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newApp(tt.enabled)
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestIsGuestBlockedPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/login/verifyOTP", true},
+		{"/LOGOUT", true},
+		{"/api/v1/config", true},
+		{"/api/v1/stats", true},
+		{"/api/v1/bandwidth", true},
+		{"/api/v1/sessions", true},
+		{"/api/logs/stream", true},
+		{"/api/cast/volume", true},
+		{"/admin/channels", true},
+		{"/channels/visibility", true},
+		{"/channels", false},
+		{"/api/admin/channels/export", true},
+		{"/loginfo", false},
+		{"/render.m3u8", false},
+		{"/", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := IsGuestBlockedPath(tt.path); got != tt.want {
+				t.Errorf("IsGuestBlockedPath(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/maintenance"
+)
+
+// streamStartPrefixes lists the route prefixes that start a new stream. Segment and manifest
+// refresh requests of running streams are not listed, so they keep playing.
+var streamStartPrefixes = []string{
+	"/live/",
+	"/mpd/",
+	"/catchup/stream/",
+	"/zee5/",
+}
+
+// isStreamStartPath reports whether the given path starts a new stream.
+func isStreamStartPath(path string) bool {
+	for _, prefix := range streamStartPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Maintenance middleware refuses new streams with 503 Service Unavailable while a maintenance
+// window that blocks them is active.
+func Maintenance() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !isStreamStartPath(c.Path()) {
+			return c.Next()
+		}
+		now := time.Now()
+		window, blocked := maintenance.BlocksNewStreams(now)
+		if !blocked {
+			return c.Next()
+		}
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(window.End.Sub(now).Seconds())+1))
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"message": window.Announcement(now),
+		})
+	}
+}
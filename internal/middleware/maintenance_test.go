@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/maintenance"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+)
+
+func TestMaintenance(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	maintenance.Init()
+	defer maintenance.Clear()
+
+	app := fiber.New()
+	app.Use(Maintenance())
+	ok := func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	}
+	app.Get("/live/:id", ok)
+	app.Get("/render.m3u8", ok)
+
+	status := func(path string) (int, string) {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		return resp.StatusCode, resp.Header.Get(fiber.HeaderRetryAfter)
+	}
+
+	now := time.Now()
+	if _, err := maintenance.Set(maintenance.Window{End: now.Add(time.Hour)}, now); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := status("/live/143"); got != fiber.StatusOK {
+		t.Errorf("stream during window without blocking: status %d, want %d", got, fiber.StatusOK)
+	}
+
+	if _, err := maintenance.Set(maintenance.Window{End: now.Add(time.Hour), BlockNewStreams: true}, now); err != nil {
+		t.Fatal(err)
+	}
+	if got, retryAfter := status("/live/143"); got != fiber.StatusServiceUnavailable || retryAfter == "" {
+		t.Errorf("new stream during blocking window: status %d, Retry-After %q", got, retryAfter)
+	}
+	if got, _ := status("/render.m3u8"); got != fiber.StatusOK {
+		t.Errorf("running stream during blocking window: status %d, want %d", got, fiber.StatusOK)
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/stats"
+)
+
+// statsSkippedPrefixes lists the route prefixes that are not counted as requests.
+var statsSkippedPrefixes = []string{
+	"/static",
+	"/api/grafana",
+	"/healthz",
+	"/api/logs",
+}
+
+// Stats middleware counts handled requests and server errors for the error rate statistics.
+func Stats() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		path := c.Path()
+		for _, prefix := range statsSkippedPrefixes {
+			if strings.HasPrefix(path, prefix) {
+				return c.Next()
+			}
+		}
+		err := c.Next()
+		stats.RecordRequest(err != nil || c.Response().StatusCode() >= fiber.StatusInternalServerError)
+		return err
+	}
+}
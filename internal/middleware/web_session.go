@@ -0,0 +1,262 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/dash"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/devices"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/hls"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/web"
+)
+
+const (
+	// WebSessionCookie is the cookie holding the signed web login session
+	WebSessionCookie = "jiotv_web_session"
+	// WebSessionTTL is how long a web login lasts
+	WebSessionTTL = 30 * 24 * time.Hour
+	// WebLoginPath is the web login page
+	WebLoginPath = "/session/login"
+	// webSessionSecretKey is the store key of the random secret that sessions are signed with
+	webSessionSecretKey = "webSessionSecret"
+)
+
+// webLoginOpenPrefixes lists the route prefixes that never need a session: the login itself, and
+// what the login page and the installed web app load.
+var webLoginOpenPrefixes = []string{
+	"/session",
+	"/static",
+	"/healthz",
+	"/favicon.ico",
+	"/manifest.webmanifest",
+	"/sw.js",
+	"/offline",
+}
+
+var (
+	// webSessionSecret signs the sessions. It is kept in the store, so that sessions survive restarts
+	// and work on every server sharing the store.
+	webSessionSecret   []byte
+	webSessionSecretMu sync.Mutex
+)
+
+// WebLoginEnabled reports whether the web login is enabled.
+func WebLoginEnabled() bool {
+	return config.Cfg.WebPassword != ""
+}
+
+// webSessionKey returns the key that sessions are signed with. It is derived from the web password
+// too, so that changing the password signs out every session.
+func webSessionKey() ([]byte, error) {
+	webSessionSecretMu.Lock()
+	defer webSessionSecretMu.Unlock()
+	if webSessionSecret == nil {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(secret)
+		if store.KVS != nil {
+			shared, err := store.SetIfAbsent(store.KVS, webSessionSecretKey, encoded)
+			if err != nil {
+				return nil, err
+			}
+			encoded = shared
+		}
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, err
+		}
+		webSessionSecret = decoded
+	}
+	mac := hmac.New(sha256.New, webSessionSecret)
+	mac.Write([]byte(config.Cfg.WebPassword))
+	return mac.Sum(nil), nil
+}
+
+// signWebSession returns the signature of a session that expires at the given Unix time.
+func signWebSession(expires string) (string, error) {
+	key, err := webSessionKey()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// NewWebSession returns the value of a session cookie that expires at the given time.
+func NewWebSession(expires time.Time) (string, error) {
+	unix := strconv.FormatInt(expires.Unix(), 10)
+	signature, err := signWebSession(unix)
+	if err != nil {
+		return "", err
+	}
+	return unix + "." + signature, nil
+}
+
+// ValidWebSession reports whether value is a session cookie signed by this server that has not expired.
+func ValidWebSession(value string, now time.Time) bool {
+	unix, signature, ok := strings.Cut(value, ".")
+	if !ok {
+		return false
+	}
+	expires, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil || now.Unix() >= expires {
+		return false
+	}
+	want, err := signWebSession(unix)
+	return err == nil && hmac.Equal([]byte(signature), []byte(want))
+}
+
+// ValidWebPassword reports whether password is the web password.
+func ValidWebPassword(password string) bool {
+	return WebLoginEnabled() && subtle.ConstantTimeCompare([]byte(password), []byte(config.Cfg.WebPassword)) == 1
+}
+
+// Authenticated reports whether the request has a valid session, or the admin token as an
+// `Authorization: Bearer` header or token query parameter, for clients that cannot log in.
+func Authenticated(c *fiber.Ctx) bool {
+	if WebLoginEnabled() && ValidWebSession(c.Cookies(WebSessionCookie), time.Now()) {
+		return true
+	}
+	adminToken := config.Cfg.AdminToken
+	if adminToken == "" {
+		return false
+	}
+	token := c.Query("token")
+	if auth := c.Get(fiber.HeaderAuthorization); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1
+}
+
+// IsAdminRequest reports whether a request with the given method and path is an admin action, like
+// the login and logout of Jio or a change of the hidden channels. These are the requests that are
+// refused in guest mode.
+func IsAdminRequest(method, path string) bool {
+	return IsGuestBlockedRequest(method, path)
+}
+
+// isWebLoginOpenPath reports whether the given path never needs a session.
+func isWebLoginOpenPath(path string) bool {
+	path = strings.ToLower(path)
+	for _, prefix := range webLoginOpenPrefixes {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// WebLogin middleware requires the web login for admin actions, and with protectPlayback for every
+// route, while the web login is enabled. With protectPlayback, the token of a device opens the routes
+// that are not admin actions, and is added to the URLs of their responses. Browsers are sent to the
+// login page, other clients and headless builds get 401 Unauthorized.
+func WebLogin(protectPlayback bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !WebLoginEnabled() {
+			return c.Next()
+		}
+		// The navbar shows a sign out button to signed in users
+		if err := c.Bind(fiber.Map{"SignedIn": ValidWebSession(c.Cookies(WebSessionCookie), time.Now())}); err != nil {
+			return err
+		}
+		if isWebLoginOpenPath(c.Path()) || (!protectPlayback && !IsAdminRequest(c.Method(), c.Path())) {
+			return c.Next()
+		}
+		if Authenticated(c) {
+			return c.Next()
+		}
+		// Device tokens open playback, but no admin actions
+		if token := c.Query(devices.TokenParam); !IsAdminRequest(c.Method(), c.Path()) && devices.Default.Authorize(token, c.IP()) {
+			if err := c.Next(); err != nil {
+				return err
+			}
+			CarryQuery(c, devices.TokenParam, token)
+			return nil
+		}
+		if web.Included && c.Method() == fiber.MethodGet && strings.Contains(c.Get(fiber.HeaderAccept), fiber.MIMETextHTML) {
+			return c.Redirect(WebLoginPath + "?next=" + url.QueryEscape(c.OriginalURL()))
+		}
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"message": "Log in on " + WebLoginPath + " or pass the admin token",
+		})
+	}
+}
+
+// isLocalURL reports whether a URL of a response points at this server: it has no host, like
+// "/render.ts?auth=...", or the host of the request, like the absolute URLs of Zee5 playlists.
+func isLocalURL(c *fiber.Ctx, rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || rawURL == "" {
+		return false
+	}
+	if parsed.Scheme == "" && parsed.Host == "" {
+		return true
+	}
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && strings.EqualFold(parsed.Host, c.Hostname())
+}
+
+// CarryQuery adds a query parameter of a request to the URLs of its response that point at this
+// server: the Location of a redirect and the URLs of an HLS playlist or a DASH manifest. The player
+// requests these next, so they keep the device token or the playback session of the request.
+func CarryQuery(c *fiber.Ctx, name, value string) {
+	if value == "" {
+		return
+	}
+	resp := c.Response()
+	if location := string(resp.Header.Peek(fiber.HeaderLocation)); isLocalURL(c, location) {
+		resp.Header.Set(fiber.HeaderLocation, withQuery(location, name, value))
+	}
+	contentType := strings.ToLower(string(resp.Header.ContentType()))
+	switch {
+	case strings.Contains(contentType, "mpegurl"):
+		playlist, err := hls.Parse(resp.Body())
+		if err != nil {
+			return
+		}
+		playlist.RewriteURIs(nil, func(ref hls.Reference) (string, bool) {
+			if !isLocalURL(c, ref.URL) {
+				return "", false
+			}
+			return withQuery(ref.URI, name, value), true
+		})
+		resp.SetBody(playlist.Bytes())
+	case strings.Contains(contentType, "dash+xml"):
+		manifest, err := dash.Rewrite(resp.Body(), nil, func(ref dash.Reference) (string, bool) {
+			// Query params of a BaseURL are lost when the URLs below it are resolved
+			if ref.Kind == dash.BaseURL || !isLocalURL(c, ref.URL) {
+				return "", false
+			}
+			return withQuery(ref.URI, name, value), true
+		})
+		if err != nil {
+			return
+		}
+		resp.SetBody(manifest)
+	}
+}
+
+// withQuery adds a query parameter to a URL, unless the URL already has it.
+func withQuery(rawURL, name, value string) string {
+	if parsed, err := url.Parse(rawURL); err == nil && parsed.Query().Has(name) {
+		return rawURL
+	}
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+	return rawURL + sep + name + "=" + url.QueryEscape(value)
+}
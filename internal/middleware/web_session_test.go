@@ -0,0 +1,279 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/devices"
+	"github.com/jiotv-go/jiotv_go/v3/web"
+)
+
+// useWebPassword enables the web login until the test ends.
+func useWebPassword(t *testing.T, password, adminToken string) {
+	t.Helper()
+	originalPassword, originalToken := config.Cfg.WebPassword, config.Cfg.AdminToken
+	config.Cfg.WebPassword, config.Cfg.AdminToken = password, adminToken
+	t.Cleanup(func() { config.Cfg.WebPassword, config.Cfg.AdminToken = originalPassword, originalToken })
+}
+
+func TestValidWebSession(t *testing.T) {
+	useWebPassword(t, "password", "")
+	now := time.Now()
+	session, err := NewWebSession(now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("NewWebSession() error = %v", err)
+	}
+	expired, err := NewWebSession(now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("NewWebSession() error = %v", err)
+	}
+	unix, _, _ := strings.Cut(session, ".")
+
+	tests := []struct {
+		name  string
+		value string
+		want  bool
+	}{
+		{name: "Valid session", value: session, want: true},
+		{name: "Expired session", value: expired, want: false},
+		{name: "Extended expiry", value: "9999999999" + session[len(unix):], want: false},
+		{name: "Forged signature", value: unix + ".forged", want: false},
+		{name: "Empty", value: "", want: false},
+		{name: "No signature", value: unix, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidWebSession(tt.value, now); got != tt.want {
+				t.Errorf("ValidWebSession(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	// Changing the password signs out every session
+	config.Cfg.WebPassword = "changed"
+	if ValidWebSession(session, now) {
+		t.Error("a session should not be valid after the password changed")
+	}
+}
+
+func TestWebLogin(t *testing.T) {
+	useWebPassword(t, "password", "admin")
+	session, err := NewWebSession(time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("NewWebSession() error = %v", err)
+	}
+
+	newApp := func(protectPlayback bool) *fiber.App {
+		app := fiber.New()
+		app.Use(WebLogin(protectPlayback))
+		ok := func(c *fiber.Ctx) error {
+			return c.SendString("ok")
+		}
+		app.Get("/logout", ok)
+		app.Get("/playlist.m3u", ok)
+		app.Get("/api/maintenance", ok)
+		app.Post("/api/maintenance", ok)
+		app.Get("/admin/channels", ok)
+		app.Get("/session/login", ok)
+		app.Get("/static/internal/common.js", ok)
+		return app
+	}
+
+	tests := []struct {
+		name            string
+		protectPlayback bool
+		method          string
+		path            string
+		cookie          string
+		header          map[string]string
+		wantStatus      int
+		wantLocation    string
+	}{
+		{name: "Playback stays open", method: http.MethodGet, path: "/playlist.m3u", wantStatus: 200},
+		{name: "Reading maintenance stays open", method: http.MethodGet, path: "/api/maintenance", wantStatus: 200},
+		{name: "Admin action needs a session", method: http.MethodPost, path: "/api/maintenance", wantStatus: 401},
+		{name: "Jio logout needs a session", method: http.MethodGet, path: "/logout", wantStatus: 401},
+		{
+			name:         "Browser is sent to the login",
+			method:       http.MethodGet,
+			path:         "/admin/channels?tab=import",
+			header:       map[string]string{"Accept": "text/html,application/xhtml+xml"},
+			wantStatus:   302,
+			wantLocation: "/session/login?next=%2Fadmin%2Fchannels%3Ftab%3Dimport",
+		},
+		{name: "Session allows admin action", method: http.MethodPost, path: "/api/maintenance", cookie: session, wantStatus: 200},
+		{name: "Forged session is refused", method: http.MethodPost, path: "/api/maintenance", cookie: "1.forged", wantStatus: 401},
+		{
+			name:       "Admin token allows admin action",
+			method:     http.MethodPost,
+			path:       "/api/maintenance",
+			header:     map[string]string{"Authorization": "Bearer admin"},
+			wantStatus: 200,
+		},
+		{name: "Protected playback needs a session", protectPlayback: true, method: http.MethodGet, path: "/playlist.m3u", wantStatus: 401},
+		{name: "Protected playback with the admin token", protectPlayback: true, method: http.MethodGet, path: "/playlist.m3u?token=admin", wantStatus: 200},
+		{name: "Login page stays open", protectPlayback: true, method: http.MethodGet, path: "/session/login", wantStatus: 200},
+		{name: "Static files stay open", protectPlayback: true, method: http.MethodGet, path: "/static/internal/common.js", wantStatus: 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Headless builds have no login page to send browsers to
+			if tt.wantLocation != "" && !web.Included {
+				tt.wantStatus, tt.wantLocation = 401, ""
+			}
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for key, value := range tt.header {
+				req.Header.Set(key, value)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: WebSessionCookie, Value: tt.cookie})
+			}
+			resp, err := newApp(tt.protectPlayback).Test(req)
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantLocation != "" && resp.Header.Get("Location") != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", resp.Header.Get("Location"), tt.wantLocation)
+			}
+		})
+	}
+}
+
+func TestWebLoginDeviceToken(t *testing.T) {
+	useWebPassword(t, "password", "admin")
+	original := devices.Default
+	devices.Default = devices.NewRegistry()
+	t.Cleanup(func() { devices.Default = original })
+	device, err := devices.Default.Create("TV")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	app := fiber.New()
+	app.Use(WebLogin(true))
+	ok := func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	}
+	app.Get("/playlist.m3u", ok)
+	app.Get("/live/:id", func(c *fiber.Ctx) error {
+		return c.Redirect("/render.m3u8?auth=abc&channel_key_id=143", fiber.StatusFound)
+	})
+	app.Get("/render.m3u8", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, "application/vnd.apple.mpegurl")
+		return c.SendString("#EXTM3U\n#EXT-X-KEY:METHOD=AES-128,URI=\"/render.key?auth=k\"\n#EXTINF:4,\n/render.ts?auth=s\n#EXTINF:4,\nhttps://cdn.example.com/s.ts\n")
+	})
+	app.Get("/zee5/render/playlist.m3u8", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, "application/vnd.apple.mpegurl")
+		return c.SendString("#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1\nhttp://example.com/zee5/render/playlist.m3u8?auth=v\n#EXTINF:4,\nhttp://example.com/zee5/render/segment.ts?auth=s\n#EXTINF:4,\nhttp://other.example.com/zee5/render/segment.ts?auth=o\n")
+	})
+	app.Get("/render.mpd", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, "application/dash+xml")
+		return c.SendString(`<MPD><BaseURL>/render.dash/host/h/path/p/</BaseURL><Period><AdaptationSet><SegmentTemplate media="$Number$.m4s" initialization="init.mp4"/></AdaptationSet></Period></MPD>`)
+	})
+	app.Get("/zee5/render/segment.ts", ok)
+	app.Get("/admin/devices", ok)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{name: "Unknown token is refused", path: "/playlist.m3u?device_token=unknown", wantStatus: 401},
+		{name: "Device token opens playback", path: "/playlist.m3u?device_token=" + device.Token, wantStatus: 200},
+		{name: "Request without the token from the same client is refused", path: "/playlist.m3u", wantStatus: 401},
+		{name: "Device token does not open admin pages", path: "/admin/devices?device_token=" + device.Token, wantStatus: 401},
+	}
+	for _, tt := range tests {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if err != nil {
+			t.Fatalf("%s: app.Test() error = %v", tt.name, err)
+		}
+		if resp.StatusCode != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, resp.StatusCode, tt.wantStatus)
+		}
+	}
+
+	// The token is carried to the URLs the player requests next
+	token := "device_token=" + device.Token
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/live/143?"+token, nil))
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	if got, want := resp.Header.Get(fiber.HeaderLocation), "/render.m3u8?auth=abc&channel_key_id=143&"+token; got != want {
+		t.Errorf("Location = %q, want %q", got, want)
+	}
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/render.m3u8?"+token, nil))
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{`URI="/render.key?auth=k&` + token + `"`, "/render.ts?auth=s&" + token + "\n", "https://cdn.example.com/s.ts\n"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("playlist = %q, want it to contain %q", body, want)
+		}
+	}
+	// Zee5 playlists point at this server with absolute URLs
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/zee5/render/playlist.m3u8?auth=m&"+token, nil))
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("Zee5 playlist status = %d, want 200", resp.StatusCode)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	for _, want := range []string{"http://example.com/zee5/render/playlist.m3u8?auth=v&" + token + "\n", "http://example.com/zee5/render/segment.ts?auth=s&" + token + "\n", "http://other.example.com/zee5/render/segment.ts?auth=o\n"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Zee5 playlist = %q, want it to contain %q", body, want)
+		}
+	}
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/zee5/render/segment.ts?auth=s&"+token, nil))
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Errorf("Zee5 segment with the carried device token status = %d, want 200", resp.StatusCode)
+	}
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/render.mpd?"+token, nil))
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	for _, want := range []string{"<BaseURL>/render.dash/host/h/path/p/</BaseURL>", `media="$Number$.m4s?` + token + `"`, `initialization="init.mp4?` + token + `"`} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("manifest = %q, want it to contain %q", body, want)
+		}
+	}
+
+	devices.Default.Revoke(device.ID)
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/live/143?"+token, nil))
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	if resp.StatusCode != 401 {
+		t.Errorf("status after revoking = %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestWebLoginDisabled(t *testing.T) {
+	useWebPassword(t, "", "")
+	app := fiber.New()
+	app.Use(WebLogin(true))
+	app.Get("/logout", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/logout", nil))
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Errorf("status = %d, want 200 while the web login is disabled", resp.StatusCode)
+	}
+}
@@ -1,25 +1,58 @@
 package plugins
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/jiotv-go/jiotv_go/v3/internal/config"
-	"github.com/jiotv-go/jiotv_go/v3/pkg/plugins/zee5"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 )
 
+// plugin is a channel source compiled into the binary. Plugins register themselves from an init
+// function in a file with a build constraint, so that headless builds can leave them out.
+type plugin struct {
+	// registerRoutes adds the routes of the plugin to the server
+	registerRoutes func(app *fiber.App)
+	// channels returns the channels of the plugin
+	channels func() []television.Channel
+	// channelByID looks up a channel of the plugin without listing all channels
+	channelByID func(id string) (television.Channel, bool)
+	// loadData loads the data of the plugin at startup
+	loadData func()
+	// epgData returns the programme data of the channels of the plugin
+	epgData func() ([]epg.Channel, []epg.Programme, error)
+	// catchupEPG returns the catchup EPG of a channel of the plugin
+	catchupEPG func(id string, offset int) ([]map[string]interface{}, error)
+	// refresh downloads the data of the plugin again
+	refresh func() error
+	// refreshInterval is how often refresh runs
+	refreshInterval time.Duration
+}
+
+// available holds the plugins compiled into the binary by name
+var available = map[string]plugin{}
+
 var activePlugins []func() []television.Channel
 
+// enabled returns the plugin if it is compiled into the binary and enabled in the config.
+func enabled(name string) (plugin, bool) {
+	p, ok := available[name]
+	return p, ok && config.PluginEnabled(name)
+}
+
 func Init(app *fiber.App) {
-	for _, plugin := range config.Cfg.Plugins {
-		switch plugin {
-		case "zee5":
-			zee5.RegisterRoutes(app)
-			utils.Log.Println("Plugin zee5 registered")
-			activePlugins = append(activePlugins, zee5.GetChannels)
-		default:
-			utils.Log.Println("Plugin " + plugin + " not found")
+	for _, name := range config.Cfg.Plugins {
+		p, ok := available[name]
+		if !ok {
+			utils.Log.Println("Plugin " + name + " not found")
+			continue
 		}
+		p.registerRoutes(app)
+		utils.Log.Println("Plugin " + name + " registered")
+		activePlugins = append(activePlugins, p.channels)
 	}
 }
 
@@ -30,3 +63,84 @@ func GetChannels() []television.Channel {
 	}
 	return channels
 }
+
+// RegisterEPGSources registers the EPG sources of the configured plugins,
+// so that their channels get programme data during EPG generation.
+func RegisterEPGSources() {
+	for _, name := range config.Cfg.Plugins {
+		if p, ok := available[name]; ok && p.epgData != nil {
+			epg.RegisterSource(epg.Source{Name: name, Fetch: p.epgData})
+		}
+	}
+}
+
+// LoadData loads the data of the enabled plugins at startup.
+func LoadData() {
+	for name, p := range available {
+		if config.PluginEnabled(name) && p.loadData != nil {
+			p.loadData()
+		}
+	}
+}
+
+// RefreshTask is a periodic data refresh of an enabled plugin
+type RefreshTask struct {
+	Name     string
+	Interval time.Duration
+	Run      func() error
+}
+
+// RefreshTasks returns the data refreshes of the enabled plugins.
+func RefreshTasks() []RefreshTask {
+	var tasks []RefreshTask
+	for name, p := range available {
+		if config.PluginEnabled(name) && p.refresh != nil {
+			tasks = append(tasks, RefreshTask{Name: name + "-data-refresh", Interval: p.refreshInterval, Run: p.refresh})
+		}
+	}
+	return tasks
+}
+
+// HasChannel reports whether the enabled plugin has the channel.
+func HasChannel(name, channelID string) bool {
+	p, ok := enabled(name)
+	if !ok {
+		return false
+	}
+	_, ok = p.channel(channelID)
+	return ok
+}
+
+// ChannelByID returns the channel with the ID among the channels of the enabled plugins.
+func ChannelByID(channelID string) (television.Channel, bool) {
+	for _, name := range config.Cfg.Plugins {
+		if p, ok := enabled(name); ok {
+			if channel, ok := p.channel(channelID); ok {
+				return channel, true
+			}
+		}
+	}
+	return television.Channel{}, false
+}
+
+// channel looks up a channel of the plugin, from all its channels if it has no lookup.
+func (p plugin) channel(channelID string) (television.Channel, bool) {
+	if p.channelByID != nil {
+		return p.channelByID(channelID)
+	}
+	for _, channel := range p.channels() {
+		if channel.ID == channelID {
+			return channel, true
+		}
+	}
+	return television.Channel{}, false
+}
+
+// CatchupEPG returns the catchup EPG of a channel of the enabled plugin.
+func CatchupEPG(name, channelID string, offset int) ([]map[string]interface{}, error) {
+	p, ok := enabled(name)
+	if !ok || p.catchupEPG == nil {
+		return nil, fmt.Errorf("plugin %s has no catchup", name)
+	}
+	return p.catchupEPG(channelID, offset)
+}
@@ -0,0 +1,22 @@
+//go:build !headless
+
+package plugins
+
+import (
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/plugins/zee5"
+)
+
+func init() {
+	available["zee5"] = plugin{
+		registerRoutes:  zee5.RegisterRoutes,
+		channels:        zee5.GetChannels,
+		channelByID:     zee5.ChannelByID,
+		loadData:        zee5.InitZee5Data,
+		epgData:         zee5.EPGData,
+		catchupEPG:      zee5.GetCatchupEPG,
+		refresh:         zee5.RefreshZee5DataFromURL,
+		refreshInterval: 4 * time.Hour,
+	}
+}
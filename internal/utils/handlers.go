@@ -1,11 +1,13 @@
 package utils
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/proxy"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/cdntoken"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 	"github.com/valyala/fasthttp"
@@ -38,6 +40,20 @@ func ForbiddenError(c *fiber.Ctx, err interface{}) error {
 	return ErrorResponse(c, fiber.StatusForbidden, err)
 }
 
+// UpstreamError sends a 503 error response with a Retry-After header while requests to
+// the JioTV API are paused by the circuit breaker or exceed the rate limit, and a 500 error response otherwise
+func UpstreamError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, utils.ErrCircuitOpen) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(utils.CircuitRetryAfter()))
+		return ErrorResponse(c, fiber.StatusServiceUnavailable, err.Error())
+	}
+	if errors.Is(err, utils.ErrRateLimited) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(utils.UpstreamLimiter().RetryAfter()))
+		return ErrorResponse(c, fiber.StatusServiceUnavailable, err.Error())
+	}
+	return InternalServerError(c, err)
+}
+
 // SetCommonHeaders sets common headers for proxy responses
 func SetCommonHeaders(c *fiber.Ctx, userAgent string) {
 	c.Request().Header.Set("User-Agent", userAgent)
@@ -69,24 +85,6 @@ func DecryptURLParam(paramName, encryptedURL string) (string, error) {
 	return decoded, nil
 }
 
-func extractHDNEAFromSetCookie(setCookie []byte) string {
-	if len(setCookie) == 0 {
-		return ""
-	}
-
-	for _, part := range bytes.Split(setCookie, []byte(";")) {
-		trimmed := bytes.TrimSpace(part)
-		switch {
-		case bytes.HasPrefix(trimmed, []byte("__hdnea__=")):
-			return string(bytes.TrimPrefix(trimmed, []byte("__hdnea__=")))
-		case bytes.HasPrefix(trimmed, []byte("hdnea=")):
-			return string(bytes.TrimPrefix(trimmed, []byte("hdnea=")))
-		}
-	}
-
-	return ""
-}
-
 // ProxyRequest performs a proxy request with common setup
 func ProxyRequest(c *fiber.Ctx, url string, client *fasthttp.Client, userAgent string) (string, error) {
 	if userAgent != "" {
@@ -97,7 +95,7 @@ func ProxyRequest(c *fiber.Ctx, url string, client *fasthttp.Client, userAgent s
 		return "", err
 	}
 
-	newHDNEA := extractHDNEAFromSetCookie(c.Response().Header.Peek(fiber.HeaderSetCookie))
+	newHDNEA := cdntoken.HDNEA.FromResponse(&c.Response().Header)
 	c.Response().Header.Del(fiber.HeaderServer)
 	// Do not leak upstream cookies to the client
 	c.Response().Header.Del(fiber.HeaderSetCookie)
@@ -2,6 +2,7 @@ package main
 
 import (
 	_ "embed"
+	"fmt"
 	"log"
 	"os"
 	"time"
@@ -35,7 +36,8 @@ func main() {
 			utils.BoolFlag("skip-update-check", "Skip checking for update on startup", "skip-update"),
 		},
 		Before: func(c *cli.Context) error {
-			if !cmd.IsTermux() {
+			// The setup command shows what it changes, so the files are not created before it runs
+			if !cmd.IsTermux() && c.Args().First() != "setup" {
 				if err := cmd.SetupEnvironment(); err != nil {
 					log.Printf("WARN: Failed to setup environment: %v", err)
 				}
@@ -44,8 +46,12 @@ func main() {
 			configPath := c.String("config")
 			// Load the config file first
 			if err := cmd.LoadConfig(configPath); err != nil {
-				// Use standard log here as utils.Log might not be initialized if config loading fails
-				log.Fatalf("Failed to load config: %v", err)
+				// The config command can validate and fix the config file, so it runs without it
+				if c.Args().First() != "config" {
+					// Use standard log here as utils.Log might not be initialized if config loading fails
+					log.Fatalf("Failed to load config: %v", err)
+				}
+				log.Printf("WARN: Failed to load config: %v", err)
 			}
 			if c.Bool("skip-update-check") {
 				log.Println("INFO: Skipping update check")
@@ -65,6 +71,11 @@ func main() {
 
 			return nil
 		},
+		After: func(c *cli.Context) error {
+			// Close the SQLite store, if it is used, so that its changes are written to the database file
+			store.Close()
+			return nil
+		},
 		Commands: []*cli.Command{
 			utils.NewCommand(utils.CommandConfig{
 				Name:        "serve",
@@ -72,25 +83,17 @@ func main() {
 				Usage:       "Start JioTV Go server",
 				Description: "The serve command starts JioTV Go server, and listens on the host and port. The default host is localhost and port is 5001.",
 				Action: func(c *cli.Context) error {
-					host := c.String("host")
-					// overwrite host if --public flag is passed
-					if c.Bool("public") {
-						cmd.Logger().Println("INFO: You are exposing your server to outside your local network (public)!")
-						cmd.Logger().Println("INFO: Overwriting host to [::] for public access")
-						host = "[::]"
-					}
-					port := c.String("port")
-					tls := c.Bool("tls")
-					tlsCertPath := c.String("tls-cert")
-					tlsKeyPath := c.String("tls-key")
-					// Pass configPath for consistency, though JioTVServer won't load it again
-					return cmd.JioTVServer(cmd.JioTVServerConfig{
-						Host:        host,
-						Port:        port,
-						TLS:         tls,
-						TLSCertPath: tlsCertPath,
-						TLSKeyPath:  tlsKeyPath,
-					})
+					return cmd.JioTVServer(serverConfig(c))
+				},
+				Flags: utils.CommonServerFlags(),
+			}),
+			utils.NewCommand(utils.CommandConfig{
+				Name:        "tui",
+				Aliases:     []string{"dashboard"},
+				Usage:       "Start JioTV Go server with a terminal dashboard",
+				Description: "The tui command starts JioTV Go server like the serve command and shows its status, the active streams and the log in the terminal. Press r to reload channels, e to regenerate EPG, l to log out and q to quit. It is meant for servers operated over SSH, like Termux or a Raspberry Pi.",
+				Action: func(c *cli.Context) error {
+					return cmd.TUI(serverConfig(c))
 				},
 				Flags: utils.CommonServerFlags(),
 			}),
@@ -110,7 +113,7 @@ func main() {
 				Name:        "epg",
 				Aliases:     []string{"e"},
 				Usage:       "Manage EPG",
-				Description: "The epg command manages EPG. It can be used to generate EPG, regenerate EPG, and delete EPG.",
+				Description: "The epg command manages EPG. It can be used to generate EPG, regenerate EPG, download external EPG, show the status of the EPG file and delete EPG.",
 				Subcommands: []*cli.Command{
 					utils.NewCommand(utils.CommandConfig{
 						Name:        "generate",
@@ -121,6 +124,27 @@ func main() {
 							return cmd.GenEPG()
 						},
 					}),
+					utils.NewCommand(utils.CommandConfig{
+						Name:        "download",
+						Aliases:     []string{"dl"},
+						Usage:       "Download external EPG",
+						Description: "The download command downloads an external XMLTV EPG and saves it to epg.xml.gz, replacing the existing file. It uses epg_url of the config unless a URL is given. Channel IDs are mapped with epg_channel_map_file of the config.",
+						Action: func(c *cli.Context) error {
+							return cmd.DownloadEPG(c.String("url"))
+						},
+						Flags: []cli.Flag{
+							utils.StringFlag("url", "", "URL of the gzipped XMLTV EPG. Default: epg_url of the config", "u"),
+						},
+					}),
+					utils.NewCommand(utils.CommandConfig{
+						Name:        "status",
+						Aliases:     []string{"s"},
+						Usage:       "Show EPG file status",
+						Description: "The status command shows when epg.xml.gz was last updated, its size, the number of channels and programmes in it and the time its programmes cover.",
+						Action: func(c *cli.Context) error {
+							return cmd.EPGStatus()
+						},
+					}),
 					utils.NewCommand(utils.CommandConfig{
 						Name:        "delete",
 						Aliases:     []string{"del", "d"},
@@ -132,6 +156,192 @@ func main() {
 					}),
 				},
 			}),
+			utils.NewCommand(utils.CommandConfig{
+				Name:        "channels",
+				Aliases:     []string{"ch"},
+				Usage:       "List and search channels",
+				Description: "The channels command fetches the JioTV, custom and plugin channels like the server does and prints their IDs, numbers, names, categories and languages. Use it to look up channel IDs without the web interface.",
+				Action: func(c *cli.Context) error {
+					return cmd.ListChannels(cmd.ChannelsOptions{
+						Search:   c.String("search"),
+						Language: c.String("lang"),
+						Category: c.String("category"),
+						JSON:     c.Bool("json"),
+					})
+				},
+				Flags: []cli.Flag{
+					utils.StringFlag("search", "", "Only list channels whose name contains the text, or with the ID", "s"),
+					utils.StringFlag("lang", "", "Only list channels of the language, e.g. hindi", "l"),
+					utils.StringFlag("category", "", "Only list channels of the category, e.g. sports", "c"),
+					utils.BoolFlag("json", "Print the channels as JSON"),
+				},
+			}),
+			utils.NewCommand(utils.CommandConfig{
+				Name:        "record",
+				Aliases:     []string{"rec"},
+				Usage:       "Record a channel to a file",
+				Description: "The record command saves a JioTV channel from --start to --end into an MPEG-TS file, without the server running. Programmes that have ended are saved from catchup. Otherwise the live stream is saved from --start, waiting for it if it is in the future, until --end. Times are like \"2024-01-15 21:00\" in local time, RFC 3339 or Unix times. Press Ctrl+C to stop early and keep what was saved.",
+				Action: func(c *cli.Context) error {
+					return cmd.Record(cmd.RecordOptions{
+						Channel:  c.Args().First(),
+						Start:    c.String("start"),
+						End:      c.String("end"),
+						Duration: c.Duration("duration"),
+						Output:   c.String("output"),
+						Quality:  c.String("quality"),
+					})
+				},
+				Flags: []cli.Flag{
+					utils.StringFlag("start", "", "Start of the recording. Default: now", "s"),
+					utils.StringFlag("end", "", "End of the recording", "e"),
+					&cli.DurationFlag{Name: "duration", Aliases: []string{"d"}, Usage: "Length of the recording instead of --end, e.g. 30m"},
+					utils.StringFlag("output", "", "Path of the file. Default: jiotv_go-<channel>-<start>.ts", "o"),
+					utils.StringFlag("quality", "", "Stream quality: low, medium, high or auto. Default: auto", "q"),
+				},
+			}),
+			utils.NewCommand(utils.CommandConfig{
+				Name:        "library",
+				Usage:       "Export channels as a Kodi or Jellyfin library",
+				Description: "The library command writes a folder per channel with a .strm file and NFO metadata into the output directory. Add the directory to Kodi or Jellyfin as a movies library to browse and play the channels without a live TV add-on. The .strm files point to the server at --url, which has to be running to play them. Run it again to update the library.",
+				Action: func(c *cli.Context) error {
+					return cmd.ExportLibrary(c.String("output"), c.String("url"), c.String("quality"))
+				},
+				Flags: []cli.Flag{
+					utils.StringFlag("output", "jiotv_go-library", "Directory to write the library into", "o"),
+					utils.StringFlag("url", "http://localhost:5001", "URL of the JioTV Go server that plays the channels", "u"),
+					utils.StringFlag("quality", "", "Stream quality: low, medium or high. Default: the server default", "q"),
+				},
+			}),
+			utils.NewCommand(utils.CommandConfig{
+				Name:        "setup",
+				Usage:       "Download or update the config and channel files",
+				Description: "The setup command downloads jiotv_go.toml, custom-channels.json and the Zee5 data into the configs folder, or updates them. It shows the changes to existing files and asks before writing them, keeping a .bak copy of each. Settings you set in jiotv_go.toml are never replaced, only missing ones are added.",
+				Action: func(c *cli.Context) error {
+					return cmd.Setup(cmd.SetupOptions{
+						SourceURL: c.String("source-url"),
+						MergeOnly: c.Bool("merge-only"),
+						DryRun:    c.Bool("dry-run"),
+						SkipTOML:  c.Bool("skip-toml"),
+						Yes:       c.Bool("yes"),
+					}, os.Stdin, os.Stdout)
+				},
+				Flags: []cli.Flag{
+					utils.StringFlag("source-url", "", "URL of the custom channels JSON. Default: custom_channels_url of the config", "s"),
+					utils.BoolFlag("merge-only", "Only add missing channels to custom-channels.json, keeping the others", "m"),
+					utils.BoolFlag("dry-run", "Show the changes without writing them", "n"),
+					utils.BoolFlag("skip-toml", "Do not create or change jiotv_go.toml"),
+					utils.BoolFlag("yes", "Write the changes without asking", "y"),
+				},
+			}),
+			utils.NewCommand(utils.CommandConfig{
+				Name:        "config",
+				Aliases:     []string{"cfg"},
+				Usage:       "Manage the config file",
+				Description: "The config command creates, checks, changes and shows the config file. It uses the file given with --config, or the config file JioTV Go finds on startup.",
+				Subcommands: []*cli.Command{
+					utils.NewCommand(utils.CommandConfig{
+						Name:        "init",
+						Usage:       "Create a config file",
+						Description: "The init command writes an example config file with a comment on every option, jiotv_go.toml by default. Existing files are not replaced unless --force is given.",
+						Action: func(c *cli.Context) error {
+							return cmd.ConfigInit(c.String("output"), c.Bool("force"))
+						},
+						Flags: []cli.Flag{
+							utils.StringFlag("output", "jiotv_go.toml", "Path of the config file", "o"),
+							utils.BoolFlag("force", "Replace the file if it exists", "f"),
+						},
+					}),
+					utils.NewCommand(utils.CommandConfig{
+						Name:        "validate",
+						Usage:       "Check the config file",
+						Description: "The validate command checks the config file for unknown or misspelled keys, values of the wrong type or that are not allowed, and files like custom_channels_file that do not exist. It exits with an error if it finds problems.",
+						Action: func(c *cli.Context) error {
+							return cmd.ConfigValidate(c.String("config"))
+						},
+					}),
+					utils.NewCommand(utils.CommandConfig{
+						Name:        "set",
+						Usage:       "Set an option of the config file",
+						Description: "The set command sets an option of the config file, e.g. \"jiotv_go config set epg true\". Lists are comma separated, e.g. \"jiotv_go config set favorite_channels 143,144\". Comments of TOML and YAML files are kept. Lists of tables like channel_rules have to be edited in the file.",
+						Action: func(c *cli.Context) error {
+							if c.NArg() != 2 {
+								return fmt.Errorf("usage: jiotv_go config set <key> <value>")
+							}
+							return cmd.ConfigSet(c.String("config"), c.Args().Get(0), c.Args().Get(1))
+						},
+					}),
+					utils.NewCommand(utils.CommandConfig{
+						Name:        "show",
+						Usage:       "Show the config in effect",
+						Description: "The show command prints the config in effect as TOML, with the defaults and environment variables applied. Use --redact to leave out tokens and passwords before sharing it in a support request.",
+						Action: func(c *cli.Context) error {
+							return cmd.ConfigShow(c.String("config"), c.Bool("redact"))
+						},
+						Flags: []cli.Flag{
+							utils.BoolFlag("redact", "Replace tokens and passwords with REDACTED", "r"),
+						},
+					}),
+				},
+			}),
+			utils.NewCommand(utils.CommandConfig{
+				Name:        "clean",
+				Usage:       "Remove stale files",
+				Description: "The clean command removes orphaned temp files, superseded EPG backups, expired image cache entries and old logs from the JioTV Go folder. The server also does this once a day. Use --dry-run to only list the files.",
+				Action: func(c *cli.Context) error {
+					return cmd.Clean(c.Bool("dry-run"))
+				},
+				Flags: []cli.Flag{
+					utils.BoolFlag("dry-run", "List the files that would be removed without removing them", "n"),
+				},
+			}),
+			utils.NewCommand(utils.CommandConfig{
+				Name:        "analytics",
+				Usage:       "Manage local analytics",
+				Description: "The analytics command manages the feature usage counted on this machine when analytics is set to local in the config. Nothing is ever sent anywhere.",
+				Subcommands: []*cli.Command{
+					utils.NewCommand(utils.CommandConfig{
+						Name:        "export",
+						Usage:       "Export feature usage",
+						Description: "The export command prints the feature usage counts, the version, the operating system and the names of the enabled config options as JSON. Attach the output to a feature request to help prioritize. It has no channel IDs, addresses or credentials.",
+						Action: func(c *cli.Context) error {
+							return cmd.ExportAnalytics(c.App.Version, c.String("output"))
+						},
+						Flags: []cli.Flag{
+							utils.StringFlag("output", "", "Write the export to a file instead of printing it", "o"),
+						},
+					}),
+				},
+			}),
+			utils.NewCommand(utils.CommandConfig{
+				Name:        "diagnose",
+				Aliases:     []string{"doctor"},
+				Usage:       "Check why channels do not play",
+				Description: "The diagnose command checks the login, the configured proxies, DNS of the JioTV hosts, the channels API and the playback of a channel, and prints a report. Run it when a channel does not play and include the report in issues.",
+				Action: func(c *cli.Context) error {
+					return cmd.Diagnose(c.String("channel"))
+				},
+				Flags: []cli.Flag{
+					utils.StringFlag("channel", "143", "ID of the channel to play", "c"),
+				},
+			}),
+			utils.NewCommand(utils.CommandConfig{
+				Name:        "debug",
+				Usage:       "Debugging tools",
+				Description: "The debug command contains tools that help maintainers reproduce issues.",
+				Subcommands: []*cli.Command{
+					utils.NewCommand(utils.CommandConfig{
+						Name:        "capture",
+						Usage:       "Capture upstream requests for a channel",
+						Description: "The capture command plays the given channel once and saves the upstream requests and responses into a zip bundle. Credentials, device IDs and stream tokens are redacted, so the bundle can be attached to an issue.",
+						Action: func(c *cli.Context) error {
+							return cmd.DebugCapture(c.Args().First(), c.String("output"))
+						},
+						Flags: []cli.Flag{
+							utils.StringFlag("output", "", "Path of the bundle. Default: jiotv_go-debug-<channel>-<time>.zip", "o"),
+						},
+					}),
+				},
+			}),
 			{
 				Name:        "login",
 				Aliases:     []string{"l"},
@@ -232,3 +442,21 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// serverConfig returns the server config of the flags of the serve and tui commands.
+func serverConfig(c *cli.Context) cmd.JioTVServerConfig {
+	host := c.String("host")
+	// overwrite host if --public flag is passed
+	if c.Bool("public") {
+		cmd.Logger().Println("INFO: You are exposing your server to outside your local network (public)!")
+		cmd.Logger().Println("INFO: Overwriting host to [::] for public access")
+		host = "[::]"
+	}
+	return cmd.JioTVServerConfig{
+		Host:        host,
+		Port:        c.String("port"),
+		TLS:         c.Bool("tls"),
+		TLSCertPath: c.String("tls-cert"),
+		TLSKeyPath:  c.String("tls-key"),
+	}
+}
@@ -0,0 +1,92 @@
+// Package accessstats keeps which channels are watched, when and by which clients, for the stats
+// dashboard of households sharing one server. The plays are recorded by the stats recorder, which
+// keeps the IP addresses and user agents of the clients only while access stats are enabled. This
+// package keeps the plays for a month and stores them across restarts. Like local analytics,
+// nothing is ever sent anywhere.
+package accessstats
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/stats"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// statsFile stores the plays under the path prefix
+	statsFile = "access_stats.json"
+	// retention is how long plays are kept
+	retention = 30 * 24 * time.Hour
+)
+
+var (
+	// enabled is set by Init when access_stats is enabled
+	enabled bool
+	// saveMu guards savedRevision
+	saveMu sync.Mutex
+	// savedRevision is the revision of the plays last read or written
+	savedRevision uint64
+)
+
+// Init enables the access stats if access_stats is enabled and loads the previously stored plays
+// into the default stats recorder.
+func Init() {
+	enabled = config.Cfg.AccessStats
+	if !enabled {
+		stats.Default.SetRetention(0)
+		return
+	}
+	stats.Default.SetRetention(retention)
+	if err := load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		utils.Log.Printf("WARN: Failed to read access stats, starting over: %v", err)
+	}
+	utils.Log.Println("INFO: Access stats enabled. Plays are stored with the IP address and user agent of the clients.")
+}
+
+// Enabled reports whether plays are recorded with their clients and stored.
+func Enabled() bool {
+	return enabled
+}
+
+// load reads the plays from the stats file into the default stats recorder.
+func load() error {
+	content, err := store.ReadDocument(statsFile)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(content, stats.Default); err != nil {
+		return err
+	}
+	saveMu.Lock()
+	savedRevision = stats.Default.Revision()
+	saveMu.Unlock()
+	return nil
+}
+
+// Save writes the plays of the default stats recorder to disk if they changed since the last save.
+func Save() error {
+	if !enabled {
+		return nil
+	}
+	saveMu.Lock()
+	defer saveMu.Unlock()
+	revision := stats.Default.Revision()
+	if revision == savedRevision {
+		return nil
+	}
+	content, err := json.Marshal(stats.Default)
+	if err != nil {
+		return err
+	}
+	if err := store.WriteDocument(statsFile, content); err != nil {
+		return err
+	}
+	savedRevision = revision
+	return nil
+}
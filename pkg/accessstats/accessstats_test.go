@@ -0,0 +1,82 @@
+package accessstats
+
+import (
+	"io"
+	"log"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/stats"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+func setup(t *testing.T, on bool) {
+	t.Helper()
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, originalStats := config.Cfg, stats.Default
+	t.Cleanup(func() {
+		config.Cfg, stats.Default = original, originalStats
+		Init()
+		cleanup()
+	})
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	config.Cfg.AccessStats = on
+	stats.Default = stats.NewRecorder()
+	Init()
+}
+
+func TestInitRetention(t *testing.T) {
+	setup(t, true)
+	if days := len(stats.Default.Usage().Days); days != 30 {
+		t.Errorf("days of the usage = %d, want 30 while enabled", days)
+	}
+
+	config.Cfg.AccessStats = false
+	Init()
+	if days := len(stats.Default.Usage().Days); days != 7 {
+		t.Errorf("days of the usage = %d, want the default of 7 while disabled", days)
+	}
+}
+
+func TestSaveDisabled(t *testing.T) {
+	setup(t, false)
+	stats.RecordPlay("143", "tv-1234")
+	if err := Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := store.ReadDocument(statsFile); err == nil {
+		t.Error("Save() should not write the stats while disabled")
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	setup(t, true)
+	stats.RecordClientPlay("143", "tv-1234", stats.Client{IP: "10.0.0.1", UserAgent: "VLC/3.0"})
+	if err := Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	stats.Default = stats.NewRecorder()
+	Init()
+	usage := stats.Default.Usage()
+	if usage.Plays != 1 || len(usage.Clients) != 1 || usage.Clients[0].UserAgent != "VLC/3.0" {
+		t.Errorf("Usage() after Init() = %+v, want the saved play", usage)
+	}
+
+	// Unchanged plays are not written again
+	if err := store.WriteDocument(statsFile, []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if content, _ := store.ReadDocument(statsFile); string(content) != "{}" {
+		t.Error("Save() should skip unchanged plays")
+	}
+}
@@ -0,0 +1,200 @@
+// Package analytics records which features of JioTV Go are used, strictly on the local machine.
+// Nothing is ever sent anywhere. Users can export the counts and attach them to feature requests.
+package analytics
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// ModeOff disables analytics
+	ModeOff = "off"
+	// ModeLocal records feature usage in a local file
+	ModeLocal = "local"
+	// analyticsFile stores the recorded feature usage under the path prefix
+	analyticsFile = "analytics.json"
+)
+
+// FeatureUsage is how often and when a feature was used
+type FeatureUsage struct {
+	Count     int64     `json:"count"`
+	FirstUsed time.Time `json:"first_used"`
+	LastUsed  time.Time `json:"last_used"`
+}
+
+// Report is the exported analytics data. It has no channel IDs, addresses or credentials.
+type Report struct {
+	Version  string                  `json:"version"`
+	OS       string                  `json:"os"`
+	Arch     string                  `json:"arch"`
+	Since    time.Time               `json:"since"`
+	Features map[string]FeatureUsage `json:"features"`
+	Config   []string                `json:"enabled_options"`
+}
+
+// data is the content of the analytics file
+type data struct {
+	Since    time.Time               `json:"since"`
+	Features map[string]FeatureUsage `json:"features"`
+}
+
+var (
+	mu      sync.Mutex
+	enabled bool
+	dirty   bool
+	current data
+)
+
+// Init enables recording if the analytics mode is "local" and loads the previously recorded usage.
+func Init() {
+	mu.Lock()
+	defer mu.Unlock()
+	enabled = config.Cfg.Analytics == ModeLocal
+	if !enabled {
+		return
+	}
+	loaded, err := load()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		utils.Log.Printf("WARN: Failed to read analytics, starting over: %v", err)
+	}
+	current = loaded
+	utils.Log.Println("Local analytics enabled. Feature usage is only stored on this machine.")
+}
+
+// Enabled reports whether feature usage is recorded.
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return enabled
+}
+
+// Record counts a use of a feature. It does nothing unless analytics are enabled.
+func Record(feature string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !enabled {
+		return
+	}
+	now := time.Now().UTC()
+	usage := current.Features[feature]
+	if usage.FirstUsed.IsZero() {
+		usage.FirstUsed = now
+	}
+	usage.Count++
+	usage.LastUsed = now
+	current.Features[feature] = usage
+	dirty = true
+}
+
+// Save writes the recorded usage to disk if it changed since the last save.
+func Save() error {
+	mu.Lock()
+	defer mu.Unlock()
+	if !enabled || !dirty {
+		return nil
+	}
+	if err := save(current); err != nil {
+		return err
+	}
+	dirty = false
+	return nil
+}
+
+// Export returns the recorded usage from disk together with the enabled config options.
+// It works without a running server.
+func Export(version string) (Report, error) {
+	recorded, err := load()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Report{}, err
+	}
+	return Report{
+		Version:  version,
+		OS:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+		Since:    recorded.Since,
+		Features: recorded.Features,
+		Config:   enabledOptions(),
+	}, nil
+}
+
+// enabledOptions returns the names of optional features enabled in the config, without their values.
+func enabledOptions() []string {
+	cfg := config.Cfg
+	options := map[string]bool{
+		"epg":                       cfg.EPG,
+		"epg_incremental":           cfg.EPGIncremental,
+		"drm":                       cfg.DRM,
+		"guest_mode":                cfg.GuestMode,
+		"offline_mode":              cfg.OfflineMode,
+		"live_abr":                  cfg.LiveABR,
+		"manifest_conversion":       cfg.ManifestConversion,
+		"multicast_outputs":         len(cfg.MulticastOutputs) > 0,
+		"preview_interval":          cfg.PreviewInterval > 0,
+		"proxy":                     cfg.Proxy != "",
+		"proxy_rules":               len(cfg.ProxyRules) > 0,
+		"dns_rules":                 len(cfg.DNSRules) > 0,
+		"ca_bundle":                 cfg.CABundle != "",
+		"tls_pins":                  len(cfg.TLSPins) > 0,
+		"tls_fingerprint":           cfg.TLSFingerprint != "" && cfg.TLSFingerprint != "go",
+		"url_key_rotation":          cfg.URLKeyRotation > 0,
+		"url_ttl":                   cfg.URLTTL > 0,
+		"channel_rules":             len(cfg.ChannelRules) > 0,
+		"manifest_filters":          len(cfg.ManifestFilters) > 0,
+		"favorite_channels":         len(cfg.FavoriteChannels) > 0,
+		"channels_cache_on_disk":    cfg.ChannelsCacheOnDisk,
+		"preferred_audio_languages": len(cfg.PreferredAudioLanguages) > 0,
+		"prefer_audio_description":  cfg.PreferAudioDescription,
+		"prefer_sdh_subtitles":      cfg.PreferSDHSubtitles,
+		"access_stats":              cfg.AccessStats,
+		"bandwidth_accounting":      cfg.BandwidthAccounting || cfg.BandwidthCapGB > 0,
+		"max_concurrent_streams":    cfg.MaxConcurrentStreams > 0,
+	}
+	for _, plugin := range cfg.Plugins {
+		options["plugin_"+strings.ToLower(strings.TrimSpace(plugin))] = true
+	}
+	result := []string{}
+	for name, on := range options {
+		if on {
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// load reads the analytics file. It returns empty data if the file cannot be read.
+func load() (data, error) {
+	result := data{Since: time.Now().UTC(), Features: make(map[string]FeatureUsage)}
+	content, err := store.ReadDocument(analyticsFile)
+	if err != nil {
+		return result, err
+	}
+	var loaded data
+	if err := json.Unmarshal(content, &loaded); err != nil {
+		return result, err
+	}
+	if loaded.Features == nil {
+		loaded.Features = make(map[string]FeatureUsage)
+	}
+	return loaded, nil
+}
+
+// save writes the analytics file.
+func save(d data) error {
+	content, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.WriteDocument(analyticsFile, content)
+}
@@ -0,0 +1,82 @@
+package analytics
+
+import (
+	"io"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+func setup(t *testing.T, mode string) {
+	t.Helper()
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := config.Cfg
+	t.Cleanup(func() {
+		config.Cfg = original
+		cleanup()
+	})
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	config.Cfg.Analytics = mode
+	Init()
+}
+
+func TestRecordDisabled(t *testing.T) {
+	setup(t, ModeOff)
+	Record("live")
+	if err := Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	report, err := Export("v3.0.0")
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if len(report.Features) != 0 {
+		t.Errorf("features = %v, want none while disabled", report.Features)
+	}
+}
+
+func TestRecordAndExport(t *testing.T) {
+	setup(t, ModeLocal)
+	Record("live")
+	Record("live")
+	Record("catchup")
+	if err := Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// Usage survives a restart
+	Init()
+	Record("catchup")
+	if err := Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	config.Cfg.EPG = true
+	config.Cfg.Plugins = []string{" Zee5 "}
+	report, err := Export("v3.0.0")
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if report.Version != "v3.0.0" || report.OS == "" || report.Since.IsZero() {
+		t.Errorf("report = %+v", report)
+	}
+	if got := report.Features["live"].Count; got != 2 {
+		t.Errorf("live count = %d, want 2", got)
+	}
+	catchup := report.Features["catchup"]
+	if catchup.Count != 2 || catchup.LastUsed.Before(catchup.FirstUsed) {
+		t.Errorf("catchup usage = %+v, want 2 uses", catchup)
+	}
+	if want := []string{"epg", "plugin_zee5"}; !reflect.DeepEqual(report.Config, want) {
+		t.Errorf("enabled options = %v, want %v", report.Config, want)
+	}
+}
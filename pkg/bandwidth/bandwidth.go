@@ -0,0 +1,242 @@
+// Package bandwidth counts the bytes of streams proxied to each client and for each channel, month
+// by month, so that servers on metered connections can see where their data goes and stop streaming
+// once a monthly cap is reached.
+package bandwidth
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// usageFile stores the counted bytes under the path prefix
+	usageFile = "bandwidth.json"
+	// MonthFormat is the layout of the months of the usage
+	MonthFormat = "2006-01"
+	// monthsKept is the number of months whose usage is kept
+	monthsKept = 12
+	// gigabyte is the unit of bandwidth_cap_gb. Like ISPs, a gigabyte is 10^9 bytes.
+	gigabyte = 1e9
+)
+
+// Usage is the number of bytes streamed in a month
+type Usage struct {
+	Month string `json:"month"`
+	Bytes int64  `json:"bytes"`
+	// Clients are the bytes by client IP address
+	Clients map[string]int64 `json:"clients"`
+	// Channels are the bytes by channel ID
+	Channels map[string]int64 `json:"channels"`
+}
+
+// newUsage returns the empty usage of a month.
+func newUsage(month string) *Usage {
+	return &Usage{Month: month, Clients: make(map[string]int64), Channels: make(map[string]int64)}
+}
+
+// copy returns a copy of the usage that is safe to use without the lock.
+func (u *Usage) copy() Usage {
+	result := *u
+	result.Clients = make(map[string]int64, len(u.Clients))
+	for client, bytes := range u.Clients {
+		result.Clients[client] = bytes
+	}
+	result.Channels = make(map[string]int64, len(u.Channels))
+	for channel, bytes := range u.Channels {
+		result.Channels[channel] = bytes
+	}
+	return result
+}
+
+// Meter counts bytes by month
+type Meter struct {
+	mu     sync.Mutex
+	months map[string]*Usage
+	dirty  bool
+	now    func() time.Time
+}
+
+// NewMeter returns a meter without usage.
+func NewMeter() *Meter {
+	return &Meter{months: make(map[string]*Usage), now: time.Now}
+}
+
+// Add counts bytes streamed to a client for a channel. The channel may be empty if it is not known.
+func (m *Meter) Add(client, channel string, bytes int64) {
+	if bytes <= 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	month := m.now().Format(MonthFormat)
+	usage, ok := m.months[month]
+	if !ok {
+		usage = newUsage(month)
+		m.months[month] = usage
+		m.prune()
+	}
+	usage.Bytes += bytes
+	usage.Clients[client] += bytes
+	usage.Channels[channel] += bytes
+	m.dirty = true
+}
+
+// prune drops the oldest months past monthsKept. The caller must hold the lock.
+func (m *Meter) prune() {
+	if len(m.months) <= monthsKept {
+		return
+	}
+	months := make([]string, 0, len(m.months))
+	for month := range m.months {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+	for _, month := range months[:len(months)-monthsKept] {
+		delete(m.months, month)
+	}
+}
+
+// Month returns the usage of a month in MonthFormat. A month without usage has zero bytes.
+func (m *Meter) Month(month string) Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if usage, ok := m.months[month]; ok {
+		return usage.copy()
+	}
+	return newUsage(month).copy()
+}
+
+// Current returns the usage of the current month.
+func (m *Meter) Current() Usage {
+	return m.Month(m.now().Format(MonthFormat))
+}
+
+// CurrentBytes returns the bytes streamed in the current month.
+func (m *Meter) CurrentBytes() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if usage, ok := m.months[m.now().Format(MonthFormat)]; ok {
+		return usage.Bytes
+	}
+	return 0
+}
+
+// Months returns the months with usage, the most recent first.
+func (m *Meter) Months() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	months := make([]string, 0, len(m.months))
+	for month := range m.months {
+		months = append(months, month)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(months)))
+	return months
+}
+
+// load reads the usage from the usage file.
+func (m *Meter) load() error {
+	content, err := store.ReadDocument(usageFile)
+	if err != nil {
+		return err
+	}
+	var months map[string]*Usage
+	if err := json.Unmarshal(content, &months); err != nil {
+		return err
+	}
+	for month, usage := range months {
+		if usage == nil {
+			months[month] = newUsage(month)
+			continue
+		}
+		if usage.Clients == nil {
+			usage.Clients = make(map[string]int64)
+		}
+		if usage.Channels == nil {
+			usage.Channels = make(map[string]int64)
+		}
+	}
+	m.mu.Lock()
+	m.months = months
+	m.mu.Unlock()
+	return nil
+}
+
+// save writes the usage to the usage file if it changed since the last save.
+func (m *Meter) save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.dirty {
+		return nil
+	}
+	content, err := json.Marshal(m.months)
+	if err != nil {
+		return err
+	}
+	if err := store.WriteDocument(usageFile, content); err != nil {
+		return err
+	}
+	m.dirty = false
+	return nil
+}
+
+var (
+	// Default is the meter used by the server
+	Default = NewMeter()
+	// enabled is set by Init when bandwidth accounting is enabled
+	enabled bool
+)
+
+// Init enables counting if bandwidth_accounting is enabled or a bandwidth cap is set, and loads the
+// previously counted usage.
+func Init() {
+	enabled = config.Cfg.BandwidthAccounting || config.Cfg.BandwidthCapGB > 0
+	if !enabled {
+		return
+	}
+	if err := Default.load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		utils.Log.Printf("WARN: Failed to read bandwidth usage, starting over: %v", err)
+	}
+	if limit := Cap(); limit > 0 {
+		utils.Log.Printf("INFO: Bandwidth cap of %.1f GB a month, %.1f GB used this month", config.Cfg.BandwidthCapGB, float64(Default.CurrentBytes())/gigabyte)
+	}
+}
+
+// Enabled reports whether bytes are counted.
+func Enabled() bool {
+	return enabled
+}
+
+// Add counts bytes streamed to a client for a channel on the default meter. It does nothing unless
+// bandwidth accounting is enabled.
+func Add(client, channel string, bytes int64) {
+	if enabled {
+		Default.Add(client, channel, bytes)
+	}
+}
+
+// Cap returns the monthly cap in bytes, or 0 if there is none.
+func Cap() int64 {
+	return int64(config.Cfg.BandwidthCapGB * gigabyte)
+}
+
+// Exceeded reports whether the bytes streamed this month reached the monthly cap.
+func Exceeded() bool {
+	limit := Cap()
+	return enabled && limit > 0 && Default.CurrentBytes() >= limit
+}
+
+// Save writes the usage of the default meter to disk if it changed since the last save.
+func Save() error {
+	if !enabled {
+		return nil
+	}
+	return Default.save()
+}
@@ -0,0 +1,142 @@
+package bandwidth
+
+import (
+	"io"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+func setup(t *testing.T, accounting bool, capGB float64) {
+	t.Helper()
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, originalDefault := config.Cfg, Default
+	t.Cleanup(func() {
+		config.Cfg, Default = original, originalDefault
+		Init()
+		cleanup()
+	})
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	config.Cfg.BandwidthAccounting = accounting
+	config.Cfg.BandwidthCapGB = capGB
+	Default = NewMeter()
+	Init()
+}
+
+// fakeClock returns a meter whose time is set by the returned function.
+func fakeClock(start time.Time) (*Meter, func(time.Time)) {
+	m := NewMeter()
+	now := start
+	m.now = func() time.Time { return now }
+	return m, func(t time.Time) { now = t }
+}
+
+func TestMeterAdd(t *testing.T) {
+	start := time.Date(2026, 9, 30, 23, 0, 0, 0, time.Local)
+	m, setNow := fakeClock(start)
+	m.Add("10.0.0.1", "143", 1000)
+	setNow(start.Add(2 * time.Hour))
+	m.Add("10.0.0.1", "143", 300)
+	m.Add("10.0.0.2", "", 200)
+	m.Add("10.0.0.2", "144", 0)
+
+	current := m.Current()
+	want := Usage{
+		Month:    "2026-10",
+		Bytes:    500,
+		Clients:  map[string]int64{"10.0.0.1": 300, "10.0.0.2": 200},
+		Channels: map[string]int64{"143": 300, "": 200},
+	}
+	if !reflect.DeepEqual(current, want) {
+		t.Errorf("Current() = %+v, want %+v", current, want)
+	}
+	if september := m.Month("2026-09"); september.Bytes != 1000 || september.Clients["10.0.0.1"] != 1000 {
+		t.Errorf("Month(2026-09) = %+v, want 1000 bytes", september)
+	}
+	if empty := m.Month("2025-01"); empty.Bytes != 0 || empty.Clients == nil {
+		t.Errorf("Month(2025-01) = %+v, want an empty usage", empty)
+	}
+	if got := m.Months(); !reflect.DeepEqual(got, []string{"2026-10", "2026-09"}) {
+		t.Errorf("Months() = %v, want the most recent first", got)
+	}
+
+	// The returned usage is a copy
+	current.Clients["10.0.0.1"] = 0
+	if m.CurrentBytes() != 500 || m.Current().Clients["10.0.0.1"] != 300 {
+		t.Error("changing the returned usage should not change the meter")
+	}
+}
+
+func TestMeterPrune(t *testing.T) {
+	start := time.Date(2025, 1, 15, 12, 0, 0, 0, time.Local)
+	m, setNow := fakeClock(start)
+	for i := 0; i <= monthsKept; i++ {
+		setNow(start.AddDate(0, i, 0))
+		m.Add("10.0.0.1", "143", 1)
+	}
+
+	months := m.Months()
+	if len(months) != monthsKept {
+		t.Fatalf("len(Months()) = %d, want %d", len(months), monthsKept)
+	}
+	if oldest := months[len(months)-1]; oldest != "2025-02" {
+		t.Errorf("oldest month = %s, want 2025-02", oldest)
+	}
+}
+
+func TestExceeded(t *testing.T) {
+	tests := []struct {
+		name       string
+		accounting bool
+		capGB      float64
+		bytes      int64
+		enabled    bool
+		exceeded   bool
+	}{
+		{"disabled", false, 0, 0, false, false},
+		{"accounting without a cap", true, 0, 5e9, true, false},
+		{"under the cap", false, 1, 999_999_999, true, false},
+		{"cap reached", false, 1, 1e9, true, true},
+		{"fractional cap", true, 0.5, 6e8, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup(t, tt.accounting, tt.capGB)
+			Add("10.0.0.1", "143", tt.bytes)
+			if Enabled() != tt.enabled {
+				t.Errorf("Enabled() = %v, want %v", Enabled(), tt.enabled)
+			}
+			if Exceeded() != tt.exceeded {
+				t.Errorf("Exceeded() = %v, want %v", Exceeded(), tt.exceeded)
+			}
+		})
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	setup(t, true, 0)
+	Add("10.0.0.1", "143", 4096)
+	if err := Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	Default = NewMeter()
+	Init()
+	current := Default.Current()
+	if current.Bytes != 4096 || current.Channels["143"] != 4096 {
+		t.Errorf("Current() after Init() = %+v, want the saved usage", current)
+	}
+	if month := time.Now().Format(MonthFormat); current.Month != month {
+		t.Errorf("Month = %s, want %s", current.Month, month)
+	}
+}
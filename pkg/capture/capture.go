@@ -0,0 +1,311 @@
+// Package capture records sanitized upstream requests and responses into a debug bundle,
+// so that playback issues can be reproduced without the user's credentials.
+package capture
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// maxBodySize is the number of body bytes kept per exchange
+	maxBodySize = 64 * 1024
+	// minSecretLength is the length below which values are not redacted, to avoid mangling unrelated text
+	minSecretLength = 6
+	// redacted replaces sensitive values
+	redacted = "REDACTED"
+)
+
+// sensitiveHeaders are request and response headers whose values are always redacted
+var sensitiveHeaders = map[string]bool{
+	"accesstoken":    true,
+	"authorization":  true,
+	"cookie":         true,
+	"crmid":          true,
+	"deviceid":       true,
+	"refreshtoken":   true,
+	"set-cookie":     true,
+	"ssotoken":       true,
+	"subscriberid":   true,
+	"uniqueid":       true,
+	"userid":         true,
+	"x-access-token": true,
+}
+
+// sensitiveParams are query parameters whose values are always redacted
+var sensitiveParams = map[string]bool{
+	"__hdnea__":    true,
+	"access_token": true,
+	"hdnea":        true,
+	"hdntl":        true,
+	"ssotoken":     true,
+	"token":        true,
+}
+
+var (
+	// tokenPattern matches CDN tokens embedded in URLs inside manifests
+	tokenPattern = regexp.MustCompile(`((?:__)?hdnea(?:__)?|hdntl)=[^&"'\s,]+`)
+	// jsonSecretPattern matches credentials in JSON bodies
+	jsonSecretPattern = regexp.MustCompile(`(?i)"(accessToken|refreshToken|ssoToken|authToken|jToken|subscriberId|uniqueId|crmid|deviceId)"\s*:\s*"[^"]*"`)
+	// textContentTypes are the content types whose bodies are kept
+	textContentTypes = []string{"json", "mpegurl", "xml", "text", "dash", "javascript"}
+)
+
+// Exchange is a recorded upstream request and its response
+type Exchange struct {
+	Time            time.Time         `json:"time"`
+	Method          string            `json:"method"`
+	URL             string            `json:"url"`
+	RequestHeaders  map[string]string `json:"request_headers"`
+	Status          int               `json:"status,omitempty"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+	Body            string            `json:"body,omitempty"`
+	Duration        string            `json:"duration"`
+	Error           string            `json:"error,omitempty"`
+}
+
+// Recorder collects sanitized exchanges
+type Recorder struct {
+	mu        sync.Mutex
+	exchanges []Exchange
+	secrets   []string
+	// previousHTTPTransport is restored by Stop
+	previousHTTPTransport http.RoundTripper
+}
+
+// Start records all upstream requests made from now on through clients returned by
+// utils.GetRequestClient and through net/http clients using the default transport.
+// The secrets are redacted wherever they appear. Stop must be called to remove the hooks.
+func Start(secrets ...string) *Recorder {
+	r := &Recorder{previousHTTPTransport: http.DefaultTransport}
+	for _, secret := range secrets {
+		if len(secret) >= minSecretLength {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+	// Replace longer secrets first, in case one contains another
+	sort.Slice(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+
+	utils.SetRequestTransport(fasthttpTransport{recorder: r})
+	http.DefaultTransport = httpTransport{recorder: r, next: r.previousHTTPTransport}
+	return r
+}
+
+// Stop removes the recording hooks.
+func (r *Recorder) Stop() {
+	utils.SetRequestTransport(nil)
+	http.DefaultTransport = r.previousHTTPTransport
+}
+
+// Exchanges returns the recorded exchanges in order.
+func (r *Recorder) Exchanges() []Exchange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Exchange(nil), r.exchanges...)
+}
+
+// add records an exchange after sanitizing it.
+func (r *Recorder) add(exchange Exchange) {
+	exchange.URL = r.sanitizeURL(exchange.URL)
+	exchange.RequestHeaders = r.sanitizeHeaders(exchange.RequestHeaders)
+	exchange.ResponseHeaders = r.sanitizeHeaders(exchange.ResponseHeaders)
+	exchange.Body = r.sanitizeText(exchange.Body)
+	exchange.Error = r.sanitizeText(exchange.Error)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exchanges = append(r.exchanges, exchange)
+}
+
+// sanitizeText redacts secrets, CDN tokens and JSON credentials in text.
+func (r *Recorder) sanitizeText(text string) string {
+	for _, secret := range r.secrets {
+		text = strings.ReplaceAll(text, secret, redacted)
+	}
+	text = tokenPattern.ReplaceAllString(text, "$1="+redacted)
+	return jsonSecretPattern.ReplaceAllString(text, `"$1":"`+redacted+`"`)
+}
+
+// sanitizeURL redacts sensitive query parameters and secrets in a URL.
+func (r *Recorder) sanitizeURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return r.sanitizeText(rawURL)
+	}
+	query := parsed.Query()
+	for key := range query {
+		if sensitiveParams[strings.ToLower(key)] {
+			query.Set(key, redacted)
+		}
+	}
+	parsed.RawQuery = query.Encode()
+	return r.sanitizeText(parsed.String())
+}
+
+// sanitizeHeaders redacts sensitive headers and secrets in header values.
+func (r *Recorder) sanitizeHeaders(headers map[string]string) map[string]string {
+	for key, value := range headers {
+		if sensitiveHeaders[strings.ToLower(key)] {
+			headers[key] = redacted
+		} else {
+			headers[key] = r.sanitizeText(value)
+		}
+	}
+	return headers
+}
+
+// bodyText returns a printable body, or a short description for binary or empty bodies.
+func bodyText(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	isText := false
+	for _, t := range textContentTypes {
+		if strings.Contains(strings.ToLower(contentType), t) {
+			isText = true
+			break
+		}
+	}
+	if !isText {
+		return fmt.Sprintf("<%d bytes of %s>", len(body), contentType)
+	}
+	if len(body) > maxBodySize {
+		return string(body[:maxBodySize]) + fmt.Sprintf("\n<truncated, %d bytes in total>", len(body))
+	}
+	return string(body)
+}
+
+// fasthttpTransport records requests made through fasthttp clients
+type fasthttpTransport struct {
+	recorder *Recorder
+}
+
+// RoundTrip performs the request with the default transport and records it.
+func (t fasthttpTransport) RoundTrip(hc *fasthttp.HostClient, req *fasthttp.Request, resp *fasthttp.Response) (bool, error) {
+	start := time.Now()
+	retry, err := fasthttp.DefaultTransport.RoundTrip(hc, req, resp)
+
+	exchange := Exchange{
+		Time:           start,
+		Method:         string(req.Header.Method()),
+		URL:            req.URI().String(),
+		RequestHeaders: make(map[string]string),
+		Duration:       time.Since(start).String(),
+	}
+	for key, value := range req.Header.All() {
+		exchange.RequestHeaders[string(key)] = string(value)
+	}
+	if err != nil {
+		exchange.Error = err.Error()
+	} else {
+		exchange.Status = resp.StatusCode()
+		exchange.ResponseHeaders = make(map[string]string)
+		for key, value := range resp.Header.All() {
+			exchange.ResponseHeaders[string(key)] = string(value)
+		}
+		body, bodyErr := resp.BodyUncompressed()
+		if bodyErr != nil {
+			body = resp.Body()
+		}
+		exchange.Body = bodyText(string(resp.Header.ContentType()), body)
+	}
+	t.recorder.add(exchange)
+	return retry, err
+}
+
+// httpTransport records requests made through net/http clients
+type httpTransport struct {
+	recorder *Recorder
+	next     http.RoundTripper
+}
+
+// RoundTrip performs the request with the wrapped transport and records it.
+func (t httpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	exchange := Exchange{
+		Time:           start,
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestHeaders: make(map[string]string),
+		Duration:       time.Since(start).String(),
+	}
+	for key := range req.Header {
+		exchange.RequestHeaders[key] = req.Header.Get(key)
+	}
+	if err != nil {
+		exchange.Error = err.Error()
+		t.recorder.add(exchange)
+		return resp, err
+	}
+
+	exchange.Status = resp.StatusCode
+	exchange.ResponseHeaders = make(map[string]string)
+	for key := range resp.Header {
+		exchange.ResponseHeaders[key] = resp.Header.Get(key)
+	}
+	// Read the body to record it and hand the caller a copy
+	body, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if readErr != nil {
+		exchange.Error = readErr.Error()
+	}
+	exchange.Body = bodyText(resp.Header.Get("Content-Type"), body)
+	t.recorder.add(exchange)
+	return resp, nil
+}
+
+// WriteBundle writes the recorded exchanges and the info into a zip file.
+func (r *Recorder) WriteBundle(filename string, info map[string]string) error {
+	for key, value := range info {
+		info[key] = r.sanitizeText(value)
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(f)
+
+	files := []struct {
+		name  string
+		value interface{}
+	}{
+		{name: "info.json", value: info},
+		{name: "exchanges.json", value: r.Exchanges()},
+	}
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(file.value); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,153 @@
+package capture
+
+import (
+	"archive/zip"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeURL(t *testing.T) {
+	r := &Recorder{secrets: []string{"secret-unique-id"}}
+	tests := []struct {
+		name    string
+		url     string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "CDN token",
+			url:     "https://jiotvmblive.cdn.jio.com/bpk-tv/a/index.m3u8?__hdnea__=st=1~exp=2~hmac=abc",
+			want:    []string{"__hdnea__=REDACTED"},
+			notWant: []string{"hmac=abc"},
+		},
+		{
+			name:    "secret in path",
+			url:     "https://example.com/user/secret-unique-id/play?q=high",
+			want:    []string{"/user/REDACTED/play", "q=high"},
+			notWant: []string{"secret-unique-id"},
+		},
+		{
+			name: "nothing to redact",
+			url:  "https://example.com/play?id=143",
+			want: []string{"https://example.com/play?id=143"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := r.sanitizeURL(tt.url)
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("sanitizeURL() = %q, want it to contain %q", got, s)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(got, s) {
+					t.Errorf("sanitizeURL() = %q, should not contain %q", got, s)
+				}
+			}
+		})
+	}
+}
+
+func TestSanitizeText(t *testing.T) {
+	r := &Recorder{secrets: []string{"my-sso-token"}}
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "playlist token",
+			text: "#EXTINF:6,\nseg1.ts?hdntl=exp=1~hmac=abc\n",
+			want: "#EXTINF:6,\nseg1.ts?hdntl=REDACTED\n",
+		},
+		{
+			name: "JSON credentials",
+			text: `{"authToken": "abc", "refreshToken":"def", "name":"x"}`,
+			want: `{"authToken":"REDACTED", "refreshToken":"REDACTED", "name":"x"}`,
+		},
+		{
+			name: "secret value",
+			text: "ssotoken my-sso-token expired",
+			want: "ssotoken REDACTED expired",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.sanitizeText(tt.text); got != tt.want {
+				t.Errorf("sanitizeText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeHeaders(t *testing.T) {
+	r := &Recorder{}
+	got := r.sanitizeHeaders(map[string]string{
+		"accessToken":  "abc",
+		"Cookie":       "a=b",
+		"User-Agent":   "okhttp/4.2.2",
+		"deviceId":     "1234",
+		"Content-Type": "application/json",
+	})
+	for _, key := range []string{"accessToken", "Cookie", "deviceId"} {
+		if got[key] != redacted {
+			t.Errorf("header %s = %q, want %q", key, got[key], redacted)
+		}
+	}
+	if got["User-Agent"] != "okhttp/4.2.2" {
+		t.Errorf("User-Agent = %q, should be kept", got["User-Agent"])
+	}
+}
+
+func TestBodyText(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		want        string
+	}{
+		{name: "empty", contentType: "text/plain", body: nil, want: ""},
+		{name: "playlist", contentType: "application/vnd.apple.mpegurl", body: []byte("#EXTM3U"), want: "#EXTM3U"},
+		{name: "binary", contentType: "video/mp2t", body: []byte{0x47, 0x40}, want: "<2 bytes of video/mp2t>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bodyText(tt.contentType, tt.body); got != tt.want {
+				t.Errorf("bodyText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteBundle(t *testing.T) {
+	r := &Recorder{secrets: []string{"secret-crm-id"}}
+	r.add(Exchange{
+		Method:         "GET",
+		URL:            "https://example.com/?crm=secret-crm-id",
+		RequestHeaders: map[string]string{"crmid": "secret-crm-id"},
+		Status:         400,
+	})
+
+	path := filepath.Join(t.TempDir(), "bundle.zip")
+	if err := r.WriteBundle(path, map[string]string{"channel": "143"}); err != nil {
+		t.Fatalf("WriteBundle() error = %v", err)
+	}
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("failed to open bundle: %v", err)
+	}
+	defer zr.Close()
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	if strings.Join(names, ",") != "info.json,exchanges.json" {
+		t.Errorf("bundle files = %v, want [info.json exchanges.json]", names)
+	}
+	if exchanges := r.Exchanges(); strings.Contains(exchanges[0].URL, "secret-crm-id") {
+		t.Errorf("recorded URL %q contains a secret", exchanges[0].URL)
+	}
+}
@@ -0,0 +1,80 @@
+// Package cast finds Chromecasts and DLNA renderers on the local network and plays streams on them.
+// Devices are found with SSDP, Chromecasts are controlled with the Cast V2 protocol and DLNA renderers with UPnP SOAP actions.
+package cast
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// KindChromecast is the Kind of Chromecasts and Google TVs
+	KindChromecast = "chromecast"
+	// KindDLNA is the Kind of DLNA media renderers
+	KindDLNA = "dlna"
+	// controlTimeout is how long a command to a device may take
+	controlTimeout = 10 * time.Second
+)
+
+// ErrNoVolume is returned when a device does not support changing its volume
+var ErrNoVolume = errors.New("device does not support volume control")
+
+// httpClient is used for device descriptions and SOAP actions
+var httpClient = &http.Client{Timeout: controlTimeout}
+
+// Device is a renderer found by Discover.
+type Device struct {
+	// ID identifies the device across searches
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Kind  string `json:"kind"`
+	Model string `json:"model,omitempty"`
+
+	// addr is the host:port of the Cast V2 protocol of Chromecasts, and of the HTTP server of DLNA renderers
+	addr             string
+	avTransport      soapService
+	renderingControl soapService
+}
+
+// Play plays the HLS stream at streamURL on the device, replacing what it is playing.
+func Play(d Device, streamURL, title string) error {
+	if d.Kind == KindChromecast {
+		return chromecastPlay(d.addr, streamURL, title)
+	}
+	return dlnaPlay(d, streamURL, title)
+}
+
+// Stop stops playback on the device.
+func Stop(d Device) error {
+	if d.Kind == KindChromecast {
+		return chromecastStop(d.addr)
+	}
+	return dlnaStop(d)
+}
+
+// SetVolume sets the volume of the device, from 0 to 100.
+func SetVolume(d Device, level int) error {
+	level = min(max(level, 0), 100)
+	if d.Kind == KindChromecast {
+		return chromecastSetVolume(d.addr, level)
+	}
+	return dlnaSetVolume(d, level)
+}
+
+// sortDevices returns the devices sorted by name.
+func sortDevices(devices map[string]Device) []Device {
+	result := make([]Device, 0, len(devices))
+	for _, d := range devices {
+		result = append(result, d)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if a, b := strings.ToLower(result[i].Name), strings.ToLower(result[j].Name); a != b {
+			return a < b
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
@@ -0,0 +1,235 @@
+package cast
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseSSDPResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		location string
+		st       string
+		wantErr  bool
+	}{
+		{
+			name: "chromecast",
+			data: "HTTP/1.1 200 OK\r\nCACHE-CONTROL: max-age=1800\r\nLOCATION: http://192.168.1.5:8008/ssdp/device-desc.xml\r\n" +
+				"ST: urn:dial-multiscreen-org:service:dial:1\r\nUSN: uuid:abc::urn:dial-multiscreen-org:service:dial:1\r\n\r\n",
+			location: "http://192.168.1.5:8008/ssdp/device-desc.xml",
+			st:       stDIAL,
+		},
+		{
+			name:     "lowercase headers",
+			data:     "HTTP/1.1 200 OK\r\nlocation: http://192.168.1.6:49152/desc.xml\r\nst: " + stMediaRenderer + "\r\n\r\n",
+			location: "http://192.168.1.6:49152/desc.xml",
+			st:       stMediaRenderer,
+		},
+		{name: "no location", data: "HTTP/1.1 200 OK\r\nST: " + stMediaRenderer + "\r\n\r\n", wantErr: true},
+		{name: "notify", data: "NOTIFY * HTTP/1.1\r\nHOST: 239.255.255.250:1900\r\n\r\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := parseSSDPResponse([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSSDPResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (r.Location != tt.location || r.ST != tt.st) {
+				t.Errorf("parseSSDPResponse() = %+v, want location %q and ST %q", r, tt.location, tt.st)
+			}
+		})
+	}
+}
+
+const chromecastDescription = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <URLBase>http://192.168.1.5:8008</URLBase>
+  <device>
+    <deviceType>urn:dial-multiscreen-org:device:dial:1</deviceType>
+    <friendlyName>Living Room TV</friendlyName>
+    <manufacturer>Google Inc.</manufacturer>
+    <modelName>Chromecast</modelName>
+    <UDN>uuid:1234-abcd</UDN>
+  </device>
+</root>`
+
+const rendererDescription = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:MediaRenderer:1</deviceType>
+    <friendlyName>Bedroom TV</friendlyName>
+    <manufacturer>Samsung</manufacturer>
+    <modelName>UE43</modelName>
+    <UDN>uuid:5678</UDN>
+    <serviceList>
+      <service>
+        <serviceType>urn:schemas-upnp-org:service:RenderingControl:1</serviceType>
+        <controlURL>/upnp/control/RenderingControl1</controlURL>
+      </service>
+      <service>
+        <serviceType>urn:schemas-upnp-org:service:AVTransport:1</serviceType>
+        <controlURL>upnp/control/AVTransport1</controlURL>
+      </service>
+    </serviceList>
+  </device>
+</root>`
+
+func TestParseDescription(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		location string
+		st       string
+		want     Device
+		wantOK   bool
+	}{
+		{
+			name:     "chromecast",
+			data:     chromecastDescription,
+			location: "http://192.168.1.5:8008/ssdp/device-desc.xml",
+			st:       stDIAL,
+			want:     Device{ID: "chromecast-1234-abcd", Name: "Living Room TV", Kind: KindChromecast, Model: "Google Inc. Chromecast", addr: "192.168.1.5:8009"},
+			wantOK:   true,
+		},
+		{
+			name:     "renderer",
+			data:     rendererDescription,
+			location: "http://192.168.1.6:7676/dmr/desc.xml",
+			st:       stMediaRenderer,
+			want: Device{
+				ID: "dlna-5678", Name: "Bedroom TV", Kind: KindDLNA, Model: "Samsung UE43", addr: "192.168.1.6:7676",
+				avTransport:      soapService{Type: "urn:schemas-upnp-org:service:AVTransport:1", URL: "http://192.168.1.6:7676/dmr/upnp/control/AVTransport1"},
+				renderingControl: soapService{Type: "urn:schemas-upnp-org:service:RenderingControl:1", URL: "http://192.168.1.6:7676/upnp/control/RenderingControl1"},
+			},
+			wantOK: true,
+		},
+		{
+			name:     "DIAL smart TV",
+			data:     strings.Replace(rendererDescription, "Samsung", "LG", 1),
+			location: "http://192.168.1.7:1780/desc.xml",
+			st:       stDIAL,
+		},
+		{
+			name:     "renderer without AVTransport",
+			data:     chromecastDescription,
+			location: "http://192.168.1.5:8008/ssdp/device-desc.xml",
+			st:       stMediaRenderer,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := parseDescription([]byte(tt.data), tt.location, tt.st)
+			if err != nil {
+				t.Fatalf("parseDescription() error = %v", err)
+			}
+			if ok != tt.wantOK {
+				t.Fatalf("parseDescription() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && got != tt.want {
+				t.Errorf("parseDescription() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCastMessage(t *testing.T) {
+	msg := castMessage{SourceID: senderID, DestinationID: receiverID, Namespace: nsReceiver, Payload: `{"type":"GET_STATUS","requestId":1}`}
+	got, err := unmarshalCastMessage(msg.marshal())
+	if err != nil {
+		t.Fatalf("unmarshalCastMessage() error = %v", err)
+	}
+	if got != msg {
+		t.Errorf("unmarshalCastMessage(marshal()) = %+v, want %+v", got, msg)
+	}
+
+	// A payload longer than 127 bytes has a two byte length
+	msg.Payload = strings.Repeat("x", 300)
+	if got, err := unmarshalCastMessage(msg.marshal()); err != nil || got != msg {
+		t.Errorf("unmarshalCastMessage() of a long payload = %+v, %v", got, err)
+	}
+
+	if _, err := unmarshalCastMessage(msg.marshal()[:20]); err == nil {
+		t.Error("unmarshalCastMessage() of a truncated message returned no error")
+	}
+}
+
+func TestDLNA(t *testing.T) {
+	type call struct {
+		action string
+		body   string
+	}
+	var calls []call
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		action := r.Header.Get("SOAPAction")
+		calls = append(calls, call{action: action, body: string(body)})
+		if strings.Contains(action, "#SetVolume") {
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><s:Fault><detail>`+
+				`<UPnPError xmlns="urn:schemas-upnp-org:control-1-0"><errorCode>501</errorCode><errorDescription>Action Failed</errorDescription></UPnPError>`+
+				`</detail></s:Fault></s:Body></s:Envelope>`)
+		}
+	}))
+	defer server.Close()
+
+	d := Device{
+		Kind:             KindDLNA,
+		avTransport:      soapService{Type: "urn:schemas-upnp-org:service:AVTransport:1", URL: server.URL + "/av"},
+		renderingControl: soapService{Type: "urn:schemas-upnp-org:service:RenderingControl:1", URL: server.URL + "/rc"},
+	}
+	if err := Play(d, "http://192.168.1.2:5001/live/143.m3u8?a=1&b=2", "News & More"); err != nil {
+		t.Fatalf("Play() error = %v", err)
+	}
+	wantActions := []string{
+		`"urn:schemas-upnp-org:service:AVTransport:1#Stop"`,
+		`"urn:schemas-upnp-org:service:AVTransport:1#SetAVTransportURI"`,
+		`"urn:schemas-upnp-org:service:AVTransport:1#Play"`,
+	}
+	if len(calls) != len(wantActions) {
+		t.Fatalf("Play() made %d calls, want %d", len(calls), len(wantActions))
+	}
+	for i, want := range wantActions {
+		if calls[i].action != want {
+			t.Errorf("call %d SOAPAction = %s, want %s", i, calls[i].action, want)
+		}
+	}
+
+	// The URI and its metadata are escaped XML text of the action
+	var setURI struct {
+		URI      string `xml:"Body>SetAVTransportURI>CurrentURI"`
+		Metadata string `xml:"Body>SetAVTransportURI>CurrentURIMetaData"`
+	}
+	if err := xml.Unmarshal([]byte(calls[1].body), &setURI); err != nil {
+		t.Fatalf("SetAVTransportURI body is not XML: %v", err)
+	}
+	if setURI.URI != "http://192.168.1.2:5001/live/143.m3u8?a=1&b=2" {
+		t.Errorf("CurrentURI = %q", setURI.URI)
+	}
+	var metadata struct {
+		Title string `xml:"item>title"`
+		Res   string `xml:"item>res"`
+	}
+	if err := xml.Unmarshal([]byte(setURI.Metadata), &metadata); err != nil {
+		t.Fatalf("CurrentURIMetaData is not XML: %v", err)
+	}
+	if metadata.Title != "News & More" || metadata.Res != setURI.URI {
+		t.Errorf("CurrentURIMetaData = %+v", metadata)
+	}
+
+	err := SetVolume(d, 150)
+	if err == nil || !strings.Contains(err.Error(), "Action Failed") {
+		t.Errorf("SetVolume() error = %v, want the UPnP error", err)
+	}
+	if body := calls[len(calls)-1].body; !strings.Contains(body, "<DesiredVolume>100</DesiredVolume>") {
+		t.Errorf("SetVolume(150) body = %s, want volume 100", body)
+	}
+
+	if err := SetVolume(Device{Kind: KindDLNA}, 50); err != ErrNoVolume {
+		t.Errorf("SetVolume() without RenderingControl error = %v, want ErrNoVolume", err)
+	}
+}
@@ -0,0 +1,283 @@
+package cast
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+const (
+	// defaultReceiverApp is the app ID of the Default Media Receiver of Chromecasts
+	defaultReceiverApp = "CC1AD845"
+	// maxMessageSize is the largest Cast V2 message accepted
+	maxMessageSize = 64 << 10
+
+	nsConnection = "urn:x-cast:com.google.cast.tp.connection"
+	nsHeartbeat  = "urn:x-cast:com.google.cast.tp.heartbeat"
+	nsReceiver   = "urn:x-cast:com.google.cast.receiver"
+	nsMedia      = "urn:x-cast:com.google.cast.media"
+
+	senderID   = "sender-0"
+	receiverID = "receiver-0"
+)
+
+// castMessage is the CastMessage protobuf of the Cast V2 protocol, with a UTF-8 payload
+type castMessage struct {
+	SourceID      string
+	DestinationID string
+	Namespace     string
+	Payload       string
+}
+
+// Field tags of CastMessage, as field number << 3 | wire type
+const (
+	tagProtocolVersion = 1<<3 | 0
+	tagSourceID        = 2<<3 | 2
+	tagDestinationID   = 3<<3 | 2
+	tagNamespace       = 4<<3 | 2
+	tagPayloadType     = 5<<3 | 0
+	tagPayloadUTF8     = 6<<3 | 2
+)
+
+// marshal encodes the message as a protobuf.
+func (m castMessage) marshal() []byte {
+	b := []byte{tagProtocolVersion, 0}
+	for _, field := range []struct {
+		tag   byte
+		value string
+	}{{tagSourceID, m.SourceID}, {tagDestinationID, m.DestinationID}, {tagNamespace, m.Namespace}} {
+		b = append(b, field.tag)
+		b = binary.AppendUvarint(b, uint64(len(field.value)))
+		b = append(b, field.value...)
+	}
+	b = append(b, tagPayloadType, 0)
+	b = append(b, tagPayloadUTF8)
+	b = binary.AppendUvarint(b, uint64(len(m.Payload)))
+	return append(b, m.Payload...)
+}
+
+// unmarshalCastMessage decodes a CastMessage protobuf. Binary payloads and unknown fields are skipped.
+func unmarshalCastMessage(b []byte) (castMessage, error) {
+	var m castMessage
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return m, errors.New("invalid cast message")
+		}
+		b = b[n:]
+		switch key & 7 {
+		case 0:
+			if _, n = binary.Uvarint(b); n <= 0 {
+				return m, errors.New("invalid cast message")
+			}
+			b = b[n:]
+		case 2:
+			length, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < length {
+				return m, errors.New("invalid cast message")
+			}
+			value := string(b[n : n+int(length)])
+			b = b[n+int(length):]
+			switch key {
+			case tagSourceID:
+				m.SourceID = value
+			case tagDestinationID:
+				m.DestinationID = value
+			case tagNamespace:
+				m.Namespace = value
+			case tagPayloadUTF8:
+				m.Payload = value
+			}
+		default:
+			return m, fmt.Errorf("unsupported wire type %d in cast message", key&7)
+		}
+	}
+	return m, nil
+}
+
+// castConn is a Cast V2 connection to a Chromecast
+type castConn struct {
+	conn      net.Conn
+	requestID int
+}
+
+// dialChromecast connects to the Cast V2 port of a Chromecast, and to its receiver.
+func dialChromecast(addr string) (*castConn, error) {
+	dialer := &net.Dialer{Timeout: controlTimeout}
+	// Chromecasts use self-signed certificates
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(controlTimeout))
+	c := &castConn{conn: conn}
+	if err := c.send(receiverID, nsConnection, map[string]any{"type": "CONNECT"}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *castConn) Close() error {
+	c.send(receiverID, nsConnection, map[string]any{"type": "CLOSE"})
+	return c.conn.Close()
+}
+
+// send sends a JSON payload to destination.
+func (c *castConn) send(destination, namespace string, payload map[string]any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	msg := castMessage{SourceID: senderID, DestinationID: destination, Namespace: namespace, Payload: string(data)}.marshal()
+	frame := binary.BigEndian.AppendUint32(nil, uint32(len(msg)))
+	_, err = c.conn.Write(append(frame, msg...))
+	return err
+}
+
+// read reads the next message.
+func (c *castConn) read() (castMessage, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(c.conn, header[:]); err != nil {
+		return castMessage{}, err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if size > maxMessageSize {
+		return castMessage{}, fmt.Errorf("cast message of %d bytes is too large", size)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(c.conn, body); err != nil {
+		return castMessage{}, err
+	}
+	return unmarshalCastMessage(body)
+}
+
+// castResponse is the part of receiver and media responses that is used
+type castResponse struct {
+	Type      string `json:"type"`
+	RequestID int    `json:"requestId"`
+	Reason    string `json:"reason"`
+	Status    struct {
+		Applications []struct {
+			AppID       string `json:"appId"`
+			SessionID   string `json:"sessionId"`
+			TransportID string `json:"transportId"`
+		} `json:"applications"`
+	} `json:"status"`
+}
+
+// request sends a request to destination and waits for its response. Heartbeats are answered while waiting.
+func (c *castConn) request(destination, namespace string, payload map[string]any) (castResponse, error) {
+	c.requestID++
+	payload["requestId"] = c.requestID
+	if err := c.send(destination, namespace, payload); err != nil {
+		return castResponse{}, err
+	}
+	for {
+		msg, err := c.read()
+		if err != nil {
+			return castResponse{}, err
+		}
+		if msg.Namespace == nsHeartbeat {
+			if err := c.send(msg.SourceID, nsHeartbeat, map[string]any{"type": "PONG"}); err != nil {
+				return castResponse{}, err
+			}
+			continue
+		}
+		var resp castResponse
+		if err := json.Unmarshal([]byte(msg.Payload), &resp); err != nil || resp.RequestID != c.requestID {
+			continue
+		}
+		switch resp.Type {
+		case "LAUNCH_ERROR", "LOAD_FAILED", "LOAD_CANCELLED", "INVALID_REQUEST", "INVALID_PLAYER_STATE":
+			if resp.Reason != "" {
+				return resp, fmt.Errorf("chromecast: %s: %s", resp.Type, resp.Reason)
+			}
+			return resp, fmt.Errorf("chromecast: %s", resp.Type)
+		}
+		return resp, nil
+	}
+}
+
+// app returns the session and transport of the running receiver app, if it is appID.
+func (r castResponse) app(appID string) (sessionID, transportID string, ok bool) {
+	for _, app := range r.Status.Applications {
+		if appID == "" || app.AppID == appID {
+			return app.SessionID, app.TransportID, true
+		}
+	}
+	return "", "", false
+}
+
+// chromecastPlay launches the Default Media Receiver on a Chromecast and loads the stream in it.
+func chromecastPlay(addr, streamURL, title string) error {
+	c, err := dialChromecast(addr)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	resp, err := c.request(receiverID, nsReceiver, map[string]any{"type": "LAUNCH", "appId": defaultReceiverApp})
+	if err != nil {
+		return err
+	}
+	_, transportID, ok := resp.app(defaultReceiverApp)
+	if !ok {
+		return errors.New("chromecast: the media receiver did not start")
+	}
+	if err := c.send(transportID, nsConnection, map[string]any{"type": "CONNECT"}); err != nil {
+		return err
+	}
+	_, err = c.request(transportID, nsMedia, map[string]any{
+		"type":     "LOAD",
+		"autoplay": true,
+		"media": map[string]any{
+			"contentId":   streamURL,
+			"contentType": "application/x-mpegurl",
+			"streamType":  "LIVE",
+			"metadata":    map[string]any{"metadataType": 0, "title": title},
+		},
+	})
+	return err
+}
+
+// chromecastStop quits the app running on a Chromecast.
+func chromecastStop(addr string) error {
+	c, err := dialChromecast(addr)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	resp, err := c.request(receiverID, nsReceiver, map[string]any{"type": "GET_STATUS"})
+	if err != nil {
+		return err
+	}
+	sessionID, _, ok := resp.app("")
+	if !ok {
+		// Nothing is playing
+		return nil
+	}
+	_, err = c.request(receiverID, nsReceiver, map[string]any{"type": "STOP", "sessionId": sessionID})
+	return err
+}
+
+// chromecastSetVolume sets the volume of a Chromecast.
+func chromecastSetVolume(addr string, level int) error {
+	c, err := dialChromecast(addr)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	_, err = c.request(receiverID, nsReceiver, map[string]any{
+		"type":   "SET_VOLUME",
+		"volume": map[string]any{"level": float64(level) / 100},
+	})
+	return err
+}
@@ -0,0 +1,105 @@
+package cast
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// soapService is a UPnP service controlled with SOAP actions
+type soapService struct {
+	// Type is the service type, e.g. "urn:schemas-upnp-org:service:AVTransport:1"
+	Type string
+	URL  string
+}
+
+// soapFault is the UPnP error of a failed action
+type soapFault struct {
+	Code        int    `xml:"Body>Fault>detail>UPnPError>errorCode"`
+	Description string `xml:"Body>Fault>detail>UPnPError>errorDescription"`
+}
+
+// call invokes a SOAP action of the service. args are the ordered name and value pairs of the action.
+func (s soapService) call(action string, args ...string) error {
+	if s.URL == "" {
+		return fmt.Errorf("dlna: device has no service for %s", action)
+	}
+	var body bytes.Buffer
+	body.WriteString(`<?xml version="1.0" encoding="utf-8"?>` +
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body>`)
+	fmt.Fprintf(&body, `<u:%s xmlns:u="%s">`, action, s.Type)
+	for i := 0; i+1 < len(args); i += 2 {
+		fmt.Fprintf(&body, "<%s>", args[i])
+		xml.EscapeText(&body, []byte(args[i+1]))
+		fmt.Fprintf(&body, "</%s>", args[i])
+	}
+	fmt.Fprintf(&body, "</u:%s></s:Body></s:Envelope>", action)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", strconv.Quote(s.Type+"#"+action))
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var fault soapFault
+	if xml.Unmarshal(data, &fault) == nil && fault.Description != "" {
+		return fmt.Errorf("dlna: %s failed: %s (%d)", action, fault.Description, fault.Code)
+	}
+	return fmt.Errorf("dlna: %s failed with status %d", action, resp.StatusCode)
+}
+
+// didl returns the DIDL-Lite metadata of an HLS stream, which some renderers need to play it.
+func didl(streamURL, title string) string {
+	var b strings.Builder
+	b.WriteString(`<DIDL-Lite xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/">`)
+	b.WriteString(`<item id="0" parentID="-1" restricted="1"><dc:title>`)
+	xml.EscapeText(&b, []byte(title))
+	b.WriteString(`</dc:title><upnp:class>object.item.videoItem</upnp:class><res protocolInfo="http-get:*:application/vnd.apple.mpegurl:*">`)
+	xml.EscapeText(&b, []byte(streamURL))
+	b.WriteString(`</res></item></DIDL-Lite>`)
+	return b.String()
+}
+
+// dlnaPlay sets the stream as the current media of a DLNA renderer and plays it.
+func dlnaPlay(d Device, streamURL, title string) error {
+	// Some renderers refuse a new URI while playing
+	d.avTransport.call("Stop", "InstanceID", "0")
+	if err := d.avTransport.call("SetAVTransportURI",
+		"InstanceID", "0",
+		"CurrentURI", streamURL,
+		"CurrentURIMetaData", didl(streamURL, title),
+	); err != nil {
+		return err
+	}
+	return d.avTransport.call("Play", "InstanceID", "0", "Speed", "1")
+}
+
+// dlnaStop stops a DLNA renderer.
+func dlnaStop(d Device) error {
+	return d.avTransport.call("Stop", "InstanceID", "0")
+}
+
+// dlnaSetVolume sets the master volume of a DLNA renderer.
+func dlnaSetVolume(d Device, level int) error {
+	if d.renderingControl.URL == "" {
+		return ErrNoVolume
+	}
+	return d.renderingControl.call("SetVolume",
+		"InstanceID", "0",
+		"Channel", "Master",
+		"DesiredVolume", strconv.Itoa(level),
+	)
+}
@@ -0,0 +1,265 @@
+package cast
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ssdpAddr is the multicast address of SSDP
+	ssdpAddr = "239.255.255.250:1900"
+	// stDIAL is the search target of DIAL devices, which include Chromecasts
+	stDIAL = "urn:dial-multiscreen-org:service:dial:1"
+	// stMediaRenderer is the search target of DLNA renderers
+	stMediaRenderer = "urn:schemas-upnp-org:device:MediaRenderer:1"
+	// chromecastPort is the port of the Cast V2 protocol
+	chromecastPort = "8009"
+	// descriptionTimeout is how long fetching a device description may take
+	descriptionTimeout = 3 * time.Second
+)
+
+// ssdpResponse is a response to an SSDP search
+type ssdpResponse struct {
+	Location string
+	ST       string
+	USN      string
+}
+
+// parseSSDPResponse parses the HTTP over UDP response of a device to an M-SEARCH request.
+func parseSSDPResponse(data []byte) (ssdpResponse, error) {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), nil)
+	if err != nil {
+		return ssdpResponse{}, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ssdpResponse{}, fmt.Errorf("SSDP response status %d", resp.StatusCode)
+	}
+	r := ssdpResponse{
+		Location: resp.Header.Get("Location"),
+		ST:       resp.Header.Get("ST"),
+		USN:      resp.Header.Get("USN"),
+	}
+	if r.Location == "" {
+		return ssdpResponse{}, fmt.Errorf("SSDP response without location")
+	}
+	return r, nil
+}
+
+// searchRequest returns the M-SEARCH request for the search target st.
+func searchRequest(st string, timeout time.Duration) []byte {
+	mx := max(int(timeout/time.Second), 1)
+	return []byte("M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: " + ssdpAddr + "\r\n" +
+		"MAN: \"ssdp:discover\"\r\n" +
+		fmt.Sprintf("MX: %d\r\n", mx) +
+		"ST: " + st + "\r\n\r\n")
+}
+
+// search sends SSDP searches for Chromecasts and DLNA renderers and collects the responses until timeout.
+func search(ctx context.Context, timeout time.Duration) ([]ssdpResponse, error) {
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	group, err := net.ResolveUDPAddr("udp4", ssdpAddr)
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range []string{stDIAL, stMediaRenderer} {
+		if _, err := conn.WriteToUDP(searchRequest(st, timeout), group); err != nil {
+			return nil, err
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetReadDeadline(deadline)
+	var responses []ssdpResponse
+	buf := make([]byte, 8192)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			// The deadline ends the search
+			break
+		}
+		if r, err := parseSSDPResponse(buf[:n]); err == nil {
+			responses = append(responses, r)
+		}
+	}
+	return responses, nil
+}
+
+// description is a UPnP device description
+type description struct {
+	URLBase string     `xml:"URLBase"`
+	Device  descDevice `xml:"device"`
+}
+
+type descDevice struct {
+	DeviceType   string        `xml:"deviceType"`
+	FriendlyName string        `xml:"friendlyName"`
+	Manufacturer string        `xml:"manufacturer"`
+	ModelName    string        `xml:"modelName"`
+	UDN          string        `xml:"UDN"`
+	Services     []descService `xml:"serviceList>service"`
+	Devices      []descDevice  `xml:"deviceList>device"`
+}
+
+type descService struct {
+	ServiceType string `xml:"serviceType"`
+	ControlURL  string `xml:"controlURL"`
+}
+
+// service finds a service by the prefix of its type in the device or its embedded devices.
+func (d descDevice) service(prefix string) (descService, bool) {
+	for _, s := range d.Services {
+		if strings.HasPrefix(s.ServiceType, prefix) {
+			return s, true
+		}
+	}
+	for _, child := range d.Devices {
+		if s, ok := child.service(prefix); ok {
+			return s, true
+		}
+	}
+	return descService{}, false
+}
+
+// parseDescription turns the device description fetched from location into a Device.
+// It reports false for devices that cannot be cast to.
+func parseDescription(data []byte, location string, st string) (Device, bool, error) {
+	var desc description
+	if err := xml.Unmarshal(data, &desc); err != nil {
+		return Device{}, false, err
+	}
+	base, err := url.Parse(location)
+	if err != nil {
+		return Device{}, false, err
+	}
+	if desc.URLBase != "" {
+		if u, err := url.Parse(desc.URLBase); err == nil {
+			base = u
+		}
+	}
+
+	d := Device{
+		Name:  strings.TrimSpace(desc.Device.FriendlyName),
+		Model: strings.TrimSpace(desc.Device.Manufacturer + " " + desc.Device.ModelName),
+	}
+	if d.Name == "" {
+		d.Name = base.Hostname()
+	}
+	udn := strings.TrimPrefix(strings.TrimSpace(desc.Device.UDN), "uuid:")
+	if udn == "" {
+		udn = base.Host
+	}
+
+	if st == stDIAL {
+		// Only Chromecasts speak Cast V2, other DIAL devices like smart TVs are found as DLNA renderers
+		if base.Port() != "8008" && !strings.EqualFold(strings.TrimSpace(desc.Device.Manufacturer), "Google Inc.") {
+			return Device{}, false, nil
+		}
+		d.Kind = KindChromecast
+		d.ID = KindChromecast + "-" + udn
+		d.addr = net.JoinHostPort(base.Hostname(), chromecastPort)
+		return d, true, nil
+	}
+
+	transport, ok := desc.Device.service("urn:schemas-upnp-org:service:AVTransport:")
+	if !ok {
+		return Device{}, false, nil
+	}
+	d.Kind = KindDLNA
+	d.ID = KindDLNA + "-" + udn
+	d.addr = base.Host
+	d.avTransport = soapService{Type: transport.ServiceType, URL: resolve(base, transport.ControlURL)}
+	if rendering, ok := desc.Device.service("urn:schemas-upnp-org:service:RenderingControl:"); ok {
+		d.renderingControl = soapService{Type: rendering.ServiceType, URL: resolve(base, rendering.ControlURL)}
+	}
+	return d, true, nil
+}
+
+// resolve resolves a control URL against the base URL of its description.
+func resolve(base *url.URL, ref string) string {
+	u, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(u).String()
+}
+
+// fetchDescription downloads the device description of an SSDP response.
+func fetchDescription(ctx context.Context, r ssdpResponse) (Device, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, descriptionTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.Location, nil)
+	if err != nil {
+		return Device{}, false, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return Device{}, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Device{}, false, fmt.Errorf("device description %s returned status %d", r.Location, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return Device{}, false, err
+	}
+	return parseDescription(data, r.Location, r.ST)
+}
+
+// Discover searches the local network for Chromecasts and DLNA renderers for up to timeout.
+// The devices are sorted by name.
+func Discover(timeout time.Duration) ([]Device, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout+descriptionTimeout)
+	defer cancel()
+	responses, err := search(ctx, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	// A device answers once per search target and network interface, so fetch each description once
+	seen := map[string]bool{}
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		devices = map[string]Device{}
+	)
+	for _, r := range responses {
+		key := r.ST + " " + r.Location
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		wg.Add(1)
+		go func(r ssdpResponse) {
+			defer wg.Done()
+			d, ok, err := fetchDescription(ctx, r)
+			if err != nil || !ok {
+				return
+			}
+			mu.Lock()
+			devices[d.ID] = d
+			mu.Unlock()
+		}(r)
+	}
+	wg.Wait()
+	return sortDevices(devices), nil
+}
@@ -0,0 +1,271 @@
+// Package cdntoken parses, attaches and caches the Akamai tokens that CDNs sign stream URLs with:
+// hdnea of the JioTV CDN and hdntl of the Zee5 CDN. A token arrives as a query parameter of a stream
+// URL or in a Set-Cookie header, and is sent back as a cookie or query parameter.
+package cdntoken
+
+import (
+	"bytes"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Kind is a kind of CDN token, by the names it is sent with
+type Kind struct {
+	// Param is the query parameter of the token, e.g. "hdnea"
+	Param string
+	// Cookie is the cookie of the token, e.g. "__hdnea__". As a query parameter, it is preferred
+	// over Param, as the CDN sets it to rotate the token.
+	Cookie string
+}
+
+var (
+	// HDNEA is the token of the JioTV CDN
+	HDNEA = Kind{Param: "hdnea", Cookie: "__hdnea__"}
+	// HDNTL is the token of the Zee5 CDN
+	HDNTL = Kind{Param: "hdntl", Cookie: "hdntl"}
+)
+
+// textPatterns match the tokens of HDNEA and HDNTL in a text, see FromText
+var textPatterns = map[Kind]*regexp.Regexp{
+	HDNEA: textPattern(HDNEA),
+	HDNTL: textPattern(HDNTL),
+}
+
+// textPattern returns the pattern of a token in a text, e.g. "hdntl=exp=1~hmac=abc".
+func textPattern(k Kind) *regexp.Regexp {
+	return regexp.MustCompile(regexp.QuoteMeta(k.Param) + `=([^\s"]+)`)
+}
+
+// expiryPattern matches the expiry of a token, e.g. "exp=1700000000~acl=/*~hmac=..."
+var expiryPattern = regexp.MustCompile(`exp=([0-9]+)`)
+
+// names returns the names of the token, the cookie name first.
+func (k Kind) names() []string {
+	if k.Cookie == k.Param {
+		return []string{k.Param}
+	}
+	return []string{k.Cookie, k.Param}
+}
+
+// FromQuery returns the token of a raw query string, or "".
+func (k Kind) FromQuery(query string) string {
+	values, err := url.ParseQuery(query)
+	if err != nil && len(values) == 0 {
+		return ""
+	}
+	for _, name := range k.names() {
+		if token := values.Get(name); token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
+// FromURL returns the token in the query of a URL, or "".
+func (k Kind) FromURL(rawURL string) string {
+	_, query, ok := strings.Cut(rawURL, "?")
+	if !ok {
+		return ""
+	}
+	query, _, _ = strings.Cut(query, "#")
+	return k.FromQuery(query)
+}
+
+// FromSetCookie returns the token of a Set-Cookie header, or "".
+func (k Kind) FromSetCookie(setCookie []byte) string {
+	for _, part := range bytes.Split(setCookie, []byte(";")) {
+		trimmed := bytes.TrimSpace(part)
+		for _, name := range k.names() {
+			if value, ok := bytes.CutPrefix(trimmed, []byte(name+"=")); ok {
+				return string(value)
+			}
+		}
+	}
+	return ""
+}
+
+// FromResponse returns the token that the CDN set with a Set-Cookie header of the response, or "".
+func (k Kind) FromResponse(header *fasthttp.ResponseHeader) string {
+	var token string
+	for _, setCookie := range header.PeekAll(fasthttp.HeaderSetCookie) {
+		if token = k.FromSetCookie(setCookie); token != "" {
+			break
+		}
+	}
+	return token
+}
+
+// FromText returns the first token in a text, like a playlist whose URLs carry the token, or "".
+func (k Kind) FromText(text string) string {
+	pattern, ok := textPatterns[k]
+	if !ok {
+		pattern = textPattern(k)
+	}
+	if matches := pattern.FindStringSubmatch(text); len(matches) == 2 {
+		return matches[1]
+	}
+	return ""
+}
+
+// Strip returns the URL without its tokens.
+func (k Kind) Strip(rawURL string) string {
+	if rawURL == "" {
+		return rawURL
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := parsed.Query()
+	for _, name := range k.names() {
+		query.Del(name)
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+// Attach adds the token to a URL as Param, unless the URL has a token already.
+func (k Kind) Attach(rawURL, token string) string {
+	if rawURL == "" || token == "" || k.FromURL(rawURL) != "" {
+		return rawURL
+	}
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+	return rawURL + sep + k.Param + "=" + token
+}
+
+// SetQuery returns a raw query string with token as the only token, as the Cookie parameter. A query
+// that cannot be parsed is returned with the token added.
+func (k Kind) SetQuery(query, token string) string {
+	if query != "" {
+		if values, err := url.ParseQuery(query); err == nil {
+			for _, name := range k.names() {
+				values.Del(name)
+			}
+			query = values.Encode()
+		}
+	}
+	if token == "" {
+		return query
+	}
+	if query == "" {
+		return k.Cookie + "=" + token
+	}
+	return query + "&" + k.Cookie + "=" + token
+}
+
+// SetCookie sends the token as the cookie of a request, if there is a token.
+func (k Kind) SetCookie(header *fasthttp.RequestHeader, token string) {
+	if token != "" {
+		header.SetCookie(k.Cookie, token)
+	}
+}
+
+// Remaining returns how long a token is valid, and false if it has no expiry.
+func Remaining(token string) (time.Duration, bool) {
+	if token == "" {
+		return 0, false
+	}
+	if decoded, err := url.QueryUnescape(token); err == nil && decoded != "" {
+		token = decoded
+	}
+	matches := expiryPattern.FindStringSubmatch(token)
+	if len(matches) != 2 {
+		return 0, false
+	}
+	expiry, err := strconv.ParseInt(matches[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Until(time.Unix(expiry, 0)), true
+}
+
+// Expired reports whether a token has an expiry that has passed.
+func Expired(token string) bool {
+	remaining, ok := Remaining(token)
+	return ok && remaining <= 0
+}
+
+// DefaultCacheTTL is how long a cached token is used, short to avoid reusing tokens that the CDN rotated
+const DefaultCacheTTL = 20 * time.Second
+
+// Cache holds the latest token of each channel. The zero value is ready to use.
+type Cache struct {
+	// TTL is how long a token is used after it was cached, DefaultCacheTTL if zero
+	TTL time.Duration
+
+	entries sync.Map
+	// renewLocks holds a *sync.Mutex per channel ID. It serializes Renew of a channel, so that
+	// requests refused at once share one new token, without waiting for the renewal of other channels.
+	renewLocks sync.Map
+}
+
+type cacheEntry struct {
+	token   string
+	updated time.Time
+}
+
+// ttl returns how long tokens are kept.
+func (c *Cache) ttl() time.Duration {
+	if c.TTL > 0 {
+		return c.TTL
+	}
+	return DefaultCacheTTL
+}
+
+// Get returns the cached token of a channel, if it is recent enough.
+func (c *Cache) Get(channelID string) string {
+	if channelID == "" {
+		return ""
+	}
+	raw, ok := c.entries.Load(channelID)
+	if !ok {
+		return ""
+	}
+	entry := raw.(cacheEntry)
+	if time.Since(entry.updated) > c.ttl() {
+		c.entries.Delete(channelID)
+		return ""
+	}
+	return entry.token
+}
+
+// Set caches the token of a channel.
+func (c *Cache) Set(channelID, token string) {
+	if channelID == "" || token == "" {
+		return
+	}
+	c.entries.Store(channelID, cacheEntry{token: token, updated: time.Now()})
+}
+
+// Delete removes the token of a channel.
+func (c *Cache) Delete(channelID string) {
+	c.entries.Delete(channelID)
+}
+
+// Renew returns a new token of a channel after the CDN refused the given token. If another request
+// renewed the token meanwhile, that token is returned. Otherwise mint is called for a new token,
+// which is cached. minted reports whether mint was called.
+func (c *Cache) Renew(channelID, refused string, mint func() (string, error)) (token string, minted bool, err error) {
+	lock, _ := c.renewLocks.LoadOrStore(channelID, &sync.Mutex{})
+	renewMu := lock.(*sync.Mutex)
+	renewMu.Lock()
+	defer renewMu.Unlock()
+	if cached := c.Get(channelID); cached != "" && cached != refused && !Expired(cached) {
+		return cached, false, nil
+	}
+	token, err = mint()
+	if err != nil {
+		return "", true, err
+	}
+	c.Set(channelID, token)
+	return token, true, nil
+}
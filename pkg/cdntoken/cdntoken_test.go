@@ -0,0 +1,281 @@
+package cdntoken
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestFromURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		kind  Kind
+		input string
+		want  string
+	}{
+		{"hdnea parameter", HDNEA, "https://example.com/index.m3u8?hdnea=exp%3D1~hmac%3Dabc", "exp=1~hmac=abc"},
+		{"prefers __hdnea__", HDNEA, "https://example.com/index.m3u8?hdnea=old&__hdnea__=new", "new"},
+		{"ignores the fragment", HDNEA, "https://example.com/index.m3u8?hdnea=abc#t=10", "abc"},
+		{"no query", HDNEA, "https://example.com/index.m3u8", ""},
+		{"no token", HDNEA, "https://example.com/index.m3u8?foo=bar", ""},
+		{"hdntl parameter", HDNTL, "https://example.com/index.m3u8?hdntl=abc", "abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.kind.FromURL(tt.input); got != tt.want {
+				t.Errorf("FromURL(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromSetCookie(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"cookie", "__hdnea__=exp=1~hmac=abc; Path=/; Secure", "exp=1~hmac=abc"},
+		{"parameter name", "hdnea=abc; Path=/", "abc"},
+		{"other cookie", "session=abc; Path=/", ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HDNEA.FromSetCookie([]byte(tt.input)); got != tt.want {
+				t.Errorf("FromSetCookie(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromResponse(t *testing.T) {
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+	resp.Header.Add(fasthttp.HeaderSetCookie, "session=abc; Path=/")
+	resp.Header.Add(fasthttp.HeaderSetCookie, "__hdnea__=exp=1~hmac=abc; Path=/")
+
+	if got := HDNEA.FromResponse(&resp.Header); got != "exp=1~hmac=abc" {
+		t.Errorf("FromResponse() = %q, want the token of the second Set-Cookie", got)
+	}
+	if got := HDNTL.FromResponse(&resp.Header); got != "" {
+		t.Errorf("HDNTL.FromResponse() = %q, want none", got)
+	}
+}
+
+func TestFromText(t *testing.T) {
+	playlist := "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1\nindex_1.m3u8?hdntl=exp=1~hmac=abc\n"
+	if got := HDNTL.FromText(playlist); got != "exp=1~hmac=abc" {
+		t.Errorf("FromText() = %q, want the token of the first URL", got)
+	}
+	if got := HDNTL.FromText("#EXTM3U\nindex_1.m3u8\n"); got != "" {
+		t.Errorf("FromText() without a token = %q, want none", got)
+	}
+	other := Kind{Param: "token", Cookie: "token"}
+	if got := other.FromText("index_1.m3u8?token=abc"); got != "abc" {
+		t.Errorf("FromText() of another kind = %q, want abc", got)
+	}
+}
+
+func TestStrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "removes hdnea query parameter",
+			input: "https://example.com/video.mpd?foo=bar&hdnea=abc123&baz=qux",
+			want:  "https://example.com/video.mpd?baz=qux&foo=bar",
+		},
+		{
+			name:  "removes __hdnea__ query parameter",
+			input: "https://example.com/video.mpd?foo=bar&__hdnea__=abc123",
+			want:  "https://example.com/video.mpd?foo=bar",
+		},
+		{
+			name:  "returns original url without hdnea",
+			input: "https://example.com/video.mpd?foo=bar",
+			want:  "https://example.com/video.mpd?foo=bar",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HDNEA.Strip(tt.input); got != tt.want {
+				t.Errorf("Strip() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttach(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		token string
+		want  string
+	}{
+		{"without query", "https://example.com/index.m3u8", "abc", "https://example.com/index.m3u8?hdnea=abc"},
+		{"with query", "https://example.com/index.m3u8?foo=bar", "abc", "https://example.com/index.m3u8?foo=bar&hdnea=abc"},
+		{"keeps the token of the URL", "https://example.com/index.m3u8?__hdnea__=old", "abc", "https://example.com/index.m3u8?__hdnea__=old"},
+		{"no token", "https://example.com/index.m3u8", "", "https://example.com/index.m3u8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HDNEA.Attach(tt.input, tt.token); got != tt.want {
+				t.Errorf("Attach(%q, %q) = %q, want %q", tt.input, tt.token, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		token string
+		want  string
+	}{
+		{"replaces the tokens", "foo=bar&hdnea=old&__hdnea__=old", "new", "foo=bar&__hdnea__=new"},
+		{"empty query", "", "new", "__hdnea__=new"},
+		{"removes the tokens without a new one", "foo=bar&hdnea=old", "", "foo=bar"},
+		{"invalid query keeps its tokens", "foo=%zz&hdnea=old", "new", "foo=%zz&hdnea=old&__hdnea__=new"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HDNEA.SetQuery(tt.query, tt.token); got != tt.want {
+				t.Errorf("SetQuery(%q, %q) = %q, want %q", tt.query, tt.token, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	futureToken := fmt.Sprintf("exp=%d~acl=/*~data=hdntl~hmac=test", time.Now().Add(5*time.Minute).Unix())
+	remaining, ok := Remaining(futureToken)
+	if !ok {
+		t.Fatalf("expected token expiry to be parsed")
+	}
+	if remaining <= 4*time.Minute {
+		t.Fatalf("expected token to have more than 4 minutes remaining, got %s", remaining)
+	}
+
+	encodedToken := fmt.Sprintf("exp%%3D%d~hmac%%3Dtest", time.Now().Add(time.Minute).Unix())
+	if _, ok := Remaining(encodedToken); !ok {
+		t.Fatalf("expected expiry of an encoded token to be parsed")
+	}
+
+	if _, ok := Remaining("acl=/*~hmac=test"); ok {
+		t.Fatalf("expected token without expiry to have no remaining lifetime")
+	}
+}
+
+func TestExpired(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		want  bool
+	}{
+		{"expired", fmt.Sprintf("exp=%d~acl=/*~hmac=test", time.Now().Add(-time.Minute).Unix()), true},
+		{"valid", fmt.Sprintf("exp=%d~acl=/*~hmac=test", time.Now().Add(time.Minute).Unix()), false},
+		{"no expiry", "acl=/*~hmac=test", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Expired(tt.token); got != tt.want {
+				t.Errorf("Expired(%q) = %v, want %v", tt.token, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCache(t *testing.T) {
+	cache := Cache{TTL: 50 * time.Millisecond}
+	cache.Set("143", "abc")
+	cache.Set("", "ignored")
+	if got := cache.Get("143"); got != "abc" {
+		t.Fatalf("Get() = %q, want abc", got)
+	}
+	if got := cache.Get(""); got != "" {
+		t.Fatalf("Get() without a channel = %q, want none", got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if got := cache.Get("143"); got != "" {
+		t.Fatalf("Get() after the TTL = %q, want none", got)
+	}
+
+	cache.Set("143", "abc")
+	cache.Delete("143")
+	if got := cache.Get("143"); got != "" {
+		t.Fatalf("Get() after Delete() = %q, want none", got)
+	}
+}
+
+func TestCacheRenew(t *testing.T) {
+	var cache Cache
+	mints := 0
+	mint := func() (string, error) {
+		mints++
+		return fmt.Sprintf("token-%d", mints), nil
+	}
+
+	token, minted, err := cache.Renew("143", "refused", mint)
+	if err != nil || !minted || token != "token-1" {
+		t.Fatalf("Renew() = %q, %v, %v, want a minted token", token, minted, err)
+	}
+	// A request refused with the old token gets the token renewed meanwhile
+	token, minted, err = cache.Renew("143", "refused", mint)
+	if err != nil || minted || token != "token-1" {
+		t.Fatalf("Renew() = %q, %v, %v, want the cached token", token, minted, err)
+	}
+	// A request refused with the cached token mints another
+	token, minted, err = cache.Renew("143", "token-1", mint)
+	if err != nil || !minted || token != "token-2" {
+		t.Fatalf("Renew() = %q, %v, %v, want a new token", token, minted, err)
+	}
+
+	failed := errors.New("login expired")
+	_, _, err = cache.Renew("144", "refused", func() (string, error) { return "", failed })
+	if !errors.Is(err, failed) {
+		t.Fatalf("Renew() error = %v, want the error of mint", err)
+	}
+	if got := cache.Get("144"); got != "" {
+		t.Fatalf("Get() after a failed Renew() = %q, want none", got)
+	}
+}
+
+func TestCacheRenewPerChannel(t *testing.T) {
+	var cache Cache
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cache.Renew("143", "refused", func() (string, error) {
+			close(started)
+			<-release
+			return "slow", nil
+		})
+	}()
+	<-started
+
+	renewed := make(chan string)
+	go func() {
+		token, _, _ := cache.Renew("144", "refused", func() (string, error) { return "fast", nil })
+		renewed <- token
+	}()
+	select {
+	case token := <-renewed:
+		if token != "fast" {
+			t.Errorf("Renew() = %q, want fast", token)
+		}
+	case <-time.After(time.Second):
+		t.Error("Renew() of a channel waits for the renewal of another channel")
+	}
+	close(release)
+	<-done
+}
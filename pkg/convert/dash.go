@@ -0,0 +1,157 @@
+// Package convert converts live streams between HLS and MPEG-DASH without transcoding: the manifests
+// are written for the other format, and the segments are served as they are.
+package convert
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/hls"
+)
+
+// Anchor ties a media sequence number to the time its segment starts, so that the timeline of a
+// converted manifest stays the same across reloads of the playlist.
+type Anchor struct {
+	Sequence int64
+	// Start is the start of the segment in milliseconds since the Unix epoch
+	Start int64
+}
+
+// DASHSegmentPath returns the path of a segment relative to the BaseURL of a manifest from DASHFromHLS.
+func DASHSegmentPath(variant int, sequence int64) string {
+	return strconv.Itoa(variant) + "/" + strconv.FormatInt(sequence, 10) + ".ts"
+}
+
+// DASHFromHLS returns a dynamic DASH manifest for a live HLS stream. The representations of the manifest
+// are the variants of the stream, and its segments the MPEG-TS segments of the variants, which must have
+// the same media sequence numbers like JioTV streams do. segments are those of one of the variants.
+// Segments are listed relative to baseURL, see DASHSegmentPath.
+//
+// anchor is the anchor returned for an earlier version of the playlist, or the zero Anchor. Without it
+// and without EXT-X-PROGRAM-DATE-TIME tags, the last segment is assumed to end at now.
+func DASHFromHLS(variants []hls.Variant, segments []hls.Segment, targetDuration int, baseURL string, anchor Anchor, now time.Time) ([]byte, Anchor) {
+	starts := segmentStarts(segments, anchor, now)
+	if len(segments) > 0 {
+		// The last segment is anchored, as it is still in the playlist when it is reloaded
+		last := len(segments) - 1
+		anchor = Anchor{Sequence: segments[last].Sequence, Start: starts[last]}
+	}
+	if targetDuration <= 0 {
+		targetDuration = 6
+	}
+	var window int64
+	if len(segments) > 0 {
+		last := len(segments) - 1
+		window = starts[last] + milliseconds(segments[last].Duration) - starts[0]
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	fmt.Fprintf(&buf, `<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:mp2t-simple:2011" type="dynamic"`+
+		` availabilityStartTime="1970-01-01T00:00:00Z" publishTime="%s" minimumUpdatePeriod="PT%dS" minBufferTime="PT%dS"`+
+		` timeShiftBufferDepth="%s" suggestedPresentationDelay="PT%dS">`+"\n",
+		now.UTC().Format(time.RFC3339), targetDuration, targetDuration, isoDuration(window), 3*targetDuration)
+	fmt.Fprintf(&buf, "  <BaseURL>%s</BaseURL>\n", escape(baseURL))
+	buf.WriteString(`  <Period id="0" start="PT0S">` + "\n")
+	buf.WriteString(`    <AdaptationSet mimeType="video/mp2t" segmentAlignment="true" bitstreamSwitching="true" startWithSAP="1">` + "\n")
+	if len(segments) > 0 {
+		fmt.Fprintf(&buf, `      <SegmentTemplate timescale="1000" media="$RepresentationID$/$Number$.ts" startNumber="%d">`+"\n", segments[0].Sequence)
+		buf.WriteString("        <SegmentTimeline>\n")
+		for i := 0; i < len(segments); {
+			duration := milliseconds(segments[i].Duration)
+			// Segments of the same duration are written as one entry with repeats
+			repeat := 0
+			for i+repeat+1 < len(segments) && milliseconds(segments[i+repeat+1].Duration) == duration && !segments[i+repeat+1].Discontinuity {
+				repeat++
+			}
+			if repeat > 0 {
+				fmt.Fprintf(&buf, `          <S t="%d" d="%d" r="%d"/>`+"\n", starts[i], duration, repeat)
+			} else {
+				fmt.Fprintf(&buf, `          <S t="%d" d="%d"/>`+"\n", starts[i], duration)
+			}
+			i += repeat + 1
+		}
+		buf.WriteString("        </SegmentTimeline>\n")
+		buf.WriteString("      </SegmentTemplate>\n")
+	}
+	for i, variant := range variants {
+		fmt.Fprintf(&buf, `      <Representation id="%d" bandwidth="%d"`, i, variant.Bandwidth)
+		if variant.Codecs != "" {
+			fmt.Fprintf(&buf, ` codecs="%s"`, escape(variant.Codecs))
+		}
+		if variant.Width > 0 && variant.Height > 0 {
+			fmt.Fprintf(&buf, ` width="%d" height="%d"`, variant.Width, variant.Height)
+		}
+		if frameRate := dashFrameRate(variant.FrameRate); frameRate != "" {
+			fmt.Fprintf(&buf, ` frameRate="%s"`, frameRate)
+		}
+		buf.WriteString("/>\n")
+	}
+	buf.WriteString("    </AdaptationSet>\n  </Period>\n</MPD>\n")
+	return buf.Bytes(), anchor
+}
+
+// segmentStarts returns the start of every segment in milliseconds since the Unix epoch, from the
+// anchor if the playlist still has its segment, else from the program date time, else from now.
+func segmentStarts(segments []hls.Segment, anchor Anchor, now time.Time) []int64 {
+	starts := make([]int64, len(segments))
+	if len(segments) == 0 {
+		return starts
+	}
+	first := int64(0)
+	switch {
+	case anchor.Start != 0 && anchor.Sequence >= segments[0].Sequence && anchor.Sequence <= segments[len(segments)-1].Sequence:
+		first = anchor.Start
+		for _, segment := range segments[:anchor.Sequence-segments[0].Sequence] {
+			first -= milliseconds(segment.Duration)
+		}
+	case !segments[0].ProgramDateTime.IsZero():
+		first = segments[0].ProgramDateTime.UnixMilli()
+	default:
+		first = now.UnixMilli()
+		for _, segment := range segments {
+			first -= milliseconds(segment.Duration)
+		}
+	}
+	starts[0] = first
+	for i := 1; i < len(segments); i++ {
+		starts[i] = starts[i-1] + milliseconds(segments[i-1].Duration)
+	}
+	return starts
+}
+
+func milliseconds(seconds float64) int64 {
+	return int64(math.Round(seconds * 1000))
+}
+
+// isoDuration formats milliseconds as an ISO 8601 duration like PT36.5S.
+func isoDuration(ms int64) string {
+	return "PT" + strconv.FormatFloat(float64(ms)/1000, 'f', -1, 64) + "S"
+}
+
+// dashFrameRate formats an HLS FRAME-RATE like 29.970 as a DASH frame rate like 30000/1001.
+func dashFrameRate(frameRate string) string {
+	rate, err := strconv.ParseFloat(frameRate, 64)
+	if err != nil || rate <= 0 {
+		return ""
+	}
+	if rate == math.Trunc(rate) {
+		return strconv.Itoa(int(rate))
+	}
+	if ntsc := math.Round(rate) * 1000 / 1001; math.Abs(ntsc-rate) < 0.01 {
+		return strconv.Itoa(int(math.Round(rate))*1000) + "/1001"
+	}
+	return strings.TrimRight(strconv.FormatFloat(rate, 'f', 3, 64), "0")
+}
+
+// escape escapes text for the text or an attribute value of an element.
+func escape(text string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(text))
+	return buf.String()
+}
@@ -0,0 +1,82 @@
+package convert
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/hls"
+)
+
+func TestDASHFromHLS(t *testing.T) {
+	variants := []hls.Variant{
+		{Bandwidth: 800000, Codecs: "avc1.4d401f,mp4a.40.2", Width: 640, Height: 360, FrameRate: "29.970"},
+		{Bandwidth: 2400000, Width: 1280, Height: 720, FrameRate: "25"},
+	}
+	segments := []hls.Segment{
+		{Sequence: 100, Duration: 6},
+		{Sequence: 101, Duration: 6},
+		{Sequence: 102, Duration: 4},
+	}
+	now := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	first := now.UnixMilli() - 16000
+
+	manifest, anchor := DASHFromHLS(variants, segments, 6, "https://jiotv.example.com/dash/143/", Anchor{}, now)
+	if want := (Anchor{Sequence: 102, Start: first + 12000}); anchor != want {
+		t.Errorf("DASHFromHLS() anchor = %+v, want %+v", anchor, want)
+	}
+	for _, want := range []string{
+		`type="dynamic"`,
+		`timeShiftBufferDepth="PT16S"`,
+		`<BaseURL>https://jiotv.example.com/dash/143/</BaseURL>`,
+		`media="$RepresentationID$/$Number$.ts" startNumber="100"`,
+		`<S t="` + strconv.FormatInt(first, 10) + `" d="6000" r="1"/>`,
+		`<S t="` + strconv.FormatInt(first+12000, 10) + `" d="4000"/>`,
+		`<Representation id="0" bandwidth="800000" codecs="avc1.4d401f,mp4a.40.2" width="640" height="360" frameRate="30000/1001"/>`,
+		`<Representation id="1" bandwidth="2400000" width="1280" height="720" frameRate="25"/>`,
+	} {
+		if !strings.Contains(string(manifest), want) {
+			t.Errorf("DASHFromHLS() manifest does not contain %s:\n%s", want, manifest)
+		}
+	}
+
+	// The timeline continues from the anchor when the playlist is reloaded, whatever the time
+	reloaded := []hls.Segment{
+		{Sequence: 101, Duration: 6},
+		{Sequence: 102, Duration: 4},
+		{Sequence: 103, Duration: 6},
+	}
+	manifest, anchor = DASHFromHLS(variants, reloaded, 6, "https://jiotv.example.com/dash/143/", anchor, now.Add(5*time.Second))
+	if want := (Anchor{Sequence: 103, Start: first + 16000}); anchor != want {
+		t.Errorf("DASHFromHLS() anchor after reload = %+v, want %+v", anchor, want)
+	}
+	if want := `<S t="` + strconv.FormatInt(first+6000, 10) + `" d="6000"/>`; !strings.Contains(string(manifest), want) {
+		t.Errorf("DASHFromHLS() manifest after reload does not contain %s:\n%s", want, manifest)
+	}
+}
+
+func TestDASHFromHLSProgramDateTime(t *testing.T) {
+	start := time.Date(2026, 10, 16, 5, 0, 0, 0, time.UTC)
+	segments := []hls.Segment{{Sequence: 5, Duration: 6, ProgramDateTime: start}}
+	_, anchor := DASHFromHLS(nil, segments, 6, "/", Anchor{}, start.Add(time.Hour))
+	if anchor.Start != start.UnixMilli() {
+		t.Errorf("DASHFromHLS() anchor start = %d, want the program date time %d", anchor.Start, start.UnixMilli())
+	}
+}
+
+func TestDASHFrameRate(t *testing.T) {
+	tests := map[string]string{
+		"25":     "25",
+		"29.970": "30000/1001",
+		"59.94":  "60000/1001",
+		"12.5":   "12.5",
+		"":       "",
+		"-1":     "",
+	}
+	for input, want := range tests {
+		if got := dashFrameRate(input); got != want {
+			t.Errorf("dashFrameRate(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
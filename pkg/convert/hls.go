@@ -0,0 +1,269 @@
+package convert
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/dash"
+)
+
+var (
+	// ErrEncrypted is returned for manifests with content protection, whose segments HLS players cannot decrypt
+	ErrEncrypted = errors.New("manifest is encrypted")
+	// ErrNoRepresentation is returned for representations that are not in the manifest
+	ErrNoRepresentation = errors.New("representation not found")
+)
+
+// audioGroup is the group of the alternate audio renditions of master playlists from HLSMasterFromDASH
+const audioGroup = "audio"
+
+// periods returns the periods listed in HLS playlists: the current period of dynamic manifests, and
+// every period of static ones.
+func periods(m *dash.MPD, now time.Time) []*dash.Period {
+	if len(m.Periods) == 0 {
+		return nil
+	}
+	if !m.Dynamic() {
+		result := make([]*dash.Period, len(m.Periods))
+		for i := range m.Periods {
+			result[i] = &m.Periods[i]
+		}
+		return result
+	}
+	current := &m.Periods[0]
+	if availabilityStart, err := time.Parse(time.RFC3339Nano, m.AvailabilityStartTime); err == nil {
+		for i := range m.Periods {
+			start, _ := dash.ParseDuration(m.Periods[i].Start)
+			if !availabilityStart.Add(start).After(now) {
+				current = &m.Periods[i]
+			}
+		}
+	}
+	return []*dash.Period{current}
+}
+
+// contentType returns "video", "audio" or "text" for a representation of an adaptation set.
+func contentType(set *dash.AdaptationSet, rep *dash.Representation) string {
+	if set.ContentType != "" {
+		return set.ContentType
+	}
+	mimeType := rep.MimeType
+	if mimeType == "" {
+		mimeType = set.MimeType
+	}
+	kind, _, _ := strings.Cut(mimeType, "/")
+	if kind == "application" {
+		return "text"
+	}
+	return kind
+}
+
+// HLSMasterFromDASH returns an HLS master playlist for a DASH manifest with fMP4 segments. The video
+// representations are the variant streams, and the audio representations alternate renditions.
+// mediaURL returns the URL of the media playlist of a representation, see HLSMediaFromDASH.
+func HLSMasterFromDASH(m *dash.MPD, now time.Time, mediaURL func(representationID string) string) ([]byte, error) {
+	current := periods(m, now)
+	if len(current) == 0 {
+		return nil, ErrNoRepresentation
+	}
+	period := current[0]
+	if period.Encrypted() {
+		return nil, ErrEncrypted
+	}
+
+	var (
+		buf          bytes.Buffer
+		audio        []string
+		audioCodecs  string
+		audioBitrate int
+		names        = map[string]bool{}
+	)
+	buf.WriteString("#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-INDEPENDENT-SEGMENTS\n")
+	for _, set := range period.AdaptationSets {
+		for _, rep := range set.Representations {
+			if contentType(&set, &rep) != "audio" {
+				continue
+			}
+			// Names must be unique within the group, e.g. for several bitrates of one language
+			name := set.Lang
+			if name == "" || names[name] {
+				name = strings.TrimSpace(set.Lang + " " + rep.ID)
+			}
+			names[name] = true
+			attributes := []string{"TYPE=AUDIO", `GROUP-ID="` + audioGroup + `"`, `NAME="` + name + `"`}
+			if set.Lang != "" {
+				attributes = append(attributes, `LANGUAGE="`+set.Lang+`"`)
+			}
+			if len(audio) == 0 {
+				attributes = append(attributes, "DEFAULT=YES")
+				audioCodecs = firstNonEmpty(rep.Codecs, set.Codecs)
+			}
+			attributes = append(attributes, "AUTOSELECT=YES", `URI="`+mediaURL(rep.ID)+`"`)
+			audio = append(audio, "#EXT-X-MEDIA:"+strings.Join(attributes, ","))
+			audioBitrate = max(audioBitrate, rep.Bandwidth)
+		}
+	}
+	for _, line := range audio {
+		buf.WriteString(line + "\n")
+	}
+
+	variants := 0
+	for _, set := range period.AdaptationSets {
+		for _, rep := range set.Representations {
+			if contentType(&set, &rep) != "video" {
+				continue
+			}
+			attributes := []string{"BANDWIDTH=" + strconv.Itoa(rep.Bandwidth+audioBitrate)}
+			codecs := firstNonEmpty(rep.Codecs, set.Codecs)
+			if audioCodecs != "" {
+				codecs = strings.Trim(codecs+","+audioCodecs, ",")
+			}
+			if codecs != "" {
+				attributes = append(attributes, `CODECS="`+codecs+`"`)
+			}
+			width, height := firstPositive(rep.Width, set.Width), firstPositive(rep.Height, set.Height)
+			if width > 0 && height > 0 {
+				attributes = append(attributes, fmt.Sprintf("RESOLUTION=%dx%d", width, height))
+			}
+			if frameRate := hlsFrameRate(firstNonEmpty(rep.FrameRate, set.FrameRate)); frameRate != "" {
+				attributes = append(attributes, "FRAME-RATE="+frameRate)
+			}
+			if len(audio) > 0 {
+				attributes = append(attributes, `AUDIO="`+audioGroup+`"`)
+			}
+			buf.WriteString("#EXT-X-STREAM-INF:" + strings.Join(attributes, ",") + "\n")
+			buf.WriteString(mediaURL(rep.ID) + "\n")
+			variants++
+		}
+	}
+	if variants == 0 {
+		return nil, ErrNoRepresentation
+	}
+	return buf.Bytes(), nil
+}
+
+// HLSMediaFromDASH returns the HLS media playlist of a representation of a DASH manifest fetched from
+// manifestURL. segmentURL returns the URL players fetch a segment or initialization segment from.
+func HLSMediaFromDASH(m *dash.MPD, manifestURL *url.URL, representationID string, now time.Time, segmentURL func(upstream string) string) ([]byte, error) {
+	availabilityStart, _ := time.Parse(time.RFC3339Nano, m.AvailabilityStartTime)
+
+	var (
+		body     bytes.Buffer
+		target   float64
+		sequence uint64
+		found    bool
+		listed   bool
+		lastInit string
+		first    = true
+	)
+	for _, period := range periods(m, now) {
+		if period.Encrypted() {
+			return nil, ErrEncrypted
+		}
+		set, rep := findRepresentation(period, representationID)
+		if rep == nil {
+			continue
+		}
+		found = true
+		initialization, segments, err := m.Segments(manifestURL, period, set, rep, now)
+		if err != nil {
+			return nil, err
+		}
+		if len(segments) == 0 {
+			continue
+		}
+		if first {
+			sequence = segments[0].Number
+			first = false
+		} else {
+			body.WriteString("#EXT-X-DISCONTINUITY\n")
+		}
+		if initialization != "" && initialization != lastInit {
+			fmt.Fprintf(&body, "#EXT-X-MAP:URI=\"%s\"\n", segmentURL(initialization))
+			lastInit = initialization
+		}
+		periodStart, _ := dash.ParseDuration(period.Start)
+		for i, segment := range segments {
+			if m.Dynamic() && i == 0 && !availabilityStart.IsZero() {
+				start := availabilityStart.Add(periodStart + segment.Start)
+				body.WriteString("#EXT-X-PROGRAM-DATE-TIME:" + start.UTC().Format("2006-01-02T15:04:05.000Z07:00") + "\n")
+			}
+			seconds := segment.Duration.Seconds()
+			target = max(target, seconds)
+			body.WriteString("#EXTINF:" + strconv.FormatFloat(seconds, 'f', 3, 64) + ",\n")
+			body.WriteString(segmentURL(segment.URL) + "\n")
+			listed = true
+		}
+	}
+	if !found {
+		return nil, ErrNoRepresentation
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("#EXTM3U\n#EXT-X-VERSION:7\n")
+	fmt.Fprintf(&buf, "#EXT-X-TARGETDURATION:%d\n", int(math.Ceil(max(target, 1))))
+	fmt.Fprintf(&buf, "#EXT-X-MEDIA-SEQUENCE:%d\n", sequence)
+	if !m.Dynamic() {
+		buf.WriteString("#EXT-X-PLAYLIST-TYPE:VOD\n")
+	}
+	buf.WriteString("#EXT-X-INDEPENDENT-SEGMENTS\n")
+	buf.Write(body.Bytes())
+	if !m.Dynamic() && listed {
+		buf.WriteString("#EXT-X-ENDLIST\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// findRepresentation returns a representation of a period and its adaptation set.
+func findRepresentation(period *dash.Period, id string) (*dash.AdaptationSet, *dash.Representation) {
+	for i := range period.AdaptationSets {
+		set := &period.AdaptationSets[i]
+		for j := range set.Representations {
+			if set.Representations[j].ID == id {
+				return set, &set.Representations[j]
+			}
+		}
+	}
+	return nil, nil
+}
+
+// hlsFrameRate formats a DASH frame rate like 30000/1001 as an HLS FRAME-RATE like 29.970.
+func hlsFrameRate(frameRate string) string {
+	numerator, denominator, fraction := strings.Cut(frameRate, "/")
+	rate, err := strconv.ParseFloat(numerator, 64)
+	if err != nil || rate <= 0 {
+		return ""
+	}
+	if fraction {
+		divisor, err := strconv.ParseFloat(denominator, 64)
+		if err != nil || divisor <= 0 {
+			return ""
+		}
+		rate /= divisor
+	}
+	return strconv.FormatFloat(rate, 'f', 3, 64)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func firstPositive(values ...int) int {
+	for _, value := range values {
+		if value > 0 {
+			return value
+		}
+	}
+	return 0
+}
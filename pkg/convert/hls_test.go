@@ -0,0 +1,151 @@
+package convert
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/dash"
+)
+
+const liveManifest = `<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" type="dynamic" availabilityStartTime="2026-10-16T00:00:00Z" timeShiftBufferDepth="PT8S">
+  <Period id="0" start="PT0S">
+    <AdaptationSet contentType="video" mimeType="video/mp4" codecs="avc1.4d401f" frameRate="30000/1001">
+      <SegmentTemplate timescale="1" duration="4" startNumber="10" media="$RepresentationID$/$Number$.m4s" initialization="$RepresentationID$/init.mp4"/>
+      <Representation id="v1" bandwidth="800000" width="640" height="360"/>
+    </AdaptationSet>
+    <AdaptationSet mimeType="audio/mp4" lang="hi" codecs="mp4a.40.2">
+      <SegmentTemplate timescale="1" duration="4" startNumber="10" media="$RepresentationID$/$Number$.m4s" initialization="$RepresentationID$/init.mp4"/>
+      <Representation id="a1" bandwidth="96000"/>
+      <Representation id="a2" bandwidth="64000"/>
+    </AdaptationSet>
+  </Period>
+</MPD>`
+
+const vodManifest = `<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" type="static" mediaPresentationDuration="PT10S">
+  <Period id="0" duration="PT6S">
+    <AdaptationSet contentType="video">
+      <SegmentTemplate timescale="1" duration="3" media="p0/$Number$.m4s" initialization="init.mp4"/>
+      <Representation id="v1" bandwidth="800000"/>
+    </AdaptationSet>
+  </Period>
+  <Period id="1" duration="PT4S">
+    <AdaptationSet contentType="video">
+      <SegmentTemplate timescale="1" duration="4" media="p1/$Number$.m4s" initialization="init.mp4"/>
+      <Representation id="v1" bandwidth="800000"/>
+    </AdaptationSet>
+  </Period>
+</MPD>`
+
+func decode(t *testing.T, manifest string) *dash.MPD {
+	t.Helper()
+	m, err := dash.Decode([]byte(manifest))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	return m
+}
+
+func TestHLSMasterFromDASH(t *testing.T) {
+	now := time.Date(2026, 10, 16, 0, 1, 0, 0, time.UTC)
+	got, err := HLSMasterFromDASH(decode(t, liveManifest), now, func(id string) string { return "media.m3u8?rep=" + id })
+	if err != nil {
+		t.Fatalf("HLSMasterFromDASH() error = %v", err)
+	}
+	want := `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-INDEPENDENT-SEGMENTS
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="audio",NAME="hi",LANGUAGE="hi",DEFAULT=YES,AUTOSELECT=YES,URI="media.m3u8?rep=a1"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="audio",NAME="hi a2",LANGUAGE="hi",AUTOSELECT=YES,URI="media.m3u8?rep=a2"
+#EXT-X-STREAM-INF:BANDWIDTH=896000,CODECS="avc1.4d401f,mp4a.40.2",RESOLUTION=640x360,FRAME-RATE=29.970,AUDIO="audio"
+media.m3u8?rep=v1
+`
+	if string(got) != want {
+		t.Errorf("HLSMasterFromDASH() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHLSMediaFromDASH(t *testing.T) {
+	manifestURL, _ := url.Parse("https://cdn.example.com/live/index.mpd")
+	segmentURL := func(upstream string) string { return "/proxy?u=" + upstream }
+
+	tests := []struct {
+		name     string
+		manifest string
+		now      time.Time
+		want     string
+	}{
+		{
+			name:     "live",
+			manifest: liveManifest,
+			now:      time.Date(2026, 10, 16, 0, 1, 0, 0, time.UTC),
+			want: `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:4
+#EXT-X-MEDIA-SEQUENCE:22
+#EXT-X-INDEPENDENT-SEGMENTS
+#EXT-X-MAP:URI="/proxy?u=https://cdn.example.com/live/v1/init.mp4"
+#EXT-X-PROGRAM-DATE-TIME:2026-10-16T00:00:48.000Z
+#EXTINF:4.000,
+/proxy?u=https://cdn.example.com/live/v1/22.m4s
+#EXTINF:4.000,
+/proxy?u=https://cdn.example.com/live/v1/23.m4s
+#EXTINF:4.000,
+/proxy?u=https://cdn.example.com/live/v1/24.m4s
+`,
+		},
+		{
+			name:     "static with periods",
+			manifest: vodManifest,
+			now:      time.Date(2026, 10, 16, 0, 1, 0, 0, time.UTC),
+			want: `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:4
+#EXT-X-MEDIA-SEQUENCE:1
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXT-X-INDEPENDENT-SEGMENTS
+#EXT-X-MAP:URI="/proxy?u=https://cdn.example.com/live/init.mp4"
+#EXTINF:3.000,
+/proxy?u=https://cdn.example.com/live/p0/1.m4s
+#EXTINF:3.000,
+/proxy?u=https://cdn.example.com/live/p0/2.m4s
+#EXT-X-DISCONTINUITY
+#EXTINF:4.000,
+/proxy?u=https://cdn.example.com/live/p1/1.m4s
+#EXT-X-ENDLIST
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HLSMediaFromDASH(decode(t, tt.manifest), manifestURL, "v1", tt.now, segmentURL)
+			if err != nil {
+				t.Fatalf("HLSMediaFromDASH() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("HLSMediaFromDASH() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHLSFromDASHErrors(t *testing.T) {
+	encrypted := decode(t, `<MPD type="static"><Period><AdaptationSet contentType="video">
+  <ContentProtection schemeIdUri="urn:mpeg:dash:mp4protection:2011" value="cenc"/>
+  <SegmentTemplate duration="4" media="$Number$.m4s"/>
+  <Representation id="v1" bandwidth="800000"/>
+</AdaptationSet></Period></MPD>`)
+	now := time.Now()
+	mediaURL := func(id string) string { return id }
+
+	if _, err := HLSMasterFromDASH(encrypted, now, mediaURL); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("HLSMasterFromDASH() of an encrypted manifest error = %v, want ErrEncrypted", err)
+	}
+	if _, err := HLSMediaFromDASH(encrypted, nil, "v1", now, mediaURL); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("HLSMediaFromDASH() of an encrypted manifest error = %v, want ErrEncrypted", err)
+	}
+	if _, err := HLSMediaFromDASH(decode(t, vodManifest), nil, "missing", now, mediaURL); !errors.Is(err, ErrNoRepresentation) {
+		t.Errorf("HLSMediaFromDASH() of a missing representation error = %v, want ErrNoRepresentation", err)
+	}
+}
@@ -0,0 +1,334 @@
+// Package dash rewrites the URLs of MPEG-DASH manifests (MPDs). Manifests are edited in place, so that
+// elements and attributes the package does not know are written back unchanged.
+package dash
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ErrNotManifest is returned for data whose root element is not an MPD
+var ErrNotManifest = errors.New("not a DASH manifest")
+
+// URIKind is what a URL of a manifest points at
+type URIKind int
+
+const (
+	// BaseURL is the text of a BaseURL element, which the other URLs of its parent are relative to
+	BaseURL URIKind = iota
+	// SegmentURI points at media data, e.g. a segment, an initialization segment or a segment index.
+	// It can be a template with identifiers like $Number$, $Time$ or $RepresentationID$.
+	SegmentURI
+	// ManifestURI points at a manifest, e.g. the Location a live manifest is reloaded from
+	ManifestURI
+)
+
+// textElements are the elements whose text is a URL and what the URL points at
+var textElements = map[string]URIKind{
+	"BaseURL":       BaseURL,
+	"Location":      ManifestURI,
+	"PatchLocation": ManifestURI,
+}
+
+// attributeElements are the elements with URL attributes and the names of the attributes
+var attributeElements = map[string][]string{
+	"SegmentTemplate":     {"media", "initialization", "index", "bitstreamSwitching"},
+	"SegmentURL":          {"media", "index"},
+	"Initialization":      {"sourceURL"},
+	"RepresentationIndex": {"sourceURL"},
+	"BitstreamSwitching":  {"sourceURL"},
+}
+
+// Reference is a URL of a manifest with what it points at.
+type Reference struct {
+	Kind URIKind
+	// Element is the name of the element with the URL, e.g. SegmentTemplate
+	Element string
+	// Attribute is the name of the attribute with the URL, or empty for the text of an element
+	Attribute string
+	// URI is the URL as written in the manifest. It is empty for the BaseURL of a manifest without one.
+	URI string
+	// URL is the URI resolved against the BaseURLs in effect and the URL of the manifest.
+	// Template identifiers are kept as they are.
+	URL string
+	// Period is the index of the Period the URL appears in, or -1 for URLs outside of periods
+	Period int
+}
+
+// frame is an open element of the manifest.
+type frame struct {
+	name string
+	// inherited is the base URL in effect for the element, base the one for its children
+	inherited string
+	base      string
+	hasBase   bool
+}
+
+// edit replaces data[start:end] with text.
+type edit struct {
+	start, end int
+	text       string
+}
+
+// Rewrite replaces every URL of the manifest, in BaseURL and Location elements and in the URL attributes of
+// segment elements, with the result of rewrite. URLs are resolved against manifestURL, the URL the manifest
+// was fetched from. When rewrite returns false, the URL is kept.
+//
+// Players resolve relative URLs against the URL they fetched the manifest from, so for a manifest without a
+// BaseURL of its own, rewrite is also called with the directory of manifestURL, and a BaseURL is added if
+// it returns true.
+func Rewrite(data []byte, manifestURL *url.URL, rewrite func(ref Reference) (string, bool)) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	rootBase := ""
+	if manifestURL != nil {
+		rootBase = manifestURL.String()
+	}
+
+	var (
+		stack     []*frame
+		edits     []edit
+		text      strings.Builder
+		textStart int
+		period    = -1
+		// insertAt is where a missing BaseURL of the MPD goes, before its first child other than
+		// ProgramInformation, and indent the whitespace before that child
+		insertAt = -1
+		indent   string
+		lastText string
+		root     *frame
+	)
+	for {
+		start := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		end := int(decoder.InputOffset())
+
+		switch tok := token.(type) {
+		case xml.StartElement:
+			name := tok.Name.Local
+			base := rootBase
+			if len(stack) == 0 {
+				if name != "MPD" || root != nil {
+					return nil, ErrNotManifest
+				}
+			} else {
+				base = stack[len(stack)-1].base
+			}
+			if len(stack) == 1 {
+				if insertAt < 0 && name != "ProgramInformation" && name != "BaseURL" {
+					insertAt = start
+					if strings.TrimSpace(lastText) == "" {
+						indent = lastText
+					}
+				}
+				if name == "Period" {
+					period++
+				}
+			}
+			f := &frame{name: name, inherited: base, base: base}
+			if root == nil {
+				root = f
+			}
+			stack = append(stack, f)
+			text.Reset()
+			textStart = end
+
+			for _, attrName := range attributeElements[name] {
+				uri := attributeValue(tok, attrName)
+				if uri == "" {
+					continue
+				}
+				ref := Reference{
+					Kind:      SegmentURI,
+					Element:   name,
+					Attribute: attrName,
+					URI:       uri,
+					URL:       resolve(base, uri),
+					Period:    currentPeriod(stack, period),
+				}
+				replaced, ok := rewrite(ref)
+				if !ok {
+					continue
+				}
+				valueStart, valueEnd, found := attributeRange(data[start:end], attrName)
+				if found {
+					edits = append(edits, edit{start + valueStart, start + valueEnd, escape(replaced)})
+				}
+			}
+
+		case xml.CharData:
+			text.Write(tok)
+			lastText = string(tok)
+
+		case xml.EndElement:
+			f := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				if insertAt < 0 {
+					insertAt = start
+				}
+				break
+			}
+			kind, ok := textElements[f.name]
+			if !ok {
+				break
+			}
+			parent := stack[len(stack)-1]
+			uri := strings.TrimSpace(text.String())
+			if uri == "" {
+				break
+			}
+			resolved := resolve(parent.inherited, uri)
+			if kind == BaseURL && !parent.hasBase {
+				parent.base, parent.hasBase = resolved, true
+			}
+			ref := Reference{
+				Kind:    kind,
+				Element: f.name,
+				URI:     uri,
+				URL:     resolved,
+				Period:  currentPeriod(stack, period),
+			}
+			if replaced, ok := rewrite(ref); ok {
+				edits = append(edits, edit{textStart, start, escape(replaced)})
+			}
+		}
+	}
+	if root == nil {
+		return nil, ErrNotManifest
+	}
+
+	if !root.hasBase && manifestURL != nil && insertAt >= 0 {
+		ref := Reference{Kind: BaseURL, Element: "BaseURL", URL: resolve(rootBase, "./"), Period: -1}
+		if replaced, ok := rewrite(ref); ok {
+			edits = append(edits, edit{insertAt, insertAt, "<BaseURL>" + escape(replaced) + "</BaseURL>" + indent})
+		}
+	}
+	return apply(data, edits), nil
+}
+
+// currentPeriod returns the index of the Period the innermost open element belongs to, or -1.
+func currentPeriod(stack []*frame, period int) int {
+	if len(stack) > 1 && stack[1].name == "Period" {
+		return period
+	}
+	return -1
+}
+
+// attributeValue returns the value of an attribute of an element.
+func attributeValue(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name && attr.Name.Space == "" {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// attributeRange returns the offsets of the value of an attribute, without its quotes, in the text of a start tag.
+func attributeRange(tag []byte, name string) (int, int, bool) {
+	i := bytes.IndexAny(tag, " \t\r\n")
+	if i < 0 {
+		return 0, 0, false
+	}
+	for i < len(tag) {
+		// Skip the whitespace before the attribute name
+		for i < len(tag) && isSpace(tag[i]) {
+			i++
+		}
+		nameStart := i
+		for i < len(tag) && tag[i] != '=' && !isSpace(tag[i]) && tag[i] != '>' && tag[i] != '/' {
+			i++
+		}
+		attrName := string(tag[nameStart:i])
+		for i < len(tag) && isSpace(tag[i]) {
+			i++
+		}
+		if attrName == "" || i >= len(tag) || tag[i] != '=' {
+			return 0, 0, false
+		}
+		i++
+		for i < len(tag) && isSpace(tag[i]) {
+			i++
+		}
+		if i >= len(tag) || (tag[i] != '"' && tag[i] != '\'') {
+			return 0, 0, false
+		}
+		quote := tag[i]
+		valueStart := i + 1
+		valueEnd := bytes.IndexByte(tag[valueStart:], quote)
+		if valueEnd < 0 {
+			return 0, 0, false
+		}
+		valueEnd += valueStart
+		if attrName == name {
+			return valueStart, valueEnd, true
+		}
+		i = valueEnd + 1
+	}
+	return 0, 0, false
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r' || b == '\n'
+}
+
+// escape escapes text for the text or an attribute value of an element.
+func escape(text string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(text))
+	return buf.String()
+}
+
+// apply returns data with the edits applied.
+func apply(data []byte, edits []edit) []byte {
+	if len(edits) == 0 {
+		return data
+	}
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	var buf bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		buf.Write(data[last:e.start])
+		buf.WriteString(e.text)
+		last = e.end
+	}
+	buf.Write(data[last:])
+	return buf.Bytes()
+}
+
+// resolve returns ref as an absolute URL relative to base. Template identifiers, which may contain
+// format tags like $Number%05d$, are kept as they are: only the directories before the first identifier
+// are resolved.
+func resolve(base, ref string) string {
+	if base == "" {
+		return ref
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	prefix, rest := ref, ""
+	if i := strings.IndexByte(ref, '$'); i >= 0 {
+		slash := strings.LastIndexByte(ref[:i], '/')
+		prefix, rest = ref[:slash+1], ref[slash+1:]
+		if prefix == "" {
+			prefix = "./"
+		}
+	}
+	parsed, err := url.Parse(prefix)
+	if err != nil {
+		return ref
+	}
+	return baseURL.ResolveReference(parsed).String() + rest
+}
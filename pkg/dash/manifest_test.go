@@ -0,0 +1,158 @@
+package dash
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func readManifest(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("ReadFile(%s) error = %v", name, err)
+	}
+	return data
+}
+
+func TestRewrite(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		url      string
+		want     []Reference
+		// inserted is the text a BaseURL is inserted before, if the manifest does not have one
+		inserted string
+	}{
+		{
+			name:     "live manifest with $Number$ templates",
+			manifest: "live_number.mpd",
+			url:      "https://jiotvmblive.cdn.jio.com/bpk-tv/Colors_HD/output/index.mpd?hdnea=abc",
+			want: []Reference{
+				{Kind: BaseURL, Element: "BaseURL", URI: "dash/", URL: "https://jiotvmblive.cdn.jio.com/bpk-tv/Colors_HD/output/dash/", Period: 0},
+				{Kind: SegmentURI, Element: "SegmentTemplate", Attribute: "media", URI: "Colors_HD-$RepresentationID$-$Number$.m4s", URL: "https://jiotvmblive.cdn.jio.com/bpk-tv/Colors_HD/output/dash/Colors_HD-$RepresentationID$-$Number$.m4s", Period: 0},
+				{Kind: SegmentURI, Element: "SegmentTemplate", Attribute: "initialization", URI: "Colors_HD-$RepresentationID$-init.m4s", URL: "https://jiotvmblive.cdn.jio.com/bpk-tv/Colors_HD/output/dash/Colors_HD-$RepresentationID$-init.m4s", Period: 0},
+				{Kind: SegmentURI, Element: "SegmentTemplate", Attribute: "media", URI: "Colors_HD-$RepresentationID$-$Number$.m4s", URL: "https://jiotvmblive.cdn.jio.com/bpk-tv/Colors_HD/output/dash/Colors_HD-$RepresentationID$-$Number$.m4s", Period: 0},
+				{Kind: SegmentURI, Element: "SegmentTemplate", Attribute: "initialization", URI: "Colors_HD-$RepresentationID$-init.m4s", URL: "https://jiotvmblive.cdn.jio.com/bpk-tv/Colors_HD/output/dash/Colors_HD-$RepresentationID$-init.m4s", Period: 0},
+				{Kind: BaseURL, Element: "BaseURL", URL: "https://jiotvmblive.cdn.jio.com/bpk-tv/Colors_HD/output/", Period: -1},
+			},
+			inserted: "\n  <Period",
+		},
+		{
+			name:     "multiple periods with $Time$ templates",
+			manifest: "multi_period.mpd",
+			url:      "https://jiotvmbcod.cdn.jio.com/bpk-tv/Sports18_1_HD/output/index.mpd",
+			want: []Reference{
+				{Kind: ManifestURI, Element: "Location", URI: "https://jiotvmbcod.cdn.jio.com/bpk-tv/Sports18_1_HD/output/index.mpd?t=1", URL: "https://jiotvmbcod.cdn.jio.com/bpk-tv/Sports18_1_HD/output/index.mpd?t=1", Period: -1},
+				{Kind: BaseURL, Element: "BaseURL", URI: "https://ads.cdn.jio.com/breaks/1234/", URL: "https://ads.cdn.jio.com/breaks/1234/", Period: 0},
+				{Kind: SegmentURI, Element: "SegmentTemplate", Attribute: "media", URI: "$RepresentationID$/seg-$Number%05d$.m4s", URL: "https://ads.cdn.jio.com/breaks/1234/$RepresentationID$/seg-$Number%05d$.m4s", Period: 0},
+				{Kind: SegmentURI, Element: "SegmentTemplate", Attribute: "initialization", URI: "$RepresentationID$/init.mp4", URL: "https://ads.cdn.jio.com/breaks/1234/$RepresentationID$/init.mp4", Period: 0},
+				{Kind: BaseURL, Element: "BaseURL", URI: "video/", URL: "https://jiotvmbcod.cdn.jio.com/bpk-tv/Sports18_1_HD/output/video/", Period: 1},
+				{Kind: SegmentURI, Element: "SegmentTemplate", Attribute: "media", URI: "../video/$RepresentationID$/$Time$.m4s", URL: "https://jiotvmbcod.cdn.jio.com/bpk-tv/Sports18_1_HD/output/video/$RepresentationID$/$Time$.m4s", Period: 1},
+				{Kind: SegmentURI, Element: "SegmentTemplate", Attribute: "initialization", URI: "$RepresentationID$/init.mp4", URL: "https://jiotvmbcod.cdn.jio.com/bpk-tv/Sports18_1_HD/output/video/$RepresentationID$/init.mp4", Period: 1},
+				{Kind: BaseURL, Element: "BaseURL", URI: "https://jiotvmbcod-alt.cdn.jio.com/bpk-tv/Sports18_1_HD/output/video/", URL: "https://jiotvmbcod-alt.cdn.jio.com/bpk-tv/Sports18_1_HD/output/video/", Period: 1},
+				{Kind: SegmentURI, Element: "SegmentTemplate", Attribute: "media", URI: "https://audio.cdn.jio.com/Sports18_1_HD/$RepresentationID$/$Time$.m4s?x=1&y=2", URL: "https://audio.cdn.jio.com/Sports18_1_HD/$RepresentationID$/$Time$.m4s?x=1&y=2", Period: 1},
+				{Kind: SegmentURI, Element: "SegmentTemplate", Attribute: "initialization", URI: "/bpk-tv/Sports18_1_HD/audio/$RepresentationID$/init.mp4", URL: "https://jiotvmbcod.cdn.jio.com/bpk-tv/Sports18_1_HD/audio/$RepresentationID$/init.mp4", Period: 1},
+				{Kind: BaseURL, Element: "BaseURL", URL: "https://jiotvmbcod.cdn.jio.com/bpk-tv/Sports18_1_HD/output/", Period: -1},
+			},
+			inserted: "\n  <Location>",
+		},
+		{
+			name:     "segment list",
+			manifest: "segment_list.mpd",
+			url:      "https://jiotv.catchup.cdn.jio.com/catchup/show.mpd",
+			want: []Reference{
+				{Kind: BaseURL, Element: "BaseURL", URI: "https://jiotv.catchup.cdn.jio.com/dare_images/shows/2026-10-15/", URL: "https://jiotv.catchup.cdn.jio.com/dare_images/shows/2026-10-15/", Period: -1},
+				{Kind: SegmentURI, Element: "Initialization", Attribute: "sourceURL", URI: "show_800/init.mp4", URL: "https://jiotv.catchup.cdn.jio.com/dare_images/shows/2026-10-15/show_800/init.mp4", Period: 0},
+				{Kind: SegmentURI, Element: "SegmentURL", Attribute: "media", URI: "show_800/1.m4s?token=a&exp=1", URL: "https://jiotv.catchup.cdn.jio.com/dare_images/shows/2026-10-15/show_800/1.m4s?token=a&exp=1", Period: 0},
+				{Kind: SegmentURI, Element: "SegmentURL", Attribute: "media", URI: "show_800/2.m4s", URL: "https://jiotv.catchup.cdn.jio.com/dare_images/shows/2026-10-15/show_800/2.m4s", Period: 0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := readManifest(t, tt.manifest)
+			manifestURL, _ := url.Parse(tt.url)
+			var got []Reference
+			result, err := Rewrite(data, manifestURL, func(ref Reference) (string, bool) {
+				got = append(got, ref)
+				return "/proxy?n=" + strconv.Itoa(len(got)) + "&k=1", true
+			})
+			if err != nil {
+				t.Fatalf("Rewrite() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Rewrite() references = %+v\nwant %+v", got, tt.want)
+			}
+
+			text := string(result)
+			for i, ref := range tt.want {
+				uri := "/proxy?n=" + strconv.Itoa(i+1) + "&amp;k=1"
+				switch {
+				case ref.URI == "":
+					if !strings.Contains(text, "<BaseURL>"+uri+"</BaseURL>"+tt.inserted) {
+						t.Errorf("rewritten manifest does not contain the inserted BaseURL %d:\n%s", i+1, text)
+					}
+				case ref.Attribute != "":
+					if !strings.Contains(text, ref.Attribute+`="`+uri+`"`) && !strings.Contains(text, ref.Attribute+`='`+uri+`'`) {
+						t.Errorf("rewritten manifest does not contain %s %d:\n%s", ref.Attribute, i+1, text)
+					}
+				default:
+					if !strings.Contains(text, "<"+ref.Element+">"+uri+"</"+ref.Element+">") {
+						t.Errorf("rewritten manifest does not contain %s %d:\n%s", ref.Element, i+1, text)
+					}
+				}
+			}
+			if err := xml.Unmarshal(result, new(struct{})); err != nil {
+				t.Errorf("rewritten manifest is not valid XML: %v", err)
+			}
+		})
+	}
+}
+
+func TestRewriteKeepsManifest(t *testing.T) {
+	for _, name := range []string{"live_number.mpd", "multi_period.mpd", "segment_list.mpd"} {
+		data := readManifest(t, name)
+		manifestURL, _ := url.Parse("https://jiotvmblive.cdn.jio.com/bpk-tv/Colors_HD/output/index.mpd")
+		result, err := Rewrite(data, manifestURL, func(ref Reference) (string, bool) {
+			return "", false
+		})
+		if err != nil {
+			t.Fatalf("Rewrite(%s) error = %v", name, err)
+		}
+		if !bytes.Equal(result, data) {
+			t.Errorf("Rewrite(%s) without replacements changed the manifest:\n%s", name, result)
+		}
+	}
+
+	// Only the segment URLs change, the ContentProtection and Representation elements are written back as they are
+	data := readManifest(t, "live_number.mpd")
+	result, err := Rewrite(data, nil, func(ref Reference) (string, bool) {
+		return "seg.m4s", ref.Kind == SegmentURI && ref.Attribute == "media"
+	})
+	if err != nil {
+		t.Fatalf("Rewrite() error = %v", err)
+	}
+	want := strings.ReplaceAll(string(data), `media="Colors_HD-$RepresentationID$-$Number$.m4s"`, `media="seg.m4s"`)
+	if string(result) != want {
+		t.Errorf("Rewrite() = %s\nwant %s", result, want)
+	}
+}
+
+func TestRewriteNotManifest(t *testing.T) {
+	for _, data := range []string{
+		"<html><body>403 Forbidden</body></html>",
+		"#EXTM3U\n#EXT-X-VERSION:3\n",
+		"",
+	} {
+		if _, err := Rewrite([]byte(data), nil, func(Reference) (string, bool) { return "", false }); err == nil {
+			t.Errorf("Rewrite(%q) error = nil, want an error", data)
+		}
+	}
+}
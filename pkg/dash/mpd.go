@@ -0,0 +1,377 @@
+package dash
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultLiveWindow is how far back segments of live manifests without a timeShiftBufferDepth are listed
+const defaultLiveWindow = 2 * time.Minute
+
+// ErrUnsupportedAddressing is returned for representations without a SegmentTemplate, e.g. with SegmentBase
+var ErrUnsupportedAddressing = errors.New("representation has no segment template")
+
+// MPD is a parsed DASH manifest, with the elements needed to list its segments.
+type MPD struct {
+	Type                      string   `xml:"type,attr"`
+	AvailabilityStartTime     string   `xml:"availabilityStartTime,attr"`
+	MediaPresentationDuration string   `xml:"mediaPresentationDuration,attr"`
+	MinimumUpdatePeriod       string   `xml:"minimumUpdatePeriod,attr"`
+	TimeShiftBufferDepth      string   `xml:"timeShiftBufferDepth,attr"`
+	BaseURLs                  []string `xml:"BaseURL"`
+	Periods                   []Period `xml:"Period"`
+}
+
+// Period is a Period of a manifest.
+type Period struct {
+	ID             string          `xml:"id,attr"`
+	Start          string          `xml:"start,attr"`
+	Duration       string          `xml:"duration,attr"`
+	BaseURLs       []string        `xml:"BaseURL"`
+	AdaptationSets []AdaptationSet `xml:"AdaptationSet"`
+}
+
+// AdaptationSet is an AdaptationSet of a period.
+type AdaptationSet struct {
+	ContentType       string              `xml:"contentType,attr"`
+	MimeType          string              `xml:"mimeType,attr"`
+	Codecs            string              `xml:"codecs,attr"`
+	Lang              string              `xml:"lang,attr"`
+	Width             int                 `xml:"width,attr"`
+	Height            int                 `xml:"height,attr"`
+	FrameRate         string              `xml:"frameRate,attr"`
+	BaseURLs          []string            `xml:"BaseURL"`
+	ContentProtection []ContentProtection `xml:"ContentProtection"`
+	SegmentTemplate   *SegmentTemplate    `xml:"SegmentTemplate"`
+	Representations   []Representation    `xml:"Representation"`
+}
+
+// Representation is a Representation of an adaptation set.
+type Representation struct {
+	ID                string              `xml:"id,attr"`
+	Bandwidth         int                 `xml:"bandwidth,attr"`
+	MimeType          string              `xml:"mimeType,attr"`
+	Codecs            string              `xml:"codecs,attr"`
+	Width             int                 `xml:"width,attr"`
+	Height            int                 `xml:"height,attr"`
+	FrameRate         string              `xml:"frameRate,attr"`
+	BaseURLs          []string            `xml:"BaseURL"`
+	ContentProtection []ContentProtection `xml:"ContentProtection"`
+	SegmentTemplate   *SegmentTemplate    `xml:"SegmentTemplate"`
+}
+
+// ContentProtection is a DRM system or encryption scheme of the content.
+type ContentProtection struct {
+	SchemeIDURI string `xml:"schemeIdUri,attr"`
+}
+
+// SegmentTemplate describes the segments of a representation with a URL template.
+type SegmentTemplate struct {
+	Timescale              *uint64         `xml:"timescale,attr"`
+	Duration               *uint64         `xml:"duration,attr"`
+	StartNumber            *uint64         `xml:"startNumber,attr"`
+	PresentationTimeOffset *uint64         `xml:"presentationTimeOffset,attr"`
+	Media                  string          `xml:"media,attr"`
+	Initialization         string          `xml:"initialization,attr"`
+	Timeline               []TimelineEntry `xml:"SegmentTimeline>S"`
+}
+
+// TimelineEntry is an S element of a SegmentTimeline: R+1 segments of duration D starting at T.
+type TimelineEntry struct {
+	T *uint64 `xml:"t,attr"`
+	D uint64  `xml:"d,attr"`
+	R int     `xml:"r,attr"`
+}
+
+// Segment is a media segment of a representation.
+type Segment struct {
+	Number uint64
+	// Start is the start of the segment relative to the start of the period
+	Start    time.Duration
+	Duration time.Duration
+	URL      string
+}
+
+// Decode parses a DASH manifest.
+func Decode(data []byte) (*MPD, error) {
+	var root struct {
+		XMLName xml.Name
+		MPD
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if root.XMLName.Local != "MPD" {
+		return nil, ErrNotManifest
+	}
+	return &root.MPD, nil
+}
+
+// Dynamic reports whether the manifest is a live manifest that is reloaded for new segments.
+func (m *MPD) Dynamic() bool {
+	return m.Type == "dynamic"
+}
+
+// Encrypted reports whether any representation of the period has content protection.
+func (p *Period) Encrypted() bool {
+	for _, set := range p.AdaptationSets {
+		if len(set.ContentProtection) > 0 {
+			return true
+		}
+		for _, rep := range set.Representations {
+			if len(rep.ContentProtection) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// iso8601Duration matches the durations of manifests, like PT1H2M3.5S or P1DT2H
+var iso8601Duration = regexp.MustCompile(`^P(?:(\d+(?:\.\d+)?)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// ParseDuration parses an ISO 8601 duration of a manifest. Years and months are not supported.
+func ParseDuration(value string) (time.Duration, error) {
+	match := iso8601Duration.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	var total float64
+	for i, unit := range []float64{24 * 3600, 3600, 60, 1} {
+		if match[i+1] == "" {
+			continue
+		}
+		amount, _ := strconv.ParseFloat(match[i+1], 64)
+		total += amount * unit
+	}
+	return time.Duration(total * float64(time.Second)), nil
+}
+
+// baseURL returns the URL the URLs of a representation are relative to.
+func (m *MPD) baseURL(manifestURL *url.URL, period *Period, set *AdaptationSet, rep *Representation) string {
+	base := ""
+	if manifestURL != nil {
+		base = manifestURL.String()
+	}
+	for _, urls := range [][]string{m.BaseURLs, period.BaseURLs, set.BaseURLs, rep.BaseURLs} {
+		if len(urls) > 0 {
+			base = resolve(base, strings.TrimSpace(urls[0]))
+		}
+	}
+	return base
+}
+
+// Segments returns the initialization segment and the media segments of a representation with URLs
+// resolved against manifestURL. For dynamic manifests, only the segments available at now and within
+// the time shift buffer are returned.
+func (m *MPD) Segments(manifestURL *url.URL, period *Period, set *AdaptationSet, rep *Representation, now time.Time) (string, []Segment, error) {
+	template := rep.SegmentTemplate
+	if template == nil {
+		template = set.SegmentTemplate
+	} else if set.SegmentTemplate != nil {
+		template = inheritTemplate(set.SegmentTemplate, template)
+	}
+	if template == nil || template.Media == "" {
+		return "", nil, ErrUnsupportedAddressing
+	}
+	base := m.baseURL(manifestURL, period, set, rep)
+	expand := func(pattern string, number, t uint64) string {
+		return resolve(base, expandTemplate(pattern, rep, number, t))
+	}
+
+	timescale := uint64(1)
+	if template.Timescale != nil && *template.Timescale > 0 {
+		timescale = *template.Timescale
+	}
+	startNumber := uint64(1)
+	if template.StartNumber != nil {
+		startNumber = *template.StartNumber
+	}
+	var offset uint64
+	if template.PresentationTimeOffset != nil {
+		offset = *template.PresentationTimeOffset
+	}
+	toDuration := func(ticks uint64) time.Duration {
+		return time.Duration(float64(ticks) / float64(timescale) * float64(time.Second))
+	}
+
+	// elapsed is the time since the start of the period, up to which segments are available
+	elapsed := time.Duration(-1)
+	window := time.Duration(0)
+	if m.Dynamic() {
+		availabilityStart, err := time.Parse(time.RFC3339Nano, m.AvailabilityStartTime)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid availabilityStartTime: %w", err)
+		}
+		periodStart, _ := ParseDuration(period.Start)
+		elapsed = now.Sub(availabilityStart.Add(periodStart))
+		window, _ = ParseDuration(m.TimeShiftBufferDepth)
+		if window <= 0 {
+			window = defaultLiveWindow
+		}
+	}
+
+	var segments []Segment
+	if len(template.Timeline) > 0 {
+		// Only the segments between minTicks and nowTicks are listed, so that timelines repeating
+		// until now stay short
+		ticks := func(d time.Duration) uint64 { return uint64(d.Seconds() * float64(timescale)) }
+		minTicks, nowTicks := uint64(0), uint64(math.MaxUint64)
+		if elapsed >= 0 {
+			nowTicks = offset + ticks(elapsed)
+			if ticks(window) < nowTicks {
+				minTicks = nowTicks - ticks(window)
+			}
+		}
+		number := startNumber
+		var t uint64
+		for i, entry := range template.Timeline {
+			if entry.T != nil {
+				t = *entry.T
+			}
+			if entry.D == 0 {
+				continue
+			}
+			count := uint64(entry.R + 1)
+			if entry.R < 0 {
+				// Repeat until the next entry, or for live manifests until now
+				limit := nowTicks
+				if i+1 < len(template.Timeline) && template.Timeline[i+1].T != nil {
+					limit = *template.Timeline[i+1].T
+				}
+				count = 1
+				if limit != math.MaxUint64 && limit > t {
+					count = (limit - t) / entry.D
+				}
+			}
+			if minTicks > t {
+				skip := min((minTicks-t)/entry.D, count)
+				t += skip * entry.D
+				number += skip
+				count -= skip
+			}
+			for ; count > 0; count-- {
+				segments = append(segments, Segment{
+					Number:   number,
+					Start:    toDuration(t - min(t, offset)),
+					Duration: toDuration(entry.D),
+					URL:      expand(template.Media, number, t),
+				})
+				t += entry.D
+				number++
+			}
+		}
+	} else {
+		if template.Duration == nil || *template.Duration == 0 {
+			return "", nil, ErrUnsupportedAddressing
+		}
+		duration := *template.Duration
+		count := uint64(0)
+		first := uint64(0)
+		if elapsed >= 0 {
+			// Segments are available once they have ended
+			available := uint64(max(elapsed, 0).Seconds() * float64(timescale) / float64(duration))
+			count = available
+			windowCount := uint64(window.Seconds()*float64(timescale)/float64(duration)) + 1
+			if count > windowCount {
+				first = count - windowCount
+			}
+		} else {
+			total, err := ParseDuration(period.Duration)
+			if err != nil || total == 0 {
+				total, _ = ParseDuration(m.MediaPresentationDuration)
+			}
+			count = uint64((total.Seconds()*float64(timescale) + float64(duration) - 1) / float64(duration))
+		}
+		for i := first; i < count; i++ {
+			t := offset + i*duration
+			segments = append(segments, Segment{
+				Number:   startNumber + i,
+				Start:    toDuration(i * duration),
+				Duration: toDuration(duration),
+				URL:      expand(template.Media, startNumber+i, t),
+			})
+		}
+	}
+
+	if elapsed >= 0 {
+		// Drop the segments that are not available yet or have left the time shift buffer
+		kept := segments[:0]
+		for _, segment := range segments {
+			end := segment.Start + segment.Duration
+			if end > elapsed || end < elapsed-window {
+				continue
+			}
+			kept = append(kept, segment)
+		}
+		segments = kept
+	}
+
+	initialization := ""
+	if template.Initialization != "" {
+		initialization = expand(template.Initialization, 0, 0)
+	}
+	return initialization, segments, nil
+}
+
+// inheritTemplate returns the segment template of a representation with the attributes it does not set
+// taken from the template of its adaptation set.
+func inheritTemplate(parent, child *SegmentTemplate) *SegmentTemplate {
+	merged := *child
+	if merged.Timescale == nil {
+		merged.Timescale = parent.Timescale
+	}
+	if merged.Duration == nil {
+		merged.Duration = parent.Duration
+	}
+	if merged.StartNumber == nil {
+		merged.StartNumber = parent.StartNumber
+	}
+	if merged.PresentationTimeOffset == nil {
+		merged.PresentationTimeOffset = parent.PresentationTimeOffset
+	}
+	if merged.Media == "" {
+		merged.Media = parent.Media
+	}
+	if merged.Initialization == "" {
+		merged.Initialization = parent.Initialization
+	}
+	if len(merged.Timeline) == 0 {
+		merged.Timeline = parent.Timeline
+	}
+	return &merged
+}
+
+// templateIdentifier matches the identifiers of a segment template, like $Number$ or $Time%010d$
+var templateIdentifier = regexp.MustCompile(`\$(RepresentationID|Number|Time|Bandwidth|)(%0(\d+)d)?\$`)
+
+// expandTemplate replaces the identifiers of a segment template.
+func expandTemplate(pattern string, rep *Representation, number, segmentTime uint64) string {
+	return templateIdentifier.ReplaceAllStringFunc(pattern, func(identifier string) string {
+		match := templateIdentifier.FindStringSubmatch(identifier)
+		var value string
+		switch match[1] {
+		case "":
+			return "$"
+		case "RepresentationID":
+			return rep.ID
+		case "Number":
+			value = strconv.FormatUint(number, 10)
+		case "Time":
+			value = strconv.FormatUint(segmentTime, 10)
+		case "Bandwidth":
+			value = strconv.Itoa(rep.Bandwidth)
+		}
+		if width, _ := strconv.Atoi(match[3]); len(value) < width {
+			value = strings.Repeat("0", width-len(value)) + value
+		}
+		return value
+	})
+}
@@ -0,0 +1,165 @@
+package dash
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const numberManifest = `<?xml version="1.0"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" type="dynamic" availabilityStartTime="2026-10-16T00:00:00Z" timeShiftBufferDepth="PT12S">
+  <Period id="0" start="PT0S">
+    <AdaptationSet contentType="video" mimeType="video/mp4">
+      <SegmentTemplate timescale="1" duration="4" startNumber="10" media="$RepresentationID$/$Number%05d$.m4s" initialization="$RepresentationID$/init.mp4"/>
+      <Representation id="v1" bandwidth="800000"/>
+    </AdaptationSet>
+  </Period>
+</MPD>`
+
+const timelineManifest = `<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" type="static">
+  <BaseURL>https://vod.example.com/show/</BaseURL>
+  <Period>
+    <AdaptationSet contentType="audio">
+      <SegmentTemplate timescale="1000" media="$RepresentationID$/$Time$.m4s"/>
+      <Representation id="a1" bandwidth="96000">
+        <SegmentTemplate>
+          <SegmentTimeline>
+            <S t="0" d="2000" r="1"/>
+            <S d="1000"/>
+          </SegmentTimeline>
+        </SegmentTemplate>
+      </Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>`
+
+const repeatingManifest = `<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" type="dynamic" availabilityStartTime="2026-10-16T00:00:00Z" timeShiftBufferDepth="PT6S">
+  <Period start="PT0S">
+    <AdaptationSet contentType="video">
+      <SegmentTemplate timescale="1" media="$Number$.m4s">
+        <SegmentTimeline>
+          <S t="0" d="2" r="-1"/>
+        </SegmentTimeline>
+      </SegmentTemplate>
+      <Representation id="v1" bandwidth="800000"/>
+    </AdaptationSet>
+  </Period>
+</MPD>`
+
+func TestMPDSegments(t *testing.T) {
+	manifestURL, _ := url.Parse("https://cdn.example.com/live/index.mpd?t=1")
+	start := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		manifest string
+		now      time.Time
+		wantInit string
+		want     []Segment
+	}{
+		{
+			name:     "number based live window",
+			manifest: numberManifest,
+			now:      start.Add(time.Minute),
+			wantInit: "https://cdn.example.com/live/v1/init.mp4",
+			want: []Segment{
+				{Number: 21, Start: 44 * time.Second, Duration: 4 * time.Second, URL: "https://cdn.example.com/live/v1/00021.m4s"},
+				{Number: 22, Start: 48 * time.Second, Duration: 4 * time.Second, URL: "https://cdn.example.com/live/v1/00022.m4s"},
+				{Number: 23, Start: 52 * time.Second, Duration: 4 * time.Second, URL: "https://cdn.example.com/live/v1/00023.m4s"},
+				{Number: 24, Start: 56 * time.Second, Duration: 4 * time.Second, URL: "https://cdn.example.com/live/v1/00024.m4s"},
+			},
+		},
+		{
+			name:     "static timeline with inherited template",
+			manifest: timelineManifest,
+			now:      start,
+			want: []Segment{
+				{Number: 1, Start: 0, Duration: 2 * time.Second, URL: "https://vod.example.com/show/a1/0.m4s"},
+				{Number: 2, Start: 2 * time.Second, Duration: 2 * time.Second, URL: "https://vod.example.com/show/a1/2000.m4s"},
+				{Number: 3, Start: 4 * time.Second, Duration: time.Second, URL: "https://vod.example.com/show/a1/4000.m4s"},
+			},
+		},
+		{
+			name:     "timeline repeating until now",
+			manifest: repeatingManifest,
+			now:      start.Add(20 * time.Second),
+			want: []Segment{
+				{Number: 8, Start: 14 * time.Second, Duration: 2 * time.Second, URL: "https://cdn.example.com/live/8.m4s"},
+				{Number: 9, Start: 16 * time.Second, Duration: 2 * time.Second, URL: "https://cdn.example.com/live/9.m4s"},
+				{Number: 10, Start: 18 * time.Second, Duration: 2 * time.Second, URL: "https://cdn.example.com/live/10.m4s"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Decode([]byte(tt.manifest))
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			period := &m.Periods[0]
+			set := &period.AdaptationSets[0]
+			init, segments, err := m.Segments(manifestURL, period, set, &set.Representations[0], tt.now)
+			if err != nil {
+				t.Fatalf("Segments() error = %v", err)
+			}
+			if init != tt.wantInit {
+				t.Errorf("Segments() initialization = %q, want %q", init, tt.wantInit)
+			}
+			if !reflect.DeepEqual(segments, tt.want) {
+				t.Errorf("Segments() = %+v, want %+v", segments, tt.want)
+			}
+		})
+	}
+}
+
+func TestMPDSegmentsUnsupported(t *testing.T) {
+	m, err := Decode([]byte(`<MPD type="static"><Period><AdaptationSet><Representation id="v1"/></AdaptationSet></Period></MPD>`))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	set := &m.Periods[0].AdaptationSets[0]
+	if _, _, err := m.Segments(nil, &m.Periods[0], set, &set.Representations[0], time.Now()); !errors.Is(err, ErrUnsupportedAddressing) {
+		t.Errorf("Segments() error = %v, want ErrUnsupportedAddressing", err)
+	}
+}
+
+func TestDecodeNotManifest(t *testing.T) {
+	if _, err := Decode([]byte(`<html></html>`)); !errors.Is(err, ErrNotManifest) {
+		t.Errorf("Decode() error = %v, want ErrNotManifest", err)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "PT0S", want: 0},
+		{value: "PT1H2M3.5S", want: time.Hour + 2*time.Minute + 3500*time.Millisecond},
+		{value: "P1DT2H", want: 26 * time.Hour},
+		{value: " PT30S ", want: 30 * time.Second},
+		{value: "", wantErr: true},
+		{value: "30S", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDuration(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestExpandTemplate(t *testing.T) {
+	rep := &Representation{ID: "video=800", Bandwidth: 800000}
+	got := expandTemplate("$RepresentationID$/$Bandwidth$/$Number%06d$-$Time$-$$.m4s", rep, 42, 90000)
+	if want := "video=800/800000/000042-90000-$.m4s"; got != want {
+		t.Errorf("expandTemplate() = %q, want %q", got, want)
+	}
+}
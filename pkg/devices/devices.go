@@ -0,0 +1,246 @@
+// Package devices keeps the playback tokens of devices, like a TV or a phone, that playlists are shared
+// with. Each device gets its own token, so that the playlist of one device can be revoked without
+// changing the admin token or the playlists of the others.
+package devices
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// TokenParam is the query param of device tokens
+	TokenParam = "device_token"
+	// devicesFile stores the devices under the path prefix, without their tokens
+	devicesFile = "devices.json"
+	// tokenKeyPrefix is the prefix of the store keys of device tokens. The tokens are bearer credentials,
+	// so they are kept in the store, which is encrypted with store_encryption_key, rather than in devicesFile.
+	tokenKeyPrefix = "device_token_"
+	// maxNameLength is the length of the longest device name
+	maxNameLength = 64
+)
+
+var (
+	// ErrInvalidName is returned for empty or too long device names
+	ErrInvalidName = errors.New("device name must have 1 to 64 characters")
+	// errNoStore is returned when the devices are saved before the store is initialized
+	errNoStore = errors.New("the store is not initialized")
+)
+
+// Device is a device with a playback token
+type Device struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name"`
+	Token   string    `json:"token,omitempty"`
+	Created time.Time `json:"created"`
+	// LastUsed and LastIP are of the last request with the token, zero if it was never used
+	LastUsed time.Time `json:"last_used"`
+	LastIP   string    `json:"last_ip"`
+}
+
+// Registry keeps devices and when they last used their tokens
+type Registry struct {
+	mu      sync.Mutex
+	devices []Device
+	// revoked are the IDs of the devices whose tokens are removed from the store on the next save
+	revoked []string
+	dirty   bool
+	now     func() time.Time
+}
+
+// NewRegistry returns a registry without devices.
+func NewRegistry() *Registry {
+	return &Registry{now: time.Now}
+}
+
+// randomHex returns n random bytes in hex.
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Create adds a device with a new token.
+func (r *Registry) Create(name string) (Device, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxNameLength {
+		return Device{}, ErrInvalidName
+	}
+	id, err := randomHex(4)
+	if err != nil {
+		return Device{}, err
+	}
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return Device{}, err
+	}
+	device := Device{ID: id, Name: name, Token: base64.RawURLEncoding.EncodeToString(secret), Created: r.now()}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.devices = append(r.devices, device)
+	r.dirty = true
+	return device, nil
+}
+
+// List returns the devices in the order they were created.
+func (r *Registry) List() []Device {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Device{}, r.devices...)
+}
+
+// Revoke removes a device, so that its token stops working.
+// It reports whether the device existed.
+func (r *Registry) Revoke(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, device := range r.devices {
+		if device.ID != id {
+			continue
+		}
+		r.devices = append(r.devices[:i], r.devices[i+1:]...)
+		r.revoked = append(r.revoked, id)
+		r.dirty = true
+		return true
+	}
+	return false
+}
+
+// find returns the index of the device with the token, or -1. The caller must hold the lock.
+func (r *Registry) find(token string) int {
+	if token == "" {
+		return -1
+	}
+	for i, device := range r.devices {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(device.Token)) == 1 {
+			return i
+		}
+	}
+	return -1
+}
+
+// Valid reports whether the token is the token of a device.
+func (r *Registry) Valid(token string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.find(token) >= 0
+}
+
+// Authorize reports whether a request with the token may play, and records the use of the token by
+// the client address. Every request needs the token, clients are never remembered by their address,
+// as clients behind NAT or a reverse proxy share it.
+func (r *Registry) Authorize(token, address string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.find(token)
+	if i < 0 {
+		return false
+	}
+	r.devices[i].LastUsed, r.devices[i].LastIP = r.now(), address
+	r.dirty = true
+	return true
+}
+
+// WithToken adds a device token to a URL of this server, so that the device can request it while
+// playback needs the web login.
+func WithToken(rawURL, token string) string {
+	if token == "" {
+		return rawURL
+	}
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+	return rawURL + sep + TokenParam + "=" + url.QueryEscape(token)
+}
+
+// load reads the devices from the devices file and their tokens from the store.
+func (r *Registry) load() error {
+	content, err := store.ReadDocument(devicesFile)
+	if err != nil {
+		return err
+	}
+	var devices []Device
+	if err := json.Unmarshal(content, &devices); err != nil {
+		return err
+	}
+	if store.KVS == nil {
+		return errNoStore
+	}
+	for i := range devices {
+		token, err := store.KVS.Get(tokenKeyPrefix + devices[i].ID)
+		if err != nil {
+			utils.Log.Printf("WARN: Failed to read the token of the device %s: %v", devices[i].ID, err)
+			continue
+		}
+		devices[i].Token = token
+	}
+	r.mu.Lock()
+	r.devices = devices
+	r.mu.Unlock()
+	return nil
+}
+
+// save writes the devices to the devices file if they changed since the last save.
+func (r *Registry) save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.dirty {
+		return nil
+	}
+	if store.KVS == nil {
+		return errNoStore
+	}
+	for _, id := range r.revoked {
+		if err := store.KVS.Delete(tokenKeyPrefix + id); err != nil {
+			return err
+		}
+	}
+	r.revoked = nil
+	devices := make([]Device, len(r.devices))
+	for i, device := range r.devices {
+		if err := store.KVS.Set(tokenKeyPrefix+device.ID, device.Token); err != nil {
+			return err
+		}
+		device.Token = ""
+		devices[i] = device
+	}
+	content, err := json.Marshal(devices)
+	if err != nil {
+		return err
+	}
+	if err := store.WriteDocument(devicesFile, content); err != nil {
+		return err
+	}
+	r.dirty = false
+	return nil
+}
+
+// Default is the registry used by the server
+var Default = NewRegistry()
+
+// Init loads the devices of the default registry.
+func Init() {
+	if err := Default.load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		utils.Log.Printf("WARN: Failed to read the devices: %v", err)
+	}
+}
+
+// Save writes the devices of the default registry to disk if they changed since the last save.
+func Save() error {
+	return Default.save()
+}
@@ -0,0 +1,176 @@
+package devices
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// fakeClock returns a registry whose time is set by the returned function.
+func fakeClock(start time.Time) (*Registry, func(time.Time)) {
+	r := NewRegistry()
+	now := start
+	r.now = func() time.Time { return now }
+	return r, func(t time.Time) { now = t }
+}
+
+func TestCreate(t *testing.T) {
+	r := NewRegistry()
+	tests := []struct {
+		name    string
+		device  string
+		wantErr error
+	}{
+		{name: "Valid name", device: " Living room TV ", wantErr: nil},
+		{name: "Empty name", device: "  ", wantErr: ErrInvalidName},
+		{name: "Too long name", device: strings.Repeat("a", maxNameLength+1), wantErr: ErrInvalidName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			device, err := r.Create(tt.device)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create(%q) error = %v, want %v", tt.device, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if device.Name != strings.TrimSpace(tt.device) || device.ID == "" || device.Token == "" {
+				t.Errorf("Create(%q) = %+v", tt.device, device)
+			}
+			if !r.Valid(device.Token) {
+				t.Errorf("Valid(%q) = false, want true", device.Token)
+			}
+		})
+	}
+	first, _ := r.Create("Phone")
+	second, _ := r.Create("Phone")
+	if first.Token == second.Token || first.ID == second.ID {
+		t.Error("devices should get their own ID and token")
+	}
+	if r.Valid("") || r.Valid("unknown") {
+		t.Error("empty and unknown tokens should not be valid")
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	r, setNow := fakeClock(start)
+	device, err := r.Create("TV")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Authorize("", "10.0.0.1") {
+		t.Error("a request without a token should not be authorized")
+	}
+	if r.Authorize("unknown", "10.0.0.1") {
+		t.Error("an unknown token should not be authorized")
+	}
+	if !r.Authorize(device.Token, "10.0.0.1") {
+		t.Fatal("the device token should be authorized")
+	}
+	if got := r.List()[0]; !got.LastUsed.Equal(start) || got.LastIP != "10.0.0.1" {
+		t.Errorf("last use = %v from %q, want %v from 10.0.0.1", got.LastUsed, got.LastIP, start)
+	}
+
+	// Clients sharing the address of a device, e.g. behind NAT, still need the token
+	setNow(start.Add(time.Minute))
+	if r.Authorize("", "10.0.0.1") {
+		t.Error("a request without a token from the address of the device should not be authorized")
+	}
+
+	if !r.Revoke(device.ID) {
+		t.Fatal("Revoke() = false, want true")
+	}
+	if r.Revoke(device.ID) {
+		t.Error("revoking twice should report a missing device")
+	}
+	if r.Valid(device.Token) || r.Authorize(device.Token, "10.0.0.1") {
+		t.Error("a revoked device should not be authorized")
+	}
+}
+
+func TestWithToken(t *testing.T) {
+	tests := []struct {
+		url   string
+		token string
+		want  string
+	}{
+		{"http://localhost:5001/live/143.m3u8", "", "http://localhost:5001/live/143.m3u8"},
+		{"http://localhost:5001/live/143.m3u8", "abc", "http://localhost:5001/live/143.m3u8?device_token=abc"},
+		{"http://localhost:5001/cc/1?q=high", "abc", "http://localhost:5001/cc/1?q=high&device_token=abc"},
+	}
+	for _, tt := range tests {
+		if got := WithToken(tt.url, tt.token); got != tt.want {
+			t.Errorf("WithToken(%q, %q) = %q, want %q", tt.url, tt.token, got, tt.want)
+		}
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	original := Default
+	defer func() { Default = original }()
+
+	Default = NewRegistry()
+	Init()
+	if len(Default.List()) != 0 {
+		t.Fatalf("devices without a file = %+v, want none", Default.List())
+	}
+	device, err := Default.Create("TV")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	Default = NewRegistry()
+	Init()
+	if got := Default.List(); len(got) != 1 || got[0].Token != device.Token {
+		t.Errorf("loaded devices = %+v, want %+v", got, device)
+	}
+
+	// The token is kept in the store, not in the devices file, which only its owner can read
+	filename := filepath.Join(store.GetPathPrefix(), devicesFile)
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), device.Token) {
+		t.Errorf("devices file = %s, want it without the token", content)
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 && os.PathSeparator == '/' {
+		t.Errorf("devices file mode = %v, want 0600", mode)
+	}
+
+	Default.Revoke(device.ID)
+	if err := Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := store.Get(tokenKeyPrefix + device.ID); !errors.Is(err, store.ErrKeyNotFound) {
+		t.Errorf("token of a revoked device in the store: %v, want ErrKeyNotFound", err)
+	}
+}
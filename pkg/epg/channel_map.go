@@ -0,0 +1,161 @@
+package epg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ChannelMap maps the channel IDs of an external XMLTV EPG to the JioTV channel IDs that carry them.
+// Several JioTV channels may share the guide of one XMLTV channel, e.g. the SD and HD versions.
+type ChannelMap map[string][]string
+
+// LoadChannelMap reads a JSON or YAML file that maps JioTV channel IDs to XMLTV channel IDs,
+// e.g. {"143": "News18India.in"}, and returns it indexed by XMLTV channel ID.
+func LoadChannelMap(filename string) (ChannelMap, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is valid YAML, so one decoder reads both formats
+	var jioToXMLTV map[string]string
+	if err := yaml.Unmarshal(data, &jioToXMLTV); err != nil {
+		return nil, fmt.Errorf("invalid EPG channel map %s: %w", filename, err)
+	}
+
+	jioIDs := make([]string, 0, len(jioToXMLTV))
+	for jioID := range jioToXMLTV {
+		jioIDs = append(jioIDs, jioID)
+	}
+	sort.Strings(jioIDs)
+
+	channelMap := make(ChannelMap, len(jioToXMLTV))
+	for _, jioID := range jioIDs {
+		xmltvID := jioToXMLTV[jioID]
+		if jioID == "" || xmltvID == "" {
+			continue
+		}
+		channelMap[xmltvID] = append(channelMap[xmltvID], jioID)
+	}
+	return channelMap, nil
+}
+
+// rawElement is an XML element kept as is, so that it can be written again with other attributes
+type rawElement struct {
+	Inner []byte `xml:",innerxml"`
+}
+
+// RemapChannels copies an XMLTV document from r to w, replacing the channel IDs of <channel> and
+// <programme> elements found in the channel map. Elements of XMLTV channels mapped to several
+// JioTV channels are repeated for each of them. Channels that are not in the map are kept as they are.
+func RemapChannels(r io.Reader, w io.Writer, channelMap ChannelMap) error {
+	dec := xml.NewDecoder(r)
+	enc := xml.NewEncoder(w)
+	for {
+		token, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return enc.Flush()
+		}
+		if err != nil {
+			return err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			if err := enc.EncodeToken(xml.CopyToken(token)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		attr := ""
+		switch start.Name.Local {
+		case "channel":
+			attr = "id"
+		case "programme":
+			attr = "channel"
+		}
+		jioIDs, mapped := channelMap[attrValue(start, attr)]
+		if attr == "" || !mapped {
+			if err := enc.EncodeToken(start.Copy()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var element rawElement
+		if err := dec.DecodeElement(&element, &start); err != nil {
+			return err
+		}
+		for _, jioID := range jioIDs {
+			if err := encodeRawElement(enc, w, withAttr(start, attr, jioID), element.Inner); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// encodeRawElement writes an element whose content is already encoded.
+func encodeRawElement(enc *xml.Encoder, w io.Writer, start xml.StartElement, inner []byte) error {
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	if err := enc.Flush(); err != nil {
+		return err
+	}
+	if _, err := w.Write(inner); err != nil {
+		return err
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// attrValue returns the value of an attribute of an element.
+func attrValue(start xml.StartElement, name string) string {
+	for _, a := range start.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// withAttr returns a copy of the element with the attribute set to value.
+func withAttr(start xml.StartElement, name, value string) xml.StartElement {
+	start = start.Copy()
+	for i := range start.Attr {
+		if start.Attr[i].Name.Local == name {
+			start.Attr[i].Value = value
+		}
+	}
+	return start
+}
+
+// remapEPGData applies the channel map to a plain or gzipped XMLTV document and returns it gzipped.
+func remapEPGData(data []byte, channelMap ChannelMap) ([]byte, error) {
+	var r io.Reader = bytes.NewReader(data)
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var out bytes.Buffer
+	gz := gzip.NewWriter(&out)
+	if err := RemapChannels(r, gz, channelMap); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
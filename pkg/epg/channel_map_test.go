@@ -0,0 +1,125 @@
+package epg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadChannelMap(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		want     ChannelMap
+		wantErr  bool
+	}{
+		{
+			name:     "json",
+			filename: "map.json",
+			content:  `{"143": "News.in", "144": "News.in", "145": "Sports.in", "146": ""}`,
+			want:     ChannelMap{"News.in": {"143", "144"}, "Sports.in": {"145"}},
+		},
+		{
+			name:     "yaml with numeric keys",
+			filename: "map.yml",
+			content:  "143: News.in\n145: Sports.in\n",
+			want:     ChannelMap{"News.in": {"143"}, "Sports.in": {"145"}},
+		},
+		{
+			name:     "invalid",
+			filename: "map.json",
+			content:  `["143"]`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), tt.filename)
+			if err := os.WriteFile(filename, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadChannelMap(filename)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadChannelMap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadChannelMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := LoadChannelMap(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadChannelMap() of a missing file succeeded")
+	}
+}
+
+const externalXMLTV = `<?xml version="1.0" encoding="UTF-8"?>
+<tv generator-info-name="test">
+<channel id="News.in"><display-name>News</display-name></channel>
+<channel id="Other.in"><display-name>Other &amp; More</display-name></channel>
+<programme start="20240111100000 +0530" stop="20240111110000 +0530" channel="News.in"><title lang="en">Headlines &amp; Weather</title></programme>
+<programme start="20240111100000 +0530" stop="20240111110000 +0530" channel="Other.in"><title lang="en">Other Show</title></programme>
+</tv>`
+
+func TestRemapChannels(t *testing.T) {
+	var out bytes.Buffer
+	channelMap := ChannelMap{"News.in": {"143", "144"}}
+	if err := RemapChannels(strings.NewReader(externalXMLTV), &out, channelMap); err != nil {
+		t.Fatalf("RemapChannels() error = %v", err)
+	}
+
+	guide, err := parseJSONGuide(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatalf("remapped EPG is invalid: %v\n%s", err, out.String())
+	}
+	want := map[string]string{"143": "Headlines & Weather", "144": "Headlines & Weather", "Other.in": "Other Show"}
+	if len(guide.Channels) != len(want) {
+		t.Fatalf("channels = %+v, want %d channels", guide.Channels, len(want))
+	}
+	for _, channel := range guide.Channels {
+		title, ok := want[channel.ID]
+		if !ok || len(channel.Programmes) != 1 || channel.Programmes[0].Title != title {
+			t.Errorf("channel %s = %+v", channel.ID, channel)
+		}
+	}
+	if guide.Channels[0].Name != "News" {
+		t.Errorf("channel 143 name = %q, want %q", guide.Channels[0].Name, "News")
+	}
+}
+
+func TestRemapEPGData(t *testing.T) {
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	io.WriteString(gz, externalXMLTV)
+	gz.Close()
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"plain", []byte(externalXMLTV)},
+		{"gzipped", gzipped.Bytes()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := remapEPGData(tt.data, ChannelMap{"News.in": {"143"}})
+			if err != nil {
+				t.Fatalf("remapEPGData() error = %v", err)
+			}
+			r, err := gzip.NewReader(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("remapped EPG is not gzipped: %v", err)
+			}
+			body, _ := io.ReadAll(r)
+			if !strings.Contains(string(body), `channel="143"`) || strings.Contains(string(body), `"News.in"`) {
+				t.Errorf("remapped EPG = %s", body)
+			}
+		})
+	}
+}
@@ -1,23 +1,28 @@
 package epg
 
 import (
+	"bufio"
 	"bytes"
 	"compress/gzip"
+	"context"
 	"crypto/rand"
 	"encoding/json"
-	"encoding/xml"
 	"fmt"
+	"io"
 	"math/big"
 	"net/url"
+	"strconv"
 
 	"os"
 	"sync"
 	"time"
 
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/headers"
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/tasks"
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/urls"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/scheduler"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 	"github.com/schollz/progressbar/v3"
 	"github.com/valyala/fasthttp"
@@ -82,9 +87,30 @@ func Init() {
 		flag = true
 	}
 
-	genepg := func() error {
-		fmt.Println("\tGenerating new EPG file... Please wait.")
-		err := GenXMLGz(epgFile)
+	// Resume a generation that was interrupted by the last shutdown
+	if scheduler.ResumePending(EPG_TASK_ID) {
+		utils.Log.Println("Resuming interrupted EPG generation")
+		flag = true
+	}
+
+	genepg := func(ctx context.Context) error {
+		// Keep the cached EPG file rather than replacing it with an empty guide
+		if television.IsOffline() {
+			utils.Log.Println("Offline mode, keeping the cached EPG file")
+			return nil
+		}
+		var err error
+		if config.Cfg.EPGIncremental {
+			fmt.Println("\tUpdating EPG file... Please wait.")
+			err = UpdateXMLGzContext(ctx, epgFile)
+		} else {
+			fmt.Println("\tGenerating new EPG file... Please wait.")
+			err = GenXMLGzContext(ctx, epgFile)
+		}
+		if ctx.Err() != nil {
+			utils.Log.Println("EPG generation interrupted, it will resume after restart")
+			return ctx.Err()
+		}
 		if err != nil {
 			utils.Log.Printf("ERROR: Failed to generate EPG file: %v", err)
 			fmt.Println("\tEPG file generation failed. Server will continue running without EPG.")
@@ -94,7 +120,7 @@ func Init() {
 	}
 
 	if flag {
-		genepg()
+		scheduler.Run(EPG_TASK_ID, genepg)
 	}
 	// setup random time to avoid server load
 	random_hour_bigint, err := rand.Int(rand.Reader, big.NewInt(3))
@@ -114,7 +140,7 @@ func Init() {
 	time_now := time.Now()
 	schedule_time := time.Date(time_now.Year(), time_now.Month(), time_now.Day()+1, random_hour, random_min, 0, 0, time.UTC)
 	utils.Log.Println("Scheduled EPG generation on", schedule_time.Local())
-	go scheduler.Add(EPG_TASK_ID, time.Until(schedule_time), genepg)
+	go scheduler.AddWithContext(EPG_TASK_ID, time.Until(schedule_time), genepg)
 }
 
 // NewProgramme creates a new Programme with the given parameters.
@@ -142,86 +168,59 @@ func NewProgramme(channelID int, start, stop, title, desc, category, iconSrc str
 	}
 }
 
-// genXML generates XML EPG from JioTV API and returns it as a byte slice.
-func genXML() ([]byte, error) {
-	// Create a reusable fasthttp client with common headers
-	client := utils.GetRequestClient()
-
-	// Create channels and programmes slices with initial capacity
-	var channels []Channel
+// programmesForChannel fetches the programmes of a JioTV channel for the given day offsets,
+// where 0 is today, -1 is yesterday and 1 is tomorrow.
+func programmesForChannel(client *fasthttp.Client, req *fasthttp.Request, resp *fasthttp.Response, channel ChannelObject, offsets []int) []Programme {
 	var programmes []Programme
-	var programmesMu sync.Mutex
+	for _, offset := range offsets {
+		reqUrl := fmt.Sprintf(EPG_URL, offset, channel.ChannelID)
+		req.SetRequestURI(reqUrl)
 
-	deviceID := utils.GetDeviceID()
-	crmID := ""
-	uniqueID := ""
-	if creds, err := utils.GetJIOTVCredentials(); err == nil && creds != nil {
-		crmID = creds.CRM
-		uniqueID = creds.UniqueID
-	}
-
-	// Define a worker function for fetching EPG data
-	fetchEPG := func(channel Channel, bar *progressbar.ProgressBar) {
-		req := fasthttp.AcquireRequest()
-		utils.SetCommonJioTVHeaders(req, deviceID, crmID, uniqueID)
-		req.Header.Set(headers.Accept, headers.AcceptJSON)
-		req.Header.SetMethod("GET")
-		defer fasthttp.ReleaseRequest(req)
-
-		resp := fasthttp.AcquireResponse()
-
-		for offset := 0; offset < 2; offset++ {
-			reqUrl := fmt.Sprintf(EPG_URL, offset, channel.ID)
-			req.SetRequestURI(reqUrl)
+		if err := utils.DoWithRetry(client, req, resp, utils.DefaultRetryPolicy); err != nil {
+			// Handle error
+			utils.Log.Printf("Error fetching EPG for channel %d, offset %d: %v", channel.ChannelID, offset, err)
+			continue
+		}
+		status := resp.StatusCode()
+		if status == fasthttp.StatusNotFound {
+			break
+		}
+		if status != fasthttp.StatusOK {
+			utils.Log.Printf("Error fetching EPG for channel %d, offset %d: status %d, body: %s", channel.ChannelID, offset, status, resp.Body())
+			continue
+		}
 
-			if err := client.Do(req, resp); err != nil {
-				// Handle error
-				utils.Log.Printf("Error fetching EPG for channel %d, offset %d: %v", channel.ID, offset, err)
-				continue
-			}
-			status := resp.StatusCode()
-			if status == fasthttp.StatusNotFound {
-				break
-			}
-			if status != fasthttp.StatusOK {
-				utils.Log.Printf("Error fetching EPG for channel %d, offset %d: status %d, body: %s", channel.ID, offset, status, resp.Body())
-				continue
-			}
+		body, err := responseBody(resp)
+		if err != nil {
+			utils.Log.Printf("Error reading EPG response body for channel %d, offset %d: %v", channel.ChannelID, offset, err)
+			continue
+		}
 
-			body, err := responseBody(resp)
-			if err != nil {
-				utils.Log.Printf("Error reading EPG response body for channel %d, offset %d: %v", channel.ID, offset, err)
-				continue
-			}
+		var epgResponse EPGResponse
+		if err := json.Unmarshal(body, &epgResponse); err != nil {
+			// Handle error
+			utils.Log.Printf("Error unmarshaling EPG response for channel %d, offset %d: %v", channel.ChannelID, offset, err)
+			// Print response body for debugging
+			utils.Log.Printf("Response body: %s", body)
+			continue
+		}
 
-			var epgResponse EPGResponse
-			if err := json.Unmarshal(body, &epgResponse); err != nil {
-				// Handle error
-				utils.Log.Printf("Error unmarshaling EPG response for channel %d, offset %d: %v", channel.ID, offset, err)
-				// Print response body for debugging
-				utils.Log.Printf("Response body: %s", body)
+		for _, programme := range epgResponse.EPG {
+			startT, okStart := timeFromEpoch(programme.StartEpoch)
+			endT, okEnd := timeFromEpoch(programme.EndEpoch)
+			if !okStart || !okEnd {
 				continue
 			}
-
-			for _, programme := range epgResponse.EPG {
-				startT, okStart := timeFromEpoch(programme.StartEpoch)
-				endT, okEnd := timeFromEpoch(programme.EndEpoch)
-				if !okStart || !okEnd {
-					continue
-				}
-				startTime := formatTime(startT)
-				endTime := formatTime(endT)
-				p := NewProgramme(channel.ID, startTime, endTime, programme.Title, programme.Description, programme.ShowCategory, programme.Poster)
-				programmesMu.Lock()
-				programmes = append(programmes, p)
-				programmesMu.Unlock()
-			}
+			startTime := formatTime(startT)
+			endTime := formatTime(endT)
+			programmes = append(programmes, NewProgramme(channel.ChannelID, startTime, endTime, programme.Title, programme.Description, programme.ShowCategory, programme.Poster))
 		}
-		bar.Add(1)
-		fasthttp.ReleaseResponse(resp)
 	}
+	return programmes
+}
 
-	// Fetch channels data
+// fetchEPGChannels fetches the list of channels that have programme data from JioTV API.
+func fetchEPGChannels(client *fasthttp.Client) ([]ChannelObject, error) {
 	utils.Log.Println("Fetching channels")
 	resp, err := utils.MakeHTTPRequest(utils.HTTPRequestConfig{
 		URL:    CHANNEL_URL,
@@ -235,6 +234,7 @@ func genXML() ([]byte, error) {
 			"lbcookie":         "1",
 			"usertype":         "JIO",
 		},
+		Retry: &utils.DefaultRetryPolicy,
 	}, client)
 	if err != nil {
 		return nil, utils.LogAndReturnError(err, "Failed to fetch channels")
@@ -252,51 +252,157 @@ func genXML() ([]byte, error) {
 	if err := json.Unmarshal(body, &channelsResponse); err != nil {
 		return nil, utils.LogAndReturnError(err, "Failed to parse channels response")
 	}
+	return overrideEPGChannels(filterEPGChannels(channelsResponse.Channels, config.Cfg.EPGChannels)), nil
+}
 
-	for _, channel := range channelsResponse.Channels {
-		channels = append(channels, Channel{
-			ID:      channel.ChannelID,
-			Display: channel.ChannelName,
-		})
+// channelJob is a channel whose programmes are fetched for the given day offsets
+type channelJob struct {
+	channel ChannelObject
+	offsets []int
+}
+
+// channelResult holds the programmes fetched for a channelJob
+type channelResult struct {
+	job        channelJob
+	programmes []Programme
+}
+
+// fetchProgrammesConcurrently fetches the programmes of the jobs with a pool of workers.
+// Results are sent on the returned channel as they arrive, and it is closed once all jobs are done.
+// Once ctx is done, no further jobs are started. The caller must drain the returned channel.
+func fetchProgrammesConcurrently(ctx context.Context, client *fasthttp.Client, jobs []channelJob) <-chan channelResult {
+	deviceID := utils.GetDeviceID()
+	crmID := ""
+	uniqueID := ""
+	if creds, err := utils.GetJIOTVCredentials(); err == nil && creds != nil {
+		crmID = creds.CRM
+		uniqueID = creds.UniqueID
 	}
-	utils.Log.Println("Fetched", len(channels), "channels")
+
 	// Use a worker pool to fetch EPG data concurrently
-	const numWorkers = 20 // Adjust the number of workers based on your needs
-	channelQueue := make(chan Channel, len(channels))
+	numWorkers := epgWorkers()
+	jobQueue := make(chan channelJob, len(jobs))
+	results := make(chan channelResult, numWorkers)
 	var wg sync.WaitGroup
 
 	// Create a progress bar
-	totalChannels := len(channels) // Replace with the actual number of channels
-	bar := progressbar.Default(int64(totalChannels))
+	bar := progressbar.Default(int64(len(jobs)))
 
 	utils.Log.Println("Fetching EPG for channels")
 	for i := 0; i < numWorkers; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for channel := range channelQueue {
-				fetchEPG(channel, bar)
+			req := fasthttp.AcquireRequest()
+			defer fasthttp.ReleaseRequest(req)
+			utils.SetCommonJioTVHeaders(req, deviceID, crmID, uniqueID)
+			req.Header.Set(headers.Accept, headers.AcceptJSON)
+			req.Header.SetMethod("GET")
+			resp := fasthttp.AcquireResponse()
+			defer fasthttp.ReleaseResponse(resp)
+
+			for job := range jobQueue {
+				if ctx.Err() != nil {
+					continue
+				}
+				results <- channelResult{job: job, programmes: programmesForChannel(client, req, resp, job.channel, job.offsets)}
+				bar.Add(1)
 			}
 		}()
 	}
 	// Queue channels for processing
-	for _, channel := range channels {
-		channelQueue <- channel
+	for _, job := range jobs {
+		jobQueue <- job
 	}
-	close(channelQueue)
-	wg.Wait()
+	close(jobQueue)
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
 
-	utils.Log.Println("Fetched programmes")
-	// Create EPG and marshal it to XML
-	epg := EPG{
-		Channel:   channels,
-		Programme: programmes,
+// collectChannels fetches the JioTV channels that have programme data and the channels
+// and programmes of registered sources such as plugins.
+// Sources are fetched up front, as all channels must be written before the first programme.
+func collectChannels(client *fasthttp.Client) ([]ChannelObject, []Channel, [][]Programme, error) {
+	epgChannels, err := fetchEPGChannels(client)
+	if err != nil {
+		return nil, nil, nil, err
 	}
-	xml, err := xml.Marshal(epg)
+
+	channels := make([]Channel, 0, len(epgChannels))
+	for _, channel := range epgChannels {
+		channels = append(channels, Channel{
+			ID:      strconv.Itoa(channel.ChannelID),
+			Display: channel.ChannelName,
+		})
+	}
+	utils.Log.Println("Fetched", len(channels), "channels")
+
+	var sourceProgrammes [][]Programme
+	for _, source := range getSources() {
+		fetchedChannels, fetchedProgrammes, err := source.Fetch()
+		if err != nil {
+			utils.Log.Printf("WARN: Failed to fetch EPG from %s: %v", source.Name, err)
+			continue
+		}
+		channels = append(channels, fetchedChannels...)
+		sourceProgrammes = append(sourceProgrammes, fetchedProgrammes)
+		utils.Log.Printf("Fetched %d channels and %d programmes from %s", len(fetchedChannels), len(fetchedProgrammes), source.Name)
+	}
+	return epgChannels, channels, sourceProgrammes, nil
+}
+
+// genXML generates XML EPG from JioTV API and streams it to w.
+// Programmes are written channel by channel as they are fetched, so memory use
+// stays bounded by the number of workers rather than the size of the guide.
+func genXML(ctx context.Context, w io.Writer) error {
+	// Create a reusable fasthttp client with common headers
+	client := utils.GetRequestClient()
+
+	epgChannels, channels, sourceProgrammes, err := collectChannels(client)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	xw, err := newXMLTVWriter(w)
+	if err != nil {
+		return err
+	}
+	if err := xw.WriteChannels(channels); err != nil {
+		return err
 	}
-	return xml, nil
+
+	offsets := epgOffsets()
+	jobs := make([]channelJob, 0, len(epgChannels))
+	for _, channel := range epgChannels {
+		jobs = append(jobs, channelJob{channel: channel, offsets: offsets})
+	}
+	results := fetchProgrammesConcurrently(ctx, client, jobs)
+
+	// Write programmes as they arrive. After a write error the remaining results
+	// are still drained so that the workers can finish.
+	var writeErr error
+	for result := range results {
+		if writeErr == nil {
+			writeErr = xw.WriteProgrammes(result.programmes)
+		}
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	utils.Log.Println("Fetched programmes")
+
+	for _, programmes := range sourceProgrammes {
+		if err := xw.WriteProgrammes(programmes); err != nil {
+			return err
+		}
+	}
+	return xw.Close()
 }
 
 // formatTime formats the given time to the string representation "20060102150405 -0700".
@@ -305,34 +411,55 @@ func formatTime(t time.Time) string {
 }
 
 // GenXMLGz generates XML EPG from JioTV API and writes it to a compressed gzip file.
+// The file is written to a temporary file first, so that a failed generation keeps the previous EPG.
 func GenXMLGz(filename string) error {
+	return GenXMLGzContext(context.Background(), filename)
+}
+
+// GenXMLGzContext is GenXMLGz that stops early when ctx is done, keeping the previous EPG file.
+func GenXMLGzContext(ctx context.Context, filename string) error {
 	utils.Log.Println("Generating XML")
-	xml, err := genXML()
+	err := writeXMLGz(filename, func(w io.Writer) error {
+		return genXML(ctx, w)
+	})
 	if err != nil {
 		return err
 	}
-	// Add XML header
-	xmlHeader := `<?xml version="1.0" encoding="UTF-8"?>
-	<!DOCTYPE tv SYSTEM "http://www.w3.org/2006/05/tv">`
-	xml = append([]byte(xmlHeader), xml...)
-	// write to file
-	f, err := os.Create(filename)
+	fmt.Println("\tEPG file generated successfully")
+	return nil
+}
+
+// writeXMLGz writes the output of gen to a temporary gzip file and renames it to filename on success.
+func writeXMLGz(filename string, gen func(w io.Writer) error) error {
+	tmp := filename + ".tmp"
+	f, err := os.Create(tmp)
 	if err != nil {
 		return err
 	}
-	defer f.Close() // skipcq: GO-S2307
+	defer os.Remove(tmp)
 
-	utils.Log.Println("Writing XML to gzip file")
 	gz := gzip.NewWriter(f)
-	defer gz.Close()
-
-	if _, err := gz.Write(xml); err != nil {
+	buf := bufio.NewWriter(gz)
+	if err := gen(buf); err != nil {
+		f.Close()
 		return err
 	}
-	fmt.Println("\tEPG file generated successfully")
-	return nil
+	if err := buf.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
 }
 
+// DownloadExternalEPG downloads the EPG at epgURL to filename. Channel IDs are mapped to JioTV
+// channel IDs with the file set in config.Cfg.EPGChannelMapFile, if any.
 func DownloadExternalEPG(epgURL, filename string) error {
 	client := utils.GetRequestClient()
 
@@ -381,6 +508,14 @@ func DownloadExternalEPG(epgURL, filename string) error {
 		data := append([]byte(nil), resp.Body()...)
 		fasthttp.ReleaseResponse(resp)
 
+		if mapFile := config.Cfg.EPGChannelMapFile; mapFile != "" {
+			if channelMap, err := LoadChannelMap(mapFile); err != nil {
+				utils.Log.Printf("WARN: Serving external EPG without channel mapping: %v", err)
+			} else if data, err = remapEPGData(data, channelMap); err != nil {
+				return fmt.Errorf("failed to map external EPG channels: %w", err)
+			}
+		}
+
 		tmp := filename + ".tmp"
 		if err := os.WriteFile(tmp, data, 0644); err != nil {
 			return err
@@ -0,0 +1,197 @@
+package epg
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// coverageSlack is how early before midnight the programmes of a day may end for the day to count as complete
+const coverageSlack = time.Hour
+
+// istLocation is the time zone of the day offsets in the JioTV EPG API
+var istLocation = time.FixedZone("IST", 5*60*60+30*60)
+
+// dayStart returns the start of the JioTV day at the given offset from now.
+func dayStart(now time.Time, offset int) time.Time {
+	now = now.In(istLocation)
+	return time.Date(now.Year(), now.Month(), now.Day()+offset, 0, 0, 0, 0, istLocation)
+}
+
+// offsetsToFetch returns the day offsets that are missing from a channel whose
+// existing programmes end at coverageEnd. It returns nil if the channel is complete.
+func offsetsToFetch(coverageEnd, now time.Time, offsets []int) []int {
+	for i, offset := range offsets {
+		if coverageEnd.Before(dayStart(now, offset+1).Add(-coverageSlack)) {
+			return offsets[i:]
+		}
+	}
+	return nil
+}
+
+// parseProgrammeTime parses a programme start or stop time written by formatTime.
+func parseProgrammeTime(value string) (time.Time, bool) {
+	t, err := time.Parse("20060102150405 -0700", value)
+	return t, err == nil
+}
+
+// scanEPGFile decodes the programmes of a gzipped XMLTV file one at a time, so the file never has to be held in memory.
+func scanEPGFile(filename string, fn func(Programme) error) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	dec := xml.NewDecoder(gz)
+	for {
+		token, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "programme" {
+			continue
+		}
+		var programme Programme
+		if err := dec.DecodeElement(&programme, &start); err != nil {
+			return err
+		}
+		if err := fn(programme); err != nil {
+			return err
+		}
+	}
+}
+
+// epgCoverage returns the latest programme stop time of each channel in an EPG file.
+func epgCoverage(filename string) (map[string]time.Time, error) {
+	coverage := make(map[string]time.Time)
+	err := scanEPGFile(filename, func(programme Programme) error {
+		if stop, ok := parseProgrammeTime(programme.Stop); ok && stop.After(coverage[programme.Channel]) {
+			coverage[programme.Channel] = stop
+		}
+		return nil
+	})
+	return coverage, err
+}
+
+// UpdateXMLGz updates an existing EPG file instead of regenerating it. Only channels that are
+// new or whose programmes do not reach the end of the last configured day are fetched, and only
+// for the missing days. Programmes that ended before the first configured day are dropped. If the file does not exist or cannot be read,
+// a full generation is done with GenXMLGz.
+func UpdateXMLGz(filename string) error {
+	return UpdateXMLGzContext(context.Background(), filename)
+}
+
+// UpdateXMLGzContext is UpdateXMLGz that stops early when ctx is done, keeping the previous EPG file.
+func UpdateXMLGzContext(ctx context.Context, filename string) error {
+	if !utils.FileExists(filename) {
+		return GenXMLGzContext(ctx, filename)
+	}
+	coverage, err := epgCoverage(filename)
+	if err != nil {
+		utils.Log.Printf("WARN: Failed to read existing EPG file, regenerating it: %v", err)
+		return GenXMLGzContext(ctx, filename)
+	}
+
+	utils.Log.Println("Updating XML")
+	err = writeXMLGz(filename, func(w io.Writer) error {
+		return genXMLIncremental(ctx, w, filename, coverage, time.Now())
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println("\tEPG file updated successfully")
+	return nil
+}
+
+// genXMLIncremental writes the programmes of the existing EPG file merged with freshly fetched programmes to w.
+// existing must not be the file being written.
+func genXMLIncremental(ctx context.Context, w io.Writer, existing string, coverage map[string]time.Time, now time.Time) error {
+	client := utils.GetRequestClient()
+
+	epgChannels, channels, sourceProgrammes, err := collectChannels(client)
+	if err != nil {
+		return err
+	}
+
+	offsets := epgOffsets()
+	jioChannels := make(map[string]bool, len(epgChannels))
+	var jobs []channelJob
+	for _, channel := range epgChannels {
+		id := strconv.Itoa(channel.ChannelID)
+		jioChannels[id] = true
+		if offsets := offsetsToFetch(coverage[id], now, offsets); len(offsets) > 0 {
+			jobs = append(jobs, channelJob{channel: channel, offsets: offsets})
+		}
+	}
+	utils.Log.Printf("Fetching EPG for %d of %d channels", len(jobs), len(epgChannels))
+
+	// Fetched programmes are held until the existing programmes are written,
+	// as an update usually fetches a single day of programmes.
+	fetched := make(map[string]channelResult)
+	for result := range fetchProgrammesConcurrently(ctx, client, jobs) {
+		if len(result.programmes) > 0 {
+			fetched[strconv.Itoa(result.job.channel.ChannelID)] = result
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	xw, err := newXMLTVWriter(w)
+	if err != nil {
+		return err
+	}
+	if err := xw.WriteChannels(channels); err != nil {
+		return err
+	}
+
+	firstDayStart := dayStart(now, offsets[0])
+	err = scanEPGFile(existing, func(programme Programme) error {
+		// Programmes of sources are fetched again, and removed channels are dropped
+		if !jioChannels[programme.Channel] {
+			return nil
+		}
+		stop, ok := parseProgrammeTime(programme.Stop)
+		if !ok || !stop.After(firstDayStart) {
+			return nil
+		}
+		// Fetched programmes replace existing ones from the first fetched day onwards
+		if result, ok := fetched[programme.Channel]; ok && stop.After(dayStart(now, result.job.offsets[0])) {
+			return nil
+		}
+		return xw.WriteProgrammes([]Programme{programme})
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, result := range fetched {
+		if err := xw.WriteProgrammes(result.programmes); err != nil {
+			return err
+		}
+	}
+	for _, programmes := range sourceProgrammes {
+		if err := xw.WriteProgrammes(programmes); err != nil {
+			return err
+		}
+	}
+	return xw.Close()
+}
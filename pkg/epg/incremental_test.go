@@ -0,0 +1,76 @@
+package epg
+
+import (
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOffsetsToFetch(t *testing.T) {
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, istLocation)
+	tests := []struct {
+		name        string
+		coverageEnd time.Time
+		want        []int
+	}{
+		{name: "new channel", coverageEnd: time.Time{}, want: []int{0, 1}},
+		{name: "ends yesterday", coverageEnd: time.Date(2024, 1, 10, 0, 0, 0, 0, istLocation), want: []int{0, 1}},
+		{name: "ends today", coverageEnd: time.Date(2024, 1, 11, 0, 30, 0, 0, istLocation), want: []int{1}},
+		{name: "ends shortly before midnight of today", coverageEnd: time.Date(2024, 1, 10, 23, 30, 0, 0, istLocation), want: []int{1}},
+		{name: "ends tomorrow", coverageEnd: time.Date(2024, 1, 12, 0, 0, 0, 0, istLocation), want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := offsetsToFetch(tt.coverageEnd, now, []int{0, 1}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("offsetsToFetch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDayStart(t *testing.T) {
+	// 20:00 UTC is already the next day in India
+	now := time.Date(2024, 1, 10, 20, 0, 0, 0, time.UTC)
+	want := time.Date(2024, 1, 12, 0, 0, 0, 0, istLocation)
+	if got := dayStart(now, 1); !got.Equal(want) {
+		t.Errorf("dayStart() = %v, want %v", got, want)
+	}
+}
+
+func TestEPGCoverage(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "epg.xml.gz")
+	err := writeXMLGz(filename, func(w io.Writer) error {
+		xw, err := newXMLTVWriter(w)
+		if err != nil {
+			return err
+		}
+		if err := xw.WriteChannels([]Channel{{ID: "143", Display: "News"}}); err != nil {
+			return err
+		}
+		err = xw.WriteProgrammes([]Programme{
+			NewProgramme(143, "20240110230000 +0530", "20240111010000 +0530", "Late", "", "", ""),
+			NewProgramme(143, "20240110220000 +0530", "20240110230000 +0530", "Early", "", "", ""),
+			NewProgramme(144, "20240110220000 +0530", "20240110230000 +0530", "Other", "", "", ""),
+		})
+		if err != nil {
+			return err
+		}
+		return xw.Close()
+	})
+	if err != nil {
+		t.Fatalf("writeXMLGz() error = %v", err)
+	}
+
+	coverage, err := epgCoverage(filename)
+	if err != nil {
+		t.Fatalf("epgCoverage() error = %v", err)
+	}
+	if want := time.Date(2024, 1, 11, 1, 0, 0, 0, istLocation); !coverage["143"].Equal(want) {
+		t.Errorf("coverage[143] = %v, want %v", coverage["143"], want)
+	}
+	if want := time.Date(2024, 1, 10, 23, 0, 0, 0, istLocation); !coverage["144"].Equal(want) {
+		t.Errorf("coverage[144] = %v, want %v", coverage["144"], want)
+	}
+}
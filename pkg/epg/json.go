@@ -0,0 +1,211 @@
+package epg
+
+import (
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// JSONProgramme is a programme in the JSON EPG
+type JSONProgramme struct {
+	Start       time.Time `json:"start"`
+	Stop        time.Time `json:"stop"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Category    string    `json:"category,omitempty"`
+	Icon        string    `json:"icon,omitempty"`
+}
+
+// JSONChannel is a channel with its programmes in the JSON EPG
+type JSONChannel struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Programmes []JSONProgramme `json:"programmes"`
+}
+
+// JSONGuide is the EPG in JSON format. It is built from the XMLTV file, so both formats always have the same data.
+type JSONGuide struct {
+	Channels []JSONChannel `json:"channels"`
+	// index maps channel IDs to positions in Channels
+	index map[string]int
+}
+
+var (
+	// jsonGuideMu guards the cached guide
+	jsonGuideMu sync.Mutex
+	// jsonGuide is the guide built from jsonGuideFile at jsonGuideModTime
+	jsonGuide        *JSONGuide
+	jsonGuideFile    string
+	jsonGuideModTime time.Time
+)
+
+// ReadJSONGuide returns the JSON guide of a gzipped XMLTV file. The guide is cached until the file changes.
+func ReadJSONGuide(filename string) (*JSONGuide, error) {
+	stat, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonGuideMu.Lock()
+	defer jsonGuideMu.Unlock()
+	if jsonGuide != nil && jsonGuideFile == filename && jsonGuideModTime.Equal(stat.ModTime()) {
+		return jsonGuide, nil
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	guide, err := parseJSONGuide(gz)
+	if err != nil {
+		return nil, err
+	}
+	jsonGuide, jsonGuideFile, jsonGuideModTime = guide, filename, stat.ModTime()
+	return guide, nil
+}
+
+// parseJSONGuide builds a JSON guide from an XMLTV document.
+func parseJSONGuide(r io.Reader) (*JSONGuide, error) {
+	guide := &JSONGuide{Channels: []JSONChannel{}, index: make(map[string]int)}
+	dec := xml.NewDecoder(r)
+	for {
+		token, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return guide, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "channel":
+			var channel Channel
+			if err := dec.DecodeElement(&channel, &start); err != nil {
+				return nil, err
+			}
+			guide.channel(channel.ID).Name = channel.Display
+		case "programme":
+			var programme Programme
+			if err := dec.DecodeElement(&programme, &start); err != nil {
+				return nil, err
+			}
+			startTime, okStart := parseProgrammeTime(programme.Start)
+			stopTime, okStop := parseProgrammeTime(programme.Stop)
+			if !okStart || !okStop {
+				continue
+			}
+			channel := guide.channel(programme.Channel)
+			channel.Programmes = append(channel.Programmes, JSONProgramme{
+				Start:       startTime,
+				Stop:        stopTime,
+				Title:       programme.Title.Value,
+				Description: programme.Desc.Value,
+				Category:    programme.Category.Value,
+				Icon:        programme.Icon.Src,
+			})
+		}
+	}
+}
+
+// channel returns the channel with the given ID, adding it if needed.
+func (g *JSONGuide) channel(id string) *JSONChannel {
+	i, ok := g.index[id]
+	if !ok {
+		i = len(g.Channels)
+		g.index[id] = i
+		g.Channels = append(g.Channels, JSONChannel{ID: id, Name: id, Programmes: []JSONProgramme{}})
+	}
+	return &g.Channels[i]
+}
+
+// Channel returns the channel with the given ID and its programmes that air on the given day,
+// where 0 is today, -1 is yesterday and 1 is tomorrow. It returns false if the channel is not in the guide.
+func (g *JSONGuide) Channel(id string, day int, now time.Time) (JSONChannel, bool) {
+	i, ok := g.index[id]
+	if !ok {
+		return JSONChannel{}, false
+	}
+	channel := g.Channels[i]
+	channel.Programmes, _ = g.Window(id, dayStart(now, day), dayStart(now, day+1))
+	return channel, true
+}
+
+// Window returns the programmes of a channel that air between from and to, in the order of the guide.
+// It returns false if the channel is not in the guide.
+func (g *JSONGuide) Window(id string, from, to time.Time) ([]JSONProgramme, bool) {
+	i, ok := g.index[id]
+	if !ok {
+		return nil, false
+	}
+	programmes := []JSONProgramme{}
+	for _, programme := range g.Channels[i].Programmes {
+		if programme.Stop.After(from) && programme.Start.Before(to) {
+			programmes = append(programmes, programme)
+		}
+	}
+	return programmes, true
+}
+
+// NowNext returns the programme of a channel airing at now and the one after it.
+// Either may be nil if the guide has no such programme. It returns false if the channel is not in the guide.
+func (g *JSONGuide) NowNext(id string, now time.Time) (current, next *JSONProgramme, ok bool) {
+	i, ok := g.index[id]
+	if !ok {
+		return nil, nil, false
+	}
+	programmes := g.Channels[i].Programmes
+	for j := range programmes {
+		if !programmes[j].Stop.After(now) {
+			continue
+		}
+		if !programmes[j].Start.After(now) {
+			if current == nil {
+				current = &programmes[j]
+			}
+			continue
+		}
+		if next == nil || programmes[j].Start.Before(next.Start) {
+			next = &programmes[j]
+		}
+	}
+	return current, next, true
+}
+
+// NewJSONProgramme converts a programme of the JioTV EPG API to a JSON EPG programme.
+// Posters are prefixed with posterBaseURL. It returns nil if the programme is nil or has no valid times.
+func NewJSONProgramme(programme *EPGObject, posterBaseURL string) *JSONProgramme {
+	if programme == nil {
+		return nil
+	}
+	start, okStart := timeFromEpoch(programme.StartEpoch)
+	stop, okStop := timeFromEpoch(programme.EndEpoch)
+	if !okStart || !okStop {
+		return nil
+	}
+	result := &JSONProgramme{
+		Start:       start,
+		Stop:        stop,
+		Title:       programme.Title,
+		Description: programme.Description,
+		Category:    programme.ShowCategory,
+	}
+	if programme.Poster != "" {
+		result.Icon = posterBaseURL + programme.Poster
+	}
+	return result
+}
@@ -0,0 +1,238 @@
+package epg
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testXMLTV = `<?xml version="1.0" encoding="UTF-8"?>
+<tv>
+<channel id="143"><display-name>News</display-name></channel>
+<programme channel="143" start="20240110230000 +0530" stop="20240111010000 +0530"><title lang="en">Late Show</title><desc lang="en">Desc</desc><category lang="en">Talk</category><icon src="https://example.com/a.jpg"></icon></programme>
+<programme channel="143" start="20240111100000 +0530" stop="20240111110000 +0530"><title lang="en">Morning</title><desc lang="en"></desc><category lang="en"></category><icon src=""></icon></programme>
+<programme channel="999" start="20240111100000 +0530" stop="20240111110000 +0530"><title lang="en">Orphan</title><desc lang="en"></desc><category lang="en"></category><icon src=""></icon></programme>
+<programme channel="143" start="invalid" stop="20240111110000 +0530"><title lang="en">Broken</title><desc lang="en"></desc><category lang="en"></category><icon src=""></icon></programme>
+</tv>`
+
+func TestParseJSONGuide(t *testing.T) {
+	guide, err := parseJSONGuide(strings.NewReader(testXMLTV))
+	if err != nil {
+		t.Fatalf("parseJSONGuide() error = %v", err)
+	}
+	if len(guide.Channels) != 2 {
+		t.Fatalf("channels = %+v, want 2 channels", guide.Channels)
+	}
+	news := guide.Channels[0]
+	if news.ID != "143" || news.Name != "News" || len(news.Programmes) != 2 {
+		t.Errorf("channel 143 = %+v", news)
+	}
+	want := JSONProgramme{
+		Start:       time.Date(2024, 1, 10, 23, 0, 0, 0, istLocation),
+		Stop:        time.Date(2024, 1, 11, 1, 0, 0, 0, istLocation),
+		Title:       "Late Show",
+		Description: "Desc",
+		Category:    "Talk",
+		Icon:        "https://example.com/a.jpg",
+	}
+	got := news.Programmes[0]
+	if !got.Start.Equal(want.Start) || !got.Stop.Equal(want.Stop) || got.Title != want.Title ||
+		got.Description != want.Description || got.Category != want.Category || got.Icon != want.Icon {
+		t.Errorf("programme = %+v, want %+v", got, want)
+	}
+	// Programmes of channels without a channel element still show up
+	if orphan := guide.Channels[1]; orphan.ID != "999" || orphan.Name != "999" || len(orphan.Programmes) != 1 {
+		t.Errorf("channel 999 = %+v", orphan)
+	}
+}
+
+func TestJSONGuideChannel(t *testing.T) {
+	guide, err := parseJSONGuide(strings.NewReader(testXMLTV))
+	if err != nil {
+		t.Fatalf("parseJSONGuide() error = %v", err)
+	}
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, istLocation)
+
+	tests := []struct {
+		name   string
+		id     string
+		day    int
+		want   []string
+		wantOK bool
+	}{
+		{name: "today", id: "143", day: 0, want: []string{"Late Show"}, wantOK: true},
+		{name: "tomorrow includes programme spanning midnight", id: "143", day: 1, want: []string{"Late Show", "Morning"}, wantOK: true},
+		{name: "day without programmes", id: "143", day: -1, want: []string{}, wantOK: true},
+		{name: "unknown channel", id: "1", day: 0, wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channel, ok := guide.Channel(tt.id, tt.day, now)
+			if ok != tt.wantOK {
+				t.Fatalf("Channel() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			titles := []string{}
+			for _, programme := range channel.Programmes {
+				titles = append(titles, programme.Title)
+			}
+			if strings.Join(titles, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Channel() programmes = %v, want %v", titles, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONGuideWindow(t *testing.T) {
+	guide, err := parseJSONGuide(strings.NewReader(testXMLTV))
+	if err != nil {
+		t.Fatalf("parseJSONGuide() error = %v", err)
+	}
+	at := func(hour, minute int) time.Time { return time.Date(2024, 1, 11, hour, minute, 0, 0, istLocation) }
+
+	tests := []struct {
+		name     string
+		id       string
+		from, to time.Time
+		want     []string
+		wantOK   bool
+	}{
+		{name: "programme airing at from", id: "143", from: at(0, 30), to: at(3, 0), want: []string{"Late Show"}, wantOK: true},
+		{name: "both programmes", id: "143", from: at(0, 0), to: at(12, 0), want: []string{"Late Show", "Morning"}, wantOK: true},
+		{name: "programme ending at from", id: "143", from: at(1, 0), to: at(10, 0), want: []string{}, wantOK: true},
+		{name: "unknown channel", id: "1", from: at(0, 0), to: at(12, 0), wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			programmes, ok := guide.Window(tt.id, tt.from, tt.to)
+			if ok != tt.wantOK {
+				t.Fatalf("Window() ok = %v, want %v", ok, tt.wantOK)
+			}
+			titles := []string{}
+			for _, programme := range programmes {
+				titles = append(titles, programme.Title)
+			}
+			if ok && strings.Join(titles, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Window() programmes = %v, want %v", titles, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadJSONGuide(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "epg.xml.gz")
+	write := func(programmes []Programme) {
+		if err := writeXMLGz(filename, func(w io.Writer) error {
+			xw, err := newXMLTVWriter(w)
+			if err != nil {
+				return err
+			}
+			if err := xw.WriteProgrammes(programmes); err != nil {
+				return err
+			}
+			return xw.Close()
+		}); err != nil {
+			t.Fatalf("writeXMLGz() error = %v", err)
+		}
+	}
+
+	write([]Programme{NewProgramme(143, "20240110230000 +0530", "20240111010000 +0530", "First", "", "", "")})
+	guide, err := ReadJSONGuide(filename)
+	if err != nil {
+		t.Fatalf("ReadJSONGuide() error = %v", err)
+	}
+	if again, _ := ReadJSONGuide(filename); again != guide {
+		t.Error("ReadJSONGuide() did not return the cached guide")
+	}
+
+	write([]Programme{NewProgramme(143, "20240110230000 +0530", "20240111010000 +0530", "Second", "", "", "")})
+	// Make sure the modification time changes on file systems with coarse timestamps
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filename, later, later); err != nil {
+		t.Fatal(err)
+	}
+	guide, err = ReadJSONGuide(filename)
+	if err != nil {
+		t.Fatalf("ReadJSONGuide() error = %v", err)
+	}
+	if title := guide.Channels[0].Programmes[0].Title; title != "Second" {
+		t.Errorf("title = %q after the file changed, want %q", title, "Second")
+	}
+
+	if _, err := ReadJSONGuide(filepath.Join(t.TempDir(), "missing.xml.gz")); err == nil {
+		t.Error("ReadJSONGuide() of a missing file should fail")
+	}
+}
+
+func TestJSONGuideNowNext(t *testing.T) {
+	guide, err := parseJSONGuide(strings.NewReader(testXMLTV))
+	if err != nil {
+		t.Fatalf("parseJSONGuide() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		id       string
+		now      time.Time
+		wantOK   bool
+		wantNow  string
+		wantNext string
+	}{
+		{"airing with next", "143", time.Date(2024, 1, 11, 0, 0, 0, 0, istLocation), true, "Late Show", "Morning"},
+		{"between programmes", "143", time.Date(2024, 1, 11, 5, 0, 0, 0, istLocation), true, "", "Morning"},
+		{"after last programme", "143", time.Date(2024, 1, 11, 12, 0, 0, 0, istLocation), true, "", ""},
+		{"unknown channel", "1", time.Date(2024, 1, 11, 0, 0, 0, 0, istLocation), false, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, next, ok := guide.NowNext(tt.id, tt.now)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if title := programmeTitle(current); title != tt.wantNow {
+				t.Errorf("now = %q, want %q", title, tt.wantNow)
+			}
+			if title := programmeTitle(next); title != tt.wantNext {
+				t.Errorf("next = %q, want %q", title, tt.wantNext)
+			}
+		})
+	}
+}
+
+func programmeTitle(programme *JSONProgramme) string {
+	if programme == nil {
+		return ""
+	}
+	return programme.Title
+}
+
+func TestNewJSONProgramme(t *testing.T) {
+	if got := NewJSONProgramme(nil, ""); got != nil {
+		t.Errorf("NewJSONProgramme(nil) = %+v, want nil", got)
+	}
+	if got := NewJSONProgramme(&EPGObject{Title: "No times"}, ""); got != nil {
+		t.Errorf("NewJSONProgramme() without times = %+v, want nil", got)
+	}
+
+	got := NewJSONProgramme(&EPGObject{
+		StartEpoch:   1704960000000,
+		EndEpoch:     1704963600000,
+		Title:        "Show",
+		ShowCategory: "News",
+		Poster:       "2024-01-11/show.jpg",
+	}, "http://localhost:5001/jtvposter/")
+	if got == nil {
+		t.Fatal("NewJSONProgramme() = nil")
+	}
+	if !got.Start.Equal(time.UnixMilli(1704960000000)) || !got.Stop.Equal(time.UnixMilli(1704963600000)) {
+		t.Errorf("times = %v - %v", got.Start, got.Stop)
+	}
+	if got.Title != "Show" || got.Category != "News" || got.Icon != "http://localhost:5001/jtvposter/2024-01-11/show.jpg" {
+		t.Errorf("programme = %+v", got)
+	}
+}
@@ -0,0 +1,139 @@
+package epg
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants/headers"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+	"github.com/valyala/fasthttp"
+)
+
+// nowNextCacheAge is how long the programmes of a channel are reused for now/next lookups
+const nowNextCacheAge = 30 * time.Minute
+
+// cachedProgrammes is the EPG of a channel with the time it was fetched
+type cachedProgrammes struct {
+	programmes []EPGObject
+	fetchedAt  time.Time
+	// nextDay is whether tomorrow's programmes were fetched too
+	nextDay bool
+}
+
+var (
+	// programmesCache holds today's programmes of each channel, and tomorrow's once they were needed
+	programmesCache sync.Map
+	// programmesFetcher fetches the programmes of a channel for a day offset, replaced in tests
+	programmesFetcher = fetchProgrammes
+
+	// nowNextClient is the client shared by the now/next lookups, so that they reuse connections
+	nowNextClient     *fasthttp.Client
+	nowNextClientOnce sync.Once
+)
+
+// getNowNextClient returns the shared client of the now/next lookups.
+func getNowNextClient() *fasthttp.Client {
+	nowNextClientOnce.Do(func() {
+		nowNextClient = utils.GetRequestClient()
+	})
+	return nowNextClient
+}
+
+// findNowNext returns the programme airing at now and the one after it.
+func findNowNext(programmes []EPGObject, now time.Time) (current, next *EPGObject) {
+	for i := range programmes {
+		start, okStart := timeFromEpoch(programmes[i].StartEpoch)
+		end, okEnd := timeFromEpoch(programmes[i].EndEpoch)
+		if !okStart || !okEnd || !end.After(now) {
+			continue
+		}
+		if !start.After(now) {
+			if current == nil {
+				current = &programmes[i]
+			}
+			continue
+		}
+		if next == nil || programmes[i].StartEpoch < next.StartEpoch {
+			next = &programmes[i]
+		}
+	}
+	return current, next
+}
+
+// fetchProgrammes fetches the programmes of a JioTV channel for a day offset, 0 for today.
+func fetchProgrammes(channelID, offset int) ([]EPGObject, error) {
+	deviceID := utils.GetDeviceID()
+	crmID := ""
+	uniqueID := ""
+	if creds, err := utils.GetJIOTVCredentials(); err == nil && creds != nil {
+		crmID = creds.CRM
+		uniqueID = creds.UniqueID
+	}
+
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	utils.SetCommonJioTVHeaders(req, deviceID, crmID, uniqueID)
+	req.Header.Set(headers.Accept, headers.AcceptJSON)
+	req.Header.SetMethod("GET")
+	req.SetRequestURI(fmt.Sprintf(EPG_URL, offset, channelID))
+
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+
+	if err := utils.DoWithRetry(getNowNextClient(), req, resp, utils.DefaultRetryPolicy); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return nil, fmt.Errorf("epg request for channel %d failed: status %d", channelID, resp.StatusCode())
+	}
+	body, err := responseBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	var epgResponse EPGResponse
+	if err := json.Unmarshal(body, &epgResponse); err != nil {
+		return nil, err
+	}
+	return epgResponse.EPG, nil
+}
+
+// NowNext returns the programme airing now on a JioTV channel and the one after it.
+// Either may be nil if the guide has no such programme. When the current programme is the last
+// of the day, the next one is looked up in tomorrow's programmes. Programmes are cached for a while,
+// so that repeated lookups do not hit the EPG API.
+func NowNext(channelID string, now time.Time) (current, next *EPGObject, err error) {
+	id, err := strconv.Atoi(strings.TrimPrefix(channelID, "sl"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("no EPG for channel %s", channelID)
+	}
+
+	value, ok := programmesCache.Load(id)
+	cached, _ := value.(cachedProgrammes)
+	if !ok || time.Since(cached.fetchedAt) >= nowNextCacheAge {
+		programmes, err := programmesFetcher(id, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		cached = cachedProgrammes{programmes: programmes, fetchedAt: time.Now()}
+		programmesCache.Store(id, cached)
+	}
+
+	current, next = findNowNext(cached.programmes, now)
+	if next == nil && !cached.nextDay {
+		// Tomorrow is fetched once per cache period, even if it fails, so lookups do not retry it every time
+		cached.nextDay = true
+		if tomorrow, err := programmesFetcher(id, 1); err == nil {
+			// Copy, as lookups may still read the cached programmes
+			cached.programmes = append(cached.programmes[:len(cached.programmes):len(cached.programmes)], tomorrow...)
+			current, next = findNowNext(cached.programmes, now)
+		} else {
+			utils.SafeLogf("WARN: Failed to fetch tomorrow's EPG of channel %d: %v", id, err)
+		}
+		programmesCache.Store(id, cached)
+	}
+	return current, next, nil
+}
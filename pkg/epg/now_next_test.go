@@ -0,0 +1,96 @@
+package epg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFindNowNext(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)
+	at := func(hour int) int64 {
+		return time.Date(2024, 1, 1, hour, 0, 0, 0, time.UTC).UnixMilli()
+	}
+	programmes := []EPGObject{
+		{Title: "Morning", StartEpoch: at(9), EndEpoch: at(10)},
+		{Title: "Late", StartEpoch: at(12), EndEpoch: at(13)},
+		{Title: "Current", StartEpoch: at(10), EndEpoch: at(11)},
+		{Title: "Next", StartEpoch: at(11), EndEpoch: at(12)},
+	}
+
+	tests := []struct {
+		name        string
+		programmes  []EPGObject
+		now         time.Time
+		wantCurrent string
+		wantNext    string
+	}{
+		{name: "current and next", programmes: programmes, now: now, wantCurrent: "Current", wantNext: "Next"},
+		{name: "before guide", programmes: programmes, now: now.Add(-3 * time.Hour), wantNext: "Morning"},
+		{name: "after guide", programmes: programmes, now: now.Add(5 * time.Hour)},
+		{name: "empty guide", now: now},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, next := findNowNext(tt.programmes, tt.now)
+			if got := titleOf(current); got != tt.wantCurrent {
+				t.Errorf("current = %q, want %q", got, tt.wantCurrent)
+			}
+			if got := titleOf(next); got != tt.wantNext {
+				t.Errorf("next = %q, want %q", got, tt.wantNext)
+			}
+		})
+	}
+}
+
+func titleOf(programme *EPGObject) string {
+	if programme == nil {
+		return ""
+	}
+	return programme.Title
+}
+
+func TestNowNextFetchesTomorrow(t *testing.T) {
+	original := programmesFetcher
+	defer func() { programmesFetcher = original }()
+	defer programmesCache.Delete(143)
+
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hours int) int64 {
+		return day.Add(time.Duration(hours) * time.Hour).UnixMilli()
+	}
+	fetched := map[int]int{}
+	programmesFetcher = func(channelID, offset int) ([]EPGObject, error) {
+		fetched[offset]++
+		if offset == 0 {
+			return []EPGObject{
+				{Title: "Evening", StartEpoch: at(20), EndEpoch: at(22)},
+				{Title: "Late Night", StartEpoch: at(22), EndEpoch: at(24)},
+			}, nil
+		}
+		return []EPGObject{{Title: "Midnight", StartEpoch: at(24), EndEpoch: at(25)}}, nil
+	}
+
+	// Early in the evening, today's guide has the next programme
+	current, next, err := NowNext("143", day.Add(21*time.Hour))
+	if err != nil {
+		t.Fatalf("NowNext() error = %v", err)
+	}
+	if titleOf(current) != "Evening" || titleOf(next) != "Late Night" || fetched[1] != 0 {
+		t.Errorf("NowNext() = %q, %q with %d fetches of tomorrow, want Evening, Late Night without tomorrow", titleOf(current), titleOf(next), fetched[1])
+	}
+
+	// During the last programme of the day, the next one is tomorrow's first
+	for range 2 {
+		current, next, err = NowNext("143", day.Add(23*time.Hour))
+		if err != nil {
+			t.Fatalf("NowNext() error = %v", err)
+		}
+		if titleOf(current) != "Late Night" || titleOf(next) != "Midnight" {
+			t.Errorf("NowNext() = %q, %q, want Late Night, Midnight", titleOf(current), titleOf(next))
+		}
+	}
+	if fetched[0] != 1 || fetched[1] != 1 {
+		t.Errorf("fetches = %v, want today and tomorrow once", fetched)
+	}
+}
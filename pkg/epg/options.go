@@ -0,0 +1,94 @@
+package epg
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+const (
+	// defaultDaysFuture is the number of days after today fetched by default
+	defaultDaysFuture = 1
+	// maxDays is the furthest the JioTV EPG API serves programmes in either direction
+	maxDays = 7
+	// defaultWorkers is the number of channels fetched concurrently by default
+	defaultWorkers = 20
+)
+
+// epgOffsets returns the day offsets to fetch for every channel, in order, where 0 is today.
+func epgOffsets() []int {
+	past := min(max(config.Cfg.EPGDaysPast, 0), maxDays)
+	future := defaultDaysFuture
+	if config.Cfg.EPGDaysFuture > 0 {
+		future = min(config.Cfg.EPGDaysFuture, maxDays)
+	}
+	offsets := make([]int, 0, past+future+1)
+	for offset := -past; offset <= future; offset++ {
+		offsets = append(offsets, offset)
+	}
+	return offsets
+}
+
+// epgWorkers returns the number of channels whose programmes are fetched concurrently.
+func epgWorkers() int {
+	if config.Cfg.EPGWorkers > 0 {
+		return config.Cfg.EPGWorkers
+	}
+	return defaultWorkers
+}
+
+// filterEPGChannels returns the channels selected by filter. Entries are channel IDs,
+// and IDs prefixed with "-" are excluded. If the filter has any IDs without the prefix,
+// only those channels are included.
+func filterEPGChannels(channels []ChannelObject, filter []string) []ChannelObject {
+	if len(filter) == 0 {
+		return channels
+	}
+	include := make(map[string]bool)
+	exclude := make(map[string]bool)
+	for _, entry := range filter {
+		entry = strings.TrimSpace(entry)
+		if id, ok := strings.CutPrefix(entry, "-"); ok {
+			exclude[id] = true
+		} else if entry != "" {
+			include[entry] = true
+		}
+	}
+
+	filtered := make([]ChannelObject, 0, len(channels))
+	for _, channel := range channels {
+		id := strconv.Itoa(channel.ChannelID)
+		if exclude[id] || (len(include) > 0 && !include[id]) {
+			continue
+		}
+		filtered = append(filtered, channel)
+	}
+	return filtered
+}
+
+// overrideEPGChannels applies the channel overrides to the channel names and removes hidden channels,
+// both by the overrides and from the web interface.
+func overrideEPGChannels(channels []ChannelObject) []ChannelObject {
+	overridden := make([]ChannelObject, 0, len(channels))
+	for _, channel := range channels {
+		id := strconv.Itoa(channel.ChannelID)
+		if television.IsHiddenChannel(id) {
+			continue
+		}
+		if override, ok := television.GetChannelOverride(id); ok {
+			if override.Hide {
+				continue
+			}
+			if name := strings.TrimSpace(override.Name); name != "" {
+				channel.ChannelName = name
+			}
+			if logo := strings.TrimSpace(override.LogoURL); logo != "" {
+				channel.LogoURL = logo
+			}
+		}
+		overridden = append(overridden, channel)
+	}
+	return overridden
+}
@@ -0,0 +1,121 @@
+package epg
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+func TestEPGOffsets(t *testing.T) {
+	original := config.Cfg
+	defer func() { config.Cfg = original }()
+
+	tests := []struct {
+		name   string
+		past   int
+		future int
+		want   []int
+	}{
+		{name: "defaults", want: []int{0, 1}},
+		{name: "past and future", past: 2, future: 3, want: []int{-2, -1, 0, 1, 2, 3}},
+		{name: "negative past is ignored", past: -1, want: []int{0, 1}},
+		{name: "capped", past: 10, future: 10, want: []int{-7, -6, -5, -4, -3, -2, -1, 0, 1, 2, 3, 4, 5, 6, 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Cfg.EPGDaysPast = tt.past
+			config.Cfg.EPGDaysFuture = tt.future
+			if got := epgOffsets(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("epgOffsets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEPGWorkers(t *testing.T) {
+	original := config.Cfg
+	defer func() { config.Cfg = original }()
+
+	config.Cfg.EPGWorkers = 0
+	if got := epgWorkers(); got != defaultWorkers {
+		t.Errorf("epgWorkers() = %d, want %d", got, defaultWorkers)
+	}
+	config.Cfg.EPGWorkers = 4
+	if got := epgWorkers(); got != 4 {
+		t.Errorf("epgWorkers() = %d, want 4", got)
+	}
+}
+
+func TestFilterEPGChannels(t *testing.T) {
+	channels := []ChannelObject{{ChannelID: 143}, {ChannelID: 144}, {ChannelID: 145}}
+	tests := []struct {
+		name   string
+		filter []string
+		want   []int
+	}{
+		{name: "no filter", filter: nil, want: []int{143, 144, 145}},
+		{name: "include", filter: []string{"143", "145"}, want: []int{143, 145}},
+		{name: "exclude", filter: []string{"-144"}, want: []int{143, 145}},
+		{name: "include and exclude", filter: []string{"143", "144", "-144"}, want: []int{143}},
+		{name: "unknown channel", filter: []string{"999"}, want: []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []int{}
+			for _, channel := range filterEPGChannels(channels, tt.filter) {
+				got = append(got, channel.ChannelID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterEPGChannels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverrideEPGChannels(t *testing.T) {
+	original := config.Cfg
+	defer func() {
+		config.Cfg = original
+		television.InitChannelOverrides()
+	}()
+
+	overridesFile := filepath.Join(t.TempDir(), "overrides.yml")
+	data := "\"143\":\n  name: News18 India\n\"144\":\n  hide: true\n"
+	if err := os.WriteFile(overridesFile, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config.Cfg.ChannelOverridesFile = overridesFile
+	television.InitChannelOverrides()
+
+	channels := []ChannelObject{{ChannelID: 143, ChannelName: "News18"}, {ChannelID: 144}, {ChannelID: 145, ChannelName: "Sports"}}
+	got := overrideEPGChannels(channels)
+	want := []ChannelObject{{ChannelID: 143, ChannelName: "News18 India"}, {ChannelID: 145, ChannelName: "Sports"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("overrideEPGChannels() = %v, want %v", got, want)
+	}
+}
+
+func TestOverrideEPGChannelsHidden(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	television.InitHiddenChannels()
+	defer television.InitHiddenChannels()
+
+	if err := television.SetChannelHidden("145", "Sports", true); err != nil {
+		t.Fatal(err)
+	}
+	channels := []ChannelObject{{ChannelID: 143, ChannelName: "News18"}, {ChannelID: 145, ChannelName: "Sports"}}
+	got := overrideEPGChannels(channels)
+	want := []ChannelObject{{ChannelID: 143, ChannelName: "News18"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("overrideEPGChannels() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,130 @@
+package epg
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants/headers"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants/tasks"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/imagecache"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/scheduler"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// ARTWORK_TASK_ID is the ID of the artwork pre-fetch task
+	ARTWORK_TASK_ID = tasks.EPGArtworkTaskID
+	// Local time of day at which artwork is pre-fetched
+	artworkPrefetchHour   = 4
+	artworkPrefetchMinute = 0
+	// artworkWindow is how far ahead programmes are considered upcoming
+	artworkWindow = 24 * time.Hour
+)
+
+// PosterCacheKey returns the image cache key of a programme poster.
+func PosterCacheKey(poster string) string {
+	return "posters/" + strings.TrimPrefix(poster, "/")
+}
+
+// InitArtworkPrefetch schedules a daily pre-fetch of posters for upcoming programmes
+// on the given channels, so that guide browsing does not wait on the upstream image CDN.
+func InitArtworkPrefetch(channelIDs []string) {
+	if len(channelIDs) == 0 {
+		return
+	}
+	startAt := scheduler.NextDailyRun(time.Now(), artworkPrefetchHour, artworkPrefetchMinute)
+	scheduler.AddAt(ARTWORK_TASK_ID, startAt, 24*time.Hour, func() error {
+		return PrefetchArtwork(channelIDs)
+	})
+}
+
+// upcomingPosters returns the posters of programmes that have not ended
+// and start before the end of the window.
+func upcomingPosters(programmes []EPGObject, now time.Time, window time.Duration) []string {
+	var posters []string
+	for _, programme := range programmes {
+		if programme.Poster == "" {
+			continue
+		}
+		start, okStart := timeFromEpoch(programme.StartEpoch)
+		end, okEnd := timeFromEpoch(programme.EndEpoch)
+		if !okStart || !okEnd {
+			continue
+		}
+		if end.Before(now) || start.After(now.Add(window)) {
+			continue
+		}
+		posters = append(posters, programme.Poster)
+	}
+	return posters
+}
+
+// PrefetchArtwork downloads posters of upcoming programmes on the given channels
+// into the image cache and prunes old artwork. Channels that are not JioTV channels are skipped.
+func PrefetchArtwork(channelIDs []string) error {
+	client := utils.GetRequestClient()
+
+	deviceID := utils.GetDeviceID()
+	crmID := ""
+	uniqueID := ""
+	if creds, err := utils.GetJIOTVCredentials(); err == nil && creds != nil {
+		crmID = creds.CRM
+		uniqueID = creds.UniqueID
+	}
+
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	utils.SetCommonJioTVHeaders(req, deviceID, crmID, uniqueID)
+	req.Header.Set(headers.Accept, headers.AcceptJSON)
+	req.Header.SetMethod("GET")
+
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+
+	now := time.Now()
+	fetched := 0
+	for _, channelID := range channelIDs {
+		id, err := strconv.Atoi(strings.TrimPrefix(channelID, "sl"))
+		if err != nil {
+			continue
+		}
+
+		for offset := 0; offset < 2; offset++ {
+			req.SetRequestURI(fmt.Sprintf(EPG_URL, offset, id))
+			if err := client.Do(req, resp); err != nil {
+				utils.Log.Printf("Error fetching EPG for channel %d, offset %d: %v", id, offset, err)
+				continue
+			}
+			if resp.StatusCode() != fasthttp.StatusOK {
+				continue
+			}
+			body, err := responseBody(resp)
+			if err != nil {
+				continue
+			}
+			var epgResponse EPGResponse
+			if err := json.Unmarshal(body, &epgResponse); err != nil {
+				continue
+			}
+
+			for _, poster := range upcomingPosters(epgResponse.EPG, now, artworkWindow) {
+				if err := imagecache.Fetch(PosterCacheKey(poster), EPG_POSTER_URL+"/"+poster, client); err != nil {
+					utils.Log.Printf("Error pre-fetching poster %s: %v", poster, err)
+					continue
+				}
+				fetched++
+			}
+		}
+	}
+
+	removed, err := imagecache.Prune(imagecache.MaxAge)
+	if err != nil {
+		utils.Log.Printf("Error pruning image cache: %v", err)
+	}
+	utils.Log.Printf("Pre-fetched %d posters for %d favorite channels, pruned %d old images", fetched, len(channelIDs), removed)
+	return nil
+}
@@ -0,0 +1,34 @@
+package epg
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUpcomingPosters(t *testing.T) {
+	now := time.Date(2024, 1, 1, 4, 0, 0, 0, time.UTC)
+	hour := int64(time.Hour / time.Millisecond)
+	nowMs := now.UnixMilli()
+
+	programmes := []EPGObject{
+		{Poster: "2024-01-01/ended.jpg", StartEpoch: nowMs - 2*hour, EndEpoch: nowMs - hour},
+		{Poster: "2024-01-01/live.jpg", StartEpoch: nowMs - hour, EndEpoch: nowMs + hour},
+		{Poster: "2024-01-01/later.jpg", StartEpoch: nowMs + 10*hour, EndEpoch: nowMs + 11*hour},
+		{Poster: "2024-01-02/tomorrow.jpg", StartEpoch: nowMs + 30*hour, EndEpoch: nowMs + 31*hour},
+		{Poster: "", StartEpoch: nowMs, EndEpoch: nowMs + hour},
+		{Poster: "2024-01-01/invalid.jpg"},
+	}
+
+	got := upcomingPosters(programmes, now, 24*time.Hour)
+	want := []string{"2024-01-01/live.jpg", "2024-01-01/later.jpg"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("upcomingPosters() = %v, want %v", got, want)
+	}
+}
+
+func TestPosterCacheKey(t *testing.T) {
+	if got := PosterCacheKey("/2024-01-01/show.jpg"); got != "posters/2024-01-01/show.jpg" {
+		t.Errorf("PosterCacheKey() = %q, want %q", got, "posters/2024-01-01/show.jpg")
+	}
+}
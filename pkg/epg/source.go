@@ -0,0 +1,38 @@
+package epg
+
+import "sync"
+
+// Source supplies additional channels and programmes for EPG generation.
+// Plugins register a Source so that their channels get programme data in epg.xml.gz.
+type Source struct {
+	// Name identifies the source in logs
+	Name string
+	// Fetch returns the channels and programmes of the source
+	Fetch func() ([]Channel, []Programme, error)
+}
+
+var (
+	sources   []Source
+	sourcesMu sync.RWMutex
+)
+
+// RegisterSource adds a source to EPG generation.
+// A source registered again with the same name replaces the previous one.
+func RegisterSource(source Source) {
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+	for i, existing := range sources {
+		if existing.Name == source.Name {
+			sources[i] = source
+			return
+		}
+	}
+	sources = append(sources, source)
+}
+
+// getSources returns a copy of the registered sources.
+func getSources() []Source {
+	sourcesMu.RLock()
+	defer sourcesMu.RUnlock()
+	return append([]Source(nil), sources...)
+}
@@ -0,0 +1,34 @@
+package epg
+
+import "testing"
+
+func TestRegisterSource(t *testing.T) {
+	sourcesMu.Lock()
+	saved := sources
+	sources = nil
+	sourcesMu.Unlock()
+	defer func() {
+		sourcesMu.Lock()
+		sources = saved
+		sourcesMu.Unlock()
+	}()
+
+	fetch := func(id string) func() ([]Channel, []Programme, error) {
+		return func() ([]Channel, []Programme, error) {
+			return []Channel{{ID: id}}, nil, nil
+		}
+	}
+
+	RegisterSource(Source{Name: "a", Fetch: fetch("1")})
+	RegisterSource(Source{Name: "b", Fetch: fetch("2")})
+	RegisterSource(Source{Name: "a", Fetch: fetch("3")})
+
+	got := getSources()
+	if len(got) != 2 {
+		t.Fatalf("getSources() returned %d sources, want 2", len(got))
+	}
+	channels, _, _ := got[0].Fetch()
+	if got[0].Name != "a" || channels[0].ID != "3" {
+		t.Errorf("source %q returned channel %q, want replaced source returning %q", got[0].Name, channels[0].ID, "3")
+	}
+}
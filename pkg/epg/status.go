@@ -0,0 +1,79 @@
+package epg
+
+import (
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// FileStatus describes an EPG file.
+type FileStatus struct {
+	// ModTime is when the file was last written
+	ModTime time.Time
+	// Size is the size of the gzipped file in bytes
+	Size int64
+	// Channels and Programmes are the number of channels and programmes in the file
+	Channels   int
+	Programmes int
+	// Start and End are the earliest programme start and the latest programme stop
+	Start time.Time
+	End   time.Time
+}
+
+// Status reads a gzipped XMLTV file and returns its age, size, number of channels and programmes
+// and the time the programmes cover. Programmes are not decoded, so large files are read quickly.
+func Status(filename string) (FileStatus, error) {
+	var status FileStatus
+	info, err := os.Stat(filename)
+	if err != nil {
+		return status, err
+	}
+	status.ModTime = info.ModTime()
+	status.Size = info.Size()
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return status, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return status, err
+	}
+	defer gz.Close()
+
+	dec := xml.NewDecoder(gz)
+	for {
+		token, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return status, nil
+		}
+		if err != nil {
+			return status, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "channel":
+			status.Channels++
+		case "programme":
+			status.Programmes++
+			if t, ok := parseProgrammeTime(attrValue(start, "start")); ok && (status.Start.IsZero() || t.Before(status.Start)) {
+				status.Start = t
+			}
+			if t, ok := parseProgrammeTime(attrValue(start, "stop")); ok && t.After(status.End) {
+				status.End = t
+			}
+		default:
+			continue
+		}
+		if err := dec.Skip(); err != nil {
+			return status, err
+		}
+	}
+}
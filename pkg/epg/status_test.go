@@ -0,0 +1,56 @@
+package epg
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStatus(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "epg.xml.gz")
+	err := writeXMLGz(filename, func(w io.Writer) error {
+		xw, err := newXMLTVWriter(w)
+		if err != nil {
+			return err
+		}
+		if err := xw.WriteChannels([]Channel{{ID: "143", Display: "News"}, {ID: "144", Display: "Sports"}}); err != nil {
+			return err
+		}
+		err = xw.WriteProgrammes([]Programme{
+			NewProgramme(143, "20240110230000 +0530", "20240111010000 +0530", "Late", "", "", ""),
+			NewProgramme(143, "20240110220000 +0530", "20240110230000 +0530", "Early", "", "", ""),
+			NewProgramme(144, "20240110220000 +0530", "20240110230000 +0530", "Other", "", "", ""),
+		})
+		if err != nil {
+			return err
+		}
+		return xw.Close()
+	})
+	if err != nil {
+		t.Fatalf("writeXMLGz() error = %v", err)
+	}
+
+	status, err := Status(filename)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if status.Channels != 2 || status.Programmes != 3 {
+		t.Errorf("Status() = %d channels and %d programmes, want 2 and 3", status.Channels, status.Programmes)
+	}
+	if status.Size == 0 || status.ModTime.IsZero() {
+		t.Errorf("Status() size = %d, modified = %v", status.Size, status.ModTime)
+	}
+	if want := time.Date(2024, 1, 10, 22, 0, 0, 0, istLocation); !status.Start.Equal(want) {
+		t.Errorf("Start = %v, want %v", status.Start, want)
+	}
+	if want := time.Date(2024, 1, 11, 1, 0, 0, 0, istLocation); !status.End.Equal(want) {
+		t.Errorf("End = %v, want %v", status.End, want)
+	}
+}
+
+func TestStatusMissingFile(t *testing.T) {
+	if _, err := Status(filepath.Join(t.TempDir(), "epg.xml.gz")); err == nil {
+		t.Error("Status() of a missing file returned no error")
+	}
+}
@@ -0,0 +1,60 @@
+package epg
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// xmltvHeader is written before the root element of the EPG file
+const xmltvHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE tv SYSTEM "http://www.w3.org/2006/05/tv">
+`
+
+// xmltvWriter streams an XMLTV document, so that the whole guide never has to be held in memory.
+// Channels must be written before programmes, as required by the XMLTV DTD.
+type xmltvWriter struct {
+	w          io.Writer
+	enc        *xml.Encoder
+	programmes int
+}
+
+// newXMLTVWriter writes the XML header and the opening tv element to w.
+func newXMLTVWriter(w io.Writer) (*xmltvWriter, error) {
+	if _, err := io.WriteString(w, xmltvHeader); err != nil {
+		return nil, err
+	}
+	enc := xml.NewEncoder(w)
+	if err := enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: "tv"}}); err != nil {
+		return nil, err
+	}
+	return &xmltvWriter{w: w, enc: enc}, nil
+}
+
+// WriteChannels writes channel elements.
+func (x *xmltvWriter) WriteChannels(channels []Channel) error {
+	for _, channel := range channels {
+		if err := x.enc.Encode(channel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteProgrammes writes programme elements and flushes them to the underlying writer.
+func (x *xmltvWriter) WriteProgrammes(programmes []Programme) error {
+	for _, programme := range programmes {
+		if err := x.enc.Encode(programme); err != nil {
+			return err
+		}
+	}
+	x.programmes += len(programmes)
+	return x.enc.Flush()
+}
+
+// Close writes the closing tv element. It does not close the underlying writer.
+func (x *xmltvWriter) Close() error {
+	if err := x.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "tv"}}); err != nil {
+		return err
+	}
+	return x.enc.Flush()
+}
@@ -0,0 +1,59 @@
+package epg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestXMLTVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	xw, err := newXMLTVWriter(&buf)
+	if err != nil {
+		t.Fatalf("newXMLTVWriter() error = %v", err)
+	}
+
+	channels := []Channel{{ID: "143", Display: "Sony & Co"}, {ID: "144", Display: "Colors"}}
+	if err := xw.WriteChannels(channels); err != nil {
+		t.Fatalf("WriteChannels() error = %v", err)
+	}
+	batches := [][]Programme{
+		{NewProgramme(143, "20240101000000 +0000", "20240101010000 +0000", "News <Live>", "Desc", "News", "a.jpg")},
+		nil,
+		{
+			NewProgramme(144, "20240101000000 +0000", "20240101010000 +0000", "Show", "Desc", "Drama", "b.jpg"),
+			NewProgramme(144, "20240101010000 +0000", "20240101020000 +0000", "Movie", "Desc", "Movies", "c.jpg"),
+		},
+	}
+	for _, programmes := range batches {
+		if err := xw.WriteProgrammes(programmes); err != nil {
+			t.Fatalf("WriteProgrammes() error = %v", err)
+		}
+	}
+	if err := xw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	output := buf.String()
+	if !strings.HasPrefix(output, xmltvHeader) {
+		t.Errorf("output does not start with the XMLTV header: %q", output[:min(len(output), 80)])
+	}
+	if xw.programmes != 3 {
+		t.Errorf("programmes = %d, want 3", xw.programmes)
+	}
+
+	var got EPG
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid XML: %v\n%s", err, output)
+	}
+	if len(got.Channel) != 2 || got.Channel[0].Display != "Sony & Co" {
+		t.Errorf("channels = %+v", got.Channel)
+	}
+	if len(got.Programme) != 3 || got.Programme[0].Title.Value != "News <Live>" || got.Programme[2].Channel != "144" {
+		t.Errorf("programmes = %+v", got.Programme)
+	}
+	if strings.Index(output, "<programme") < strings.LastIndex(output, "<channel") {
+		t.Error("channels must be written before programmes")
+	}
+}
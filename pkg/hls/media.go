@@ -0,0 +1,158 @@
+package hls
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Variant is a variant stream of a master playlist.
+type Variant struct {
+	// URL is the URL of the media playlist, resolved against the URL of the master playlist
+	URL       string
+	Bandwidth int
+	Codecs    string
+	Width     int
+	Height    int
+	FrameRate string
+	// Audio is the group of the alternate audio renditions, empty if the audio is muxed into the variant
+	Audio string
+}
+
+// Key is the encryption of a segment, from its EXT-X-KEY tag.
+type Key struct {
+	Method string
+	URL    string
+	IV     string
+}
+
+// Segment is a media segment of a media playlist.
+type Segment struct {
+	Sequence int64
+	Duration float64
+	// URL is the URL of the segment, resolved against the URL of the playlist
+	URL string
+	Key *Key
+	// ProgramDateTime is the wall clock time of the start of the segment, zero if the playlist does not have it
+	ProgramDateTime time.Time
+	Discontinuity   bool
+}
+
+// Variants returns the variant streams of a master playlist with URLs resolved against base.
+func (p *Playlist) Variants(base *url.URL) []Variant {
+	var variants []Variant
+	var pending *Variant
+	for _, line := range p.Lines {
+		switch {
+		case line.Type == Tag && line.Name == "EXT-X-STREAM-INF":
+			pending = &Variant{}
+			for _, attr := range line.Attributes {
+				switch attr.Key {
+				case "BANDWIDTH":
+					pending.Bandwidth, _ = strconv.Atoi(attr.Value)
+				case "CODECS":
+					pending.Codecs = attr.Value
+				case "RESOLUTION":
+					width, height, _ := strings.Cut(attr.Value, "x")
+					pending.Width, _ = strconv.Atoi(width)
+					pending.Height, _ = strconv.Atoi(height)
+				case "FRAME-RATE":
+					pending.FrameRate = attr.Value
+				case "AUDIO":
+					pending.Audio = attr.Value
+				}
+			}
+		case line.Type == URI && pending != nil:
+			pending.URL = resolve(base, line.Value)
+			variants = append(variants, *pending)
+			pending = nil
+		}
+	}
+	return variants
+}
+
+// TargetDuration returns the target duration of a media playlist in seconds.
+func (p *Playlist) TargetDuration() int {
+	for _, line := range p.Lines {
+		if line.Type == Tag && line.Name == "EXT-X-TARGETDURATION" {
+			duration, _ := strconv.Atoi(strings.TrimSpace(line.Value))
+			return duration
+		}
+	}
+	return 0
+}
+
+// Ended reports whether a media playlist has an EXT-X-ENDLIST tag, so that no segments are added to it.
+func (p *Playlist) Ended() bool {
+	for _, line := range p.Lines {
+		if line.Type == Tag && line.Name == "EXT-X-ENDLIST" {
+			return true
+		}
+	}
+	return false
+}
+
+// Segments returns the segments of a media playlist with URLs resolved against base.
+// AES-128 keys without IV get the IV implied by the media sequence number.
+func (p *Playlist) Segments(base *url.URL) []Segment {
+	var (
+		segments []Segment
+		segment  Segment
+		key      *Key
+	)
+	for _, line := range p.Lines {
+		switch line.Type {
+		case Tag:
+			switch line.Name {
+			case "EXT-X-MEDIA-SEQUENCE":
+				segment.Sequence, _ = strconv.ParseInt(strings.TrimSpace(line.Value), 10, 64)
+			case "EXT-X-KEY":
+				method, _ := line.Attribute("METHOD")
+				if method == "" || method == "NONE" {
+					key = nil
+					continue
+				}
+				uri, _ := line.Attribute("URI")
+				iv, _ := line.Attribute("IV")
+				key = &Key{Method: method, URL: resolve(base, uri), IV: iv}
+			case "EXTINF":
+				value, _, _ := strings.Cut(line.Value, ",")
+				segment.Duration, _ = strconv.ParseFloat(strings.TrimSpace(value), 64)
+			case "EXT-X-PROGRAM-DATE-TIME":
+				segment.ProgramDateTime = parseDateTime(strings.TrimSpace(line.Value))
+			case "EXT-X-DISCONTINUITY":
+				segment.Discontinuity = true
+			}
+		case URI:
+			segment.URL = resolve(base, line.Value)
+			if key != nil {
+				segmentKey := *key
+				if segmentKey.Method == "AES-128" && segmentKey.IV == "" {
+					segmentKey.IV = fmt.Sprintf("0x%032X", segment.Sequence)
+				}
+				segment.Key = &segmentKey
+			}
+			segments = append(segments, segment)
+			// The program date time of the next segment follows from the duration of this one
+			next := Segment{Sequence: segment.Sequence + 1}
+			if !segment.ProgramDateTime.IsZero() {
+				next.ProgramDateTime = segment.ProgramDateTime.Add(time.Duration(math.Round(segment.Duration * float64(time.Second))))
+			}
+			segment = next
+		}
+	}
+	return segments
+}
+
+// parseDateTime parses an EXT-X-PROGRAM-DATE-TIME value. Some servers leave the colon out of the time zone offset.
+func parseDateTime(value string) time.Time {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999Z0700"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,91 @@
+package hls
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestVariants(t *testing.T) {
+	playlist, err := Parse([]byte(masterPlaylist))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	base, _ := url.Parse("https://jiotvmblive.cdn.jio.com/bpk-tv/Colors_HD/index.m3u8")
+	want := []Variant{{
+		URL:       "https://jiotvmblive.cdn.jio.com/bpk-tv/Colors_HD/index_800.m3u8?x=1",
+		Bandwidth: 800000,
+		Codecs:    "avc1.4d401f,mp4a.40.2",
+		Audio:     "aud",
+	}}
+	if got := playlist.Variants(base); !reflect.DeepEqual(got, want) {
+		t.Errorf("Variants() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSegments(t *testing.T) {
+	playlist, err := Parse([]byte(`#EXTM3U
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:7
+#EXT-X-PROGRAM-DATE-TIME:2026-10-16T05:30:00.000+0530
+#EXT-X-KEY:METHOD=AES-128,URI="keys/1.key"
+#EXTINF:6.000,
+7.ts
+#EXTINF:4.5,
+8.ts
+#EXT-X-KEY:METHOD=NONE
+#EXT-X-DISCONTINUITY
+#EXTINF:6,
+9.ts
+#EXT-X-ENDLIST
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := playlist.TargetDuration(); got != 6 {
+		t.Errorf("TargetDuration() = %d, want 6", got)
+	}
+	if !playlist.Ended() {
+		t.Error("Ended() = false for a playlist with EXT-X-ENDLIST")
+	}
+
+	base, _ := url.Parse("https://cdn.example.com/live/index.m3u8")
+	start := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	want := []Segment{
+		{
+			Sequence:        7,
+			Duration:        6,
+			URL:             "https://cdn.example.com/live/7.ts",
+			Key:             &Key{Method: "AES-128", URL: "https://cdn.example.com/live/keys/1.key", IV: "0x00000000000000000000000000000007"},
+			ProgramDateTime: start,
+		},
+		{
+			Sequence:        8,
+			Duration:        4.5,
+			URL:             "https://cdn.example.com/live/8.ts",
+			Key:             &Key{Method: "AES-128", URL: "https://cdn.example.com/live/keys/1.key", IV: "0x00000000000000000000000000000008"},
+			ProgramDateTime: start.Add(6 * time.Second),
+		},
+		{
+			Sequence:        9,
+			Duration:        6,
+			URL:             "https://cdn.example.com/live/9.ts",
+			ProgramDateTime: start.Add(10500 * time.Millisecond),
+			Discontinuity:   true,
+		},
+	}
+	got := playlist.Segments(base)
+	if len(got) != len(want) {
+		t.Fatalf("Segments() returned %d segments, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !got[i].ProgramDateTime.Equal(want[i].ProgramDateTime) {
+			t.Errorf("segment %d ProgramDateTime = %v, want %v", i, got[i].ProgramDateTime, want[i].ProgramDateTime)
+		}
+		got[i].ProgramDateTime, want[i].ProgramDateTime = time.Time{}, time.Time{}
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("segment %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
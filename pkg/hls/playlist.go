@@ -0,0 +1,299 @@
+// Package hls parses and writes HLS playlists. Playlists are kept line by line, so that tags the
+// package does not know are written back unchanged, and URIs can be rewritten in every place they appear.
+package hls
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// ErrNotPlaylist is returned for data that does not start with #EXTM3U
+var ErrNotPlaylist = errors.New("not an HLS playlist")
+
+// LineType is the kind of a playlist line
+type LineType int
+
+const (
+	// Blank is an empty line
+	Blank LineType = iota
+	// Comment is a line starting with # that is not a tag
+	Comment
+	// Tag is a line starting with #EXT
+	Tag
+	// URI is the URI of a segment or, in master playlists, of a variant stream
+	URI
+)
+
+// URIKind is what a URI of a playlist points at
+type URIKind int
+
+const (
+	// PlaylistURI points at another playlist, e.g. a variant stream or an alternate rendition
+	PlaylistURI URIKind = iota
+	// SegmentURI points at media data, e.g. a segment, a partial segment or an initialization section
+	SegmentURI
+	// KeyURI points at an encryption key
+	KeyURI
+)
+
+// uriTags are the tags with a URI attribute and what the URI points at
+var uriTags = map[string]URIKind{
+	"EXT-X-MEDIA":              PlaylistURI,
+	"EXT-X-I-FRAME-STREAM-INF": PlaylistURI,
+	"EXT-X-RENDITION-REPORT":   PlaylistURI,
+	"EXT-X-MAP":                SegmentURI,
+	"EXT-X-PART":               SegmentURI,
+	"EXT-X-PRELOAD-HINT":       SegmentURI,
+	"EXT-X-KEY":                KeyURI,
+	"EXT-X-SESSION-KEY":        KeyURI,
+}
+
+// attributeTags are the tags whose value is an attribute list
+var attributeTags = map[string]bool{
+	"EXT-X-MEDIA":              true,
+	"EXT-X-STREAM-INF":         true,
+	"EXT-X-I-FRAME-STREAM-INF": true,
+	"EXT-X-RENDITION-REPORT":   true,
+	"EXT-X-MAP":                true,
+	"EXT-X-PART":               true,
+	"EXT-X-PART-INF":           true,
+	"EXT-X-PRELOAD-HINT":       true,
+	"EXT-X-KEY":                true,
+	"EXT-X-SESSION-KEY":        true,
+	"EXT-X-SESSION-DATA":       true,
+	"EXT-X-SERVER-CONTROL":     true,
+	"EXT-X-SKIP":               true,
+	"EXT-X-START":              true,
+	"EXT-X-DATERANGE":          true,
+	"EXT-X-CONTENT-STEERING":   true,
+	"EXT-X-DEFINE":             true,
+}
+
+// Attribute is an attribute of a tag. Quoted values are stored without their quotes.
+type Attribute struct {
+	Key    string
+	Value  string
+	Quoted bool
+}
+
+// Line is a line of a playlist.
+type Line struct {
+	Type LineType
+	// Name is the name of a tag without the leading #, e.g. EXT-X-KEY
+	Name string
+	// Value is the text after the colon of a tag, or the URI of a URI line
+	Value string
+	// Attributes are the attributes of tags with an attribute list
+	Attributes []Attribute
+
+	// raw is the original text, written back while the line is unchanged
+	raw     string
+	changed bool
+}
+
+// Playlist is a parsed HLS playlist.
+type Playlist struct {
+	Lines []*Line
+	// Master reports whether the playlist lists variant streams rather than segments
+	Master bool
+
+	trailingNewline bool
+}
+
+// Parse parses an HLS playlist. Lines ending with CRLF are written back with LF only.
+func Parse(data []byte) (*Playlist, error) {
+	text := string(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if !strings.HasPrefix(strings.TrimSpace(text), "#EXTM3U") {
+		return nil, ErrNotPlaylist
+	}
+	playlist := &Playlist{trailingNewline: strings.HasSuffix(text, "\n")}
+	for _, raw := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		line := parseLine(strings.TrimSuffix(raw, "\r"))
+		if line.Type == Tag && (line.Name == "EXT-X-STREAM-INF" || line.Name == "EXT-X-I-FRAME-STREAM-INF") {
+			playlist.Master = true
+		}
+		playlist.Lines = append(playlist.Lines, line)
+	}
+	return playlist, nil
+}
+
+// parseLine parses a line of a playlist.
+func parseLine(raw string) *Line {
+	line := &Line{raw: raw}
+	trimmed := strings.TrimSpace(raw)
+	switch {
+	case trimmed == "":
+		line.Type = Blank
+	case strings.HasPrefix(trimmed, "#EXT"):
+		line.Type = Tag
+		name, value, _ := strings.Cut(trimmed[1:], ":")
+		line.Name, line.Value = name, value
+		if attributeTags[name] {
+			line.Attributes = parseAttributes(value)
+		}
+	case strings.HasPrefix(trimmed, "#"):
+		line.Type = Comment
+	default:
+		line.Type = URI
+		line.Value = trimmed
+	}
+	return line
+}
+
+// parseAttributes parses an attribute list like `METHOD=AES-128,URI="key?a=1,2"`, keeping the attribute order.
+func parseAttributes(list string) []Attribute {
+	var attrs []Attribute
+	for list != "" {
+		key, rest, ok := strings.Cut(list, "=")
+		if !ok {
+			break
+		}
+		attr := Attribute{Key: strings.TrimSpace(key)}
+		if strings.HasPrefix(rest, `"`) {
+			attr.Quoted = true
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				attr.Value, rest = rest[1:], ""
+			} else {
+				attr.Value, rest = rest[1:end+1], rest[end+2:]
+			}
+			rest = strings.TrimPrefix(rest, ",")
+		} else {
+			attr.Value, rest, _ = strings.Cut(rest, ",")
+		}
+		attrs = append(attrs, attr)
+		list = rest
+	}
+	return attrs
+}
+
+// Attribute returns the value of an attribute of a tag.
+func (l *Line) Attribute(key string) (string, bool) {
+	for _, attr := range l.Attributes {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// SetAttribute sets the value of an attribute of a tag, adding it if the tag does not have it.
+func (l *Line) SetAttribute(key, value string, quoted bool) {
+	l.changed = true
+	for i := range l.Attributes {
+		if l.Attributes[i].Key == key {
+			l.Attributes[i].Value = value
+			l.Attributes[i].Quoted = quoted
+			return
+		}
+	}
+	l.Attributes = append(l.Attributes, Attribute{Key: key, Value: value, Quoted: quoted})
+}
+
+// SetURI replaces the URI of a URI line.
+func (l *Line) SetURI(uri string) {
+	l.changed = true
+	l.Value = uri
+}
+
+// String returns the text of the line.
+func (l *Line) String() string {
+	if !l.changed {
+		return l.raw
+	}
+	switch l.Type {
+	case URI:
+		return l.Value
+	case Tag:
+		if l.Attributes == nil {
+			if l.Value == "" {
+				return "#" + l.Name
+			}
+			return "#" + l.Name + ":" + l.Value
+		}
+		parts := make([]string, len(l.Attributes))
+		for i, attr := range l.Attributes {
+			if attr.Quoted {
+				parts[i] = attr.Key + `="` + attr.Value + `"`
+			} else {
+				parts[i] = attr.Key + "=" + attr.Value
+			}
+		}
+		return "#" + l.Name + ":" + strings.Join(parts, ",")
+	default:
+		return l.raw
+	}
+}
+
+// Bytes returns the text of the playlist.
+func (p *Playlist) Bytes() []byte {
+	var buf bytes.Buffer
+	for i, line := range p.Lines {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(line.String())
+	}
+	if p.trailingNewline {
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// Reference is a URI of a playlist with what it points at.
+type Reference struct {
+	Kind URIKind
+	// Tag is the name of the tag with the URI, or empty for URI lines
+	Tag string
+	// URI is the URI as written in the playlist
+	URI string
+	// URL is the URI resolved against the URL of the playlist
+	URL string
+}
+
+// RewriteURIs replaces every URI of the playlist, in URI lines and in URI attributes of tags, with
+// the result of rewrite. URIs are resolved against base, the URL the playlist was fetched from.
+// When rewrite returns false, the URI is kept.
+func (p *Playlist) RewriteURIs(base *url.URL, rewrite func(ref Reference) (string, bool)) {
+	afterStreamInf := false
+	for _, line := range p.Lines {
+		switch line.Type {
+		case Tag:
+			afterStreamInf = line.Name == "EXT-X-STREAM-INF"
+			kind, ok := uriTags[line.Name]
+			if !ok {
+				continue
+			}
+			uri, ok := line.Attribute("URI")
+			if !ok || uri == "" {
+				continue
+			}
+			if replaced, ok := rewrite(Reference{Kind: kind, Tag: line.Name, URI: uri, URL: resolve(base, uri)}); ok {
+				line.SetAttribute("URI", replaced, true)
+			}
+		case URI:
+			kind := SegmentURI
+			if afterStreamInf || p.Master {
+				kind = PlaylistURI
+			}
+			afterStreamInf = false
+			if replaced, ok := rewrite(Reference{Kind: kind, URI: line.Value, URL: resolve(base, line.Value)}); ok {
+				line.SetURI(replaced)
+			}
+		}
+	}
+}
+
+// resolve returns ref as an absolute URL relative to base.
+func resolve(base *url.URL, ref string) string {
+	if base == nil {
+		return ref
+	}
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(parsed).String()
+}
@@ -0,0 +1,169 @@
+package hls
+
+import (
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const mediaPlaylist = `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,PART-HOLD-BACK=1.0
+#EXT-X-MEDIA-SEQUENCE:100
+# a comment
+#EXT-X-KEY:METHOD=AES-128,URI="https://tv.media.jio.com/keys/100.key?a=1,2",IV=0x1
+#EXT-X-MAP:URI="init.mp4",BYTERANGE="720@0"
+#EXTINF:6.0,
+#EXT-X-BYTERANGE:1000@720
+segments/100.m4s
+
+#EXT-X-DISCONTINUITY
+#EXT-X-KEY:METHOD=NONE
+#EXTINF:6.0,
+https://other.cdn.jio.com/101.ts?token=abc
+#EXT-X-PART:DURATION=1.0,URI="102.0.m4s",INDEPENDENT=YES
+#EXT-X-PRELOAD-HINT:TYPE=PART,URI="102.1.m4s"
+#EXT-X-RENDITION-REPORT:URI="../low/index.m3u8",LAST-MSN=102
+`
+
+const masterPlaylist = "#EXTM3U\r\n" +
+	"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aud\",NAME=\"Tamil\",URI=\"audio/tam.m3u8\"\r\n" +
+	"#EXT-X-MEDIA:TYPE=CLOSED-CAPTIONS,GROUP-ID=\"cc\",NAME=\"English\",INSTREAM-ID=\"CC1\"\r\n" +
+	"#EXT-X-STREAM-INF:BANDWIDTH=800000,CODECS=\"avc1.4d401f,mp4a.40.2\",AUDIO=\"aud\"\r\n" +
+	"index_800.m3u8?x=1\r\n" +
+	"#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=100000,URI=\"iframe.m3u8\"\r\n"
+
+func TestParse(t *testing.T) {
+	playlist, err := Parse([]byte(mediaPlaylist))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if playlist.Master {
+		t.Error("Parse() of a media playlist set Master")
+	}
+	if got := string(playlist.Bytes()); got != mediaPlaylist {
+		t.Errorf("Bytes() of an unchanged playlist = %q, want the input", got)
+	}
+
+	key := playlist.Lines[6]
+	if key.Type != Tag || key.Name != "EXT-X-KEY" {
+		t.Fatalf("line 6 = %+v, want the key tag", key)
+	}
+	want := []Attribute{
+		{Key: "METHOD", Value: "AES-128"},
+		{Key: "URI", Value: "https://tv.media.jio.com/keys/100.key?a=1,2", Quoted: true},
+		{Key: "IV", Value: "0x1"},
+	}
+	if !reflect.DeepEqual(key.Attributes, want) {
+		t.Errorf("key attributes = %+v, want %+v", key.Attributes, want)
+	}
+	if byteRange := playlist.Lines[9]; byteRange.Name != "EXT-X-BYTERANGE" || byteRange.Value != "1000@720" || byteRange.Attributes != nil {
+		t.Errorf("byte range = %+v", byteRange)
+	}
+	if comment := playlist.Lines[5]; comment.Type != Comment {
+		t.Errorf("line 5 = %+v, want a comment", comment)
+	}
+
+	master, err := Parse([]byte(masterPlaylist))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !master.Master {
+		t.Error("Parse() of a master playlist did not set Master")
+	}
+	if got := string(master.Bytes()); got != strings.ReplaceAll(masterPlaylist, "\r\n", "\n") {
+		t.Errorf("Bytes() = %q, want the input with LF line endings", got)
+	}
+
+	if _, err := Parse([]byte("<html>Forbidden</html>")); err != ErrNotPlaylist {
+		t.Errorf("Parse() of an error page error = %v, want ErrNotPlaylist", err)
+	}
+}
+
+func TestRewriteURIs(t *testing.T) {
+	tests := []struct {
+		name     string
+		playlist string
+		base     string
+		want     []Reference
+	}{
+		{
+			name:     "media playlist",
+			playlist: mediaPlaylist,
+			base:     "https://cdn.jio.com/live/high/index.m3u8?hdnea=abc",
+			want: []Reference{
+				{Kind: KeyURI, Tag: "EXT-X-KEY", URI: "https://tv.media.jio.com/keys/100.key?a=1,2", URL: "https://tv.media.jio.com/keys/100.key?a=1,2"},
+				{Kind: SegmentURI, Tag: "EXT-X-MAP", URI: "init.mp4", URL: "https://cdn.jio.com/live/high/init.mp4"},
+				{Kind: SegmentURI, URI: "segments/100.m4s", URL: "https://cdn.jio.com/live/high/segments/100.m4s"},
+				{Kind: SegmentURI, URI: "https://other.cdn.jio.com/101.ts?token=abc", URL: "https://other.cdn.jio.com/101.ts?token=abc"},
+				{Kind: SegmentURI, Tag: "EXT-X-PART", URI: "102.0.m4s", URL: "https://cdn.jio.com/live/high/102.0.m4s"},
+				{Kind: SegmentURI, Tag: "EXT-X-PRELOAD-HINT", URI: "102.1.m4s", URL: "https://cdn.jio.com/live/high/102.1.m4s"},
+				{Kind: PlaylistURI, Tag: "EXT-X-RENDITION-REPORT", URI: "../low/index.m3u8", URL: "https://cdn.jio.com/live/low/index.m3u8"},
+			},
+		},
+		{
+			name:     "master playlist",
+			playlist: masterPlaylist,
+			base:     "https://cdn.jio.com/live/index.m3u8",
+			want: []Reference{
+				{Kind: PlaylistURI, Tag: "EXT-X-MEDIA", URI: "audio/tam.m3u8", URL: "https://cdn.jio.com/live/audio/tam.m3u8"},
+				{Kind: PlaylistURI, URI: "index_800.m3u8?x=1", URL: "https://cdn.jio.com/live/index_800.m3u8?x=1"},
+				{Kind: PlaylistURI, Tag: "EXT-X-I-FRAME-STREAM-INF", URI: "iframe.m3u8", URL: "https://cdn.jio.com/live/iframe.m3u8"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			playlist, err := Parse([]byte(tt.playlist))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			base, _ := url.Parse(tt.base)
+			var got []Reference
+			playlist.RewriteURIs(base, func(ref Reference) (string, bool) {
+				got = append(got, ref)
+				return "/proxy?n=" + strconv.Itoa(len(got)), true
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RewriteURIs() references = %+v\nwant %+v", got, tt.want)
+			}
+
+			text := string(playlist.Bytes())
+			for i := range tt.want {
+				if !strings.Contains(text, "/proxy?n="+strconv.Itoa(i+1)) {
+					t.Errorf("rewritten playlist does not contain URI %d:\n%s", i+1, text)
+				}
+			}
+			for _, ref := range tt.want {
+				if strings.Contains(text, `"`+ref.URI+`"`) || strings.Contains(text, "\n"+ref.URI+"\n") {
+					t.Errorf("rewritten playlist still contains %s:\n%s", ref.URI, text)
+				}
+			}
+		})
+	}
+}
+
+func TestRewriteURIsKeepsOtherAttributes(t *testing.T) {
+	playlist, err := Parse([]byte(mediaPlaylist))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	playlist.RewriteURIs(nil, func(ref Reference) (string, bool) {
+		// Keep everything but the key
+		return "/render.key", ref.Kind == KeyURI
+	})
+	text := string(playlist.Bytes())
+	for _, want := range []string{
+		"\n#EXT-X-KEY:METHOD=AES-128,URI=\"/render.key\",IV=0x1\n",
+		"\n#EXT-X-MAP:URI=\"init.mp4\",BYTERANGE=\"720@0\"\n",
+		"\n#EXT-X-KEY:METHOD=NONE\n",
+		"\n#EXT-X-BYTERANGE:1000@720\nsegments/100.m4s\n\n#EXT-X-DISCONTINUITY\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("rewritten playlist does not contain %q:\n%s", want, text)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package imagecache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants/headers"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// cacheDirName is the directory under the path prefix where images are cached
+	cacheDirName = "image_cache"
+	// fetchTimeout is the timeout for downloading a single image
+	fetchTimeout = 20 * time.Second
+	// MaxAge is how long cached images are kept before they are pruned
+	MaxAge = 7 * 24 * time.Hour
+)
+
+// ErrInvalidKey is returned when a cache key would escape the cache directory
+var ErrInvalidKey = errors.New("invalid image cache key")
+
+// Dir returns the directory where images are cached.
+func Dir() string {
+	return filepath.Join(utils.GetPathPrefix(), cacheDirName)
+}
+
+// path returns the file path for the given cache key.
+// Keys are slash separated relative paths like "posters/2024-01-01/show.jpg".
+func path(key string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(key, "/")))
+	if cleaned == "." || filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidKey, key)
+	}
+	return filepath.Join(Dir(), cleaned), nil
+}
+
+// Path returns the file path of a cached image and whether it exists.
+func Path(key string) (string, bool) {
+	p, err := path(key)
+	if err != nil {
+		return "", false
+	}
+	if stat, err := os.Stat(p); err != nil || stat.IsDir() {
+		return p, false
+	}
+	return p, true
+}
+
+// Get returns the cached image for the given key.
+func Get(key string) ([]byte, bool) {
+	p, ok := Path(key)
+	if !ok {
+		return nil, false
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Put stores an image in the cache under the given key.
+func Put(key string, data []byte) error {
+	p, err := path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	tmp := p + ".tmp"
+	// skipcq: GSC-G306
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+// Fetch downloads the image at url into the cache under the given key.
+// Images that are already cached are not downloaded again.
+func Fetch(key, url string, client *fasthttp.Client) error {
+	if _, ok := Path(key); ok {
+		return nil
+	}
+	data, err := Download(url, client)
+	if err != nil {
+		return err
+	}
+	return Put(key, data)
+}
+
+// Download downloads the image at url without caching it.
+func Download(url string, client *fasthttp.Client) ([]byte, error) {
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(url)
+	req.Header.SetMethod("GET")
+	req.Header.SetUserAgent(headers.UserAgentOkHttp)
+
+	if err := client.DoTimeout(req, resp, fetchTimeout); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return nil, fmt.Errorf("image download failed: status %d", resp.StatusCode())
+	}
+	if contentType := string(resp.Header.ContentType()); contentType != "" && !strings.HasPrefix(contentType, "image/") {
+		return nil, fmt.Errorf("image download failed: unexpected content type %s", contentType)
+	}
+	return append([]byte(nil), resp.Body()...), nil
+}
+
+// Expired returns the paths of cached images that were not modified within maxAge.
+func Expired(maxAge time.Duration) ([]string, error) {
+	var expired []string
+	cutoff := time.Now().Add(-maxAge)
+	err := filepath.Walk(Dir(), func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !info.ModTime().Before(cutoff) {
+			return nil
+		}
+		expired = append(expired, p)
+		return nil
+	})
+	return expired, err
+}
+
+// Prune removes cached images that were not modified within maxAge.
+// It returns the number of removed files.
+func Prune(maxAge time.Duration) (int, error) {
+	expired, err := Expired(maxAge)
+	removed := 0
+	for _, p := range expired {
+		if removeErr := os.Remove(p); removeErr != nil {
+			return removed, removeErr
+		}
+		removed++
+	}
+	return removed, err
+}
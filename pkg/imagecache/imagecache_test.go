@@ -0,0 +1,81 @@
+package imagecache
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+)
+
+func TestPutGet(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+
+	if _, ok := Get("posters/2024-01-01/show.jpg"); ok {
+		t.Fatal("Get() found image before Put()")
+	}
+	if err := Put("posters/2024-01-01/show.jpg", []byte("image")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	data, ok := Get("posters/2024-01-01/show.jpg")
+	if !ok || string(data) != "image" {
+		t.Errorf("Get() = %q, %v, want %q, true", data, ok, "image")
+	}
+}
+
+func TestInvalidKey(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+
+	tests := []string{"", "..", "../secret", "posters/../../secret"}
+	for _, key := range tests {
+		t.Run(key, func(t *testing.T) {
+			if err := Put(key, []byte("x")); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Put(%q) error = %v, want ErrInvalidKey", key, err)
+			}
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+
+	if removed, err := Prune(time.Hour); err != nil || removed != 0 {
+		t.Fatalf("Prune() on empty cache = %d, %v, want 0, nil", removed, err)
+	}
+
+	if err := Put("old.jpg", []byte("old")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Put("new.jpg", []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	oldPath, _ := Path("old.jpg")
+	past := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(oldPath, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := Prune(24 * time.Hour)
+	if err != nil || removed != 1 {
+		t.Fatalf("Prune() = %d, %v, want 1, nil", removed, err)
+	}
+	if _, ok := Path("old.jpg"); ok {
+		t.Error("Prune() kept old image")
+	}
+	if _, ok := Path("new.jpg"); !ok {
+		t.Error("Prune() removed new image")
+	}
+}
@@ -0,0 +1,187 @@
+package imagecache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // decode GIF images
+	"image/jpeg"
+	"image/png"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// MaxDimension is the largest width or height images are resized to
+	MaxDimension = 2048
+	// jpegQuality is the quality of resized JPEG images
+	jpegQuality = 80
+	// webpQuality is the quality of WebP images encoded with ffmpeg
+	webpQuality = 75
+	// encodeTimeout is how long encoding an image with ffmpeg may take
+	encodeTimeout = 10 * time.Second
+)
+
+// Image formats of a Transform
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+// Transform resizes an image and converts it to another format.
+type Transform struct {
+	// Width and Height are the largest size of the image, 0 for no limit. Images keep their
+	// aspect ratio and are never enlarged.
+	Width  int
+	Height int
+	// Format is FormatJPEG, FormatPNG or FormatWebP, or empty to keep PNG images as PNG and
+	// encode others as JPEG.
+	Format string
+}
+
+// IsZero reports whether the transform leaves images as they are.
+func (t Transform) IsZero() bool {
+	return t.Width <= 0 && t.Height <= 0 && t.Format == ""
+}
+
+// Key returns the cache key of the image of key after the transform.
+func (t Transform) Key(key string) string {
+	format := t.Format
+	if format == "" {
+		format = "auto"
+	}
+	return "resized/" + strconv.Itoa(t.Width) + "x" + strconv.Itoa(t.Height) + "/" + format + "/" + strings.TrimPrefix(key, "/")
+}
+
+// Apply decodes a JPEG, PNG or GIF image, resizes it and encodes it in the format of the transform.
+// Images in the requested format that need no resizing are returned as they are. If WebP cannot be
+// encoded, e.g. because ffmpeg is not installed, the image is encoded as JPEG instead.
+func (t Transform) Apply(data []byte) ([]byte, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	width, height := fitSize(bounds.Dx(), bounds.Dy(), t.Width, t.Height)
+	resized := width != bounds.Dx() || height != bounds.Dy()
+	if resized {
+		img = scaleDown(img, width, height)
+	}
+
+	target := t.Format
+	if target == "" {
+		target = FormatJPEG
+		if format == FormatPNG {
+			target = FormatPNG
+		}
+	}
+	if !resized && target == format {
+		return data, nil
+	}
+
+	switch target {
+	case FormatWebP:
+		webp, err := encodeWebP(img)
+		if err == nil {
+			return webp, nil
+		}
+		utils.SafeLogf("WARN: Serving JPEG instead of WebP: %v", err)
+		return encodeJPEG(img)
+	case FormatPNG:
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return encodeJPEG(img)
+	}
+}
+
+// fitSize returns the size of a width by height image that fits in maxWidth by maxHeight,
+// keeping its aspect ratio. Zero limits are ignored, and images are never enlarged.
+func fitSize(width, height, maxWidth, maxHeight int) (int, int) {
+	if maxWidth > 0 && width > maxWidth {
+		width, height = maxWidth, max(height*maxWidth/width, 1)
+	}
+	if maxHeight > 0 && height > maxHeight {
+		width, height = max(width*maxHeight/height, 1), maxHeight
+	}
+	return width, height
+}
+
+// scaleDown scales the image to width by height pixels, averaging the pixels that make up each new one.
+func scaleDown(img image.Image, width, height int) image.Image {
+	bounds := img.Bounds()
+	dst := image.NewRGBA64(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(bounds.Min.Y+(y+1)*bounds.Dy()/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(bounds.Min.X+(x+1)*bounds.Dx()/width, x0+1)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
+
+func encodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeWebP encodes the image as WebP with ffmpeg, as the standard library has no WebP encoder.
+func encodeWebP(img image.Image) ([]byte, error) {
+	path, err := utils.FFmpegPath()
+	if err != nil {
+		return nil, err
+	}
+	var input bytes.Buffer
+	if err := png.Encode(&input, img); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), encodeTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path,
+		"-hide_banner", "-loglevel", "error",
+		"-f", "png_pipe", "-i", "pipe:0",
+		"-c:v", "libwebp", "-quality", strconv.Itoa(webpQuality),
+		"-f", "webp", "pipe:1",
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = &input
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("ffmpeg: %s", msg)
+		}
+		return nil, fmt.Errorf("ffmpeg: %w", err)
+	}
+	if stdout.Len() == 0 {
+		return nil, errors.New("ffmpeg: no WebP output")
+	}
+	return stdout.Bytes(), nil
+}
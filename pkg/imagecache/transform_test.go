@@ -0,0 +1,106 @@
+package imagecache
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// encodeImage returns a solid image of the size in the format
+func encodeImage(t *testing.T, width, height int, format string) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: 20, G: 200, B: 20, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	var err error
+	if format == FormatJPEG {
+		err = jpeg.Encode(&buf, img, nil)
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestFitSize(t *testing.T) {
+	tests := []struct {
+		name                      string
+		width, height, maxW, maxH int
+		wantWidth, wantHeight     int
+	}{
+		{"no limits", 400, 200, 0, 0, 400, 200},
+		{"width", 400, 200, 100, 0, 100, 50},
+		{"height", 400, 200, 0, 50, 100, 50},
+		{"both, height limits", 400, 400, 300, 100, 100, 100},
+		{"never enlarged", 40, 20, 100, 100, 40, 20},
+		{"thin image keeps a pixel", 1000, 1, 10, 0, 10, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width, height := fitSize(tt.width, tt.height, tt.maxW, tt.maxH)
+			if width != tt.wantWidth || height != tt.wantHeight {
+				t.Errorf("fitSize() = %dx%d, want %dx%d", width, height, tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
+
+func TestTransformApply(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		transform  Transform
+		wantFormat string
+		wantWidth  int
+		unchanged  bool
+	}{
+		{"jpeg resized", FormatJPEG, Transform{Width: 100}, FormatJPEG, 100, false},
+		{"png stays png", FormatPNG, Transform{Width: 100}, FormatPNG, 100, false},
+		{"png to jpeg", FormatPNG, Transform{Format: FormatJPEG}, FormatJPEG, 400, false},
+		{"png that fits is unchanged", FormatPNG, Transform{Width: 800, Format: FormatPNG}, FormatPNG, 400, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := encodeImage(t, 400, 200, tt.source)
+			data, err := tt.transform.Apply(source)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if tt.unchanged != bytes.Equal(data, source) {
+				t.Errorf("Apply() returned the image unchanged = %v, want %v", !tt.unchanged, tt.unchanged)
+			}
+			img, format, err := image.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Apply() returned an invalid image: %v", err)
+			}
+			if format != tt.wantFormat || img.Bounds().Dx() != tt.wantWidth {
+				t.Errorf("Apply() = %s %d pixels wide, want %s %d pixels wide", format, img.Bounds().Dx(), tt.wantFormat, tt.wantWidth)
+			}
+		})
+	}
+
+	if _, err := (Transform{Width: 100}).Apply([]byte("not an image")); err == nil {
+		t.Error("Apply() error = nil, want an error for data that is not an image")
+	}
+}
+
+func TestTransformKey(t *testing.T) {
+	if got, want := (Transform{Width: 160}).Key("images/logo.png"), "resized/160x0/auto/images/logo.png"; got != want {
+		t.Errorf("Key() = %q, want %q", got, want)
+	}
+	if got, want := (Transform{Height: 90, Format: FormatWebP}).Key("/posters/a.jpg"), "resized/0x90/webp/posters/a.jpg"; got != want {
+		t.Errorf("Key() = %q, want %q", got, want)
+	}
+	if !(Transform{}).IsZero() || (Transform{Format: FormatPNG}).IsZero() {
+		t.Error("IsZero() is wrong")
+	}
+}
@@ -0,0 +1,207 @@
+// Package janitor removes stale files that JioTV Go leaves behind under the path prefix.
+package janitor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants/tasks"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/imagecache"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/scheduler"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// JANITOR_TASK_ID is the ID of the scheduled cleanup task
+	JANITOR_TASK_ID = tasks.JanitorTaskID
+	// Local time of day at which the cleanup runs
+	janitorHour   = 3
+	janitorMinute = 30
+	// tmpMaxAge is how old a temp file must be before it is considered orphaned,
+	// so that files still being written are left alone
+	tmpMaxAge = time.Hour
+	// logMaxAge is how long rotated log files are kept
+	logMaxAge = 7 * 24 * time.Hour
+	// epgFileName is the name of the generated EPG file
+	epgFileName = "epg.xml.gz"
+	// logFileName is the name of the active log file
+	logFileName = "jiotv_go.log"
+)
+
+// Reasons a file is removed
+const (
+	ReasonTempFile   = "orphaned temp file"
+	ReasonEPGBackup  = "superseded EPG backup"
+	ReasonImageCache = "expired image cache entry"
+	ReasonOldLog     = "old log file"
+)
+
+// Item is a stale file found by the janitor
+type Item struct {
+	Path   string
+	Reason string
+	Size   int64
+}
+
+// Init schedules a daily cleanup.
+func Init() {
+	startAt := scheduler.NextDailyRun(time.Now(), janitorHour, janitorMinute)
+	scheduler.AddAt(JANITOR_TASK_ID, startAt, 24*time.Hour, func() error {
+		items, err := Clean(false)
+		utils.SafeLogf("Janitor removed %d stale files (%s)", len(items), FormatSize(TotalSize(items)))
+		return err
+	})
+}
+
+// logDir returns the directory that holds the log files.
+func logDir() string {
+	if config.Cfg.LogPath != "" {
+		return config.Cfg.LogPath
+	}
+	return utils.GetPathPrefix()
+}
+
+// Scan returns the stale files under the path prefix without removing them.
+func Scan(now time.Time) ([]Item, error) {
+	var items []Item
+	seen := make(map[string]bool)
+	add := func(path, reason string, info os.FileInfo) {
+		if seen[path] {
+			return
+		}
+		seen[path] = true
+		items = append(items, Item{Path: path, Reason: reason, Size: info.Size()})
+	}
+
+	prefix := utils.GetPathPrefix()
+	entries, err := os.ReadDir(prefix)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var epgModTime time.Time
+	if info, err := os.Stat(filepath.Join(prefix, epgFileName)); err == nil {
+		epgModTime = info.ModTime()
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		name := entry.Name()
+		path := filepath.Join(prefix, name)
+		switch {
+		case strings.HasSuffix(name, ".tmp"):
+			if now.Sub(info.ModTime()) > tmpMaxAge {
+				add(path, ReasonTempFile, info)
+			}
+		case strings.HasPrefix(name, epgFileName) && name != epgFileName:
+			// Backups are only superseded once a newer EPG file exists
+			if !epgModTime.IsZero() && info.ModTime().Before(epgModTime) {
+				add(path, ReasonEPGBackup, info)
+			}
+		}
+	}
+
+	// Interrupted image downloads leave temp files in the image cache
+	err = filepath.Walk(imagecache.Dir(), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(path, ".tmp") && now.Sub(info.ModTime()) > tmpMaxAge {
+			add(path, ReasonTempFile, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	expired, err := imagecache.Expired(imagecache.MaxAge)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range expired {
+		if info, err := os.Stat(path); err == nil {
+			add(path, ReasonImageCache, info)
+		}
+	}
+
+	// Rotated logs are named like jiotv_go-2024-01-01T00-00-00.000.log(.gz)
+	logBase := strings.TrimSuffix(logFileName, filepath.Ext(logFileName))
+	logEntries, err := os.ReadDir(logDir())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range logEntries {
+		name := entry.Name()
+		if entry.IsDir() || name == logFileName || !strings.HasPrefix(name, logBase+"-") {
+			continue
+		}
+		if !strings.HasSuffix(name, ".log") && !strings.HasSuffix(name, ".log.gz") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if now.Sub(info.ModTime()) > logMaxAge {
+			add(filepath.Join(logDir(), name), ReasonOldLog, info)
+		}
+	}
+
+	return items, nil
+}
+
+// Clean removes the stale files under the path prefix and returns them.
+// With dryRun set, the files are only listed.
+func Clean(dryRun bool) ([]Item, error) {
+	items, err := Scan(time.Now())
+	if err != nil || dryRun {
+		return items, err
+	}
+
+	removed := make([]Item, 0, len(items))
+	for _, item := range items {
+		if err := os.Remove(item.Path); err != nil && !os.IsNotExist(err) {
+			utils.SafeLogf("Janitor failed to remove %s: %v", item.Path, err)
+			continue
+		}
+		removed = append(removed, item)
+	}
+	return removed, nil
+}
+
+// TotalSize returns the combined size of the items in bytes.
+func TotalSize(items []Item) int64 {
+	var total int64
+	for _, item := range items {
+		total += item.Size
+	}
+	return total
+}
+
+// FormatSize formats a size in bytes for display, e.g. "1.5 MB".
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,133 @@
+package janitor
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/imagecache"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// writeFile creates a file with the given age.
+func writeFile(t *testing.T, path string, age time.Duration) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	modTime := time.Now().Add(-age)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("Failed to set file time: %v", err)
+	}
+}
+
+func TestScanAndClean(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+
+	prefix := utils.GetPathPrefix()
+	day := 24 * time.Hour
+
+	stale := map[string]string{
+		filepath.Join(prefix, "epg.xml.gz.tmp"):                          ReasonTempFile,
+		filepath.Join(prefix, "epg.xml.gz.bak"):                          ReasonEPGBackup,
+		filepath.Join(imagecache.Dir(), "posters", "old.jpg"):            ReasonImageCache,
+		filepath.Join(imagecache.Dir(), "posters", "new.jpg.tmp"):        ReasonTempFile,
+		filepath.Join(prefix, "jiotv_go-2024-01-01T00-00-00.000.log"):    ReasonOldLog,
+		filepath.Join(prefix, "jiotv_go-2024-01-01T00-00-00.000.log.gz"): ReasonOldLog,
+	}
+	kept := []string{
+		filepath.Join(prefix, "epg.xml.gz"),
+		filepath.Join(prefix, "store_v4.toml"),
+		filepath.Join(prefix, "fresh.tmp"),
+		filepath.Join(prefix, "jiotv_go.log"),
+		filepath.Join(prefix, "jiotv_go-2025-01-01T00-00-00.000.log"),
+		filepath.Join(imagecache.Dir(), "posters", "new.jpg"),
+	}
+
+	for path := range stale {
+		writeFile(t, path, 10*day)
+	}
+	for _, path := range kept {
+		writeFile(t, path, time.Minute)
+	}
+	writeFile(t, filepath.Join(prefix, "store_v4.toml"), 30*day)
+	writeFile(t, filepath.Join(prefix, "jiotv_go.log"), 30*day)
+
+	items, err := Scan(time.Now())
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	found := make(map[string]string)
+	for _, item := range items {
+		found[item.Path] = item.Reason
+	}
+	for path, reason := range stale {
+		if found[path] != reason {
+			t.Errorf("Scan() reason for %s = %q, want %q", filepath.Base(path), found[path], reason)
+		}
+	}
+	if len(found) != len(stale) {
+		var paths []string
+		for path := range found {
+			paths = append(paths, filepath.Base(path))
+		}
+		sort.Strings(paths)
+		t.Errorf("Scan() found %d files, want %d: %v", len(found), len(stale), paths)
+	}
+
+	// A dry run must not remove anything
+	if _, err := Clean(true); err != nil {
+		t.Fatalf("Clean(true) error = %v", err)
+	}
+	for path := range stale {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Clean(true) removed %s", filepath.Base(path))
+		}
+	}
+
+	removed, err := Clean(false)
+	if err != nil {
+		t.Fatalf("Clean(false) error = %v", err)
+	}
+	if len(removed) != len(stale) {
+		t.Errorf("Clean(false) removed %d files, want %d", len(removed), len(stale))
+	}
+	for path := range stale {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Clean(false) did not remove %s", filepath.Base(path))
+		}
+	}
+	for _, path := range kept {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Clean(false) removed %s", filepath.Base(path))
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		size     int64
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1536, "1.5 KB"},
+		{5 * 1024 * 1024, "5.0 MB"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatSize(tt.size); got != tt.expected {
+			t.Errorf("FormatSize(%d) = %q, want %q", tt.size, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Package library exports channels as a media library of .strm files with NFO metadata, which Kodi
+// and Jellyfin can scan like a folder of movies, without a live TV add-on.
+package library
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Entry is a channel of the library.
+type Entry struct {
+	ID        string
+	Name      string
+	Number    int
+	StreamURL string
+	LogoURL   string
+	Category  string
+	Language  string
+}
+
+// File is a file of the library, with a slash separated path relative to the library directory.
+type File struct {
+	Path string
+	Data []byte
+}
+
+// nfo is the NFO metadata of a channel, in the movie format of Kodi, which Jellyfin reads too.
+type nfo struct {
+	XMLName  xml.Name `xml:"movie"`
+	Title    string   `xml:"title"`
+	SortName string   `xml:"sorttitle,omitempty"`
+	Plot     string   `xml:"plot,omitempty"`
+	Genre    string   `xml:"genre,omitempty"`
+	Tag      string   `xml:"tag,omitempty"`
+	Studio   string   `xml:"studio"`
+	Thumb    *thumb   `xml:"thumb,omitempty"`
+	UniqueID uniqueID `xml:"uniqueid"`
+}
+
+type thumb struct {
+	Aspect string `xml:"aspect,attr"`
+	URL    string `xml:",chardata"`
+}
+
+type uniqueID struct {
+	Type    string `xml:"type,attr"`
+	Default bool   `xml:"default,attr"`
+	ID      string `xml:",chardata"`
+}
+
+// fileName returns a name of the channel that is safe in file names on all platforms.
+func fileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20, strings.ContainsRune(`<>:"/\|?*`, r):
+			return '_'
+		}
+		return r
+	}, name)
+	// Windows does not allow names ending with a dot or space
+	return strings.TrimRight(strings.TrimSpace(name), ". ")
+}
+
+// Files returns the files of the library: a folder per channel with a .strm file, which has the
+// stream URL, and an NFO file of the same name. Channels with the same name get their ID appended.
+func Files(entries []Entry) ([]File, error) {
+	files := make([]File, 0, 2*len(entries))
+	used := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if entry.StreamURL == "" {
+			continue
+		}
+		name := fileName(entry.Name)
+		if name == "" || used[strings.ToLower(name)] {
+			name = strings.TrimSpace(name + " " + fileName(entry.ID))
+		}
+		used[strings.ToLower(name)] = true
+
+		metadata := nfo{
+			Title:    entry.Name,
+			Genre:    entry.Category,
+			Tag:      entry.Language,
+			Studio:   "JioTV Go",
+			UniqueID: uniqueID{Type: "jiotv", Default: true, ID: entry.ID},
+		}
+		if entry.Number > 0 {
+			// Sort the library in the order of the channel numbers
+			metadata.SortName = fmt.Sprintf("%05d %s", entry.Number, entry.Name)
+			metadata.Plot = "Channel " + strconv.Itoa(entry.Number)
+		}
+		if entry.LogoURL != "" {
+			metadata.Thumb = &thumb{Aspect: "poster", URL: entry.LogoURL}
+		}
+		data, err := xml.MarshalIndent(metadata, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files,
+			File{Path: name + "/" + name + ".strm", Data: []byte(entry.StreamURL + "\n")},
+			File{Path: name + "/" + name + ".nfo", Data: append([]byte(xml.Header), append(data, '\n')...)},
+		)
+	}
+	return files, nil
+}
+
+// Write writes the library of the entries into dir, replacing the files of earlier exports.
+// It returns the number of channels written.
+func Write(dir string, entries []Entry) (int, error) {
+	files, err := Files(entries)
+	if err != nil {
+		return 0, err
+	}
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file.Path))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return 0, err
+		}
+		if err := os.WriteFile(path, file.Data, 0o644); err != nil {
+			return 0, err
+		}
+	}
+	return len(files) / 2, nil
+}
+
+// WriteZip writes the library of the entries as a zip file to w.
+func WriteZip(w io.Writer, entries []Entry) error {
+	files, err := Files(entries)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(w)
+	for _, file := range files {
+		fw, err := zw.Create(file.Path)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(file.Data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
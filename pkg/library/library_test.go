@@ -0,0 +1,97 @@
+package library
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "Colors HD", want: "Colors HD"},
+		{name: "AT/DD: News?", want: "AT_DD_ News_"},
+		{name: " Star Plus. ", want: "Star Plus"},
+	}
+	for _, tt := range tests {
+		if got := fileName(tt.name); got != tt.want {
+			t.Errorf("fileName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFiles(t *testing.T) {
+	entries := []Entry{
+		{ID: "143", Name: "Sony HD", Number: 5, StreamURL: "http://localhost:5001/live/143.m3u8", LogoURL: "http://localhost:5001/logo/143.png", Category: "Entertainment", Language: "Hindi"},
+		{ID: "144", Name: "Sony HD", StreamURL: "http://localhost:5001/live/144.m3u8"},
+		{ID: "145", Name: "No Stream"},
+	}
+	files, err := Files(entries)
+	if err != nil {
+		t.Fatalf("Files() error = %v", err)
+	}
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+	want := []string{"Sony HD/Sony HD.strm", "Sony HD/Sony HD.nfo", "Sony HD 144/Sony HD 144.strm", "Sony HD 144/Sony HD 144.nfo"}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Fatalf("Files() paths = %v, want %v", paths, want)
+	}
+	if got := string(files[0].Data); got != "http://localhost:5001/live/143.m3u8\n" {
+		t.Errorf("strm = %q", got)
+	}
+	nfo := string(files[1].Data)
+	for _, part := range []string{
+		"<title>Sony HD</title>",
+		"<sorttitle>00005 Sony HD</sorttitle>",
+		"<genre>Entertainment</genre>",
+		"<tag>Hindi</tag>",
+		`<thumb aspect="poster">http://localhost:5001/logo/143.png</thumb>`,
+		`<uniqueid type="jiotv" default="true">143</uniqueid>`,
+	} {
+		if !strings.Contains(nfo, part) {
+			t.Errorf("nfo = %s, missing %s", nfo, part)
+		}
+	}
+	if strings.Contains(string(files[3].Data), "<thumb") {
+		t.Errorf("nfo of a channel without logo has a thumb: %s", files[3].Data)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	count, err := Write(dir, []Entry{{ID: "143", Name: "Sony HD", StreamURL: "http://localhost:5001/live/143.m3u8"}})
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Write() = %d, want 1", count)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "Sony HD", "Sony HD.strm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "http://localhost:5001/live/143.m3u8\n" {
+		t.Errorf("strm = %q", data)
+	}
+}
+
+func TestWriteZip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteZip(&buf, []Entry{{ID: "143", Name: "Sony HD", StreamURL: "http://localhost:5001/live/143.m3u8"}}); err != nil {
+		t.Fatalf("WriteZip() error = %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) != 2 || zr.File[0].Name != "Sony HD/Sony HD.strm" {
+		t.Errorf("zip files = %v", zr.File)
+	}
+}
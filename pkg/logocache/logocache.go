@@ -0,0 +1,70 @@
+// Package logocache keeps local copies of channel logos, optionally resized, so that IPTV players
+// and TVs do not depend on hotlinking the logos of the JioTV CDN.
+package logocache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/imagecache"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// maxSize is the largest width and height logos are resized to
+	maxSize = 1024
+	// maxDownloads is how many logos are downloaded at the same time
+	maxDownloads = 4
+)
+
+// downloads limits the number of logos downloaded at the same time
+var downloads = make(chan struct{}, maxDownloads)
+
+// Enabled reports whether logos are cached in the config.
+func Enabled() bool {
+	return config.Cfg.LogoCache
+}
+
+// Size returns the width and height logos are resized to fit in, or 0 to keep their size.
+func Size() int {
+	return min(max(config.Cfg.LogoSize, 0), maxSize)
+}
+
+// key returns the image cache key of the logo at upstreamURL. Keys change with the URL,
+// so that a new logo of a channel is downloaded again.
+func key(upstreamURL string, size int) string {
+	sum := sha256.Sum256([]byte(upstreamURL))
+	return "logos/" + strconv.Itoa(size) + "/" + hex.EncodeToString(sum[:12]) + ".png"
+}
+
+// Get returns the logo at upstreamURL as a PNG, downloading and resizing it if it is not cached yet.
+func Get(upstreamURL string, client *fasthttp.Client) ([]byte, error) {
+	size := Size()
+	cacheKey := key(upstreamURL, size)
+	if data, ok := imagecache.Get(cacheKey); ok {
+		return data, nil
+	}
+
+	downloads <- struct{}{}
+	data, err := imagecache.Download(upstreamURL, client)
+	<-downloads
+	if err != nil {
+		return nil, err
+	}
+	logo, err := resize(data, size)
+	if err != nil {
+		return nil, err
+	}
+	if err := imagecache.Put(cacheKey, logo); err != nil {
+		return nil, err
+	}
+	return logo, nil
+}
+
+// resize returns the image as a PNG that fits in size by size pixels, keeping its aspect ratio.
+// Images are never enlarged, and PNG images that fit are returned as they are.
+func resize(data []byte, size int) ([]byte, error) {
+	return imagecache.Transform{Width: size, Height: size, Format: imagecache.FormatPNG}.Apply(data)
+}
@@ -0,0 +1,95 @@
+package logocache
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+// encode returns a solid image of the size in the format
+func encode(t *testing.T, width, height int, format string) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 20, B: 20, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	var err error
+	if format == "jpeg" {
+		err = jpeg.Encode(&buf, img, nil)
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		name       string
+		width      int
+		height     int
+		format     string
+		size       int
+		wantWidth  int
+		wantHeight int
+	}{
+		{"wide logo", 400, 200, "png", 100, 100, 50},
+		{"tall logo", 100, 300, "jpeg", 150, 50, 150},
+		{"small logo is not enlarged", 80, 40, "png", 100, 80, 40},
+		{"original size", 400, 200, "jpeg", 0, 400, 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := resize(encode(t, tt.width, tt.height, tt.format), tt.size)
+			if err != nil {
+				t.Fatalf("resize() error = %v", err)
+			}
+			img, format, err := image.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("resize() returned an invalid image: %v", err)
+			}
+			if format != "png" {
+				t.Errorf("resize() format = %s, want png", format)
+			}
+			if got := img.Bounds().Size(); got.X != tt.wantWidth || got.Y != tt.wantHeight {
+				t.Errorf("resize() size = %v, want %dx%d", got, tt.wantWidth, tt.wantHeight)
+			}
+			if r, _, _, _ := img.At(0, 0).RGBA(); r>>8 < 190 {
+				t.Errorf("resize() changed the colour of the logo, red = %d", r>>8)
+			}
+		})
+	}
+
+	if _, err := resize([]byte("not an image"), 100); err == nil {
+		t.Error("resize() error = nil, want an error for data that is not an image")
+	}
+}
+
+func TestSizeAndKey(t *testing.T) {
+	original := config.Cfg.LogoSize
+	defer func() { config.Cfg.LogoSize = original }()
+
+	for _, tt := range []struct{ size, want int }{{0, 0}, {-5, 0}, {256, 256}, {5000, maxSize}} {
+		config.Cfg.LogoSize = tt.size
+		if got := Size(); got != tt.want {
+			t.Errorf("Size() with logo_size %d = %d, want %d", tt.size, got, tt.want)
+		}
+	}
+
+	if key("https://example.com/a.png", 256) == key("https://example.com/b.png", 256) {
+		t.Error("key() is the same for different logos")
+	}
+	if key("https://example.com/a.png", 256) == key("https://example.com/a.png", 128) {
+		t.Error("key() is the same for different sizes")
+	}
+}
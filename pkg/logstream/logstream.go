@@ -0,0 +1,136 @@
+// Package logstream keeps the recent server log in memory and streams new log lines to subscribers.
+package logstream
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+)
+
+const (
+	// historySize is the number of recent lines sent to new subscribers
+	historySize = 200
+	// subscriberBuffer is the number of lines buffered per subscriber. Lines are dropped for
+	// subscribers that fall further behind, so that a slow client never blocks logging.
+	subscriberBuffer = 256
+)
+
+// Log levels, from the most to the least verbose
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
+// levelRank orders the log levels by severity
+var levelRank = map[string]int{
+	LevelDebug: 0,
+	LevelInfo:  1,
+	LevelWarn:  2,
+	LevelError: 3,
+}
+
+// Line is a line of the server log.
+type Line struct {
+	Level string
+	Text  string
+}
+
+// Hub is an io.Writer for a logger that keeps the recent lines and passes new lines to subscribers.
+type Hub struct {
+	mu          sync.Mutex
+	history     []Line
+	subscribers map[chan Line]string
+}
+
+// Default is the hub the server log is written to.
+var Default = NewHub()
+
+// NewHub returns an empty hub.
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[chan Line]string)}
+}
+
+// ValidLevel reports whether level is a known log level.
+func ValidLevel(level string) bool {
+	_, ok := levelRank[level]
+	return ok
+}
+
+// atLeast reports whether a line of the level is shown for the minimum level.
+func atLeast(level, minLevel string) bool {
+	return levelRank[level] >= levelRank[minLevel]
+}
+
+// levelOf guesses the level of a log line from its message, as the server log has no structured levels.
+// The "[INFO] " or "[DEBUG] " prefix of the logger is the same for every line and is ignored.
+func levelOf(text string) string {
+	for _, prefix := range []string{"[INFO] ", "[DEBUG] "} {
+		if strings.HasPrefix(text, prefix) {
+			text = text[len(prefix):]
+			break
+		}
+	}
+	upper := strings.ToUpper(text)
+	switch {
+	case strings.Contains(upper, "ERROR") || strings.Contains(upper, "FATAL") || strings.Contains(upper, "PANIC"):
+		return LevelError
+	case strings.Contains(upper, "WARN"):
+		return LevelWarn
+	case strings.Contains(upper, "[DEBUG]"):
+		return LevelDebug
+	default:
+		return LevelInfo
+	}
+}
+
+// Write records the log lines in p. It never fails, so that logging is not affected.
+func (h *Hub) Write(p []byte) (int, error) {
+	text := string(bytes.TrimRight(p, "\r\n"))
+	if text == "" {
+		return len(p), nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, part := range strings.Split(text, "\n") {
+		line := Line{Level: levelOf(part), Text: strings.TrimRight(part, "\r")}
+		h.history = append(h.history, line)
+		for ch, minLevel := range h.subscribers {
+			if !atLeast(line.Level, minLevel) {
+				continue
+			}
+			select {
+			case ch <- line:
+			default:
+			}
+		}
+	}
+	if len(h.history) > historySize {
+		h.history = append(h.history[:0:0], h.history[len(h.history)-historySize:]...)
+	}
+	return len(p), nil
+}
+
+// Subscribe returns the recent lines and a channel of new lines of at least minLevel.
+// The returned function ends the subscription and must be called.
+func (h *Hub) Subscribe(minLevel string) ([]Line, <-chan Line, func()) {
+	ch := make(chan Line, subscriberBuffer)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var recent []Line
+	for _, line := range h.history {
+		if atLeast(line.Level, minLevel) {
+			recent = append(recent, line)
+		}
+	}
+	h.subscribers[ch] = minLevel
+
+	return recent, ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subscribers, ch)
+	}
+}
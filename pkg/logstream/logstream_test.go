@@ -0,0 +1,76 @@
+package logstream
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"testing"
+)
+
+func TestLevelOf(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"[INFO] 2024/01/11 10:00:00 Server started", LevelInfo},
+		{"[INFO] 2024/01/11 10:00:00 WARN: Failed to save analytics", LevelWarn},
+		{"[INFO] 2024/01/11 10:00:00 Error rendering M3U8 file", LevelError},
+		{"[DEBUG] 2024/01/11 10:00:00 handlers.go:10: Token refreshed", LevelInfo},
+		{"[DEBUG] 2024/01/11 10:00:00 handlers.go:10: [DEBUG] Token selection", LevelDebug},
+	}
+	for _, tt := range tests {
+		if got := levelOf(tt.text); got != tt.want {
+			t.Errorf("levelOf(%q) = %s, want %s", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestHub(t *testing.T) {
+	hub := NewHub()
+	logger := log.New(io.MultiWriter(io.Discard, hub), "[INFO] ", 0)
+	logger.Println("Server started")
+	logger.Println("WARN: Proxy unreachable")
+
+	recent, lines, unsubscribe := hub.Subscribe(LevelWarn)
+	defer unsubscribe()
+	if len(recent) != 1 || recent[0].Text != "[INFO] WARN: Proxy unreachable" {
+		t.Errorf("Subscribe() recent = %+v, want the warning only", recent)
+	}
+
+	logger.Println("Serving channel 143")
+	logger.Println("ERROR: Render failed")
+	select {
+	case line := <-lines:
+		if line.Level != LevelError || line.Text != "[INFO] ERROR: Render failed" {
+			t.Errorf("streamed line = %+v, want the error", line)
+		}
+	default:
+		t.Fatal("no line streamed")
+	}
+	select {
+	case line := <-lines:
+		t.Errorf("unexpected streamed line %+v", line)
+	default:
+	}
+
+	// Only the most recent lines are kept
+	for i := 0; i < historySize+10; i++ {
+		logger.Printf("line %d", i)
+	}
+	recent, _, unsubscribeAll := hub.Subscribe(LevelDebug)
+	unsubscribeAll()
+	if len(recent) != historySize || recent[len(recent)-1].Text != fmt.Sprintf("[INFO] line %d", historySize+9) {
+		t.Errorf("history has %d lines ending with %+v", len(recent), recent[len(recent)-1])
+	}
+}
+
+func TestHubSlowSubscriber(t *testing.T) {
+	hub := NewHub()
+	_, _, unsubscribe := hub.Subscribe(LevelDebug)
+	defer unsubscribe()
+
+	// Writing must not block while nobody reads the subscription
+	for i := 0; i < subscriberBuffer*2; i++ {
+		fmt.Fprintf(hub, "line %d\n", i)
+	}
+}
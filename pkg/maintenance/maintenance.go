@@ -0,0 +1,144 @@
+// Package maintenance keeps the scheduled maintenance window of the server, so that clients can
+// announce planned restarts and optionally refuse new streams while the window is active.
+package maintenance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// maintenanceFile stores the scheduled window under the path prefix, so that it survives restarts
+const maintenanceFile = "maintenance.json"
+
+// Window is a scheduled maintenance window
+type Window struct {
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Message string    `json:"message,omitempty"`
+	// BlockNewStreams refuses to start streams while the window is active
+	BlockNewStreams bool `json:"block_new_streams"`
+}
+
+// Active reports whether the window is in progress at now.
+func (w Window) Active(now time.Time) bool {
+	return !now.Before(w.Start) && now.Before(w.End)
+}
+
+// Announcement returns the text shown to clients about the window, in the server's time zone.
+func (w Window) Announcement(now time.Time) string {
+	var text string
+	if w.Active(now) {
+		text = "Maintenance in progress until " + w.End.Local().Format("Mon 2 Jan 15:04")
+	} else {
+		text = fmt.Sprintf("Maintenance scheduled from %s to %s",
+			w.Start.Local().Format("Mon 2 Jan 15:04"), w.End.Local().Format("Mon 2 Jan 15:04"))
+	}
+	if w.Message != "" {
+		text += ": " + w.Message
+	}
+	return text
+}
+
+// Validate checks that the window ends after it starts and has not ended at now.
+func (w Window) Validate(now time.Time) error {
+	if w.End.IsZero() {
+		return errors.New("end time is required")
+	}
+	if !w.End.After(w.Start) {
+		return errors.New("end time must be after start time")
+	}
+	if !w.End.After(now) {
+		return errors.New("end time must be in the future")
+	}
+	return nil
+}
+
+var (
+	mu      sync.RWMutex
+	current *Window
+)
+
+// Init loads the scheduled window from disk.
+func Init() {
+	mu.Lock()
+	defer mu.Unlock()
+	current = nil
+	content, err := store.ReadDocument(maintenanceFile)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			utils.Log.Printf("WARN: Failed to read maintenance window: %v", err)
+		}
+		return
+	}
+	var w Window
+	if err := json.Unmarshal(content, &w); err != nil {
+		utils.Log.Printf("WARN: Ignoring invalid maintenance window: %v", err)
+		return
+	}
+	current = &w
+}
+
+// Get returns the scheduled window, unless there is none or it has ended at now.
+func Get(now time.Time) (Window, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if current == nil || !now.Before(current.End) {
+		return Window{}, false
+	}
+	return *current, true
+}
+
+// Announcement returns the announcement of the scheduled window, or an empty string if there is none.
+func Announcement(now time.Time) string {
+	w, ok := Get(now)
+	if !ok {
+		return ""
+	}
+	return w.Announcement(now)
+}
+
+// BlocksNewStreams reports whether new streams are refused at now.
+func BlocksNewStreams(now time.Time) (Window, bool) {
+	w, ok := Get(now)
+	return w, ok && w.BlockNewStreams && w.Active(now)
+}
+
+// Set schedules a window, replacing the previous one. A window without start time starts at now.
+func Set(w Window, now time.Time) (Window, error) {
+	if w.Start.IsZero() {
+		w.Start = now
+	}
+	// The message is shown on one line, e.g. as an M3U comment
+	w.Message = strings.Join(strings.Fields(w.Message), " ")
+	if err := w.Validate(now); err != nil {
+		return Window{}, err
+	}
+	content, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return Window{}, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if err := store.WriteDocument(maintenanceFile, content); err != nil {
+		return Window{}, err
+	}
+	current = &w
+	return w, nil
+}
+
+// Clear cancels the scheduled window.
+func Clear() error {
+	mu.Lock()
+	defer mu.Unlock()
+	current = nil
+	return store.RemoveDocument(maintenanceFile)
+}
@@ -0,0 +1,106 @@
+package maintenance
+
+import (
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+func setup(t *testing.T) {
+	t.Helper()
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cleanup)
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	Init()
+}
+
+func TestWindowValidate(t *testing.T) {
+	now := time.Date(2024, 1, 11, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		window  Window
+		wantErr bool
+	}{
+		{"upcoming", Window{Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)}, false},
+		{"active", Window{Start: now.Add(-time.Hour), End: now.Add(time.Hour)}, false},
+		{"no end", Window{Start: now}, true},
+		{"ends before start", Window{Start: now.Add(2 * time.Hour), End: now.Add(time.Hour)}, true},
+		{"ended", Window{Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.window.Validate(now); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWindowAnnouncement(t *testing.T) {
+	now := time.Now()
+	w := Window{Start: now.Add(time.Hour), End: now.Add(2 * time.Hour), Message: "Updating"}
+	if got := w.Announcement(now); !strings.HasPrefix(got, "Maintenance scheduled from ") || !strings.HasSuffix(got, ": Updating") {
+		t.Errorf("Announcement() of upcoming window = %q", got)
+	}
+	w.Start = now.Add(-time.Hour)
+	w.Message = ""
+	if got := w.Announcement(now); !strings.HasPrefix(got, "Maintenance in progress until ") || strings.Contains(got, ":  ") {
+		t.Errorf("Announcement() of active window = %q", got)
+	}
+}
+
+func TestSetGetClear(t *testing.T) {
+	setup(t)
+	now := time.Now()
+
+	if _, ok := Get(now); ok {
+		t.Fatal("Get() found a window before one was set")
+	}
+	if _, err := Set(Window{End: now.Add(-time.Minute)}, now); err == nil {
+		t.Error("Set() accepted a window that has ended")
+	}
+
+	w, err := Set(Window{End: now.Add(time.Hour), Message: "Restart\nfor update", BlockNewStreams: true}, now)
+	if err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if !w.Start.Equal(now) || w.Message != "Restart for update" {
+		t.Errorf("Set() = %+v", w)
+	}
+	if _, blocked := BlocksNewStreams(now); !blocked {
+		t.Error("BlocksNewStreams() = false during the window")
+	}
+	if _, blocked := BlocksNewStreams(now.Add(2 * time.Hour)); blocked {
+		t.Error("BlocksNewStreams() = true after the window")
+	}
+	if got := Announcement(now.Add(2 * time.Hour)); got != "" {
+		t.Errorf("Announcement() after the window = %q, want empty", got)
+	}
+
+	// The window survives restarts
+	Init()
+	if got, ok := Get(now); !ok || !got.End.Equal(w.End) || got.Message != w.Message {
+		t.Errorf("Get() after Init() = %+v, %v, want %+v", got, ok, w)
+	}
+
+	if err := Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	Init()
+	if _, ok := Get(now); ok {
+		t.Error("Get() found a window after Clear()")
+	}
+	if err := Clear(); err != nil {
+		t.Errorf("Clear() without window error = %v", err)
+	}
+}
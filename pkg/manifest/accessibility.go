@@ -0,0 +1,165 @@
+package manifest
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// Accessibility characteristics of HLS renditions, see RFC 8216 section 4.3.4.1
+const (
+	characteristicDescribesVideo       = "public.accessibility.describes-video"
+	characteristicDescribesMusicSound  = "public.accessibility.describes-music-and-sound"
+	characteristicTranscribesDialogues = "public.accessibility.transcribes-spoken-dialog"
+)
+
+var (
+	// audioDescriptionName matches rendition names of audio description tracks
+	audioDescriptionName = regexp.MustCompile(`(?i)audio description|described|\bAD\b`)
+	// sdhName matches rendition names of subtitles for the deaf and hard of hearing
+	sdhName = regexp.MustCompile(`(?i)\bSDH\b|\bCC\b|hard of hearing`)
+)
+
+// mediaAttribute is an attribute of an EXT-X-MEDIA tag
+type mediaAttribute struct {
+	key   string
+	value string
+}
+
+// parseMediaAttributes parses the attribute list of an EXT-X-MEDIA tag, keeping the attribute order.
+// Quoted values keep their quotes so that the tag can be written back unchanged.
+func parseMediaAttributes(list string) []mediaAttribute {
+	var attrs []mediaAttribute
+	for len(list) > 0 {
+		eq := strings.IndexByte(list, '=')
+		if eq < 0 {
+			break
+		}
+		key := strings.TrimSpace(list[:eq])
+		list = list[eq+1:]
+
+		var value string
+		if strings.HasPrefix(list, `"`) {
+			end := strings.IndexByte(list[1:], '"')
+			if end < 0 {
+				value, list = list, ""
+			} else {
+				value, list = list[:end+2], list[end+2:]
+			}
+			list = strings.TrimPrefix(list, ",")
+		} else if comma := strings.IndexByte(list, ','); comma >= 0 {
+			value, list = list[:comma], list[comma+1:]
+		} else {
+			value, list = list, ""
+		}
+		attrs = append(attrs, mediaAttribute{key: key, value: value})
+	}
+	return attrs
+}
+
+// formatMediaAttributes writes an attribute list back to its text form.
+func formatMediaAttributes(attrs []mediaAttribute) string {
+	parts := make([]string, len(attrs))
+	for i, attr := range attrs {
+		parts[i] = attr.key + "=" + attr.value
+	}
+	return strings.Join(parts, ",")
+}
+
+// mediaAttributeValue returns the unquoted value of an attribute.
+func mediaAttributeValue(attrs []mediaAttribute, key string) string {
+	for _, attr := range attrs {
+		if attr.key == key {
+			return strings.Trim(attr.value, `"`)
+		}
+	}
+	return ""
+}
+
+// setMediaAttribute sets an attribute, appending it if it does not exist.
+func setMediaAttribute(attrs []mediaAttribute, key, value string) []mediaAttribute {
+	for i := range attrs {
+		if attrs[i].key == key {
+			attrs[i].value = value
+			return attrs
+		}
+	}
+	return append(attrs, mediaAttribute{key: key, value: value})
+}
+
+// hasCharacteristic reports whether a rendition has any of the characteristics.
+func hasCharacteristic(attrs []mediaAttribute, characteristics ...string) bool {
+	for _, c := range strings.Split(mediaAttributeValue(attrs, "CHARACTERISTICS"), ",") {
+		for _, want := range characteristics {
+			if strings.TrimSpace(c) == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsAudioDescription reports whether an EXT-X-MEDIA attribute list describes an audio description track.
+func IsAudioDescription(list string) bool {
+	attrs := parseMediaAttributes(list)
+	if mediaAttributeValue(attrs, "TYPE") != "AUDIO" {
+		return false
+	}
+	return hasCharacteristic(attrs, characteristicDescribesVideo) || audioDescriptionName.MatchString(mediaAttributeValue(attrs, "NAME"))
+}
+
+// IsSDH reports whether an EXT-X-MEDIA attribute list describes subtitles for the deaf and hard of hearing.
+func IsSDH(list string) bool {
+	attrs := parseMediaAttributes(list)
+	if mediaAttributeValue(attrs, "TYPE") != "SUBTITLES" {
+		return false
+	}
+	return hasCharacteristic(attrs, characteristicDescribesMusicSound, characteristicTranscribesDialogues) || sdhName.MatchString(mediaAttributeValue(attrs, "NAME"))
+}
+
+// preferRenditions returns a filter that makes the first rendition matched by isPreferred the default
+// of its rendition group. Other renditions of the group stay available but are no longer the default.
+// Groups without a matching rendition are left unchanged.
+func preferRenditions(isPreferred func(list string) bool) Filter {
+	const mediaTag = "#EXT-X-MEDIA:"
+	return func(manifest []byte) []byte {
+		lines := splitLines(manifest)
+
+		// Find the preferred rendition of each group
+		preferred := make(map[string]int)
+		for i, line := range lines {
+			text := string(bytes.TrimSpace(line))
+			if !strings.HasPrefix(text, mediaTag) || !isPreferred(text[len(mediaTag):]) {
+				continue
+			}
+			attrs := parseMediaAttributes(text[len(mediaTag):])
+			group := mediaAttributeValue(attrs, "TYPE") + "/" + mediaAttributeValue(attrs, "GROUP-ID")
+			if _, ok := preferred[group]; !ok {
+				preferred[group] = i
+			}
+		}
+		if len(preferred) == 0 {
+			return manifest
+		}
+
+		for i, line := range lines {
+			text := string(bytes.TrimSpace(line))
+			if !strings.HasPrefix(text, mediaTag) {
+				continue
+			}
+			attrs := parseMediaAttributes(text[len(mediaTag):])
+			index, ok := preferred[mediaAttributeValue(attrs, "TYPE")+"/"+mediaAttributeValue(attrs, "GROUP-ID")]
+			if !ok {
+				continue
+			}
+			if index == i {
+				attrs = setMediaAttribute(attrs, "DEFAULT", "YES")
+				attrs = setMediaAttribute(attrs, "AUTOSELECT", "YES")
+			} else {
+				attrs = setMediaAttribute(attrs, "DEFAULT", "NO")
+			}
+			lines[i] = []byte(mediaTag + formatMediaAttributes(attrs))
+		}
+		return bytes.Join(lines, []byte("\n"))
+	}
+}
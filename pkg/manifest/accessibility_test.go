@@ -0,0 +1,108 @@
+package manifest
+
+import "testing"
+
+const testAccessibleMaster = "#EXTM3U\n" +
+	"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aud\",NAME=\"Hindi\",LANGUAGE=\"hi\",DEFAULT=YES,AUTOSELECT=YES,URI=\"hi.m3u8\"\n" +
+	"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aud\",NAME=\"Hindi, Described\",LANGUAGE=\"hi\",CHARACTERISTICS=\"public.accessibility.describes-video\",URI=\"hi_ad.m3u8\"\n" +
+	"#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID=\"subs\",NAME=\"English\",LANGUAGE=\"en\",DEFAULT=NO,URI=\"en.m3u8\"\n" +
+	"#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID=\"subs\",NAME=\"English SDH\",LANGUAGE=\"en\",URI=\"en_sdh.m3u8\"\n" +
+	"#EXT-X-STREAM-INF:BANDWIDTH=800000,AUDIO=\"aud\",SUBTITLES=\"subs\"\n" +
+	"low.m3u8\n"
+
+func TestAccessibilityDetection(t *testing.T) {
+	tests := []struct {
+		name  string
+		list  string
+		isAD  bool
+		isSDH bool
+	}{
+		{
+			name: "Audio description by characteristic",
+			list: `TYPE=AUDIO,GROUP-ID="aud",NAME="Hindi",CHARACTERISTICS="public.accessibility.describes-video"`,
+			isAD: true,
+		},
+		{
+			name: "Audio description by name",
+			list: `TYPE=AUDIO,GROUP-ID="aud",NAME="English (Audio Description)"`,
+			isAD: true,
+		},
+		{
+			name: "Regular audio",
+			list: `TYPE=AUDIO,GROUP-ID="aud",NAME="Tamil"`,
+		},
+		{
+			name:  "SDH by characteristic",
+			list:  `TYPE=SUBTITLES,GROUP-ID="subs",NAME="English",CHARACTERISTICS="public.accessibility.transcribes-spoken-dialog,public.accessibility.describes-music-and-sound"`,
+			isSDH: true,
+		},
+		{
+			name:  "SDH by name",
+			list:  `TYPE=SUBTITLES,GROUP-ID="subs",NAME="English [CC]"`,
+			isSDH: true,
+		},
+		{
+			name: "Regular subtitles",
+			list: `TYPE=SUBTITLES,GROUP-ID="subs",NAME="English"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsAudioDescription(tt.list); got != tt.isAD {
+				t.Errorf("IsAudioDescription() = %v, want %v", got, tt.isAD)
+			}
+			if got := IsSDH(tt.list); got != tt.isSDH {
+				t.Errorf("IsSDH() = %v, want %v", got, tt.isSDH)
+			}
+		})
+	}
+}
+
+func TestPreferRenditions(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   Filter
+		input    string
+		expected string
+	}{
+		{
+			name:   "Prefer audio description",
+			filter: preferRenditions(IsAudioDescription),
+			input:  testAccessibleMaster,
+			expected: "#EXTM3U\n" +
+				"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aud\",NAME=\"Hindi\",LANGUAGE=\"hi\",DEFAULT=NO,AUTOSELECT=YES,URI=\"hi.m3u8\"\n" +
+				"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aud\",NAME=\"Hindi, Described\",LANGUAGE=\"hi\",CHARACTERISTICS=\"public.accessibility.describes-video\",URI=\"hi_ad.m3u8\",DEFAULT=YES,AUTOSELECT=YES\n" +
+				"#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID=\"subs\",NAME=\"English\",LANGUAGE=\"en\",DEFAULT=NO,URI=\"en.m3u8\"\n" +
+				"#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID=\"subs\",NAME=\"English SDH\",LANGUAGE=\"en\",URI=\"en_sdh.m3u8\"\n" +
+				"#EXT-X-STREAM-INF:BANDWIDTH=800000,AUDIO=\"aud\",SUBTITLES=\"subs\"\n" +
+				"low.m3u8\n",
+		},
+		{
+			name:   "Prefer SDH subtitles",
+			filter: preferRenditions(IsSDH),
+			input:  testAccessibleMaster,
+			expected: "#EXTM3U\n" +
+				"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aud\",NAME=\"Hindi\",LANGUAGE=\"hi\",DEFAULT=YES,AUTOSELECT=YES,URI=\"hi.m3u8\"\n" +
+				"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aud\",NAME=\"Hindi, Described\",LANGUAGE=\"hi\",CHARACTERISTICS=\"public.accessibility.describes-video\",URI=\"hi_ad.m3u8\"\n" +
+				"#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID=\"subs\",NAME=\"English\",LANGUAGE=\"en\",DEFAULT=NO,URI=\"en.m3u8\"\n" +
+				"#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID=\"subs\",NAME=\"English SDH\",LANGUAGE=\"en\",URI=\"en_sdh.m3u8\",DEFAULT=YES,AUTOSELECT=YES\n" +
+				"#EXT-X-STREAM-INF:BANDWIDTH=800000,AUDIO=\"aud\",SUBTITLES=\"subs\"\n" +
+				"low.m3u8\n",
+		},
+		{
+			name:     "Manifest without accessible renditions is unchanged",
+			filter:   preferRenditions(IsAudioDescription),
+			input:    testMaster,
+			expected: testMaster,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(tt.filter([]byte(tt.input))); got != tt.expected {
+				t.Errorf("filter() =\n%s\nwant\n%s", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+// Package manifest provides a chain of filters applied to rewritten HLS manifests.
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// Filter transforms an HLS manifest.
+type Filter func(manifest []byte) []byte
+
+// Factory creates a filter from its config value.
+type Factory func(value string) (Filter, error)
+
+// chainEntry is a configured filter with the channels it applies to
+type chainEntry struct {
+	name     string
+	filter   Filter
+	channels map[string]bool
+}
+
+var (
+	factories   = map[string]Factory{}
+	factoriesMu sync.RWMutex
+
+	chain     []chainEntry
+	chainOnce sync.Once
+)
+
+func init() {
+	Register("drop_iframes", func(string) (Filter, error) {
+		return dropTags("#EXT-X-I-FRAME-STREAM-INF"), nil
+	})
+	Register("strip_daterange", func(string) (Filter, error) {
+		return dropTags("#EXT-X-DATERANGE"), nil
+	})
+	Register("drop_tag", func(value string) (Filter, error) {
+		tag := strings.TrimSpace(value)
+		if !strings.HasPrefix(tag, "#EXT") {
+			return nil, fmt.Errorf("drop_tag needs an HLS tag like #EXT-X-CUE-OUT, got %q", value)
+		}
+		return dropTags(tag), nil
+	})
+	Register("start_offset", func(value string) (Filter, error) {
+		offset, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("start_offset needs a number of seconds, got %q", value)
+		}
+		return forceStartOffset(offset), nil
+	})
+	Register("prefer_audio_description", func(string) (Filter, error) {
+		return preferRenditions(IsAudioDescription), nil
+	})
+	Register("prefer_sdh", func(string) (Filter, error) {
+		return preferRenditions(IsSDH), nil
+	})
+	Register("prefer_audio_language", func(value string) (Filter, error) {
+		languages, err := parseLanguages(value)
+		if err != nil {
+			return nil, err
+		}
+		return preferAudioLanguages(languages), nil
+	})
+}
+
+// Register adds a filter factory under the given name, replacing any existing one.
+// Filters are referenced by name from the manifest_filters config.
+func Register(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[name] = factory
+}
+
+// buildChain creates the filter chain from the config, skipping invalid filters.
+func buildChain(filters []config.ManifestFilter) []chainEntry {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	entries := make([]chainEntry, 0, len(filters))
+	for i, f := range filters {
+		factory, ok := factories[f.Name]
+		if !ok {
+			utils.SafeLogf("WARN: Skipping manifest filter %d: unknown filter %q", i+1, f.Name)
+			continue
+		}
+		filter, err := factory(f.Value)
+		if err != nil {
+			utils.SafeLogf("WARN: Skipping manifest filter %d: %v", i+1, err)
+			continue
+		}
+		entry := chainEntry{name: f.Name, filter: filter}
+		if len(f.Channels) > 0 {
+			entry.channels = make(map[string]bool, len(f.Channels))
+			for _, id := range f.Channels {
+				entry.channels[id] = true
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// applyChain runs the filters that apply to the channel in order.
+// Filters without a channel list apply to every channel.
+func applyChain(entries []chainEntry, channelID string, manifest []byte) []byte {
+	for _, entry := range entries {
+		if entry.channels != nil && !entry.channels[channelID] {
+			continue
+		}
+		manifest = entry.filter(manifest)
+	}
+	return manifest
+}
+
+// configuredFilters returns the manifest filters enabled in the config.
+// The language and accessibility preferences run first so that manifest_filters can still override them.
+// Audio description runs after the language, as it is needed rather than preferred.
+func configuredFilters() []config.ManifestFilter {
+	var filters []config.ManifestFilter
+	if len(config.Cfg.PreferredAudioLanguages) > 0 {
+		filters = append(filters, config.ManifestFilter{Name: "prefer_audio_language", Value: strings.Join(config.Cfg.PreferredAudioLanguages, ",")})
+	}
+	if config.Cfg.PreferAudioDescription {
+		filters = append(filters, config.ManifestFilter{Name: "prefer_audio_description"})
+	}
+	if config.Cfg.PreferSDHSubtitles {
+		filters = append(filters, config.ManifestFilter{Name: "prefer_sdh"})
+	}
+	return append(filters, config.Cfg.ManifestFilters...)
+}
+
+// Apply runs the configured manifest filters for the channel on the manifest.
+// The filter chain is built from the config on first use.
+func Apply(channelID string, manifest []byte) []byte {
+	chainOnce.Do(func() {
+		chain = buildChain(configuredFilters())
+	})
+	if len(chain) == 0 {
+		return manifest
+	}
+	return applyChain(chain, channelID, manifest)
+}
+
+// splitLines splits a manifest into lines without their line endings.
+func splitLines(manifest []byte) [][]byte {
+	lines := bytes.Split(manifest, []byte("\n"))
+	for i, line := range lines {
+		lines[i] = bytes.TrimSuffix(line, []byte("\r"))
+	}
+	return lines
+}
+
+// dropTags returns a filter that removes all lines starting with the tag.
+func dropTags(tag string) Filter {
+	prefix := []byte(tag)
+	return func(manifest []byte) []byte {
+		lines := splitLines(manifest)
+		kept := lines[:0]
+		for _, line := range lines {
+			if bytes.HasPrefix(bytes.TrimSpace(line), prefix) {
+				continue
+			}
+			kept = append(kept, line)
+		}
+		return bytes.Join(kept, []byte("\n"))
+	}
+}
+
+// forceStartOffset returns a filter that sets the EXT-X-START offset of the manifest.
+// Negative offsets are relative to the end of the playlist, i.e. the live edge.
+func forceStartOffset(offset float64) Filter {
+	start := []byte("#EXT-X-START:TIME-OFFSET=" + strconv.FormatFloat(offset, 'f', -1, 64))
+	return func(manifest []byte) []byte {
+		lines := splitLines(manifest)
+		if len(lines) == 0 || !bytes.HasPrefix(bytes.TrimSpace(lines[0]), []byte("#EXTM3U")) {
+			return manifest
+		}
+		result := make([][]byte, 0, len(lines)+1)
+		result = append(result, lines[0], start)
+		for _, line := range lines[1:] {
+			if bytes.HasPrefix(bytes.TrimSpace(line), []byte("#EXT-X-START")) {
+				continue
+			}
+			result = append(result, line)
+		}
+		return bytes.Join(result, []byte("\n"))
+	}
+}
@@ -0,0 +1,111 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+const testMaster = "#EXTM3U\n" +
+	"#EXT-X-STREAM-INF:BANDWIDTH=800000\n" +
+	"low.m3u8\n" +
+	"#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=80000,URI=\"iframe.m3u8\"\n"
+
+const testMedia = "#EXTM3U\n" +
+	"#EXT-X-START:TIME-OFFSET=0\n" +
+	"#EXT-X-DATERANGE:ID=\"ad1\",START-DATE=\"2024-01-01T00:00:00Z\"\n" +
+	"#EXTINF:6.0,\n" +
+	"seg1.ts\n"
+
+func TestApplyChain(t *testing.T) {
+	tests := []struct {
+		name      string
+		filters   []config.ManifestFilter
+		channelID string
+		input     string
+		expected  string
+	}{
+		{
+			name:     "Drop I-frame playlists",
+			filters:  []config.ManifestFilter{{Name: "drop_iframes"}},
+			input:    testMaster,
+			expected: "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=800000\nlow.m3u8\n",
+		},
+		{
+			name:     "Strip date ranges",
+			filters:  []config.ManifestFilter{{Name: "strip_daterange"}},
+			input:    testMedia,
+			expected: "#EXTM3U\n#EXT-X-START:TIME-OFFSET=0\n#EXTINF:6.0,\nseg1.ts\n",
+		},
+		{
+			name:     "Force start offset replaces existing tag",
+			filters:  []config.ManifestFilter{{Name: "start_offset", Value: "-30"}},
+			input:    testMedia,
+			expected: "#EXTM3U\n#EXT-X-START:TIME-OFFSET=-30\n#EXT-X-DATERANGE:ID=\"ad1\",START-DATE=\"2024-01-01T00:00:00Z\"\n#EXTINF:6.0,\nseg1.ts\n",
+		},
+		{
+			name:     "Drop custom tag",
+			filters:  []config.ManifestFilter{{Name: "drop_tag", Value: "#EXTINF"}},
+			input:    "#EXTM3U\n#EXTINF:6.0,\nseg1.ts",
+			expected: "#EXTM3U\nseg1.ts",
+		},
+		{
+			name: "Filters limited to other channels are skipped",
+			filters: []config.ManifestFilter{
+				{Name: "strip_daterange", Channels: []string{"143"}},
+			},
+			channelID: "144",
+			input:     testMedia,
+			expected:  testMedia,
+		},
+		{
+			name: "Filters limited to the channel are applied in order",
+			filters: []config.ManifestFilter{
+				{Name: "strip_daterange", Channels: []string{"143"}},
+				{Name: "start_offset", Value: "10"},
+			},
+			channelID: "143",
+			input:     testMedia,
+			expected:  "#EXTM3U\n#EXT-X-START:TIME-OFFSET=10\n#EXTINF:6.0,\nseg1.ts\n",
+		},
+		{
+			name: "Invalid filters are ignored",
+			filters: []config.ManifestFilter{
+				{Name: "unknown"},
+				{Name: "start_offset", Value: "abc"},
+				{Name: "drop_tag", Value: "seg1.ts"},
+			},
+			input:    testMedia,
+			expected: testMedia,
+		},
+		{
+			name:     "Start offset skips non-HLS content",
+			filters:  []config.ManifestFilter{{Name: "start_offset", Value: "-30"}},
+			input:    "not a manifest",
+			expected: "not a manifest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := buildChain(tt.filters)
+			got := string(applyChain(chain, tt.channelID, []byte(tt.input)))
+			if got != tt.expected {
+				t.Errorf("applyChain() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("custom_test", func(string) (Filter, error) {
+		return func(manifest []byte) []byte {
+			return []byte("#EXTM3U\n#CUSTOM")
+		}, nil
+	})
+
+	chain := buildChain([]config.ManifestFilter{{Name: "custom_test"}})
+	if got := string(applyChain(chain, "1", []byte("#EXTM3U"))); got != "#EXTM3U\n#CUSTOM" {
+		t.Errorf("custom filter result = %q", got)
+	}
+}
@@ -0,0 +1,93 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// languageCodes maps ISO 639-2 codes and English names of the languages on JioTV
+// to their ISO 639-1 code, as streams use any of them to label audio tracks.
+var languageCodes = map[string]string{
+	"hin": "hi", "hindi": "hi",
+	"eng": "en", "english": "en",
+	"tam": "ta", "tamil": "ta",
+	"tel": "te", "telugu": "te",
+	"kan": "kn", "kannada": "kn",
+	"mal": "ml", "malayalam": "ml",
+	"mar": "mr", "marathi": "mr",
+	"ben": "bn", "bengali": "bn", "bangla": "bn",
+	"guj": "gu", "gujarati": "gu",
+	"pan": "pa", "punjabi": "pa",
+	"ori": "or", "ory": "or", "odia": "or", "oriya": "or",
+	"asm": "as", "assamese": "as",
+	"urd": "ur", "urdu": "ur",
+	"bho": "bho", "bhojpuri": "bho",
+}
+
+// normalizeLanguage returns the ISO 639-1 code of a language code, tag or name where known,
+// so that "hin", "hi-IN" and "Hindi" compare equal.
+func normalizeLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if code, ok := languageCodes[language]; ok {
+		return code
+	}
+	primary, _, _ := strings.Cut(language, "-")
+	if code, ok := languageCodes[primary]; ok {
+		return code
+	}
+	return primary
+}
+
+// IsAudioLanguage reports whether an EXT-X-MEDIA attribute list describes an audio track in the language.
+// The language is compared with the LANGUAGE and NAME attributes of the track.
+func IsAudioLanguage(list, language string) bool {
+	attrs := parseMediaAttributes(list)
+	if mediaAttributeValue(attrs, "TYPE") != "AUDIO" {
+		return false
+	}
+	want := normalizeLanguage(language)
+	if want == "" {
+		return false
+	}
+	if lang := mediaAttributeValue(attrs, "LANGUAGE"); lang != "" && normalizeLanguage(lang) == want {
+		return true
+	}
+	return normalizeLanguage(mediaAttributeValue(attrs, "NAME")) == want
+}
+
+// preferAudioLanguages returns a filter that makes the audio track in the first available
+// of the languages the default. The least preferred language is applied first, so that
+// more preferred languages override it in groups that have them.
+func preferAudioLanguages(languages []string) Filter {
+	filters := make([]Filter, 0, len(languages))
+	for i := len(languages) - 1; i >= 0; i-- {
+		language := languages[i]
+		filters = append(filters, preferRenditions(func(list string) bool {
+			return IsAudioLanguage(list, language)
+		}))
+	}
+	return func(manifest []byte) []byte {
+		if !bytes.Contains(manifest, []byte("TYPE=AUDIO")) {
+			return manifest
+		}
+		for _, filter := range filters {
+			manifest = filter(manifest)
+		}
+		return manifest
+	}
+}
+
+// parseLanguages parses a comma separated list of languages.
+func parseLanguages(value string) ([]string, error) {
+	var languages []string
+	for _, language := range strings.Split(value, ",") {
+		if language = strings.TrimSpace(language); language != "" {
+			languages = append(languages, language)
+		}
+	}
+	if len(languages) == 0 {
+		return nil, fmt.Errorf("prefer_audio_language needs a comma separated list of languages like hi,en, got %q", value)
+	}
+	return languages, nil
+}
@@ -0,0 +1,68 @@
+package manifest
+
+import "testing"
+
+const testMultiAudioMaster = "#EXTM3U\n" +
+	"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aud\",NAME=\"Hindi\",LANGUAGE=\"hin\",DEFAULT=YES,AUTOSELECT=YES,URI=\"hin.m3u8\"\n" +
+	"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aud\",NAME=\"English\",LANGUAGE=\"eng\",URI=\"eng.m3u8\"\n" +
+	"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aud\",NAME=\"Tamil\",LANGUAGE=\"ta-IN\",URI=\"tam.m3u8\"\n" +
+	"#EXT-X-STREAM-INF:BANDWIDTH=800000,AUDIO=\"aud\"\n" +
+	"low.m3u8\n"
+
+func TestIsAudioLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		list     string
+		language string
+		expected bool
+	}{
+		{"ISO 639-1 code matches ISO 639-2 code", `TYPE=AUDIO,NAME="Track 1",LANGUAGE="hin"`, "hi", true},
+		{"language name matches tag", `TYPE=AUDIO,NAME="Track 1",LANGUAGE="ta-IN"`, "Tamil", true},
+		{"name matches without language", `TYPE=AUDIO,NAME="English"`, "en", true},
+		{"other language", `TYPE=AUDIO,NAME="Hindi",LANGUAGE="hi"`, "en", false},
+		{"subtitles are not audio", `TYPE=SUBTITLES,NAME="English",LANGUAGE="en"`, "en", false},
+		{"empty language", `TYPE=AUDIO,NAME="Hindi",LANGUAGE="hi"`, " ", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsAudioLanguage(tt.list, tt.language); got != tt.expected {
+				t.Errorf("IsAudioLanguage(%q, %q) = %v, want %v", tt.list, tt.language, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPreferAudioLanguages(t *testing.T) {
+	tests := []struct {
+		name      string
+		languages []string
+		expected  string
+	}{
+		{
+			name:      "First available language wins",
+			languages: []string{"bn", "ta", "en"},
+			expected: "#EXTM3U\n" +
+				"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aud\",NAME=\"Hindi\",LANGUAGE=\"hin\",DEFAULT=NO,AUTOSELECT=YES,URI=\"hin.m3u8\"\n" +
+				"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aud\",NAME=\"English\",LANGUAGE=\"eng\",URI=\"eng.m3u8\",DEFAULT=NO,AUTOSELECT=YES\n" +
+				"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aud\",NAME=\"Tamil\",LANGUAGE=\"ta-IN\",URI=\"tam.m3u8\",DEFAULT=YES,AUTOSELECT=YES\n" +
+				"#EXT-X-STREAM-INF:BANDWIDTH=800000,AUDIO=\"aud\"\n" +
+				"low.m3u8\n",
+		},
+		{
+			name:      "Unavailable languages leave the manifest unchanged",
+			languages: []string{"bn"},
+			expected:  testMultiAudioMaster,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(preferAudioLanguages(tt.languages)([]byte(testMultiAudioMaster))); got != tt.expected {
+				t.Errorf("filter() =\n%s\nwant\n%s", got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := parseLanguages(" , "); err == nil {
+		t.Error("parseLanguages() of an empty list succeeded")
+	}
+}
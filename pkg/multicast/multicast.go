@@ -0,0 +1,318 @@
+// Package multicast sends live channels as MPEG-TS streams over UDP or RTP, e.g. to multicast groups
+// on the local network, so that set-top boxes and IPTV players without HLS support can play them.
+package multicast
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/hls"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// liveEdgeSegments is how many segments from the end of the playlist an output starts, like HLS players do
+	liveEdgeSegments = 3
+	// retryInterval is how long an output waits after the playlist or a segment could not be fetched
+	retryInterval = 5 * time.Second
+	// minPollInterval is the shortest time between two reloads of the playlist
+	minPollInterval = time.Second
+	// queuedSegments is how many segments are fetched ahead of the one being sent
+	queuedSegments = 2
+)
+
+// ErrExists is returned when a stream is already sent to the destination
+var ErrExists = errors.New("a stream is already sent to this multicast address")
+
+// Source is the live stream sent by an output.
+type Source interface {
+	// Playlist returns the current segments of the stream and its target duration in seconds
+	Playlist() ([]hls.Segment, int, error)
+	// Segment downloads a segment of the playlist, decrypted if needed
+	Segment(segment hls.Segment) ([]byte, error)
+}
+
+// Destination is the address a stream is sent to.
+type Destination struct {
+	// RTP wraps the MPEG-TS packets in RTP packets, else they are sent as plain UDP datagrams
+	RTP  bool
+	Addr *net.UDPAddr
+}
+
+// ParseDestination parses a destination like "udp://239.255.0.1:5000", "rtp://239.255.0.1:5000" or
+// "239.255.0.1:5000". The "udp://@239.255.0.1:5000" form of VLC is accepted too.
+func ParseDestination(value string) (Destination, error) {
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, "://") {
+		value = "udp://" + value
+	}
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return Destination{}, fmt.Errorf("invalid multicast address %q: %w", value, err)
+	}
+	var dest Destination
+	switch strings.ToLower(parsed.Scheme) {
+	case "udp":
+	case "rtp":
+		dest.RTP = true
+	default:
+		return Destination{}, fmt.Errorf("invalid multicast address %q: scheme must be udp or rtp", value)
+	}
+	host, port, err := net.SplitHostPort(strings.TrimPrefix(parsed.Host, "@"))
+	if err != nil {
+		return Destination{}, fmt.Errorf("invalid multicast address %q: %w", value, err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return Destination{}, fmt.Errorf("invalid multicast address %q: %q is not an IP address", value, host)
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil || portNumber <= 0 || portNumber > 65535 {
+		return Destination{}, fmt.Errorf("invalid multicast address %q: invalid port %q", value, port)
+	}
+	dest.Addr = &net.UDPAddr{IP: ip, Port: portNumber}
+	return dest, nil
+}
+
+// String returns the destination in the form players open it, e.g. "udp://@239.255.0.1:5000".
+func (d Destination) String() string {
+	scheme := "udp"
+	if d.RTP {
+		scheme = "rtp"
+	}
+	host := d.Addr.String()
+	if d.Addr.IP.IsMulticast() {
+		host = "@" + host
+	}
+	return scheme + "://" + host
+}
+
+// Options are the socket options of an output.
+type Options struct {
+	// TTL is how many routers multicast packets may pass, 1 keeps them on the local network
+	TTL int
+	// Interface is the name of the network interface multicast packets are sent from, or empty for the default
+	Interface string
+}
+
+// Status is the state of an output, see Outputs.
+type Status struct {
+	Channel     string    `json:"channel"`
+	Destination string    `json:"destination"`
+	Started     time.Time `json:"started"`
+	// Sequence is the media sequence number of the last segment sent
+	Sequence  int64  `json:"sequence"`
+	BytesSent int64  `json:"bytes_sent"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// output is a stream being sent to a destination
+type output struct {
+	channel string
+	dest    Destination
+	conn    *net.UDPConn
+	started time.Time
+	done    chan struct{}
+	stopped sync.Once
+
+	mu        sync.Mutex
+	sequence  int64
+	bytesSent int64
+	lastError string
+}
+
+// segment is a downloaded segment waiting to be sent
+type segment struct {
+	sequence int64
+	duration time.Duration
+	data     []byte
+}
+
+var (
+	outputsMu sync.Mutex
+	// outputs are keyed by the String of their destination
+	outputs = map[string]*output{}
+)
+
+// Start starts sending the stream of a channel from source to dest until Stop is called.
+func Start(channel string, dest Destination, opts Options, source Source) error {
+	outputsMu.Lock()
+	defer outputsMu.Unlock()
+	if _, ok := outputs[dest.String()]; ok {
+		return ErrExists
+	}
+	conn, err := dial(dest, opts)
+	if err != nil {
+		return err
+	}
+	o := &output{channel: channel, dest: dest, conn: conn, started: time.Now(), done: make(chan struct{}), sequence: -1}
+	outputs[dest.String()] = o
+
+	segments := make(chan segment, queuedSegments)
+	go o.fetch(source, segments)
+	go o.send(segments)
+	return nil
+}
+
+// Stop stops the output to dest.
+func Stop(dest Destination) {
+	outputsMu.Lock()
+	defer outputsMu.Unlock()
+	if o, ok := outputs[dest.String()]; ok {
+		o.stop()
+		delete(outputs, dest.String())
+	}
+}
+
+// StopAll stops all outputs.
+func StopAll() {
+	outputsMu.Lock()
+	defer outputsMu.Unlock()
+	for key, o := range outputs {
+		o.stop()
+		delete(outputs, key)
+	}
+}
+
+// Outputs returns the state of the running outputs, sorted by destination.
+func Outputs() []Status {
+	outputsMu.Lock()
+	defer outputsMu.Unlock()
+	result := make([]Status, 0, len(outputs))
+	for _, o := range outputs {
+		o.mu.Lock()
+		result = append(result, Status{
+			Channel:     o.channel,
+			Destination: o.dest.String(),
+			Started:     o.started,
+			Sequence:    o.sequence,
+			BytesSent:   o.bytesSent,
+			LastError:   o.lastError,
+		})
+		o.mu.Unlock()
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Destination < result[j].Destination })
+	return result
+}
+
+func (o *output) stop() {
+	o.stopped.Do(func() {
+		close(o.done)
+		o.conn.Close()
+	})
+}
+
+// setError records an error of the output. Repeated errors are logged once.
+func (o *output) setError(err error) {
+	o.mu.Lock()
+	repeated := o.lastError == err.Error()
+	o.lastError = err.Error()
+	o.mu.Unlock()
+	if !repeated {
+		utils.Log.Printf("WARN: Multicast output of channel %s to %s: %v", o.channel, o.dest, err)
+	}
+}
+
+// wait waits for d and reports whether the output is still running.
+func (o *output) wait(d time.Duration) bool {
+	select {
+	case <-o.done:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// fetch downloads the new segments of the source in order and queues them for sending.
+func (o *output) fetch(source Source, segments chan<- segment) {
+	defer close(segments)
+	next := int64(-1)
+	for {
+		playlist, targetDuration, err := source.Playlist()
+		if err != nil {
+			o.setError(err)
+			if !o.wait(retryInterval) {
+				return
+			}
+			continue
+		}
+		if len(playlist) > 0 {
+			first, last := playlist[0].Sequence, playlist[len(playlist)-1].Sequence
+			// Start at the live edge, and jump back to it after falling behind or a restart of the stream
+			if next < first || next > last+1 {
+				next = playlist[max(len(playlist)-liveEdgeSegments, 0)].Sequence
+			}
+		}
+		for _, seg := range playlist {
+			if seg.Sequence < next {
+				continue
+			}
+			data, err := source.Segment(seg)
+			if err != nil {
+				o.setError(err)
+				break
+			}
+			select {
+			case segments <- segment{sequence: seg.Sequence, duration: time.Duration(seg.Duration * float64(time.Second)), data: data}:
+			case <-o.done:
+				return
+			}
+			next = seg.Sequence + 1
+		}
+		if !o.wait(max(time.Duration(targetDuration)*time.Second/2, minPollInterval)) {
+			return
+		}
+	}
+}
+
+// send sends the queued segments, paced so that each segment takes as long as it plays.
+func (o *output) send(segments <-chan segment) {
+	p := newPacketizer(o.dest.RTP)
+	var clock time.Time
+	for seg := range segments {
+		// Restart the clock after a stall, rather than sending the backlog in a burst
+		if now := time.Now(); clock.Before(now.Add(-seg.duration)) {
+			clock = now
+		}
+		datagrams := p.datagrams(seg.data)
+		if len(datagrams) == 0 {
+			continue
+		}
+		interval := seg.duration / time.Duration(len(datagrams))
+		failed := false
+		for _, datagram := range datagrams {
+			if wait := time.Until(clock); wait > time.Millisecond {
+				if !o.wait(wait) {
+					return
+				}
+			}
+			packet := p.packet(datagram, clock)
+			if _, err := o.conn.Write(packet); err != nil {
+				select {
+				case <-o.done:
+					return
+				default:
+				}
+				o.setError(err)
+				failed = true
+			}
+			clock = clock.Add(interval)
+			o.mu.Lock()
+			o.bytesSent += int64(len(packet))
+			o.mu.Unlock()
+		}
+		o.mu.Lock()
+		o.sequence = seg.sequence
+		if !failed {
+			o.lastError = ""
+		}
+		o.mu.Unlock()
+	}
+}
@@ -0,0 +1,147 @@
+package multicast
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/hls"
+)
+
+func TestParseDestination(t *testing.T) {
+	tests := []struct {
+		value   string
+		rtp     bool
+		addr    string
+		str     string
+		wantErr bool
+	}{
+		{value: "udp://239.255.0.1:5000", addr: "239.255.0.1:5000", str: "udp://@239.255.0.1:5000"},
+		{value: "udp://@239.255.0.1:5000", addr: "239.255.0.1:5000", str: "udp://@239.255.0.1:5000"},
+		{value: "239.255.0.1:5000", addr: "239.255.0.1:5000", str: "udp://@239.255.0.1:5000"},
+		{value: "RTP://239.255.0.2:1234", rtp: true, addr: "239.255.0.2:1234", str: "rtp://@239.255.0.2:1234"},
+		{value: "udp://192.168.1.20:1234", addr: "192.168.1.20:1234", str: "udp://192.168.1.20:1234"},
+		{value: "udp://[ff05::1]:5000", addr: "[ff05::1]:5000", str: "udp://@[ff05::1]:5000"},
+		{value: "http://239.255.0.1:5000", wantErr: true},
+		{value: "udp://239.255.0.1", wantErr: true},
+		{value: "udp://stb.local:5000", wantErr: true},
+		{value: "udp://239.255.0.1:70000", wantErr: true},
+	}
+	for _, tt := range tests {
+		dest, err := ParseDestination(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDestination(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if dest.RTP != tt.rtp || dest.Addr.String() != tt.addr {
+			t.Errorf("ParseDestination(%q) = %v %s, want %v %s", tt.value, dest.RTP, dest.Addr, tt.rtp, tt.addr)
+		}
+		if got := dest.String(); got != tt.str {
+			t.Errorf("ParseDestination(%q).String() = %q, want %q", tt.value, got, tt.str)
+		}
+	}
+}
+
+func TestDatagrams(t *testing.T) {
+	p := newPacketizer(false)
+	data := bytes.Repeat([]byte{0x47}, tsPacketSize*10+5)
+	datagrams := p.datagrams(data)
+	if len(datagrams) != 2 {
+		t.Fatalf("datagrams() returned %d datagrams, want 2", len(datagrams))
+	}
+	if len(datagrams[0]) != tsPacketSize*packetsPerDatagram || len(datagrams[1]) != tsPacketSize*3 {
+		t.Errorf("datagrams() sizes = %d, %d, want %d, %d", len(datagrams[0]), len(datagrams[1]), tsPacketSize*packetsPerDatagram, tsPacketSize*3)
+	}
+}
+
+func TestRTPPacket(t *testing.T) {
+	p := newPacketizer(true)
+	payload := bytes.Repeat([]byte{0x47}, tsPacketSize)
+	first := p.packet(payload, p.epoch)
+	second := p.packet(payload, p.epoch.Add(time.Second))
+
+	if first[0] != 0x80 || first[1] != rtpPayloadMP2T {
+		t.Errorf("RTP header starts with % x, want 80 21", first[:2])
+	}
+	if seq1, seq2 := binary.BigEndian.Uint16(first[2:]), binary.BigEndian.Uint16(second[2:]); seq2 != seq1+1 {
+		t.Errorf("RTP sequence numbers = %d, %d, want consecutive numbers", seq1, seq2)
+	}
+	if ts := binary.BigEndian.Uint32(second[4:]) - binary.BigEndian.Uint32(first[4:]); ts != rtpClockRate {
+		t.Errorf("RTP timestamps one second apart differ by %d, want %d", ts, rtpClockRate)
+	}
+	if !bytes.Equal(first[8:12], second[8:12]) {
+		t.Error("RTP packets of one output have different SSRCs")
+	}
+	if !bytes.Equal(first[rtpHeaderSize:], payload) {
+		t.Error("RTP payload differs from the MPEG-TS packets")
+	}
+}
+
+// fakeSource is a stream of two short segments, filled with their sequence number
+type fakeSource struct{}
+
+func (fakeSource) Playlist() ([]hls.Segment, int, error) {
+	return []hls.Segment{{Sequence: 1, Duration: 0.02}, {Sequence: 2, Duration: 0.02}}, 1, nil
+}
+
+func (fakeSource) Segment(segment hls.Segment) ([]byte, error) {
+	return bytes.Repeat([]byte{byte(segment.Sequence)}, tsPacketSize*packetsPerDatagram*2), nil
+}
+
+func TestStart(t *testing.T) {
+	listener, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Skipf("cannot listen on UDP: %v", err)
+	}
+	defer listener.Close()
+
+	dest := Destination{RTP: true, Addr: listener.LocalAddr().(*net.UDPAddr)}
+	if err := Start("143", dest, Options{}, fakeSource{}); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer Stop(dest)
+	if err := Start("143", dest, Options{}, fakeSource{}); err != ErrExists {
+		t.Errorf("second Start() error = %v, want ErrExists", err)
+	}
+
+	// Both segments are sent in two datagrams each, in order
+	listener.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 2048)
+	for i, want := range []byte{1, 1, 2, 2} {
+		n, err := listener.Read(buf)
+		if err != nil {
+			t.Fatalf("datagram %d: %v", i, err)
+		}
+		if n != rtpHeaderSize+tsPacketSize*packetsPerDatagram {
+			t.Fatalf("datagram %d has %d bytes, want %d", i, n, rtpHeaderSize+tsPacketSize*packetsPerDatagram)
+		}
+		if buf[rtpHeaderSize] != want {
+			t.Errorf("datagram %d is from segment %d, want %d", i, buf[rtpHeaderSize], want)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		outputs := Outputs()
+		if len(outputs) == 1 && outputs[0].Channel == "143" && outputs[0].Sequence == 2 {
+			if outputs[0].BytesSent < 4*int64(rtpHeaderSize+tsPacketSize*packetsPerDatagram) {
+				t.Errorf("Outputs() BytesSent = %d, want at least 4 datagrams", outputs[0].BytesSent)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Outputs() = %+v, want the output with sequence 2", outputs)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	Stop(dest)
+	if outputs := Outputs(); len(outputs) != 0 {
+		t.Errorf("Outputs() after Stop() = %+v, want none", outputs)
+	}
+}
@@ -0,0 +1,105 @@
+package multicast
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	// tsPacketSize is the size of an MPEG-TS packet
+	tsPacketSize = 188
+	// packetsPerDatagram is how many MPEG-TS packets are sent per datagram, the most that fit an Ethernet frame
+	packetsPerDatagram = 7
+	// rtpHeaderSize is the size of an RTP header without CSRCs or extensions
+	rtpHeaderSize = 12
+	// rtpPayloadMP2T is the static RTP payload type of MPEG-TS (RFC 3551)
+	rtpPayloadMP2T = 33
+	// rtpClockRate is the RTP clock rate of MPEG-TS
+	rtpClockRate = 90000
+)
+
+// dial opens the socket of an output.
+func dial(dest Destination, opts Options) (*net.UDPConn, error) {
+	conn, err := net.DialUDP("udp", nil, dest.Addr)
+	if err != nil {
+		return nil, err
+	}
+	if !dest.Addr.IP.IsMulticast() {
+		return conn, nil
+	}
+	var ifi *net.Interface
+	if opts.Interface != "" {
+		if ifi, err = net.InterfaceByName(opts.Interface); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("multicast interface %q: %w", opts.Interface, err)
+		}
+	}
+	ttl := max(opts.TTL, 1)
+	if dest.Addr.IP.To4() != nil {
+		p := ipv4.NewPacketConn(conn)
+		err = p.SetMulticastTTL(ttl)
+		if err == nil && ifi != nil {
+			err = p.SetMulticastInterface(ifi)
+		}
+	} else {
+		p := ipv6.NewPacketConn(conn)
+		err = p.SetMulticastHopLimit(ttl)
+		if err == nil && ifi != nil {
+			err = p.SetMulticastInterface(ifi)
+		}
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// packetizer splits segments into datagrams and adds RTP headers to them.
+type packetizer struct {
+	rtp      bool
+	sequence uint16
+	ssrc     uint32
+	// epoch is the time of RTP timestamp 0
+	epoch time.Time
+}
+
+func newPacketizer(rtp bool) *packetizer {
+	return &packetizer{rtp: rtp, sequence: uint16(rand.Uint32()), ssrc: rand.Uint32(), epoch: time.Now()}
+}
+
+// datagrams splits a segment into payloads of whole MPEG-TS packets.
+func (p *packetizer) datagrams(data []byte) [][]byte {
+	// Trailing bytes that are not a whole packet would desynchronise receivers
+	data = data[:len(data)-len(data)%tsPacketSize]
+	const size = tsPacketSize * packetsPerDatagram
+	result := make([][]byte, 0, (len(data)+size-1)/size)
+	for len(data) > 0 {
+		n := min(size, len(data))
+		result = append(result, data[:n])
+		data = data[n:]
+	}
+	return result
+}
+
+// packet returns the datagram of a payload sent at t, with an RTP header if the output uses RTP.
+func (p *packetizer) packet(payload []byte, t time.Time) []byte {
+	if !p.rtp {
+		return payload
+	}
+	packet := make([]byte, rtpHeaderSize+len(payload))
+	packet[0] = 2 << 6 // version 2
+	packet[1] = rtpPayloadMP2T
+	binary.BigEndian.PutUint16(packet[2:], p.sequence)
+	binary.BigEndian.PutUint32(packet[4:], uint32(t.Sub(p.epoch).Seconds()*rtpClockRate))
+	binary.BigEndian.PutUint32(packet[8:], p.ssrc)
+	copy(packet[rtpHeaderSize:], payload)
+	p.sequence++
+	return packet
+}
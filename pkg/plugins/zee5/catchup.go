@@ -0,0 +1,144 @@
+package zee5
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// epochThreshold separates second based epochs from millisecond based ones.
+const epochThreshold = 100000000000
+
+// GetCatchupEPG fetches the programmes of a Zee5 channel for the given day offset.
+// The returned entries use the same keys as the JioTV catchup EPG so that they
+// can be rendered by the existing catchup views.
+func GetCatchupEPG(id string, offset int) ([]map[string]interface{}, error) {
+	body, err := fetchGuide(id, offset)
+	if err != nil {
+		return nil, err
+	}
+	return parseCatchupEPG(body, id)
+}
+
+// parseCatchupEPG converts a Zee5 EPG response into catchup entries for the channel.
+func parseCatchupEPG(body []byte, id string) ([]map[string]interface{}, error) {
+	programs, err := parseGuide(body, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var epgList []map[string]interface{}
+	for _, program := range programs {
+		epgList = append(epgList, map[string]interface{}{
+			"showname":    program.Title,
+			"description": program.Description,
+			"posterURL":   program.Poster,
+			"startEpoch":  program.Start.UnixMilli(),
+			"endEpoch":    program.End.UnixMilli(),
+			"srno":        "",
+		})
+	}
+	if epgList == nil {
+		return nil, fmt.Errorf("no programmes found for channel %s", id)
+	}
+
+	// Show the latest programmes first, like the JioTV catchup list
+	for i, j := 0, len(epgList)-1; i < j; i, j = i+1, j-1 {
+		epgList[i], epgList[j] = epgList[j], epgList[i]
+	}
+	return epgList, nil
+}
+
+// parseEpoch parses a second or millisecond epoch into a time.
+func parseEpoch(value string) (time.Time, error) {
+	epoch, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if epoch < epochThreshold {
+		epoch = epoch * 1000
+	}
+	return time.UnixMilli(epoch), nil
+}
+
+// catchupWindowParams are the query parameters of the time-shift window of a catchup playlist
+var catchupWindowParams = []string{"start", "end"}
+
+// buildCatchupURL appends the time-shift window to a live stream URL.
+// Zee5 streams are served from Akamai, whose live archive takes the window as start and end query
+// parameters in epoch seconds. Relative variant playlist URLs lose the query of the master playlist,
+// so handlePlaylist carries the window to them. A CDN that ignores them plays the live window.
+func buildCatchupURL(liveURL, cookie string, start, end time.Time) string {
+	params := url.Values{}
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(end.Unix(), 10))
+	query := params.Encode()
+	if cookie != "" {
+		query = cookie + "&" + query
+	}
+	if strings.Contains(liveURL, "?") {
+		return liveURL + "&" + query
+	}
+	return liveURL + "?" + query
+}
+
+// withCatchupWindow adds the time-shift window of the playlist at baseURL to the URL of a playlist
+// it refers to, unless the URL has its own window.
+func withCatchupWindow(playlistURL string, baseURL *url.URL) string {
+	parsed, err := url.Parse(playlistURL)
+	if err != nil {
+		return playlistURL
+	}
+	window, query := baseURL.Query(), parsed.Query()
+	var missing []string
+	for _, param := range catchupWindowParams {
+		if value := window.Get(param); value != "" && !query.Has(param) {
+			missing = append(missing, param+"="+url.QueryEscape(value))
+		}
+	}
+	if len(missing) == 0 {
+		return playlistURL
+	}
+	if parsed.RawQuery == "" {
+		parsed.RawQuery = strings.Join(missing, "&")
+	} else {
+		parsed.RawQuery += "&" + strings.Join(missing, "&")
+	}
+	return parsed.String()
+}
+
+// CatchupHandler serves the time-shifted playlist of a Zee5 channel.
+// The start and end query parameters are epochs in seconds or milliseconds.
+func CatchupHandler(c *fiber.Ctx) error {
+	id := c.Params("id")
+	start, err := parseEpoch(c.Query("start"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("invalid start param")
+	}
+	end, err := parseEpoch(c.Query("end"))
+	if err != nil || !end.After(start) {
+		return c.Status(fiber.StatusBadRequest).SendString("invalid end param")
+	}
+
+	data, err := readDataFile()
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		return err
+	}
+	channelItem, ok := findChannelItem(data, id)
+	if !ok || channelItem.URL == "" {
+		return c.Status(fiber.StatusNotFound).SendString("Channel not found")
+	}
+
+	cookie, err := getCookie()
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		return err
+	}
+	handlePlaylist(c, true, buildCatchupURL(channelItem.URL, cookie, start, end), serverURL(c), id)
+	return nil
+}
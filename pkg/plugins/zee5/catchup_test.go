@@ -0,0 +1,195 @@
+package zee5
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
+)
+
+func TestParseCatchupEPG(t *testing.T) {
+	body := []byte(`{"items":[{"id":"0-9-zeetv","items":[
+		{"title":"Morning Show","description":"News","start_time":"2024-01-01T06:00:00Z","end_time":"2024-01-01T07:00:00Z","list_image":"https://img/a.jpg"},
+		{"title":"Evening Show","start_time":"2024-01-01T18:00:00Z","end_time":"2024-01-01T19:00:00Z","image_url":"https://img/b.jpg"},
+		{"title":"Broken","start_time":"bad","end_time":"2024-01-01T19:00:00Z"}
+	]},{"id":"0-9-other","items":[
+		{"title":"Other","start_time":"2024-01-01T06:00:00Z","end_time":"2024-01-01T07:00:00Z"}
+	]}]}`)
+
+	got, err := parseCatchupEPG(body, "0-9-zeetv")
+	if err != nil {
+		t.Fatalf("parseCatchupEPG() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("parseCatchupEPG() returned %d programmes, want 2", len(got))
+	}
+	if got[0]["showname"] != "Evening Show" {
+		t.Errorf("first programme = %v, want latest programme first", got[0]["showname"])
+	}
+	if got[0]["posterURL"] != "https://img/b.jpg" {
+		t.Errorf("posterURL = %v, want image_url fallback", got[0]["posterURL"])
+	}
+	wantStart := time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC).UnixMilli()
+	if got[1]["startEpoch"] != wantStart {
+		t.Errorf("startEpoch = %v, want %d", got[1]["startEpoch"], wantStart)
+	}
+
+	if _, err := parseCatchupEPG([]byte(`{"items":[]}`), "0-9-zeetv"); err == nil {
+		t.Error("parseCatchupEPG() expected error for empty response")
+	}
+}
+
+func TestBuildCatchupURL(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	end := time.Unix(1700003600, 0)
+
+	tests := []struct {
+		name    string
+		liveURL string
+		cookie  string
+		want    string
+	}{
+		{
+			name:    "With cookie",
+			liveURL: "https://cdn.example.com/live/index.m3u8",
+			cookie:  "hdntl=abc",
+			want:    "https://cdn.example.com/live/index.m3u8?hdntl=abc&end=1700003600&start=1700000000",
+		},
+		{
+			name:    "Existing query",
+			liveURL: "https://cdn.example.com/live/index.m3u8?a=1",
+			want:    "https://cdn.example.com/live/index.m3u8?a=1&end=1700003600&start=1700000000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildCatchupURL(tt.liveURL, tt.cookie, start, end); got != tt.want {
+				t.Errorf("buildCatchupURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEpoch(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"1700000000", 1700000000, false},
+		{"1700000000000", 1700000000, false},
+		{"abc", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseEpoch(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseEpoch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.Unix() != tt.want {
+				t.Errorf("parseEpoch() = %d, want %d", got.Unix(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCatchupPlaylistKeepsWindow(t *testing.T) {
+	secureurl.Init()
+	// The shape of an Akamai master playlist of a Zee5 channel, with relative variant playlists
+	master := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-INDEPENDENT-SEGMENTS
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",LANGUAGE="hin",NAME="Hindi",DEFAULT=YES,AUTOSELECT=YES,URI="index_3_a.m3u8?null=0"
+#EXT-X-STREAM-INF:BANDWIDTH=1240000,AVERAGE-BANDWIDTH=1100000,RESOLUTION=854x480,CODECS="avc1.4d401f,mp4a.40.2",AUDIO="aac"
+index_1_av.m3u8?null=0
+#EXT-X-STREAM-INF:BANDWIDTH=2560000,AVERAGE-BANDWIDTH=2300000,RESOLUTION=1280x720,CODECS="avc1.4d401f,mp4a.40.2",AUDIO="aac"
+index_2_av.m3u8?null=0&start=1600000000&end=1600003600
+`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+		io.WriteString(w, master)
+	}))
+	defer server.Close()
+
+	start, end := time.Unix(1700000000, 0), time.Unix(1700003600, 0)
+	catchupURL := buildCatchupURL(server.URL+"/hls/live/2023/zeetv/master.m3u8", "hdntl=exp=1700009999~acl=%2f*~hmac=ab", start, end)
+
+	app := fiber.New()
+	app.Get("/catchup", func(c *fiber.Ctx) error {
+		handlePlaylist(c, true, catchupURL, "http://localhost:5001/t/family", "0-9-zeetv")
+		return nil
+	})
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/catchup", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+
+	var playlists []url.Values
+	for _, line := range strings.Split(string(body), "\n") {
+		_, rendered, ok := strings.Cut(line, "http://localhost:5001/t/family/zee5/render/playlist.m3u8?")
+		if !ok {
+			continue
+		}
+		rendered, _, _ = strings.Cut(rendered, `"`)
+		params, _ := url.ParseQuery(rendered)
+		if got := params.Get(renderChannelParam); got != "0-9-zeetv" {
+			t.Errorf("rendered playlist channel = %q, want the channel for its manifest filters", got)
+		}
+		upstream, err := secureurl.DecryptURL(params.Get("auth"))
+		if err != nil {
+			t.Fatalf("DecryptURL() error = %v", err)
+		}
+		parsed, err := url.Parse(upstream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		playlists = append(playlists, parsed.Query())
+	}
+	if len(playlists) != 3 {
+		t.Fatalf("rendered playlists = %d, want 3 in\n%s", len(playlists), body)
+	}
+	// The audio and 480p playlists get the window of the master playlist, the 720p playlist keeps its own
+	for i, want := range []string{"1700000000", "1700000000", "1600000000"} {
+		if got := playlists[i].Get("start"); got != want || playlists[i].Get("end") == "" {
+			t.Errorf("playlist %d query = %v, want start=%s and an end", i, playlists[i], want)
+		}
+	}
+}
+
+func TestServerURL(t *testing.T) {
+	app := fiber.New()
+	app.Get("/url", func(c *fiber.Ctx) error {
+		if base := c.Query("tenant"); base != "" {
+			c.Locals(constants.TenantBaseLocal, base)
+		}
+		return c.SendString(serverURL(c))
+	})
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"/url", "http://example.com"},
+		{"/url?tenant=/t/family", "http://example.com/t/family"},
+	}
+	for _, tt := range tests {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, tt.target, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != tt.want {
+			t.Errorf("serverURL() of %s = %q, want %q", tt.target, body, tt.want)
+		}
+	}
+}
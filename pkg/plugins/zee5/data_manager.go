@@ -37,9 +37,7 @@ func loadAndCacheZee5Data() {
 		data = nil
 	}
 
-	zee5DataMu.Lock()
-	zee5DataCache = data
-	zee5DataMu.Unlock()
+	setCachedZee5Data(data)
 
 	if data != nil && len(data.Data) > 0 {
 		utils.SafeLogf("INFO: Zee5 cached %d channels", len(data.Data))
@@ -48,6 +46,16 @@ func loadAndCacheZee5Data() {
 	}
 }
 
+// setCachedZee5Data indexes the channels of the data and caches it.
+func setCachedZee5Data(data *DataFile) {
+	if data != nil {
+		data.indexChannels()
+	}
+	zee5DataMu.Lock()
+	zee5DataCache = data
+	zee5DataMu.Unlock()
+}
+
 // GetCachedZee5Data returns the cached zee5 data
 func GetCachedZee5Data() *DataFile {
 	zee5DataMu.RLock()
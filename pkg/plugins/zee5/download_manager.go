@@ -81,9 +81,7 @@ func DownloadZee5Data() error {
 	}
 
 	// Update the cached data
-	zee5DataMu.Lock()
-	zee5DataCache = data
-	zee5DataMu.Unlock()
+	setCachedZee5Data(data)
 
 	utils.SafeLogf("INFO: Successfully downloaded and cached %d Zee5 channels", len(data.Data))
 	return nil
@@ -0,0 +1,203 @@
+package zee5
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// ZEE5_EPG_URL returns the programme guide of a channel between Unix start and end times.
+	ZEE5_EPG_URL = "https://catalogapi.zee5.com/v1/epg?channels=%s&start=%d&end=%d&page_size=100&translation=en&country=IN"
+	// epgDays is the number of days fetched for the EPG, starting today.
+	epgDays = 2
+	// epgWorkers is the number of channels fetched concurrently for the EPG.
+	epgWorkers = 5
+)
+
+// istLocation is the time zone of the days of the guide
+var istLocation = time.FixedZone("IST", 5*60*60+30*60)
+
+// guideDay returns the Unix start and end of the IST day at the given offset from now.
+func guideDay(now time.Time, offset int) (start, end int64) {
+	now = now.In(istLocation)
+	day := time.Date(now.Year(), now.Month(), now.Day()+offset, 0, 0, 0, 0, istLocation)
+	return day.Unix(), day.AddDate(0, 0, 1).Unix()
+}
+
+// epgResponse is the response of the Zee5 EPG API.
+type epgResponse struct {
+	Items []struct {
+		ID    string       `json:"id"`
+		Items []epgProgram `json:"items"`
+	} `json:"items"`
+}
+
+// epgProgram is a single programme returned by the Zee5 EPG API.
+type epgProgram struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	StartTime   string   `json:"start_time"`
+	EndTime     string   `json:"end_time"`
+	ListImage   string   `json:"list_image"`
+	ImageURL    string   `json:"image_url"`
+	Genres      []string `json:"genres"`
+}
+
+// guideProgram is a parsed programme of a Zee5 channel.
+type guideProgram struct {
+	Title       string
+	Description string
+	Category    string
+	Poster      string
+	Start       time.Time
+	End         time.Time
+}
+
+// fetchGuide fetches the raw programme guide of a channel for the given day offset.
+func fetchGuide(id string, offset int) ([]byte, error) {
+	client := &http.Client{Timeout: 15 * time.Second, Transport: utils.HTTPTransport()}
+	start, end := guideDay(time.Now(), offset)
+	req, err := http.NewRequest("GET", fmt.Sprintf(ZEE5_EPG_URL, url.QueryEscape(id), start, end), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", USER_AGENT)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching zee5 epg: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("zee5 epg returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read zee5 epg body: %w", err)
+	}
+	return body, nil
+}
+
+// parseGuide parses a Zee5 EPG response into programmes of the given channel,
+// ordered by start time. Programmes with invalid times are skipped.
+func parseGuide(body []byte, id string) ([]guideProgram, error) {
+	var result epgResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse zee5 epg: %w", err)
+	}
+
+	var programs []guideProgram
+	for _, channel := range result.Items {
+		if channel.ID != "" && channel.ID != id {
+			continue
+		}
+		for _, program := range channel.Items {
+			start, err := time.Parse(time.RFC3339, program.StartTime)
+			if err != nil {
+				continue
+			}
+			end, err := time.Parse(time.RFC3339, program.EndTime)
+			if err != nil {
+				continue
+			}
+			poster := program.ListImage
+			if poster == "" {
+				poster = program.ImageURL
+			}
+			category := ""
+			if len(program.Genres) > 0 {
+				category = program.Genres[0]
+			}
+			programs = append(programs, guideProgram{
+				Title:       program.Title,
+				Description: program.Description,
+				Category:    category,
+				Poster:      poster,
+				Start:       start,
+				End:         end,
+			})
+		}
+	}
+	sort.SliceStable(programs, func(i, j int) bool {
+		return programs[i].Start.Before(programs[j].Start)
+	})
+	return programs, nil
+}
+
+// toEPGProgramme converts a guide programme into an EPG programme of the channel.
+func toEPGProgramme(channelID string, program guideProgram) epg.Programme {
+	return epg.Programme{
+		Channel:  channelID,
+		Start:    program.Start.Format("20060102150405 -0700"),
+		Stop:     program.End.Format("20060102150405 -0700"),
+		Title:    epg.Title{Value: program.Title, Lang: "en"},
+		Desc:     epg.Desc{Value: program.Description, Lang: "en"},
+		Category: epg.Category{Value: program.Category, Lang: "en"},
+		Icon:     epg.Icon{Src: program.Poster},
+	}
+}
+
+// EPGData returns the channels and programmes of all Zee5 channels for EPG generation.
+// Channel IDs match the tvg-id used for Zee5 channels in the playlist.
+func EPGData() ([]epg.Channel, []epg.Programme, error) {
+	data, err := readDataFile()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	channels := make([]epg.Channel, 0, len(data.Data))
+	for _, channelItem := range data.Data {
+		channels = append(channels, epg.Channel{ID: channelItem.ID, Display: channelItem.Name})
+	}
+
+	var programmes []epg.Programme
+	var programmesMu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan ChannelItem, len(data.Data))
+
+	for i := 0; i < epgWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for channelItem := range queue {
+				for offset := 0; offset < epgDays; offset++ {
+					body, err := fetchGuide(channelItem.ID, offset)
+					if err != nil {
+						utils.SafeLogf("[zee5] Error fetching EPG for channel %s, offset %d: %v", channelItem.ID, offset, err)
+						break
+					}
+					programs, err := parseGuide(body, channelItem.ID)
+					if err != nil {
+						utils.SafeLogf("[zee5] Error parsing EPG for channel %s, offset %d: %v", channelItem.ID, offset, err)
+						continue
+					}
+					programmesMu.Lock()
+					for _, program := range programs {
+						programmes = append(programmes, toEPGProgramme(channelItem.ID, program))
+					}
+					programmesMu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, channelItem := range data.Data {
+		queue <- channelItem
+	}
+	close(queue)
+	wg.Wait()
+
+	return channels, programmes, nil
+}
@@ -0,0 +1,73 @@
+package zee5
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseGuide(t *testing.T) {
+	body := []byte(`{"items":[{"id":"0-9-zeetv","items":[
+		{"title":"Evening Show","start_time":"2024-01-01T18:00:00Z","end_time":"2024-01-01T19:00:00Z","genres":["Drama"]},
+		{"title":"Morning Show","start_time":"2024-01-01T06:00:00Z","end_time":"2024-01-01T07:00:00Z"}
+	]}]}`)
+
+	programs, err := parseGuide(body, "0-9-zeetv")
+	if err != nil {
+		t.Fatalf("parseGuide() error = %v", err)
+	}
+	if len(programs) != 2 {
+		t.Fatalf("parseGuide() returned %d programmes, want 2", len(programs))
+	}
+	if programs[0].Title != "Morning Show" {
+		t.Errorf("first programme = %q, want programmes ordered by start time", programs[0].Title)
+	}
+	if programs[1].Category != "Drama" {
+		t.Errorf("Category = %q, want %q", programs[1].Category, "Drama")
+	}
+
+	if _, err := parseGuide([]byte(`not json`), "0-9-zeetv"); err == nil {
+		t.Error("parseGuide() expected error for invalid JSON")
+	}
+}
+
+func TestToEPGProgramme(t *testing.T) {
+	program := guideProgram{
+		Title:  "Show",
+		Poster: "https://img/a.jpg",
+		Start:  time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC),
+		End:    time.Date(2024, 1, 1, 7, 0, 0, 0, time.UTC),
+	}
+
+	got := toEPGProgramme("0-9-zeetv", program)
+	if got.Channel != "0-9-zeetv" {
+		t.Errorf("Channel = %q, want %q", got.Channel, "0-9-zeetv")
+	}
+	if got.Start != "20240101060000 +0000" || got.Stop != "20240101070000 +0000" {
+		t.Errorf("Start/Stop = %q/%q, want XMLTV formatted times", got.Start, got.Stop)
+	}
+	if got.Icon.Src != program.Poster {
+		t.Errorf("Icon.Src = %q, want %q", got.Icon.Src, program.Poster)
+	}
+}
+
+func TestGuideDay(t *testing.T) {
+	// 20:00 UTC is 01:30 of the next day in IST
+	now := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		offset int
+		want   time.Time
+	}{
+		{"today", 0, time.Date(2024, 1, 1, 18, 30, 0, 0, time.UTC)},
+		{"tomorrow", 1, time.Date(2024, 1, 2, 18, 30, 0, 0, time.UTC)},
+		{"yesterday", -1, time.Date(2023, 12, 31, 18, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := guideDay(now, tt.offset)
+			if start != tt.want.Unix() || end != tt.want.Add(24*time.Hour).Unix() {
+				t.Errorf("guideDay(%d) = %d, %d, want %d, %d", tt.offset, start, end, tt.want.Unix(), tt.want.Add(24*time.Hour).Unix())
+			}
+		})
+	}
+}
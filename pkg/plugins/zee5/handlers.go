@@ -10,12 +10,16 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/hashicorp/golang-lru/v2/expirable"
 	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
 )
 
 var cache *expirable.LRU[string, string]
 
+// renderChannelParam is the query parameter of the render playlist URLs with the channel ID
+const renderChannelParam = "channel_id"
+
 func init() {
 	cache = expirable.NewLRU[string, string](50, nil, time.Second*3600)
 }
@@ -143,6 +147,18 @@ func zee5IntLanguageID(id int) int {
 type DataFile struct {
 	Title string        `json:"title"`
 	Data  []ChannelItem `json:"data"`
+	// byID holds the position of each channel in Data, set when the data is cached
+	byID map[string]int
+}
+
+// indexChannels indexes the channels by ID, so that finding a channel does not scan all channels.
+func (d *DataFile) indexChannels() {
+	d.byID = make(map[string]int, len(d.Data))
+	for i, channelItem := range d.Data {
+		if _, ok := d.byID[channelItem.ID]; !ok {
+			d.byID[channelItem.ID] = i
+		}
+	}
 }
 
 func readDataFile() (*DataFile, error) {
@@ -165,40 +181,72 @@ func LiveHandler(c *fiber.Ctx) error {
 		return err
 	}
 	url := ""
-
-	for _, channelItem := range data.Data {
-		if channelItem.ID == id {
-			url = channelItem.URL
-			break
-		}
+	if channelItem, ok := findChannelItem(data, id); ok {
+		url = channelItem.URL
 	}
 	if url == "" {
 		c.Set("ID", id)
 		return c.SendString("Channel not found")
 	}
+	cookie, err := getCookie()
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		return err
+	}
+	hostURL := serverURL(c)
+	handlePlaylist(c, true, url+"?"+cookie, hostURL, id)
+	return nil
+}
+
+// serverURL returns the URL of this server for the playlists of a request, with the path prefix of
+// its tenant, so that players stay on the tenant they started on.
+func serverURL(c *fiber.Ctx) string {
+	base, _ := c.Locals(constants.TenantBaseLocal).(string)
+	return strings.ToLower(c.Protocol()) + "://" + c.Hostname() + base
+}
+
+// findChannelItem returns the channel with the given ID from the data file.
+func findChannelItem(data *DataFile, id string) (ChannelItem, bool) {
+	if data == nil {
+		return ChannelItem{}, false
+	}
+	if data.byID != nil {
+		i, ok := data.byID[id]
+		if !ok {
+			return ChannelItem{}, false
+		}
+		return data.Data[i], true
+	}
+	for _, channelItem := range data.Data {
+		if channelItem.ID == id {
+			return channelItem, true
+		}
+	}
+	return ChannelItem{}, false
+}
+
+// getCookie returns the cached hdntl cookie, generating a new one if needed.
+func getCookie() (string, error) {
 	uaHash := getMD5Hash(USER_AGENT)
 	cookie, found := cache.Get(uaHash)
 	if !found {
 		cookieMap, err := generateCookieZee5(USER_AGENT)
 		if err != nil {
-			c.Status(fiber.StatusInternalServerError).SendString(err.Error())
-			return err
+			return "", err
 		}
 		cookie = cookieMap["cookie"]
 		cache.Add(uaHash, cookie)
 	}
-	hostURL := strings.ToLower(c.Protocol()) + "://" + c.Hostname()
-	handlePlaylist(c, true, url+"?"+cookie, hostURL)
-	return nil
+	return cookie, nil
 }
 
 func RenderHandler(c *fiber.Ctx) error {
-	hostURL := strings.ToLower(c.Protocol()) + "://" + c.Hostname()
+	hostURL := serverURL(c)
 	coded_url, err := secureurl.DecryptURL(c.Query("auth"))
 	if err != nil {
 		return err
 	}
-	handlePlaylist(c, false, coded_url, hostURL)
+	handlePlaylist(c, false, coded_url, hostURL, c.Query(renderChannelParam))
 	return nil
 }
 
@@ -217,6 +265,7 @@ func RegisterRoutes(app *fiber.App) {
 	app.Get("/zee5/render/playlist.m3u8", RenderHandler)
 	app.Get("/zee5/render/segment.ts", RenderTSChunkHandler)
 	app.Get("/zee5/render/segment.mp4", RenderMP4ChunkHandler)
+	app.Get("/zee5/catchup/:id", CatchupHandler)
 }
 
 func GetChannels() []television.Channel {
@@ -228,16 +277,34 @@ func GetChannels() []television.Channel {
 	}
 
 	for _, channelItem := range data.Data {
-		channels = append(channels, television.Channel{
-			ID:       channelItem.ID,
-			Name:     channelItem.Name,
-			URL:      "zee5/" + channelItem.ID,
-			LogoURL:  channelItem.Logo,
-			Category: 0,
-			Language: channelItem.Language.JioTVID(),
-			IsHD:     strings.Contains(strings.ToLower(channelItem.Name), " hd"),
-			IsCustom: true,
-		})
+		channels = append(channels, toChannel(channelItem))
 	}
 	return channels
 }
+
+// ChannelByID returns the Zee5 channel with the ID.
+func ChannelByID(id string) (television.Channel, bool) {
+	data, err := readDataFile()
+	if err != nil {
+		return television.Channel{}, false
+	}
+	channelItem, ok := findChannelItem(data, id)
+	if !ok {
+		return television.Channel{}, false
+	}
+	return toChannel(channelItem), true
+}
+
+// toChannel returns the channel of the channel list for a Zee5 channel.
+func toChannel(channelItem ChannelItem) television.Channel {
+	return television.Channel{
+		ID:       channelItem.ID,
+		Name:     channelItem.Name,
+		URL:      "zee5/" + channelItem.ID,
+		LogoURL:  channelItem.Logo,
+		Category: 0,
+		Language: channelItem.Language.JioTVID(),
+		IsHD:     strings.Contains(strings.ToLower(channelItem.Name), " hd"),
+		IsCustom: true,
+	}
+}
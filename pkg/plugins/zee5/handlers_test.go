@@ -0,0 +1,21 @@
+package zee5
+
+import "testing"
+
+func TestFindChannelItem(t *testing.T) {
+	data := &DataFile{Data: []ChannelItem{{ID: "0-9-zeetv", Name: "Zee TV"}, {ID: "0-9-zeenews", Name: "Zee News"}}}
+	for _, indexed := range []bool{false, true} {
+		if indexed {
+			data.indexChannels()
+		}
+		if got, ok := findChannelItem(data, "0-9-zeenews"); !ok || got.Name != "Zee News" {
+			t.Errorf("findChannelItem() indexed=%v = %+v, %v, want Zee News", indexed, got, ok)
+		}
+		if _, ok := findChannelItem(data, "0-9-missing"); ok {
+			t.Errorf("findChannelItem() indexed=%v found a missing channel", indexed)
+		}
+	}
+	if _, ok := findChannelItem(nil, "0-9-zeetv"); ok {
+		t.Error("findChannelItem() found a channel without data")
+	}
+}
@@ -14,12 +14,30 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/andybalholm/brotli"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/cdntoken"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/manifest"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+	"github.com/valyala/fasthttp"
+)
+
+var (
+	// contentClient is the shared client for playlists and segments
+	contentClient     *fasthttp.Client
+	contentClientOnce sync.Once
+)
+
+const (
+	// contentTimeout is the timeout for fetching a playlist or segment
+	contentTimeout = 30 * time.Second
+	// contentMaxRedirects is the number of redirects followed when fetching content
+	contentMaxRedirects = 5
 )
 
 const (
@@ -81,7 +99,7 @@ func generateGuestToken() string {
 func fetchPlatformToken(userAgent string) (string, error) {
 	urlStr := "https://www.zee5.com/live-tv/aaj-tak/0-9-aajtak"
 
-	client := &http.Client{}
+	client := &http.Client{Transport: utils.HTTPTransport()}
 	req, err := http.NewRequest("GET", urlStr, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
@@ -228,7 +246,7 @@ func fetchM3u8URL(guestToken, platformToken, ddToken string, userAgent string) (
 		return "", fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	client := &http.Client{}
+	client := &http.Client{Transport: utils.HTTPTransport()}
 	req, err := http.NewRequest("POST", fullURL, bytes.NewBuffer(jsonPayload))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
@@ -297,6 +315,7 @@ func generateCookieZee5(userAgent string) (map[string]string, error) {
 
 	// 3. Fetch the M3U8 content to get the 'hdntl' cookie
 	client := &http.Client{
+		Transport: utils.HTTPTransport(),
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return nil
 		},
@@ -323,26 +342,21 @@ func generateCookieZee5(userAgent string) (map[string]string, error) {
 	}
 	body := string(bodyBytes)
 
-	re := regexp.MustCompile(`hdntl=([^\s"]+)`)
-	matches := re.FindStringSubmatch(body)
-	if len(matches) > 0 {
-		return map[string]string{"cookie": matches[0]}, nil
+	if token := cdntoken.HDNTL.FromText(body); token != "" {
+		return map[string]string{"cookie": cdntoken.HDNTL.SetQuery("", token)}, nil
 	}
 	return nil, fmt.Errorf("hdntl token not found in response")
 }
 
-func transformURL(relURLStr string, baseURL *url.URL, isMaster bool, prefix string) string {
+// transformURL points a URL of a playlist at the render routes of this server. Playlist URLs carry
+// the channel ID, so that the manifest filters of the channel apply to the variant playlists too.
+func transformURL(relURLStr string, baseURL *url.URL, isMaster bool, prefix string, channelID string) string {
 	relURL, err := url.Parse(relURLStr)
 	if err != nil {
 		return relURLStr
 	}
 
 	absURL := baseURL.ResolveReference(relURL).String()
-	coded_url, err := secureurl.EncryptURL(absURL)
-	if err != nil {
-		utils.Log.Println(err)
-		return ""
-	}
 	path := relURL.Path
 	if path == "" {
 		path = relURL.String()
@@ -350,6 +364,14 @@ func transformURL(relURLStr string, baseURL *url.URL, isMaster bool, prefix stri
 
 	// Simple extension check
 	isM3U8 := strings.Contains(path, ".m3u8")
+	if isM3U8 {
+		absURL = withCatchupWindow(absURL, baseURL)
+	}
+	coded_url, err := secureurl.EncryptURL(absURL)
+	if err != nil {
+		utils.Log.Println(err)
+		return ""
+	}
 	isSegment := strings.Contains(path, ".ts") || strings.Contains(path, ".mp4")
 	segmentType := ""
 	if strings.Contains(path, ".mp4") {
@@ -362,6 +384,9 @@ func transformURL(relURLStr string, baseURL *url.URL, isMaster bool, prefix stri
 		newParams := url.Values{}
 
 		newParams.Set("auth", coded_url)
+		if channelID != "" {
+			newParams.Set(renderChannelParam, channelID)
+		}
 		return fmt.Sprintf("%s/zee5/render/playlist.m3u8?%s", prefix, newParams.Encode())
 
 	} else if isSegment && !isMaster {
@@ -375,31 +400,43 @@ func transformURL(relURLStr string, baseURL *url.URL, isMaster bool, prefix stri
 	return absURL
 }
 
-func fetchContent(targetURL string) ([]byte, http.Header, error) {
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", targetURL, nil)
-	if err != nil {
-		return nil, nil, err
-	}
+// getContentClient returns the shared client used to fetch playlists and segments.
+// Reusing a single client keeps upstream connections alive between segment requests.
+func getContentClient() *fasthttp.Client {
+	contentClientOnce.Do(func() {
+		contentClient = utils.GetRequestClient()
+	})
+	return contentClient
+}
 
-	req.Header.Set("User-Agent", USER_AGENT)
+// fetchContent fetches targetURL and returns the response body and content type.
+func fetchContent(targetURL string) ([]byte, string, error) {
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, nil, err
+	req.SetRequestURI(targetURL)
+	req.Header.SetMethod("GET")
+	req.Header.SetUserAgent(USER_AGENT)
+	req.SetTimeout(contentTimeout)
+
+	if err := getContentClient().DoRedirects(req, resp, contentMaxRedirects); err != nil {
+		return nil, "", err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, nil, fmt.Errorf("upstream returned status %d", resp.StatusCode)
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return nil, "", fmt.Errorf("upstream returned status %d", resp.StatusCode())
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	return body, resp.Header, err
+	// Copy the body as the response is released on return
+	body := append([]byte(nil), resp.Body()...)
+	return body, string(resp.Header.ContentType()), nil
 }
 
-// handlePlaylist contains the common logic for processing m3u8 playlists
-func handlePlaylist(c *fiber.Ctx, isMaster bool, targetURLStr string, prefix string) {
+// handlePlaylist contains the common logic for processing m3u8 playlists.
+// channelID selects the manifest filters to apply, an empty ID only applies filters for all channels.
+func handlePlaylist(c *fiber.Ctx, isMaster bool, targetURLStr string, prefix string, channelID string) {
 	if targetURLStr == "" {
 		c.Status(fiber.StatusBadRequest).SendString("missing url param")
 		return
@@ -439,7 +476,7 @@ func handlePlaylist(c *fiber.Ctx, isMaster bool, targetURLStr string, prefix str
 			matches := reMediaURI.FindStringSubmatch(trimmed)
 			if len(matches) > 1 {
 				originalURI := matches[1]
-				newURI := transformURL(originalURI, baseURL, isMaster, prefix)
+				newURI := transformURL(originalURI, baseURL, isMaster, prefix, channelID)
 				line = strings.Replace(line, originalURI, newURI, 1)
 			}
 			processedLines = append(processedLines, line)
@@ -452,14 +489,14 @@ func handlePlaylist(c *fiber.Ctx, isMaster bool, targetURLStr string, prefix str
 		}
 
 		// It's a URI line
-		newLine := transformURL(trimmed, baseURL, isMaster, prefix)
+		newLine := transformURL(trimmed, baseURL, isMaster, prefix, channelID)
 		processedLines = append(processedLines, newLine)
 	}
 
 	c.Set("Content-Type", "application/vnd.apple.mpegurl")
 	c.Set("Access-Control-Allow-Origin", "*") // Good practice for proxy
 
-	c.Send([]byte(strings.Join(processedLines, "\n")))
+	c.Send(manifest.Apply(channelID, []byte(strings.Join(processedLines, "\n"))))
 }
 
 // ProxySegmentHandler handles the /segment.ts endpoint
@@ -477,18 +514,15 @@ func ProxySegmentHandler(c *fiber.Ctx) {
 	}
 	targetURLStr = coded_url
 
-	content, respHeaders, err := fetchContent(targetURLStr)
+	content, contentType, err := fetchContent(targetURLStr)
 	if err != nil {
 		c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("failed to fetch: %v", err))
 		return
 	}
 
-	// Copy headers
-	if ct := respHeaders.Get("Content-Type"); ct != "" {
-		c.Set("Content-Type", ct)
-	}
-	if cl := respHeaders.Get("Content-Length"); cl != "" {
-		c.Set("Content-Length", cl)
+	// Copy headers, Content-Length is set from the body by fiber
+	if contentType != "" {
+		c.Set("Content-Type", contentType)
 	}
 	c.Set("Access-Control-Allow-Origin", "*")
 
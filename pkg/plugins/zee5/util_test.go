@@ -0,0 +1,70 @@
+package zee5
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/segment.ts":
+			if r.UserAgent() != USER_AGENT {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Content-Type", "video/mp2t")
+			w.Write([]byte("segment"))
+		case "/redirect.ts":
+			http.Redirect(w, r, "/segment.ts", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name            string
+		path            string
+		wantBody        string
+		wantContentType string
+		wantErr         bool
+	}{
+		{
+			name:            "Segment",
+			path:            "/segment.ts",
+			wantBody:        "segment",
+			wantContentType: "video/mp2t",
+		},
+		{
+			name:            "Follows redirects",
+			path:            "/redirect.ts",
+			wantBody:        "segment",
+			wantContentType: "video/mp2t",
+		},
+		{
+			name:    "Upstream error",
+			path:    "/missing.ts",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, contentType, err := fetchContent(server.URL + tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("fetchContent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("fetchContent() body = %q, want %q", body, tt.wantBody)
+			}
+			if contentType != tt.wantContentType {
+				t.Errorf("fetchContent() content type = %q, want %q", contentType, tt.wantContentType)
+			}
+		})
+	}
+}
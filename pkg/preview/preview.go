@@ -0,0 +1,169 @@
+// Package preview captures still frames of live channels with ffmpeg, so that channel grids and
+// dashboards can show what is on instead of a logo.
+package preview
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// minInterval is the shortest time a frame is served before a new one is captured
+	minInterval = 10 * time.Second
+	// captureTimeout is how long downloading a segment and decoding a frame from it may take
+	captureTimeout = 30 * time.Second
+	// maxCaptures is how many frames are captured at the same time
+	maxCaptures = 2
+	// maxFrames is how many frames are cached, the least recently served ones are dropped first
+	maxFrames = 500
+	// width is the width of the frames in pixels, the height keeps the aspect ratio
+	width = 320
+)
+
+// ErrNoFFmpeg is returned when the ffmpeg executable is not found
+var ErrNoFFmpeg = errors.New("ffmpeg is not installed, previews need ffmpeg")
+
+// Frame is a captured JPEG frame of a channel.
+type Frame struct {
+	JPEG     []byte
+	Captured time.Time
+}
+
+// entry is the cached frame of a channel. Its mutex is held while a new frame is captured, so that
+// concurrent requests for the same channel wait for one capture.
+type entry struct {
+	mu       sync.Mutex
+	frame    Frame
+	lastUsed time.Time
+}
+
+var (
+	framesMu sync.Mutex
+	frames   = map[string]*entry{}
+	// captures limits the number of ffmpeg processes
+	captures = make(chan struct{}, maxCaptures)
+	// capture decodes the first video frame of an MPEG-TS segment, replaced in tests
+	capture = ffmpegCapture
+)
+
+// Enabled reports whether previews are enabled in the config.
+func Enabled() bool {
+	return config.Cfg.PreviewInterval > 0
+}
+
+// Interval returns how long a frame is served before a new one is captured.
+func Interval() time.Duration {
+	return max(time.Duration(config.Cfg.PreviewInterval)*time.Second, minInterval)
+}
+
+// Get returns the frame of key. A new frame is captured from the MPEG-TS segment returned by segment
+// when there is none or it is older than Interval. If that fails, the older frame is returned.
+func Get(key string, segment func() ([]byte, error)) (Frame, error) {
+	e := getEntry(key)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.frame.JPEG != nil && time.Since(e.frame.Captured) < Interval() {
+		return e.frame, nil
+	}
+	jpeg, err := captureFrame(segment)
+	if err != nil {
+		if e.frame.JPEG != nil && !errors.Is(err, ErrNoFFmpeg) {
+			return e.frame, nil
+		}
+		return Frame{}, err
+	}
+	e.frame = Frame{JPEG: jpeg, Captured: time.Now()}
+	return e.frame, nil
+}
+
+// getEntry returns the cache entry of key, dropping the least recently used entry when the cache is full.
+func getEntry(key string) *entry {
+	framesMu.Lock()
+	defer framesMu.Unlock()
+	now := time.Now()
+	if e, ok := frames[key]; ok {
+		e.lastUsed = now
+		return e
+	}
+	if len(frames) >= maxFrames {
+		oldestKey := ""
+		var oldest time.Time
+		for k, e := range frames {
+			if oldestKey == "" || e.lastUsed.Before(oldest) {
+				oldestKey, oldest = k, e.lastUsed
+			}
+		}
+		delete(frames, oldestKey)
+	}
+	e := &entry{lastUsed: now}
+	frames[key] = e
+	return e
+}
+
+// captureFrame downloads a segment and decodes a frame from it, waiting for a free capture slot.
+func captureFrame(segment func() ([]byte, error)) ([]byte, error) {
+	select {
+	case captures <- struct{}{}:
+	case <-time.After(captureTimeout):
+		return nil, errors.New("too many previews are being captured")
+	}
+	defer func() { <-captures }()
+	data, err := segment()
+	if err != nil {
+		return nil, err
+	}
+	return capture(data)
+}
+
+// ffmpegPath returns the ffmpeg executable from the config or the PATH.
+func ffmpegPath() (string, error) {
+	path, err := utils.FFmpegPath()
+	if err != nil {
+		return "", ErrNoFFmpeg
+	}
+	return path, nil
+}
+
+// ffmpegCapture decodes the first video frame of an MPEG-TS segment into a JPEG.
+func ffmpegCapture(segment []byte) ([]byte, error) {
+	path, err := ffmpegPath()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), captureTimeout)
+	defer cancel()
+	args := append([]string{"-hide_banner", "-loglevel", "error"}, utils.FFmpegHWAccelArgs()...)
+	args = append(args,
+		"-i", "pipe:0",
+		"-frames:v", "1",
+		"-vf", "scale="+strconv.Itoa(width)+":-2",
+		"-q:v", "5",
+		"-f", "image2pipe", "-c:v", "mjpeg",
+		"pipe:1",
+	)
+	cmd := exec.CommandContext(ctx, path, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(segment)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("ffmpeg: %s", msg)
+		}
+		return nil, fmt.Errorf("ffmpeg: %w", err)
+	}
+	if stdout.Len() == 0 {
+		return nil, errors.New("ffmpeg: the segment has no video frame")
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,79 @@
+package preview
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+func TestInterval(t *testing.T) {
+	originalCfg := config.Cfg
+	defer func() { config.Cfg = originalCfg }()
+
+	tests := []struct {
+		seconds int
+		enabled bool
+		want    time.Duration
+	}{
+		{seconds: 0, enabled: false, want: minInterval},
+		{seconds: 5, enabled: true, want: minInterval},
+		{seconds: 120, enabled: true, want: 2 * time.Minute},
+	}
+	for _, tt := range tests {
+		config.Cfg.PreviewInterval = tt.seconds
+		if got := Enabled(); got != tt.enabled {
+			t.Errorf("Enabled() with %d seconds = %v, want %v", tt.seconds, got, tt.enabled)
+		}
+		if got := Interval(); got != tt.want {
+			t.Errorf("Interval() with %d seconds = %v, want %v", tt.seconds, got, tt.want)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	originalCfg, originalCapture := config.Cfg, capture
+	defer func() {
+		config.Cfg, capture = originalCfg, originalCapture
+		frames = map[string]*entry{}
+	}()
+	config.Cfg.PreviewInterval = 60
+	frames = map[string]*entry{}
+	capture = func(segment []byte) ([]byte, error) {
+		return append([]byte("jpeg of "), segment...), nil
+	}
+
+	downloads := 0
+	segment := func() ([]byte, error) {
+		downloads++
+		return []byte("segment"), nil
+	}
+	first, err := Get("143", segment)
+	if err != nil || string(first.JPEG) != "jpeg of segment" {
+		t.Fatalf("Get() = %q, %v, want the captured frame", first.JPEG, err)
+	}
+	if second, err := Get("143", segment); err != nil || !second.Captured.Equal(first.Captured) || downloads != 1 {
+		t.Errorf("second Get() captured again, want the cached frame")
+	}
+
+	// An old frame is captured again, and still served if that fails
+	frames["143"].frame.Captured = time.Now().Add(-2 * time.Minute)
+	failing := func() ([]byte, error) { return nil, errors.New("upstream error") }
+	if stale, err := Get("143", failing); err != nil || string(stale.JPEG) != "jpeg of segment" {
+		t.Errorf("Get() after a failed capture = %q, %v, want the old frame", stale.JPEG, err)
+	}
+	if _, err := Get("144", failing); err == nil {
+		t.Error("Get() of a channel without frame after a failed capture returned no error")
+	}
+}
+
+func TestFFmpegNotFound(t *testing.T) {
+	originalCfg := config.Cfg
+	defer func() { config.Cfg = originalCfg }()
+	config.Cfg.FFmpegPath = "/nonexistent/ffmpeg"
+
+	if _, err := ffmpegCapture([]byte("segment")); !errors.Is(err, ErrNoFFmpeg) {
+		t.Errorf("ffmpegCapture() error = %v, want ErrNoFFmpeg", err)
+	}
+}
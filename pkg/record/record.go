@@ -0,0 +1,143 @@
+// Package record saves HLS streams to MPEG-TS files, for one-shot recordings of live channels
+// and catchup programmes from the command line.
+package record
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/hls"
+)
+
+const (
+	// maxFailures is how many times in a row the playlist or a segment may fail before recording stops
+	maxFailures = 5
+	// retryInterval is how long recording waits after the playlist or a segment could not be fetched
+	retryInterval = 5 * time.Second
+	// minPollInterval is the shortest time between two reloads of a live playlist
+	minPollInterval = time.Second
+)
+
+// Source is a stream read segment by segment.
+type Source interface {
+	// Playlist returns the current segments of the stream and its target duration in seconds
+	Playlist() ([]hls.Segment, int, error)
+	// Segment downloads a segment of the playlist, decrypted if needed
+	Segment(segment hls.Segment) ([]byte, error)
+}
+
+// Options are the options of a recording.
+type Options struct {
+	// Live reloads the playlist for new segments until Until, else the segments of the playlist
+	// are saved once, as for catchup programmes that have ended.
+	Live  bool
+	Until time.Time
+	// Progress is called after each segment that is saved
+	Progress func(Progress)
+}
+
+// Progress is the state of a recording.
+type Progress struct {
+	Segments int
+	Bytes    int64
+	// Duration is the total duration of the saved segments
+	Duration time.Duration
+}
+
+// sleep waits for d, or returns the error of ctx if it is done first.
+var sleep = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Record writes the segments of the stream to w until the playlist is saved or, for live streams,
+// until the time of the options. It stops early when ctx is done, keeping what was written.
+func Record(ctx context.Context, source Source, w io.Writer, opts Options) (Progress, error) {
+	var progress Progress
+	last := int64(-1)
+	failures := 0
+	retry := func(err error) error {
+		failures++
+		if failures >= maxFailures {
+			return err
+		}
+		return sleep(ctx, retryInterval)
+	}
+
+	for {
+		if ctx.Err() != nil {
+			return progress, nil
+		}
+		if opts.Live && !time.Now().Before(opts.Until) {
+			return progress, nil
+		}
+
+		segments, targetDuration, err := source.Playlist()
+		if err != nil {
+			if err := retry(err); err != nil {
+				return progress, interrupted(ctx, err)
+			}
+			continue
+		}
+		if opts.Live && last < 0 && len(segments) > 0 {
+			// Start at the newest segment, like the recording was started now
+			last = segments[len(segments)-1].Sequence - 1
+		}
+
+		for _, segment := range segments {
+			if segment.Sequence <= last {
+				continue
+			}
+			if opts.Live && !time.Now().Before(opts.Until) {
+				return progress, nil
+			}
+			data, err := source.Segment(segment)
+			for err != nil {
+				if err := retry(err); err != nil {
+					return progress, interrupted(ctx, fmt.Errorf("segment %d: %w", segment.Sequence, err))
+				}
+				data, err = source.Segment(segment)
+			}
+			failures = 0
+			if _, err := w.Write(data); err != nil {
+				return progress, err
+			}
+			last = segment.Sequence
+			progress.Segments++
+			progress.Bytes += int64(len(data))
+			progress.Duration += time.Duration(segment.Duration * float64(time.Second))
+			if opts.Progress != nil {
+				opts.Progress(progress)
+			}
+		}
+		failures = 0
+
+		if !opts.Live {
+			if len(segments) == 0 {
+				return progress, errors.New("the playlist has no segments")
+			}
+			return progress, nil
+		}
+		poll := max(time.Duration(targetDuration)*time.Second/2, minPollInterval)
+		if err := sleep(ctx, poll); err != nil {
+			return progress, nil
+		}
+	}
+}
+
+// interrupted returns nil if ctx is done, so that stopping a recording is not an error.
+func interrupted(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
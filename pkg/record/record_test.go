@@ -0,0 +1,112 @@
+package record
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/hls"
+)
+
+// fakeSource serves a playlist of segments whose data is their sequence number
+type fakeSource struct {
+	playlists [][]hls.Segment
+	reloads   int
+	failures  int
+}
+
+func (s *fakeSource) Playlist() ([]hls.Segment, int, error) {
+	playlist := s.playlists[min(s.reloads, len(s.playlists)-1)]
+	s.reloads++
+	return playlist, 2, nil
+}
+
+func (s *fakeSource) Segment(segment hls.Segment) ([]byte, error) {
+	if s.failures > 0 {
+		s.failures--
+		return nil, errors.New("failed")
+	}
+	return []byte{byte(segment.Sequence)}, nil
+}
+
+func segments(sequences ...int64) []hls.Segment {
+	var result []hls.Segment
+	for _, sequence := range sequences {
+		result = append(result, hls.Segment{Sequence: sequence, Duration: 2})
+	}
+	return result
+}
+
+func noSleep(t *testing.T) {
+	original := sleep
+	sleep = func(ctx context.Context, d time.Duration) error { return ctx.Err() }
+	t.Cleanup(func() { sleep = original })
+}
+
+func TestRecordPlaylist(t *testing.T) {
+	noSleep(t)
+	source := &fakeSource{playlists: [][]hls.Segment{segments(1, 2, 3)}, failures: 2}
+	var out bytes.Buffer
+	var calls int
+	progress, err := Record(context.Background(), source, &out, Options{Progress: func(Progress) { calls++ }})
+	if err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if !bytes.Equal(out.Bytes(), []byte{1, 2, 3}) {
+		t.Errorf("Record() wrote %v, want [1 2 3]", out.Bytes())
+	}
+	if progress.Segments != 3 || progress.Bytes != 3 || progress.Duration != 6*time.Second || calls != 3 {
+		t.Errorf("Record() progress = %+v after %d calls", progress, calls)
+	}
+}
+
+func TestRecordLive(t *testing.T) {
+	noSleep(t)
+	source := &fakeSource{playlists: [][]hls.Segment{segments(1, 2, 3), segments(2, 3, 4), segments(3, 4, 5)}}
+	var out bytes.Buffer
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err := Record(ctx, source, &out, Options{Live: true, Until: time.Now().Add(time.Hour), Progress: func(p Progress) {
+		if p.Segments == 3 {
+			cancel()
+		}
+	}})
+	if err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	// The recording starts at the newest segment and skips segments it already saved
+	if !bytes.Equal(out.Bytes(), []byte{3, 4, 5}) {
+		t.Errorf("Record() wrote %v, want [3 4 5]", out.Bytes())
+	}
+}
+
+func TestRecordLiveEnded(t *testing.T) {
+	noSleep(t)
+	source := &fakeSource{playlists: [][]hls.Segment{segments(1)}}
+	var out bytes.Buffer
+	if _, err := Record(context.Background(), source, &out, Options{Live: true, Until: time.Now().Add(-time.Second)}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if out.Len() != 0 || source.reloads != 0 {
+		t.Errorf("Record() after the end wrote %v with %d reloads", out.Bytes(), source.reloads)
+	}
+}
+
+func TestRecordFailures(t *testing.T) {
+	noSleep(t)
+	source := &fakeSource{playlists: [][]hls.Segment{segments(1, 2)}, failures: maxFailures}
+	var out bytes.Buffer
+	if _, err := Record(context.Background(), source, &out, Options{}); err == nil {
+		t.Error("Record() returned no error after the segment kept failing")
+	}
+}
+
+func TestRecordEmptyPlaylist(t *testing.T) {
+	noSleep(t)
+	source := &fakeSource{playlists: [][]hls.Segment{nil}}
+	if _, err := Record(context.Background(), source, &bytes.Buffer{}, Options{}); err == nil {
+		t.Error("Record() of an empty playlist returned no error")
+	}
+}
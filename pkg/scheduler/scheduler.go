@@ -1,41 +1,252 @@
 package scheduler
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 	"github.com/madflojo/tasks"
 )
 
+const (
+	// pendingTasksFile stores the IDs of tasks interrupted by the last shutdown
+	pendingTasksFile = "scheduler_pending.json"
+	// stopPollInterval is how often Stop checks whether running tasks have finished
+	stopPollInterval = 50 * time.Millisecond
+)
+
 var (
 	// Scheduler is the task scheduler
 	Scheduler *tasks.Scheduler
+
+	// stopTimeout is how long Stop waits for running tasks to finish
+	stopTimeout = 30 * time.Second
+
+	// mu guards the fields below
+	mu sync.Mutex
+	// ctx is passed to running tasks and cancelled by Stop
+	ctx    context.Context
+	cancel context.CancelFunc
+	// stopping is set by Stop so that no new task runs are started
+	stopping bool
+	// running counts the running instances of each task
+	running = make(map[string]int)
+	// preempted holds tasks that returned early because Stop cancelled them
+	preempted = make(map[string]bool)
+	// pending holds tasks interrupted by the last shutdown. They run again as soon as they are added.
+	pending = make(map[string]bool)
 )
 
+// Init creates the task scheduler and loads the tasks interrupted by the last shutdown.
 func Init() {
 	// Create a new task scheduler
 	Scheduler = tasks.New()
+
+	mu.Lock()
+	defer mu.Unlock()
+	ctx, cancel = context.WithCancel(context.Background())
+	stopping = false
+	running = make(map[string]int)
+	preempted = make(map[string]bool)
+	pending = loadPendingTasks()
 }
 
+// Stop stops scheduling tasks, cancels the context of running tasks and waits for them to finish,
+// at most for stopTimeout. Tasks that did not finish are stored and run again after a restart.
 func Stop() {
+	if Scheduler == nil {
+		return
+	}
 	// Stop the task scheduler
 	Scheduler.Stop()
+
+	mu.Lock()
+	stopping = true
+	if cancel != nil {
+		cancel()
+	}
+	mu.Unlock()
+
+	deadline := time.Now().Add(stopTimeout)
+	for {
+		mu.Lock()
+		remaining := len(running)
+		mu.Unlock()
+		if remaining == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			utils.Log.Printf("WARN: %d scheduled tasks did not finish within %v", remaining, stopTimeout)
+			break
+		}
+		time.Sleep(stopPollInterval)
+	}
+
+	mu.Lock()
+	interrupted := make([]string, 0, len(running)+len(preempted))
+	for id := range running {
+		interrupted = append(interrupted, id)
+	}
+	for id := range preempted {
+		if running[id] == 0 {
+			interrupted = append(interrupted, id)
+		}
+	}
+	mu.Unlock()
+
+	if len(interrupted) == 0 {
+		return
+	}
+	sort.Strings(interrupted)
+	if err := savePendingTasks(interrupted); err != nil {
+		utils.Log.Printf("WARN: Failed to save interrupted tasks: %v", err)
+		return
+	}
+	utils.Log.Printf("Interrupted tasks will resume after restart: %v", interrupted)
 }
 
+// Add adds a task that runs every interval.
 func Add(id string, interval time.Duration, task func() error) {
+	AddWithContext(id, interval, ignoreContext(task))
+}
+
+// AddWithContext adds a task that runs every interval. The context passed to the task is
+// cancelled when the scheduler stops, and the task should then return its error.
+func AddWithContext(id string, interval time.Duration, task func(ctx context.Context) error) {
+	add(id, &tasks.Task{Interval: interval}, task)
+	utils.Log.Printf("Task added with ID: %v\n", id)
+}
+
+// AddAt adds a task that first runs at startAt and then repeats every interval.
+func AddAt(id string, startAt time.Time, interval time.Duration, task func() error) {
+	AddAtWithContext(id, startAt, interval, ignoreContext(task))
+}
+
+// AddAtWithContext is AddAt for tasks that stop early when the scheduler stops, like AddWithContext.
+func AddAtWithContext(id string, startAt time.Time, interval time.Duration, task func(ctx context.Context) error) {
+	add(id, &tasks.Task{Interval: interval, StartAfter: startAt}, task)
+	utils.Log.Printf("Task added with ID: %v, first run at %v\n", id, startAt.Local())
+}
+
+// Run runs a task once in the calling goroutine. The run is tracked like a scheduled run,
+// so Stop waits for it and resumes it after a restart if it is interrupted.
+func Run(id string, task func(ctx context.Context) error) error {
+	return track(id, task)()
+}
+
+// ResumePending reports whether the task was interrupted by the last shutdown and removes it
+// from the pending tasks, so that adding the task does not run it again. Use it for tasks
+// that run on startup anyway.
+func ResumePending(id string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	if !pending[id] {
+		return false
+	}
+	delete(pending, id)
+	return true
+}
+
+// add schedules a task, and runs it right away if it was interrupted by the last shutdown.
+func add(id string, t *tasks.Task, task func(ctx context.Context) error) {
 	// delete any existing task with the same ID
 	Scheduler.Del(id)
-	// Add a task
-	err := Scheduler.AddWithID(id, &tasks.Task{
-		Interval: interval,
-		TaskFunc: task,
-		ErrFunc: func(err error) {
-			utils.Log.Printf("Task failed: %v\n", err)
-		},
-	})
-	if err != nil {
+	t.TaskFunc = track(id, task)
+	t.ErrFunc = func(err error) {
+		utils.Log.Printf("Task failed: %v\n", err)
+	}
+	if err := Scheduler.AddWithID(id, t); err != nil {
 		utils.Log.Printf("Failed to add task: %v\n", err)
 		return
 	}
-	utils.Log.Printf("Task added with ID: %v\n", id)
+	if ResumePending(id) {
+		utils.Log.Printf("Resuming interrupted task: %v\n", id)
+		go func() {
+			if err := t.TaskFunc(); err != nil {
+				t.ErrFunc(err)
+			}
+		}()
+	}
+}
+
+// track wraps a task so that Stop can cancel it and wait for it.
+func track(id string, task func(ctx context.Context) error) func() error {
+	return func() error {
+		mu.Lock()
+		if stopping {
+			mu.Unlock()
+			return nil
+		}
+		taskCtx := ctx
+		if taskCtx == nil {
+			taskCtx = context.Background()
+		}
+		running[id]++
+		mu.Unlock()
+
+		err := task(taskCtx)
+
+		mu.Lock()
+		if running[id]--; running[id] <= 0 {
+			delete(running, id)
+		}
+		if taskCtx.Err() != nil && errors.Is(err, context.Canceled) {
+			preempted[id] = true
+		}
+		mu.Unlock()
+		return err
+	}
+}
+
+// ignoreContext adapts a task without context support.
+func ignoreContext(task func() error) func(ctx context.Context) error {
+	return func(context.Context) error {
+		if task == nil {
+			return nil
+		}
+		return task()
+	}
+}
+
+// loadPendingTasks reads and removes the tasks interrupted by the last shutdown.
+func loadPendingTasks() map[string]bool {
+	result := make(map[string]bool)
+	data, err := store.ReadDocument(pendingTasksFile)
+	if err != nil {
+		return result
+	}
+	store.RemoveDocument(pendingTasksFile)
+
+	var ids []string
+	if err := json.Unmarshal(data, &ids); err != nil {
+		utils.Log.Printf("WARN: Failed to read interrupted tasks: %v", err)
+		return result
+	}
+	for _, id := range ids {
+		result[id] = true
+	}
+	return result
+}
+
+// savePendingTasks stores the tasks to resume after a restart.
+func savePendingTasks(ids []string) error {
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	return store.WriteDocument(pendingTasksFile, data)
+}
+
+// NextDailyRun returns the next time after now at the given local hour and minute.
+func NextDailyRun(now time.Time, hour, minute int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
 }
@@ -1,18 +1,27 @@
 package scheduler
 
 import (
+	"context"
 	"log"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 )
 
 func TestMain(m *testing.M) {
 	// Initialize logger for tests
 	utils.Log = log.New(os.Stdout, "", log.LstdFlags)
-	os.Exit(m.Run())
+	// Keep interrupted tasks out of the real JioTV Go folder
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		log.Fatal(err)
+	}
+	code := m.Run()
+	cleanup()
+	os.Exit(code)
 }
 
 func TestInit(t *testing.T) {
@@ -132,3 +141,115 @@ func TestAdd(t *testing.T) {
 		Add("nil_task", 1*time.Second, nil)
 	})
 }
+
+func TestNextDailyRun(t *testing.T) {
+	loc := time.UTC
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{
+			name: "Later today",
+			now:  time.Date(2024, 1, 1, 1, 0, 0, 0, loc),
+			want: time.Date(2024, 1, 1, 4, 0, 0, 0, loc),
+		},
+		{
+			name: "Already passed today",
+			now:  time.Date(2024, 1, 1, 5, 0, 0, 0, loc),
+			want: time.Date(2024, 1, 2, 4, 0, 0, 0, loc),
+		},
+		{
+			name: "Exactly at run time",
+			now:  time.Date(2024, 1, 31, 4, 0, 0, 0, loc),
+			want: time.Date(2024, 2, 1, 4, 0, 0, 0, loc),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextDailyRun(tt.now, 4, 0); !got.Equal(tt.want) {
+				t.Errorf("NextDailyRun() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStopResumesInterruptedTasks(t *testing.T) {
+	originalTimeout := stopTimeout
+	stopTimeout = 100 * time.Millisecond
+	defer func() { stopTimeout = originalTimeout }()
+
+	Init()
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	defer close(release)
+
+	// Stops when the scheduler stops
+	go Run("cancellable", func(ctx context.Context) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	// Ignores the context and outlives the stop timeout
+	go Run("stuck", func(ctx context.Context) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	})
+	// Finishes normally
+	if err := Run("finished", func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	<-started
+	<-started
+
+	Stop()
+
+	// Runs are not started after Stop
+	ran := false
+	Run("late", func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+	if ran {
+		t.Error("Run() started a task after Stop")
+	}
+
+	Init()
+	for _, id := range []string{"cancellable", "stuck"} {
+		if !ResumePending(id) {
+			t.Errorf("ResumePending(%q) = false, want true", id)
+		}
+	}
+	for _, id := range []string{"finished", "late", "cancellable"} {
+		if ResumePending(id) {
+			t.Errorf("ResumePending(%q) = true, want false", id)
+		}
+	}
+
+	// The pending tasks are only loaded once
+	Init()
+	if ResumePending("stuck") {
+		t.Error("pending tasks were loaded again after a restart")
+	}
+}
+
+func TestAddResumesPendingTask(t *testing.T) {
+	Init()
+	if err := savePendingTasks([]string{"resumed"}); err != nil {
+		t.Fatalf("savePendingTasks() error = %v", err)
+	}
+	Init()
+	defer Stop()
+
+	done := make(chan struct{})
+	AddWithContext("resumed", time.Hour, func(ctx context.Context) error {
+		close(done)
+		return nil
+	})
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("pending task was not run when it was added")
+	}
+}
@@ -3,20 +3,83 @@ package secureurl
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/url"
+	"sync"
+	"time"
 
 	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 )
 
+const (
+	// sharedKeysName is the store key of the keyring shared by the servers of a shared store
+	sharedKeysName = "secureurlKeys"
+	// keyIDSize is the size of the key ID that prefixes encrypted URLs
+	keyIDSize = 4
+	// expirySize is the size of the expiry that is encrypted with the URL
+	expirySize = 8
+	// macSize is the size of the HMAC that ends encrypted URLs
+	macSize = 16
+	// macKeyInfo derives the HMAC key from the encryption key
+	macKeyInfo = "jiotv_go secureurl hmac"
+	// maxKeys is the number of keys kept in the keyring, including the current key
+	maxKeys = 5
+	// defaultGrace is how long URLs encrypted with a rotated key still decrypt
+	defaultGrace = 24 * time.Hour
+	// reloadWindow is how long after its last request an expired reload URL still decrypts
+	reloadWindow = 2 * time.Minute
+	// LinkSignatureParam is the query param of the signature of links
+	LinkSignatureParam = "sig"
+)
+
 var (
-	key                  []byte
+	// ErrKeyExpired is returned for URLs encrypted with a key that was rotated out of the keyring
+	ErrKeyExpired = errors.New("URL was encrypted with an expired or unknown key")
+	// ErrEncryptionDisabled is returned when rotating keys with URL encryption disabled
+	ErrEncryptionDisabled = errors.New("URL encryption is disabled")
+	// ErrURLExpired is returned for URLs older than url_ttl
+	ErrURLExpired = errors.New("URL has expired")
+	// ErrInvalidSignature is returned for URLs that were not encrypted by this server or were modified
+	ErrInvalidSignature = errors.New("URL signature is invalid")
+)
+
+// urlKey is a key of the keyring
+type urlKey struct {
+	ID      uint32    `json:"id"`
+	Key     []byte    `json:"key"`
+	Created time.Time `json:"created"`
+}
+
+// KeyInfo describes a key of the keyring without its secret
+type KeyInfo struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	// Expires is when URLs encrypted with a rotated key stop decrypting, nil for the current key
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+var (
+	keysMu sync.RWMutex
+	// keys is the keyring, newest first. URLs are encrypted with the first key.
+	keys                 []urlKey
 	disableUrlEncryption bool
+
+	reloadsMu sync.Mutex
+	// reloads holds when the reload URLs that expire were last requested, by URL
+	reloads = make(map[string]time.Time)
+	// reloadsPruned is when reloads were last pruned
+	reloadsPruned time.Time
 )
 
 func generateKey() []byte {
@@ -28,58 +91,260 @@ func generateKey() []byte {
 	return key
 }
 
+// newKey returns a key with a random ID that is not used in the keyring.
+func newKey(ring []urlKey) urlKey {
+	for {
+		id := binary.BigEndian.Uint32(generateKey())
+		if findKey(ring, id) == nil {
+			return urlKey{ID: id, Key: generateKey(), Created: time.Now()}
+		}
+	}
+}
+
+// findKey returns the key with the ID, or nil.
+func findKey(ring []urlKey, id uint32) *urlKey {
+	for i := range ring {
+		if ring[i].ID == id {
+			return &ring[i]
+		}
+	}
+	return nil
+}
+
+// graceWindow returns how long URLs encrypted with a rotated key still decrypt.
+func graceWindow() time.Duration {
+	if config.Cfg.URLKeyGrace > 0 {
+		return time.Duration(config.Cfg.URLKeyGrace) * time.Hour
+	}
+	return defaultGrace
+}
+
+// validKey returns the key of the keyring with the ID, unless it was rotated out more than the grace
+// window ago. A key is rotated out when the next key is created.
+func validKey(ring []urlKey, id uint32, now time.Time) []byte {
+	for i, k := range ring {
+		if k.ID != id {
+			continue
+		}
+		if i > 0 && now.Sub(ring[i-1].Created) > graceWindow() {
+			return nil
+		}
+		return k.Key
+	}
+	return nil
+}
+
+// pruneKeys drops the keys that were rotated out more than the grace window ago.
+func pruneKeys(ring []urlKey, now time.Time) []urlKey {
+	for i := 1; i < len(ring); i++ {
+		if i >= maxKeys || now.Sub(ring[i-1].Created) > graceWindow() {
+			return ring[:i]
+		}
+	}
+	return ring
+}
+
+// urlTTL returns how long encrypted URLs work, 0 for no expiry.
+func urlTTL() time.Duration {
+	return time.Duration(config.Cfg.URLTTL) * time.Hour
+}
+
+// macKey derives the HMAC key of an encryption key.
+func macKey(key []byte) ([]byte, error) {
+	return hkdf.Key(sha256.New, key, nil, macKeyInfo, sha256.Size)
+}
+
+// sign returns the HMAC of the key ID, IV and ciphertext of an encrypted URL.
+func sign(key, data []byte) ([]byte, error) {
+	mk, err := macKey(key)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, mk)
+	mac.Write(data)
+	return mac.Sum(nil)[:macSize], nil
+}
+
+// EncryptURL encrypts a URL with the current key. The URL expires after url_ttl, and is signed so
+// that it cannot be modified.
 func EncryptURL(inputURL string) (string, error) {
 	if disableUrlEncryption {
 		return url.QueryEscape(inputURL), nil
 	}
+	var expires time.Time
+	if ttl := urlTTL(); ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+	return encryptURL(inputURL, expires)
+}
 
-	block, err := aes.NewCipher(key)
+// encryptURL encrypts and signs a URL that expires at the given time, never if it is zero.
+func encryptURL(inputURL string, expires time.Time) (string, error) {
+	keysMu.RLock()
+	if len(keys) == 0 {
+		keysMu.RUnlock()
+		return "", errors.New("URL encryption key is not initialized")
+	}
+	current := keys[0]
+	keysMu.RUnlock()
+
+	block, err := aes.NewCipher(current.Key)
 	if err != nil {
 		return "", err
 	}
 
-	ciphertext := make([]byte, aes.BlockSize+len(inputURL))
-	iv := ciphertext[:aes.BlockSize]
+	plaintext := make([]byte, expirySize+len(inputURL))
+	if !expires.IsZero() {
+		binary.BigEndian.PutUint64(plaintext, uint64(expires.Unix()))
+	}
+	copy(plaintext[expirySize:], inputURL)
+
+	ciphertext := make([]byte, keyIDSize+aes.BlockSize+len(plaintext))
+	binary.BigEndian.PutUint32(ciphertext, current.ID)
+	iv := ciphertext[keyIDSize : keyIDSize+aes.BlockSize]
 	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
 		return "", err
 	}
 
 	stream := cipher.NewCTR(block, iv)
-	stream.XORKeyStream(ciphertext[aes.BlockSize:], []byte(inputURL))
+	stream.XORKeyStream(ciphertext[keyIDSize+aes.BlockSize:], plaintext)
+
+	mac, err := sign(current.Key, ciphertext)
+	if err != nil {
+		return "", err
+	}
 
-	encryptedURL := base64.URLEncoding.EncodeToString(ciphertext)
+	encryptedURL := base64.URLEncoding.EncodeToString(append(ciphertext, mac...))
 	return encryptedURL, nil
 }
 
+// DecryptURL decrypts a URL encrypted by EncryptURL, after checking its signature and expiry.
 func DecryptURL(encryptedURL string) (string, error) {
+	decryptedURL, expires, err := decryptURL(encryptedURL)
+	if err != nil {
+		return "", err
+	}
+	if !expires.IsZero() && time.Now().After(expires) {
+		return "", ErrURLExpired
+	}
+	return decryptedURL, nil
+}
+
+// DecryptReloadURL decrypts a URL that players request again and again for as long as a channel plays, like
+// the URL of a live playlist or manifest. Unlike DecryptURL, an expired URL keeps working while it is
+// requested at least every reloadWindow, so that a channel does not stop while it is watched. A URL that
+// was not requested before it expired, e.g. one copied from a player, fails with ErrURLExpired.
+func DecryptReloadURL(encryptedURL string) (string, error) {
+	decryptedURL, expires, err := decryptURL(encryptedURL)
+	if err != nil || expires.IsZero() {
+		return decryptedURL, err
+	}
+
+	now := time.Now()
+	reloadsMu.Lock()
+	defer reloadsMu.Unlock()
+	if now.Sub(reloadsPruned) > reloadWindow {
+		for reloadURL, last := range reloads {
+			if now.Sub(last) > reloadWindow {
+				delete(reloads, reloadURL)
+			}
+		}
+		reloadsPruned = now
+	}
+	if now.After(expires) {
+		if last, ok := reloads[encryptedURL]; !ok || now.Sub(last) > reloadWindow {
+			delete(reloads, encryptedURL)
+			return "", ErrURLExpired
+		}
+	}
+	reloads[encryptedURL] = now
+	return decryptedURL, nil
+}
+
+// LinksExpire reports whether links to the server are signed to expire after url_ttl.
+func LinksExpire() bool {
+	return !disableUrlEncryption && urlTTL() > 0
+}
+
+// SignLink returns the signature of a link to a path of this server, like /live/143.m3u8. The
+// signature expires after url_ttl.
+func SignLink(path string) (string, error) {
+	return EncryptURL(path)
+}
+
+// VerifyLink checks the signature of a link to a path of this server.
+func VerifyLink(path, signature string) error {
+	signedPath, err := DecryptURL(signature)
+	if err != nil {
+		return err
+	}
+	if signedPath != path {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// decryptURL decrypts a URL encrypted by EncryptURL after checking its signature, and returns when it
+// expires, zero if never.
+func decryptURL(encryptedURL string) (string, time.Time, error) {
 	if disableUrlEncryption {
 		decoded_url, err := url.QueryUnescape(encryptedURL)
-		return decoded_url, err
+		return decoded_url, time.Time{}, err
 	}
 
 	ciphertext, err := base64.URLEncoding.DecodeString(encryptedURL)
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
 
-	block, err := aes.NewCipher(key)
+	if len(ciphertext) < keyIDSize+aes.BlockSize+expirySize+macSize {
+		return "", time.Time{}, errors.New("ciphertext too short")
+	}
+
+	id := binary.BigEndian.Uint32(ciphertext)
+	keysMu.RLock()
+	key := validKey(keys, id, time.Now())
+	keysMu.RUnlock()
+	if key == nil && store.Shared() && store.KVS != nil {
+		// Another server may have rotated the shared keyring
+		if err := reloadSharedKeys(); err == nil {
+			keysMu.RLock()
+			key = validKey(keys, id, time.Now())
+			keysMu.RUnlock()
+		}
+	}
+	if key == nil {
+		return "", time.Time{}, ErrKeyExpired
+	}
+
+	signed, mac := ciphertext[:len(ciphertext)-macSize], ciphertext[len(ciphertext)-macSize:]
+	want, err := sign(key, signed)
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
+	}
+	if !hmac.Equal(mac, want) {
+		return "", time.Time{}, ErrInvalidSignature
 	}
 
-	if len(ciphertext) < aes.BlockSize {
-		return "", errors.New("ciphertext too short")
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", time.Time{}, err
 	}
 
-	iv := ciphertext[:aes.BlockSize]
-	ciphertext = ciphertext[aes.BlockSize:]
+	iv := signed[keyIDSize : keyIDSize+aes.BlockSize]
+	plaintext := make([]byte, len(signed)-keyIDSize-aes.BlockSize)
 
 	stream := cipher.NewCTR(block, iv)
-	stream.XORKeyStream(ciphertext, ciphertext)
+	stream.XORKeyStream(plaintext, signed[keyIDSize+aes.BlockSize:])
 
-	decryptedURL := string(ciphertext)
+	var expires time.Time
+	if expiry := binary.BigEndian.Uint64(plaintext); expiry != 0 {
+		expires = time.Unix(int64(expiry), 0)
+	}
 
-	return decryptedURL, nil
+	decryptedURL := string(plaintext[expirySize:])
+
+	return decryptedURL, expires, nil
 }
 
 func Init() {
@@ -88,5 +353,135 @@ func Init() {
 		fmt.Println("Warning! URL encryption is disabled. Anyone can pass modified URLs to your server.")
 		return
 	}
-	key = generateKey()
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	keys = []urlKey{newKey(nil)}
+	if store.Shared() && store.KVS != nil {
+		shared, err := loadSharedKeys(keys)
+		if err != nil {
+			utils.Log.Println("Warning! Could not share the URL encryption key, URLs only work on this server:", err)
+			return
+		}
+		keys = shared
+	}
+}
+
+// loadSharedKeys returns the keyring shared through the store, which is set to generated by the first
+// server to start. This lets the servers behind a load balancer decrypt the URLs of each other.
+func loadSharedKeys(generated []urlKey) ([]urlKey, error) {
+	encoded, err := json.Marshal(generated)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := store.SetIfAbsent(store.KVS, sharedKeysName, string(encoded))
+	if err != nil {
+		return nil, err
+	}
+	return decodeKeys(shared)
+}
+
+// decodeKeys decodes a keyring saved in the store.
+func decodeKeys(encoded string) ([]urlKey, error) {
+	var ring []urlKey
+	if err := json.Unmarshal([]byte(encoded), &ring); err != nil {
+		return nil, err
+	}
+	if len(ring) == 0 {
+		return nil, errors.New("shared keyring is empty")
+	}
+	for _, k := range ring {
+		if len(k.Key) != 32 {
+			return nil, fmt.Errorf("shared key %08x has %d bytes, want 32", k.ID, len(k.Key))
+		}
+	}
+	return ring, nil
+}
+
+// reloadSharedKeys replaces the keyring with the one in the shared store.
+func reloadSharedKeys() error {
+	encoded, err := store.KVS.Get(sharedKeysName)
+	if err != nil {
+		return err
+	}
+	ring, err := decodeKeys(encoded)
+	if err != nil {
+		return err
+	}
+	keysMu.Lock()
+	keys = ring
+	keysMu.Unlock()
+	return nil
+}
+
+// Rotate encrypts URLs with a new key from now on. URLs encrypted with the previous keys still
+// decrypt for the grace window, unless revoke is set, e.g. because URLs leaked.
+// With a shared store, the new keyring is shared with the other servers.
+func Rotate(revoke bool) error {
+	if disableUrlEncryption {
+		return ErrEncryptionDisabled
+	}
+	shared := store.Shared() && store.KVS != nil
+	if shared {
+		if err := reloadSharedKeys(); err != nil && !errors.Is(err, store.ErrKeyNotFound) {
+			return err
+		}
+	}
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	ring := append([]urlKey{newKey(keys)}, keys...)
+	if revoke {
+		ring = ring[:1]
+	}
+	ring = pruneKeys(ring, time.Now())
+	if shared {
+		encoded, err := json.Marshal(ring)
+		if err != nil {
+			return err
+		}
+		if err := store.KVS.Set(sharedKeysName, string(encoded)); err != nil {
+			return err
+		}
+	}
+	keys = ring
+	return nil
+}
+
+// RotateIfDue rotates the key when it is older than url_key_rotation. With a shared store, it first
+// picks up the keys rotated by other servers.
+func RotateIfDue() error {
+	if disableUrlEncryption {
+		return nil
+	}
+	if store.Shared() && store.KVS != nil {
+		if err := reloadSharedKeys(); err != nil && !errors.Is(err, store.ErrKeyNotFound) {
+			return err
+		}
+	}
+	interval := time.Duration(config.Cfg.URLKeyRotation) * time.Hour
+	keysMu.Lock()
+	due := interval > 0 && len(keys) > 0 && time.Since(keys[0].Created) >= interval
+	if !due {
+		keys = pruneKeys(keys, time.Now())
+	}
+	keysMu.Unlock()
+	if due {
+		return Rotate(false)
+	}
+	return nil
+}
+
+// Keys describes the keys of the keyring, newest first.
+func Keys() []KeyInfo {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	infos := make([]KeyInfo, 0, len(keys))
+	for i, k := range keys {
+		info := KeyInfo{ID: fmt.Sprintf("%08x", k.ID), Created: k.Created}
+		if i > 0 {
+			expires := keys[i-1].Created.Add(graceWindow())
+			info.Expires = &expires
+		}
+		infos = append(infos, info)
+	}
+	return infos
 }
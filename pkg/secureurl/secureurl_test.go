@@ -1,7 +1,15 @@
 package secureurl
 
 import (
+	"crypto/aes"
+	"encoding/base64"
+	"errors"
 	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
 )
 
 func TestGenerateKey(t *testing.T) {
@@ -140,3 +148,297 @@ func TestInit(t *testing.T) {
 		})
 	}
 }
+
+func TestInitSharedKey(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+
+	server := miniredis.RunT(t)
+	originalBackend, originalURL := config.Cfg.StoreBackend, config.Cfg.RedisURL
+	defer func() { config.Cfg.StoreBackend, config.Cfg.RedisURL = originalBackend, originalURL }()
+	config.Cfg.StoreBackend = store.BackendRedis
+	config.Cfg.RedisURL = "redis://" + server.Addr()
+	if err := store.Init(); err != nil {
+		t.Fatalf("store.Init() error = %v", err)
+	}
+
+	// A URL encrypted by another server sharing the store
+	Init()
+	encrypted, err := EncryptURL("https://example.com/live.m3u8")
+	if err != nil {
+		t.Fatalf("EncryptURL() error = %v", err)
+	}
+	keys = []urlKey{newKey(nil)}
+
+	Init()
+	if got, err := DecryptURL(encrypted); err != nil || got != "https://example.com/live.m3u8" {
+		t.Errorf("DecryptURL() = %q, %v, want the URL encrypted with the shared key", got, err)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	original := config.Cfg
+	defer func() { config.Cfg = original }()
+	config.Cfg.DisableURLEncryption = false
+	config.Cfg.URLKeyGrace = 1
+	Init()
+
+	before, err := EncryptURL("https://example.com/before.m3u8")
+	if err != nil {
+		t.Fatalf("EncryptURL() error = %v", err)
+	}
+	if err := Rotate(false); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	after, err := EncryptURL("https://example.com/after.m3u8")
+	if err != nil {
+		t.Fatalf("EncryptURL() error = %v", err)
+	}
+	if got, err := DecryptURL(before); err != nil || got != "https://example.com/before.m3u8" {
+		t.Errorf("DecryptURL() of a URL of the previous key = %q, %v, want it to work in the grace window", got, err)
+	}
+	if infos := Keys(); len(infos) != 2 || infos[0].Expires != nil || infos[1].Expires == nil {
+		t.Errorf("Keys() = %+v, want the current key and the previous key with its expiry", infos)
+	}
+
+	// The previous key expires after the grace window
+	keysMu.Lock()
+	keys[0].Created = keys[0].Created.Add(-2 * time.Hour)
+	keysMu.Unlock()
+	if _, err := DecryptURL(before); !errors.Is(err, ErrKeyExpired) {
+		t.Errorf("DecryptURL() of a URL of an expired key error = %v, want ErrKeyExpired", err)
+	}
+	if got, err := DecryptURL(after); err != nil || got != "https://example.com/after.m3u8" {
+		t.Errorf("DecryptURL() of a URL of the current key = %q, %v", got, err)
+	}
+	if err := RotateIfDue(); err != nil {
+		t.Fatalf("RotateIfDue() error = %v", err)
+	}
+	if infos := Keys(); len(infos) != 1 {
+		t.Errorf("Keys() after RotateIfDue() = %+v, want the expired key dropped", infos)
+	}
+
+	// Revoking drops the previous keys right away
+	if err := Rotate(true); err != nil {
+		t.Fatalf("Rotate(true) error = %v", err)
+	}
+	if _, err := DecryptURL(after); !errors.Is(err, ErrKeyExpired) {
+		t.Errorf("DecryptURL() of a URL of a revoked key error = %v, want ErrKeyExpired", err)
+	}
+}
+
+func TestRotateIfDue(t *testing.T) {
+	original := config.Cfg
+	defer func() { config.Cfg = original }()
+	config.Cfg.DisableURLEncryption = false
+	Init()
+
+	tests := []struct {
+		name     string
+		rotation int
+		age      time.Duration
+		want     bool
+	}{
+		{"rotation disabled", 0, 48 * time.Hour, false},
+		{"key is young", 6, time.Hour, false},
+		{"key is old", 6, 7 * time.Hour, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Cfg.URLKeyRotation = tt.rotation
+			keysMu.Lock()
+			keys[0].Created = time.Now().Add(-tt.age)
+			id := keys[0].ID
+			keysMu.Unlock()
+			if err := RotateIfDue(); err != nil {
+				t.Fatalf("RotateIfDue() error = %v", err)
+			}
+			keysMu.RLock()
+			rotated := keys[0].ID != id
+			keysMu.RUnlock()
+			if rotated != tt.want {
+				t.Errorf("rotated = %v, want %v", rotated, tt.want)
+			}
+		})
+	}
+}
+
+func TestRotateShared(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+
+	server := miniredis.RunT(t)
+	original := config.Cfg
+	defer func() { config.Cfg = original }()
+	config.Cfg.DisableURLEncryption = false
+	config.Cfg.StoreBackend = store.BackendRedis
+	config.Cfg.RedisURL = "redis://" + server.Addr()
+	if err := store.Init(); err != nil {
+		t.Fatalf("store.Init() error = %v", err)
+	}
+	Init()
+
+	// Another server sharing the store rotates the key
+	if err := Rotate(false); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	encrypted, err := EncryptURL("https://example.com/live.m3u8")
+	if err != nil {
+		t.Fatalf("EncryptURL() error = %v", err)
+	}
+	keysMu.Lock()
+	keys = keys[1:]
+	keysMu.Unlock()
+
+	if got, err := DecryptURL(encrypted); err != nil || got != "https://example.com/live.m3u8" {
+		t.Errorf("DecryptURL() = %q, %v, want the URL encrypted with the rotated shared key", got, err)
+	}
+}
+
+func TestURLTTL(t *testing.T) {
+	original := config.Cfg
+	defer func() { config.Cfg = original }()
+	config.Cfg.DisableURLEncryption = false
+	Init()
+
+	tests := []struct {
+		name    string
+		expires time.Time
+		wantErr error
+	}{
+		{"no expiry", time.Time{}, nil},
+		{"fresh", time.Now().Add(time.Hour), nil},
+		{"expired", time.Now().Add(-time.Minute), ErrURLExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encrypted, err := encryptURL("https://example.com/live.m3u8", tt.expires)
+			if err != nil {
+				t.Fatalf("encryptURL() error = %v", err)
+			}
+			got, err := DecryptURL(encrypted)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DecryptURL() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got != "https://example.com/live.m3u8" {
+				t.Errorf("DecryptURL() = %q", got)
+			}
+		})
+	}
+
+	config.Cfg.URLTTL = 2
+	encrypted, err := EncryptURL("https://example.com/live.m3u8")
+	if err != nil {
+		t.Fatalf("EncryptURL() error = %v", err)
+	}
+	if _, err := DecryptURL(encrypted); err != nil {
+		t.Errorf("DecryptURL() of a URL within url_ttl error = %v", err)
+	}
+}
+
+func TestDecryptURLSignature(t *testing.T) {
+	original := config.Cfg
+	defer func() { config.Cfg = original }()
+	config.Cfg.DisableURLEncryption = false
+	Init()
+
+	encrypted, err := EncryptURL("https://example.com/live.m3u8")
+	if err != nil {
+		t.Fatalf("EncryptURL() error = %v", err)
+	}
+	data, _ := base64.URLEncoding.DecodeString(encrypted)
+	// Flipping a bit of the ciphertext would change the URL without the signature
+	data[keyIDSize+aes.BlockSize+expirySize] ^= 1
+	if _, err := DecryptURL(base64.URLEncoding.EncodeToString(data)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("DecryptURL() of a modified URL error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestDecryptReloadURL(t *testing.T) {
+	original := config.Cfg
+	defer func() { config.Cfg = original }()
+	config.Cfg.DisableURLEncryption = false
+	Init()
+
+	fresh, err := encryptURL("https://example.com/index.m3u8", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("encryptURL() error = %v", err)
+	}
+	if got, err := DecryptReloadURL(fresh); err != nil || got != "https://example.com/index.m3u8" {
+		t.Errorf("DecryptReloadURL() of a fresh URL = %q, %v", got, err)
+	}
+
+	expired, err := encryptURL("https://example.com/index.m3u8", time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("encryptURL() error = %v", err)
+	}
+	if _, err := DecryptReloadURL(expired); !errors.Is(err, ErrURLExpired) {
+		t.Errorf("DecryptReloadURL() of a URL that expired before it was requested error = %v, want ErrURLExpired", err)
+	}
+
+	// A player that kept reloading the URL while it expired keeps playing
+	reloadsMu.Lock()
+	reloads[expired] = time.Now().Add(-reloadWindow / 2)
+	reloadsMu.Unlock()
+	if _, err := DecryptReloadURL(expired); err != nil {
+		t.Errorf("DecryptReloadURL() of a URL that is being reloaded error = %v", err)
+	}
+	if _, err := DecryptURL(expired); !errors.Is(err, ErrURLExpired) {
+		t.Errorf("DecryptURL() of an expired URL error = %v, want ErrURLExpired", err)
+	}
+
+	// A player that stopped reloading it does not
+	reloadsMu.Lock()
+	reloads[expired] = time.Now().Add(-2 * reloadWindow)
+	reloadsMu.Unlock()
+	if _, err := DecryptReloadURL(expired); !errors.Is(err, ErrURLExpired) {
+		t.Errorf("DecryptReloadURL() of a URL that is no longer reloaded error = %v, want ErrURLExpired", err)
+	}
+}
+
+func TestVerifyLink(t *testing.T) {
+	original := config.Cfg
+	defer func() { config.Cfg = original }()
+	config.Cfg.DisableURLEncryption = false
+	Init()
+
+	if LinksExpire() {
+		t.Error("LinksExpire() = true without url_ttl")
+	}
+	config.Cfg.URLTTL = 2
+	if !LinksExpire() {
+		t.Error("LinksExpire() = false with url_ttl")
+	}
+
+	signature, err := SignLink("/live/143.m3u8")
+	if err != nil {
+		t.Fatalf("SignLink() error = %v", err)
+	}
+	expired, err := encryptURL("/live/143.m3u8", time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("encryptURL() error = %v", err)
+	}
+	tests := []struct {
+		name      string
+		path      string
+		signature string
+		wantErr   error
+	}{
+		{"valid", "/live/143.m3u8", signature, nil},
+		{"other path", "/live/144.m3u8", signature, ErrInvalidSignature},
+		{"expired", "/live/143.m3u8", expired, ErrURLExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyLink(tt.path, tt.signature); !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyLink(%q) error = %v, want %v", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
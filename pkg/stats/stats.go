@@ -0,0 +1,473 @@
+// Package stats keeps hourly, in-memory viewing and request statistics.
+package stats
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultRetention is how long hourly buckets are kept, unless set with SetRetention
+	defaultRetention = 7 * 24 * time.Hour
+	// activeWindow is how long a stream counts as active after its last request, unless set with SetIdleTimeout
+	activeWindow = time.Minute
+)
+
+// Point is a value at the start of an hour
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// ChannelCount is the number of plays of a channel
+type ChannelCount struct {
+	ChannelID string
+	Plays     int
+	Viewers   int
+}
+
+// ActiveStream is a channel a client is watching
+type ActiveStream struct {
+	ChannelID string
+	Client    string
+	Started   time.Time
+	LastSeen  time.Time
+}
+
+// Client is the address and user agent a viewer played from
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+// bucket holds the statistics of a single hour
+type bucket struct {
+	plays    int
+	viewers  map[string]struct{}
+	channels map[string]*channelBucket
+	// clients holds the plays of the viewers that were recorded with their client
+	clients  map[string]*clientBucket
+	requests int
+	errors   int
+	bytes    int64
+}
+
+// channelBucket holds the statistics of a channel within an hour
+type channelBucket struct {
+	plays      int
+	viewers    map[string]struct{}
+	lastPlayed time.Time
+}
+
+// clientBucket holds the plays of a viewer within an hour
+type clientBucket struct {
+	client    Client
+	plays     int
+	channels  map[string]int
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+// Recorder collects statistics in hourly buckets
+type Recorder struct {
+	mu      sync.Mutex
+	buckets map[int64]*bucket
+	// active holds the streams by channel and client
+	active map[string]*ActiveStream
+	// idle is how long a stream stays active without requests
+	idle time.Duration
+	// retention is how long hourly buckets are kept
+	retention time.Duration
+	// since is when the plays started to be recorded
+	since time.Time
+	// revision changes whenever plays are recorded or reset
+	revision uint64
+	now      func() time.Time
+}
+
+// Default is the recorder used by the server
+var Default = NewRecorder()
+
+// NewRecorder returns an empty recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{
+		buckets:   make(map[int64]*bucket),
+		active:    make(map[string]*ActiveStream),
+		idle:      activeWindow,
+		retention: defaultRetention,
+		since:     time.Now(),
+		now:       time.Now,
+	}
+}
+
+// hourKey returns the unix time of the start of the hour.
+func hourKey(t time.Time) int64 {
+	return t.Truncate(time.Hour).Unix()
+}
+
+// current returns the bucket of the current hour and drops expired buckets.
+// The caller must hold the lock.
+func (r *Recorder) current() *bucket {
+	now := r.now()
+	key := hourKey(now)
+	b, ok := r.buckets[key]
+	if !ok {
+		b = newBucket()
+		r.buckets[key] = b
+		// Buckets are only created once an hour, a good time to drop old ones
+		r.expireBuckets(now)
+	}
+	return b
+}
+
+// newBucket returns an empty bucket.
+func newBucket() *bucket {
+	return &bucket{
+		viewers:  make(map[string]struct{}),
+		channels: make(map[string]*channelBucket),
+		clients:  make(map[string]*clientBucket),
+	}
+}
+
+// expireBuckets drops the buckets past the retention. The caller must hold the lock.
+func (r *Recorder) expireBuckets(now time.Time) {
+	cutoff := hourKey(now.Add(-r.retention))
+	for k := range r.buckets {
+		if k < cutoff {
+			delete(r.buckets, k)
+		}
+	}
+}
+
+// SetRetention sets how long hourly buckets are kept. A retention of 0 restores the default of a week.
+func (r *Recorder) SetRetention(retention time.Duration) {
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retention = retention
+	r.expireBuckets(r.now())
+}
+
+// RecordPlay records that a viewer started playing a channel.
+func (r *Recorder) RecordPlay(channelID, viewer string) {
+	r.RecordClientPlay(channelID, viewer, Client{})
+}
+
+// RecordClientPlay records a play like RecordPlay, along with the client the viewer played from. The
+// client is kept for the usage of each viewer, unless it is empty.
+func (r *Recorder) RecordClientPlay(channelID, viewer string, client Client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	b := r.current()
+	b.plays++
+	b.viewers[viewer] = struct{}{}
+	cb, ok := b.channels[channelID]
+	if !ok {
+		cb = &channelBucket{viewers: make(map[string]struct{})}
+		b.channels[channelID] = cb
+	}
+	cb.plays++
+	cb.viewers[viewer] = struct{}{}
+	cb.lastPlayed = now
+	r.revision++
+
+	if client == (Client{}) {
+		return
+	}
+	clb, ok := b.clients[viewer]
+	if !ok {
+		clb = &clientBucket{channels: make(map[string]int), firstSeen: now}
+		b.clients[viewer] = clb
+	}
+	clb.client = client
+	clb.plays++
+	clb.channels[channelID]++
+	clb.lastSeen = now
+}
+
+// RecordRequest records a handled request and whether it failed.
+func (r *Recorder) RecordRequest(failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b := r.current()
+	b.requests++
+	if failed {
+		b.errors++
+	}
+}
+
+// RecordBytes records bytes of streams proxied to clients.
+func (r *Recorder) RecordBytes(n int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.current().bytes += n
+}
+
+// series returns one point per hour between from and to, using value for each bucket.
+// Hours without data are reported as zero so that graphs show gaps as drops. The range is clamped
+// to the retention and now, as there are no buckets outside of it, which also bounds the number of
+// points of a range sent by a client.
+func (r *Recorder) series(from, to time.Time, value func(b *bucket) float64) []Point {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if oldest := now.Add(-r.retention); from.Before(oldest) {
+		from = oldest
+	}
+	if to.After(now) {
+		to = now
+	}
+
+	var points []Point
+	for t := from.Truncate(time.Hour); !t.After(to); t = t.Add(time.Hour) {
+		v := 0.0
+		if b, ok := r.buckets[t.Unix()]; ok {
+			v = value(b)
+		}
+		points = append(points, Point{Time: t, Value: v})
+	}
+	return points
+}
+
+// ViewersPerHour returns the number of distinct viewers in each hour.
+func (r *Recorder) ViewersPerHour(from, to time.Time) []Point {
+	return r.series(from, to, func(b *bucket) float64 {
+		return float64(len(b.viewers))
+	})
+}
+
+// PlaysPerHour returns the number of started plays in each hour.
+func (r *Recorder) PlaysPerHour(from, to time.Time) []Point {
+	return r.series(from, to, func(b *bucket) float64 {
+		return float64(b.plays)
+	})
+}
+
+// BytesPerHour returns the bytes of streams proxied to clients in each hour.
+func (r *Recorder) BytesPerHour(from, to time.Time) []Point {
+	return r.series(from, to, func(b *bucket) float64 {
+		return float64(b.bytes)
+	})
+}
+
+// ErrorRatePerHour returns the share of failed requests in each hour, from 0 to 1.
+func (r *Recorder) ErrorRatePerHour(from, to time.Time) []Point {
+	return r.series(from, to, func(b *bucket) float64 {
+		if b.requests == 0 {
+			return 0
+		}
+		return float64(b.errors) / float64(b.requests)
+	})
+}
+
+// TopChannels returns the most played channels between from and to.
+// Viewers are counted per hour, so a viewer watching for two hours counts twice.
+func (r *Recorder) TopChannels(from, to time.Time, limit int) []ChannelCount {
+	r.mu.Lock()
+	totals := make(map[string]*ChannelCount)
+	fromKey, toKey := hourKey(from), hourKey(to)
+	for key, b := range r.buckets {
+		if key < fromKey || key > toKey {
+			continue
+		}
+		for id, cb := range b.channels {
+			total, ok := totals[id]
+			if !ok {
+				total = &ChannelCount{ChannelID: id}
+				totals[id] = total
+			}
+			total.Plays += cb.plays
+			total.Viewers += len(cb.viewers)
+		}
+	}
+	r.mu.Unlock()
+
+	result := make([]ChannelCount, 0, len(totals))
+	for _, total := range totals {
+		result = append(result, *total)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Plays != result[j].Plays {
+			return result[i].Plays > result[j].Plays
+		}
+		return result[i].ChannelID < result[j].ChannelID
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// SetIdleTimeout sets how long a stream stays active without requests. A timeout of 0 restores the default of a minute.
+func (r *Recorder) SetIdleTimeout(idle time.Duration) {
+	if idle <= 0 {
+		idle = activeWindow
+	}
+	r.mu.Lock()
+	r.idle = idle
+	r.mu.Unlock()
+}
+
+// IdleTimeout returns how long a stream stays active without requests.
+func (r *Recorder) IdleTimeout() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.idle
+}
+
+// RecordActivity records that a client requested the playlist or a segment of a channel. Players reload
+// the playlist every few seconds, so the stream stays active until it has not been requested for the
+// idle timeout.
+func (r *Recorder) RecordActivity(channelID, client string) {
+	r.AdmitStream(channelID, client, 0)
+}
+
+// AdmitStream records the activity like RecordActivity, unless the stream is new and limit streams
+// are already active, in which case it reports false. A limit of 0 admits every stream.
+//
+// A client that switches channels would be refused until its previous stream expires, so a new
+// stream of a client takes the place of the client's oldest stream. If that stream is still
+// playing, it is refused on its next request instead.
+func (r *Recorder) AdmitStream(channelID, client string, limit int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	key := channelID + "|" + client
+	if stream, ok := r.active[key]; ok && now.Sub(stream.LastSeen) < r.idle {
+		stream.LastSeen = now
+		return true
+	}
+	if limit > 0 {
+		active, replaced := r.expireActive(now), r.oldestOf(client)
+		if active >= limit && replaced == "" {
+			return false
+		}
+		if active >= limit {
+			delete(r.active, replaced)
+		}
+	}
+	r.active[key] = &ActiveStream{ChannelID: channelID, Client: client, Started: now, LastSeen: now}
+	return true
+}
+
+// CanStart reports whether AdmitStream would admit the stream, without recording it.
+func (r *Recorder) CanStart(channelID, client string, limit int) bool {
+	if limit <= 0 {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if stream, ok := r.active[channelID+"|"+client]; ok && now.Sub(stream.LastSeen) < r.idle {
+		return true
+	}
+	return r.expireActive(now) < limit || r.oldestOf(client) != ""
+}
+
+// expireActive drops the streams that were not requested within the active window and returns the
+// number of remaining streams. The caller must hold the lock.
+func (r *Recorder) expireActive(now time.Time) int {
+	for key, stream := range r.active {
+		if now.Sub(stream.LastSeen) >= r.idle {
+			delete(r.active, key)
+		}
+	}
+	return len(r.active)
+}
+
+// oldestOf returns the key of the longest running active stream of a client, or an empty string if
+// the client has none. The caller must hold the lock and expire the streams first.
+func (r *Recorder) oldestOf(client string) string {
+	oldestKey := ""
+	var oldest time.Time
+	for key, stream := range r.active {
+		if stream.Client == client && (oldestKey == "" || stream.Started.Before(oldest)) {
+			oldestKey, oldest = key, stream.Started
+		}
+	}
+	return oldestKey
+}
+
+// ActiveStreams returns the streams requested within the idle timeout, the longest running first.
+func (r *Recorder) ActiveStreams() []ActiveStream {
+	r.mu.Lock()
+	now := r.now()
+	result := make([]ActiveStream, 0, len(r.active))
+	for key, stream := range r.active {
+		if now.Sub(stream.LastSeen) >= r.idle {
+			delete(r.active, key)
+			continue
+		}
+		result = append(result, *stream)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Started.Equal(result[j].Started) {
+			return result[i].Started.Before(result[j].Started)
+		}
+		return result[i].ChannelID+result[i].Client < result[j].ChannelID+result[j].Client
+	})
+	return result
+}
+
+// ActiveChannel returns the channel whose playlist the client requested last within the idle timeout,
+// or an empty string if there is none.
+func (r *Recorder) ActiveChannel(client string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	channel := ""
+	var lastSeen time.Time
+	for _, stream := range r.active {
+		if stream.Client == client && now.Sub(stream.LastSeen) < r.idle && stream.LastSeen.After(lastSeen) {
+			channel, lastSeen = stream.ChannelID, stream.LastSeen
+		}
+	}
+	return channel
+}
+
+// RecordPlay records a play on the default recorder.
+func RecordPlay(channelID, viewer string) {
+	Default.RecordPlay(channelID, viewer)
+}
+
+// RecordClientPlay records a play with the client of the viewer on the default recorder.
+func RecordClientPlay(channelID, viewer string, client Client) {
+	Default.RecordClientPlay(channelID, viewer, client)
+}
+
+// RecordBytes records proxied bytes on the default recorder.
+func RecordBytes(n int64) {
+	Default.RecordBytes(n)
+}
+
+// RecordRequest records a request on the default recorder.
+func RecordRequest(failed bool) {
+	Default.RecordRequest(failed)
+}
+
+// RecordActivity records a playlist request on the default recorder.
+func RecordActivity(channelID, client string) {
+	Default.RecordActivity(channelID, client)
+}
+
+// AdmitStream records the activity of a stream on the default recorder if fewer than limit streams
+// are active.
+func AdmitStream(channelID, client string, limit int) bool {
+	return Default.AdmitStream(channelID, client, limit)
+}
+
+// ActiveStreams returns the active streams of the default recorder.
+func ActiveStreams() []ActiveStream {
+	return Default.ActiveStreams()
+}
@@ -0,0 +1,211 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestRecorder(now *time.Time) *Recorder {
+	r := NewRecorder()
+	r.now = func() time.Time { return *now }
+	return r
+}
+
+func TestRecorderHourlySeries(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 15, 0, 0, time.UTC)
+	r := newTestRecorder(&now)
+
+	r.RecordPlay("143", "10.0.0.1")
+	r.RecordPlay("143", "10.0.0.1")
+	r.RecordPlay("144", "10.0.0.2")
+	r.RecordRequest(false)
+	r.RecordRequest(true)
+	r.RecordBytes(1000)
+
+	now = now.Add(2 * time.Hour)
+	r.RecordPlay("143", "10.0.0.3")
+	r.RecordRequest(false)
+	r.RecordBytes(500)
+
+	from := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		points []Point
+		want   []float64
+	}{
+		{name: "viewers", points: r.ViewersPerHour(from, to), want: []float64{2, 0, 1}},
+		{name: "plays", points: r.PlaysPerHour(from, to), want: []float64{3, 0, 1}},
+		{name: "error rate", points: r.ErrorRatePerHour(from, to), want: []float64{0.5, 0, 0}},
+		{name: "bytes", points: r.BytesPerHour(from, to), want: []float64{1000, 0, 500}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.points) != len(tt.want) {
+				t.Fatalf("got %d points, want %d", len(tt.points), len(tt.want))
+			}
+			for i, p := range tt.points {
+				if p.Value != tt.want[i] {
+					t.Errorf("point %d = %v, want %v", i, p.Value, tt.want[i])
+				}
+				if wantTime := from.Add(time.Duration(i) * time.Hour); !p.Time.Equal(wantTime) {
+					t.Errorf("point %d time = %v, want %v", i, p.Time, wantTime)
+				}
+			}
+		})
+	}
+}
+
+func TestRecorderSeriesClampsRange(t *testing.T) {
+	now := time.Date(2024, 1, 8, 10, 15, 0, 0, time.UTC)
+	r := newTestRecorder(&now)
+
+	points := r.PlaysPerHour(time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC))
+	if want := int(defaultRetention/time.Hour) + 1; len(points) != want {
+		t.Fatalf("got %d points, want %d within the retention", len(points), want)
+	}
+	if last := points[len(points)-1].Time; !last.Equal(now.Truncate(time.Hour)) {
+		t.Errorf("last point = %v, want the current hour", last)
+	}
+}
+
+func TestRecorderTopChannels(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	r := newTestRecorder(&now)
+
+	r.RecordPlay("144", "a")
+	r.RecordPlay("143", "a")
+	r.RecordPlay("143", "b")
+	r.RecordPlay("145", "a")
+	now = now.Add(time.Hour)
+	r.RecordPlay("145", "a")
+	r.RecordPlay("145", "b")
+
+	got := r.TopChannels(now.Add(-time.Hour), now, 2)
+	want := []ChannelCount{
+		{ChannelID: "145", Plays: 3, Viewers: 3},
+		{ChannelID: "143", Plays: 2, Viewers: 2},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("TopChannels() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("TopChannels()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	if got := r.TopChannels(now, now, 0); len(got) != 1 {
+		t.Errorf("TopChannels() of last hour returned %d channels, want 1", len(got))
+	}
+}
+
+func TestRecorderDropsExpiredBuckets(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	r := newTestRecorder(&now)
+
+	r.RecordPlay("143", "a")
+	now = now.Add(defaultRetention + 2*time.Hour)
+	r.RecordPlay("143", "a")
+
+	if len(r.buckets) != 1 {
+		t.Errorf("got %d buckets, want 1", len(r.buckets))
+	}
+}
+
+func TestRecorderActiveStreams(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	r := newTestRecorder(&now)
+
+	r.RecordActivity("143", "10.0.0.1")
+	now = now.Add(10 * time.Second)
+	r.RecordActivity("144", "10.0.0.2")
+	now = now.Add(40 * time.Second)
+	r.RecordActivity("143", "10.0.0.1")
+
+	if got := r.ActiveChannel("10.0.0.2"); got != "144" {
+		t.Errorf("ActiveChannel() = %q, want 144", got)
+	}
+
+	now = now.Add(30 * time.Second)
+	if got := r.ActiveChannel("10.0.0.2"); got != "" {
+		t.Errorf("ActiveChannel() after timeout = %q, want none", got)
+	}
+	active := r.ActiveStreams()
+	if len(active) != 1 || active[0].ChannelID != "143" || active[0].Client != "10.0.0.1" {
+		t.Fatalf("ActiveStreams() = %+v, want only 143 of 10.0.0.1", active)
+	}
+	if want := now.Add(-80 * time.Second); !active[0].Started.Equal(want) {
+		t.Errorf("Started = %v, want %v", active[0].Started, want)
+	}
+
+	// A stream requested again after it timed out starts over
+	now = now.Add(2 * time.Minute)
+	r.RecordActivity("143", "10.0.0.1")
+	active = r.ActiveStreams()
+	if len(active) != 1 || !active[0].Started.Equal(now) {
+		t.Errorf("ActiveStreams() after timeout = %+v, want a new stream", active)
+	}
+}
+
+func TestRecorderAdmitStream(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	r := newTestRecorder(&now)
+
+	steps := []struct {
+		name    string
+		channel string
+		client  string
+		want    bool
+	}{
+		{"first stream", "143", "10.0.0.1", true},
+		{"second stream", "144", "10.0.0.2", true},
+		{"playing stream", "143", "10.0.0.1", true},
+		{"third client", "145", "10.0.0.3", false},
+		{"channel switch", "146", "10.0.0.1", true},
+		{"replaced stream", "143", "10.0.0.1", true},
+	}
+	for _, step := range steps {
+		if got := r.CanStart(step.channel, step.client, 2); got != step.want {
+			t.Errorf("%s: CanStart() = %v, want %v", step.name, got, step.want)
+		}
+		if got := r.AdmitStream(step.channel, step.client, 2); got != step.want {
+			t.Errorf("%s: AdmitStream() = %v, want %v", step.name, got, step.want)
+		}
+	}
+	if active := r.ActiveStreams(); len(active) != 2 {
+		t.Errorf("ActiveStreams() = %+v, want 2 streams", active)
+	}
+
+	// Streams that stopped make room for new ones
+	now = now.Add(activeWindow)
+	if !r.AdmitStream("145", "10.0.0.3", 2) {
+		t.Error("AdmitStream() after the streams expired = false, want true")
+	}
+	if !r.AdmitStream("147", "10.0.0.4", 0) {
+		t.Error("AdmitStream() without a limit = false, want true")
+	}
+}
+
+func TestRecorderSetIdleTimeout(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	r := newTestRecorder(&now)
+	r.SetIdleTimeout(5 * time.Minute)
+
+	r.RecordActivity("143", "10.0.0.1")
+	now = now.Add(3 * time.Minute)
+	if active := r.ActiveStreams(); len(active) != 1 {
+		t.Errorf("ActiveStreams() within the idle timeout = %+v, want the stream", active)
+	}
+	now = now.Add(3 * time.Minute)
+	if active := r.ActiveStreams(); len(active) != 0 {
+		t.Errorf("ActiveStreams() after the idle timeout = %+v, want none", active)
+	}
+
+	r.SetIdleTimeout(0)
+	if got := r.IdleTimeout(); got != activeWindow {
+		t.Errorf("IdleTimeout() after SetIdleTimeout(0) = %v, want %v", got, activeWindow)
+	}
+}
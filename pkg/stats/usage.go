@@ -0,0 +1,304 @@
+package stats
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// dayFormat is the layout of the days of the plays per day
+const dayFormat = "2006-01-02"
+
+// ChannelUsage is how often a channel was played
+type ChannelUsage struct {
+	ChannelID  string    `json:"channel_id"`
+	Plays      int64     `json:"plays"`
+	Clients    int       `json:"clients"`
+	LastPlayed time.Time `json:"last_played"`
+}
+
+// ClientUsage is what a viewer played, with the address and user agent it last played from
+type ClientUsage struct {
+	Viewer    string           `json:"viewer"`
+	IP        string           `json:"ip"`
+	UserAgent string           `json:"user_agent"`
+	Plays     int64            `json:"plays"`
+	FirstSeen time.Time        `json:"first_seen"`
+	LastSeen  time.Time        `json:"last_seen"`
+	Channels  map[string]int64 `json:"channels"`
+}
+
+// DayUsage is the number of plays on a day
+type DayUsage struct {
+	Day   string `json:"day"`
+	Plays int64  `json:"plays"`
+}
+
+// Usage is the plays within the retention, as shown on the stats dashboard
+type Usage struct {
+	Since time.Time `json:"since"`
+	Plays int64     `json:"plays"`
+	// Channels are sorted by plays, the most played first
+	Channels []ChannelUsage `json:"channels"`
+	// Clients are the viewers recorded with their client, sorted by the last play, the most recent first
+	Clients []ClientUsage `json:"clients"`
+	// Hours is the number of plays in each hour of the day, in the time zone of the server
+	Hours [24]int64 `json:"hours"`
+	// Days is the number of plays on each day of the retention, the oldest first
+	Days []DayUsage `json:"days"`
+}
+
+// Usage returns the plays of channels and clients within the retention.
+func (r *Recorder) Usage() Usage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	usage := Usage{Since: r.since, Channels: []ChannelUsage{}, Clients: []ClientUsage{}, Days: []DayUsage{}}
+	if oldest := now.Add(-r.retention); usage.Since.Before(oldest) {
+		usage.Since = oldest
+	}
+
+	channels := make(map[string]*ChannelUsage)
+	channelViewers := make(map[string]map[string]struct{})
+	clients := make(map[string]*ClientUsage)
+	// lastClient is the time the client of each viewer was recorded, as the latest one is reported
+	lastClient := make(map[string]time.Time)
+	days := make(map[string]int64)
+	for key, b := range r.buckets {
+		start := time.Unix(key, 0)
+		usage.Plays += int64(b.plays)
+		usage.Hours[start.Hour()] += int64(b.plays)
+		days[start.Format(dayFormat)] += int64(b.plays)
+
+		for id, cb := range b.channels {
+			channel, ok := channels[id]
+			if !ok {
+				channel = &ChannelUsage{ChannelID: id}
+				channels[id] = channel
+				channelViewers[id] = make(map[string]struct{})
+			}
+			channel.Plays += int64(cb.plays)
+			if cb.lastPlayed.After(channel.LastPlayed) {
+				channel.LastPlayed = cb.lastPlayed
+			}
+			for viewer := range cb.viewers {
+				channelViewers[id][viewer] = struct{}{}
+			}
+		}
+
+		for viewer, clb := range b.clients {
+			client, ok := clients[viewer]
+			if !ok {
+				client = &ClientUsage{Viewer: viewer, FirstSeen: clb.firstSeen, Channels: make(map[string]int64)}
+				clients[viewer] = client
+			}
+			client.Plays += int64(clb.plays)
+			for id, plays := range clb.channels {
+				client.Channels[id] += int64(plays)
+			}
+			if clb.firstSeen.Before(client.FirstSeen) {
+				client.FirstSeen = clb.firstSeen
+			}
+			if clb.lastSeen.After(lastClient[viewer]) {
+				lastClient[viewer] = clb.lastSeen
+				client.LastSeen = clb.lastSeen
+				client.IP, client.UserAgent = clb.client.IP, clb.client.UserAgent
+			}
+		}
+	}
+
+	for id, channel := range channels {
+		channel.Clients = len(channelViewers[id])
+		usage.Channels = append(usage.Channels, *channel)
+	}
+	sort.Slice(usage.Channels, func(i, j int) bool {
+		if usage.Channels[i].Plays != usage.Channels[j].Plays {
+			return usage.Channels[i].Plays > usage.Channels[j].Plays
+		}
+		return usage.Channels[i].ChannelID < usage.Channels[j].ChannelID
+	})
+
+	for _, client := range clients {
+		usage.Clients = append(usage.Clients, *client)
+	}
+	sort.Slice(usage.Clients, func(i, j int) bool {
+		if !usage.Clients[i].LastSeen.Equal(usage.Clients[j].LastSeen) {
+			return usage.Clients[i].LastSeen.After(usage.Clients[j].LastSeen)
+		}
+		if usage.Clients[i].Plays != usage.Clients[j].Plays {
+			return usage.Clients[i].Plays > usage.Clients[j].Plays
+		}
+		return usage.Clients[i].Viewer < usage.Clients[j].Viewer
+	})
+
+	for i := max(int(r.retention/(24*time.Hour)), 1) - 1; i >= 0; i-- {
+		day := now.AddDate(0, 0, -i).Format(dayFormat)
+		usage.Days = append(usage.Days, DayUsage{Day: day, Plays: days[day]})
+	}
+	return usage
+}
+
+// ResetPlays drops the recorded plays, viewers and clients. The counts of requests, errors and bytes
+// are kept.
+func (r *Recorder) ResetPlays() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, b := range r.buckets {
+		cleared := newBucket()
+		cleared.requests, cleared.errors, cleared.bytes = b.requests, b.errors, b.bytes
+		r.buckets[key] = cleared
+	}
+	r.since = r.now()
+	r.revision++
+}
+
+// Revision returns a number that changes whenever plays are recorded or reset, so that unchanged
+// plays need not be saved again.
+func (r *Recorder) Revision() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.revision
+}
+
+// storedChannel is the stored statistics of a channel within an hour
+type storedChannel struct {
+	Plays      int       `json:"plays"`
+	Viewers    []string  `json:"viewers"`
+	LastPlayed time.Time `json:"last_played"`
+}
+
+// storedClient is the stored plays of a viewer within an hour
+type storedClient struct {
+	IP        string         `json:"ip"`
+	UserAgent string         `json:"user_agent"`
+	Plays     int            `json:"plays"`
+	Channels  map[string]int `json:"channels"`
+	FirstSeen time.Time      `json:"first_seen"`
+	LastSeen  time.Time      `json:"last_seen"`
+}
+
+// storedBucket is the stored statistics of a single hour
+type storedBucket struct {
+	Hour     int64                    `json:"hour"`
+	Plays    int                      `json:"plays"`
+	Viewers  []string                 `json:"viewers"`
+	Channels map[string]storedChannel `json:"channels"`
+	Clients  map[string]storedClient  `json:"clients,omitempty"`
+	Requests int                      `json:"requests"`
+	Errors   int                      `json:"errors"`
+	Bytes    int64                    `json:"bytes"`
+}
+
+// storedRecorder is the stored statistics of a recorder. Active streams are not stored, as they
+// expire long before the server is back.
+type storedRecorder struct {
+	Since   time.Time      `json:"since"`
+	Buckets []storedBucket `json:"buckets"`
+}
+
+// viewerList returns the viewers of a set.
+func viewerList(viewers map[string]struct{}) []string {
+	list := make([]string, 0, len(viewers))
+	for viewer := range viewers {
+		list = append(list, viewer)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// viewerSet returns the set of a list of viewers.
+func viewerSet(viewers []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(viewers))
+	for _, viewer := range viewers {
+		set[viewer] = struct{}{}
+	}
+	return set
+}
+
+// MarshalJSON returns the hourly buckets of the recorder, to be restored with UnmarshalJSON.
+func (r *Recorder) MarshalJSON() ([]byte, error) {
+	r.mu.Lock()
+	stored := storedRecorder{Since: r.since, Buckets: make([]storedBucket, 0, len(r.buckets))}
+	for key, b := range r.buckets {
+		sb := storedBucket{
+			Hour:     key,
+			Plays:    b.plays,
+			Viewers:  viewerList(b.viewers),
+			Channels: make(map[string]storedChannel, len(b.channels)),
+			Clients:  make(map[string]storedClient, len(b.clients)),
+			Requests: b.requests,
+			Errors:   b.errors,
+			Bytes:    b.bytes,
+		}
+		for id, cb := range b.channels {
+			sb.Channels[id] = storedChannel{Plays: cb.plays, Viewers: viewerList(cb.viewers), LastPlayed: cb.lastPlayed}
+		}
+		for viewer, clb := range b.clients {
+			// The channels are copied, as they are encoded after the lock is released
+			channels := make(map[string]int, len(clb.channels))
+			for id, plays := range clb.channels {
+				channels[id] = plays
+			}
+			sb.Clients[viewer] = storedClient{
+				IP:        clb.client.IP,
+				UserAgent: clb.client.UserAgent,
+				Plays:     clb.plays,
+				Channels:  channels,
+				FirstSeen: clb.firstSeen,
+				LastSeen:  clb.lastSeen,
+			}
+		}
+		stored.Buckets = append(stored.Buckets, sb)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(stored.Buckets, func(i, j int) bool {
+		return stored.Buckets[i].Hour < stored.Buckets[j].Hour
+	})
+	return json.Marshal(stored)
+}
+
+// UnmarshalJSON replaces the hourly buckets of the recorder with ones returned by MarshalJSON.
+// Buckets past the retention are dropped.
+func (r *Recorder) UnmarshalJSON(data []byte) error {
+	var stored storedRecorder
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+
+	buckets := make(map[int64]*bucket, len(stored.Buckets))
+	for _, sb := range stored.Buckets {
+		b := newBucket()
+		b.plays, b.viewers = sb.Plays, viewerSet(sb.Viewers)
+		b.requests, b.errors, b.bytes = sb.Requests, sb.Errors, sb.Bytes
+		for id, sc := range sb.Channels {
+			b.channels[id] = &channelBucket{plays: sc.Plays, viewers: viewerSet(sc.Viewers), lastPlayed: sc.LastPlayed}
+		}
+		for viewer, sc := range sb.Clients {
+			channels := sc.Channels
+			// A file edited by hand may lack the channels
+			if channels == nil {
+				channels = make(map[string]int)
+			}
+			b.clients[viewer] = &clientBucket{
+				client:    Client{IP: sc.IP, UserAgent: sc.UserAgent},
+				plays:     sc.Plays,
+				channels:  channels,
+				firstSeen: sc.FirstSeen,
+				lastSeen:  sc.LastSeen,
+			}
+		}
+		buckets[sb.Hour] = b
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buckets = buckets
+	if !stored.Since.IsZero() {
+		r.since = stored.Since
+	}
+	r.expireBuckets(r.now())
+	r.revision++
+	return nil
+}
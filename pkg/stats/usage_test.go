@@ -0,0 +1,117 @@
+package stats
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestRecorderUsage(t *testing.T) {
+	start := time.Date(2026, 10, 16, 20, 30, 0, 0, time.Local)
+	now := start.Add(-36 * time.Hour)
+	r := newTestRecorder(&now)
+	r.since = now
+	vlc := Client{IP: "192.168.1.10", UserAgent: "VLC/3.0"}
+
+	r.RecordClientPlay("143", "tv", vlc)
+	now = start
+	r.RecordClientPlay("143", "phone", Client{IP: "192.168.1.11", UserAgent: "Kodi/21"})
+	r.RecordClientPlay("143", "tv", vlc)
+	r.RecordClientPlay("144", "tv", Client{IP: "192.168.1.12", UserAgent: "VLC/3.0"})
+	r.RecordPlay("144", "guest")
+
+	usage := r.Usage()
+	if usage.Plays != 5 {
+		t.Errorf("Plays = %d, want 5", usage.Plays)
+	}
+	if len(usage.Channels) != 2 || usage.Channels[0].ChannelID != "143" || usage.Channels[0].Plays != 3 || usage.Channels[0].Clients != 2 {
+		t.Errorf("Channels = %+v, want 143 first with 3 plays by 2 clients", usage.Channels)
+	}
+	if !usage.Channels[0].LastPlayed.Equal(start) {
+		t.Errorf("LastPlayed = %v, want %v", usage.Channels[0].LastPlayed, start)
+	}
+	// Viewers without a client are counted, but not listed
+	if len(usage.Clients) != 2 {
+		t.Fatalf("Clients = %+v, want tv and phone", usage.Clients)
+	}
+	if tv := usage.Clients[0]; tv.Viewer != "tv" || tv.Plays != 3 || tv.Channels["143"] != 2 || tv.IP != "192.168.1.12" || !tv.FirstSeen.Equal(start.Add(-36*time.Hour)) {
+		t.Errorf("Clients[0] = %+v, want tv with 3 plays from its latest address", tv)
+	}
+	if usage.Hours[20] != 4 || usage.Hours[8] != 1 {
+		t.Errorf("Hours = %v, want 4 plays at 20:00 and 1 at 08:00", usage.Hours)
+	}
+	if days := int(defaultRetention / (24 * time.Hour)); len(usage.Days) != days {
+		t.Fatalf("len(Days) = %d, want %d", len(usage.Days), days)
+	}
+	if today, yesterday := usage.Days[len(usage.Days)-1], usage.Days[len(usage.Days)-2]; today.Day != "2026-10-16" || today.Plays != 4 || yesterday.Plays != 1 {
+		t.Errorf("last days = %+v, %+v, want 4 plays today and 1 yesterday", yesterday, today)
+	}
+
+	r.RecordRequest(false)
+	r.ResetPlays()
+	if usage := r.Usage(); usage.Plays != 0 || len(usage.Clients) != 0 || len(usage.Channels) != 0 {
+		t.Errorf("Usage() after ResetPlays() = %+v, want no plays", usage)
+	}
+	if rate := r.ErrorRatePerHour(now, now); len(rate) != 1 || r.buckets[hourKey(now)].requests != 1 {
+		t.Error("ResetPlays() should keep the requests")
+	}
+}
+
+func TestRecorderRetention(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+	r := newTestRecorder(&now)
+	r.SetRetention(30 * 24 * time.Hour)
+
+	r.RecordPlay("143", "a")
+	now = now.Add(20 * 24 * time.Hour)
+	r.RecordPlay("143", "a")
+	if usage := r.Usage(); usage.Plays != 2 || len(usage.Days) != 30 {
+		t.Errorf("Usage() = %d plays over %d days, want 2 over 30", usage.Plays, len(usage.Days))
+	}
+
+	r.SetRetention(0)
+	if usage := r.Usage(); usage.Plays != 1 {
+		t.Errorf("Plays after restoring the default retention = %d, want 1", usage.Plays)
+	}
+}
+
+func TestRecorderJSON(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+	r := newTestRecorder(&now)
+	r.RecordClientPlay("143", "tv", Client{IP: "192.168.1.10", UserAgent: "VLC/3.0"})
+	r.RecordRequest(true)
+	r.RecordBytes(1000)
+
+	content, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	restored := newTestRecorder(&now)
+	revision := restored.Revision()
+	if err := json.Unmarshal(content, restored); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if restored.Revision() == revision {
+		t.Error("Unmarshal() should change the revision")
+	}
+
+	want, got := r.Usage(), restored.Usage()
+	if got.Plays != 1 || len(got.Clients) != 1 || got.Clients[0].UserAgent != "VLC/3.0" || got.Channels[0].Clients != 1 {
+		t.Errorf("Usage() after Unmarshal() = %+v, want %+v", got, want)
+	}
+	if points := restored.ErrorRatePerHour(now, now); points[0].Value != 1 {
+		t.Errorf("error rate after Unmarshal() = %v, want 1", points[0].Value)
+	}
+	if points := restored.BytesPerHour(now, now); points[0].Value != 1000 {
+		t.Errorf("bytes after Unmarshal() = %v, want 1000", points[0].Value)
+	}
+
+	// Buckets past the retention are dropped
+	now = now.Add(defaultRetention + 2*time.Hour)
+	if err := json.Unmarshal(content, restored); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(restored.buckets) != 0 {
+		t.Errorf("got %d buckets, want the expired ones dropped", len(restored.buckets))
+	}
+}
@@ -0,0 +1,175 @@
+package store
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+const (
+	// encryptedPrefix starts the values encrypted with the store encryption key
+	encryptedPrefix = "enc:v1:"
+	// encryptionCheckKey holds a known value encrypted with the key, to detect a wrong or missing key
+	encryptionCheckKey = "encryptionCheck"
+	// encryptionCheckValue is the plaintext of the encryption check
+	encryptionCheckValue = "jiotv_go"
+)
+
+var (
+	// ErrWrongEncryptionKey is returned when values of the store were encrypted with another key
+	ErrWrongEncryptionKey = errors.New("the store was encrypted with another store_encryption_key")
+	// ErrEncryptionKeyRequired is returned when the store is encrypted and no key is configured
+	ErrEncryptionKeyRequired = errors.New("the store is encrypted, set store_encryption_key to open it")
+)
+
+// EncryptedStore encrypts the values of another store with AES-256-GCM, so that the JioTV tokens are
+// not readable from the files or the database. Values that are still in plaintext, from before
+// the encryption was enabled, are encrypted when they are read.
+type EncryptedStore struct {
+	Store
+	aead cipher.AEAD
+}
+
+// NewEncryptedStore returns s with its values encrypted with a key derived from secret.
+func NewEncryptedStore(s Store, secret string) (*EncryptedStore, error) {
+	if secret == "" {
+		return nil, errors.New("the encryption key is empty")
+	}
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedStore{Store: s, aead: aead}, nil
+}
+
+// encryptionKey returns the configured store encryption key. A key of the form "file:<path>" is
+// read from the file, e.g. a Docker secret, so that it is not kept in the config or environment.
+func encryptionKey() (string, error) {
+	key := config.Cfg.StoreEncryptionKey
+	if path, ok := strings.CutPrefix(key, "file:"); ok {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read store_encryption_key: %w", err)
+		}
+		key = strings.TrimSpace(string(content))
+		if key == "" {
+			return "", fmt.Errorf("store_encryption_key file %s is empty", path)
+		}
+	}
+	return key, nil
+}
+
+// withEncryption wraps s in an EncryptedStore when a key is configured. It fails when the values of
+// s were encrypted with another key, or when they are encrypted and no key is configured.
+func withEncryption(s Store) (Store, error) {
+	key, err := encryptionKey()
+	if err != nil {
+		return nil, err
+	}
+	check, checkErr := s.Get(encryptionCheckKey)
+	if checkErr != nil && !errors.Is(checkErr, ErrKeyNotFound) {
+		return nil, checkErr
+	}
+	if key == "" {
+		if checkErr == nil {
+			return nil, ErrEncryptionKeyRequired
+		}
+		return s, nil
+	}
+
+	encrypted, err := NewEncryptedStore(s, key)
+	if err != nil {
+		return nil, err
+	}
+	if checkErr == nil {
+		if value, err := encrypted.decrypt(check); err != nil || value != encryptionCheckValue {
+			return nil, ErrWrongEncryptionKey
+		}
+		return encrypted, nil
+	}
+	if err := encrypted.Set(encryptionCheckKey, encryptionCheckValue); err != nil {
+		return nil, err
+	}
+	return encrypted, nil
+}
+
+// encrypt returns the encrypted value with a random nonce.
+func (s *EncryptedStore) encrypt(value string) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := s.aead.Seal(nonce, nonce, []byte(value), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt returns the plaintext of an encrypted value.
+func (s *EncryptedStore) decrypt(value string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < s.aead.NonceSize() {
+		return "", errors.New("encrypted value is too short")
+	}
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrWrongEncryptionKey
+	}
+	return string(plaintext), nil
+}
+
+// Get retrieves and decrypts the value for the specified key from the store.
+func (s *EncryptedStore) Get(key string) (string, error) {
+	value, err := s.Store.Get(key)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		// Encrypt the values stored before the encryption was enabled
+		if err := s.Set(key, value); err != nil {
+			return "", err
+		}
+		return value, nil
+	}
+	return s.decrypt(value)
+}
+
+// Set encrypts and sets the value for the specified key in the store.
+func (s *EncryptedStore) Set(key, value string) error {
+	encrypted, err := s.encrypt(value)
+	if err != nil {
+		return err
+	}
+	return s.Store.Set(key, encrypted)
+}
+
+// setIfAbsent sets the key to value unless it is set, and returns the value of the key.
+func (s *EncryptedStore) setIfAbsent(key, value string) (string, error) {
+	encrypted, err := s.encrypt(value)
+	if err != nil {
+		return "", err
+	}
+	current, err := SetIfAbsent(s.Store, key, encrypted)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(current, encryptedPrefix) {
+		return current, nil
+	}
+	return s.decrypt(current)
+}
@@ -0,0 +1,136 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+// useEncryptionKey sets the store encryption key until the test ends.
+func useEncryptionKey(t *testing.T, key string) {
+	t.Helper()
+	original := config.Cfg.StoreEncryptionKey
+	config.Cfg.StoreEncryptionKey = key
+	t.Cleanup(func() { config.Cfg.StoreEncryptionKey = original })
+}
+
+func TestEncryptedStore(t *testing.T) {
+	for _, backend := range []string{BackendTOML, BackendSQLite, BackendRedis} {
+		t.Run(backend, func(t *testing.T) {
+			cleanup, err := SetupTestPathPrefix()
+			if err != nil {
+				t.Fatalf("Failed to setup test environment: %v", err)
+			}
+			defer cleanup()
+			switch backend {
+			case BackendSQLite:
+				useSQLiteBackend(t)
+			case BackendRedis:
+				useRedisBackend(t)
+			}
+			useEncryptionKey(t, "correct horse battery staple")
+			if err := Init(); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			encrypted, ok := KVS.(*EncryptedStore)
+			if !ok {
+				t.Fatalf("KVS = %T, want *EncryptedStore", KVS)
+			}
+
+			if err := Set("ssoToken", "secret-token"); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			if got, err := Get("ssoToken"); err != nil || got != "secret-token" {
+				t.Errorf("Get() = %q, %v, want secret-token", got, err)
+			}
+			raw, err := encrypted.Store.Get("ssoToken")
+			if err != nil || !strings.HasPrefix(raw, encryptedPrefix) || strings.Contains(raw, "secret-token") {
+				t.Errorf("stored value = %q, %v, want it encrypted", raw, err)
+			}
+
+			family, err := ForTenant("family")
+			if err != nil {
+				t.Fatalf("ForTenant() error = %v", err)
+			}
+			if _, ok := family.(*EncryptedStore); !ok {
+				t.Errorf("ForTenant() = %T, want *EncryptedStore", family)
+			}
+			if got, err := SetIfAbsent(KVS, "secureurlKey", "key"); err != nil || got != "key" {
+				t.Errorf("SetIfAbsent() = %q, %v, want key", got, err)
+			}
+
+			// Reopening with another key or without a key fails
+			useEncryptionKey(t, "wrong")
+			if err := Init(); !errors.Is(err, ErrWrongEncryptionKey) {
+				t.Errorf("Init() with another key error = %v, want ErrWrongEncryptionKey", err)
+			}
+			useEncryptionKey(t, "")
+			if err := Init(); !errors.Is(err, ErrEncryptionKeyRequired) {
+				t.Errorf("Init() without a key error = %v, want ErrEncryptionKeyRequired", err)
+			}
+		})
+	}
+}
+
+func TestEncryptedStoreEncryptsPlaintext(t *testing.T) {
+	cleanup, err := SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+	if err := Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if err := Set("refreshToken", "plain-token"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("from a file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	useEncryptionKey(t, "file:"+keyFile)
+	if err := Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if got, err := Get("refreshToken"); err != nil || got != "plain-token" {
+		t.Errorf("Get() = %q, %v, want plain-token", got, err)
+	}
+	content, err := os.ReadFile(filepath.Join(GetPathPrefix(), storeFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "plain-token") {
+		t.Errorf("the value read in plaintext was not encrypted: %s", content)
+	}
+
+	useEncryptionKey(t, "file:"+filepath.Join(t.TempDir(), "missing"))
+	if err := Init(); err == nil {
+		t.Error("Init() with a missing key file should fail")
+	}
+}
+
+func TestTomlStoreFileMode(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), storeFileName)
+	if err := os.WriteFile(filename, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Open(filename)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if err := s.Set("ssoToken", "token"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 && os.PathSeparator == '/' {
+		t.Errorf("store file mode = %o, want 600", mode)
+	}
+}
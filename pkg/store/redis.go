@@ -0,0 +1,164 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// redisKeyPrefix starts the keys of JioTV Go in Redis
+	redisKeyPrefix = "jiotv_go:"
+	// redisTimeout bounds each command, so that an unreachable server fails requests instead of hanging them
+	redisTimeout = 5 * time.Second
+)
+
+// RedisStore is a store in a Redis server. The key-value pairs are fields of a hash and each
+// document is a key of its own, all under a prefix, so that tenants and other applications can
+// share the server.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// OpenRedis connects to the Redis server at rawURL, e.g. "redis://:password@localhost:6379/0".
+// The key-value pairs of the TOML store under the path prefix are imported into a new store.
+func OpenRedis(rawURL string) (*RedisStore, error) {
+	if rawURL == "" {
+		return nil, errors.New("redis_url is required with the redis store backend")
+	}
+	opts, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis_url: %w", err)
+	}
+	s := &RedisStore{client: redis.NewClient(opts), prefix: redisKeyPrefix}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := s.client.Ping(ctx).Err(); err != nil {
+		s.client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	if err := s.importTOML(filepath.Join(GetPathPrefix(), storeFileName)); err != nil {
+		s.client.Close()
+		return nil, fmt.Errorf("failed to import the TOML store: %w", err)
+	}
+	return s, nil
+}
+
+// tenant returns the store of a tenant, which shares the connection under its own prefix.
+func (s *RedisStore) tenant(name string) (*RedisStore, error) {
+	t := &RedisStore{client: s.client, prefix: s.prefix + "tenants:" + name + ":"}
+	dir := filepath.Join(GetPathPrefix(), "tenants", name)
+	if err := t.importTOML(filepath.Join(dir, storeFileName)); err != nil {
+		return nil, fmt.Errorf("failed to import the TOML store: %w", err)
+	}
+	return t, nil
+}
+
+// kvKey is the hash of the key-value pairs.
+func (s *RedisStore) kvKey() string {
+	return s.prefix + "kv"
+}
+
+// documentKey is the key of a document.
+func (s *RedisStore) documentKey(name string) string {
+	return s.prefix + "documents:" + name
+}
+
+// importTOML copies the key-value pairs of the TOML store in filename when the store has none,
+// so that switching the backend keeps the login.
+func (s *RedisStore) importTOML(filename string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if n, err := s.client.Exists(ctx, s.kvKey()).Result(); err != nil || n > 0 {
+		return err
+	}
+
+	var cfg Config
+	if _, err := toml.DecodeFile(filename, &cfg); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for key, value := range cfg.Data {
+		// Another server may be importing at the same time, keep what it wrote
+		if err := s.client.HSetNX(ctx, s.kvKey(), key, value).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get retrieves the value for the specified key from the store.
+func (s *RedisStore) Get(key string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	value, err := s.client.HGet(ctx, s.kvKey(), key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+	}
+	return value, err
+}
+
+// Set sets the value for the specified key in the store.
+func (s *RedisStore) Set(key, value string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	return s.client.HSet(ctx, s.kvKey(), key, value).Err()
+}
+
+// Delete removes the entry for the specified key from the store.
+func (s *RedisStore) Delete(key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	return s.client.HDel(ctx, s.kvKey(), key).Err()
+}
+
+// setIfAbsent sets the key to value unless it is set, and returns the value of the key.
+func (s *RedisStore) setIfAbsent(key, value string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := s.client.HSetNX(ctx, s.kvKey(), key, value).Err(); err != nil {
+		return "", err
+	}
+	return s.client.HGet(ctx, s.kvKey(), key).Result()
+}
+
+// ReadDocument returns the content of the document. A missing document returns an error
+// that wraps os.ErrNotExist.
+func (s *RedisStore) ReadDocument(name string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	content, err := s.client.Get(ctx, s.documentKey(name)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("document %s: %w", name, os.ErrNotExist)
+	}
+	return content, err
+}
+
+// WriteDocument replaces the content of the document.
+func (s *RedisStore) WriteDocument(name string, content []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	return s.client.Set(ctx, s.documentKey(name), content, 0).Err()
+}
+
+// RemoveDocument removes the document. Removing a missing document is not an error.
+func (s *RedisStore) RemoveDocument(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	return s.client.Del(ctx, s.documentKey(name)).Err()
+}
+
+// Close closes the connection to the server, which the tenant stores share.
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,192 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+// useRedisBackend selects the Redis backend with an in-memory server until the test ends.
+func useRedisBackend(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	server := miniredis.RunT(t)
+	originalBackend, originalURL := config.Cfg.StoreBackend, config.Cfg.RedisURL
+	config.Cfg.StoreBackend = BackendRedis
+	config.Cfg.RedisURL = "redis://" + server.Addr()
+	t.Cleanup(func() {
+		config.Cfg.StoreBackend, config.Cfg.RedisURL = originalBackend, originalURL
+	})
+	return server
+}
+
+func TestOpenRedis(t *testing.T) {
+	cleanup, err := SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+	server := useRedisBackend(t)
+
+	s, err := OpenRedis(config.Cfg.RedisURL)
+	if err != nil {
+		t.Fatalf("OpenRedis() error = %v", err)
+	}
+	defer s.Close()
+
+	if _, err := s.Get("ssoToken"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Get() of a missing key error = %v, want ErrKeyNotFound", err)
+	}
+	if err := s.Set("ssoToken", "token"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, err := s.Get("ssoToken"); err != nil || got != "token" {
+		t.Errorf("Get() = %q, %v, want token", got, err)
+	}
+	if got := server.HGet(redisKeyPrefix+"kv", "ssoToken"); got != "token" {
+		t.Errorf("value in Redis = %q, want token", got)
+	}
+	if err := s.Delete("ssoToken"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Get("ssoToken"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Get() of a deleted key error = %v, want ErrKeyNotFound", err)
+	}
+
+	if got, err := s.setIfAbsent("secret", "first"); err != nil || got != "first" {
+		t.Errorf("setIfAbsent() = %q, %v, want first", got, err)
+	}
+	if got, err := s.setIfAbsent("secret", "second"); err != nil || got != "first" {
+		t.Errorf("setIfAbsent() of a set key = %q, %v, want first", got, err)
+	}
+}
+
+func TestOpenRedisErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{name: "No URL", url: ""},
+		{name: "Invalid URL", url: "http://localhost:6379"},
+		{name: "Unreachable server", url: "redis://127.0.0.1:1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if s, err := OpenRedis(tt.url); err == nil {
+				s.Close()
+				t.Errorf("OpenRedis(%q) should fail", tt.url)
+			}
+		})
+	}
+}
+
+func TestOpenRedisImportsTOML(t *testing.T) {
+	cleanup, err := SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+	server := useRedisBackend(t)
+
+	tomlStore, err := Open(filepath.Join(GetPathPrefix(), storeFileName))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if err := tomlStore.Set("refreshToken", "old"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	// The login of another server is kept
+	server.HSet(redisKeyPrefix+"kv", "refreshToken", "shared")
+
+	s, err := OpenRedis(config.Cfg.RedisURL)
+	if err != nil {
+		t.Fatalf("OpenRedis() error = %v", err)
+	}
+	defer s.Close()
+	if got, err := s.Get("refreshToken"); err != nil || got != "shared" {
+		t.Errorf("Get() = %q, %v, want the value in Redis", got, err)
+	}
+
+	server.FlushAll()
+	s.Close()
+	s, err = OpenRedis(config.Cfg.RedisURL)
+	if err != nil {
+		t.Fatalf("OpenRedis() error = %v", err)
+	}
+	if got, err := s.Get("refreshToken"); err != nil || got != "old" {
+		t.Errorf("Get() = %q, %v, want the value of the TOML store", got, err)
+	}
+}
+
+func TestInitRedis(t *testing.T) {
+	cleanup, err := SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+	server := useRedisBackend(t)
+
+	if err := Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if _, ok := KVS.(*RedisStore); !ok {
+		t.Fatalf("KVS = %T, want *RedisStore", KVS)
+	}
+	if !Shared() {
+		t.Error("Shared() = false with the Redis backend")
+	}
+
+	if err := WriteDocument("doc.json", []byte("content")); err != nil {
+		t.Fatalf("WriteDocument() error = %v", err)
+	}
+	if got, err := server.Get(redisKeyPrefix + "documents:doc.json"); err != nil || got != "content" {
+		t.Errorf("document in Redis = %q, %v", got, err)
+	}
+	if _, err := os.Stat(filepath.Join(GetPathPrefix(), "doc.json")); err == nil {
+		t.Error("the document should not be written to a file")
+	}
+	if err := RemoveDocument("doc.json"); err != nil {
+		t.Fatalf("RemoveDocument() error = %v", err)
+	}
+	if _, err := ReadDocument("doc.json"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadDocument() of a removed document error = %v, want os.ErrNotExist", err)
+	}
+
+	family, err := ForTenant("family")
+	if err != nil {
+		t.Fatalf("ForTenant() error = %v", err)
+	}
+	if err := family.Set("ssoToken", "family-token"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, err := Get("ssoToken"); err == nil {
+		t.Error("tenant value should not be visible in the global store")
+	}
+	if got := server.HGet(redisKeyPrefix+"tenants:family:kv", "ssoToken"); got != "family-token" {
+		t.Errorf("tenant value in Redis = %q, want family-token", got)
+	}
+}
+
+func TestSetIfAbsent(t *testing.T) {
+	cleanup, err := SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+	if err := Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if Shared() {
+		t.Error("Shared() = true with the TOML backend")
+	}
+
+	if got, err := SetIfAbsent(KVS, "secret", "first"); err != nil || got != "first" {
+		t.Errorf("SetIfAbsent() = %q, %v, want first", got, err)
+	}
+	if got, err := SetIfAbsent(KVS, "secret", "second"); err != nil || got != "first" {
+		t.Errorf("SetIfAbsent() of a set key = %q, %v, want first", got, err)
+	}
+}
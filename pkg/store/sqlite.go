@@ -0,0 +1,176 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	// Pure Go SQLite driver, so that builds without cgo keep working
+	_ "modernc.org/sqlite"
+)
+
+// sqliteFileName is the name of the SQLite database under the path prefix
+const sqliteFileName = "store.db"
+
+// sqliteMigrations are the schema changes of the SQLite store, applied in order. The number of
+// applied migrations is kept in the user_version of the database. Never change an applied
+// migration, add a new one instead.
+var sqliteMigrations = []string{
+	// 1: key-value pairs like the TOML store, e.g. the JioTV tokens and the device ID
+	`CREATE TABLE kv (
+		key   TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	)`,
+	// 2: documents that are JSON files under the path prefix with the TOML store
+	`CREATE TABLE documents (
+		name       TEXT PRIMARY KEY,
+		content    BLOB NOT NULL,
+		updated_at INTEGER NOT NULL
+	)`,
+}
+
+// SQLiteStore is a store in an embedded SQLite database.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens the SQLite store in filename, creating the database if it does not exist, and
+// migrates its schema. The key-value pairs of a TOML store next to a new database are imported.
+func OpenSQLite(filename string) (*SQLiteStore, error) {
+	_, statErr := os.Stat(filename)
+	isNew := os.IsNotExist(statErr)
+
+	// Writers wait for each other instead of failing with SQLITE_BUSY
+	dsn := "file:" + filepath.ToSlash(filename) + "?" + url.Values{
+		"_pragma": {"busy_timeout(5000)", "journal_mode(WAL)"},
+	}.Encode()
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	s := &SQLiteStore{db: db}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate %s: %w", filename, err)
+	}
+	// The database holds the JioTV tokens, so only its owner may read it
+	if err := os.Chmod(filename, 0o600); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if isNew {
+		if err := s.importTOML(filepath.Join(filepath.Dir(filename), storeFileName)); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to import the TOML store: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// migrate applies the migrations that were not applied yet.
+func (s *SQLiteStore) migrate() error {
+	var version int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(sqliteMigrations) {
+		return fmt.Errorf("database version %d is newer than this JioTV Go, which knows %d", version, len(sqliteMigrations))
+	}
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		// PRAGMA does not take parameters
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importTOML copies the key-value pairs of the TOML store in filename, so that switching the
+// backend keeps the login.
+func (s *SQLiteStore) importTOML(filename string) error {
+	var cfg Config
+	if _, err := toml.DecodeFile(filename, &cfg); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for key, value := range cfg.Data {
+		if err := s.Set(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get retrieves the value for the specified key from the store.
+func (s *SQLiteStore) Get(key string) (string, error) {
+	var value string
+	err := s.db.QueryRow("SELECT value FROM kv WHERE key = ?", key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+	}
+	return value, err
+}
+
+// Set sets the value for the specified key in the store.
+func (s *SQLiteStore) Set(key, value string) error {
+	_, err := s.db.Exec("INSERT INTO kv (key, value) VALUES (?, ?) ON CONFLICT (key) DO UPDATE SET value = excluded.value", key, value)
+	return err
+}
+
+// Delete removes the entry for the specified key from the store.
+func (s *SQLiteStore) Delete(key string) error {
+	_, err := s.db.Exec("DELETE FROM kv WHERE key = ?", key)
+	return err
+}
+
+// ReadDocument returns the content of the document. A missing document returns an error
+// that wraps os.ErrNotExist.
+func (s *SQLiteStore) ReadDocument(name string) ([]byte, error) {
+	var content []byte
+	err := s.db.QueryRow("SELECT content FROM documents WHERE name = ?", name).Scan(&content)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("document %s: %w", name, os.ErrNotExist)
+	}
+	return content, err
+}
+
+// WriteDocument replaces the content of the document.
+func (s *SQLiteStore) WriteDocument(name string, content []byte) error {
+	_, err := s.db.Exec("INSERT INTO documents (name, content, updated_at) VALUES (?, ?, ?) "+
+		"ON CONFLICT (name) DO UPDATE SET content = excluded.content, updated_at = excluded.updated_at",
+		name, content, time.Now().Unix())
+	return err
+}
+
+// RemoveDocument removes the document. Removing a missing document is not an error.
+func (s *SQLiteStore) RemoveDocument(name string) error {
+	_, err := s.db.Exec("DELETE FROM documents WHERE name = ?", name)
+	return err
+}
+
+// Close closes the database.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,200 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+// useSQLiteBackend selects the SQLite backend until the test ends.
+func useSQLiteBackend(t *testing.T) {
+	t.Helper()
+	original := config.Cfg.StoreBackend
+	config.Cfg.StoreBackend = BackendSQLite
+	t.Cleanup(func() { config.Cfg.StoreBackend = original })
+}
+
+func TestOpenSQLite(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), sqliteFileName)
+	s, err := OpenSQLite(filename)
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+
+	var version int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil || version != len(sqliteMigrations) {
+		t.Errorf("user_version = %d, %v, want %d", version, err, len(sqliteMigrations))
+	}
+
+	if _, err := s.Get("ssoToken"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Get() of a missing key error = %v, want ErrKeyNotFound", err)
+	}
+	if err := s.Set("ssoToken", "first"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := s.Set("ssoToken", "second"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := s.Set("deviceId", "device"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := s.Delete("deviceId"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	s.Close()
+
+	// Reopening keeps the values and does not migrate again
+	s, err = OpenSQLite(filename)
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	defer s.Close()
+	if got, err := s.Get("ssoToken"); err != nil || got != "second" {
+		t.Errorf("Get() = %q, %v, want second", got, err)
+	}
+	if _, err := s.Get("deviceId"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Get() of a deleted key error = %v, want ErrKeyNotFound", err)
+	}
+}
+
+func TestOpenSQLiteNewerVersion(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), sqliteFileName)
+	s, err := OpenSQLite(filename)
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	if _, err := s.db.Exec(fmt.Sprintf("PRAGMA user_version = %d", len(sqliteMigrations)+1)); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	if s, err := OpenSQLite(filename); err == nil {
+		s.Close()
+		t.Error("OpenSQLite() of a database of a newer version should fail")
+	}
+}
+
+func TestOpenSQLiteImportsTOML(t *testing.T) {
+	dir := t.TempDir()
+	tomlStore, err := Open(filepath.Join(dir, storeFileName))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if err := tomlStore.Set("refreshToken", "token"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	s, err := OpenSQLite(filepath.Join(dir, sqliteFileName))
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	defer s.Close()
+	if got, err := s.Get("refreshToken"); err != nil || got != "token" {
+		t.Errorf("Get() = %q, %v, want the value of the TOML store", got, err)
+	}
+}
+
+func TestInitBackend(t *testing.T) {
+	cleanup, err := SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+
+	original := config.Cfg.StoreBackend
+	defer func() { config.Cfg.StoreBackend = original }()
+	config.Cfg.StoreBackend = "memcached"
+	if err := Init(); err == nil {
+		t.Error("Init() with an unknown backend should fail")
+	}
+
+	config.Cfg.StoreBackend = " SQLite "
+	if err := Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if _, ok := KVS.(*SQLiteStore); !ok {
+		t.Fatalf("KVS = %T, want *SQLiteStore", KVS)
+	}
+	if _, err := os.Stat(filepath.Join(GetPathPrefix(), sqliteFileName)); err != nil {
+		t.Errorf("the database was not created under the path prefix: %v", err)
+	}
+
+	family, err := ForTenant("family")
+	if err != nil {
+		t.Fatalf("ForTenant() error = %v", err)
+	}
+	if _, ok := family.(*SQLiteStore); !ok {
+		t.Errorf("ForTenant() = %T, want *SQLiteStore", family)
+	}
+}
+
+func TestDocuments(t *testing.T) {
+	for _, backend := range []string{BackendTOML, BackendSQLite} {
+		t.Run(backend, func(t *testing.T) {
+			cleanup, err := SetupTestPathPrefix()
+			if err != nil {
+				t.Fatalf("Failed to setup test environment: %v", err)
+			}
+			defer cleanup()
+			if backend == BackendSQLite {
+				useSQLiteBackend(t)
+			}
+			if err := Init(); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+
+			if _, err := ReadDocument("doc.json"); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("ReadDocument() of a missing document error = %v, want os.ErrNotExist", err)
+			}
+			if err := WriteDocument("doc.json", []byte(`{"a": 1}`)); err != nil {
+				t.Fatalf("WriteDocument() error = %v", err)
+			}
+			if got, err := ReadDocument("doc.json"); err != nil || string(got) != `{"a": 1}` {
+				t.Errorf("ReadDocument() = %s, %v", got, err)
+			}
+			_, err = os.Stat(filepath.Join(GetPathPrefix(), "doc.json"))
+			if inFile := err == nil; inFile != (backend == BackendTOML) {
+				t.Errorf("document file exists = %v with the %s backend", inFile, backend)
+			}
+			if err := RemoveDocument("doc.json"); err != nil {
+				t.Fatalf("RemoveDocument() error = %v", err)
+			}
+			if _, err := ReadDocument("doc.json"); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("ReadDocument() of a removed document error = %v, want os.ErrNotExist", err)
+			}
+			if err := RemoveDocument("doc.json"); err != nil {
+				t.Errorf("RemoveDocument() of a missing document error = %v", err)
+			}
+		})
+	}
+}
+
+func TestReadDocumentFromFile(t *testing.T) {
+	cleanup, err := SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+	// A document written with the TOML backend
+	if err := os.WriteFile(filepath.Join(GetPathPrefix(), "doc.json"), []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	useSQLiteBackend(t)
+	if err := Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if got, err := ReadDocument("doc.json"); err != nil || string(got) != "old" {
+		t.Errorf("ReadDocument() = %s, %v, want the content of the file", got, err)
+	}
+	if err := WriteDocument("doc.json", []byte("new")); err != nil {
+		t.Fatalf("WriteDocument() error = %v", err)
+	}
+	if got, err := ReadDocument("doc.json"); err != nil || string(got) != "new" {
+		t.Errorf("ReadDocument() = %s, %v, want the content in the database", got, err)
+	}
+}
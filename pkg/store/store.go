@@ -18,6 +18,22 @@ type Config struct {
 	Data map[string]string `toml:"data"`
 }
 
+// Store is a key-value store. It is implemented by the TOML, SQLite and Redis stores.
+type Store interface {
+	Get(key string) (string, error)
+	Set(key, value string) error
+	Delete(key string) error
+}
+
+// documentStore is a store that also keeps documents, instead of files under the path prefix.
+type documentStore interface {
+	Store
+	ReadDocument(name string) ([]byte, error)
+	WriteDocument(name string, content []byte) error
+	RemoveDocument(name string) error
+	Close() error
+}
+
 // TomlStore represents the TOML storage.
 type TomlStore struct {
 	filename string
@@ -26,71 +42,310 @@ type TomlStore struct {
 }
 
 // KVS represents global key-value store.
-var KVS *TomlStore
+var KVS Store
+
+// storeFileName is the name of the store file under the path prefix.
+// store_vX.toml, where X is changed whenever new version requires re-login
+const storeFileName = "store_v4.toml"
+
+const (
+	// BackendTOML keeps the store in a TOML file and documents in JSON files under the path prefix
+	BackendTOML = "toml"
+	// BackendSQLite keeps the store and documents in an SQLite database under the path prefix
+	BackendSQLite = "sqlite"
+	// BackendRedis keeps the store and documents in a Redis server, which several JioTV Go
+	// servers can share
+	BackendRedis = "redis"
+)
+
+var (
+	// tenantStores holds the opened store of each tenant, indexed by tenant name
+	tenantStores   = make(map[string]Store)
+	tenantStoresMu sync.Mutex
+
+	// documents is the global store when it keeps the documents, nil with the TOML backend
+	documents   documentStore
+	documentsMu sync.RWMutex
+)
 
-// Init initializes the TOML file, creates if not exist, otherwise reads and decodes to struct.
+// Init initializes the store of the configured backend. The TOML file is created if it does not
+// exist, otherwise read and decoded to struct.
 func Init() error {
-	KVS = &TomlStore{}
-	// store_vX.toml, where X is changed whenever new version requires re-login
-	filename := filepath.Join(GetPathPrefix(), "store_v4.toml")
+	backend, err := Backend()
+	if err != nil {
+		return err
+	}
+	Close()
+
+	var s documentStore
+	switch backend {
+	case BackendSQLite:
+		s, err = OpenSQLite(filepath.Join(GetPathPrefix(), sqliteFileName))
+	case BackendRedis:
+		s, err = OpenRedis(config.Cfg.RedisURL)
+	default:
+		tomlStore, err := Open(filepath.Join(GetPathPrefix(), storeFileName))
+		KVS = tomlStore
+		if err != nil {
+			return err
+		}
+		KVS, err = withEncryption(tomlStore)
+		return err
+	}
+	if err != nil {
+		KVS = nil
+		return err
+	}
+	documentsMu.Lock()
+	documents = s
+	documentsMu.Unlock()
+	KVS, err = withEncryption(s)
+	return err
+}
+
+// Backend returns the configured store backend, BackendTOML by default.
+func Backend() (string, error) {
+	switch backend := strings.ToLower(strings.TrimSpace(config.Cfg.StoreBackend)); backend {
+	case "", BackendTOML:
+		return BackendTOML, nil
+	case BackendSQLite, BackendRedis:
+		return backend, nil
+	default:
+		return "", fmt.Errorf("unknown store_backend %q, use %q, %q or %q", config.Cfg.StoreBackend, BackendTOML, BackendSQLite, BackendRedis)
+	}
+}
 
-	KVS.mu.Lock()
-	defer KVS.mu.Unlock()
+// Shared reports whether the store is shared with other JioTV Go servers, as with the Redis
+// backend. Servers behind a load balancer then also share the state that is kept in memory
+// otherwise, like the key of the encrypted URLs.
+func Shared() bool {
+	backend, _ := Backend()
+	return backend == BackendRedis
+}
+
+// Close closes the databases and connections of the global and tenant stores, if any.
+func Close() {
+	documentsMu.Lock()
+	if documents != nil {
+		documents.Close()
+		documents = nil
+	}
+	documentsMu.Unlock()
+
+	tenantStoresMu.Lock()
+	for _, s := range tenantStores {
+		if encrypted, ok := s.(*EncryptedStore); ok {
+			s = encrypted.Store
+		}
+		if s, ok := s.(*SQLiteStore); ok {
+			s.Close()
+		}
+	}
+	tenantStores = make(map[string]Store)
+	tenantStoresMu.Unlock()
+}
+
+// Open opens the TOML store in filename, creating the file if it does not exist.
+func Open(filename string) (*TomlStore, error) {
+	s := &TomlStore{filename: filename}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	KVS.filename = filename
 	if _, err := os.Stat(filename); os.IsNotExist(err) {
 		// Create a new file with an empty configuration.
-		KVS.config = Config{
+		s.config = Config{
 			Data: make(map[string]string),
 		}
-		return saveConfig()
+		return s, s.save()
 	}
 
 	// Read and decode existing configuration from the file.
-	_, err := toml.DecodeFile(filename, &KVS.config)
-	return err
+	_, err := toml.DecodeFile(filename, &s.config)
+	if s.config.Data == nil {
+		s.config.Data = make(map[string]string)
+	}
+	return s, err
+}
+
+// ForTenant returns the store of a tenant, kept in its own directory under the path prefix.
+// The empty tenant name returns the global store KVS.
+func ForTenant(name string) (Store, error) {
+	if name == "" {
+		return KVS, nil
+	}
+
+	tenantStoresMu.Lock()
+	defer tenantStoresMu.Unlock()
+	if s, ok := tenantStores[name]; ok {
+		return s, nil
+	}
+
+	dir := filepath.Join(GetPathPrefix(), "tenants", name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	var s Store
+	var err error
+	switch backend, _ := Backend(); backend {
+	case BackendSQLite:
+		s, err = OpenSQLite(filepath.Join(dir, sqliteFileName))
+	case BackendRedis:
+		// Tenants share the connection of the global store under their own keys
+		documentsMu.RLock()
+		global, ok := documents.(*RedisStore)
+		documentsMu.RUnlock()
+		if !ok {
+			return nil, errors.New("the store is not initialized")
+		}
+		s, err = global.tenant(name)
+	default:
+		s, err = Open(filepath.Join(dir, storeFileName))
+	}
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := withEncryption(s)
+	if err != nil {
+		if s, ok := s.(*SQLiteStore); ok {
+			s.Close()
+		}
+		return nil, err
+	}
+	tenantStores[name] = encrypted
+	return encrypted, nil
 }
 
 // Get retrieves the value for the specified key from the TOML store.
 func Get(key string) (string, error) {
-	KVS.mu.Lock()
-	defer KVS.mu.Unlock()
+	return KVS.Get(key)
+}
 
-	value, ok := KVS.config.Data[key]
-	if !ok {
-		return "", fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+// SetIfAbsent sets the key to value in s unless it is set already, and returns the value of the key.
+// With the Redis backend this is atomic, so that servers starting together agree on the value.
+func SetIfAbsent(s Store, key, value string) (string, error) {
+	if s, ok := s.(interface {
+		setIfAbsent(key, value string) (string, error)
+	}); ok {
+		return s.setIfAbsent(key, value)
 	}
-	return value, nil
+	current, err := s.Get(key)
+	if err == nil {
+		return current, nil
+	}
+	if !errors.Is(err, ErrKeyNotFound) {
+		return "", err
+	}
+	return value, s.Set(key, value)
 }
 
 // Set sets the value for the specified key in the TOML store.
 func Set(key, value string) error {
-	KVS.mu.Lock()
-	defer KVS.mu.Unlock()
-
-	KVS.config.Data[key] = value
-	return saveConfig()
+	return KVS.Set(key, value)
 }
 
 // Delete removes the entry for the specified key from the TOML store.
 func Delete(key string) error {
-	KVS.mu.Lock()
-	defer KVS.mu.Unlock()
+	return KVS.Delete(key)
+}
+
+// Get retrieves the value for the specified key from the store.
+func (s *TomlStore) Get(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.config.Data[key]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+	}
+	return value, nil
+}
+
+// Set sets the value for the specified key in the store.
+func (s *TomlStore) Set(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.config.Data[key] = value
+	return s.save()
+}
+
+// Delete removes the entry for the specified key from the store.
+func (s *TomlStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	delete(KVS.config.Data, key)
-	return saveConfig()
+	delete(s.config.Data, key)
+	return s.save()
 }
 
-// saveConfig saves the current configuration to the TOML file.
-func saveConfig() error {
-	file, err := os.Create(KVS.filename)
+// save saves the current configuration to the TOML file. It must be called with s.mu held.
+// The file holds the JioTV tokens, so only its owner may read it.
+func (s *TomlStore) save() error {
+	file, err := os.OpenFile(s.filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
+	// Files created by older versions were readable by everyone
+	if err := file.Chmod(0o600); err != nil {
+		return err
+	}
 
 	encoder := toml.NewEncoder(file)
-	return encoder.Encode(KVS.config)
+	return encoder.Encode(s.config)
+}
+
+// ReadDocument returns the content of a document, like the channel list cache. With the TOML backend
+// documents are files under the path prefix. With the SQLite and Redis backends, a document that is
+// not in the store yet is read from its file, so that switching the backend keeps the data. A missing
+// document returns an error that wraps os.ErrNotExist.
+func ReadDocument(name string) ([]byte, error) {
+	documentsMu.RLock()
+	s := documents
+	documentsMu.RUnlock()
+	if s != nil {
+		content, err := s.ReadDocument(name)
+		if !errors.Is(err, os.ErrNotExist) {
+			return content, err
+		}
+	}
+	return os.ReadFile(filepath.Join(GetPathPrefix(), name))
+}
+
+// WriteDocument replaces the content of a document. Files are replaced atomically.
+func WriteDocument(name string, content []byte) error {
+	documentsMu.RLock()
+	s := documents
+	documentsMu.RUnlock()
+	if s != nil {
+		return s.WriteDocument(name, content)
+	}
+
+	filename := filepath.Join(GetPathPrefix(), name)
+	tmp := filename + ".tmp"
+	// Documents hold client addresses and device data, so only the user running JioTV Go can read them
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// RemoveDocument removes a document. Removing a missing document is not an error.
+func RemoveDocument(name string) error {
+	documentsMu.RLock()
+	s := documents
+	documentsMu.RUnlock()
+	if s != nil {
+		if err := s.RemoveDocument(name); err != nil {
+			return err
+		}
+	}
+	// The file of a document read before the backend was switched is removed too
+	if err := os.Remove(filepath.Join(GetPathPrefix(), name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
 }
 
 // Errors
@@ -148,6 +403,7 @@ func SetupTestPathPrefix() (cleanup func(), err error) {
 	config.Cfg.PathPrefix = tempDir
 
 	// Reset the global store to nil so Init() can be called again
+	Close()
 	KVS = nil
 
 	// Return cleanup function
@@ -155,6 +411,7 @@ func SetupTestPathPrefix() (cleanup func(), err error) {
 		// Restore the original pathPrefix
 		config.Cfg.PathPrefix = originalPathPrefix
 		// Reset KVS to nil
+		Close()
 		KVS = nil
 		// Clean up the temporary directory
 		os.RemoveAll(tempDir)
@@ -2,6 +2,7 @@ package store
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -225,13 +226,13 @@ func TestDelete(t *testing.T) {
 	}
 }
 
-func TestSaveConfig(t *testing.T) {
+func TestTomlStore_save(t *testing.T) {
 	tests := []struct {
 		name    string
 		wantErr bool
 	}{
 		{
-			name:    "Test saveConfig function",
+			name:    "Test save method",
 			wantErr: false, // Should work if store is initialized
 		},
 	}
@@ -249,8 +250,8 @@ func TestSaveConfig(t *testing.T) {
 				t.Fatalf("Failed to initialize store: %v", err)
 			}
 
-			if err := saveConfig(); (err != nil) != tt.wantErr {
-				t.Errorf("saveConfig() error = %v, wantErr %v", err, tt.wantErr)
+			if err := KVS.(*TomlStore).save(); (err != nil) != tt.wantErr {
+				t.Errorf("save() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
@@ -285,3 +286,41 @@ func TestGetPathPrefix(t *testing.T) {
 		})
 	}
 }
+
+func TestForTenant(t *testing.T) {
+	cleanup, err := SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+	if err := Init(); err != nil {
+		t.Fatalf("Failed to initialize store: %v", err)
+	}
+
+	if s, err := ForTenant(""); err != nil || s != KVS {
+		t.Fatalf("ForTenant(\"\") = %v, %v, want KVS", s, err)
+	}
+
+	family, err := ForTenant("family")
+	if err != nil {
+		t.Fatalf("ForTenant() error = %v", err)
+	}
+	if again, _ := ForTenant("family"); again != family {
+		t.Error("ForTenant() should return the same store for the same tenant")
+	}
+	if err := family.Set("ssoToken", "family-token"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, err := Get("ssoToken"); err == nil {
+		t.Error("tenant value should not be visible in the global store")
+	}
+
+	// Values are persisted in the tenant directory
+	reopened, err := Open(filepath.Join(GetPathPrefix(), "tenants", "family", storeFileName))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if value, err := reopened.Get("ssoToken"); err != nil || value != "family-token" {
+		t.Errorf("reopened Get() = %q, %v, want family-token", value, err)
+	}
+}
@@ -0,0 +1,141 @@
+package television
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// defaultChannelsCacheTTL is how long the channel list is fresh if not configured
+	defaultChannelsCacheTTL = 5 * time.Minute
+	// channelsCacheFileName is the name of the on-disk channel list cache
+	channelsCacheFileName = "channels_cache.json"
+)
+
+// channelsCacheFile is the on-disk format of the channel list cache
+type channelsCacheFile struct {
+	FetchedAt time.Time        `json:"fetched_at"`
+	Channels  ChannelsResponse `json:"channels"`
+}
+
+var (
+	// lastChannels holds the last channels fetched from the JioTV API
+	lastChannels          *ChannelsResponse
+	lastChannelsFetchedAt time.Time
+	lastChannelsMu        sync.RWMutex
+
+	// channelsRefreshing is set while a background refresh is running
+	channelsRefreshing atomic.Bool
+	// channelsDiskOnce loads the on-disk cache at most once
+	channelsDiskOnce sync.Once
+)
+
+// channelsCacheTTL returns how long the cached channel list is fresh.
+// A negative duration means the cache is disabled.
+func channelsCacheTTL() time.Duration {
+	switch {
+	case config.Cfg.ChannelsCacheTTL < 0:
+		return -1
+	case config.Cfg.ChannelsCacheTTL > 0:
+		return time.Duration(config.Cfg.ChannelsCacheTTL) * time.Second
+	}
+	return defaultChannelsCacheTTL
+}
+
+// channelsCacheOnDisk reports whether the channel list is stored on disk.
+// Offline mode needs the stored list to start without the JioTV API.
+func channelsCacheOnDisk() bool {
+	return config.Cfg.ChannelsCacheOnDisk || config.Cfg.OfflineMode
+}
+
+// setCachedChannels stores the channels fetched from the JioTV API and records how they changed.
+func setCachedChannels(channels ChannelsResponse, fetchedAt time.Time) {
+	lastChannelsMu.Lock()
+	previous := lastChannels
+	lastChannels = &channels
+	lastChannelsFetchedAt = fetchedAt
+	lastChannelsMu.Unlock()
+	invalidateChannelIndex()
+
+	if previous != nil {
+		recordChannelChanges(previous.Result, channels.Result, fetchedAt)
+	}
+
+	if channelsCacheOnDisk() {
+		if err := saveChannelsToDisk(channels, fetchedAt); err != nil {
+			utils.SafeLogf("Failed to save channels cache: %v", err)
+		}
+	}
+}
+
+// getCachedChannels returns the last channels fetched from the JioTV API and when they were fetched.
+func getCachedChannels() (ChannelsResponse, time.Time, bool) {
+	lastChannelsMu.RLock()
+	defer lastChannelsMu.RUnlock()
+	if lastChannels == nil {
+		return ChannelsResponse{}, time.Time{}, false
+	}
+	return *lastChannels, lastChannelsFetchedAt, true
+}
+
+// CachedChannels returns the last fetched channel list merged with custom channels, without
+// calling the JioTV API. It reports false if the channels were not fetched yet.
+func CachedChannels() (ChannelsResponse, bool) {
+	cached, _, ok := getCachedChannels()
+	if !ok {
+		return ChannelsResponse{}, false
+	}
+	return withCustomChannels(cached), true
+}
+
+// saveChannelsToDisk writes the channel list cache to the store.
+func saveChannelsToDisk(channels ChannelsResponse, fetchedAt time.Time) error {
+	data, err := json.Marshal(channelsCacheFile{FetchedAt: fetchedAt, Channels: channels})
+	if err != nil {
+		return err
+	}
+	return store.WriteDocument(channelsCacheFileName, data)
+}
+
+// loadChannelsFromDisk fills the in-memory cache from the on-disk cache if it is empty.
+func loadChannelsFromDisk() {
+	data, err := store.ReadDocument(channelsCacheFileName)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			utils.SafeLogf("Failed to read channels cache: %v", err)
+		}
+		return
+	}
+	var cache channelsCacheFile
+	if err := json.Unmarshal(data, &cache); err != nil {
+		utils.SafeLogf("Ignoring invalid channels cache: %v", err)
+		return
+	}
+
+	lastChannelsMu.Lock()
+	defer lastChannelsMu.Unlock()
+	if lastChannels == nil {
+		lastChannels = &cache.Channels
+		lastChannelsFetchedAt = cache.FetchedAt
+	}
+}
+
+// refreshChannelsInBackground fetches the channel list unless a refresh is already running.
+func refreshChannelsInBackground() {
+	if !channelsRefreshing.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer channelsRefreshing.Store(false)
+		if _, err := fetchChannels(); err != nil {
+			utils.SafeLogf("Background channels refresh failed: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,92 @@
+package television
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+)
+
+// resetChannelsCache clears the in-memory channel list cache.
+func resetChannelsCache() {
+	lastChannelsMu.Lock()
+	lastChannels = nil
+	lastChannelsFetchedAt = time.Time{}
+	lastChannelsMu.Unlock()
+	channelsDiskOnce = sync.Once{}
+	invalidateChannelIndex()
+}
+
+func TestChannelsCacheTTL(t *testing.T) {
+	original := config.Cfg.ChannelsCacheTTL
+	defer func() { config.Cfg.ChannelsCacheTTL = original }()
+
+	tests := []struct {
+		name  string
+		value int
+		want  time.Duration
+	}{
+		{name: "default", value: 0, want: defaultChannelsCacheTTL},
+		{name: "configured", value: 60, want: time.Minute},
+		{name: "disabled", value: -1, want: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Cfg.ChannelsCacheTTL = tt.value
+			if got := channelsCacheTTL(); got != tt.want {
+				t.Errorf("channelsCacheTTL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChannelsServesFreshCache(t *testing.T) {
+	resetChannelsCache()
+	defer resetChannelsCache()
+
+	setCachedChannels(ChannelsResponse{Code: 200, Result: []Channel{{ID: "143", Name: "Cached"}}}, time.Now())
+
+	got, err := Channels()
+	if err != nil {
+		t.Fatalf("Channels() error = %v", err)
+	}
+	if len(got.Result) != 1 || got.Result[0].ID != "143" {
+		t.Errorf("Channels() = %v, want cached channel 143", got.Result)
+	}
+}
+
+func TestChannelsCacheOnDisk(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("SetupTestPathPrefix failed: %v", err)
+	}
+	defer cleanup()
+
+	original := config.Cfg.ChannelsCacheOnDisk
+	config.Cfg.ChannelsCacheOnDisk = true
+	defer func() { config.Cfg.ChannelsCacheOnDisk = original }()
+
+	resetChannelsCache()
+	defer resetChannelsCache()
+
+	fetchedAt := time.Now().Truncate(time.Second)
+	setCachedChannels(ChannelsResponse{Code: 200, Result: []Channel{{ID: "144", Name: "On Disk"}}}, fetchedAt)
+
+	// Simulate a restart
+	resetChannelsCache()
+	loadChannelsFromDisk()
+
+	got, gotFetchedAt, ok := getCachedChannels()
+	if !ok {
+		t.Fatal("getCachedChannels() found no channels after loading from disk")
+	}
+	if len(got.Result) != 1 || got.Result[0].ID != "144" {
+		t.Errorf("loaded channels = %v, want channel 144", got.Result)
+	}
+	if !gotFetchedAt.Equal(fetchedAt) {
+		t.Errorf("loaded fetchedAt = %v, want %v", gotFetchedAt, fetchedAt)
+	}
+}
@@ -0,0 +1,105 @@
+package television
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// maxChannelChanges is the number of channel list changes kept in memory
+const maxChannelChanges = 50
+
+// RenamedChannel is a channel whose name changed between two channel lists
+type RenamedChannel struct {
+	ID      string `json:"channel_id"`
+	OldName string `json:"old_name"`
+	NewName string `json:"new_name"`
+}
+
+// ChannelChanges describes how the JioTV channel list changed on a refresh
+type ChannelChanges struct {
+	Time    time.Time        `json:"time"`
+	Added   []Channel        `json:"added"`
+	Removed []Channel        `json:"removed"`
+	Renamed []RenamedChannel `json:"renamed"`
+}
+
+// IsEmpty reports whether the channel list did not change.
+func (c ChannelChanges) IsEmpty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Renamed) == 0
+}
+
+var (
+	// channelChanges holds the most recent channel list changes, oldest first
+	channelChanges   []ChannelChanges
+	channelChangesMu sync.RWMutex
+)
+
+// diffChannels compares two channel lists by channel ID.
+// The result is sorted by channel ID so that it is stable across refreshes.
+func diffChannels(oldChannels, newChannels []Channel) ChannelChanges {
+	oldByID := make(map[string]Channel, len(oldChannels))
+	for _, channel := range oldChannels {
+		oldByID[channel.ID] = channel
+	}
+	newByID := make(map[string]Channel, len(newChannels))
+	for _, channel := range newChannels {
+		newByID[channel.ID] = channel
+	}
+
+	var changes ChannelChanges
+	for id, channel := range newByID {
+		old, ok := oldByID[id]
+		if !ok {
+			changes.Added = append(changes.Added, channel)
+		} else if old.Name != channel.Name {
+			changes.Renamed = append(changes.Renamed, RenamedChannel{ID: id, OldName: old.Name, NewName: channel.Name})
+		}
+	}
+	for id, channel := range oldByID {
+		if _, ok := newByID[id]; !ok {
+			changes.Removed = append(changes.Removed, channel)
+		}
+	}
+
+	sort.Slice(changes.Added, func(i, j int) bool { return changes.Added[i].ID < changes.Added[j].ID })
+	sort.Slice(changes.Removed, func(i, j int) bool { return changes.Removed[i].ID < changes.Removed[j].ID })
+	sort.Slice(changes.Renamed, func(i, j int) bool { return changes.Renamed[i].ID < changes.Renamed[j].ID })
+	return changes
+}
+
+// recordChannelChanges compares a refreshed channel list with the previous one,
+// logs a summary and keeps the changes for the changes endpoint.
+func recordChannelChanges(oldChannels, newChannels []Channel, at time.Time) {
+	changes := diffChannels(oldChannels, newChannels)
+	if changes.IsEmpty() {
+		return
+	}
+	changes.Time = at
+
+	utils.SafeLogf("Channel list changed: %d added, %d removed, %d renamed", len(changes.Added), len(changes.Removed), len(changes.Renamed))
+	for _, channel := range changes.Removed {
+		utils.SafeLogf("Channel removed: %s (%s)", channel.Name, channel.ID)
+	}
+
+	channelChangesMu.Lock()
+	defer channelChangesMu.Unlock()
+	channelChanges = append(channelChanges, changes)
+	if len(channelChanges) > maxChannelChanges {
+		channelChanges = channelChanges[len(channelChanges)-maxChannelChanges:]
+	}
+}
+
+// GetChannelChanges returns the recorded channel list changes, newest first.
+func GetChannelChanges() []ChannelChanges {
+	channelChangesMu.RLock()
+	defer channelChangesMu.RUnlock()
+
+	result := make([]ChannelChanges, 0, len(channelChanges))
+	for i := len(channelChanges) - 1; i >= 0; i-- {
+		result = append(result, channelChanges[i])
+	}
+	return result
+}
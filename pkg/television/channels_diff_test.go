@@ -0,0 +1,65 @@
+package television
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiffChannels(t *testing.T) {
+	oldChannels := []Channel{
+		{ID: "143", Name: "Sony"},
+		{ID: "144", Name: "Colors"},
+		{ID: "145", Name: "Star"},
+	}
+	newChannels := []Channel{
+		{ID: "146", Name: "Zee"},
+		{ID: "144", Name: "Colors HD"},
+		{ID: "143", Name: "Sony"},
+	}
+
+	changes := diffChannels(oldChannels, newChannels)
+
+	if len(changes.Added) != 1 || changes.Added[0].ID != "146" {
+		t.Errorf("Added = %v, want channel 146", changes.Added)
+	}
+	if len(changes.Removed) != 1 || changes.Removed[0].ID != "145" {
+		t.Errorf("Removed = %v, want channel 145", changes.Removed)
+	}
+	want := RenamedChannel{ID: "144", OldName: "Colors", NewName: "Colors HD"}
+	if len(changes.Renamed) != 1 || changes.Renamed[0] != want {
+		t.Errorf("Renamed = %v, want %v", changes.Renamed, want)
+	}
+
+	if !diffChannels(oldChannels, oldChannels).IsEmpty() {
+		t.Error("diffChannels() of identical lists is not empty")
+	}
+}
+
+func TestSetCachedChannelsRecordsChanges(t *testing.T) {
+	resetChannelsCache()
+	defer resetChannelsCache()
+	channelChangesMu.Lock()
+	channelChanges = nil
+	channelChangesMu.Unlock()
+
+	now := time.Now()
+	setCachedChannels(ChannelsResponse{Result: []Channel{{ID: "143", Name: "Sony"}}}, now)
+	setCachedChannels(ChannelsResponse{Result: []Channel{{ID: "143", Name: "Sony"}}}, now.Add(time.Minute))
+	if got := GetChannelChanges(); len(got) != 0 {
+		t.Fatalf("GetChannelChanges() = %v, want no changes", got)
+	}
+
+	setCachedChannels(ChannelsResponse{Result: []Channel{{ID: "144", Name: "Colors"}}}, now.Add(2*time.Minute))
+	setCachedChannels(ChannelsResponse{Result: []Channel{{ID: "144", Name: "Colors HD"}}}, now.Add(3*time.Minute))
+
+	got := GetChannelChanges()
+	if len(got) != 2 {
+		t.Fatalf("GetChannelChanges() returned %d changes, want 2", len(got))
+	}
+	if len(got[0].Renamed) != 1 {
+		t.Errorf("newest change = %+v, want a rename", got[0])
+	}
+	if len(got[1].Added) != 1 || len(got[1].Removed) != 1 {
+		t.Errorf("oldest change = %+v, want one added and one removed", got[1])
+	}
+}
@@ -0,0 +1,39 @@
+package television
+
+import (
+	"sync"
+	"time"
+)
+
+// liveResultMaxAge is how long a live result may be served while the playback API is unavailable.
+// Stream tokens are short lived, so older results would not play anyway.
+const liveResultMaxAge = 5 * time.Minute
+
+// cachedLiveResult is a live result with the time it was fetched
+type cachedLiveResult struct {
+	result    LiveURLOutput
+	fetchedAt time.Time
+}
+
+// liveResultCache holds the last live result of each channel, indexed by subscriber and channel ID
+var liveResultCache sync.Map
+
+// setCachedLiveResult stores the live result of a channel as a fallback.
+func setCachedLiveResult(key string, result *LiveURLOutput) {
+	liveResultCache.Store(key, cachedLiveResult{result: *result, fetchedAt: time.Now()})
+}
+
+// getCachedLiveResult returns the last live result of a channel if it is recent enough.
+func getCachedLiveResult(key string) (*LiveURLOutput, bool) {
+	value, ok := liveResultCache.Load(key)
+	if !ok {
+		return nil, false
+	}
+	entry := value.(cachedLiveResult)
+	if time.Since(entry.fetchedAt) > liveResultMaxAge {
+		liveResultCache.Delete(key)
+		return nil, false
+	}
+	result := entry.result
+	return &result, true
+}
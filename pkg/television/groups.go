@@ -0,0 +1,69 @@
+package television
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	// channelGroups holds the groups set by channel ID in the custom channels file
+	channelGroups   map[string]string
+	channelGroupsMu sync.RWMutex
+)
+
+// setChannelGroups replaces the groups set by channel ID.
+func setChannelGroups(groups map[string]string) {
+	channelGroupsMu.Lock()
+	channelGroups = groups
+	channelGroupsMu.Unlock()
+}
+
+// applyChannelGroups sets the groups from the custom channels file on the channels.
+func applyChannelGroups(channels []Channel) []Channel {
+	channelGroupsMu.RLock()
+	groups := channelGroups
+	channelGroupsMu.RUnlock()
+	if len(groups) == 0 {
+		return channels
+	}
+
+	result := make([]Channel, len(channels))
+	copy(result, channels)
+	for i := range result {
+		if group := strings.TrimSpace(groups[result[i].ID]); group != "" {
+			result[i].Group = group
+		}
+	}
+	return result
+}
+
+// ChannelGroups returns the sorted names of the groups the channels are in.
+func ChannelGroups(channels []Channel) []string {
+	seen := make(map[string]bool)
+	var groups []string
+	for _, channel := range channels {
+		if channel.Group != "" && !seen[channel.Group] {
+			seen[channel.Group] = true
+			groups = append(groups, channel.Group)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return strings.ToLower(groups[i]) < strings.ToLower(groups[j])
+	})
+	return groups
+}
+
+// FilterChannelsByGroups returns the channels in any of the groups, ignoring case.
+func FilterChannelsByGroups(channels []Channel, groups []string) []Channel {
+	var filtered []Channel
+	for _, channel := range channels {
+		for _, group := range groups {
+			if channel.Group != "" && strings.EqualFold(channel.Group, strings.TrimSpace(group)) {
+				filtered = append(filtered, channel)
+				break
+			}
+		}
+	}
+	return filtered
+}
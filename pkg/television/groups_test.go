@@ -0,0 +1,76 @@
+package television
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+func TestApplyChannelRulesWithChannelGroups(t *testing.T) {
+	defer setChannelGroups(nil)
+	setChannelGroups(map[string]string{"143": "News", "144": " Sports "})
+
+	channels := []Channel{
+		{ID: "143", Name: "Aaj Tak"},
+		{ID: "144", Name: "Star Sports 1 HD"},
+		{ID: "cc_1", Name: "My Channel", IsCustom: true, Group: "Local"},
+	}
+	rules := []config.ChannelRule{{MatchName: "^aaj", SetGroup: "Hindi News"}}
+
+	result := ApplyChannelRules(channels, nil)
+	if got := []string{result[0].Group, result[1].Group, result[2].Group}; !reflect.DeepEqual(got, []string{"News", "Sports", "Local"}) {
+		t.Errorf("ApplyChannelRules() groups = %q, want the groups of the file", got)
+	}
+	if channels[0].Group != "" {
+		t.Error("ApplyChannelRules() changed the given channels")
+	}
+
+	result = ApplyChannelRules(channels, rules)
+	if result[0].Group != "Hindi News" {
+		t.Errorf("ApplyChannelRules() group = %q, want the group of the rule", result[0].Group)
+	}
+}
+
+func TestChannelGroups(t *testing.T) {
+	channels := []Channel{
+		{ID: "1", Group: "sports"},
+		{ID: "2"},
+		{ID: "3", Group: "News"},
+		{ID: "4", Group: "sports"},
+	}
+	if got, want := ChannelGroups(channels), []string{"News", "sports"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ChannelGroups() = %q, want %q", got, want)
+	}
+	if got := ChannelGroups([]Channel{{ID: "1"}}); got != nil {
+		t.Errorf("ChannelGroups() = %q, want none", got)
+	}
+}
+
+func TestFilterChannelsByGroups(t *testing.T) {
+	channels := []Channel{
+		{ID: "1", Group: "Sports"},
+		{ID: "2"},
+		{ID: "3", Group: "News"},
+	}
+	tests := []struct {
+		name    string
+		groups  []string
+		wantIDs []string
+	}{
+		{"one group ignoring case", []string{"sports"}, []string{"1"}},
+		{"several groups", []string{"News", " Sports"}, []string{"1", "3"}},
+		{"unknown group", []string{"Kids"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []string
+			for _, channel := range FilterChannelsByGroups(channels, tt.groups) {
+				ids = append(ids, channel.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("FilterChannelsByGroups() = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
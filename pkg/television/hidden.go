@@ -0,0 +1,103 @@
+package television
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// hiddenChannelsFile stores the channels hidden from the web interface under the path prefix,
+// so that they stay hidden after a restart
+const hiddenChannelsFile = "hidden_channels.json"
+
+var (
+	// hiddenChannels holds the names of the hidden channels by channel ID
+	hiddenChannels   map[string]string
+	hiddenChannelsMu sync.RWMutex
+)
+
+// InitHiddenChannels loads the hidden channels from disk.
+func InitHiddenChannels() {
+	var hidden map[string]string
+	content, err := store.ReadDocument(hiddenChannelsFile)
+	if err == nil {
+		if err := json.Unmarshal(content, &hidden); err != nil {
+			utils.SafeLogf("WARN: Ignoring invalid hidden channels file: %v", err)
+			hidden = nil
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		utils.SafeLogf("WARN: Failed to read hidden channels: %v", err)
+	}
+	hiddenChannelsMu.Lock()
+	hiddenChannels = hidden
+	hiddenChannelsMu.Unlock()
+	invalidateChannelIndex()
+}
+
+// HiddenChannels returns the names of the hidden channels by channel ID.
+func HiddenChannels() map[string]string {
+	hiddenChannelsMu.RLock()
+	defer hiddenChannelsMu.RUnlock()
+	hidden := make(map[string]string, len(hiddenChannels))
+	for id, name := range hiddenChannels {
+		hidden[id] = name
+	}
+	return hidden
+}
+
+// IsHiddenChannel reports whether the channel is hidden.
+func IsHiddenChannel(channelID string) bool {
+	hiddenChannelsMu.RLock()
+	defer hiddenChannelsMu.RUnlock()
+	_, ok := hiddenChannels[channelID]
+	return ok
+}
+
+// SetChannelHidden hides or shows a channel and saves the hidden channels. The name is kept to list
+// the channel while it is hidden.
+func SetChannelHidden(channelID, name string, hidden bool) error {
+	hiddenChannelsMu.Lock()
+	defer hiddenChannelsMu.Unlock()
+	updated := make(map[string]string, len(hiddenChannels)+1)
+	for id, n := range hiddenChannels {
+		updated[id] = n
+	}
+	if hidden {
+		updated[channelID] = name
+	} else {
+		delete(updated, channelID)
+	}
+
+	content, err := json.MarshalIndent(updated, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := store.WriteDocument(hiddenChannelsFile, content); err != nil {
+		return err
+	}
+	hiddenChannels = updated
+	invalidateChannelIndex()
+	return nil
+}
+
+// removeHiddenChannels returns the channels that are not hidden.
+func removeHiddenChannels(channels []Channel) []Channel {
+	hiddenChannelsMu.RLock()
+	hidden := hiddenChannels
+	hiddenChannelsMu.RUnlock()
+	if len(hidden) == 0 {
+		return channels
+	}
+
+	result := make([]Channel, 0, len(channels))
+	for _, channel := range channels {
+		if _, ok := hidden[channel.ID]; !ok {
+			result = append(result, channel)
+		}
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package television
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+func TestHiddenChannels(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("SetupTestPathPrefix failed: %v", err)
+	}
+	defer cleanup()
+	InitHiddenChannels()
+	defer InitHiddenChannels()
+
+	resetChannelsCache()
+	defer resetChannelsCache()
+	setCachedChannels(ChannelsResponse{Code: 200, Result: []Channel{{ID: "143", Name: "News"}, {ID: "144", Name: "Sports"}}}, time.Now())
+
+	if err := SetChannelHidden("143", "News", true); err != nil {
+		t.Fatalf("SetChannelHidden() error = %v", err)
+	}
+	// The hidden channels are kept after a restart
+	InitHiddenChannels()
+	if !IsHiddenChannel("143") || IsHiddenChannel("144") {
+		t.Errorf("IsHiddenChannel() = %v, %v, want only 143 hidden", IsHiddenChannel("143"), IsHiddenChannel("144"))
+	}
+	if got, want := HiddenChannels(), map[string]string{"143": "News"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("HiddenChannels() = %v, want %v", got, want)
+	}
+	got, err := Channels()
+	if err != nil {
+		t.Fatalf("Channels() error = %v", err)
+	}
+	if len(got.Result) != 1 || got.Result[0].ID != "144" {
+		t.Errorf("Channels() = %v, want only channel 144", got.Result)
+	}
+
+	if err := SetChannelHidden("143", "", false); err != nil {
+		t.Fatalf("SetChannelHidden() error = %v", err)
+	}
+	if got, _ := Channels(); len(got.Result) != 2 {
+		t.Errorf("Channels() = %v, want both channels after showing 143", got.Result)
+	}
+}
+
+func TestInitHiddenChannelsInvalidFile(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("SetupTestPathPrefix failed: %v", err)
+	}
+	defer cleanup()
+	defer InitHiddenChannels()
+
+	if err := os.WriteFile(filepath.Join(utils.GetPathPrefix(), hiddenChannelsFile), []byte("[1, 2]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	InitHiddenChannels()
+	if hidden := HiddenChannels(); len(hidden) != 0 {
+		t.Errorf("HiddenChannels() = %v, want none for an invalid file", hidden)
+	}
+}
@@ -0,0 +1,244 @@
+package television
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Modes of ImportCustomChannels
+const (
+	// ImportMerge adds the imported channels whose ID is not in the custom channels file
+	ImportMerge = "merge"
+	// ImportReplace replaces the channels of the custom channels file with the imported channels
+	ImportReplace = "replace"
+)
+
+// Formats of custom channel imports and exports
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatM3U  = "m3u"
+)
+
+// ImportResult is the result of an import of custom channels
+type ImportResult struct {
+	// Added is the number of imported channels written to the file
+	Added int `json:"added"`
+	// Skipped is the number of imported channels whose ID is already in the file, when merging
+	Skipped int `json:"skipped"`
+	// Total is the number of channels in the file after the import
+	Total int `json:"total"`
+}
+
+// DetectImportFormat returns the format of an uploaded custom channels file: by the format given,
+// else by the extension of the file name, else by the content.
+func DetectImportFormat(data []byte, format, name string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case FormatJSON:
+		return FormatJSON, nil
+	case FormatYAML, "yml":
+		return FormatYAML, nil
+	case FormatM3U, "m3u8":
+		return FormatM3U, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unsupported format %q, use json, yaml or m3u", format)
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".yml", ".yaml":
+		return FormatYAML, nil
+	case ".m3u", ".m3u8":
+		return FormatM3U, nil
+	}
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("#EXTM3U")):
+		return FormatM3U, nil
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return FormatJSON, nil
+	}
+	return FormatYAML, nil
+}
+
+// ParseImport parses an uploaded custom channels file of the format. Channels of M3U playlists are
+// mapped to categories and languages like m3u_sources, and only HTTPS streams are kept. Channels of
+// JSON and YAML files are validated, and the channels with errors are left out.
+func ParseImport(data []byte, format string) ([]CustomChannel, []ChannelIssue, error) {
+	switch format {
+	case FormatM3U:
+		channels, err := ParseM3U(bytes.NewReader(data), config.M3USource{})
+		if err != nil {
+			return nil, nil, err
+		}
+		issues := validateChannels(channels, nil)
+		return validCustomChannels(channels, issues), issues, nil
+	case FormatJSON, FormatYAML:
+		name := "import.json"
+		if format == FormatYAML {
+			name = "import.yml"
+		}
+		customConfig, issues, err := ValidateCustomChannels(data, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		return validCustomChannels(customConfig.Channels, issues), issues, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported format %q, use json, yaml or m3u", format)
+}
+
+// ImportCustomChannels merges the channels into the custom channels file, or replaces its channels,
+// and reloads the custom channels. Channel numbers and groups of the file are kept. The previous
+// file is kept as a .bak file.
+func ImportCustomChannels(channels []CustomChannel, mode string) (ImportResult, error) {
+	filePath := config.Cfg.CustomChannelsFile
+	if filePath == "" {
+		return ImportResult{}, errors.New("custom_channels_file is not set in the config")
+	}
+	if mode != ImportMerge && mode != ImportReplace {
+		return ImportResult{}, fmt.Errorf("unsupported mode %q, use merge or replace", mode)
+	}
+
+	current, old, err := readCustomChannelsFile(filePath)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	var result ImportResult
+	if mode == ImportReplace {
+		current.Channels = channels
+		result.Added = len(channels)
+	} else {
+		ids := make(map[string]bool, len(current.Channels))
+		for _, channel := range current.Channels {
+			ids[normalizeCustomChannelID(channel.ID)] = true
+		}
+		for _, channel := range channels {
+			id := normalizeCustomChannelID(channel.ID)
+			if ids[id] {
+				result.Skipped++
+				continue
+			}
+			ids[id] = true
+			current.Channels = append(current.Channels, channel)
+			result.Added++
+		}
+	}
+	result.Total = len(current.Channels)
+	if result.Added == 0 && mode == ImportMerge {
+		return result, nil
+	}
+	if err := writeCustomChannelsFile(filePath, current, old); err != nil {
+		return ImportResult{}, err
+	}
+	return result, nil
+}
+
+// readCustomChannelsFile reads the custom channels file as it is, without validating the channels.
+// A missing file has no channels. The content is returned to back it up before the file is changed.
+func readCustomChannelsFile(filePath string) (CustomChannelsConfig, []byte, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return CustomChannelsConfig{}, nil, nil
+		}
+		return CustomChannelsConfig{}, nil, err
+	}
+	customConfig, err := detectAndParseFormat(data, filePath)
+	if err != nil {
+		return CustomChannelsConfig{}, nil, fmt.Errorf("the custom channels file cannot be read: %w", withLineContext(data, err))
+	}
+	return customConfig, data, nil
+}
+
+// writeCustomChannelsFile writes the custom channels file in the format of its extension, keeps
+// the previous content as a .bak file and reloads the custom channels.
+func writeCustomChannelsFile(filePath string, customConfig CustomChannelsConfig, old []byte) error {
+	format := FormatJSON
+	if ext := strings.ToLower(filepath.Ext(filePath)); ext == ".yml" || ext == ".yaml" {
+		format = FormatYAML
+	}
+	data, err := ExportCustomChannels(customConfig, format)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+	if old != nil {
+		if err := os.WriteFile(filePath+".bak", old, 0644); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return err
+	}
+	ReloadCustomChannels()
+	return nil
+}
+
+// CurrentCustomChannels returns the channels, numbers and groups of the custom channels file.
+func CurrentCustomChannels() (CustomChannelsConfig, error) {
+	return loadCustomChannelsConfig(config.Cfg.CustomChannelsFile)
+}
+
+// ExportCustomChannels writes custom channels as a JSON or YAML custom channels file, or as an M3U
+// playlist of their stream URLs.
+func ExportCustomChannels(customConfig CustomChannelsConfig, format string) ([]byte, error) {
+	if customConfig.Channels == nil {
+		customConfig.Channels = []CustomChannel{}
+	}
+	switch format {
+	case FormatJSON:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(customConfig); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case FormatYAML:
+		return yaml.Marshal(customConfig)
+	case FormatM3U:
+		var buf bytes.Buffer
+		buf.WriteString("#EXTM3U\n")
+		for _, channel := range customConfig.Channels {
+			group, language := channel.Group, ""
+			if group == "" && channel.Category != 0 {
+				group = CategoryMap[channel.Category]
+			}
+			if channel.Language != 0 {
+				language = LanguageMap[channel.Language]
+			}
+			fmt.Fprintf(&buf, "#EXTINF:-1 tvg-id=\"%s\" tvg-logo=\"%s\" group-title=\"%s\" tvg-language=\"%s\",%s\n%s\n",
+				m3uAttribute(channel.ID), m3uAttribute(channel.LogoURL), m3uAttribute(group),
+				m3uAttribute(language), channel.Name, channel.URL)
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unsupported format %q, use json, yaml or m3u", format)
+}
+
+// m3uAttribute removes the quotes that would end an M3U attribute value.
+func m3uAttribute(value string) string {
+	return strings.ReplaceAll(value, `"`, "'")
+}
+
+// normalizeCustomChannelID returns the ID of a custom channel in the channel list, with the cc_ prefix.
+func normalizeCustomChannelID(id string) string {
+	id = strings.TrimSpace(id)
+	if !strings.HasPrefix(id, "cc_") {
+		id = "cc_" + id
+	}
+	return id
+}
@@ -0,0 +1,191 @@
+package television
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+func TestDetectImportFormat(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		format     string
+		fileName   string
+		wantFormat string
+		wantErr    bool
+	}{
+		{name: "format given", data: "{}", format: "yml", wantFormat: FormatYAML},
+		{name: "format over extension", format: "m3u8", fileName: "channels.json", wantFormat: FormatM3U},
+		{name: "unsupported format", format: "xml", wantErr: true},
+		{name: "JSON extension", fileName: "channels.JSON", wantFormat: FormatJSON},
+		{name: "YAML extension", fileName: "channels.yaml", wantFormat: FormatYAML},
+		{name: "M3U content", data: "\n#EXTM3U\n", wantFormat: FormatM3U},
+		{name: "JSON content", data: ` {"channels": []}`, wantFormat: FormatJSON},
+		{name: "YAML content", data: "channels: []", fileName: "upload", wantFormat: FormatYAML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectImportFormat([]byte(tt.data), tt.format, tt.fileName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DetectImportFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.wantFormat {
+				t.Errorf("DetectImportFormat() = %q, want %q", got, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestParseImport(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		format     string
+		wantIDs    []string
+		wantIssues int
+		wantErr    bool
+	}{
+		{
+			name:   "M3U",
+			format: FormatM3U,
+			data: "#EXTM3U\n" +
+				"#EXTINF:-1 tvg-id=\"news\" group-title=\"News\" tvg-language=\"Hindi\",News\nhttps://example.com/news.m3u8\n" +
+				"#EXTINF:-1 tvg-id=\"plain\",Plain\nhttp://example.com/plain.m3u8\n",
+			wantIDs: []string{"news"},
+		},
+		{
+			name:       "JSON with an invalid channel",
+			format:     FormatJSON,
+			data:       `{"channels": [{"id": "a", "name": "A", "url": "https://example.com/a.m3u8"}, {"id": "b", "name": "B", "url": ""}]}`,
+			wantIDs:    []string{"a"},
+			wantIssues: 1,
+		},
+		{
+			name:    "YAML",
+			format:  FormatYAML,
+			data:    "channels:\n  - id: a\n    name: A\n    url: https://example.com/a.m3u8\n",
+			wantIDs: []string{"a"},
+		},
+		{name: "invalid JSON", format: FormatJSON, data: `{"channels": [}`, wantErr: true},
+		{name: "unsupported format", format: "xml", data: "<channels/>", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channels, issues, err := ParseImport([]byte(tt.data), tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseImport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var ids []string
+			for _, channel := range channels {
+				ids = append(ids, channel.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.wantIDs, ",") {
+				t.Errorf("ParseImport() channels = %v, want %v", ids, tt.wantIDs)
+			}
+			if len(issues) != tt.wantIssues {
+				t.Errorf("ParseImport() issues = %v, want %d", issues, tt.wantIssues)
+			}
+		})
+	}
+}
+
+func TestImportCustomChannels(t *testing.T) {
+	originalFile := config.Cfg.CustomChannelsFile
+	defer func() {
+		config.Cfg.CustomChannelsFile = originalFile
+		ReloadCustomChannels()
+	}()
+
+	current := `{"channels": [{"id": "a", "name": "A", "url": "https://example.com/a.m3u8"}], "channel_numbers": {"a": 101}}`
+	imported := []CustomChannel{
+		{ID: "cc_a", Name: "A Again", URL: "https://example.com/a2.m3u8"},
+		{ID: "b", Name: "B", URL: "https://example.com/b.m3u8"},
+	}
+
+	tests := []struct {
+		name       string
+		mode       string
+		fileName   string
+		wantResult ImportResult
+		wantIDs    []string
+		wantErr    bool
+	}{
+		{name: "merge", mode: ImportMerge, fileName: "custom-channels.json", wantResult: ImportResult{Added: 1, Skipped: 1, Total: 2}, wantIDs: []string{"a", "b"}},
+		{name: "replace", mode: ImportReplace, fileName: "custom-channels.yml", wantResult: ImportResult{Added: 2, Total: 2}, wantIDs: []string{"cc_a", "b"}},
+		{name: "unsupported mode", mode: "append", fileName: "custom-channels.json", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), tt.fileName)
+			data := []byte(current)
+			if tt.fileName == "custom-channels.yml" {
+				data = []byte("channels:\n  - id: a\n    name: A\n    url: https://example.com/a.m3u8\nchannel_numbers:\n  a: 101\n")
+			}
+			if err := os.WriteFile(filePath, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			config.Cfg.CustomChannelsFile = filePath
+
+			result, err := ImportCustomChannels(imported, tt.mode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ImportCustomChannels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result != tt.wantResult {
+				t.Errorf("ImportCustomChannels() = %+v, want %+v", result, tt.wantResult)
+			}
+
+			customConfig, err := CurrentCustomChannels()
+			if err != nil {
+				t.Fatalf("CurrentCustomChannels() error = %v", err)
+			}
+			var ids []string
+			for _, channel := range customConfig.Channels {
+				ids = append(ids, channel.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.wantIDs, ",") {
+				t.Errorf("channels = %v, want %v", ids, tt.wantIDs)
+			}
+			if customConfig.ChannelNumbers["a"] != 101 {
+				t.Errorf("channel_numbers = %v, want the number of a kept", customConfig.ChannelNumbers)
+			}
+			if backup, err := os.ReadFile(filePath + ".bak"); err != nil || string(backup) != string(data) {
+				t.Errorf("backup = %q, %v, want the previous file", backup, err)
+			}
+		})
+	}
+}
+
+func TestExportCustomChannels(t *testing.T) {
+	customConfig := CustomChannelsConfig{Channels: []CustomChannel{
+		{ID: "news", Name: "News", URL: "https://example.com/news.m3u8", LogoURL: "https://example.com/news.png", Category: 12, Language: 1},
+		{ID: "quote", Name: "Quote", URL: "https://example.com/quote.m3u8", Group: `My "Group"`},
+	}}
+
+	data, err := ExportCustomChannels(customConfig, FormatM3U)
+	if err != nil {
+		t.Fatalf("ExportCustomChannels() error = %v", err)
+	}
+	want := "#EXTM3U\n" +
+		"#EXTINF:-1 tvg-id=\"news\" tvg-logo=\"https://example.com/news.png\" group-title=\"News\" tvg-language=\"Hindi\",News\nhttps://example.com/news.m3u8\n" +
+		"#EXTINF:-1 tvg-id=\"quote\" tvg-logo=\"\" group-title=\"My 'Group'\" tvg-language=\"\",Quote\nhttps://example.com/quote.m3u8\n"
+	if string(data) != want {
+		t.Errorf("ExportCustomChannels() =\n%s\nwant\n%s", data, want)
+	}
+
+	// An exported M3U playlist imports the same channels
+	channels, _, err := ParseImport(data, FormatM3U)
+	if err != nil || len(channels) != 2 || channels[0].Category != 12 || channels[0].Language != 1 {
+		t.Errorf("ParseImport() of the export = %+v, %v", channels, err)
+	}
+
+	if _, err := ExportCustomChannels(customConfig, "xml"); err == nil {
+		t.Error("ExportCustomChannels() with an unsupported format should fail")
+	}
+}
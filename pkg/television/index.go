@@ -0,0 +1,142 @@
+package television
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+// ChannelIndex looks up channels by ID, language and category without scanning the channel list
+type ChannelIndex struct {
+	channels []Channel
+	// byID, byLanguage and byCategory hold positions in channels
+	byID       map[string]int
+	byLanguage map[int][]int
+	byCategory map[int][]int
+
+	// caps are the quality caps of the channels from capsFor, the channel rules they were evaluated for
+	caps    map[string]qualityCaps
+	capsFor []config.ChannelRule
+	capsMu  sync.Mutex
+}
+
+var (
+	// channelIndex is the index of the channels returned by Channels
+	channelIndex           *ChannelIndex
+	channelIndexBuiltAt    time.Time
+	channelIndexGeneration uint64
+	channelIndexMu         sync.Mutex
+)
+
+// NewChannelIndex indexes the channels. The first channel with an ID wins.
+func NewChannelIndex(channels []Channel) *ChannelIndex {
+	index := &ChannelIndex{
+		channels:   channels,
+		byID:       make(map[string]int, len(channels)),
+		byLanguage: make(map[int][]int),
+		byCategory: make(map[int][]int),
+	}
+	for i, channel := range channels {
+		if _, ok := index.byID[channel.ID]; !ok {
+			index.byID[channel.ID] = i
+		}
+		index.byLanguage[channel.Language] = append(index.byLanguage[channel.Language], i)
+		index.byCategory[channel.Category] = append(index.byCategory[channel.Category], i)
+	}
+	return index
+}
+
+// Len returns the number of indexed channels.
+func (index *ChannelIndex) Len() int {
+	return len(index.channels)
+}
+
+// Channel returns the channel with the ID.
+func (index *ChannelIndex) Channel(id string) (Channel, bool) {
+	i, ok := index.byID[id]
+	if !ok {
+		return Channel{}, false
+	}
+	return index.channels[i], true
+}
+
+// Filter returns the channels with the language and category like FilterChannels, in the order of the
+// indexed list. 0 matches any language or category.
+func (index *ChannelIndex) Filter(language, category int) []Channel {
+	var positions []int
+	switch {
+	case language != 0 && category != 0:
+		// Check the other field on the shorter list
+		byLanguage, byCategory := index.byLanguage[language], index.byCategory[category]
+		if len(byCategory) < len(byLanguage) {
+			for _, i := range byCategory {
+				if index.channels[i].Language == language {
+					positions = append(positions, i)
+				}
+			}
+		} else {
+			for _, i := range byLanguage {
+				if index.channels[i].Category == category {
+					positions = append(positions, i)
+				}
+			}
+		}
+	case language != 0:
+		positions = index.byLanguage[language]
+	case category != 0:
+		positions = index.byCategory[category]
+	default:
+		return append([]Channel(nil), index.channels...)
+	}
+
+	var channels []Channel
+	for _, i := range positions {
+		channels = append(channels, index.channels[i])
+	}
+	return channels
+}
+
+// ChannelsIndex returns the index of the channels returned by Channels. The index is built again when
+// the channel list is refreshed, the custom channels are reloaded or channels are hidden.
+func ChannelsIndex() (*ChannelIndex, error) {
+	ttl := channelsCacheTTL()
+	channelIndexMu.Lock()
+	index, builtAt, generation := channelIndex, channelIndexBuiltAt, channelIndexGeneration
+	channelIndexMu.Unlock()
+	// A stale index would not refresh the channel list in the background
+	if index != nil && ttl >= 0 && time.Since(builtAt) < ttl {
+		return index, nil
+	}
+
+	apiResponse, err := Channels()
+	if err != nil {
+		return nil, err
+	}
+	index = NewChannelIndex(apiResponse.Result)
+
+	channelIndexMu.Lock()
+	// The channels changed while the index was built, so it is built again next time
+	if ttl >= 0 && generation == channelIndexGeneration {
+		channelIndex, channelIndexBuiltAt = index, time.Now()
+	}
+	channelIndexMu.Unlock()
+	return index, nil
+}
+
+// ChannelByID returns the channel with the ID among the JioTV and custom channels.
+func ChannelByID(id string) (Channel, bool) {
+	index, err := ChannelsIndex()
+	if err != nil {
+		return Channel{}, false
+	}
+	return index.Channel(id)
+}
+
+// invalidateChannelIndex drops the index of the channels after they changed.
+func invalidateChannelIndex() {
+	channelIndexMu.Lock()
+	channelIndex = nil
+	channelIndexGeneration++
+	channelIndexMu.Unlock()
+}
@@ -0,0 +1,63 @@
+package television
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChannelIndex(t *testing.T) {
+	channels := []Channel{
+		{ID: "1", Name: "Hindi News", Language: 1, Category: 12},
+		{ID: "2", Name: "English News", Language: 6, Category: 12},
+		{ID: "3", Name: "Hindi Movies", Language: 1, Category: 6},
+		{ID: "1", Name: "Duplicate", Language: 6, Category: 6},
+	}
+	index := NewChannelIndex(channels)
+
+	if got, ok := index.Channel("1"); !ok || got.Name != "Hindi News" {
+		t.Errorf("Channel(1) = %+v, %v, want the first channel with the ID", got, ok)
+	}
+	if _, ok := index.Channel("4"); ok {
+		t.Error("Channel(4) found a channel that is not indexed")
+	}
+
+	tests := []struct {
+		name     string
+		language int
+		category int
+	}{
+		{name: "all channels"},
+		{name: "by language", language: 1},
+		{name: "by category", category: 6},
+		{name: "by language and category", language: 6, category: 6},
+		{name: "no match", language: 6, category: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, want := index.Filter(tt.language, tt.category), FilterChannels(channels, tt.language, tt.category)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Filter(%d, %d) = %+v, want %+v", tt.language, tt.category, got, want)
+			}
+		})
+	}
+}
+
+func TestChannelsIndexRefresh(t *testing.T) {
+	resetChannelsCache()
+	defer resetChannelsCache()
+
+	setCachedChannels(ChannelsResponse{Code: 200, Result: []Channel{{ID: "143", Name: "News"}}}, time.Now())
+	if got, ok := ChannelByID("143"); !ok || got.Name != "News" {
+		t.Fatalf("ChannelByID(143) = %+v, %v, want News", got, ok)
+	}
+
+	// A refreshed channel list is indexed again
+	setCachedChannels(ChannelsResponse{Code: 200, Result: []Channel{{ID: "143", Name: "News HD"}, {ID: "144", Name: "Sports"}}}, time.Now())
+	if got, ok := ChannelByID("143"); !ok || got.Name != "News HD" {
+		t.Errorf("ChannelByID(143) = %+v, %v, want News HD", got, ok)
+	}
+	if _, ok := ChannelByID("144"); !ok {
+		t.Error("ChannelByID(144) did not find the new channel")
+	}
+}
@@ -0,0 +1,207 @@
+package television
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants/headers"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// linkCheckTimeout is how long a custom channel URL may take to respond
+	linkCheckTimeout = 10 * time.Second
+	// linkCheckWorkers is how many custom channel URLs are checked at the same time
+	linkCheckWorkers = 8
+	// linkCheckMaxBody is the most of a response read by a check. Longer responses, like
+	// continuous streams, count as alive.
+	linkCheckMaxBody = 1 << 20
+)
+
+// ErrChannelCheckRunning is returned when a custom channels check is started while one is running
+var ErrChannelCheckRunning = errors.New("a custom channels check is already running")
+
+// ChannelCheck is the result of the check of the stream URL of a custom channel
+type ChannelCheck struct {
+	ID     string `json:"channel_id"`
+	Name   string `json:"channel_name"`
+	URL    string `json:"url"`
+	Alive  bool   `json:"alive"`
+	Status int    `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ChannelCheckReport is the result of a check of all custom channels
+type ChannelCheckReport struct {
+	Time    time.Time `json:"time"`
+	Checked int       `json:"checked"`
+	Dead    int       `json:"dead"`
+	// Hidden reports whether dead channels are removed from the channel list
+	Hidden   bool           `json:"hidden"`
+	Channels []ChannelCheck `json:"channels"`
+}
+
+var (
+	// channelCheckReport holds the result of the last check, deadChannels its dead channel IDs
+	channelCheckReport ChannelCheckReport
+	deadChannels       map[string]bool
+	channelCheckMu     sync.RWMutex
+	// channelCheckRunning is held while a check runs
+	channelCheckRunning sync.Mutex
+
+	// probeChannelURL checks a stream URL, replaced in tests
+	probeChannelURL = probeURL
+)
+
+// CheckCustomChannels checks the stream URLs of the custom channels with a HEAD request, or a
+// short GET request for servers that do not allow HEAD, and keeps the result. Channels whose URL
+// does not respond, or responds with an error status, are dead.
+func CheckCustomChannels(ctx context.Context) (ChannelCheckReport, error) {
+	if !channelCheckRunning.TryLock() {
+		return ChannelCheckReport{}, ErrChannelCheckRunning
+	}
+	defer channelCheckRunning.Unlock()
+
+	var channels []Channel
+	for _, channel := range getCustomChannels() {
+		if strings.HasPrefix(channel.URL, "http://") || strings.HasPrefix(channel.URL, "https://") {
+			channels = append(channels, channel)
+		}
+	}
+
+	client := utils.GetRequestClient()
+	client.MaxResponseBodySize = linkCheckMaxBody
+	results := make([]ChannelCheck, len(channels))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(linkCheckWorkers, len(channels)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				channel := channels[i]
+				result := ChannelCheck{ID: channel.ID, Name: channel.Name, URL: channel.URL}
+				status, err := probeChannelURL(client, channel.URL)
+				result.Status = status
+				if err != nil {
+					result.Error = err.Error()
+				} else {
+					result.Alive = true
+				}
+				results[i] = result
+			}
+		}()
+	}
+	for i := range channels {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return ChannelCheckReport{}, err
+	}
+
+	report := newChannelCheckReport(results, time.Now())
+	dead := make(map[string]bool, report.Dead)
+	for _, result := range report.Channels {
+		if !result.Alive {
+			dead[result.ID] = true
+		}
+	}
+	channelCheckMu.Lock()
+	channelCheckReport = report
+	deadChannels = dead
+	channelCheckMu.Unlock()
+	invalidateChannelIndex()
+
+	utils.SafeLogf("Custom channels check: %d of %d channels are dead", report.Dead, report.Checked)
+	return report, nil
+}
+
+// newChannelCheckReport sums up the results, dead channels first and then by channel ID.
+func newChannelCheckReport(results []ChannelCheck, at time.Time) ChannelCheckReport {
+	report := ChannelCheckReport{Time: at, Checked: len(results), Hidden: config.Cfg.HideDeadChannels, Channels: results}
+	for _, result := range results {
+		if !result.Alive {
+			report.Dead++
+		}
+	}
+	sort.Slice(report.Channels, func(i, j int) bool {
+		a, b := report.Channels[i], report.Channels[j]
+		if a.Alive != b.Alive {
+			return !a.Alive
+		}
+		return a.ID < b.ID
+	})
+	return report
+}
+
+// GetChannelCheckReport returns the result of the last custom channels check, if one has run.
+func GetChannelCheckReport() (ChannelCheckReport, bool) {
+	channelCheckMu.RLock()
+	defer channelCheckMu.RUnlock()
+	report := channelCheckReport
+	report.Hidden = config.Cfg.HideDeadChannels
+	return report, !report.Time.IsZero()
+}
+
+// IsDeadChannel reports whether the last check found the custom channel dead.
+func IsDeadChannel(channelID string) bool {
+	channelCheckMu.RLock()
+	defer channelCheckMu.RUnlock()
+	return deadChannels[channelID]
+}
+
+// probeURL requests url with HEAD, and with a GET request for the first bytes if HEAD fails,
+// as some servers do not allow HEAD. It returns the status and an error if the URL is dead.
+func probeURL(client *fasthttp.Client, url string) (int, error) {
+	status, err := probeURLWithMethod(client, url, fasthttp.MethodHead)
+	if err == nil && status < fasthttp.StatusBadRequest {
+		return status, nil
+	}
+	status, err = probeURLWithMethod(client, url, fasthttp.MethodGet)
+	if err != nil {
+		return status, err
+	}
+	if status >= fasthttp.StatusBadRequest {
+		return status, fmt.Errorf("status %d", status)
+	}
+	return status, nil
+}
+
+// probeURLWithMethod requests url and returns the status of the response.
+func probeURLWithMethod(client *fasthttp.Client, url, method string) (int, error) {
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(url)
+	req.Header.SetMethod(method)
+	req.Header.SetUserAgent(headers.UserAgentOkHttp)
+	if method == fasthttp.MethodGet {
+		req.Header.Set("Range", "bytes=0-1023")
+	}
+	err := client.DoTimeout(req, resp, linkCheckTimeout)
+	if errors.Is(err, fasthttp.ErrBodyTooLarge) {
+		// The server sent the headers and keeps streaming, e.g. a continuous MPEG-TS stream
+		return resp.StatusCode(), nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return resp.StatusCode(), nil
+}
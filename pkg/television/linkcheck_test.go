@@ -0,0 +1,111 @@
+package television
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/valyala/fasthttp"
+)
+
+// newLinkCheckServer serves a live stream on /live.m3u8, a stream that rejects HEAD requests
+// on /nohead.m3u8 and 404 on other paths.
+func newLinkCheckServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/live.m3u8":
+			w.Write([]byte("#EXTM3U\n"))
+		case r.URL.Path == "/nohead.m3u8" && r.Method == http.MethodHead:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case r.URL.Path == "/nohead.m3u8":
+			if r.Header.Get("Range") == "" {
+				t.Error("GET request without a Range header")
+			}
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write([]byte("#EXTM3U\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProbeURL(t *testing.T) {
+	server := newLinkCheckServer(t)
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name       string
+		url        string
+		wantStatus int
+		wantErr    bool
+	}{
+		{"alive", server.URL + "/live.m3u8", fasthttp.StatusOK, false},
+		{"HEAD not allowed", server.URL + "/nohead.m3u8", fasthttp.StatusPartialContent, false},
+		{"not found", server.URL + "/gone.m3u8", fasthttp.StatusNotFound, true},
+		{"connection refused", closed.URL + "/live.m3u8", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := probeURL(&fasthttp.Client{}, tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("probeURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("probeURL() status = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCheckCustomChannels(t *testing.T) {
+	server := newLinkCheckServer(t)
+	originalHide := config.Cfg.HideDeadChannels
+	customChannelsMu.RLock()
+	originalChannels := customChannelsCacheList
+	customChannelsMu.RUnlock()
+	setCustomChannels([]Channel{
+		{ID: "cc_live", Name: "Live", URL: server.URL + "/live.m3u8"},
+		{ID: "cc_nohead", Name: "No HEAD", URL: server.URL + "/nohead.m3u8"},
+		{ID: "cc_gone", Name: "Gone", URL: server.URL + "/gone.m3u8"},
+		{ID: "cc_rtmp", Name: "RTMP", URL: "rtmp://example.com/live"},
+	})
+	defer func() {
+		config.Cfg.HideDeadChannels = originalHide
+		setCustomChannels(originalChannels)
+		channelCheckMu.Lock()
+		channelCheckReport, deadChannels = ChannelCheckReport{}, nil
+		channelCheckMu.Unlock()
+	}()
+
+	report, err := CheckCustomChannels(context.Background())
+	if err != nil {
+		t.Fatalf("CheckCustomChannels() error = %v", err)
+	}
+	if report.Checked != 3 || report.Dead != 1 {
+		t.Errorf("Checked = %d, Dead = %d, want 3 and 1", report.Checked, report.Dead)
+	}
+	if len(report.Channels) != 3 || report.Channels[0].ID != "cc_gone" || report.Channels[0].Alive {
+		t.Errorf("Channels = %+v, want the dead channel cc_gone first", report.Channels)
+	}
+	if !IsDeadChannel("cc_gone") || IsDeadChannel("cc_live") || IsDeadChannel("cc_rtmp") {
+		t.Error("IsDeadChannel() does not match the check")
+	}
+	if saved, ok := GetChannelCheckReport(); !ok || saved.Dead != 1 {
+		t.Errorf("GetChannelCheckReport() = %+v, %v, want the report", saved, ok)
+	}
+
+	countChannels := func() int { return len(getCustomChannels()) }
+	config.Cfg.HideDeadChannels = false
+	if got := countChannels(); got != 4 {
+		t.Errorf("getCustomChannels() returned %d channels, want 4", got)
+	}
+	config.Cfg.HideDeadChannels = true
+	if got := countChannels(); got != 3 {
+		t.Errorf("getCustomChannels() with hide_dead_channels returned %d channels, want 3", got)
+	}
+}
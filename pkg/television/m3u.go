@@ -0,0 +1,183 @@
+package television
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+// ParseM3U returns the channels of an M3U playlist with HTTPS stream URLs, mapped by the rules of the source.
+func ParseM3U(r io.Reader, source config.M3USource) ([]CustomChannel, error) {
+
+	var channels []CustomChannel
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var currentChannel CustomChannel
+	isInfoLine := false
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#EXTINF:") {
+			isInfoLine = true
+			currentChannel = CustomChannel{}
+			// Parse metadata
+			// Example: #EXTINF:-1 tvg-id="Sony_HD" tvg-logo="http://..." group-title="Entertainment",Sony HD
+
+			// Extract Name (after last comma)
+			lastCommaIdx := strings.LastIndex(line, ",")
+			if lastCommaIdx != -1 {
+				currentChannel.Name = strings.TrimSpace(line[lastCommaIdx+1:])
+			}
+
+			// Extract Logo
+			currentChannel.LogoURL = extractAttribute(line, "tvg-logo")
+
+			// Extract ID
+			id := extractAttribute(line, "tvg-id")
+			if id == "" {
+				// Generate a random ID or use Name
+				id = strings.ReplaceAll(strings.ToLower(currentChannel.Name), " ", "_")
+			}
+			currentChannel.ID = source.Prefix + id
+
+			// Map Category (simple mapping or default)
+			// group-title="Entertainment"
+			groupTitle := extractAttribute(line, "group-title")
+			currentChannel.Category = m3uCategory(source, groupTitle)
+
+			// Set defaults
+			currentChannel.Language = m3uLanguage(source, extractAttribute(line, "tvg-language"))
+			currentChannel.IsHD = strings.Contains(strings.ToUpper(currentChannel.Name), "HD")
+
+		} else if strings.HasPrefix(line, "#") && isInfoLine {
+			continue
+		} else if !strings.HasPrefix(line, "#") && isInfoLine {
+			// This is the URL line
+			currentChannel.URL = line
+			if strings.HasPrefix(strings.ToLower(currentChannel.URL), "https://") {
+				channels = append(channels, currentChannel)
+			}
+			isInfoLine = false
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return channels, nil
+}
+
+func extractAttribute(line, key string) string {
+	keyStr := key + "=\""
+	start := strings.Index(line, keyStr)
+	if start == -1 {
+		return ""
+	}
+	start += len(keyStr)
+	end := strings.Index(line[start:], "\"")
+	if end == -1 {
+		return ""
+	}
+	return line[start : start+end]
+}
+
+func mapCategory(group string) int {
+	// Simple mapping based on known categories in pkg/television/types.go
+	// 5: "Entertainment", 6: "Movies", 7: "Kids", 8: "Sports",
+	group = strings.ToLower(group)
+	if strings.Contains(group, "entertainment") {
+		return 5
+	}
+	if strings.Contains(group, "movie") {
+		return 6
+	}
+	if strings.Contains(group, "kid") {
+		return 7
+	}
+	if strings.Contains(group, "sport") {
+		return 8
+	}
+	if strings.Contains(group, "news") {
+		return 12 // Assuming 12 is News, check types.go later if needed, but 12 is common
+	}
+	// Default
+	return 0 // All Categories
+}
+
+// m3uCategory returns the category ID of a group title: the one mapped by the source,
+// else the built-in mapping, else the default category of the source.
+func m3uCategory(source config.M3USource, group string) int {
+	for title, id := range source.Categories {
+		if strings.EqualFold(strings.TrimSpace(title), strings.TrimSpace(group)) {
+			return id
+		}
+	}
+	if id := mapCategory(group); id != 0 {
+		return id
+	}
+	return source.Category
+}
+
+// m3uLanguage returns the language ID of a tvg-language value: the one mapped by the source,
+// else the built-in mapping. Channels without a language get the default language of the source.
+func m3uLanguage(source config.M3USource, lang string) int {
+	for value, id := range source.Languages {
+		if strings.EqualFold(strings.TrimSpace(value), strings.TrimSpace(lang)) {
+			return id
+		}
+	}
+	if strings.TrimSpace(lang) == "" {
+		return source.Language
+	}
+	return mapLanguage(lang)
+}
+
+func mapLanguage(lang string) int {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	switch lang {
+	case "hindi":
+		return 1
+	case "marathi":
+		return 2
+	case "punjabi":
+		return 3
+	case "urdu":
+		return 4
+	case "bengali":
+		return 5
+	case "english":
+		return 6
+	case "malayalam":
+		return 7
+	case "tamil":
+		return 8
+	case "gujarati":
+		return 9
+	case "odia", "oriya":
+		return 10
+	case "telugu":
+		return 11
+	case "bhojpuri":
+		return 12
+	case "kannada":
+		return 13
+	case "assamese":
+		return 14
+	case "nepali":
+		return 15
+	case "french":
+		return 16
+	case "":
+		return 0
+	default:
+		return 18
+	}
+}
@@ -0,0 +1,42 @@
+package television
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+const testM3U = `#EXTM3U
+#EXTINF:-1 tvg-id="sports1" tvg-logo="https://logo/1.png" group-title="Cricket" tvg-language="Hin",Sports One HD
+https://example.com/sports1.m3u8
+#EXTINF:-1 tvg-id="news1" group-title="News",News One
+https://example.com/news1.m3u8
+#EXTINF:-1 group-title="Misc",Plain Channel
+https://example.com/plain.m3u8
+#EXTINF:-1 tvg-id="insecure" group-title="Misc",Insecure
+http://example.com/insecure.m3u8
+`
+
+func TestParseM3U(t *testing.T) {
+	source := config.M3USource{
+		Prefix:     "src_",
+		Categories: map[string]int{"cricket": 8},
+		Languages:  map[string]int{"hin": 1},
+		Category:   5,
+		Language:   6,
+	}
+	channels, err := ParseM3U(strings.NewReader(testM3U), source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []CustomChannel{
+		{ID: "src_sports1", Name: "Sports One HD", URL: "https://example.com/sports1.m3u8", LogoURL: "https://logo/1.png", Category: 8, Language: 1, IsHD: true},
+		{ID: "src_news1", Name: "News One", URL: "https://example.com/news1.m3u8", Category: 12, Language: 6},
+		{ID: "src_plain_channel", Name: "Plain Channel", URL: "https://example.com/plain.m3u8", Category: 5, Language: 6},
+	}
+	if !reflect.DeepEqual(channels, want) {
+		t.Errorf("ParseM3U() =\n%+v\nwant\n%+v", channels, want)
+	}
+}
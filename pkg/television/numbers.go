@@ -0,0 +1,85 @@
+package television
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ChannelNumbersAuto numbers all channels in list order, keeping the numbers set in the custom channels file
+const ChannelNumbersAuto = "auto"
+
+var (
+	// channelNumbers holds the channel numbers set by channel ID in the custom channels file
+	channelNumbers   map[string]int
+	channelNumbersMu sync.RWMutex
+)
+
+// setChannelNumbers replaces the channel numbers set by channel ID.
+func setChannelNumbers(numbers map[string]int) {
+	channelNumbersMu.Lock()
+	channelNumbers = numbers
+	channelNumbersMu.Unlock()
+}
+
+// NumberChannels assigns channel numbers and sorts the channels by number.
+// Numbers come from the custom channels file, and with mode "auto" the other channels are numbered
+// in list order with the numbers that are left. A number that is already taken is dropped, and
+// channels without a number are listed after the numbered ones in their original order.
+func NumberChannels(channels []Channel, mode string) []Channel {
+	channelNumbersMu.RLock()
+	numbers := channelNumbers
+	channelNumbersMu.RUnlock()
+
+	auto := strings.EqualFold(strings.TrimSpace(mode), ChannelNumbersAuto)
+	if len(numbers) == 0 && !auto && !hasChannelNumbers(channels) {
+		return channels
+	}
+
+	result := make([]Channel, len(channels))
+	copy(result, channels)
+	taken := make(map[int]bool)
+	for i := range result {
+		if number, ok := numbers[result[i].ID]; ok {
+			result[i].Number = number
+		}
+		if result[i].Number <= 0 || taken[result[i].Number] {
+			result[i].Number = 0
+			continue
+		}
+		taken[result[i].Number] = true
+	}
+
+	if auto {
+		next := 1
+		for i := range result {
+			if result[i].Number != 0 {
+				continue
+			}
+			for taken[next] {
+				next++
+			}
+			result[i].Number = next
+			taken[next] = true
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i].Number, result[j].Number
+		if a == 0 || b == 0 {
+			return a != 0 && b == 0
+		}
+		return a < b
+	})
+	return result
+}
+
+// hasChannelNumbers reports whether any channel has a number.
+func hasChannelNumbers(channels []Channel) bool {
+	for _, channel := range channels {
+		if channel.Number != 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package television
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNumberChannels(t *testing.T) {
+	defer setChannelNumbers(nil)
+
+	channels := []Channel{
+		{ID: "143", Name: "Aaj Tak"},
+		{ID: "144", Name: "Star Sports 1 HD"},
+		{ID: "cc_news", Name: "My News", IsCustom: true, Number: 7},
+		{ID: "145", Name: "Colors"},
+	}
+
+	tests := []struct {
+		name    string
+		numbers map[string]int
+		mode    string
+		wantIDs []string
+		wantNos []int
+	}{
+		{
+			name:    "Only custom channel numbers",
+			wantIDs: []string{"cc_news", "143", "144", "145"},
+			wantNos: []int{7, 0, 0, 0},
+		},
+		{
+			name:    "Numbers by channel ID",
+			numbers: map[string]int{"145": 3, "144": 101},
+			wantIDs: []string{"145", "cc_news", "144", "143"},
+			wantNos: []int{3, 7, 101, 0},
+		},
+		{
+			name:    "Auto numbers skip taken numbers",
+			numbers: map[string]int{"144": 2},
+			mode:    "auto",
+			wantIDs: []string{"143", "144", "145", "cc_news"},
+			wantNos: []int{1, 2, 3, 7},
+		},
+		{
+			name:    "Taken number is dropped",
+			numbers: map[string]int{"143": 7, "145": -1},
+			wantIDs: []string{"143", "144", "cc_news", "145"},
+			wantNos: []int{7, 0, 0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setChannelNumbers(tt.numbers)
+			result := NumberChannels(channels, tt.mode)
+			var ids []string
+			var numbers []int
+			for _, channel := range result {
+				ids = append(ids, channel.ID)
+				numbers = append(numbers, channel.Number)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) || !reflect.DeepEqual(numbers, tt.wantNos) {
+				t.Errorf("NumberChannels() = %v %v, want %v %v", ids, numbers, tt.wantIDs, tt.wantNos)
+			}
+		})
+	}
+
+	if channels[0].Number != 0 {
+		t.Error("NumberChannels() changed the given channels")
+	}
+}
+
+func TestNumberChannelsWithoutNumbers(t *testing.T) {
+	setChannelNumbers(nil)
+	channels := []Channel{{ID: "2"}, {ID: "1"}}
+	if result := NumberChannels(channels, ""); !reflect.DeepEqual(result, channels) {
+		t.Errorf("NumberChannels() = %v, want the channels unchanged", result)
+	}
+}
@@ -0,0 +1,57 @@
+package television
+
+import (
+	"sync/atomic"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// offline is set while JioTV Go runs from cached data because the JioTV API is unreachable
+var offline atomic.Bool
+
+// IsOffline reports whether JioTV Go is running in degraded mode from cached data.
+func IsOffline() bool {
+	return offline.Load()
+}
+
+// setOffline switches degraded mode on or off and logs the change.
+func setOffline(value bool) {
+	if offline.Swap(value) == value {
+		return
+	}
+	if value {
+		utils.SafeLogf("WARN: JioTV API is unreachable, running in offline mode from cached data")
+	} else {
+		utils.SafeLogf("JioTV API is reachable again, leaving offline mode")
+	}
+}
+
+// InitOfflineMode checks whether the JioTV API is reachable at startup when offline mode is enabled.
+// If it is not, the server keeps running from the last cached channel list instead of showing an empty UI.
+func InitOfflineMode() {
+	if !config.Cfg.OfflineMode {
+		return
+	}
+	channelsDiskOnce.Do(loadChannelsFromDisk)
+
+	if _, err := fetchChannels(); err != nil {
+		if _, _, ok := getCachedChannels(); !ok {
+			utils.SafeLogf("WARN: JioTV API is unreachable and no cached channel list exists: %v", err)
+		}
+	}
+}
+
+// offlineChannels returns the cached channel list after a failed fetch when offline mode is enabled.
+// Otherwise, or if there is no cached channel list, the fetch error is returned.
+func offlineChannels(err error) (ChannelsResponse, error) {
+	if !config.Cfg.OfflineMode {
+		return ChannelsResponse{}, err
+	}
+	cached, _, ok := getCachedChannels()
+	if !ok {
+		return ChannelsResponse{}, err
+	}
+	setOffline(true)
+	return withCustomChannels(cached), nil
+}
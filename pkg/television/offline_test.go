@@ -0,0 +1,54 @@
+package television
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+func TestOfflineChannels(t *testing.T) {
+	original := config.Cfg.OfflineMode
+	defer func() { config.Cfg.OfflineMode = original }()
+	defer offline.Store(false)
+
+	fetchErr := errors.New("unreachable")
+	cached := ChannelsResponse{Result: []Channel{{ID: "143", Name: "Cached"}}}
+
+	tests := []struct {
+		name        string
+		offlineMode bool
+		cached      bool
+		wantErr     bool
+		wantOffline bool
+	}{
+		{name: "disabled", offlineMode: false, cached: true, wantErr: true},
+		{name: "no cached channels", offlineMode: true, cached: false, wantErr: true},
+		{name: "serves cached channels", offlineMode: true, cached: true, wantOffline: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetChannelsCache()
+			defer resetChannelsCache()
+			offline.Store(false)
+			config.Cfg.OfflineMode = tt.offlineMode
+			if tt.cached {
+				lastChannels = &cached
+				lastChannelsFetchedAt = time.Now()
+			}
+
+			got, err := offlineChannels(fetchErr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("offlineChannels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (len(got.Result) != 1 || got.Result[0].ID != "143") {
+				t.Errorf("offlineChannels() = %v, want cached channels", got.Result)
+			}
+			if IsOffline() != tt.wantOffline {
+				t.Errorf("IsOffline() = %v, want %v", IsOffline(), tt.wantOffline)
+			}
+		})
+	}
+}
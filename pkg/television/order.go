@@ -0,0 +1,88 @@
+package television
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+// ErrInvalidChannelOrder is returned when a channel to reorder is not in the custom channels file
+// or is listed twice
+var ErrInvalidChannelOrder = errors.New("invalid channel order")
+
+// sortCustomChannels returns the custom channels sorted by their order. Channels without an order
+// follow the ordered ones in file order.
+func sortCustomChannels(channels []Channel) []Channel {
+	sorted := make([]Channel, len(channels))
+	copy(sorted, channels)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Order, sorted[j].Order
+		if a <= 0 || b <= 0 {
+			return a > 0 && b <= 0
+		}
+		return a < b
+	})
+	return sorted
+}
+
+// CustomChannelsInOrder returns all custom channels in their order, including dead channels.
+func CustomChannelsInOrder() []Channel {
+	customChannelsMu.RLock()
+	defer customChannelsMu.RUnlock()
+	return append([]Channel(nil), customChannelsCacheList...)
+}
+
+// SetCustomChannelOrder saves the order and the pinned channels in the custom channels file by
+// channel ID, with or without the cc_ prefix. Channels in order are numbered from 1 and the other
+// channels lose their order. A nil order or pinned list leaves the orders or pinned flags as they are.
+func SetCustomChannelOrder(order, pinned []string) error {
+	filePath := config.Cfg.CustomChannelsFile
+	if filePath == "" {
+		return errors.New("custom_channels_file is not set in the config")
+	}
+	customConfig, old, err := readCustomChannelsFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	index := make(map[string]int, len(customConfig.Channels))
+	for i, channel := range customConfig.Channels {
+		index[normalizeCustomChannelID(channel.ID)] = i
+	}
+	positions, err := customChannelPositions(order, index)
+	if err != nil {
+		return err
+	}
+	pins, err := customChannelPositions(pinned, index)
+	if err != nil {
+		return err
+	}
+
+	for i := range customConfig.Channels {
+		if order != nil {
+			customConfig.Channels[i].Order = positions[i]
+		}
+		if pinned != nil {
+			customConfig.Channels[i].Pinned = pins[i] > 0
+		}
+	}
+	return writeCustomChannelsFile(filePath, customConfig, old)
+}
+
+// customChannelPositions returns the position from 1 of each listed channel by its index in the file.
+func customChannelPositions(ids []string, index map[string]int) (map[int]int, error) {
+	positions := make(map[int]int, len(ids))
+	for _, id := range ids {
+		i, ok := index[normalizeCustomChannelID(id)]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not a custom channel", ErrInvalidChannelOrder, id)
+		}
+		if _, ok := positions[i]; ok {
+			return nil, fmt.Errorf("%w: %q is listed twice", ErrInvalidChannelOrder, id)
+		}
+		positions[i] = len(positions) + 1
+	}
+	return positions, nil
+}
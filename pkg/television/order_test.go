@@ -0,0 +1,99 @@
+package television
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+func channelIDs(channels []Channel) string {
+	ids := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		ids = append(ids, channel.ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestSortCustomChannels(t *testing.T) {
+	channels := []Channel{
+		{ID: "a"},
+		{ID: "b", Order: 2},
+		{ID: "c"},
+		{ID: "d", Order: 1},
+		{ID: "e", Order: -1},
+	}
+	if got, want := channelIDs(sortCustomChannels(channels)), "d,b,a,c,e"; got != want {
+		t.Errorf("sortCustomChannels() = %s, want %s", got, want)
+	}
+	if got := channelIDs(channels); got != "a,b,c,d,e" {
+		t.Errorf("sortCustomChannels() changed its argument to %s", got)
+	}
+}
+
+func TestSetCustomChannelOrder(t *testing.T) {
+	originalFile := config.Cfg.CustomChannelsFile
+	defer func() {
+		config.Cfg.CustomChannelsFile = originalFile
+		ReloadCustomChannels()
+	}()
+
+	config.Cfg.CustomChannelsFile = filepath.Join(t.TempDir(), "custom-channels.json")
+	data := `{"channels": [
+  {"id": "a", "name": "A", "url": "https://example.com/a.m3u8", "order": 1},
+  {"id": "b", "name": "B", "url": "https://example.com/b.m3u8"},
+  {"id": "c", "name": "C", "url": "https://example.com/c.m3u8", "pinned": true}
+]}`
+	if err := os.WriteFile(config.Cfg.CustomChannelsFile, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ReloadCustomChannels()
+	if got, want := channelIDs(CustomChannelsInOrder()), "cc_a,cc_b,cc_c"; got != want {
+		t.Fatalf("CustomChannelsInOrder() = %s, want %s", got, want)
+	}
+
+	tests := []struct {
+		name       string
+		order      []string
+		pinned     []string
+		wantIDs    string
+		wantPinned string
+		wantErr    bool
+	}{
+		{name: "reorder", order: []string{"c", "cc_b"}, wantIDs: "cc_c,cc_b,cc_a", wantPinned: "cc_c"},
+		{name: "pin and keep the order", pinned: []string{"a"}, wantIDs: "cc_c,cc_b,cc_a", wantPinned: "cc_a"},
+		{name: "clear the order", order: []string{}, pinned: []string{}, wantIDs: "cc_a,cc_b,cc_c"},
+		{name: "unknown channel", order: []string{"x"}, wantErr: true},
+		{name: "channel listed twice", pinned: []string{"a", "cc_a"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SetCustomChannelOrder(tt.order, tt.pinned)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidChannelOrder) {
+					t.Errorf("SetCustomChannelOrder() error = %v, want ErrInvalidChannelOrder", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetCustomChannelOrder() error = %v", err)
+			}
+			channels := CustomChannelsInOrder()
+			if got := channelIDs(channels); got != tt.wantIDs {
+				t.Errorf("CustomChannelsInOrder() = %s, want %s", got, tt.wantIDs)
+			}
+			var pinned []Channel
+			for _, channel := range channels {
+				if channel.Pinned {
+					pinned = append(pinned, channel)
+				}
+			}
+			if got := channelIDs(pinned); got != tt.wantPinned {
+				t.Errorf("pinned channels = %s, want %s", got, tt.wantPinned)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package television
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// ChannelOverride changes the metadata of a channel, like a JioTV channel, without changing the channel itself.
+// Empty and zero fields are left as they are.
+type ChannelOverride struct {
+	Name     string `json:"name" yaml:"name"`
+	LogoURL  string `json:"logo_url" yaml:"logo_url"`
+	Category int    `json:"category" yaml:"category"`
+	Language int    `json:"language" yaml:"language"`
+	// Hide removes the channel from the channel list, the playlist and the EPG
+	Hide bool `json:"hide" yaml:"hide"`
+}
+
+var (
+	// channelOverrides holds the overrides of the channel overrides file by channel ID
+	channelOverrides   map[string]ChannelOverride
+	channelOverridesMu sync.RWMutex
+)
+
+// LoadChannelOverrides reads a JSON or YAML file of overrides by channel ID,
+// e.g. {"143": {"name": "News18 India", "hide": false}}.
+func LoadChannelOverrides(filePath string) (map[string]ChannelOverride, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is valid YAML, so one decoder reads both formats
+	var overrides map[string]ChannelOverride
+	if err := yaml.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("invalid channel overrides file %s: %w", filePath, err)
+	}
+	return overrides, nil
+}
+
+// InitChannelOverrides loads the channel overrides file at startup if configured
+func InitChannelOverrides() {
+	var overrides map[string]ChannelOverride
+	if filePath := config.Cfg.ChannelOverridesFile; filePath != "" {
+		var err error
+		if overrides, err = LoadChannelOverrides(filePath); err != nil {
+			utils.SafeLogf("Error loading channel overrides: %v", err)
+		}
+	}
+	setChannelOverrides(overrides)
+}
+
+// setChannelOverrides replaces the overrides by channel ID.
+func setChannelOverrides(overrides map[string]ChannelOverride) {
+	channelOverridesMu.Lock()
+	channelOverrides = overrides
+	channelOverridesMu.Unlock()
+	invalidateChannelIndex()
+}
+
+// GetChannelOverride returns the override of the channel, if there is one.
+func GetChannelOverride(channelID string) (ChannelOverride, bool) {
+	channelOverridesMu.RLock()
+	defer channelOverridesMu.RUnlock()
+	override, ok := channelOverrides[channelID]
+	return override, ok
+}
+
+// applyChannelOverrides returns the channels with the overrides applied and hidden channels removed.
+func applyChannelOverrides(channels []Channel) []Channel {
+	channelOverridesMu.RLock()
+	overrides := channelOverrides
+	channelOverridesMu.RUnlock()
+	if len(overrides) == 0 {
+		return channels
+	}
+
+	result := make([]Channel, 0, len(channels))
+	for _, channel := range channels {
+		override, ok := overrides[channel.ID]
+		if !ok {
+			result = append(result, channel)
+			continue
+		}
+		if override.Hide {
+			continue
+		}
+		if name := strings.TrimSpace(override.Name); name != "" {
+			channel.Name = name
+		}
+		if logo := strings.TrimSpace(override.LogoURL); logo != "" {
+			channel.LogoURL = logo
+		}
+		if override.Category != 0 {
+			channel.Category = override.Category
+		}
+		if override.Language != 0 {
+			channel.Language = override.Language
+		}
+		result = append(result, channel)
+	}
+	return result
+}
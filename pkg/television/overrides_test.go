@@ -0,0 +1,71 @@
+package television
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadChannelOverrides(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+	}{
+		{"json", "overrides.json", `{"143": {"name": "News18 India", "language": 1}, "144": {"hide": true}}`},
+		{"yaml with unquoted ids", "overrides.yml", "143:\n  name: News18 India\n  language: 1\n144:\n  hide: true\n"},
+	}
+	want := map[string]ChannelOverride{
+		"143": {Name: "News18 India", Language: 1},
+		"144": {Hide: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(filePath, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadChannelOverrides(filePath)
+			if err != nil {
+				t.Fatalf("LoadChannelOverrides() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("LoadChannelOverrides() = %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	if _, err := LoadChannelOverrides(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadChannelOverrides() error = nil, want an error for a missing file")
+	}
+}
+
+func TestApplyChannelOverrides(t *testing.T) {
+	defer setChannelOverrides(nil)
+
+	channels := []Channel{
+		{ID: "143", Name: "News18", LogoURL: "news18.png", Category: 12, Language: 1},
+		{ID: "144", Name: "Hidden"},
+		{ID: "145", Name: "Sports", Category: 8},
+	}
+	if got := applyChannelOverrides(channels); !reflect.DeepEqual(got, channels) {
+		t.Errorf("applyChannelOverrides() = %+v, want the channels unchanged without overrides", got)
+	}
+
+	setChannelOverrides(map[string]ChannelOverride{
+		"143": {Name: " News18 India ", LogoURL: "https://example.com/logo.png", Language: 6},
+		"144": {Name: "Ignored", Hide: true},
+	})
+	got := applyChannelOverrides(channels)
+	want := []Channel{
+		{ID: "143", Name: "News18 India", LogoURL: "https://example.com/logo.png", Category: 12, Language: 6},
+		{ID: "145", Name: "Sports", Category: 8},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("applyChannelOverrides() = %+v, want %+v", got, want)
+	}
+	if channels[0].Name != "News18" {
+		t.Error("applyChannelOverrides() changed the given channels")
+	}
+}
@@ -0,0 +1,72 @@
+package television
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/hls"
+)
+
+// RewritePlaylist rewrites the URIs of an HLS playlist fetched from playlistURL, so that players fetch
+// playlists through /render.m3u8, segments through /render.ts and keys through /render.key.
+// params are appended to every upstream URL, and quality is passed on to the playlists.
+// Data that is not a playlist, e.g. an error page, is returned unchanged.
+func RewritePlaylist(playlist []byte, playlistURL, params, channelID, quality string) []byte {
+	parsed, err := hls.Parse(playlist)
+	if err != nil {
+		return playlist
+	}
+	base, err := url.Parse(playlistURL)
+	if err != nil {
+		return playlist
+	}
+	parsed.RewriteURIs(base, func(ref hls.Reference) (string, bool) {
+		var result []byte
+		var err error
+		switch ref.Kind {
+		case hls.PlaylistURI:
+			result, err = CreateEncryptedURL(EncryptedURLConfig{
+				Match:       ref.URL,
+				Params:      params,
+				ChannelID:   channelID,
+				EndpointURL: "/render.m3u8",
+				Quality:     quality,
+			})
+		case hls.SegmentURI:
+			result, err = segmentURI(ref.URL, params, channelID)
+		case hls.KeyURI:
+			// Keys of other DRM systems, like skd:// URIs, and inline data: keys are left to the player
+			if !strings.HasPrefix(ref.URL, "http://") && !strings.HasPrefix(ref.URL, "https://") {
+				return "", false
+			}
+			result = ReplaceKey([]byte(ref.URL), params, channelID)
+		}
+		if err != nil || result == nil {
+			return "", false
+		}
+		return string(result), true
+	})
+	return parsed.Bytes()
+}
+
+// segmentURI returns the URI a player fetches the segment at segmentURL from: /render.ts,
+// or the CDN directly if the TS handler is disabled.
+func segmentURI(segmentURL, params, channelID string) ([]byte, error) {
+	if config.Cfg.DisableTSHandler {
+		if params == "" {
+			return []byte(segmentURL), nil
+		}
+		sep := "?"
+		if strings.Contains(segmentURL, "?") {
+			sep = "&"
+		}
+		return []byte(segmentURL + sep + params), nil
+	}
+	return CreateEncryptedURL(EncryptedURLConfig{
+		Match:       segmentURL,
+		Params:      params,
+		ChannelID:   channelID,
+		EndpointURL: "/render.ts",
+	})
+}
@@ -0,0 +1,99 @@
+package television
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
+)
+
+// decodeRenderURL returns the endpoint and the upstream URL of a rewritten URI.
+func decodeRenderURL(t *testing.T, uri string) (string, string, url.Values) {
+	t.Helper()
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("invalid URI %q: %v", uri, err)
+	}
+	upstream, err := secureurl.DecryptURL(parsed.Query().Get("auth"))
+	if err != nil {
+		t.Fatalf("DecryptURL(%q) error = %v", uri, err)
+	}
+	return parsed.Path, upstream, parsed.Query()
+}
+
+func TestRewritePlaylist(t *testing.T) {
+	setupTest() // Initialize necessary components
+	playlistURL := "https://jiotvmblive.cdn.jio.com/bpk-tv/Colors_HD/output/index.m3u8?hdnea=abc"
+
+	master := `#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aud",NAME="English",URI="https://other.cdn.jio.com/audio/eng.m3u8"
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME="English",LANGUAGE="en",URI="subs/eng.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=800000,AUDIO="aud",SUBTITLES="subs"
+Colors_HD-800.m3u8
+`
+	lines := strings.Split(string(RewritePlaylist([]byte(master), playlistURL, "hdnea=abc", "143", "abr")), "\n")
+	wantPlaylists := map[int]string{
+		1: "https://other.cdn.jio.com/audio/eng.m3u8?hdnea=abc",
+		2: "https://jiotvmblive.cdn.jio.com/bpk-tv/Colors_HD/output/subs/eng.m3u8?hdnea=abc",
+		4: "https://jiotvmblive.cdn.jio.com/bpk-tv/Colors_HD/output/Colors_HD-800.m3u8?hdnea=abc",
+	}
+	for i, want := range wantPlaylists {
+		uri := lines[i]
+		if start := strings.Index(uri, `URI="`); start >= 0 {
+			uri = uri[start+len(`URI="`) : len(uri)-1]
+		}
+		path, upstream, query := decodeRenderURL(t, uri)
+		if path != "/render.m3u8" || upstream != want || query.Get("channel_key_id") != "143" || query.Get("q") != "abr" {
+			t.Errorf("line %d = %s points to %s %s, want /render.m3u8 %s", i, lines[i], path, upstream, want)
+		}
+	}
+	if lines[3] != `#EXT-X-STREAM-INF:BANDWIDTH=800000,AUDIO="aud",SUBTITLES="subs"` {
+		t.Errorf("stream info = %s, want it unchanged", lines[3])
+	}
+
+	media := `#EXTM3U
+#EXT-X-TARGETDURATION:6
+#EXT-X-KEY:METHOD=AES-128,URI="https://tv.media.jio.com/streams_live/Colors_HD/100.key",IV=0x1
+#EXTINF:6.0,
+Colors_HD-100.ts
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="skd://colors-hd"
+#EXTINF:6.0,
+https://other.cdn.jio.com/Colors_HD-101.aac?t=1
+#EXTINF:6.0,
+eng-1.vtt
+`
+	lines = strings.Split(string(RewritePlaylist([]byte(media), playlistURL, "hdnea=abc", "143", "")), "\n")
+	key := strings.TrimSuffix(strings.TrimPrefix(lines[2], `#EXT-X-KEY:METHOD=AES-128,URI="`), `",IV=0x1`)
+	if path, upstream, _ := decodeRenderURL(t, key); path != "/render.key" || upstream != "https://tv.media.jio.com/streams_live/Colors_HD/100.key?hdnea=abc" {
+		t.Errorf("key = %s points to %s %s", lines[2], path, upstream)
+	}
+	if lines[5] != `#EXT-X-KEY:METHOD=SAMPLE-AES,URI="skd://colors-hd"` {
+		t.Errorf("FairPlay key = %s, want it unchanged", lines[5])
+	}
+	wantSegments := map[int]string{
+		4: "https://jiotvmblive.cdn.jio.com/bpk-tv/Colors_HD/output/Colors_HD-100.ts?hdnea=abc",
+		7: "https://other.cdn.jio.com/Colors_HD-101.aac?t=1&hdnea=abc",
+		9: "https://jiotvmblive.cdn.jio.com/bpk-tv/Colors_HD/output/eng-1.vtt?hdnea=abc",
+	}
+	for i, want := range wantSegments {
+		if path, upstream, _ := decodeRenderURL(t, lines[i]); path != "/render.ts" || upstream != want {
+			t.Errorf("line %d = %s points to %s %s, want /render.ts %s", i, lines[i], path, upstream, want)
+		}
+	}
+
+	// Segments are fetched from the CDN directly without the TS handler
+	original := config.Cfg.DisableTSHandler
+	config.Cfg.DisableTSHandler = true
+	defer func() { config.Cfg.DisableTSHandler = original }()
+	lines = strings.Split(string(RewritePlaylist([]byte(media), playlistURL, "hdnea=abc", "143", "")), "\n")
+	if lines[4] != "https://jiotvmblive.cdn.jio.com/bpk-tv/Colors_HD/output/Colors_HD-100.ts?hdnea=abc" {
+		t.Errorf("segment without TS handler = %s", lines[4])
+	}
+
+	errorPage := []byte("<html>403 Forbidden</html>")
+	if got := RewritePlaylist(errorPage, playlistURL, "", "143", ""); string(got) != string(errorPage) {
+		t.Errorf("RewritePlaylist() of an error page = %s, want it unchanged", got)
+	}
+}
@@ -0,0 +1,267 @@
+package television
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// ProviderJioTV identifies channels served by the JioTV API
+	ProviderJioTV = "jiotv"
+	// ProviderCustom identifies channels loaded from the custom channels file
+	ProviderCustom = "custom"
+	// ProviderZee5 identifies channels served by the zee5 plugin
+	ProviderZee5 = "zee5"
+)
+
+// qualityRank orders quality levels from lowest to highest.
+// "auto" is adaptive and may switch to the highest level.
+var qualityRank = map[string]int{
+	"low":    1,
+	"medium": 2,
+	"high":   3,
+	"auto":   4,
+}
+
+// qualityCaps are the quality caps of a channel
+type qualityCaps struct {
+	live    string
+	catchup string
+}
+
+var (
+	// compiledRules are the compiled rules of compiledRulesFor, so that the rules of the config
+	// are compiled once instead of on every request
+	compiledRules    []compiledChannelRule
+	compiledRulesFor []config.ChannelRule
+	compiledRulesMu  sync.Mutex
+)
+
+// compiledChannelRule is a ChannelRule with its patterns compiled
+type compiledChannelRule struct {
+	rule     config.ChannelRule
+	name     *regexp.Regexp
+	category *regexp.Regexp
+	provider *regexp.Regexp
+}
+
+// ChannelProvider returns the provider of a channel: jiotv, custom or zee5.
+func ChannelProvider(channel Channel) string {
+	if !channel.IsCustom {
+		return ProviderJioTV
+	}
+	if strings.HasPrefix(channel.URL, "zee5/") {
+		return ProviderZee5
+	}
+	return ProviderCustom
+}
+
+// compilePattern compiles a case-insensitive pattern, returning nil for an empty pattern.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return nil, nil
+	}
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// compileChannelRule compiles the patterns of a single rule.
+func compileChannelRule(rule config.ChannelRule) (compiledChannelRule, error) {
+	compiled := compiledChannelRule{rule: rule}
+	var err error
+	if compiled.name, err = compilePattern(rule.MatchName); err != nil {
+		return compiled, err
+	}
+	if compiled.category, err = compilePattern(rule.MatchCategory); err != nil {
+		return compiled, err
+	}
+	if compiled.provider, err = compilePattern(rule.MatchProvider); err != nil {
+		return compiled, err
+	}
+	return compiled, nil
+}
+
+// compileChannelRules compiles the given rules, skipping rules with invalid patterns.
+func compileChannelRules(rules []config.ChannelRule) []compiledChannelRule {
+	compiled := make([]compiledChannelRule, 0, len(rules))
+	for i, rule := range rules {
+		compiledRule, err := compileChannelRule(rule)
+		if err != nil {
+			utils.SafeLogf("WARN: Skipping channel rule %d due to invalid pattern: %v", i+1, err)
+			continue
+		}
+		compiled = append(compiled, compiledRule)
+	}
+	return compiled
+}
+
+// cachedChannelRules returns the compiled rules, compiling them only if they are not the rules of the last call.
+// Invalid patterns of the config are reported when it loads, see config.JioTVConfig.Load.
+func cachedChannelRules(rules []config.ChannelRule) []compiledChannelRule {
+	compiledRulesMu.Lock()
+	defer compiledRulesMu.Unlock()
+	if !sameRules(rules, compiledRulesFor) {
+		compiledRules = compileChannelRules(rules)
+		compiledRulesFor = rules
+	}
+	return compiledRules
+}
+
+// sameRules reports whether a and b are the same rules of the config. The rules are replaced, not
+// changed in place, when the config loads, so the slices are compared instead of the rules.
+func sameRules(a, b []config.ChannelRule) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// matches reports whether the rule applies to the channel.
+func (r compiledChannelRule) matches(channel Channel) bool {
+	if r.name == nil && r.category == nil && r.provider == nil {
+		return false
+	}
+	if r.name != nil && !r.name.MatchString(channel.Name) {
+		return false
+	}
+	if r.category != nil && !r.category.MatchString(CategoryMap[channel.Category]) {
+		return false
+	}
+	if r.provider != nil && !r.provider.MatchString(ChannelProvider(channel)) {
+		return false
+	}
+	return true
+}
+
+// apply returns the channel with the rule's changes applied.
+func (r compiledChannelRule) apply(channel Channel) (Channel, bool) {
+	if r.rule.Hide {
+		return channel, false
+	}
+	if r.rule.SetCategory != 0 {
+		channel.Category = r.rule.SetCategory
+	}
+	if r.rule.SetLanguage != 0 {
+		channel.Language = r.rule.SetLanguage
+	}
+	if r.rule.SetGroup != "" {
+		channel.Group = r.rule.SetGroup
+	}
+	if quality := strings.ToLower(strings.TrimSpace(r.rule.MaxQuality)); quality != "" {
+		channel.MaxQuality = quality
+	}
+	if quality := strings.ToLower(strings.TrimSpace(r.rule.MaxCatchupQuality)); quality != "" {
+		channel.MaxCatchupQuality = quality
+	}
+	return channel, true
+}
+
+// applyChannelRules returns the channel with the matching rules applied in order, and whether it is visible.
+func applyChannelRules(channel Channel, compiled []compiledChannelRule) (Channel, bool) {
+	visible := true
+	for _, rule := range compiled {
+		if !rule.matches(channel) {
+			continue
+		}
+		if channel, visible = rule.apply(channel); !visible {
+			break
+		}
+	}
+	return channel, visible
+}
+
+// ApplyChannelRules applies the transformation rules to the merged channel list.
+// Rules are applied in order, after the groups of the custom channels file, so that rules can
+// override them. Hidden channels are removed from the returned list.
+func ApplyChannelRules(channels []Channel, rules []config.ChannelRule) []Channel {
+	channels = applyChannelGroups(channels)
+	if len(rules) == 0 {
+		return channels
+	}
+
+	compiled := cachedChannelRules(rules)
+	result := make([]Channel, 0, len(channels))
+	for _, channel := range channels {
+		if channel, visible := applyChannelRules(channel, compiled); visible {
+			result = append(result, channel)
+		}
+	}
+	return result
+}
+
+// qualityCaps returns the quality caps of the indexed channels from the rules. They are evaluated
+// once for the full channel list, so that filtered lists of the handlers do not lose the caps of
+// the other channels, and again when the channel list is refreshed or the config loads.
+func (index *ChannelIndex) qualityCaps(rules []config.ChannelRule) map[string]qualityCaps {
+	index.capsMu.Lock()
+	defer index.capsMu.Unlock()
+	if index.caps != nil && sameRules(rules, index.capsFor) {
+		return index.caps
+	}
+
+	compiled := cachedChannelRules(rules)
+	caps := make(map[string]qualityCaps)
+	for _, channel := range index.channels {
+		channel, visible := applyChannelRules(channel, compiled)
+		if visible && (channel.MaxQuality != "" || channel.MaxCatchupQuality != "") {
+			caps[channel.ID] = qualityCaps{live: channel.MaxQuality, catchup: channel.MaxCatchupQuality}
+		}
+	}
+	index.caps, index.capsFor = caps, rules
+	return caps
+}
+
+// hasQualityCapRules reports whether any rule caps the stream quality.
+func hasQualityCapRules(rules []config.ChannelRule) bool {
+	for _, rule := range rules {
+		if strings.TrimSpace(rule.MaxQuality) != "" || strings.TrimSpace(rule.MaxCatchupQuality) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// channelQualityCapsFor returns the quality caps of a channel from the channel rules.
+func channelQualityCapsFor(channelID string) qualityCaps {
+	rules := config.Cfg.ChannelRules
+	if !hasQualityCapRules(rules) {
+		return qualityCaps{}
+	}
+	index, err := ChannelsIndex()
+	if err != nil {
+		utils.SafeLogf("WARN: Failed to evaluate channel rules: %v", err)
+		return qualityCaps{}
+	}
+	return index.qualityCaps(rules)[channelID]
+}
+
+// ChannelQualityCap returns the quality cap of a channel, or an empty string if there is none.
+func ChannelQualityCap(channelID string) string {
+	return channelQualityCapsFor(channelID).live
+}
+
+// ChannelCatchupQualityCap returns the catchup quality cap of a channel, or an empty string if there is none.
+// A channel rule's max_catchup_quality takes precedence over the global catchup_max_quality.
+func ChannelCatchupQualityCap(channelID string) string {
+	if quality := channelQualityCapsFor(channelID).catchup; quality != "" {
+		return quality
+	}
+	return strings.ToLower(strings.TrimSpace(config.Cfg.CatchupMaxQuality))
+}
+
+// CapQuality lowers the requested quality to maxQuality if it exceeds it.
+// An empty requested quality is treated as "auto".
+func CapQuality(requested, maxQuality string) string {
+	maxRank, ok := qualityRank[maxQuality]
+	if !ok {
+		return requested
+	}
+	quality := strings.ToLower(requested)
+	if quality == "" {
+		quality = "auto"
+	}
+	if rank, ok := qualityRank[quality]; !ok || rank > maxRank {
+		return maxQuality
+	}
+	return requested
+}
@@ -0,0 +1,205 @@
+package television
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+func TestApplyChannelRules(t *testing.T) {
+	testChannels := []Channel{
+		{ID: "1", Name: "Star Sports 1 HD", Category: 8, Language: 6},
+		{ID: "2", Name: "Aaj Tak", Category: 12, Language: 1},
+		{ID: "cc_1", Name: "My Channel", URL: "https://example.com/a.m3u8", IsCustom: true},
+		{ID: "0-9-zeetv", Name: "Zee TV", URL: "zee5/0-9-zeetv", IsCustom: true},
+	}
+
+	tests := []struct {
+		name     string
+		rules    []config.ChannelRule
+		expected []Channel
+	}{
+		{
+			name:     "No rules returns channels unchanged",
+			rules:    nil,
+			expected: testChannels,
+		},
+		{
+			name:  "Hide by category",
+			rules: []config.ChannelRule{{MatchCategory: "^news$", Hide: true}},
+			expected: []Channel{
+				testChannels[0],
+				testChannels[2],
+				testChannels[3],
+			},
+		},
+		{
+			name: "Set fields by provider",
+			rules: []config.ChannelRule{
+				{MatchProvider: "zee5", SetCategory: 5, SetLanguage: 1, SetGroup: "Zee5"},
+			},
+			expected: []Channel{
+				testChannels[0],
+				testChannels[1],
+				testChannels[2],
+				{ID: "0-9-zeetv", Name: "Zee TV", URL: "zee5/0-9-zeetv", IsCustom: true, Category: 5, Language: 1, Group: "Zee5"},
+			},
+		},
+		{
+			name: "Later rules override earlier ones",
+			rules: []config.ChannelRule{
+				{MatchName: "sports", SetGroup: "Sports", MaxQuality: "High", MaxCatchupQuality: "medium"},
+				{MatchName: "hd$", SetGroup: "HD Sports", MaxCatchupQuality: "Low"},
+			},
+			expected: []Channel{
+				{ID: "1", Name: "Star Sports 1 HD", Category: 8, Language: 6, Group: "HD Sports", MaxQuality: "high", MaxCatchupQuality: "low"},
+				testChannels[1],
+				testChannels[2],
+				testChannels[3],
+			},
+		},
+		{
+			name: "Invalid and empty rules are ignored",
+			rules: []config.ChannelRule{
+				{MatchName: "(", Hide: true},
+				{Hide: true},
+			},
+			expected: testChannels,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ApplyChannelRules(testChannels, tt.rules)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ApplyChannelRules() = %+v, want %+v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCachedChannelRules(t *testing.T) {
+	rules := []config.ChannelRule{{MatchName: "sports", SetGroup: "Sports"}}
+	compiled := cachedChannelRules(rules)
+	if len(compiled) != 1 {
+		t.Fatalf("cachedChannelRules() = %d rules, want 1", len(compiled))
+	}
+	if again := cachedChannelRules(rules); &again[0] != &compiled[0] {
+		t.Error("cachedChannelRules() compiled the same rules again")
+	}
+	other := []config.ChannelRule{{MatchName: "news", SetGroup: "News"}}
+	if got := cachedChannelRules(other); got[0].rule.SetGroup != "News" {
+		t.Errorf("cachedChannelRules() of other rules = %+v, want the other rules", got[0].rule)
+	}
+}
+
+// setTestChannelIndex makes the channels the indexed channel list, as if they were fetched.
+func setTestChannelIndex(t *testing.T, channels []Channel) {
+	channelIndexMu.Lock()
+	channelIndex, channelIndexBuiltAt = NewChannelIndex(channels), time.Now()
+	channelIndexMu.Unlock()
+	t.Cleanup(invalidateChannelIndex)
+}
+
+func TestChannelCatchupQualityCap(t *testing.T) {
+	original := config.Cfg
+	defer func() { config.Cfg = original }()
+	config.Cfg.ChannelsCacheTTL = 0
+	channels := []Channel{{ID: "1", Name: "Star Sports 1 HD"}, {ID: "2", Name: "Aaj Tak"}, {ID: "3", Name: "Star Movies"}}
+	setTestChannelIndex(t, channels)
+
+	tests := []struct {
+		name     string
+		global   string
+		rules    []config.ChannelRule
+		wantByID map[string]string
+	}{
+		{
+			name:     "No caps",
+			wantByID: map[string]string{"1": "", "2": ""},
+		},
+		{
+			name:     "Global cap",
+			global:   "Medium",
+			wantByID: map[string]string{"1": "medium", "2": "medium"},
+		},
+		{
+			name:   "Channel rules override the global cap",
+			global: "low",
+			rules: []config.ChannelRule{
+				{MatchName: "sports", MaxCatchupQuality: "high"},
+				{MatchName: "movies", MaxQuality: "medium"},
+			},
+			wantByID: map[string]string{"1": "high", "2": "low", "3": "low"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Cfg.CatchupMaxQuality = tt.global
+			config.Cfg.ChannelRules = tt.rules
+			for id, want := range tt.wantByID {
+				if got := ChannelCatchupQualityCap(id); got != want {
+					t.Errorf("ChannelCatchupQualityCap(%q) = %q, want %q", id, got, want)
+				}
+			}
+		})
+	}
+	// Live caps are independent of catchup caps
+	if got := ChannelQualityCap("3"); got != "medium" {
+		t.Errorf("ChannelQualityCap(3) = %q, want medium", got)
+	}
+	if got := ChannelQualityCap("1"); got != "" {
+		t.Errorf("ChannelQualityCap(1) = %q, want no cap", got)
+	}
+
+	// Rules applied to a filtered list, like a category of the channel list, keep the caps of the other channels
+	ApplyChannelRules(channels[:1], config.Cfg.ChannelRules)
+	if got := ChannelQualityCap("3"); got != "medium" {
+		t.Errorf("ChannelQualityCap(3) after rules on a filtered list = %q, want medium", got)
+	}
+}
+
+func TestChannelProvider(t *testing.T) {
+	tests := []struct {
+		channel  Channel
+		expected string
+	}{
+		{Channel{ID: "1"}, ProviderJioTV},
+		{Channel{ID: "cc_1", URL: "https://example.com", IsCustom: true}, ProviderCustom},
+		{Channel{ID: "0-9-zeetv", URL: "zee5/0-9-zeetv", IsCustom: true}, ProviderZee5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.channel.ID, func(t *testing.T) {
+			if got := ChannelProvider(tt.channel); got != tt.expected {
+				t.Errorf("ChannelProvider() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCapQuality(t *testing.T) {
+	tests := []struct {
+		requested  string
+		maxQuality string
+		expected   string
+	}{
+		{"high", "", "high"},
+		{"high", "medium", "medium"},
+		{"", "medium", "medium"},
+		{"auto", "high", "high"},
+		{"low", "medium", "low"},
+		{"unknown", "low", "low"},
+		{"medium", "invalid", "medium"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.requested+"_"+tt.maxQuality, func(t *testing.T) {
+			if got := CapQuality(tt.requested, tt.maxQuality); got != tt.expected {
+				t.Errorf("CapQuality(%q, %q) = %q, want %q", tt.requested, tt.maxQuality, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package television
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// sonyIDPrefix starts the IDs of Sony channels, which are played from the Sony data file instead of JioTV
+const sonyIDPrefix = "sl"
+
+// ErrSonyChannelNotFound is returned for Sony channel IDs that are not in the Sony data file
+var ErrSonyChannelNotFound = errors.New("sony channel not found")
+
+// SonyChannel is a channel of the Sony data file
+type SonyChannel struct {
+	// ID starts with "sl", e.g. "sl291"
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	LogoURL  string `json:"logo_url"`
+	Category int    `json:"category"`
+	Language int    `json:"language"`
+	IsHD     bool   `json:"is_hd"`
+	// URL is the stream URL, or with FollowRedirect the URL that redirects to the stream
+	URL string `json:"url"`
+	// FollowRedirect requests URL on every play and plays the Location of its redirect
+	FollowRedirect bool `json:"follow_redirect,omitempty"`
+	// Token is a query string, like "hdnea=...", added to the stream URL
+	Token string `json:"token,omitempty"`
+	// Disabled keeps the channel in the file but out of the channel list
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// SonyData is the Sony data file
+type SonyData struct {
+	Channels []SonyChannel `json:"channels"`
+}
+
+var (
+	// sonyChannels holds the channels of the Sony data file by ID
+	sonyChannels map[string]SonyChannel
+	// sonyChannelList holds the enabled Sony channels in file order
+	sonyChannelList []Channel
+	sonyChannelsMu  sync.RWMutex
+)
+
+// parseSonyData parses and checks a Sony data file.
+func parseSonyData(content []byte) (SonyData, error) {
+	var data SonyData
+	if err := json.Unmarshal(content, &data); err != nil {
+		return SonyData{}, fmt.Errorf("failed to parse the Sony data: %w", err)
+	}
+	seen := make(map[string]bool, len(data.Channels))
+	for i, channel := range data.Channels {
+		if !strings.HasPrefix(channel.ID, sonyIDPrefix) || len(channel.ID) == len(sonyIDPrefix) {
+			return SonyData{}, fmt.Errorf("channel %d: id %q must start with %q", i+1, channel.ID, sonyIDPrefix)
+		}
+		if seen[channel.ID] {
+			return SonyData{}, fmt.Errorf("channel %s is listed twice", channel.ID)
+		}
+		seen[channel.ID] = true
+		if u, err := url.Parse(channel.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return SonyData{}, fmt.Errorf("channel %s: url %q is not an http or https URL", channel.ID, channel.URL)
+		}
+	}
+	return data, nil
+}
+
+// setSonyChannels caches the channels of a Sony data file.
+func setSonyChannels(data SonyData) {
+	channels := make(map[string]SonyChannel, len(data.Channels))
+	var list []Channel
+	for _, channel := range data.Channels {
+		channels[channel.ID] = channel
+		if channel.Disabled {
+			continue
+		}
+		list = append(list, Channel{
+			ID:       channel.ID,
+			Name:     channel.Name,
+			LogoURL:  channel.LogoURL,
+			Category: channel.Category,
+			Language: channel.Language,
+			IsHD:     channel.IsHD,
+		})
+	}
+	sonyChannelsMu.Lock()
+	sonyChannels = channels
+	sonyChannelList = list
+	sonyChannelsMu.Unlock()
+	invalidateChannelIndex()
+}
+
+// sonyChannelsAPI returns the enabled Sony channels for the channel list.
+func sonyChannelsAPI() []Channel {
+	sonyChannelsMu.RLock()
+	defer sonyChannelsMu.RUnlock()
+	return append([]Channel(nil), sonyChannelList...)
+}
+
+// LoadSonyChannels reads the Sony channels from sony_data_file. Without the file, there are no Sony channels.
+func LoadSonyChannels() error {
+	path := strings.TrimSpace(config.Cfg.SonyDataFile)
+	if path == "" {
+		setSonyChannels(SonyData{})
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		setSonyChannels(SonyData{})
+		return err
+	}
+	data, err := parseSonyData(content)
+	if err != nil {
+		return err
+	}
+	setSonyChannels(data)
+	return nil
+}
+
+// downloadSonyData downloads and checks the Sony data file of the given URL.
+func downloadSonyData(dataURL string) ([]byte, SonyData, error) {
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	req.SetRequestURI(dataURL)
+	req.Header.SetMethod("GET")
+
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+
+	if err := utils.DoWithRetry(utils.GetRequestClient(), req, resp, utils.DefaultRetryPolicy); err != nil {
+		return nil, SonyData{}, err
+	}
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return nil, SonyData{}, fmt.Errorf("failed to download the Sony data: HTTP %d", resp.StatusCode())
+	}
+	content := append([]byte(nil), resp.Body()...)
+	data, err := parseSonyData(content)
+	return content, data, err
+}
+
+// RefreshSonyChannels downloads sony_data_url to sony_data_file and reloads the Sony channels. If the
+// download fails, the channels of the existing file are kept.
+func RefreshSonyChannels() error {
+	dataURL := strings.TrimSpace(config.Cfg.SonyDataURL)
+	path := strings.TrimSpace(config.Cfg.SonyDataFile)
+	if dataURL == "" || path == "" {
+		return LoadSonyChannels()
+	}
+	content, data, err := downloadSonyData(dataURL)
+	if err != nil {
+		if utils.FileExists(path) {
+			utils.Log.Printf("WARN: Sony data download failed (keeping existing file): %v", err)
+			return LoadSonyChannels()
+		}
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return err
+	}
+	setSonyChannels(data)
+	utils.Log.Printf("INFO: Refreshed %d Sony channels from URL", len(data.Channels))
+	return nil
+}
+
+// followSonyRedirect requests the URL of a channel and returns the Location of its redirect.
+func followSonyRedirect(channel SonyChannel) (string, error) {
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	req.SetRequestURI(channel.URL)
+	req.Header.SetMethod("GET")
+
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+
+	if err := utils.DoWithRetry(utils.GetRequestClient(), req, resp, utils.DefaultRetryPolicy); err != nil {
+		return "", err
+	}
+	if !fasthttp.StatusCodeIsRedirect(resp.StatusCode()) {
+		return "", fmt.Errorf("%s did not redirect: HTTP %d", channel.ID, resp.StatusCode())
+	}
+	location := string(resp.Header.Peek(fasthttp.HeaderLocation))
+	if location == "" {
+		return "", fmt.Errorf("%s redirected without a location", channel.ID)
+	}
+	base, err := url.Parse(channel.URL)
+	if err != nil {
+		return "", err
+	}
+	target, err := base.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	return target.String(), nil
+}
+
+// withSonyToken adds the token of a channel to its stream URL.
+func withSonyToken(streamURL, token string) string {
+	token = strings.TrimLeft(strings.TrimSpace(token), "?&")
+	if token == "" {
+		return streamURL
+	}
+	if strings.Contains(streamURL, "?") {
+		return streamURL + "&" + token
+	}
+	return streamURL + "?" + token
+}
+
+// getSLChannel returns the stream URL of a Sony channel of the Sony data file.
+func getSLChannel(channelID string) (*LiveURLOutput, error) {
+	sonyChannelsMu.RLock()
+	channel, ok := sonyChannels[channelID]
+	sonyChannelsMu.RUnlock()
+	if !ok {
+		return nil, ErrSonyChannelNotFound
+	}
+
+	streamURL := channel.URL
+	if channel.FollowRedirect {
+		location, err := followSonyRedirect(channel)
+		if err != nil {
+			return nil, err
+		}
+		streamURL = location
+	}
+	streamURL = withSonyToken(streamURL, channel.Token)
+
+	result := new(LiveURLOutput)
+	result.Result = streamURL
+	result.Bitrates.Auto = streamURL
+	return result, nil
+}
@@ -0,0 +1,137 @@
+package television
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+func TestParseSonyData(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{"valid", `{"channels":[{"id":"sl291","name":"Sony HD","url":"https://example.com/live.m3u8"}]}`, false},
+		{"no channels", `{"channels":[]}`, false},
+		{"invalid JSON", `{"channels":`, true},
+		{"ID without prefix", `{"channels":[{"id":"291","url":"https://example.com/live.m3u8"}]}`, true},
+		{"prefix only", `{"channels":[{"id":"sl","url":"https://example.com/live.m3u8"}]}`, true},
+		{"duplicate ID", `{"channels":[{"id":"sl1","url":"https://example.com/a.m3u8"},{"id":"sl1","url":"https://example.com/b.m3u8"}]}`, true},
+		{"URL without scheme", `{"channels":[{"id":"sl1","url":"example.com/live.m3u8"}]}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseSonyData([]byte(tt.content)); (err != nil) != tt.wantErr {
+				t.Errorf("parseSonyData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetSLChannel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/stream/master.m3u8?sig=1", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+	defer setSonyChannels(SonyData{})
+
+	setSonyChannels(SonyData{Channels: []SonyChannel{
+		{ID: "sl1", URL: server.URL + "/redirect", FollowRedirect: true, Token: "hdnea=abc"},
+		{ID: "sl2", URL: "https://example.com/live.m3u8", Token: "?hdnea=abc"},
+		{ID: "sl3", URL: server.URL + "/live.m3u8", FollowRedirect: true},
+		{ID: "sl4", URL: "https://example.com/off.m3u8", Disabled: true},
+	}})
+
+	tests := []struct {
+		name    string
+		id      string
+		want    string
+		wantErr bool
+	}{
+		{"follows the redirect and adds the token", "sl1", server.URL + "/stream/master.m3u8?sig=1&hdnea=abc", false},
+		{"adds the token to the URL", "sl2", "https://example.com/live.m3u8?hdnea=abc", false},
+		{"URL that does not redirect", "sl3", "", true},
+		{"disabled channels still play", "sl4", "https://example.com/off.m3u8", false},
+		{"unknown channel", "sl5", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getSLChannel(tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getSLChannel(%q) error = %v, wantErr %v", tt.id, err, tt.wantErr)
+			}
+			if err == nil && (got.Result != tt.want || got.Bitrates.Auto != tt.want) {
+				t.Errorf("getSLChannel(%q) = %q, want %q", tt.id, got.Result, tt.want)
+			}
+		})
+	}
+	if _, err := getSLChannel("sl5"); !errors.Is(err, ErrSonyChannelNotFound) {
+		t.Errorf("getSLChannel() of an unknown channel error = %v, want ErrSonyChannelNotFound", err)
+	}
+	if got := sonyChannelsAPI(); len(got) != 3 {
+		t.Errorf("sonyChannelsAPI() = %+v, want the 3 enabled channels", got)
+	}
+}
+
+func TestRefreshSonyChannels(t *testing.T) {
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	content := `{"channels":[{"id":"sl291","name":"Sony HD","url":"https://example.com/live.m3u8"}]}`
+	serve := content
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(serve))
+	}))
+	defer server.Close()
+
+	original := config.Cfg
+	defer func() {
+		config.Cfg = original
+		setSonyChannels(SonyData{})
+	}()
+	path := filepath.Join(t.TempDir(), "sony", "sony-data.json")
+	config.Cfg.SonyDataFile = path
+	config.Cfg.SonyDataURL = server.URL
+
+	if err := RefreshSonyChannels(); err != nil {
+		t.Fatalf("RefreshSonyChannels() error = %v", err)
+	}
+	if saved, err := os.ReadFile(path); err != nil || string(saved) != content {
+		t.Errorf("saved file = %q, %v, want the downloaded data", saved, err)
+	}
+	if got := sonyChannelsAPI(); len(got) != 1 || got[0].ID != "sl291" || got[0].URL != "" {
+		t.Errorf("sonyChannelsAPI() = %+v, want sl291 without its stream URL", got)
+	}
+
+	// Invalid data keeps the existing file
+	serve = `{"channels":[{"id":"291","url":"https://example.com/live.m3u8"}]}`
+	if err := RefreshSonyChannels(); err != nil {
+		t.Fatalf("RefreshSonyChannels() with invalid data error = %v", err)
+	}
+	if saved, _ := os.ReadFile(path); string(saved) != content {
+		t.Errorf("saved file = %q, want the previous data", saved)
+	}
+	if got := sonyChannelsAPI(); len(got) != 1 {
+		t.Errorf("sonyChannelsAPI() = %+v, want the channels of the existing file", got)
+	}
+
+	// Without a file, there are no Sony channels
+	config.Cfg.SonyDataFile, config.Cfg.SonyDataURL = "", ""
+	if err := RefreshSonyChannels(); err != nil || len(sonyChannelsAPI()) != 0 {
+		t.Errorf("RefreshSonyChannels() without a file = %v, %+v, want no channels", err, sonyChannelsAPI())
+	}
+}
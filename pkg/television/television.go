@@ -1,14 +1,13 @@
 package television
 
 import (
-	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/url"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/valyala/fasthttp"
 	"gopkg.in/yaml.v3"
@@ -17,6 +16,7 @@ import (
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants"
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/headers"
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/urls"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/cdntoken"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 )
 
@@ -52,11 +52,18 @@ func logExcessiveChannelsWarning(channelCount int, context string) {
 var (
 	// customChannelsCacheMap holds cached custom channels indexed by ID for efficient lookups
 	customChannelsCacheMap map[string]Channel
-	customChannelsMu       sync.RWMutex
+	// customChannelsCacheList holds the cached custom channels in their order
+	customChannelsCacheList []Channel
+	customChannelsMu        sync.RWMutex
 )
 
 // New function creates a new Television instance with the provided credentials
 func New(credentials *utils.JIOTV_CREDENTIALS) *Television {
+	return NewForAccount(credentials, utils.DefaultAccount)
+}
+
+// NewForAccount creates a new Television instance with the provided credentials and the device ID of account
+func NewForAccount(credentials *utils.JIOTV_CREDENTIALS, account *utils.Account) *Television {
 	// Check if credentials are provided
 	if credentials == nil {
 		// If credentials are not provided, set them to empty strings
@@ -73,7 +80,7 @@ func New(credentials *utils.JIOTV_CREDENTIALS) *Television {
 		"channel_id":      "",
 		"crmid":           credentials.CRM,
 		"userId":          credentials.CRM,
-		"deviceId":        utils.GetDeviceID(),
+		"deviceId":        account.DeviceID(),
 		"devicetype":      "phone",
 		"isott":           "false",
 		"languageId":      "6",
@@ -134,27 +141,38 @@ func GetCustomChannelByID(channelID string) (Channel, bool) {
 
 // loadAndCacheCustomChannels loads custom channels from file and caches them
 func loadAndCacheCustomChannels() {
-	channels, err := LoadCustomChannels(config.Cfg.CustomChannelsFile)
-	next := make(map[string]Channel)
+	customConfig, err := loadCustomChannelsConfig(config.Cfg.CustomChannelsFile)
+	var channels []Channel
 	if err != nil {
 		utils.SafeLogf("Error loading custom channels: %v", err)
 	} else {
-		for _, channel := range channels {
-			next[channel.ID] = channel
-		}
-
+		channels = convertCustomConfigToChannels(customConfig)
 		logExcessiveChannelsWarning(len(channels), "Cached")
 	}
+	setCustomChannels(channels)
+	setChannelNumbers(customConfig.ChannelNumbers)
+	setChannelGroups(customConfig.ChannelGroups)
+}
+
+// setCustomChannels caches the custom channels, sorted by their order, and indexes them by ID.
+func setCustomChannels(channels []Channel) {
+	list := sortCustomChannels(channels)
+	next := make(map[string]Channel, len(list))
+	for _, channel := range list {
+		next[channel.ID] = channel
+	}
 
 	customChannelsMu.Lock()
 	customChannelsCacheMap = next
+	customChannelsCacheList = list
 	customChannelsMu.Unlock()
+	invalidateChannelIndex()
 }
 
 // Live method generates m3u8 link from JioTV API with the provided channel ID
 func (tv *Television) Live(channelID string) (*LiveURLOutput, error) {
 	// If channelID starts with sl, then it is a Sony Channel
-	if len(channelID) >= 2 && channelID[:2] == "sl" {
+	if strings.HasPrefix(channelID, sonyIDPrefix) {
 		return getSLChannel(channelID)
 	}
 
@@ -188,16 +206,38 @@ func (tv *Television) Live(channelID string) (*LiveURLOutput, error) {
 	resp := fasthttp.AcquireResponse()
 	defer fasthttp.ReleaseResponse(resp)
 
-	// Perform the HTTP POST request
-	if err := tv.Client.Do(req, resp); err != nil {
-		if strings.Contains(err.Error(), "server closed connection before returning the first response byte") {
-			utils.Log.Println("Retrying the request...")
-			return tv.Live(channelID)
+	// Serve the last known result instead of queuing when calls exceed the rate limit
+	limiter := utils.UpstreamLimiter()
+	if !limiter.TryTake() {
+		if cached, ok := getCachedLiveResult(tv.Crm + "/" + channelID); ok {
+			return cached, nil
 		}
+		if err := limiter.Wait(utils.RateLimitMaxWait); err != nil {
+			return nil, err
+		}
+	}
+
+	// Pause playback requests while the API is failing and serve the last known result instead
+	breaker := utils.Circuit(utils.CircuitPlayback)
+	if err := breaker.Allow(); err != nil {
+		if cached, ok := getCachedLiveResult(tv.Crm + "/" + channelID); ok {
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	// Perform the HTTP POST request
+	err := utils.DoWithRetry(tv.Client, req, resp, utils.DefaultRetryPolicy)
+	breaker.Record(err, resp.StatusCode())
+	if err != nil {
 		utils.Log.Panic(err)
 		return nil, err
 	}
 	if resp.StatusCode() != fasthttp.StatusOK {
+		if utils.IsGeoBlocked(resp.StatusCode(), resp.Body()) {
+			// Try the next proxy if a list is configured
+			utils.ReportProxyFailure()
+		}
 		// Store the response body as a string
 		response := string(resp.Body())
 
@@ -216,55 +256,25 @@ func (tv *Television) Live(channelID string) (*LiveURLOutput, error) {
 	}
 
 	// Extract hdnea from any URL fields in the response (Live does not set Set-Cookie)
-	extractHdneaFromURL := func(u string) string {
-		if u == "" {
-			return ""
-		}
-		idx := strings.Index(u, "hdnea=")
-		if idx == -1 {
-			return ""
-		}
-		// token starts after hdnea=
-		token := u[idx+len("hdnea="):]
-		if i := strings.IndexByte(token, '&'); i != -1 {
-			token = token[:i]
-		}
-		return token
-	}
-	hdnea := extractHdneaFromURL(result.Bitrates.Auto)
+	hdnea := cdntoken.HDNEA.FromURL(result.Bitrates.Auto)
 	if hdnea == "" {
-		hdnea = extractHdneaFromURL(result.Mpd.Result)
+		hdnea = cdntoken.HDNEA.FromURL(result.Mpd.Result)
 	}
 	result.Hdnea = hdnea
 
 	// If hdnea exists and URLs don't already have it, append as query param
 	if hdnea != "" {
-		appendHdnea := func(u string) string {
-			if u == "" {
-				return u
-			}
-			if strings.Contains(u, "hdnea=") {
-				return u
-			}
-			sep := "?"
-			if strings.Contains(u, "?") {
-				sep = "&"
-			}
-			return u + sep + "hdnea=" + hdnea
-		}
-		result.Bitrates.Auto = appendHdnea(result.Bitrates.Auto)
-		result.Bitrates.High = appendHdnea(result.Bitrates.High)
-		result.Bitrates.Medium = appendHdnea(result.Bitrates.Medium)
-		result.Bitrates.Low = appendHdnea(result.Bitrates.Low)
-		result.Result = appendHdnea(result.Result)
-		if result.Mpd.Result != "" {
-			result.Mpd.Result = appendHdnea(result.Mpd.Result)
-		}
-		if result.Mpd.Key != "" {
-			result.Mpd.Key = appendHdnea(result.Mpd.Key)
-		}
+		result.Bitrates.Auto = cdntoken.HDNEA.Attach(result.Bitrates.Auto, hdnea)
+		result.Bitrates.High = cdntoken.HDNEA.Attach(result.Bitrates.High, hdnea)
+		result.Bitrates.Medium = cdntoken.HDNEA.Attach(result.Bitrates.Medium, hdnea)
+		result.Bitrates.Low = cdntoken.HDNEA.Attach(result.Bitrates.Low, hdnea)
+		result.Result = cdntoken.HDNEA.Attach(result.Result, hdnea)
+		result.Mpd.Result = cdntoken.HDNEA.Attach(result.Mpd.Result, hdnea)
+		result.Mpd.Key = cdntoken.HDNEA.Attach(result.Mpd.Key, hdnea)
 	}
 
+	// Results are kept per subscriber, so accounts of different tenants never share stream URLs
+	setCachedLiveResult(tv.Crm+"/"+channelID, &result)
 	return &result, nil
 }
 
@@ -286,62 +296,23 @@ func (tv *Television) Render(streamURL string, hdneaToken string) ([]byte, int,
 
 	// Prefer explicit token override from handler cache; otherwise derive from URL query.
 	// When both hdnea and __hdnea__ are present, prefer __hdnea__ as the fresher token.
-	if hdneaToken != "" {
-		req.Header.SetCookie("__hdnea__", hdneaToken)
-	} else if strings.Contains(streamURL, "hdnea=") {
-		// quick parse to extract value
-		q := streamURL[strings.Index(streamURL, "?")+1:]
-		var parsedToken string
-		for _, p := range strings.Split(q, "&") {
-			if strings.HasPrefix(p, "__hdnea__=") {
-				token := strings.TrimPrefix(p, "__hdnea__=")
-				if decodedToken, decodeErr := url.QueryUnescape(token); decodeErr == nil {
-					token = decodedToken
-				}
-				parsedToken = token
-				break
-			}
-			if parsedToken == "" && strings.HasPrefix(p, "hdnea=") {
-				token := strings.TrimPrefix(p, "hdnea=")
-				if decodedToken, decodeErr := url.QueryUnescape(token); decodeErr == nil {
-					token = decodedToken
-				}
-				parsedToken = token
-			}
-		}
-		if parsedToken != "" {
-			req.Header.SetCookie("__hdnea__", parsedToken)
-		}
+	if hdneaToken == "" {
+		hdneaToken = cdntoken.HDNEA.FromURL(streamURL)
 	}
+	cdntoken.HDNEA.SetCookie(&req.Header, hdneaToken)
 
 	resp := fasthttp.AcquireResponse()
 	defer fasthttp.ReleaseResponse(resp)
 
 	// Perform the HTTP GET request
-	if err := tv.Client.Do(req, resp); err != nil {
+	if err := utils.DoWithRetry(tv.Client, req, resp, utils.DefaultRetryPolicy); err != nil {
 		utils.Log.Println("Render upstream request failed:", err)
 		return []byte(""), fasthttp.StatusBadGateway, ""
 	}
 
 	buf := resp.Body()
 	// Capture any __hdnea__ Set-Cookie returned by upstream so caller can set cookie on client
-	var newHdnea string
-	setCookie := resp.Header.Peek("Set-Cookie")
-	if setCookie != nil {
-		setCookieStr := string(setCookie)
-		// Parse Set-Cookie: name=value; attributes...
-		// Look for __hdnea__=value
-		if strings.Contains(setCookieStr, "__hdnea__=") {
-			parts := strings.Split(setCookieStr, ";")
-			for _, part := range parts {
-				trimmed := strings.TrimSpace(part)
-				if strings.HasPrefix(trimmed, "__hdnea__=") {
-					newHdnea = strings.TrimPrefix(trimmed, "__hdnea__=")
-					break
-				}
-			}
-		}
-	}
+	newHdnea := cdntoken.HDNEA.FromResponse(&resp.Header)
 
 	return buf, resp.StatusCode(), newHdnea
 }
@@ -393,8 +364,18 @@ func detectAndParseFormat(data []byte, filePath string) (CustomChannelsConfig, e
 
 // LoadCustomChannels loads custom channels from configuration file
 func LoadCustomChannels(filePath string) ([]Channel, error) {
+	customConfig, err := loadCustomChannelsConfig(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return convertCustomConfigToChannels(customConfig), nil
+}
+
+// loadCustomChannelsConfig reads the custom channels configuration file.
+// The built-in sample channels are used when a file with a default name does not exist.
+func loadCustomChannelsConfig(filePath string) (CustomChannelsConfig, error) {
 	if filePath == "" {
-		return []Channel{}, nil
+		return CustomChannelsConfig{}, nil
 	}
 
 	// Check if file exists and read it
@@ -404,29 +385,31 @@ func LoadCustomChannels(filePath string) ([]Channel, error) {
 		if isDefaultCustomChannelsPath(filePath) {
 			customConfig, err := loadBuiltInCustomChannelsConfig()
 			if err == nil {
-				return convertCustomConfigToChannels(customConfig), nil
+				return customConfig, nil
 			}
 		}
-		return []Channel{}, nil
+		return CustomChannelsConfig{}, nil
 	}
 
 	if fileResult.Error != nil {
-		return nil, fileResult.Error
+		return CustomChannelsConfig{}, fileResult.Error
 	}
 
-	// Parse the file using format detection
-	customConfig, err := detectAndParseFormat(fileResult.Data, filePath)
+	// Parse the file using format detection, and leave out the channels with errors
+	customConfig, issues, err := ValidateCustomChannels(fileResult.Data, filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse custom channels file: %w", err)
+		return CustomChannelsConfig{}, fmt.Errorf("failed to parse custom channels file: %w", err)
+	}
+	if len(issues) > 0 {
+		utils.SafeLogf("WARN: %v\nChannels with errors are left out. Check the file on /api/v1/channels/validate.", &ValidationError{File: filePath, Issues: issues})
+		customConfig.Channels = validCustomChannels(customConfig.Channels, issues)
 	}
 
-	channels := convertCustomConfigToChannels(customConfig)
-
-	utils.SafeLogf("Loaded %d custom channels from %s", len(channels), filePath)
+	utils.SafeLogf("Loaded %d custom channels from %s", len(customConfig.Channels), filePath)
 
 	// Warn user about performance implications if too many channels
-	logExcessiveChannelsWarning(len(channels), "You have loaded")
-	return channels, nil
+	logExcessiveChannelsWarning(len(customConfig.Channels), "You have loaded")
+	return customConfig, nil
 }
 
 func getCustomChannels() []Channel {
@@ -434,7 +417,10 @@ func getCustomChannels() []Channel {
 	defer customChannelsMu.RUnlock()
 
 	var customChannels []Channel
-	for _, channel := range customChannelsCacheMap {
+	for _, channel := range customChannelsCacheList {
+		if config.Cfg.HideDeadChannels && IsDeadChannel(channel.ID) {
+			continue
+		}
 		customChannels = append(customChannels, channel)
 	}
 	return customChannels
@@ -472,6 +458,10 @@ func convertCustomConfigToChannels(customConfig CustomChannelsConfig) []Channel
 			Category: customChannel.Category,
 			Language: customChannel.Language,
 			IsHD:     customChannel.IsHD,
+			Number:   customChannel.Number,
+			Group:    strings.TrimSpace(customChannel.Group),
+			Order:    customChannel.Order,
+			Pinned:   customChannel.Pinned,
 		}
 		channels = append(channels, channel)
 	}
@@ -501,8 +491,37 @@ const builtInCustomChannelsJSON = `{
   ]
 }`
 
-// Channels fetch channels from JioTV API and merge with custom channels
+// Channels returns the JioTV channels merged with custom channels.
+// The channel list is cached: a stale list is served while it is refreshed in the background,
+// so listing channels keeps working during brief upstream outages.
 func Channels() (ChannelsResponse, error) {
+	ttl := channelsCacheTTL()
+	if ttl < 0 {
+		return fetchChannels()
+	}
+	if channelsCacheOnDisk() {
+		channelsDiskOnce.Do(loadChannelsFromDisk)
+	}
+
+	cached, fetchedAt, ok := getCachedChannels()
+	if !ok {
+		return fetchChannels()
+	}
+	if time.Since(fetchedAt) >= ttl {
+		refreshChannelsInBackground()
+	}
+	return withCustomChannels(cached), nil
+}
+
+// ReloadChannels reloads the custom channels file and fetches the channel list from the JioTV API,
+// instead of waiting for the cached list to expire.
+func ReloadChannels() (ChannelsResponse, error) {
+	ReloadCustomChannels()
+	return fetchChannels()
+}
+
+// fetchChannels fetches channels from JioTV API and merges them with custom channels
+func fetchChannels() (ChannelsResponse, error) {
 	// Create a fasthttp.Client
 	client := utils.GetRequestClient()
 
@@ -517,28 +536,61 @@ func Channels() (ChannelsResponse, error) {
 		"usertype":         "JIO",
 	}
 
+	// Serve the last fetched channels instead of queuing when calls exceed the rate limit
+	limiter := utils.UpstreamLimiter()
+	if !limiter.TryTake() {
+		if cached, _, ok := getCachedChannels(); ok {
+			return withCustomChannels(cached), nil
+		}
+		if err := limiter.Wait(utils.RateLimitMaxWait); err != nil {
+			return ChannelsResponse{}, err
+		}
+	}
+
+	// Serve the last fetched channels while the API is failing
+	breaker := utils.Circuit(utils.CircuitChannels)
+	if err := breaker.Allow(); err != nil {
+		if cached, _, ok := getCachedChannels(); ok {
+			return withCustomChannels(cached), nil
+		}
+		return ChannelsResponse{}, err
+	}
+
 	// Make the HTTP request
 	resp, err := utils.MakeHTTPRequest(utils.HTTPRequestConfig{
 		URL:     CHANNELS_API_URL,
 		Method:  "GET",
 		Headers: requestHeaders,
+		Retry:   &utils.DefaultRetryPolicy,
 	}, client)
 	if err != nil {
+		breaker.Failure()
 		utils.Log.Printf("Error fetching channels from JioTV API: %v", err)
-		return ChannelsResponse{}, err
+		return offlineChannels(err)
 	}
 	defer fasthttp.ReleaseResponse(resp)
+	breaker.Record(nil, resp.StatusCode())
 
 	var apiResponse ChannelsResponse
 
 	// Parse JSON response
 	if err := utils.ParseJSONResponse(resp, &apiResponse); err != nil {
 		utils.Log.Printf("Error parsing channels API response: %v", err)
-		return ChannelsResponse{}, err
+		return offlineChannels(err)
 	}
+	setCachedChannels(apiResponse, time.Now())
+	setOffline(false)
+
+	return withCustomChannels(apiResponse), nil
+}
 
-	// disable sony channels temporarily
-	// apiResponse.Result = append(apiResponse.Result, SONY_CHANNELS_API...)
+// withCustomChannels applies the channel overrides and appends the custom channels to the JioTV channels if configured.
+// Hidden channels are removed.
+func withCustomChannels(apiResponse ChannelsResponse) ChannelsResponse {
+	apiResponse.Result = applyChannelOverrides(append([]Channel(nil), apiResponse.Result...))
+
+	// Append the Sony channels of sony_data_file
+	apiResponse.Result = append(apiResponse.Result, sonyChannelsAPI()...)
 
 	// Load and append custom channels if configured
 	if config.Cfg.CustomChannelsFile != "" {
@@ -546,7 +598,10 @@ func Channels() (ChannelsResponse, error) {
 		apiResponse.Result = append(apiResponse.Result, customChannels...)
 	}
 
-	return apiResponse, nil
+	// Remove the channels hidden from the web interface
+	apiResponse.Result = removeHiddenChannels(apiResponse.Result)
+
+	return apiResponse
 }
 
 // FilterChannels Function is used to filter channels by language and category
@@ -616,63 +671,6 @@ func FilterChannelsByDefaults(channels []Channel, categories, languages []int) [
 	return filteredChannels
 }
 
-func ReplaceM3U8(baseUrl, match []byte, params, channel_id string, quality string) []byte {
-	config := EncryptedURLConfig{
-		BaseURL:     string(baseUrl),
-		Match:       string(match),
-		Params:      params,
-		ChannelID:   channel_id,
-		EndpointURL: "/render.m3u8",
-		Quality:     quality,
-	}
-
-	result, err := CreateEncryptedURL(config)
-	if err != nil {
-		return nil
-	}
-	return result
-}
-
-func ReplaceTS(baseUrl, match []byte, params, channelID string) []byte {
-	if config.Cfg.DisableTSHandler {
-		return []byte(string(baseUrl) + string(match) + "?" + params)
-	}
-
-	config := EncryptedURLConfig{
-		BaseURL:     string(baseUrl),
-		Match:       string(match),
-		Params:      params,
-		ChannelID:   channelID,
-		EndpointURL: "/render.ts",
-	}
-
-	result, err := CreateEncryptedURL(config)
-	if err != nil {
-		return nil
-	}
-	return result
-}
-
-func ReplaceAAC(baseUrl, match []byte, params, channelID string) []byte {
-	if config.Cfg.DisableTSHandler {
-		return []byte(string(baseUrl) + string(match) + "?" + params)
-	}
-
-	config := EncryptedURLConfig{
-		BaseURL:     string(baseUrl),
-		Match:       string(match),
-		Params:      params,
-		ChannelID:   channelID,
-		EndpointURL: "/render.ts",
-	}
-
-	result, err := CreateEncryptedURL(config)
-	if err != nil {
-		return nil
-	}
-	return result
-}
-
 func ReplaceKey(match []byte, params, channel_id string) []byte {
 	config := EncryptedURLConfig{
 		BaseURL:     "",
@@ -689,52 +687,6 @@ func ReplaceKey(match []byte, params, channel_id string) []byte {
 	return result
 }
 
-func getSLChannel(channelID string) (*LiveURLOutput, error) {
-	// Check if the channel is available in the SONY_CHANNELS map
-	if val, ok := SONY_JIO_MAP[channelID]; ok {
-		// If the channel is available in the SONY_CHANNELS map, then return the link
-		result := new(LiveURLOutput)
-
-		chu, err := base64.StdEncoding.DecodeString(SONY_CHANNELS[val])
-		if err != nil {
-			utils.Log.Panic(err)
-			return nil, err
-		}
-
-		channel_url := string(chu)
-
-		// Make a get request to the channel url and store location header in actual_url
-		req := fasthttp.AcquireRequest()
-		defer fasthttp.ReleaseRequest(req)
-
-		req.SetRequestURI(channel_url)
-		req.Header.SetMethod("GET")
-
-		resp := fasthttp.AcquireResponse()
-		defer fasthttp.ReleaseResponse(resp)
-
-		// Perform the HTTP GET request
-		if err := utils.GetRequestClient().Do(req, resp); err != nil {
-			utils.Log.Panic(err)
-		}
-
-		if resp.StatusCode() != fasthttp.StatusFound {
-			utils.Log.Panicf("Request failed with status code: %d", resp.StatusCode())
-			utils.Log.Panicln("Response: ", string(resp.Body()))
-		}
-
-		// Store the location header in actual_url
-		actual_url := string(resp.Header.Peek("Location"))
-
-		result.Result = actual_url
-		result.Bitrates.Auto = actual_url
-		return result, nil
-	} else {
-		// If the channel is not available in the SONY_CHANNELS map, then return an error
-		return nil, fmt.Errorf("Channel not found")
-	}
-}
-
 func (tv *Television) GetCatchupURL(channelID, srno, start, end string) (*LiveURLOutput, error) {
 	formData := fasthttp.AcquireArgs()
 	defer fasthttp.ReleaseArgs(formData)
@@ -765,19 +717,23 @@ func (tv *Television) GetCatchupURL(channelID, srno, start, end string) (*LiveUR
 	resp := fasthttp.AcquireResponse()
 	defer fasthttp.ReleaseResponse(resp)
 
-	maxRetries := 3
-	for i := 0; i < maxRetries; i++ {
-		if err := tv.Client.Do(req, resp); err != nil {
-			if strings.Contains(err.Error(), "server closed connection before returning the first response byte") {
-				utils.Log.Printf("Retrying the catchup request (attempt %d/%d)...", i+1, maxRetries)
-				continue
-			}
-			utils.Log.Panicln(err)
-			return nil, err
-		}
-		break
+	if err := utils.UpstreamLimiter().Wait(utils.RateLimitMaxWait); err != nil {
+		return nil, err
+	}
+	breaker := utils.Circuit(utils.CircuitPlayback)
+	if err := breaker.Allow(); err != nil {
+		return nil, err
+	}
+	err := utils.DoWithRetry(tv.Client, req, resp, utils.DefaultRetryPolicy)
+	breaker.Record(err, resp.StatusCode())
+	if err != nil {
+		utils.Log.Panicln(err)
+		return nil, err
 	}
 	if resp.StatusCode() != fasthttp.StatusOK {
+		if utils.IsGeoBlocked(resp.StatusCode(), resp.Body()) {
+			utils.ReportProxyFailure()
+		}
 		response := string(resp.Body())
 		utils.Log.Printf("Catchup request failed with status code: %d", resp.StatusCode())
 		utils.Log.Println("Request headers:", req.Header.String())
@@ -801,28 +757,9 @@ func (tv *Television) GetCatchupURL(channelID, srno, start, end string) (*LiveUR
 		return nil, err
 	}
 
-	extractHdneaFromURL := func(u string) string {
-		if u == "" {
-			return ""
-		}
-		qIdx := strings.Index(u, "?")
-		if qIdx == -1 {
-			return ""
-		}
-		query := u[qIdx+1:]
-		for _, p := range strings.Split(query, "&") {
-			if strings.HasPrefix(p, "hdnea=") {
-				return strings.TrimPrefix(p, "hdnea=")
-			}
-			if strings.HasPrefix(p, "__hdnea__=") {
-				return strings.TrimPrefix(p, "__hdnea__=")
-			}
-		}
-		return ""
-	}
-	hdnea := extractHdneaFromURL(result.Result)
+	hdnea := cdntoken.HDNEA.FromURL(result.Result)
 	if hdnea == "" {
-		hdnea = extractHdneaFromURL(result.Bitrates.Auto)
+		hdnea = cdntoken.HDNEA.FromURL(result.Bitrates.Auto)
 	}
 	result.Hdnea = hdnea
 	return &result, nil
@@ -131,171 +131,6 @@ func TestFilterChannels(t *testing.T) {
 	}
 }
 
-func TestReplaceM3U8(t *testing.T) {
-	setupTest() // Initialize necessary components
-	type args struct {
-		baseUrl    []byte
-		match      []byte
-		params     string
-		channel_id string
-		quality    string
-	}
-	tests := []struct {
-		name string
-		args args
-	}{
-		{
-			name: "Replace M3U8 URL with parameters and quality",
-			args: args{
-				baseUrl:    []byte("test.m3u8"),
-				match:      []byte("test.m3u8"),
-				params:     "param1=value1",
-				channel_id: "123",
-				quality:    "high",
-			},
-		},
-		{
-			name: "Replace M3U8 URL with empty params",
-			args: args{
-				baseUrl:    []byte("example.m3u8"),
-				match:      []byte("example.m3u8"),
-				params:     "",
-				channel_id: "456",
-				quality:    "auto",
-			},
-		},
-		{
-			name: "Replace M3U8 URL with empty quality",
-			args: args{
-				baseUrl:    []byte("original.m3u8"),
-				match:      []byte("original.m3u8"),
-				params:     "param1=value1",
-				channel_id: "123",
-				quality:    "",
-			},
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := ReplaceM3U8(tt.args.baseUrl, tt.args.match, tt.args.params, tt.args.channel_id, tt.args.quality)
-			// The function encrypts URLs, so we check that it produces some output
-			if len(got) == 0 {
-				t.Errorf("ReplaceM3U8() returned empty result")
-			}
-			// Should contain render path
-			if !strings.Contains(string(got), "/render") {
-				t.Errorf("ReplaceM3U8() should contain /render path, got %s", string(got))
-			}
-			// If quality is provided, should contain quality parameter
-			if tt.args.quality != "" && !strings.Contains(string(got), "q="+tt.args.quality) {
-				t.Errorf("ReplaceM3U8() should contain quality parameter q=%s, got %s", tt.args.quality, string(got))
-			}
-		})
-	}
-}
-
-func TestReplaceTS(t *testing.T) {
-	setupTest() // Initialize necessary components
-	type args struct {
-		baseUrl []byte
-		match   []byte
-		params  string
-	}
-	tests := []struct {
-		name string
-		args args
-	}{
-		{
-			name: "Replace TS URL with parameters",
-			args: args{
-				baseUrl: []byte("segment.ts"),
-				match:   []byte("segment.ts"),
-				params:  "param1=value1",
-			},
-		},
-		{
-			name: "Replace TS URL with empty params",
-			args: args{
-				baseUrl: []byte("test.ts"),
-				match:   []byte("test.ts"),
-				params:  "",
-			},
-		},
-		{
-			name: "No match found",
-			args: args{
-				baseUrl: []byte("original content"),
-				match:   []byte("not_found.ts"),
-				params:  "param1=value1",
-			},
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := ReplaceTS(tt.args.baseUrl, tt.args.match, tt.args.params, "123")
-			// The function encrypts URLs, so we check that it produces some output
-			if len(got) == 0 {
-				t.Errorf("ReplaceTS() returned empty result")
-			}
-			// Should contain render path
-			if !strings.Contains(string(got), "/render") {
-				t.Errorf("ReplaceTS() should contain /render path, got %s", string(got))
-			}
-		})
-	}
-}
-
-func TestReplaceAAC(t *testing.T) {
-	setupTest() // Initialize necessary components
-	type args struct {
-		baseUrl []byte
-		match   []byte
-		params  string
-	}
-	tests := []struct {
-		name string
-		args args
-	}{
-		{
-			name: "Replace AAC URL with parameters",
-			args: args{
-				baseUrl: []byte("audio.aac"),
-				match:   []byte("audio.aac"),
-				params:  "param1=value1",
-			},
-		},
-		{
-			name: "Replace AAC URL with empty params",
-			args: args{
-				baseUrl: []byte("test.aac"),
-				match:   []byte("test.aac"),
-				params:  "",
-			},
-		},
-		{
-			name: "No match found",
-			args: args{
-				baseUrl: []byte("original content"),
-				match:   []byte("not_found.aac"),
-				params:  "param1=value1",
-			},
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := ReplaceAAC(tt.args.baseUrl, tt.args.match, tt.args.params, "123")
-			// The function encrypts URLs, so we check that it produces some output
-			if len(got) == 0 {
-				t.Errorf("ReplaceAAC() returned empty result")
-			}
-			// Should contain render path
-			if !strings.Contains(string(got), "/render") {
-				t.Errorf("ReplaceAAC() should contain /render path, got %s", string(got))
-			}
-		})
-	}
-}
-
 func TestReplaceKey(t *testing.T) {
 	setupTest() // Initialize necessary components
 	type args struct {
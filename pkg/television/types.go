@@ -28,13 +28,20 @@ type Channel struct {
 	IsHD               bool   `json:"isHD"`
 	IsCatchupAvailable bool   `json:"isCatchupAvailable"`
 	IsCustom           bool   `json:"-"`
+	Group              string `json:"group,omitempty"`
+	Number             int    `json:"channel_number,omitempty"`
+	Pinned             bool   `json:"pinned,omitempty"`
+	Order              int    `json:"-"`
+	MaxQuality         string `json:"-"`
+	MaxCatchupQuality  string `json:"-"`
 }
 
-// UnmarshalJSON to Override Channel.ID to convert int from json to string
+// UnmarshalJSON to Override Channel.ID to convert int from json to string.
+// String IDs are accepted too, so that channels marshalled by JioTV Go can be read back.
 func (c *Channel) UnmarshalJSON(b []byte) error {
 	type Alias Channel
 	aux := &struct {
-		ID int `json:"channel_id"`
+		ID json.RawMessage `json:"channel_id"`
 		*Alias
 	}{
 		Alias: (*Alias)(c),
@@ -42,7 +49,16 @@ func (c *Channel) UnmarshalJSON(b []byte) error {
 	if err := json.Unmarshal(b, &aux); err != nil {
 		return err
 	}
-	c.ID = strconv.Itoa(aux.ID)
+	if len(aux.ID) > 0 && aux.ID[0] == '"' {
+		return json.Unmarshal(aux.ID, &c.ID)
+	}
+	var id int
+	if len(aux.ID) > 0 {
+		if err := json.Unmarshal(aux.ID, &id); err != nil {
+			return err
+		}
+	}
+	c.ID = strconv.Itoa(id)
 	return nil
 }
 
@@ -131,38 +147,6 @@ var LanguageMap = map[int]string{
 	18: "Other",
 }
 
-var SONY_CHANNELS = map[string]string{
-	"sonyhd":         "aHR0cHM6Ly9kYWkuZ29vZ2xlLmNvbS9saW5lYXIvaGxzL2V2ZW50L2RCZHdPaUdhUXZ5MFRBMXpPc2pWNncvbWFzdGVyLm0zdTg=",
-	"sonysabhd":      "aHR0cHM6Ly9kYWkuZ29vZ2xlLmNvbS9saW5lYXIvaGxzL2V2ZW50L0NyVGl2a0RFU1dxd3ZVajN6RkVZRUEvbWFzdGVyLm0zdTg=",
-	"sonypal":        "aHR0cHM6Ly9kYWkuZ29vZ2xlLmNvbS9saW5lYXIvaGxzL2V2ZW50L2RoUHJHUndEUnZ1TVF0bWx6cHB6UVEvbWFzdGVyLm0zdTg=",
-	"sonypixhd":      "aHR0cHM6Ly9kYWkuZ29vZ2xlLmNvbS9saW5lYXIvaGxzL2V2ZW50L3g3clhXZDJFUloydHZ5UVdQbU8xSEEvbWFzdGVyLm0zdTg=",
-	"sonymaxhd":      "aHR0cHM6Ly9kYWkuZ29vZ2xlLmNvbS9saW5lYXIvaGxzL2V2ZW50L1VjakhOSm1DUTFXUmxHS2xabTczUUEvbWFzdGVyLm0zdTg=",
-	"sonymax2":       "aHR0cHM6Ly9kYWkuZ29vZ2xlLmNvbS9saW5lYXIvaGxzL2V2ZW50L01kUTVaeS1QU3JhT2NjWHU4amZsQ2cvbWFzdGVyLm0zdTg=",
-	"sonywah":        "aHR0cHM6Ly9kYWkuZ29vZ2xlLmNvbS9saW5lYXIvaGxzL2V2ZW50L2dYNXJDQmY2UTctRDVBV1ktc292elEvbWFzdGVyLm0zdTg=",
-	"sonyten1hd":     "aHR0cHM6Ly9kYWkuZ29vZ2xlLmNvbS9saW5lYXIvaGxzL2V2ZW50L3dHNzVuNVU4UnJPS2lGemFXT2JYYkEvbWFzdGVyLm0zdTg=",
-	"sonyten2hd":     "aHR0cHM6Ly9kYWkuZ29vZ2xlLmNvbS9saW5lYXIvaGxzL2V2ZW50L1Y5aC1peU94UmlHcDQxcHBRU2NEU1EvbWFzdGVyLm0zdTg=",
-	"sonyten3hd":     "aHR0cHM6Ly9kYWkuZ29vZ2xlLmNvbS9saW5lYXIvaGxzL2V2ZW50L2x0c0NHN1RCU0NTRG15cTByUXR2U0EvbWFzdGVyLm0zdTg=",
-	"sonyten4hd":     "aHR0cHM6Ly9kYWkuZ29vZ2xlLmNvbS9saW5lYXIvaGxzL2V2ZW50L3NtWXliSV9KVG9XYUh6d294U0U5cUEvbWFzdGVyLm0zdTg=",
-	"sonyten5hd":     "aHR0cHM6Ly9kYWkuZ29vZ2xlLmNvbS9saW5lYXIvaGxzL2V2ZW50L1NsZV9UUjhyUUl1WkhXenNoRVhZalEvbWFzdGVyLm0zdTg=",
-	"sonybbcearthhd": "aHR0cHM6Ly9kYWkuZ29vZ2xlLmNvbS9saW5lYXIvaGxzL2V2ZW50LzZiVldZSUtHUzBDSWEtY09wWlpKUFEvbWFzdGVyLm0zdTg=",
-}
-
-var SONY_JIO_MAP = map[string]string{
-	"sl291":  "sonyhd",
-	"sl154":  "sonysabhd",
-	"sl474":  "sonypal",
-	"sl762":  "sonypixhd",
-	"sl476":  "sonymaxhd",
-	"sl483":  "sonymax2",
-	"sl1393": "sonywah",
-	"sl162":  "sonyten1hd",
-	"sl891":  "sonyten2hd",
-	"sl892":  "sonyten3hd",
-	"sl1772": "sonyten4hd",
-	"sl155":  "sonyten5hd",
-	"sl852":  "sonybbcearthhd",
-}
-
 // CustomChannel represents a custom channel definition from configuration file
 type CustomChannel struct {
 	ID       string `json:"id" yaml:"id"`
@@ -172,117 +156,19 @@ type CustomChannel struct {
 	Category int    `json:"category" yaml:"category"`
 	Language int    `json:"language" yaml:"language"`
 	IsHD     bool   `json:"is_hd" yaml:"is_hd"`
+	Number   int    `json:"number,omitempty" yaml:"number,omitempty"`
+	Group    string `json:"group,omitempty" yaml:"group,omitempty"`
+	// Order sorts the custom channels, from 1. Channels without an order follow in file order.
+	Order int `json:"order,omitempty" yaml:"order,omitempty"`
+	// Pinned lists the channel before all other channels
+	Pinned bool `json:"pinned,omitempty" yaml:"pinned,omitempty"`
 }
 
 // CustomChannelsConfig represents the structure of custom channels configuration file
 type CustomChannelsConfig struct {
 	Channels []CustomChannel `json:"channels" yaml:"channels"`
-}
-
-var SONY_CHANNELS_API = []Channel{
-	{
-		ID:       "sl291",
-		Name:     "SL Sony HD",
-		Language: 1,
-		Category: 5,
-		IsHD:     true,
-		LogoURL:  "Sony_HD.png",
-	},
-	{
-		ID:       "sl154",
-		Name:     "SL Sony SAB HD",
-		Language: 1,
-		Category: 5,
-		IsHD:     true,
-		LogoURL:  "Sony_SAB_HD.png",
-	},
-	{
-		ID:       "sl474",
-		Name:     "SL Sony PAL",
-		Language: 1,
-		Category: 5,
-		IsHD:     false,
-		LogoURL:  "Sony_Pal.png",
-	},
-	{
-		ID:       "sl762",
-		Name:     "SL Sony PIX HD",
-		Language: 6,
-		Category: 6,
-		IsHD:     true,
-		LogoURL:  "Sony_Pix_HD.png",
-	},
-	{
-		ID:       "sl476",
-		Name:     "SL Sony MAX HD",
-		Language: 1,
-		Category: 6,
-		IsHD:     true,
-		LogoURL:  "Sony_Max_HD.png",
-	},
-	{
-		ID:       "sl483",
-		Name:     "SL Sony MAX 2",
-		Language: 1,
-		Category: 6,
-		IsHD:     false,
-		LogoURL:  "Sony_MAX2.png",
-	},
-	// Disabled as it requires CORS bypass
-	// {
-	// 	ID:       "sl1393",
-	// 	Name:     "SL Sony WAH",
-	// 	Language: 1,
-	// 	Category: 5,
-	// 	IsHD:     false,
-	// 	LogoURL:  "Sony_Wah.png",
-	// },
-	{
-		ID:       "sl162",
-		Name:     "SL Sony TEN 1 HD",
-		Language: 6,
-		Category: 8,
-		IsHD:     true,
-		LogoURL:  "Ten_HD.png",
-	},
-	{
-		ID:       "sl891",
-		Name:     "SL Sony TEN 2 HD",
-		Language: 6,
-		Category: 8,
-		IsHD:     true,
-		LogoURL:  "Ten2_HD.png",
-	},
-	{
-		ID:       "sl892",
-		Name:     "SL Sony TEN 3 HD",
-		Language: 1,
-		Category: 8,
-		IsHD:     true,
-		LogoURL:  "Ten3_HD.png",
-	},
-	{
-		ID:       "sl1772",
-		Name:     "SL Sony TEN 4 HD",
-		Language: 8,
-		Category: 8,
-		IsHD:     true,
-		LogoURL:  "Ten_4_HD_Tamil.png",
-	},
-	{
-		ID:       "sl155",
-		Name:     "SL Sony TEN 5 HD",
-		Language: 6,
-		Category: 8,
-		IsHD:     true,
-		LogoURL:  "Six_HD.png",
-	},
-	{
-		ID:       "sl852",
-		Name:     "SL Sony BBC Earth HD",
-		Language: 6,
-		Category: 10,
-		IsHD:     true,
-		LogoURL:  "Sony_BBC_Earth_HD_English.png",
-	},
+	// ChannelNumbers numbers other channels, like JioTV channels, by their channel ID
+	ChannelNumbers map[string]int `json:"channel_numbers,omitempty" yaml:"channel_numbers,omitempty"`
+	// ChannelGroups puts other channels, like JioTV channels, in groups by their channel ID
+	ChannelGroups map[string]string `json:"channel_groups,omitempty" yaml:"channel_groups,omitempty"`
 }
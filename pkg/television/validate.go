@@ -0,0 +1,308 @@
+package television
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Levels of a custom channel issue
+const (
+	// IssueError leaves the channel out of the channel list
+	IssueError = "error"
+	// IssueWarning is reported, but the channel is kept
+	IssueWarning = "warning"
+)
+
+// ChannelIssue is a problem of an entry of the custom channels file
+type ChannelIssue struct {
+	// Index is the position of the channel in the channels list, from 0
+	Index int    `json:"index"`
+	ID    string `json:"channel_id,omitempty"`
+	// Line is the line of the channel in the file, 0 if unknown
+	Line    int    `json:"line,omitempty"`
+	Field   string `json:"field"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// String describes the issue with its position, e.g. `channels[3] (line 42, id "news"): url is empty`.
+func (i ChannelIssue) String() string {
+	var position []string
+	if i.Line > 0 {
+		position = append(position, fmt.Sprintf("line %d", i.Line))
+	}
+	if i.ID != "" {
+		position = append(position, fmt.Sprintf("id %q", i.ID))
+	}
+	where := fmt.Sprintf("channels[%d]", i.Index)
+	if len(position) > 0 {
+		where += " (" + strings.Join(position, ", ") + ")"
+	}
+	return fmt.Sprintf("%s: %s %s", where, i.Field, i.Message)
+}
+
+// ValidationError lists the issues of a custom channels file
+type ValidationError struct {
+	File   string
+	Issues []ChannelIssue
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Issues)+1)
+	problems := "problems"
+	if len(e.Issues) == 1 {
+		problems = "problem"
+	}
+	lines = append(lines, fmt.Sprintf("%s has %d %s:", e.File, len(e.Issues), problems))
+	for _, issue := range e.Issues {
+		lines = append(lines, "  "+issue.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// customChannelFields are the keys of a custom channel entry
+var customChannelFields = func() map[string]bool {
+	fields := map[string]bool{}
+	t := reflect.TypeOf(CustomChannel{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		fields[name] = true
+	}
+	return fields
+}()
+
+// channelEntry is the position and keys of a channel entry in the file
+type channelEntry struct {
+	Line int
+	Keys []string
+}
+
+// ValidateCustomChannels parses custom channels JSON or YAML and checks every channel. Errors of the
+// file itself, like invalid JSON, are returned with their line and column. The format is picked like
+// when loading the file, by the extension of filePath or else by the content.
+func ValidateCustomChannels(data []byte, filePath string) (CustomChannelsConfig, []ChannelIssue, error) {
+	customConfig, err := detectAndParseFormat(data, filePath)
+	if err != nil {
+		return CustomChannelsConfig{}, nil, withLineContext(data, err)
+	}
+	var entries []channelEntry
+	if isJSONChannels(data, filePath) {
+		entries = jsonChannelEntries(data)
+	} else {
+		entries = yamlChannelEntries(data)
+	}
+	if len(entries) != len(customConfig.Channels) {
+		entries = nil
+	}
+	return customConfig, validateChannels(customConfig.Channels, entries), nil
+}
+
+// validateChannels checks the fields of the channels. entries may be nil if the positions are unknown.
+func validateChannels(channels []CustomChannel, entries []channelEntry) []ChannelIssue {
+	var issues []ChannelIssue
+	seen := make(map[string]int, len(channels))
+	for i, channel := range channels {
+		var entry channelEntry
+		if entries != nil {
+			entry = entries[i]
+		}
+		add := func(field, level, message string) {
+			issues = append(issues, ChannelIssue{Index: i, ID: channel.ID, Line: entry.Line, Field: field, Level: level, Message: message})
+		}
+
+		if id := strings.TrimSpace(channel.ID); id == "" {
+			add("id", IssueError, "is empty")
+		} else {
+			normalized := normalizeCustomChannelID(id)
+			if first, ok := seen[normalized]; ok {
+				add("id", IssueError, fmt.Sprintf("is already used by channels[%d]", first))
+			} else {
+				seen[normalized] = i
+			}
+		}
+		if strings.TrimSpace(channel.Name) == "" {
+			add("name", IssueError, "is empty")
+		}
+		if strings.TrimSpace(channel.URL) == "" {
+			add("url", IssueError, "is empty")
+		} else if !isAbsoluteURL(channel.URL) {
+			add("url", IssueError, fmt.Sprintf("%q is not an absolute URL", channel.URL))
+		}
+		if channel.LogoURL != "" && !isAbsoluteURL(channel.LogoURL) {
+			add("logo_url", IssueWarning, fmt.Sprintf("%q is not an absolute URL", channel.LogoURL))
+		}
+		if _, ok := CategoryMap[channel.Category]; !ok {
+			add("category", IssueWarning, fmt.Sprintf("%d is not a known category ID", channel.Category))
+		}
+		if _, ok := LanguageMap[channel.Language]; !ok {
+			add("language", IssueWarning, fmt.Sprintf("%d is not a known language ID", channel.Language))
+		}
+		if channel.Number < 0 {
+			add("number", IssueWarning, "must not be negative")
+		}
+		if channel.Order < 0 {
+			add("order", IssueWarning, "must not be negative")
+		}
+		for _, key := range entry.Keys {
+			if !customChannelFields[key] {
+				add(key, IssueWarning, "is not a custom channel field and is ignored")
+			}
+		}
+	}
+	return issues
+}
+
+// validCustomChannels returns the channels without error issues.
+func validCustomChannels(channels []CustomChannel, issues []ChannelIssue) []CustomChannel {
+	invalid := map[int]bool{}
+	for _, issue := range issues {
+		if issue.Level == IssueError {
+			invalid[issue.Index] = true
+		}
+	}
+	if len(invalid) == 0 {
+		return channels
+	}
+	valid := make([]CustomChannel, 0, len(channels)-len(invalid))
+	for i, channel := range channels {
+		if !invalid[i] {
+			valid = append(valid, channel)
+		}
+	}
+	return valid
+}
+
+func isAbsoluteURL(value string) bool {
+	u, err := url.Parse(strings.TrimSpace(value))
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// isJSONChannels reports whether the file is read as JSON, like detectAndParseFormat does.
+func isJSONChannels(data []byte, filePath string) bool {
+	if strings.HasSuffix(filePath, ".json") {
+		return true
+	}
+	if strings.HasSuffix(filePath, ".yml") || strings.HasSuffix(filePath, ".yaml") {
+		return false
+	}
+	trimmed := bytes.TrimSpace(data)
+	return (bytes.HasPrefix(trimmed, []byte("{")) || bytes.HasPrefix(trimmed, []byte("["))) && json.Valid(trimmed)
+}
+
+// jsonChannelEntries returns the line and keys of each entry of the channels list of JSON data.
+func jsonChannelEntries(data []byte) []channelEntry {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		return nil
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil
+		}
+		if key != "channels" {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil
+			}
+			continue
+		}
+		if token, err := dec.Token(); err != nil || token != json.Delim('[') {
+			return nil
+		}
+		var entries []channelEntry
+		for dec.More() {
+			start := int(dec.InputOffset())
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return nil
+			}
+			// The offset is after the previous token, skip the comma and spaces before the entry
+			start += bytes.IndexFunc(data[start:], func(r rune) bool {
+				return r != ',' && r != ' ' && r != '\t' && r != '\n' && r != '\r'
+			})
+			entry := channelEntry{Line: lineAt(data, start)}
+			var fields map[string]json.RawMessage
+			if json.Unmarshal(raw, &fields) == nil {
+				for key := range fields {
+					entry.Keys = append(entry.Keys, key)
+				}
+				sort.Strings(entry.Keys)
+			}
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+	return nil
+}
+
+// yamlChannelEntries returns the line and keys of each entry of the channels list of YAML data.
+func yamlChannelEntries(data []byte) []channelEntry {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "channels" {
+			continue
+		}
+		list := root.Content[i+1]
+		if list.Kind != yaml.SequenceNode {
+			return nil
+		}
+		entries := make([]channelEntry, 0, len(list.Content))
+		for _, item := range list.Content {
+			entry := channelEntry{Line: item.Line}
+			if item.Kind == yaml.MappingNode {
+				for j := 0; j+1 < len(item.Content); j += 2 {
+					entry.Keys = append(entry.Keys, item.Content[j].Value)
+				}
+			}
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+	return nil
+}
+
+// withLineContext adds the line and column to JSON errors, which only have a byte offset.
+// YAML errors already have the line.
+func withLineContext(data []byte, err error) error {
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		return err
+	}
+	line, column := lineAt(data, int(offset)), columnAt(data, int(offset))
+	return fmt.Errorf("line %d, column %d: %w", line, column, err)
+}
+
+// lineAt returns the line of the byte offset, from 1.
+func lineAt(data []byte, offset int) int {
+	offset = min(max(offset, 0), len(data))
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// columnAt returns the column of the byte offset, from 1.
+func columnAt(data []byte, offset int) int {
+	offset = min(max(offset, 0), len(data))
+	return offset - (bytes.LastIndexByte(data[:offset], '\n') + 1) + 1
+}
@@ -0,0 +1,109 @@
+package television
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateCustomChannels(t *testing.T) {
+	jsonData := `{
+  "channels": [
+    {"id": "news", "name": "News", "url": "https://example.com/news.m3u8", "category": 12, "language": 6},
+    {
+      "id": "",
+      "name": "No ID",
+      "url": "https://example.com/noid.m3u8"
+    },
+    {"id": "cc_news", "name": "News Again", "url": "https://example.com/news2.m3u8"},
+    {"id": "music", "name": "Music", "url": "", "category": 99},
+    {"id": "kids", "name": "Kids", "url": "example.com/kids.m3u8", "logo": "https://example.com/kids.png"}
+  ]
+}`
+	yamlData := `channels:
+  - id: news
+    name: News
+    url: https://example.com/news.m3u8
+  - id: music
+    name: ""
+    url: https://example.com/music.m3u8
+    language: 42
+`
+
+	tests := []struct {
+		name       string
+		data       string
+		filePath   string
+		wantIssues []string
+		wantErr    string
+	}{
+		{
+			name:     "JSON",
+			data:     jsonData,
+			filePath: "custom-channels.json",
+			wantIssues: []string{
+				`channels[1] (line 4): id is empty`,
+				`channels[2] (line 9, id "cc_news"): id is already used by channels[0]`,
+				`channels[3] (line 10, id "music"): url is empty`,
+				`channels[3] (line 10, id "music"): category 99 is not a known category ID`,
+				`channels[4] (line 11, id "kids"): url "example.com/kids.m3u8" is not an absolute URL`,
+				`channels[4] (line 11, id "kids"): logo is not a custom channel field and is ignored`,
+			},
+		},
+		{
+			name:     "YAML",
+			data:     yamlData,
+			filePath: "custom-channels.yml",
+			wantIssues: []string{
+				`channels[1] (line 5, id "music"): name is empty`,
+				`channels[1] (line 5, id "music"): language 42 is not a known language ID`,
+			},
+		},
+		{
+			name:     "JSON detected by content",
+			data:     `{"channels": [{"id": "a", "name": "A", "url": "https://example.com/a.m3u8", "number": 7, "group": "G", "extra": 1}]}`,
+			filePath: "channels",
+			wantIssues: []string{
+				`channels[0] (line 1, id "a"): extra is not a custom channel field and is ignored`,
+			},
+		},
+		{
+			name:     "invalid JSON",
+			data:     "{\n  \"channels\": [\n    {\"id\": \"a\",}\n  ]\n}",
+			filePath: "custom-channels.json",
+			wantErr:  "line 3, column",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, issues, err := ValidateCustomChannels([]byte(tt.data), tt.filePath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ValidateCustomChannels() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateCustomChannels() error = %v", err)
+			}
+			var got []string
+			for _, issue := range issues {
+				got = append(got, issue.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.wantIssues, "\n") {
+				t.Errorf("issues =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.wantIssues, "\n"))
+			}
+		})
+	}
+}
+
+func TestValidCustomChannels(t *testing.T) {
+	channels := []CustomChannel{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	issues := []ChannelIssue{
+		{Index: 0, Level: IssueWarning},
+		{Index: 1, Level: IssueError},
+	}
+	valid := validCustomChannels(channels, issues)
+	if len(valid) != 2 || valid[0].ID != "a" || valid[1].ID != "c" {
+		t.Errorf("validCustomChannels() = %v, want channels a and c", valid)
+	}
+}
@@ -0,0 +1,317 @@
+package timeshift
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrSegmentNotFound is returned for segments that are not or no longer in the buffer
+var ErrSegmentNotFound = errors.New("segment is not in the timeshift buffer")
+
+// Source is the upstream live stream recorded by a buffer
+type Source interface {
+	// Playlist returns the current media playlist of the stream
+	Playlist() (*MediaPlaylist, error)
+	// Segment downloads a segment of the playlist
+	Segment(uri string) ([]byte, error)
+}
+
+// segment is a recorded segment, kept in memory or in a file
+type segment struct {
+	id              int64
+	duration        float64
+	key             *Key
+	discontinuity   bool
+	programDateTime string
+	data            []byte
+	path            string
+}
+
+// Buffer is a rolling recording of a live stream, served as an HLS playlist
+// that players can pause and seek back in.
+type Buffer struct {
+	window time.Duration
+	// dir holds the segment files, or is empty to keep segments in memory
+	dir string
+
+	mu       sync.RWMutex
+	segments []*segment
+	duration float64
+	nextID   int64
+	// discontinuitySequence counts the discontinuities of segments dropped from the buffer
+	discontinuitySequence int64
+	targetDuration        int
+	// lastUpstream is the media sequence number of the last recorded upstream segment
+	lastUpstream int64
+	recording    bool
+	// gap marks the next recorded segment as a discontinuity
+	gap        bool
+	lastErr    error
+	lastAccess time.Time
+	// lastAdded is when the last segment was recorded
+	lastAdded time.Time
+
+	ready     chan struct{}
+	readyOnce sync.Once
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newBuffer returns an empty buffer that keeps up to window of the stream.
+func newBuffer(window time.Duration, dir string) *Buffer {
+	return &Buffer{
+		window:     window,
+		dir:        dir,
+		lastAccess: time.Now(),
+		// Segment IDs continue to grow across buffers of a stream, so that players never see an ID again
+		nextID: time.Now().Unix(),
+		ready:  make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// touch records that a player uses the buffer.
+func (b *Buffer) touch() {
+	b.mu.Lock()
+	b.lastAccess = time.Now()
+	b.mu.Unlock()
+}
+
+// idleFor returns how long no player has used the buffer.
+func (b *Buffer) idleFor(now time.Time) time.Duration {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return now.Sub(b.lastAccess)
+}
+
+// Wait blocks until the buffer has its first segment, it stops recording or the timeout expires.
+// It returns the last recording error if the buffer has no segments.
+func (b *Buffer) Wait(timeout time.Duration) error {
+	select {
+	case <-b.ready:
+		return nil
+	case <-b.done:
+	case <-time.After(timeout):
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.segments) > 0 {
+		return nil
+	}
+	if b.lastErr != nil {
+		return b.lastErr
+	}
+	return errors.New("timeshift buffer has no segments yet")
+}
+
+// Duration returns the length of the recorded stream.
+func (b *Buffer) Duration() time.Duration {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return time.Duration(b.duration * float64(time.Second))
+}
+
+// Stats describes the health of a buffer
+type Stats struct {
+	// Duration is the length of the recorded stream
+	Duration time.Duration
+	// Segments is the number of recorded segments
+	Segments int
+	// LastSegment is when the last segment was recorded, or zero before the first one
+	LastSegment time.Time
+	// Err is the last error recording the stream, or nil after a segment was recorded since
+	Err error
+}
+
+// Stats returns the health of the buffer. Unlike Playlist and Segment, it does not count as a
+// player using the buffer.
+func (b *Buffer) Stats() Stats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return Stats{
+		Duration:    time.Duration(b.duration * float64(time.Second)),
+		Segments:    len(b.segments),
+		LastSegment: b.lastAdded,
+		Err:         b.lastErr,
+	}
+}
+
+// newSegments returns the segments of an upstream playlist that are not recorded yet.
+func (b *Buffer) newSegments(playlist *MediaPlaylist) []RemoteSegment {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if playlist.TargetDuration > b.targetDuration {
+		b.targetDuration = playlist.TargetDuration
+	}
+	if len(playlist.Segments) == 0 {
+		return nil
+	}
+	if !b.recording {
+		return playlist.Segments
+	}
+
+	last := playlist.Segments[len(playlist.Segments)-1].Sequence
+	if last < b.lastUpstream {
+		// The upstream stream restarted with new sequence numbers
+		b.gap = true
+		return playlist.Segments
+	}
+	var fresh []RemoteSegment
+	for _, remote := range playlist.Segments {
+		if remote.Sequence > b.lastUpstream {
+			fresh = append(fresh, remote)
+		}
+	}
+	if len(fresh) > 0 && fresh[0].Sequence > b.lastUpstream+1 {
+		// Segments were missed, e.g. while the upstream was unreachable
+		b.gap = true
+	}
+	return fresh
+}
+
+// add records a downloaded segment and drops the oldest segments beyond the window.
+func (b *Buffer) add(remote RemoteSegment, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	select {
+	case <-b.done:
+		return errors.New("timeshift buffer is closed")
+	default:
+	}
+
+	s := &segment{
+		id:              b.nextID,
+		duration:        remote.Duration,
+		key:             remote.Key,
+		discontinuity:   remote.Discontinuity || b.gap,
+		programDateTime: remote.ProgramDateTime,
+	}
+	if b.dir != "" {
+		s.path = filepath.Join(b.dir, fmt.Sprintf("%d.ts", s.id))
+		if err := os.WriteFile(s.path, data, 0o600); err != nil {
+			return err
+		}
+	} else {
+		s.data = data
+	}
+	if len(b.segments) == 0 {
+		// A discontinuity before the first segment has no meaning
+		s.discontinuity = false
+	}
+
+	b.segments = append(b.segments, s)
+	b.duration += s.duration
+	b.nextID++
+	b.lastUpstream = remote.Sequence
+	b.recording = true
+	b.gap = false
+	b.lastErr = nil
+	b.lastAdded = time.Now()
+
+	for len(b.segments) > 1 && b.duration-b.segments[0].duration >= b.window.Seconds() {
+		b.drop()
+	}
+	b.readyOnce.Do(func() { close(b.ready) })
+	return nil
+}
+
+// drop removes the oldest segment. The caller must hold the write lock.
+func (b *Buffer) drop() {
+	oldest := b.segments[0]
+	b.segments[0] = nil
+	b.segments = b.segments[1:]
+	b.duration -= oldest.duration
+	if b.segments[0].discontinuity {
+		b.discontinuitySequence++
+	}
+	if oldest.path != "" {
+		os.Remove(oldest.path)
+	}
+}
+
+// setError records a failure to update the buffer.
+func (b *Buffer) setError(err error) {
+	b.mu.Lock()
+	b.lastErr = err
+	b.mu.Unlock()
+}
+
+// Playlist returns the recorded stream as an HLS media playlist without end, so that players treat
+// it as live with the whole buffer seekable. segmentURL and keyURL return the URIs of segments and
+// encryption keys as seen by the player.
+func (b *Buffer) Playlist(segmentURL func(id int64) string, keyURL func(uri string) string) []byte {
+	b.touch()
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var out bytes.Buffer
+	out.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	fmt.Fprintf(&out, "#EXT-X-TARGETDURATION:%d\n", max(b.targetDuration, 1))
+	if len(b.segments) > 0 {
+		fmt.Fprintf(&out, "#EXT-X-MEDIA-SEQUENCE:%d\n", b.segments[0].id)
+	}
+	if b.discontinuitySequence > 0 {
+		fmt.Fprintf(&out, "#EXT-X-DISCONTINUITY-SEQUENCE:%d\n", b.discontinuitySequence)
+	}
+
+	var currentKey *Key
+	for _, s := range b.segments {
+		if s.discontinuity {
+			out.WriteString("#EXT-X-DISCONTINUITY\n")
+		}
+		switch {
+		case s.key != nil && (currentKey == nil || *s.key != *currentKey):
+			fmt.Fprintf(&out, "#EXT-X-KEY:METHOD=%s,URI=\"%s\"", s.key.Method, keyURL(s.key.URI))
+			if s.key.IV != "" {
+				fmt.Fprintf(&out, ",IV=%s", s.key.IV)
+			}
+			out.WriteString("\n")
+		case s.key == nil && currentKey != nil:
+			out.WriteString("#EXT-X-KEY:METHOD=NONE\n")
+		}
+		currentKey = s.key
+		if s.programDateTime != "" {
+			fmt.Fprintf(&out, "#EXT-X-PROGRAM-DATE-TIME:%s\n", s.programDateTime)
+		}
+		fmt.Fprintf(&out, "#EXTINF:%.3f,\n%s\n", s.duration, segmentURL(s.id))
+	}
+	return out.Bytes()
+}
+
+// Segment returns a recorded segment.
+func (b *Buffer) Segment(id int64) ([]byte, error) {
+	b.touch()
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.segments) == 0 {
+		return nil, ErrSegmentNotFound
+	}
+	index := id - b.segments[0].id
+	if index < 0 || index >= int64(len(b.segments)) {
+		return nil, ErrSegmentNotFound
+	}
+	s := b.segments[index]
+	if s.path == "" {
+		return s.data, nil
+	}
+	return os.ReadFile(s.path)
+}
+
+// close stops recording and frees the recorded segments.
+func (b *Buffer) close() {
+	b.closeOnce.Do(func() {
+		close(b.done)
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.segments = nil
+		b.duration = 0
+		if b.dir != "" {
+			os.RemoveAll(b.dir)
+		}
+	})
+}
@@ -0,0 +1,185 @@
+package timeshift
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func remoteSegments(first, count int64, duration float64) []RemoteSegment {
+	var segments []RemoteSegment
+	for seq := first; seq < first+count; seq++ {
+		segments = append(segments, RemoteSegment{
+			Sequence: seq,
+			Duration: duration,
+			URI:      fmt.Sprintf("https://cdn.example.com/seg-%d.ts", seq),
+		})
+	}
+	return segments
+}
+
+func segmentURL(id int64) string {
+	return fmt.Sprintf("%d.ts", id)
+}
+
+func keyURL(uri string) string {
+	return "/render.key?uri=" + uri
+}
+
+func TestBufferWindow(t *testing.T) {
+	for _, storage := range []string{StorageMemory, StorageDisk} {
+		t.Run(storage, func(t *testing.T) {
+			dir := ""
+			if storage == StorageDisk {
+				dir = t.TempDir()
+			}
+			b := newBuffer(20*time.Second, dir)
+			b.nextID = 0
+			for _, remote := range remoteSegments(1, 6, 6) {
+				if err := b.add(remote, []byte(remote.URI)); err != nil {
+					t.Fatalf("add() error = %v", err)
+				}
+			}
+
+			// 4 segments of 6 seconds are needed to always keep 20 seconds
+			if got := b.Duration(); got != 24*time.Second {
+				t.Errorf("Duration() = %v, want 24s", got)
+			}
+			playlist := string(b.Playlist(segmentURL, keyURL))
+			if !strings.Contains(playlist, "#EXT-X-MEDIA-SEQUENCE:2\n") || strings.Count(playlist, "#EXTINF:6.000,") != 4 ||
+				!strings.HasSuffix(playlist, "5.ts\n") || strings.Contains(playlist, "#EXT-X-ENDLIST") {
+				t.Errorf("Playlist() = %s", playlist)
+			}
+
+			data, err := b.Segment(2)
+			if err != nil || string(data) != "https://cdn.example.com/seg-3.ts" {
+				t.Errorf("Segment(2) = %q, %v", data, err)
+			}
+			if _, err := b.Segment(1); !errors.Is(err, ErrSegmentNotFound) {
+				t.Errorf("Segment(1) error = %v, want %v", err, ErrSegmentNotFound)
+			}
+			if _, err := b.Segment(6); !errors.Is(err, ErrSegmentNotFound) {
+				t.Errorf("Segment(6) error = %v, want %v", err, ErrSegmentNotFound)
+			}
+
+			if dir != "" {
+				entries, _ := os.ReadDir(dir)
+				if len(entries) != 4 {
+					t.Errorf("%d segment files, want 4", len(entries))
+				}
+				b.close()
+				if _, err := os.Stat(dir); !os.IsNotExist(err) {
+					t.Errorf("buffer directory still exists after close: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestBufferNewSegments(t *testing.T) {
+	b := newBuffer(time.Hour, "")
+	b.nextID = 0
+	record := func(playlist *MediaPlaylist) []int64 {
+		var sequences []int64
+		for _, remote := range b.newSegments(playlist) {
+			if err := b.add(remote, nil); err != nil {
+				t.Fatalf("add() error = %v", err)
+			}
+			sequences = append(sequences, remote.Sequence)
+		}
+		return sequences
+	}
+
+	tests := []struct {
+		name     string
+		playlist *MediaPlaylist
+		want     []int64
+	}{
+		{"first playlist", &MediaPlaylist{TargetDuration: 6, Segments: remoteSegments(10, 3, 6)}, []int64{10, 11, 12}},
+		{"reload", &MediaPlaylist{TargetDuration: 6, Segments: remoteSegments(11, 3, 6)}, []int64{13}},
+		{"unchanged", &MediaPlaylist{TargetDuration: 6, Segments: remoteSegments(11, 3, 6)}, nil},
+		{"missed segments", &MediaPlaylist{TargetDuration: 6, Segments: remoteSegments(20, 2, 6)}, []int64{20, 21}},
+		{"restarted stream", &MediaPlaylist{TargetDuration: 6, Segments: remoteSegments(1, 1, 6)}, []int64{1}},
+	}
+	for _, tt := range tests {
+		got := record(tt.playlist)
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: recorded %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	playlist := string(b.Playlist(segmentURL, keyURL))
+	// Discontinuities before the first segment after the gap and after the restart
+	if strings.Count(playlist, "#EXT-X-DISCONTINUITY\n") != 2 || !strings.Contains(playlist, "#EXT-X-DISCONTINUITY\n#EXTINF:6.000,\n4.ts") ||
+		!strings.Contains(playlist, "#EXT-X-DISCONTINUITY\n#EXTINF:6.000,\n6.ts") {
+		t.Errorf("Playlist() = %s", playlist)
+	}
+}
+
+func TestBufferDiscontinuitySequence(t *testing.T) {
+	b := newBuffer(12*time.Second, "")
+	b.nextID = 0
+	segments := remoteSegments(1, 5, 6)
+	segments[1].Discontinuity = true
+	for _, remote := range segments {
+		if err := b.add(remote, nil); err != nil {
+			t.Fatalf("add() error = %v", err)
+		}
+	}
+	playlist := string(b.Playlist(segmentURL, keyURL))
+	if !strings.Contains(playlist, "#EXT-X-MEDIA-SEQUENCE:3\n#EXT-X-DISCONTINUITY-SEQUENCE:1\n") || strings.Contains(playlist, "#EXT-X-DISCONTINUITY\n") {
+		t.Errorf("Playlist() = %s", playlist)
+	}
+}
+
+func TestBufferStats(t *testing.T) {
+	b := newBuffer(time.Minute, "")
+	if stats := b.Stats(); stats.Segments != 0 || !stats.LastSegment.IsZero() {
+		t.Errorf("Stats() of an empty buffer = %+v", stats)
+	}
+	accessed := b.lastAccess
+
+	before := time.Now()
+	for _, remote := range remoteSegments(1, 3, 4) {
+		if err := b.add(remote, nil); err != nil {
+			t.Fatalf("add() error = %v", err)
+		}
+	}
+	b.setError(errors.New("upstream unreachable"))
+	stats := b.Stats()
+	if stats.Segments != 3 || stats.Duration != 12*time.Second || stats.LastSegment.Before(before) {
+		t.Errorf("Stats() = %+v, want 3 segments of 12s recorded after %v", stats, before)
+	}
+	if stats.Err == nil || stats.Err.Error() != "upstream unreachable" {
+		t.Errorf("Stats().Err = %v, want the last error", stats.Err)
+	}
+	if b.lastAccess != accessed {
+		t.Error("Stats() counted as a player using the buffer")
+	}
+}
+
+func TestBufferPlaylistKeys(t *testing.T) {
+	b := newBuffer(time.Hour, "")
+	b.nextID = 0
+	key := &Key{Method: "AES-128", URI: "https://keys.example.com/k", IV: "0x01"}
+	segments := remoteSegments(1, 4, 6)
+	segments[0].Key = key
+	segments[1].Key = &Key{Method: "AES-128", URI: "https://keys.example.com/k", IV: "0x01"}
+	segments[2].Key = &Key{Method: "AES-128", URI: "https://keys.example.com/k", IV: "0x03"}
+	for _, remote := range segments {
+		if err := b.add(remote, nil); err != nil {
+			t.Fatalf("add() error = %v", err)
+		}
+	}
+
+	want := "#EXT-X-KEY:METHOD=AES-128,URI=\"/render.key?uri=https://keys.example.com/k\",IV=0x01\n#EXTINF:6.000,\n0.ts\n" +
+		"#EXTINF:6.000,\n1.ts\n" +
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"/render.key?uri=https://keys.example.com/k\",IV=0x03\n#EXTINF:6.000,\n2.ts\n" +
+		"#EXT-X-KEY:METHOD=NONE\n#EXTINF:6.000,\n3.ts\n"
+	if playlist := string(b.Playlist(segmentURL, keyURL)); !strings.HasSuffix(playlist, want) {
+		t.Errorf("Playlist() = %s, want suffix %s", playlist, want)
+	}
+}
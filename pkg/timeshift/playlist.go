@@ -0,0 +1,215 @@
+package timeshift
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrAlternateAudio is returned for streams whose audio is a separate rendition,
+// which the buffer cannot record together with the video
+var ErrAlternateAudio = errors.New("stream has separate audio renditions")
+
+// Key is the encryption of a segment, from its #EXT-X-KEY tag
+type Key struct {
+	Method string
+	URI    string
+	IV     string
+}
+
+// RemoteSegment is a segment of an upstream media playlist
+type RemoteSegment struct {
+	Sequence        int64
+	Duration        float64
+	URI             string
+	Key             *Key
+	Discontinuity   bool
+	ProgramDateTime string
+}
+
+// MediaPlaylist is an upstream HLS media playlist
+type MediaPlaylist struct {
+	TargetDuration int
+	Segments       []RemoteSegment
+}
+
+// Variant is a stream of an HLS master playlist
+type Variant struct {
+	URI       string
+	Bandwidth int
+}
+
+// IsMasterPlaylist reports whether an HLS playlist lists variant streams rather than segments.
+func IsMasterPlaylist(body []byte) bool {
+	return bytes.Contains(body, []byte("#EXT-X-STREAM-INF"))
+}
+
+// ParseMasterPlaylist returns the variants of an HLS master playlist with URIs resolved against base.
+// It returns ErrAlternateAudio if the audio is not muxed into the variants.
+func ParseMasterPlaylist(body []byte, base *url.URL) ([]Variant, error) {
+	var variants []Variant
+	var pending *Variant
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXT-X-MEDIA:"):
+			attrs := parseAttributes(strings.TrimPrefix(line, "#EXT-X-MEDIA:"))
+			if attrs["TYPE"] == "AUDIO" && attrs["URI"] != "" {
+				return nil, ErrAlternateAudio
+			}
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
+			attrs := parseAttributes(strings.TrimPrefix(line, "#EXT-X-STREAM-INF:"))
+			bandwidth, _ := strconv.Atoi(attrs["BANDWIDTH"])
+			pending = &Variant{Bandwidth: bandwidth}
+		case strings.HasPrefix(line, "#"):
+		case pending != nil:
+			pending.URI = resolve(base, line)
+			variants = append(variants, *pending)
+			pending = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(variants) == 0 {
+		return nil, errors.New("master playlist has no variants")
+	}
+	return variants, nil
+}
+
+// SelectVariant returns the variant for a quality: the highest bandwidth for "high" and "auto",
+// the lowest for "low" and the middle one for "medium".
+func SelectVariant(variants []Variant, quality string) Variant {
+	sorted := append([]Variant(nil), variants...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Bandwidth < sorted[j].Bandwidth
+	})
+	switch quality {
+	case "low":
+		return sorted[0]
+	case "medium":
+		return sorted[len(sorted)/2]
+	default:
+		return sorted[len(sorted)-1]
+	}
+}
+
+// ParseMediaPlaylist parses an HLS media playlist with URIs resolved against base.
+// AES-128 keys without IV get the IV implied by the media sequence number, so that segments
+// can be served again with other sequence numbers.
+func ParseMediaPlaylist(body []byte, base *url.URL) (*MediaPlaylist, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(body), []byte("#EXTM3U")) {
+		return nil, errors.New("not an HLS playlist")
+	}
+
+	playlist := &MediaPlaylist{}
+	var (
+		sequence        int64
+		duration        float64
+		key             *Key
+		discontinuity   bool
+		programDateTime string
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXT-X-TARGETDURATION:"):
+			playlist.TargetDuration, _ = strconv.Atoi(strings.TrimPrefix(line, "#EXT-X-TARGETDURATION:"))
+		case strings.HasPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"):
+			sequence, _ = strconv.ParseInt(strings.TrimPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"), 10, 64)
+		case strings.HasPrefix(line, "#EXT-X-KEY:"):
+			attrs := parseAttributes(strings.TrimPrefix(line, "#EXT-X-KEY:"))
+			if attrs["METHOD"] == "" || attrs["METHOD"] == "NONE" {
+				key = nil
+				continue
+			}
+			key = &Key{Method: attrs["METHOD"], URI: resolve(base, attrs["URI"]), IV: attrs["IV"]}
+		case line == "#EXT-X-DISCONTINUITY":
+			discontinuity = true
+		case strings.HasPrefix(line, "#EXT-X-PROGRAM-DATE-TIME:"):
+			programDateTime = strings.TrimPrefix(line, "#EXT-X-PROGRAM-DATE-TIME:")
+		case strings.HasPrefix(line, "#EXTINF:"):
+			value, _, _ := strings.Cut(strings.TrimPrefix(line, "#EXTINF:"), ",")
+			duration, _ = strconv.ParseFloat(strings.TrimSpace(value), 64)
+		case strings.HasPrefix(line, "#"):
+		default:
+			segment := RemoteSegment{
+				Sequence:        sequence,
+				Duration:        duration,
+				URI:             resolve(base, line),
+				Discontinuity:   discontinuity,
+				ProgramDateTime: programDateTime,
+			}
+			if key != nil {
+				segmentKey := *key
+				if segmentKey.Method == "AES-128" && segmentKey.IV == "" {
+					segmentKey.IV = fmt.Sprintf("0x%032X", sequence)
+				}
+				segment.Key = &segmentKey
+			}
+			playlist.Segments = append(playlist.Segments, segment)
+			sequence++
+			duration = 0
+			discontinuity = false
+			programDateTime = ""
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if playlist.TargetDuration <= 0 {
+		for _, segment := range playlist.Segments {
+			playlist.TargetDuration = max(playlist.TargetDuration, int(math.Ceil(segment.Duration)))
+		}
+	}
+	return playlist, nil
+}
+
+// parseAttributes parses an HLS attribute list like `METHOD=AES-128,URI="key?a=1,2"`.
+func parseAttributes(list string) map[string]string {
+	attrs := make(map[string]string)
+	for list != "" {
+		name, rest, ok := strings.Cut(list, "=")
+		if !ok {
+			break
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+			rest = strings.TrimPrefix(rest, ",")
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		attrs[strings.TrimSpace(name)] = value
+		list = rest
+	}
+	return attrs
+}
+
+// resolve returns ref as an absolute URL relative to base.
+func resolve(base *url.URL, ref string) string {
+	if base == nil || ref == "" {
+		return ref
+	}
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(parsed).String()
+}
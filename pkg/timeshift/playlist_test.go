@@ -0,0 +1,131 @@
+package timeshift
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParseMasterPlaylist(t *testing.T) {
+	base, _ := url.Parse("https://cdn.example.com/bpk-tv/News/index.m3u8?a=1")
+	tests := []struct {
+		name    string
+		body    string
+		want    []Variant
+		wantErr error
+	}{
+		{
+			name: "muxed variants",
+			body: "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=800000,CODECS=\"avc1.4d401f,mp4a.40.2\"\nNews-audio_1=96000-video=700000.m3u8\n" +
+				"#EXT-X-STREAM-INF:BANDWIDTH=3000000,RESOLUTION=1280x720\nhttps://other.example.com/high.m3u8\n",
+			want: []Variant{
+				{URI: "https://cdn.example.com/bpk-tv/News/News-audio_1=96000-video=700000.m3u8", Bandwidth: 800000},
+				{URI: "https://other.example.com/high.m3u8", Bandwidth: 3000000},
+			},
+		},
+		{
+			name: "separate audio",
+			body: "#EXTM3U\n#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aac\",NAME=\"Hindi\",URI=\"audio.m3u8\"\n" +
+				"#EXT-X-STREAM-INF:BANDWIDTH=800000,AUDIO=\"aac\"\nvideo.m3u8\n",
+			wantErr: ErrAlternateAudio,
+		},
+		{
+			name:    "no variants",
+			body:    "#EXTM3U\n",
+			wantErr: errors.New("master playlist has no variants"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMasterPlaylist([]byte(tt.body), base)
+			if (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("ParseMasterPlaylist() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(tt.wantErr, ErrAlternateAudio) && !errors.Is(err, ErrAlternateAudio) {
+				t.Errorf("ParseMasterPlaylist() error = %v, want %v", err, ErrAlternateAudio)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMasterPlaylist() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectVariant(t *testing.T) {
+	variants := []Variant{
+		{URI: "medium", Bandwidth: 1500000},
+		{URI: "high", Bandwidth: 3000000},
+		{URI: "low", Bandwidth: 400000},
+	}
+	for quality, want := range map[string]string{"low": "low", "medium": "medium", "high": "high", "auto": "high"} {
+		if got := SelectVariant(variants, quality).URI; got != want {
+			t.Errorf("SelectVariant(%s) = %s, want %s", quality, got, want)
+		}
+	}
+}
+
+func TestParseMediaPlaylist(t *testing.T) {
+	base, _ := url.Parse("https://cdn.example.com/bpk-tv/News/video.m3u8")
+	body := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:100
+#EXT-X-KEY:METHOD=AES-128,URI="https://keys.example.com/key?id=1,2"
+#EXT-X-PROGRAM-DATE-TIME:2024-01-11T10:00:00.000Z
+#EXTINF:6.000,
+seg-100.ts
+#EXT-X-KEY:METHOD=AES-128,URI="key2",IV=0x0000000000000000000000000000ABCD
+#EXTINF:5.5,title
+seg-101.ts
+#EXT-X-DISCONTINUITY
+#EXT-X-KEY:METHOD=NONE
+#EXTINF:4,
+/ads/ad.ts
+`
+	got, err := ParseMediaPlaylist([]byte(body), base)
+	if err != nil {
+		t.Fatalf("ParseMediaPlaylist() error = %v", err)
+	}
+	want := &MediaPlaylist{
+		TargetDuration: 6,
+		Segments: []RemoteSegment{
+			{
+				Sequence:        100,
+				Duration:        6,
+				URI:             "https://cdn.example.com/bpk-tv/News/seg-100.ts",
+				Key:             &Key{Method: "AES-128", URI: "https://keys.example.com/key?id=1,2", IV: "0x00000000000000000000000000000064"},
+				ProgramDateTime: "2024-01-11T10:00:00.000Z",
+			},
+			{
+				Sequence: 101,
+				Duration: 5.5,
+				URI:      "https://cdn.example.com/bpk-tv/News/seg-101.ts",
+				Key:      &Key{Method: "AES-128", URI: "https://cdn.example.com/bpk-tv/News/key2", IV: "0x0000000000000000000000000000ABCD"},
+			},
+			{
+				Sequence:      102,
+				Duration:      4,
+				URI:           "https://cdn.example.com/ads/ad.ts",
+				Discontinuity: true,
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseMediaPlaylist() = %+v, want %+v", got, want)
+	}
+
+	if _, err := ParseMediaPlaylist([]byte("<html>Forbidden</html>"), base); err == nil {
+		t.Error("ParseMediaPlaylist() of a non-playlist succeeded")
+	}
+}
+
+func TestParseMediaPlaylistTargetDuration(t *testing.T) {
+	got, err := ParseMediaPlaylist([]byte("#EXTM3U\n#EXTINF:4.2,\na.ts\n#EXTINF:6.4,\nb.ts\n"), nil)
+	if err != nil {
+		t.Fatalf("ParseMediaPlaylist() error = %v", err)
+	}
+	if got.TargetDuration != 7 {
+		t.Errorf("TargetDuration = %d, want 7", got.TargetDuration)
+	}
+}
@@ -0,0 +1,178 @@
+// Package timeshift records a rolling buffer of the live channels being watched,
+// so that players can pause live TV and seek back instead of always playing at the live edge.
+package timeshift
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// StorageMemory keeps buffers in memory
+	StorageMemory = "memory"
+	// StorageDisk keeps buffers in the system temporary directory
+	StorageDisk = "disk"
+
+	// maxBuffers is the number of channels that are recorded at the same time
+	maxBuffers = 4
+	// minIdleTimeout is the shortest time a buffer is kept without players
+	minIdleTimeout = time.Minute
+	// minPollInterval and maxPollInterval bound how often the upstream playlist is reloaded
+	minPollInterval = time.Second
+	maxPollInterval = 5 * time.Second
+	// dirName is the directory under the system temporary directory for disk buffers
+	dirName = "jiotv_go_timeshift"
+)
+
+// ErrTooManyBuffers is returned when the maximum number of channels is already recorded
+var ErrTooManyBuffers = errors.New("too many channels in timeshift")
+
+var (
+	buffersMu sync.Mutex
+	buffers   = map[string]*Buffer{}
+)
+
+// Enabled reports whether timeshift is enabled in the config.
+func Enabled() bool {
+	return config.Cfg.TimeshiftMinutes > 0
+}
+
+// Window returns how much of a live stream is buffered.
+func Window() time.Duration {
+	return time.Duration(config.Cfg.TimeshiftMinutes) * time.Minute
+}
+
+// idleTimeout returns how long a buffer keeps recording without players. A paused player can
+// resume anywhere in the window, so the buffer records for the length of the window.
+func idleTimeout(window time.Duration) time.Duration {
+	return max(window, minIdleTimeout)
+}
+
+// Get returns the buffer of a stream. If the stream is not recorded yet, it starts recording
+// the source returned by newSource.
+func Get(key string, newSource func() (Source, error)) (*Buffer, error) {
+	buffersMu.Lock()
+	defer buffersMu.Unlock()
+	if b, ok := buffers[key]; ok {
+		b.touch()
+		return b, nil
+	}
+	if len(buffers) >= maxBuffers {
+		return nil, ErrTooManyBuffers
+	}
+
+	source, err := newSource()
+	if err != nil {
+		return nil, err
+	}
+	dir := ""
+	if strings.EqualFold(config.Cfg.TimeshiftStorage, StorageDisk) {
+		parent := filepath.Join(os.TempDir(), dirName)
+		if err := os.MkdirAll(parent, 0o700); err != nil {
+			return nil, err
+		}
+		if dir, err = os.MkdirTemp(parent, "buffer-"); err != nil {
+			return nil, err
+		}
+	}
+
+	b := newBuffer(Window(), dir)
+	buffers[key] = b
+	go func() {
+		b.record(source, idleTimeout(b.window))
+		buffersMu.Lock()
+		if buffers[key] == b {
+			delete(buffers, key)
+		}
+		buffersMu.Unlock()
+	}()
+	return b, nil
+}
+
+// Lookup returns the buffer of a stream that is being recorded.
+func Lookup(key string) (*Buffer, bool) {
+	buffersMu.Lock()
+	defer buffersMu.Unlock()
+	b, ok := buffers[key]
+	return b, ok
+}
+
+// Keys returns the keys of the streams that are being recorded.
+func Keys() []string {
+	buffersMu.Lock()
+	defer buffersMu.Unlock()
+	keys := make([]string, 0, len(buffers))
+	for key := range buffers {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Stop stops recording a stream and frees its buffer.
+func Stop(key string) {
+	buffersMu.Lock()
+	defer buffersMu.Unlock()
+	if b, ok := buffers[key]; ok {
+		b.close()
+		delete(buffers, key)
+	}
+}
+
+// StopAll stops recording all streams and frees their buffers.
+func StopAll() {
+	buffersMu.Lock()
+	defer buffersMu.Unlock()
+	for key, b := range buffers {
+		b.close()
+		delete(buffers, key)
+	}
+}
+
+// record updates the buffer from the source until no player has used it for idle.
+func (b *Buffer) record(source Source, idle time.Duration) {
+	defer b.close()
+	for {
+		wait := b.update(source)
+		select {
+		case <-b.done:
+			return
+		case <-time.After(wait):
+		}
+		if b.idleFor(time.Now()) > idle {
+			return
+		}
+	}
+}
+
+// update records the new segments of the source and returns when to update again.
+func (b *Buffer) update(source Source) time.Duration {
+	playlist, err := source.Playlist()
+	if err != nil {
+		utils.Log.Printf("WARN: Timeshift could not reload the playlist: %v", err)
+		b.setError(err)
+		return maxPollInterval
+	}
+	for _, remote := range b.newSegments(playlist) {
+		data, err := source.Segment(remote.URI)
+		if err != nil {
+			// Retry on the next update. If the segment has left the playlist by then, it is marked as a gap.
+			utils.Log.Printf("WARN: Timeshift could not download a segment: %v", err)
+			b.setError(err)
+			break
+		}
+		if err := b.add(remote, data); err != nil {
+			utils.Log.Printf("WARN: Timeshift could not store a segment: %v", err)
+			b.setError(err)
+			break
+		}
+	}
+	interval := time.Duration(playlist.TargetDuration) * time.Second / 2
+	return min(max(interval, minPollInterval), maxPollInterval)
+}
@@ -0,0 +1,111 @@
+package timeshift
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// fakeSource is a live stream whose playlist moves forward by one segment on every reload
+type fakeSource struct {
+	mu   sync.Mutex
+	next int64
+}
+
+func (s *fakeSource) Playlist() (*MediaPlaylist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next++
+	return &MediaPlaylist{TargetDuration: 1, Segments: remoteSegments(s.next, 3, 1)}, nil
+}
+
+func (s *fakeSource) Segment(uri string) ([]byte, error) {
+	return []byte(uri), nil
+}
+
+func TestGet(t *testing.T) {
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	originalCfg := config.Cfg
+	defer func() {
+		config.Cfg = originalCfg
+		StopAll()
+	}()
+	config.Cfg.TimeshiftMinutes = 1
+	config.Cfg.TimeshiftStorage = StorageDisk
+
+	if !Enabled() || Window() != time.Minute {
+		t.Fatalf("Enabled() = %v, Window() = %v", Enabled(), Window())
+	}
+
+	sources := 0
+	newSource := func() (Source, error) {
+		sources++
+		return &fakeSource{}, nil
+	}
+	b, err := Get("test/143/auto", newSource)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if again, err := Get("test/143/auto", newSource); err != nil || again != b || sources != 1 {
+		t.Errorf("second Get() = %p, %v with %d sources, want the same buffer", again, err, sources)
+	}
+	if err := b.Wait(10 * time.Second); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if playlist := string(b.Playlist(segmentURL, keyURL)); strings.Count(playlist, "#EXTINF:1.000,") != 3 {
+		t.Errorf("Playlist() = %s", playlist)
+	}
+	if stat, err := os.Stat(b.dir); b.dir == "" || err != nil || !stat.IsDir() {
+		t.Errorf("disk buffer directory %q: %v", b.dir, err)
+	}
+
+	if _, ok := Lookup("test/143/auto"); !ok {
+		t.Error("Lookup() found no buffer")
+	}
+	Stop("test/143/auto")
+	if _, ok := Lookup("test/143/auto"); ok {
+		t.Error("Lookup() found a stopped buffer")
+	}
+	if _, err := os.Stat(b.dir); !os.IsNotExist(err) {
+		t.Errorf("stopped buffer directory still exists: %v", err)
+	}
+
+	for i := 0; i < maxBuffers; i++ {
+		if _, err := Get(strings.Repeat("x", i+1), newSource); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+	}
+	if _, err := Get("one too many", newSource); !errors.Is(err, ErrTooManyBuffers) {
+		t.Errorf("Get() error = %v, want %v", err, ErrTooManyBuffers)
+	}
+}
+
+func TestRecordStopsWhenIdle(t *testing.T) {
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	b := newBuffer(time.Minute, "")
+	done := make(chan struct{})
+	go func() {
+		b.record(&fakeSource{}, time.Millisecond)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("record() did not stop without players")
+	}
+	if b.Duration() != 0 {
+		t.Errorf("Duration() = %v after stopping, want 0", b.Duration())
+	}
+}
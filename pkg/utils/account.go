@@ -0,0 +1,25 @@
+package utils
+
+import "github.com/jiotv-go/jiotv_go/v3/pkg/store"
+
+// Account is a JioTV login, with its device ID and credentials kept in a store.
+type Account struct {
+	// kvs is the store of the account. nil means the global store.
+	kvs store.Store
+}
+
+// DefaultAccount is the account kept in the global store, used by the package level functions.
+var DefaultAccount = &Account{}
+
+// NewAccount returns the account kept in the given store.
+func NewAccount(kvs store.Store) *Account {
+	return &Account{kvs: kvs}
+}
+
+// store returns the store of the account.
+func (a *Account) store() store.Store {
+	if a.kvs == nil {
+		return store.KVS
+	}
+	return a.kvs
+}
@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// circuitFailureThreshold is the number of consecutive failures that open a circuit
+	circuitFailureThreshold = 5
+	// defaultCircuitCooldown is how long a circuit stays open if not configured
+	defaultCircuitCooldown = 30 * time.Second
+)
+
+// Upstream endpoints guarded by circuit breakers
+const (
+	CircuitPlayback = "playback"
+	CircuitChannels = "channels"
+)
+
+// ErrCircuitOpen is returned while requests to a failing upstream endpoint are paused
+var ErrCircuitOpen = errors.New("JioTV is temporarily unavailable, please try again shortly")
+
+// CircuitBreaker stops calls to an upstream endpoint after repeated failures.
+// After the cooldown a single trial call is let through: if it succeeds the
+// circuit closes again, otherwise it stays open for another cooldown.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	name      string
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	// trialAt is when the current trial call was let through, zero if there is none
+	trialAt time.Time
+	now     func() time.Time
+}
+
+var (
+	circuitBreakers   = map[string]*CircuitBreaker{}
+	circuitBreakersMu sync.Mutex
+)
+
+// NewCircuitBreaker returns a closed circuit breaker.
+func NewCircuitBreaker(name string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// circuitCooldown returns the configured cooldown of the shared circuit breakers.
+func circuitCooldown() time.Duration {
+	if config.Cfg.CircuitBreakerCooldown > 0 {
+		return time.Duration(config.Cfg.CircuitBreakerCooldown) * time.Second
+	}
+	return defaultCircuitCooldown
+}
+
+// CircuitRetryAfter returns the number of seconds clients should wait before retrying
+// a request that was rejected by an open circuit.
+func CircuitRetryAfter() int {
+	return int(circuitCooldown().Seconds())
+}
+
+// Circuit returns the shared circuit breaker of an upstream endpoint.
+func Circuit(name string) *CircuitBreaker {
+	circuitBreakersMu.Lock()
+	defer circuitBreakersMu.Unlock()
+
+	breaker, ok := circuitBreakers[name]
+	if !ok {
+		breaker = NewCircuitBreaker(name, circuitFailureThreshold, circuitCooldown())
+		circuitBreakers[name] = breaker
+	}
+	return breaker
+}
+
+// IsCircuitFailureStatus reports whether an upstream status code counts as a failure.
+// 400 is included because the playback API answers it during outages.
+func IsCircuitFailureStatus(status int) bool {
+	return status == fasthttp.StatusBadRequest || status >= fasthttp.StatusInternalServerError
+}
+
+// Allow returns ErrCircuitOpen if calls are paused.
+func (b *CircuitBreaker) Allow() error {
+	if config.Cfg.DisableCircuitBreaker {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openUntil.IsZero() {
+		return nil
+	}
+	now := b.now()
+	// A trial call that never reported back is given up on after another cooldown
+	trialPending := !b.trialAt.IsZero() && now.Sub(b.trialAt) < b.cooldown
+	if now.Before(b.openUntil) || trialPending {
+		return fmt.Errorf("%w (%s)", ErrCircuitOpen, b.name)
+	}
+	// Cooldown is over, let a single trial call through
+	b.trialAt = now
+	return nil
+}
+
+// Success records a successful call and closes the circuit.
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.openUntil.IsZero() {
+		SafeLogf("Circuit %s closed, upstream recovered", b.name)
+	}
+	b.failures = 0
+	b.openUntil = time.Time{}
+	b.trialAt = time.Time{}
+}
+
+// Failure records a failed call and opens the circuit once the threshold is reached.
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if !b.trialAt.IsZero() || b.failures >= b.threshold {
+		b.openUntil = b.now().Add(b.cooldown)
+		b.trialAt = time.Time{}
+		SafeLogf("Circuit %s opened for %s after %d failures", b.name, b.cooldown, b.failures)
+	}
+}
+
+// Record records the outcome of a call from its error and status code.
+func (b *CircuitBreaker) Record(err error, status int) {
+	if err != nil || IsCircuitFailureStatus(status) {
+		b.Failure()
+		return
+	}
+	b.Success()
+}
+
+// IsOpen reports whether calls are currently paused.
+func (b *CircuitBreaker) IsOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.openUntil.IsZero() && b.now().Before(b.openUntil)
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker("test", 3, 30*time.Second)
+	breaker.now = func() time.Time { return now }
+
+	// Failures below the threshold keep the circuit closed
+	breaker.Failure()
+	breaker.Failure()
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Allow() after 2 failures = %v, want nil", err)
+	}
+
+	// A success resets the failure count
+	breaker.Success()
+	breaker.Failure()
+	breaker.Failure()
+	if breaker.IsOpen() {
+		t.Fatal("IsOpen() = true after success reset")
+	}
+
+	breaker.Failure()
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Allow() after threshold = %v, want ErrCircuitOpen", err)
+	}
+
+	// After the cooldown a single trial call is allowed
+	now = now.Add(31 * time.Second)
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Allow() after cooldown = %v, want nil", err)
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second Allow() during trial = %v, want ErrCircuitOpen", err)
+	}
+
+	// A failed trial opens the circuit again
+	breaker.Failure()
+	if !breaker.IsOpen() {
+		t.Fatal("IsOpen() = false after failed trial")
+	}
+
+	// A successful trial closes the circuit
+	now = now.Add(31 * time.Second)
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Allow() after second cooldown = %v, want nil", err)
+	}
+	breaker.Success()
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Allow() after successful trial = %v, want nil", err)
+	}
+}
+
+func TestCircuitBreakerAbandonedTrial(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker("test", 1, 10*time.Second)
+	breaker.now = func() time.Time { return now }
+
+	breaker.Failure()
+	now = now.Add(11 * time.Second)
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Allow() after cooldown = %v, want nil", err)
+	}
+
+	// The trial never reports back, another one is allowed after a cooldown
+	now = now.Add(11 * time.Second)
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Allow() after abandoned trial = %v, want nil", err)
+	}
+}
+
+func TestCircuitBreakerRecord(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		status   int
+		expected bool
+	}{
+		{"Success", nil, 200, false},
+		{"Not found is not a failure", nil, 404, false},
+		{"Bad request", nil, 400, true},
+		{"Server error", nil, 503, true},
+		{"Connection error", errors.New("connection refused"), 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker := NewCircuitBreaker("test", 1, time.Minute)
+			breaker.Record(tt.err, tt.status)
+			if got := breaker.IsOpen(); got != tt.expected {
+				t.Errorf("IsOpen() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
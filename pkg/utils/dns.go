@@ -0,0 +1,258 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+const (
+	// dotPort is the port of DNS-over-TLS servers given without one
+	dotPort = "853"
+	// dohTimeout is the timeout of a DNS-over-HTTPS query
+	dohTimeout = 5 * time.Second
+	// dnsMessageType is the media type of DNS-over-HTTPS queries and answers (RFC 8484)
+	dnsMessageType = "application/dns-message"
+	// maxDNSMessage is the size of the largest DNS message
+	maxDNSMessage = 65535
+	// anyDomain is the DNS rule domain that matches every host
+	anyDomain = "*"
+)
+
+// dnsRoute is a DNS rule with its resolver prepared
+type dnsRoute struct {
+	domain   string
+	resolver *net.Resolver
+}
+
+var (
+	dnsRoutesOnce sync.Once
+	dnsRoutesList []dnsRoute
+)
+
+// dnsRoutes returns the resolvers of the dns_rules of the config. Rules with an invalid resolver are
+// skipped with a warning.
+func dnsRoutes() []dnsRoute {
+	dnsRoutesOnce.Do(func() {
+		dnsRoutesList = newDNSRoutes(config.Cfg.DNSRules)
+	})
+	return dnsRoutesList
+}
+
+// newDNSRoutes prepares the resolvers of DNS rules.
+func newDNSRoutes(rules []config.DNSRule) []dnsRoute {
+	routes := make([]dnsRoute, 0, len(rules))
+	for _, rule := range rules {
+		if strings.TrimSpace(rule.Domain) == "" {
+			continue
+		}
+		resolver, err := newResolver(rule.Resolver)
+		if err != nil {
+			if Log != nil {
+				Log.Printf("WARN: DNS rule for %s: %v", rule.Domain, err)
+			}
+			continue
+		}
+		routes = append(routes, dnsRoute{domain: rule.Domain, resolver: resolver})
+	}
+	return routes
+}
+
+// resolverFor returns the resolver of the first DNS rule matching the host, or nil for the system resolver.
+func resolverFor(routes []dnsRoute, host string) *net.Resolver {
+	for _, route := range routes {
+		if strings.TrimSpace(route.domain) == anyDomain || matchesProxyDomain(route.domain, host) {
+			return route.resolver
+		}
+	}
+	return nil
+}
+
+// newResolver returns a resolver that sends queries to a DNS-over-HTTPS URL like
+// "https://1.1.1.1/dns-query" or to a DNS-over-TLS server like "tls://1.1.1.1".
+func newResolver(server string) (*net.Resolver, error) {
+	server = strings.TrimSpace(server)
+	parsed, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "https":
+		if parsed.Host == "" {
+			return nil, fmt.Errorf("DNS-over-HTTPS URL %q has no host", server)
+		}
+		client := &http.Client{Timeout: dohTimeout}
+		return &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return &dohConn{ctx: ctx, url: server, client: client}, nil
+			},
+		}, nil
+	case "tls":
+		host, port := parsed.Hostname(), parsed.Port()
+		if host == "" {
+			return nil, fmt.Errorf("DNS-over-TLS server %q has no host", server)
+		}
+		if port == "" {
+			port = dotPort
+		}
+		addr := net.JoinHostPort(host, port)
+		dialer := &tls.Dialer{
+			NetDialer: &net.Dialer{Timeout: directDialTimeout},
+			Config:    &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12},
+		}
+		return &net.Resolver{
+			PreferGo: true,
+			// The Go resolver sends queries over TCP framing on connections that are not packet connections
+			Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "tcp", addr)
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("DNS resolver %q must be an https:// or tls:// URL", server)
+	}
+}
+
+// resolvingDial returns a dial function that resolves hosts with the DNS rules and connects with the dialer.
+func resolvingDial(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	routes := dnsRoutes()
+	if len(routes) == 0 {
+		return dialer.DialContext
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		resolver := resolverFor(routes, host)
+		if resolver == nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		withResolver := *dialer
+		withResolver.Resolver = resolver
+		return withResolver.DialContext(ctx, network, addr)
+	}
+}
+
+var (
+	httpTransportOnce sync.Once
+	httpTransport     http.RoundTripper
+)
+
+// HTTPTransport returns the transport for net/http clients of upstream requests. It resolves hosts
+// with the dns_rules and connects with the TLS options of the config, and is http.DefaultTransport
+// without them.
+func HTTPTransport() http.RoundTripper {
+	httpTransportOnce.Do(func() {
+		tlsConfig := UpstreamTLSConfig()
+		if len(dnsRoutes()) == 0 && tlsConfig == nil {
+			httpTransport = http.DefaultTransport
+			return
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DialContext = resolvingDial(&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second})
+		if tlsConfig != nil {
+			transport.TLSClientConfig = tlsConfig.Clone()
+		}
+		httpTransport = transport
+	})
+	return httpTransport
+}
+
+// dohConn sends the DNS queries written to it to a DNS-over-HTTPS server. The Go resolver uses the
+// framing of DNS over TCP on connections that are not packet connections, so every query and
+// answer is prefixed by its length.
+type dohConn struct {
+	ctx      context.Context
+	url      string
+	client   *http.Client
+	query    bytes.Buffer
+	answer   bytes.Buffer
+	deadline time.Time
+}
+
+func (c *dohConn) Write(b []byte) (int, error) {
+	return c.query.Write(b)
+}
+
+func (c *dohConn) Read(b []byte) (int, error) {
+	if c.answer.Len() == 0 {
+		if err := c.exchange(); err != nil {
+			return 0, err
+		}
+	}
+	return c.answer.Read(b)
+}
+
+// exchange posts the written query to the server and buffers its answer.
+func (c *dohConn) exchange() error {
+	data := c.query.Bytes()
+	if len(data) < 2 {
+		return io.ErrUnexpectedEOF
+	}
+	length := int(binary.BigEndian.Uint16(data))
+	if len(data) < 2+length {
+		return io.ErrUnexpectedEOF
+	}
+	message := data[2 : 2+length]
+
+	ctx := c.ctx
+	if !c.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, c.deadline)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", dnsMessageType)
+	req.Header.Set("Accept", dnsMessageType)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("DNS-over-HTTPS server answered %s", resp.Status)
+	}
+	answer, err := io.ReadAll(io.LimitReader(resp.Body, maxDNSMessage+1))
+	if err != nil {
+		return err
+	}
+	if len(answer) > maxDNSMessage {
+		return fmt.Errorf("DNS-over-HTTPS answer is larger than %d bytes", maxDNSMessage)
+	}
+	c.query.Next(2 + length)
+	c.answer.Write(binary.BigEndian.AppendUint16(nil, uint16(len(answer))))
+	c.answer.Write(answer)
+	return nil
+}
+
+func (c *dohConn) Close() error                     { return nil }
+func (c *dohConn) LocalAddr() net.Addr              { return dohAddr(c.url) }
+func (c *dohConn) RemoteAddr() net.Addr             { return dohAddr(c.url) }
+func (c *dohConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *dohConn) SetWriteDeadline(time.Time) error { return nil }
+
+func (c *dohConn) SetDeadline(t time.Time) error {
+	c.deadline = t
+	return nil
+}
+
+// dohAddr is the address of a DNS-over-HTTPS server
+type dohAddr string
+
+func (a dohAddr) Network() string { return "https" }
+func (a dohAddr) String() string  { return string(a) }
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestNewResolver(t *testing.T) {
+	tests := []struct {
+		server  string
+		wantErr bool
+	}{
+		{"https://1.1.1.1/dns-query", false},
+		{"https://dns.google/dns-query", false},
+		{"tls://1.1.1.1", false},
+		{"tls://dns.quad9.net:853", false},
+		{"udp://1.1.1.1", true},
+		{"1.1.1.1", true},
+		{"https:///dns-query", true},
+		{"tls://", true},
+	}
+	for _, tt := range tests {
+		if _, err := newResolver(tt.server); (err != nil) != tt.wantErr {
+			t.Errorf("newResolver(%q) error = %v, wantErr %v", tt.server, err, tt.wantErr)
+		}
+	}
+}
+
+func TestResolverFor(t *testing.T) {
+	routes := newDNSRoutes([]config.DNSRule{
+		{Domain: "akamaized.net", Resolver: "https://1.1.1.1/dns-query"},
+		{Domain: "jio.com", Resolver: "invalid"},
+		{Domain: "*", Resolver: "tls://9.9.9.9"},
+	})
+	if len(routes) != 2 {
+		t.Fatalf("len(routes) = %d, want the rule with an invalid resolver skipped", len(routes))
+	}
+
+	tests := []struct {
+		host string
+		want *net.Resolver
+	}{
+		{"jiotvlive.akamaized.net", routes[0].resolver},
+		{"jiotvapi.media.jio.com", routes[1].resolver},
+		{"example.com", routes[1].resolver},
+	}
+	for _, tt := range tests {
+		if got := resolverFor(routes, tt.host); got != tt.want {
+			t.Errorf("resolverFor(%q) picked the wrong resolver", tt.host)
+		}
+	}
+	if got := resolverFor(routes[:1], "example.com"); got != nil {
+		t.Error("resolverFor() without a matching rule should use the system resolver")
+	}
+}
+
+// dohHandler answers A queries with 192.0.2.1 and other queries without records.
+func dohHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != dnsMessageType {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var query dnsmessage.Message
+		if err := query.Unpack(body); err != nil || len(query.Questions) != 1 {
+			http.Error(w, "bad query", http.StatusBadRequest)
+			return
+		}
+		question := query.Questions[0]
+		answer := dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: query.ID, Response: true, RecursionAvailable: true},
+			Questions: query.Questions,
+		}
+		if question.Type == dnsmessage.TypeA {
+			answer.Answers = []dnsmessage.Resource{{
+				Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+				Body:   &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}},
+			}}
+		}
+		packed, err := answer.Pack()
+		if err != nil {
+			t.Errorf("packing the answer: %v", err)
+		}
+		w.Header().Set("Content-Type", dnsMessageType)
+		w.Write(packed)
+	}
+}
+
+func TestDoHConn(t *testing.T) {
+	server := httptest.NewTLSServer(dohHandler(t))
+	defer server.Close()
+
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return &dohConn{ctx: ctx, url: server.URL, client: server.Client()}, nil
+		},
+	}
+	addrs, err := resolver.LookupHost(context.Background(), "jiotvlive.akamaized.net")
+	if err != nil {
+		t.Fatalf("LookupHost() error = %v", err)
+	}
+	if len(addrs) != 1 || addrs[0] != "192.0.2.1" {
+		t.Errorf("LookupHost() = %v, want [192.0.2.1]", addrs)
+	}
+}
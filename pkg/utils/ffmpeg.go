@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+const (
+	// hwaccelAuto detects the hardware acceleration of ffmpeg_hwaccel
+	hwaccelAuto = "auto"
+	// hwaccelNone disables the hardware acceleration of ffmpeg_hwaccel
+	hwaccelNone = "none"
+	// hwaccelProbeTimeout is how long probing a hardware acceleration may take
+	hwaccelProbeTimeout = 10 * time.Second
+)
+
+// hwaccelPreference is the order in which detected hardware accelerations are tried: the dedicated
+// GPUs of NVIDIA, then Intel Quick Sync and VA-API of Intel and AMD, then those of macOS, the
+// Raspberry Pi and Windows.
+var hwaccelPreference = []string{"cuda", "qsv", "vaapi", "videotoolbox", "drm", "v4l2m2m", "d3d11va", "dxva2"}
+
+var (
+	hwaccelOnce     sync.Once
+	detectedHWAccel string
+)
+
+// FFmpegPath returns the ffmpeg executable from the config or the PATH.
+func FFmpegPath() (string, error) {
+	name := strings.TrimSpace(config.Cfg.FFmpegPath)
+	if name == "" {
+		name = "ffmpeg"
+	}
+	return exec.LookPath(name)
+}
+
+// FFmpegHWAccel returns the hardware acceleration ffmpeg decodes video with, or an empty string to
+// decode in software. With ffmpeg_hwaccel set to auto, the first hardware acceleration of the
+// preference that ffmpeg supports and that initialises on this machine is used. It is detected
+// once, on the first call.
+func FFmpegHWAccel() string {
+	switch name := strings.ToLower(strings.TrimSpace(config.Cfg.FFmpegHWAccel)); name {
+	case hwaccelNone:
+		return ""
+	case "", hwaccelAuto:
+		hwaccelOnce.Do(func() {
+			detectedHWAccel = detectHWAccel()
+		})
+		return detectedHWAccel
+	default:
+		return name
+	}
+}
+
+// FFmpegHWAccelArgs returns the ffmpeg input options that decode the next input with the hardware
+// acceleration, or none to decode in software. ffmpeg still decodes in software what the hardware
+// does not support.
+func FFmpegHWAccelArgs() []string {
+	hwaccel := FFmpegHWAccel()
+	if hwaccel == "" {
+		return nil
+	}
+	args := []string{"-hwaccel", hwaccel}
+	if device := strings.TrimSpace(config.Cfg.FFmpegHWAccelDevice); device != "" {
+		args = append(args, "-hwaccel_device", device)
+	}
+	return args
+}
+
+// detectHWAccel returns the preferred hardware acceleration that ffmpeg lists and that initialises,
+// or an empty string if there is none.
+func detectHWAccel() string {
+	path, err := FFmpegPath()
+	if err != nil {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), hwaccelProbeTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, path, "-hide_banner", "-hwaccels").Output()
+	if err != nil {
+		return ""
+	}
+	// ffmpeg lists the hardware accelerations it was built with, which need not be present here
+	for _, hwaccel := range listedHWAccels(string(output)) {
+		if probeHWAccel(path, hwaccel) {
+			if Log != nil {
+				Log.Printf("INFO: ffmpeg decodes video with %s hardware acceleration", hwaccel)
+			}
+			return hwaccel
+		}
+	}
+	return ""
+}
+
+// listedHWAccels returns the hardware accelerations of the output of `ffmpeg -hwaccels` that are in
+// the preference, the most preferred first.
+func listedHWAccels(output string) []string {
+	listed := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		// The list follows a "Hardware acceleration methods:" heading
+		if name := strings.TrimSpace(line); name != "" && !strings.HasSuffix(name, ":") {
+			listed[strings.ToLower(name)] = true
+		}
+	}
+	var result []string
+	for _, hwaccel := range hwaccelPreference {
+		if listed[hwaccel] {
+			result = append(result, hwaccel)
+		}
+	}
+	return result
+}
+
+// probeHWAccel reports whether ffmpeg can initialise the device of a hardware acceleration.
+func probeHWAccel(path, hwaccel string) bool {
+	device := hwaccel
+	if name := strings.TrimSpace(config.Cfg.FFmpegHWAccelDevice); name != "" {
+		device += ":" + name
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), hwaccelProbeTimeout)
+	defer cancel()
+	return exec.CommandContext(ctx, path,
+		"-hide_banner", "-loglevel", "error",
+		"-init_hw_device", device,
+		"-f", "lavfi", "-i", "nullsrc",
+		"-frames:v", "1", "-f", "null", "-",
+	).Run() == nil
+}
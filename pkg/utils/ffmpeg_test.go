@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+func TestListedHWAccels(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{
+			name:   "preferred first",
+			output: "Hardware acceleration methods:\nvdpau\nvaapi\ndrm\nopencl\ncuda\n\n",
+			want:   []string{"cuda", "vaapi", "drm"},
+		},
+		{
+			name:   "raspberry pi",
+			output: "Hardware acceleration methods:\r\ndrm\r\n",
+			want:   []string{"drm"},
+		},
+		{
+			name:   "none",
+			output: "Hardware acceleration methods:\n\n",
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := listedHWAccels(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listedHWAccels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFFmpegHWAccelArgs(t *testing.T) {
+	originalCfg := config.Cfg
+	defer func() { config.Cfg = originalCfg }()
+
+	tests := []struct {
+		name    string
+		hwaccel string
+		device  string
+		want    []string
+	}{
+		{name: "disabled", hwaccel: "none", device: "/dev/dri/renderD128", want: nil},
+		{name: "named", hwaccel: "VAAPI", want: []string{"-hwaccel", "vaapi"}},
+		{name: "with a device", hwaccel: "cuda", device: "1", want: []string{"-hwaccel", "cuda", "-hwaccel_device", "1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Cfg.FFmpegHWAccel, config.Cfg.FFmpegHWAccelDevice = tt.hwaccel, tt.device
+			if got := FFmpegHWAccelArgs(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FFmpegHWAccelArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
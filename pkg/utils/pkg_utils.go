@@ -18,6 +18,8 @@ type HTTPRequestConfig struct {
 	Headers     map[string]string
 	UserAgent   string
 	ContentType string
+	// Retry enables retries of transient failures. The request is sent once if nil.
+	Retry *RetryPolicy
 }
 
 // MakeHTTPRequest creates and executes a fasthttp request with common patterns
@@ -53,7 +55,11 @@ func MakeHTTPRequest(config HTTPRequestConfig, client *fasthttp.Client) (*fastht
 	resp := fasthttp.AcquireResponse()
 	
 	// Perform the HTTP request
-	if err := client.Do(req, resp); err != nil {
+	policy := RetryPolicy{MaxAttempts: 1}
+	if config.Retry != nil {
+		policy = *config.Retry
+	}
+	if err := DoWithRetry(client, req, resp, policy); err != nil {
 		fasthttp.ReleaseResponse(resp)
 		return nil, err
 	}
@@ -92,16 +98,21 @@ type BatchStoreOperations struct {
 
 // ExecuteBatchStoreOperations executes multiple store operations in sequence
 func ExecuteBatchStoreOperations(ops BatchStoreOperations) error {
+	return executeBatchStoreOperations(store.KVS, ops)
+}
+
+// executeBatchStoreOperations executes multiple store operations in sequence on the given store
+func executeBatchStoreOperations(kvs store.Store, ops BatchStoreOperations) error {
 	// Execute all Set operations
 	for key, value := range ops.Sets {
-		if err := store.Set(key, value); err != nil {
+		if err := kvs.Set(key, value); err != nil {
 			return fmt.Errorf("failed to set %s: %w", key, err)
 		}
 	}
 
 	// Execute all Delete operations
 	for _, key := range ops.Deletes {
-		if err := store.Delete(key); err != nil {
+		if err := kvs.Delete(key); err != nil {
 			return fmt.Errorf("failed to delete %s: %w", key, err)
 		}
 	}
@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttpproxy"
+)
+
+const (
+	// directProxy is the proxy rule value that bypasses the proxy
+	directProxy = "direct"
+	// proxyDialTimeout is the dial timeout for proxy connections
+	proxyDialTimeout = 10 * time.Second
+	// directDialTimeout is the dial timeout for direct connections
+	directDialTimeout = 5 * time.Second
+	// happyEyeballsDelay is how long to wait on the preferred address family before racing the other one
+	happyEyeballsDelay = 300 * time.Millisecond
+)
+
+// proxyRoute is a proxy rule with its dialer prepared
+type proxyRoute struct {
+	domain string
+	dial   fasthttp.DialFunc
+}
+
+// isDirectProxy reports whether the proxy value means a direct connection.
+func isDirectProxy(proxy string) bool {
+	proxy = strings.TrimSpace(proxy)
+	return proxy == "" || strings.EqualFold(proxy, directProxy)
+}
+
+// isSocksProxy reports whether the proxy URL is a SOCKS5 proxy.
+func isSocksProxy(proxy string) bool {
+	lower := strings.ToLower(proxy)
+	return strings.HasPrefix(lower, "socks5://") || strings.HasPrefix(lower, "socks5h://")
+}
+
+// proxyWithAuth adds the credentials to the proxy URL if it does not carry any.
+// The credentials are escaped so that passwords may contain special characters.
+func proxyWithAuth(proxy, username, password string) string {
+	if isDirectProxy(proxy) || username == "" {
+		return proxy
+	}
+	scheme := ""
+	rest := proxy
+	if idx := strings.Index(proxy, "://"); idx != -1 {
+		scheme = proxy[:idx+3]
+		rest = proxy[idx+3:]
+	}
+	if strings.Contains(rest, "@") {
+		return proxy
+	}
+	return scheme + url.UserPassword(username, password).String() + "@" + rest
+}
+
+// redactProxy hides the password of a proxy URL so that it can be logged.
+func redactProxy(proxy string) string {
+	scheme := ""
+	rest := proxy
+	if idx := strings.Index(proxy, "://"); idx != -1 {
+		scheme = proxy[:idx+3]
+		rest = proxy[idx+3:]
+	}
+	at := strings.LastIndex(rest, "@")
+	if at == -1 {
+		return proxy
+	}
+	userinfo := rest[:at]
+	if colon := strings.Index(userinfo, ":"); colon != -1 {
+		userinfo = userinfo[:colon] + ":***"
+	}
+	return scheme + userinfo + rest[at:]
+}
+
+// dialNetwork returns the network to dial for the given IP preference.
+// Unknown values fall back to "tcp", which uses both address families.
+func dialNetwork(preference string) string {
+	switch strings.ToLower(strings.TrimSpace(preference)) {
+	case "v4", "ipv4", "4":
+		return "tcp4"
+	case "v6", "ipv6", "6":
+		return "tcp6"
+	default:
+		return "tcp"
+	}
+}
+
+// newDirectDialer returns a dialer that connects without a proxy.
+// With the "auto" preference IPv6 and IPv4 addresses are raced (RFC 6555),
+// so a broken IPv6 path does not stall requests to dual-stack CDNs.
+// Hosts are resolved with the DNS rules of the config.
+func newDirectDialer() fasthttp.DialFunc {
+	dial := resolvingDial(&net.Dialer{
+		Timeout:       directDialTimeout,
+		FallbackDelay: happyEyeballsDelay,
+	})
+	network := dialNetwork(config.Cfg.IPPreference)
+	return func(addr string) (net.Conn, error) {
+		return dial(context.Background(), network, addr)
+	}
+}
+
+// newProxyDialer returns a dialer for the given proxy URL.
+// SOCKS5 proxies are used for socks5:// URLs, HTTP proxies for everything else.
+func newProxyDialer(proxy string) fasthttp.DialFunc {
+	if isDirectProxy(proxy) {
+		return newDirectDialer()
+	}
+	if isSocksProxy(proxy) {
+		return fasthttpproxy.FasthttpSocksDialerDualStack(proxy)
+	}
+	return fasthttpproxy.FasthttpHTTPDialerDualStackTimeout(proxy, proxyDialTimeout)
+}
+
+// matchesProxyDomain reports whether host is the domain or one of its subdomains.
+// A leading "*." or "." in the domain is ignored.
+func matchesProxyDomain(domain, host string) bool {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	domain = strings.TrimPrefix(strings.TrimPrefix(domain, "*"), ".")
+	if domain == "" {
+		return false
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// newRoutingDialer returns a dialer that picks the proxy for each connection
+// from the rules, falling back to the default proxy when no rule matches.
+func newRoutingDialer(defaultDial fasthttp.DialFunc, rules []config.ProxyRule) fasthttp.DialFunc {
+	routes := make([]proxyRoute, 0, len(rules))
+	for _, rule := range rules {
+		if strings.TrimSpace(rule.Domain) == "" {
+			continue
+		}
+		routes = append(routes, proxyRoute{
+			domain: rule.Domain,
+			dial:   newProxyDialer(rule.Proxy),
+		})
+	}
+
+	return func(addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		for _, route := range routes {
+			if matchesProxyDomain(route.domain, host) {
+				return route.dial(addr)
+			}
+		}
+		return defaultDial(addr)
+	}
+}
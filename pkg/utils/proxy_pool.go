@@ -0,0 +1,341 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// proxyProbeTimeout is the timeout for checking if a proxy can reach the JioTV API
+	proxyProbeTimeout = 5 * time.Second
+)
+
+var (
+	// proxyProbeAddr is the address dialed through a proxy to check its health
+	proxyProbeAddr = JIOTV_API_DOMAIN + ":443"
+	// errProxyProbeTimeout is returned when a proxy health check times out
+	errProxyProbeTimeout = errors.New("proxy health check timed out")
+)
+
+// proxyPool holds a failover list of proxies and the one currently in use
+type proxyPool struct {
+	mu      sync.RWMutex
+	proxies []string
+	dialers []fasthttp.DialFunc
+	active  int
+	// probe checks whether the proxy behind dial is usable
+	probe func(dial fasthttp.DialFunc) error
+
+	connsMu sync.Mutex
+	// conns are the open connections dialed through the proxies
+	conns map[*proxyConn]struct{}
+}
+
+// proxyConn is a connection dialed through a proxy of a pool, closed when the pool switches away
+// from its proxy
+type proxyConn struct {
+	net.Conn
+	pool  *proxyPool
+	proxy int
+	once  sync.Once
+}
+
+// Close closes the connection and forgets it.
+func (c *proxyConn) Close() error {
+	err := net.ErrClosed
+	c.once.Do(func() {
+		c.pool.connsMu.Lock()
+		delete(c.pool.conns, c)
+		c.pool.connsMu.Unlock()
+		err = c.Conn.Close()
+	})
+	return err
+}
+
+var (
+	// sharedProxyPool is the pool built from the configured proxy list
+	sharedProxyPool   *proxyPool
+	sharedProxyPoolMu sync.Mutex
+	// sharedProxyPoolKey is the proxy config the shared pool was built from
+	sharedProxyPoolKey string
+)
+
+// parseProxyList splits a comma separated list of proxies.
+func parseProxyList(proxy string) []string {
+	var proxies []string
+	for _, p := range strings.Split(proxy, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
+
+// configuredProxies returns the configured proxies with credentials applied.
+func configuredProxies() []string {
+	proxies := parseProxyList(config.Cfg.Proxy)
+	for i, p := range proxies {
+		proxies[i] = proxyWithAuth(p, config.Cfg.ProxyUsername, config.Cfg.ProxyPassword)
+	}
+	return proxies
+}
+
+// probeProxy dials the JioTV API through the proxy.
+func probeProxy(dial fasthttp.DialFunc) error {
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := dial(proxyProbeAddr)
+		done <- result{conn, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return r.err
+		}
+		return r.conn.Close()
+	case <-time.After(proxyProbeTimeout):
+		// Close the connection if the dial completes after the timeout
+		go func() {
+			if r := <-done; r.err == nil {
+				r.conn.Close()
+			}
+		}()
+		return errProxyProbeTimeout
+	}
+}
+
+// newProxyPool returns a pool for the given proxies, starting with the first one.
+func newProxyPool(proxies []string) *proxyPool {
+	pool := &proxyPool{
+		proxies: proxies,
+		dialers: make([]fasthttp.DialFunc, len(proxies)),
+		probe:   probeProxy,
+	}
+	for i, p := range proxies {
+		pool.dialers[i] = newProxyDialer(p)
+	}
+	return pool
+}
+
+// getProxyPool returns the shared proxy pool, rebuilding it if the proxy config changed.
+// It returns nil when fewer than two proxies are configured.
+func getProxyPool() *proxyPool {
+	sharedProxyPoolMu.Lock()
+	defer sharedProxyPoolMu.Unlock()
+
+	key := config.Cfg.Proxy + "|" + config.Cfg.ProxyUsername + "|" + config.Cfg.ProxyPassword
+	if sharedProxyPool != nil && sharedProxyPoolKey == key {
+		return sharedProxyPool
+	}
+
+	proxies := configuredProxies()
+	if len(proxies) < 2 {
+		sharedProxyPool = nil
+	} else {
+		sharedProxyPool = newProxyPool(proxies)
+	}
+	sharedProxyPoolKey = key
+	return sharedProxyPool
+}
+
+// current returns the index and dialer of the active proxy.
+func (p *proxyPool) current() (int, fasthttp.DialFunc) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.active, p.dialers[p.active]
+}
+
+// failover switches away from the failed proxy to the next healthy one.
+// If no other proxy passes the health check, the next proxy in the list is used anyway.
+// Nothing happens if another caller already switched away from the failed proxy.
+func (p *proxyPool) failover(failed int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.active != failed {
+		return
+	}
+	next := (failed + 1) % len(p.proxies)
+	for i := 1; i < len(p.proxies); i++ {
+		candidate := (failed + i) % len(p.proxies)
+		if err := p.probe(p.dialers[candidate]); err != nil {
+			Log.Printf("Proxy %s failed health check: %v", redactProxy(p.proxies[candidate]), err)
+			continue
+		}
+		next = candidate
+		break
+	}
+	p.active = next
+	Log.Printf("Switching proxy from %s to %s", redactProxy(p.proxies[failed]), redactProxy(p.proxies[next]))
+	p.closeConns(failed)
+}
+
+// track remembers a connection dialed through a proxy, so that it can be closed on failover.
+func (p *proxyPool) track(proxy int, conn net.Conn) net.Conn {
+	tracked := &proxyConn{Conn: conn, pool: p, proxy: proxy}
+	p.connsMu.Lock()
+	defer p.connsMu.Unlock()
+	if p.conns == nil {
+		p.conns = map[*proxyConn]struct{}{}
+	}
+	p.conns[tracked] = struct{}{}
+	return tracked
+}
+
+// closeConns closes the connections dialed through a proxy. HTTP clients keep idle connections
+// alive, and would otherwise keep sending requests through the failed proxy. Requests that are
+// in flight on them fail with a connection error and are retried through the new proxy.
+func (p *proxyPool) closeConns(proxy int) {
+	p.connsMu.Lock()
+	var conns []*proxyConn
+	for conn := range p.conns {
+		if conn.proxy == proxy {
+			conns = append(conns, conn)
+		}
+	}
+	p.connsMu.Unlock()
+	for _, conn := range conns {
+		conn.Close()
+	}
+}
+
+// probeAll checks every proxy and activates the first healthy one.
+func (p *proxyPool) probeAll() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, dial := range p.dialers {
+		if err := p.probe(dial); err != nil {
+			Log.Printf("Proxy %s failed health check: %v", redactProxy(p.proxies[i]), err)
+			continue
+		}
+		p.active = i
+		Log.Printf("Using proxy %s", redactProxy(p.proxies[i]))
+		return
+	}
+	Log.Println("WARN: No configured proxy passed the health check, keeping " + redactProxy(p.proxies[p.active]))
+}
+
+// dial connects through the active proxy and fails over to the
+// next proxies on connection errors.
+func (p *proxyPool) dial(addr string) (net.Conn, error) {
+	var lastErr error
+	for attempt := 0; attempt < len(p.proxies); attempt++ {
+		idx, dial := p.current()
+		conn, err := dial(addr)
+		if err == nil {
+			return p.track(idx, conn), nil
+		}
+		lastErr = err
+		Log.Printf("Proxy %s connection error: %v", redactProxy(p.proxies[idx]), err)
+		p.failover(idx)
+	}
+	return nil, lastErr
+}
+
+// defaultProxyDialer returns the dialer for requests that match no proxy rule.
+func defaultProxyDialer() fasthttp.DialFunc {
+	if pool := getProxyPool(); pool != nil {
+		return pool.dial
+	}
+	proxies := configuredProxies()
+	if len(proxies) == 0 {
+		return newDirectDialer()
+	}
+	return newProxyDialer(proxies[0])
+}
+
+// ProbeProxies checks the configured proxies and activates the first healthy one.
+// It does nothing unless a list of proxies is configured.
+func ProbeProxies() {
+	if pool := getProxyPool(); pool != nil {
+		pool.probeAll()
+	}
+}
+
+// IsGeoBlocked reports whether a response of the JioTV API refuses the region of the client, as
+// opposed to an error of the request like an expired token. The API answers such errors with a JSON
+// body, while the CDN in front of it answers clients outside India with 451 or an HTML
+// "Access Denied" page and 403.
+func IsGeoBlocked(status int, body []byte) bool {
+	switch status {
+	case fasthttp.StatusUnavailableForLegalReasons:
+		return true
+	case fasthttp.StatusForbidden:
+		body = bytes.TrimSpace(body)
+		return len(body) > 0 && !json.Valid(body)
+	}
+	return false
+}
+
+// ReportProxyFailure switches to the next healthy proxy. It should be called when
+// the JioTV API refuses the region of the active proxy, see IsGeoBlocked.
+// Connection errors fail over without it. It does nothing unless a list of proxies is configured.
+func ReportProxyFailure() {
+	if pool := getProxyPool(); pool != nil {
+		idx, _ := pool.current()
+		pool.failover(idx)
+	}
+}
+
+// ProxyCheck is the result of checking a configured proxy, see CheckProxies.
+type ProxyCheck struct {
+	// Proxy is the proxy with its password redacted
+	Proxy string
+	Err   error
+}
+
+// validateProxy checks that a proxy is "direct" or an address with a port, with an optional
+// http, https or socks5 scheme and credentials.
+func validateProxy(proxy string) error {
+	if isDirectProxy(proxy) {
+		return nil
+	}
+	value := proxy
+	if !strings.Contains(value, "://") {
+		value = "http://" + value
+	}
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("unsupported proxy scheme %q, use http or socks5", parsed.Scheme)
+	}
+	if parsed.Hostname() == "" || parsed.Port() == "" {
+		return fmt.Errorf("proxy needs a host and a port, e.g. http://127.0.0.1:8080")
+	}
+	return nil
+}
+
+// CheckProxies validates the configured proxies and dials the JioTV API through each of them,
+// in the order of the config. It returns no checks when no proxy is configured.
+func CheckProxies() []ProxyCheck {
+	proxies := configuredProxies()
+	checks := make([]ProxyCheck, 0, len(proxies))
+	for _, p := range proxies {
+		err := validateProxy(p)
+		if err == nil {
+			err = probeProxy(newProxyDialer(p))
+		}
+		checks = append(checks, ProxyCheck{Proxy: redactProxy(p), Err: err})
+	}
+	return checks
+}
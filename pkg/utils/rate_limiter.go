@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+const (
+	// defaultRateLimitBurst is the burst of the upstream rate limit if not configured
+	defaultRateLimitBurst = 10
+	// RateLimitMaxWait is how long a call to the JioTV API is queued before it is rejected
+	RateLimitMaxWait = 10 * time.Second
+)
+
+// ErrRateLimited is returned for calls to the JioTV API that exceed the configured rate
+var ErrRateLimited = errors.New("too many requests to JioTV, please try again shortly")
+
+// TokenBucket limits the rate of calls. It holds up to burst tokens and gains perMinute tokens
+// per minute. Every call takes a token. Methods of a nil TokenBucket allow every call.
+type TokenBucket struct {
+	mu        sync.Mutex
+	perMinute int
+	burst     int
+	// tokens may be negative when calls have reserved tokens that are not available yet
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+	sleep  func(time.Duration)
+}
+
+var (
+	upstreamLimiter   *TokenBucket
+	upstreamLimiterMu sync.Mutex
+)
+
+// NewTokenBucket returns a full token bucket.
+func NewTokenBucket(perMinute, burst int) *TokenBucket {
+	return &TokenBucket{
+		perMinute: perMinute,
+		burst:     burst,
+		tokens:    float64(burst),
+		last:      time.Now(),
+		now:       time.Now,
+		sleep:     time.Sleep,
+	}
+}
+
+// UpstreamLimiter returns the token bucket shared by calls to the JioTV playback and channels APIs,
+// or nil if the rate is not limited.
+func UpstreamLimiter() *TokenBucket {
+	perMinute := config.Cfg.UpstreamRateLimit
+	if perMinute <= 0 {
+		return nil
+	}
+	burst := config.Cfg.UpstreamRateBurst
+	if burst <= 0 {
+		burst = defaultRateLimitBurst
+	}
+
+	upstreamLimiterMu.Lock()
+	defer upstreamLimiterMu.Unlock()
+	if upstreamLimiter == nil || upstreamLimiter.perMinute != perMinute || upstreamLimiter.burst != burst {
+		upstreamLimiter = NewTokenBucket(perMinute, burst)
+	}
+	return upstreamLimiter
+}
+
+// refill adds the tokens gained since the last call. The caller must hold the lock.
+func (b *TokenBucket) refill() time.Time {
+	now := b.now()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(float64(b.burst), b.tokens+elapsed.Minutes()*float64(b.perMinute))
+		b.last = now
+	}
+	return now
+}
+
+// untilToken returns how long it takes until a token is available. The caller must hold the lock.
+func (b *TokenBucket) untilToken() time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / float64(b.perMinute) * float64(time.Minute))
+}
+
+// TryTake takes a token if one is available without waiting.
+func (b *TokenBucket) TryTake() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Wait takes a token, waiting for it in turn with other calls. It returns ErrRateLimited
+// without waiting if the token would not be available within maxWait.
+func (b *TokenBucket) Wait(maxWait time.Duration) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	b.refill()
+	delay := b.untilToken()
+	if delay > maxWait {
+		b.mu.Unlock()
+		return ErrRateLimited
+	}
+	// Reserve the token, so that later calls queue behind this one
+	b.tokens--
+	b.mu.Unlock()
+
+	if delay > 0 {
+		b.sleep(delay)
+	}
+	return nil
+}
+
+// RetryAfter returns the number of seconds until a token is available, at least 1.
+func (b *TokenBucket) RetryAfter() int {
+	if b == nil {
+		return 1
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	return max(1, int(math.Ceil(b.untilToken().Seconds())))
+}
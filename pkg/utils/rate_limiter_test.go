@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var slept []time.Duration
+	bucket := NewTokenBucket(60, 2)
+	bucket.now = func() time.Time { return now }
+	bucket.last = now
+	bucket.sleep = func(d time.Duration) { slept = append(slept, d) }
+
+	// The burst is available at once
+	if !bucket.TryTake() || !bucket.TryTake() {
+		t.Fatal("TryTake() of the burst = false, want true")
+	}
+	if bucket.TryTake() {
+		t.Fatal("TryTake() of an empty bucket = true, want false")
+	}
+	if got := bucket.RetryAfter(); got != 1 {
+		t.Errorf("RetryAfter() = %d, want 1", got)
+	}
+
+	// Calls queue one after the other at the configured rate
+	for i := 0; i < 3; i++ {
+		if err := bucket.Wait(5 * time.Second); err != nil {
+			t.Fatalf("Wait() #%d error = %v", i, err)
+		}
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	if len(slept) != len(want) {
+		t.Fatalf("slept %v, want %v", slept, want)
+	}
+	for i := range want {
+		if slept[i] != want[i] {
+			t.Errorf("sleep #%d = %v, want %v", i, slept[i], want[i])
+		}
+	}
+
+	// Calls that would wait longer than allowed are rejected without reserving a token
+	if err := bucket.Wait(3 * time.Second); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Wait() error = %v, want %v", err, ErrRateLimited)
+	}
+	if got := bucket.RetryAfter(); got != 4 {
+		t.Errorf("RetryAfter() = %d, want 4", got)
+	}
+
+	// Tokens refill up to the burst
+	now = now.Add(time.Hour)
+	if !bucket.TryTake() || !bucket.TryTake() || bucket.TryTake() {
+		t.Error("bucket did not refill to its burst")
+	}
+}
+
+func TestNilTokenBucket(t *testing.T) {
+	var bucket *TokenBucket
+	if !bucket.TryTake() {
+		t.Error("TryTake() = false, want true")
+	}
+	if err := bucket.Wait(0); err != nil {
+		t.Errorf("Wait() error = %v, want nil", err)
+	}
+	if got := bucket.RetryAfter(); got != 1 {
+		t.Errorf("RetryAfter() = %d, want 1", got)
+	}
+}
+
+func TestUpstreamLimiter(t *testing.T) {
+	originalCfg := config.Cfg
+	defer func() { config.Cfg = originalCfg }()
+
+	config.Cfg.UpstreamRateLimit = 0
+	if UpstreamLimiter() != nil {
+		t.Error("UpstreamLimiter() without rate limit is not nil")
+	}
+
+	config.Cfg.UpstreamRateLimit = 30
+	config.Cfg.UpstreamRateBurst = 0
+	limiter := UpstreamLimiter()
+	if limiter == nil || limiter.perMinute != 30 || limiter.burst != defaultRateLimitBurst {
+		t.Fatalf("UpstreamLimiter() = %+v, want 30 per minute with default burst", limiter)
+	}
+	if UpstreamLimiter() != limiter {
+		t.Error("UpstreamLimiter() is not shared")
+	}
+
+	config.Cfg.UpstreamRateBurst = 5
+	if got := UpstreamLimiter(); got == limiter || got.burst != 5 {
+		t.Errorf("UpstreamLimiter() after config change = %+v, want burst 5", got)
+	}
+}
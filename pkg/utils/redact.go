@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	// logRedacted replaces tokens in the log
+	logRedacted = "REDACTED"
+	// maxLogSecrets is the number of recent credential values redacted from the log. Tokens are
+	// refreshed every few hours, so older values do not need to be kept.
+	maxLogSecrets = 32
+	// minLogSecretLength is the length below which values are not redacted, to avoid mangling unrelated text
+	minLogSecretLength = 8
+)
+
+var (
+	// logTokenParamPattern matches tokens in query strings, like the CDN tokens, the admin token and device tokens
+	logTokenParamPattern = regexp.MustCompile(`(?i)\b(__hdnea__|hdnea|hdntl|access_?token|sso_?token|refresh_?token|device_token|token)=[^&"'\s,\]]+`)
+	// logJSONSecretPattern matches credentials in JSON
+	logJSONSecretPattern = regexp.MustCompile(`(?i)"(accessToken|refreshToken|ssoToken|authToken|jToken)"\s*:\s*"[^"]*"`)
+	// logBearerPattern matches bearer tokens in Authorization headers
+	logBearerPattern = regexp.MustCompile(`(?i)\b(Bearer)\s+[A-Za-z0-9._~+/=-]+`)
+
+	// logSecrets holds the recent credential values, which are redacted wherever they appear
+	logSecrets   []string
+	logSecretsMu sync.RWMutex
+)
+
+// addLogSecrets adds credential values to be redacted from the log.
+func addLogSecrets(values ...string) {
+	logSecretsMu.Lock()
+	defer logSecretsMu.Unlock()
+	for _, value := range values {
+		if len(value) < minLogSecretLength {
+			continue
+		}
+		known := false
+		for _, secret := range logSecrets {
+			if secret == value {
+				known = true
+				break
+			}
+		}
+		if !known {
+			logSecrets = append(logSecrets, value)
+		}
+	}
+	if len(logSecrets) > maxLogSecrets {
+		logSecrets = logSecrets[len(logSecrets)-maxLogSecrets:]
+	}
+}
+
+// RedactLog replaces the JioTV credentials, CDN tokens and other tokens in a log line.
+func RedactLog(text string) string {
+	logSecretsMu.RLock()
+	for _, secret := range logSecrets {
+		text = strings.ReplaceAll(text, secret, logRedacted)
+	}
+	logSecretsMu.RUnlock()
+	text = logTokenParamPattern.ReplaceAllString(text, "$1="+logRedacted)
+	text = logJSONSecretPattern.ReplaceAllString(text, `"$1":"`+logRedacted+`"`)
+	return logBearerPattern.ReplaceAllString(text, "$1 "+logRedacted)
+}
+
+// redactWriter redacts tokens from what is written to the log. The logger writes whole lines,
+// so a token is never split across writes.
+type redactWriter struct {
+	w io.Writer
+}
+
+// Write writes p to the underlying writer with the tokens redacted. It reports the length of p,
+// as the logger does not expect the redacted length.
+func (r redactWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, RedactLog(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
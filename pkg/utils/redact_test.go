@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRedactLog(t *testing.T) {
+	addLogSecrets("known-sso-token-value", "short")
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "CDN token in URL",
+			text: "GET https://jiotv.live.cdn.jio.com/a.m3u8?__hdnea__=st=1~exp=2~hmac=abc&x=1",
+			want: "GET https://jiotv.live.cdn.jio.com/a.m3u8?__hdnea__=REDACTED&x=1",
+		},
+		{
+			name: "Admin token in query params",
+			text: "200 - GET /api/config Params:[token=admin-secret]",
+			want: "200 - GET /api/config Params:[token=REDACTED]",
+		},
+		{
+			name: "Device token in query params",
+			text: "200 - GET /live/143.m3u8 Params:[q=high&device_token=Zm9vYmFyYmF6cXV4]",
+			want: "200 - GET /live/143.m3u8 Params:[q=high&device_token=REDACTED]",
+		},
+		{
+			name: "Credentials in JSON",
+			text: `response {"ssoToken": "abc", "name": "x"}`,
+			want: `response {"ssoToken":"REDACTED", "name": "x"}`,
+		},
+		{
+			name: "Bearer token",
+			text: "Authorization: Bearer abc.def-ghi",
+			want: "Authorization: Bearer REDACTED",
+		},
+		{
+			name: "Known credential",
+			text: "Using known-sso-token-value for the request",
+			want: "Using REDACTED for the request",
+		},
+		{
+			name: "Short values are kept",
+			text: "short message",
+			want: "short message",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RedactLog(tt.text); got != tt.want {
+				t.Errorf("RedactLog() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactWriter(t *testing.T) {
+	var buf bytes.Buffer
+	line := []byte("GET /render.m3u8?hdnea=secret\n")
+	n, err := redactWriter{w: &buf}.Write(line)
+	if err != nil || n != len(line) {
+		t.Errorf("Write() = %d, %v, want %d", n, err, len(line))
+	}
+	if got := buf.String(); got != "GET /render.m3u8?hdnea=REDACTED\n" {
+		t.Errorf("written = %q", got)
+	}
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// RetryPolicy describes how failed upstream requests are retried
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry. It doubles with every retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff between attempts
+	MaxDelay time.Duration
+	// RetryStatusCodes are the response status codes that are retried
+	RetryStatusCodes []int
+}
+
+// DefaultRetryPolicy retries connection errors and transient server errors a few times
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:      3,
+	BaseDelay:        250 * time.Millisecond,
+	MaxDelay:         2 * time.Second,
+	RetryStatusCodes: []int{fasthttp.StatusTooManyRequests, fasthttp.StatusBadGateway, fasthttp.StatusServiceUnavailable, fasthttp.StatusGatewayTimeout},
+}
+
+// retrySleep is replaced in tests to avoid waiting
+var retrySleep = time.Sleep
+
+// backoff returns the jittered delay before the given retry, starting at 1.
+// The delay is a random duration between half and all of the exponential backoff.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	if p.BaseDelay <= 0 || retry < 1 {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := 1; i < retry && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// shouldRetryStatus reports whether the status code is retried by the policy.
+func (p RetryPolicy) shouldRetryStatus(status int) bool {
+	for _, code := range p.RetryStatusCodes {
+		if code == status {
+			return true
+		}
+	}
+	return false
+}
+
+// isRetryableError reports whether a request error is a transient connection error.
+func isRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, fasthttp.ErrConnectionClosed) ||
+		errors.Is(err, fasthttp.ErrTimeout) ||
+		errors.Is(err, fasthttp.ErrDialTimeout) ||
+		errors.Is(err, fasthttp.ErrNoFreeConns) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// DoWithRetry performs the request and retries it according to the policy.
+// Retries happen on transient connection errors and on the policy's status codes.
+// The response of the last attempt is left in resp.
+func DoWithRetry(client *fasthttp.Client, req *fasthttp.Request, resp *fasthttp.Response, policy RetryPolicy) error {
+	attempts := policy.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			retrySleep(policy.backoff(attempt - 1))
+		}
+
+		err = client.Do(req, resp)
+		if err != nil {
+			if !isRetryableError(err) {
+				return err
+			}
+			SafeLogf("Request to %s failed (attempt %d/%d): %v", req.URI().Host(), attempt, attempts, err)
+			continue
+		}
+		if !policy.shouldRetryStatus(resp.StatusCode()) || attempt == attempts {
+			return nil
+		}
+		SafeLogf("Request to %s returned status %d (attempt %d/%d)", req.URI().Host(), resp.StatusCode(), attempt, attempts)
+	}
+	return err
+}
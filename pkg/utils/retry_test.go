@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+
+	tests := []struct {
+		retry int
+		min   time.Duration
+		max   time.Duration
+	}{
+		{0, 0, 0},
+		{1, 50 * time.Millisecond, 100 * time.Millisecond},
+		{2, 100 * time.Millisecond, 200 * time.Millisecond},
+		{3, 150 * time.Millisecond, 300 * time.Millisecond},
+		{10, 150 * time.Millisecond, 300 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if got := policy.backoff(tt.retry); got < tt.min || got > tt.max {
+				t.Errorf("backoff(%d) = %v, want between %v and %v", tt.retry, got, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"nil", nil, false},
+		{"connection closed", fasthttp.ErrConnectionClosed, true},
+		{"timeout", fasthttp.ErrTimeout, true},
+		{"other", errors.New("invalid URI"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableError(tt.err); got != tt.expected {
+				t.Errorf("isRetryableError() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDoWithRetry(t *testing.T) {
+	originalSleep := retrySleep
+	retrySleep = func(time.Duration) {}
+	defer func() { retrySleep = originalSleep }()
+
+	tests := []struct {
+		name             string
+		statuses         []int
+		policy           RetryPolicy
+		expectedStatus   int
+		expectedAttempts int32
+	}{
+		{
+			name:             "Succeeds after transient errors",
+			statuses:         []int{503, 502, 200},
+			policy:           DefaultRetryPolicy,
+			expectedStatus:   200,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "Gives up after max attempts",
+			statuses:         []int{503, 503, 503, 503},
+			policy:           DefaultRetryPolicy,
+			expectedStatus:   503,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "Does not retry other status codes",
+			statuses:         []int{403, 200},
+			policy:           DefaultRetryPolicy,
+			expectedStatus:   403,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "Single attempt policy",
+			statuses:         []int{503, 200},
+			policy:           RetryPolicy{MaxAttempts: 1},
+			expectedStatus:   503,
+			expectedAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(tt.statuses[n-1])
+			}))
+			defer server.Close()
+
+			req := fasthttp.AcquireRequest()
+			defer fasthttp.ReleaseRequest(req)
+			resp := fasthttp.AcquireResponse()
+			defer fasthttp.ReleaseResponse(resp)
+			req.SetRequestURI(server.URL)
+
+			if err := DoWithRetry(&fasthttp.Client{}, req, resp, tt.policy); err != nil {
+				t.Fatalf("DoWithRetry() error = %v", err)
+			}
+			if resp.StatusCode() != tt.expectedStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode(), tt.expectedStatus)
+			}
+			if got := atomic.LoadInt32(&attempts); got != tt.expectedAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.expectedAttempts)
+			}
+		})
+	}
+}
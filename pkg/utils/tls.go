@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+const (
+	// TLSFingerprintAndroid offers the cipher suites and curves of the TLS stack of Android
+	TLSFingerprintAndroid = "android"
+	// pinPrefix is the optional prefix of TLS pins, as in the output of common pinning tools
+	pinPrefix = "sha256/"
+)
+
+// androidCipherSuites are the TLS 1.2 cipher suites offered by OkHttp and ExoPlayer on Android.
+// TLS 1.3 suites are not configurable and are always offered.
+var androidCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+	tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+	tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+}
+
+// androidCurves are the key exchange groups offered on Android, without the post-quantum
+// group that Go offers by default.
+var androidCurves = []tls.CurveID{tls.X25519, tls.CurveP256, tls.CurveP384}
+
+// tlsPinRoute is a TLS pin rule with its hashes decoded
+type tlsPinRoute struct {
+	domain string
+	hashes [][sha256.Size]byte
+}
+
+var (
+	upstreamTLSOnce   sync.Once
+	upstreamTLSConfig *tls.Config
+)
+
+// UpstreamTLSConfig returns the TLS config of the clients of upstream requests, built from the
+// ca_bundle, tls_pins and tls_fingerprint options. It is nil when none of them is set.
+func UpstreamTLSConfig() *tls.Config {
+	upstreamTLSOnce.Do(func() {
+		var err error
+		upstreamTLSConfig, err = newUpstreamTLSConfig(config.Cfg.CABundle, config.Cfg.TLSPins, config.Cfg.TLSFingerprint)
+		if err != nil && Log != nil {
+			Log.Printf("WARN: %v", err)
+		}
+	})
+	return upstreamTLSConfig
+}
+
+// newUpstreamTLSConfig returns a TLS config that trusts the certificates of the CA bundle in addition
+// to the system roots, checks the pins and offers the ClientHello of the fingerprint. Invalid options
+// are skipped and returned as an error, along with the config of the valid ones.
+func newUpstreamTLSConfig(caBundle string, pins []config.TLSPin, fingerprint string) (*tls.Config, error) {
+	caBundle, fingerprint = strings.TrimSpace(caBundle), strings.ToLower(strings.TrimSpace(fingerprint))
+	if caBundle == "" && len(pins) == 0 && fingerprint == "" {
+		return nil, nil
+	}
+	var errs []error
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caBundle != "" {
+		pool, err := loadCABundle(caBundle)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ca_bundle: %w", err))
+		} else {
+			tlsConfig.RootCAs = pool
+		}
+	}
+
+	routes, err := newTLSPinRoutes(pins)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if len(routes) > 0 {
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			return verifyPins(routes, state)
+		}
+	}
+
+	switch fingerprint {
+	case "", "go":
+	case TLSFingerprintAndroid:
+		tlsConfig.CipherSuites = androidCipherSuites
+		tlsConfig.CurvePreferences = androidCurves
+	default:
+		errs = append(errs, fmt.Errorf("tls_fingerprint %q is not \"go\" or %q", fingerprint, TLSFingerprintAndroid))
+	}
+	return tlsConfig, errors.Join(errs...)
+}
+
+// loadCABundle returns the system roots with the certificates of a PEM file added.
+func loadCABundle(path string) (*x509.CertPool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if pool == nil || err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(content) {
+		return nil, fmt.Errorf("%s has no PEM certificates", path)
+	}
+	return pool, nil
+}
+
+// newTLSPinRoutes decodes the pins of the rules. Rules without a valid pin are skipped.
+func newTLSPinRoutes(pins []config.TLSPin) ([]tlsPinRoute, error) {
+	var errs []error
+	routes := make([]tlsPinRoute, 0, len(pins))
+	for _, rule := range pins {
+		if strings.TrimSpace(rule.Domain) == "" {
+			continue
+		}
+		route := tlsPinRoute{domain: rule.Domain}
+		for _, pin := range rule.Pins {
+			hash, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(strings.TrimSpace(pin), pinPrefix))
+			if err != nil || len(hash) != sha256.Size {
+				errs = append(errs, fmt.Errorf("tls_pins for %s: %q is not a base64 SHA-256 hash", rule.Domain, pin))
+				continue
+			}
+			route.hashes = append(route.hashes, [sha256.Size]byte(hash))
+		}
+		if len(route.hashes) == 0 {
+			errs = append(errs, fmt.Errorf("tls_pins for %s has no valid pin, the rule is skipped", rule.Domain))
+			continue
+		}
+		routes = append(routes, route)
+	}
+	return routes, errors.Join(errs...)
+}
+
+// verifyPins checks that a certificate of the verified chains of a pinned host has one of its pins.
+func verifyPins(routes []tlsPinRoute, state tls.ConnectionState) error {
+	for _, route := range routes {
+		if !matchesProxyDomain(route.domain, state.ServerName) {
+			continue
+		}
+		for _, chain := range state.VerifiedChains {
+			for _, cert := range chain {
+				hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+				for _, pin := range route.hashes {
+					if hash == pin {
+						return nil
+					}
+				}
+			}
+		}
+		return fmt.Errorf("the certificate of %s does not match tls_pins", state.ServerName)
+	}
+	return nil
+}
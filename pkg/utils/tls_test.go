@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+// writeCABundle writes the certificate of a test server to a PEM file and returns its path.
+func writeCABundle(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	content := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewUpstreamTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	bundle := writeCABundle(t, server)
+	pin := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+
+	tests := []struct {
+		name        string
+		caBundle    string
+		pins        []config.TLSPin
+		fingerprint string
+		wantNil     bool
+		wantErr     bool
+		wantRoots   bool
+		wantVerify  bool
+		wantAndroid bool
+	}{
+		{name: "unset", wantNil: true},
+		{name: "go fingerprint", fingerprint: "go"},
+		{name: "android fingerprint", fingerprint: "Android", wantAndroid: true},
+		{name: "unknown fingerprint", fingerprint: "chrome", wantErr: true},
+		{name: "ca bundle", caBundle: bundle, wantRoots: true},
+		{name: "missing ca bundle", caBundle: filepath.Join(t.TempDir(), "missing.pem"), wantErr: true},
+		{name: "pins", pins: []config.TLSPin{{Domain: "jio.com", Pins: []string{"sha256/" + pin}}}, wantVerify: true},
+		{name: "invalid pins", pins: []config.TLSPin{{Domain: "jio.com", Pins: []string{"not-a-pin"}}}, wantErr: true},
+		{name: "some invalid pins", pins: []config.TLSPin{{Domain: "jio.com", Pins: []string{pin, "c2hvcnQ="}}}, wantErr: true, wantVerify: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newUpstreamTLSConfig(tt.caBundle, tt.pins, tt.fingerprint)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (got == nil) != tt.wantNil {
+				t.Fatalf("config = %v, wantNil %v", got, tt.wantNil)
+			}
+			if got == nil {
+				return
+			}
+			if (got.RootCAs != nil) != tt.wantRoots {
+				t.Errorf("RootCAs set = %v, want %v", got.RootCAs != nil, tt.wantRoots)
+			}
+			if (got.VerifyConnection != nil) != tt.wantVerify {
+				t.Errorf("VerifyConnection set = %v, want %v", got.VerifyConnection != nil, tt.wantVerify)
+			}
+			if (got.CipherSuites != nil) != tt.wantAndroid || (got.CurvePreferences != nil) != tt.wantAndroid {
+				t.Errorf("Android cipher suites and curves set = %v, want %v", got.CipherSuites != nil, tt.wantAndroid)
+			}
+		})
+	}
+}
+
+func TestVerifyPins(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	bundle := writeCABundle(t, server)
+	hash := sha256.Sum256(server.Certificate().RawSubjectPublicKeyInfo)
+	serverPin := base64.StdEncoding.EncodeToString(hash[:])
+	otherPin := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+
+	tests := []struct {
+		name    string
+		pins    []config.TLSPin
+		wantErr bool
+	}{
+		{"no pins", nil, false},
+		{"matching pin", []config.TLSPin{{Domain: "example.com", Pins: []string{otherPin, "sha256/" + serverPin}}}, false},
+		{"other pin", []config.TLSPin{{Domain: "example.com", Pins: []string{otherPin}}}, true},
+		{"other domain", []config.TLSPin{{Domain: "jio.com", Pins: []string{otherPin}}}, false},
+		{"first rule wins", []config.TLSPin{{Domain: "example.com", Pins: []string{otherPin}}, {Domain: "com", Pins: []string{serverPin}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tlsConfig, err := newUpstreamTLSConfig(bundle, tt.pins, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The certificate of test servers is valid for example.com
+			tlsConfig.ServerName = "example.com"
+			conn, err := tls.Dial("tcp", server.Listener.Addr().String(), tlsConfig)
+			if err == nil {
+				conn.Close()
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("handshake error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -8,11 +8,9 @@ import (
 	"fmt"
 	"io" // Ensure io is imported
 	"log"
-	"net"
 	"os"
 	"path/filepath" // Ensure path/filepath is imported
 	"strconv"
-	"strings"
 	"time"
 
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -20,9 +18,9 @@ import (
 	"github.com/jiotv-go/jiotv_go/v3/internal/config"
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/headers"
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/urls"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/logstream"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
 	"github.com/valyala/fasthttp"
-	"github.com/valyala/fasthttp/fasthttpproxy"
 )
 
 const (
@@ -76,6 +74,8 @@ func GetLogger() *log.Logger {
 		MaxAge:     7, // days
 	}
 	outputWriters = append(outputWriters, fileLogger)
+	// Keep recent lines in memory for the log stream API
+	outputWriters = append(outputWriters, logstream.Default)
 
 	// Step 3: Create Logger
 	if len(outputWriters) == 0 {
@@ -85,7 +85,8 @@ func GetLogger() *log.Logger {
 		outputWriters = append(outputWriters, os.Stdout)
 	}
 
-	multiWriter := io.MultiWriter(outputWriters...)
+	// Tokens are redacted, so that the log can be shared and is safe on shared machines
+	multiWriter := redactWriter{w: io.MultiWriter(outputWriters...)}
 	logger := log.New(multiWriter, "", 0) // Initial prefix and flags are set to zero values.
 
 	// Step 4: Set Logger Flags and Prefix
@@ -140,6 +141,11 @@ func LoginSendOTP(number string) (bool, error) {
 
 // LoginVerifyOTP verifies OTP for login
 func LoginVerifyOTP(number, otp string) (map[string]string, error) {
+	return DefaultAccount.LoginVerifyOTP(number, otp)
+}
+
+// LoginVerifyOTP verifies OTP and saves the credentials in the account
+func (a *Account) LoginVerifyOTP(number, otp string) (map[string]string, error) {
 	// convert number string to base64
 	encoded_number := base64.StdEncoding.EncodeToString([]byte(number))
 
@@ -154,7 +160,7 @@ func LoginVerifyOTP(number, otp string) (map[string]string, error) {
 				Platform: LoginPayloadDeviceInfoInfoPlatform{
 					Name: "SM-G930F",
 				},
-				AndroidID: GetDeviceID(),
+				AndroidID: a.DeviceID(),
 			},
 		},
 	}
@@ -193,7 +199,7 @@ func LoginVerifyOTP(number, otp string) (map[string]string, error) {
 		crm := result.SessionAttributes.User.SubscriberID
 		uniqueId := result.SessionAttributes.User.Unique
 
-		WriteJIOTVCredentials(&JIOTV_CREDENTIALS{
+		a.WriteCredentials(&JIOTV_CREDENTIALS{
 			SSOToken:             ssoToken,
 			CRM:                  crm,
 			UniqueID:             uniqueId,
@@ -224,15 +230,20 @@ func GetPathPrefix() string {
 
 // GetDeviceID returns the device ID
 func GetDeviceID() string {
-	deviceID, err := store.Get("deviceId")
+	return DefaultAccount.DeviceID()
+}
+
+// DeviceID returns the device ID of the account, generating it if not present
+func (a *Account) DeviceID() string {
+	deviceID, err := a.store().Get("deviceId")
 	if err != nil {
 		Log.Println(err)
-		err = GenerateRandomString()
+		err = a.generateDeviceID()
 		if err != nil {
 			Log.Println(err)
 			return ""
 		}
-		deviceID, err = store.Get("deviceId")
+		deviceID, err = a.store().Get("deviceId")
 		if deviceID == "" {
 			Log.Println("Device ID is empty")
 			return ""
@@ -247,44 +258,51 @@ func GetDeviceID() string {
 // GetJIOTVCredentials return credentials from environment variables or credentials file
 // Important note: If credentials are provided from environment variables, they will be used instead of credentials file
 func GetJIOTVCredentials() (*JIOTV_CREDENTIALS, error) {
-	ssoToken, err := store.Get("ssoToken")
+	return DefaultAccount.Credentials()
+}
+
+// Credentials returns the credentials of the account
+func (a *Account) Credentials() (*JIOTV_CREDENTIALS, error) {
+	kvs := a.store()
+	ssoToken, err := kvs.Get("ssoToken")
 	if err != nil {
 		return nil, err
 	}
 
-	crm, err := store.Get("crm")
+	crm, err := kvs.Get("crm")
 	if err != nil {
 		return nil, err
 	}
 
-	uniqueId, err := store.Get("uniqueId")
+	uniqueId, err := kvs.Get("uniqueId")
 	if err != nil {
 		return nil, err
 	}
 
 	// Required for OTP login
-	accessToken, err := store.Get("accessToken")
+	accessToken, err := kvs.Get("accessToken")
 	if err != nil {
 		return nil, nil
 	}
 
 	// Required for OTP login
-	refreshToken, err := store.Get("refreshToken")
+	refreshToken, err := kvs.Get("refreshToken")
 	if err != nil {
 		return nil, nil
 	}
 
 	// Required for OTP login
-	lastTokenRefreshTime, err := store.Get("lastTokenRefreshTime")
+	lastTokenRefreshTime, err := kvs.Get("lastTokenRefreshTime")
 	if err != nil {
 		return nil, nil
 	}
 
-	lastSSOTokenRefreshTime, err := store.Get("lastSSOTokenRefreshTime")
+	lastSSOTokenRefreshTime, err := kvs.Get("lastSSOTokenRefreshTime")
 	if err != nil {
 		return nil, nil
 	}
 
+	addLogSecrets(ssoToken, accessToken, refreshToken)
 	return &JIOTV_CREDENTIALS{
 		SSOToken:                ssoToken,
 		CRM:                     crm,
@@ -298,6 +316,13 @@ func GetJIOTVCredentials() (*JIOTV_CREDENTIALS, error) {
 
 // WriteJIOTVCredentials writes credentials data to file
 func WriteJIOTVCredentials(credentials *JIOTV_CREDENTIALS) error {
+	return DefaultAccount.WriteCredentials(credentials)
+}
+
+// WriteCredentials writes the credentials of the account to its store
+func (a *Account) WriteCredentials(credentials *JIOTV_CREDENTIALS) error {
+	addLogSecrets(credentials.SSOToken, credentials.AccessToken, credentials.RefreshToken)
+
 	// Prepare batch operations
 	sets := map[string]string{
 		"ssoToken":     credentials.SSOToken,
@@ -321,15 +346,20 @@ func WriteJIOTVCredentials(credentials *JIOTV_CREDENTIALS) error {
 	}
 
 	// Execute batch operations
-	return ExecuteBatchStoreOperations(BatchStoreOperations{
+	return executeBatchStoreOperations(a.store(), BatchStoreOperations{
 		Sets: sets,
 	})
 }
 
 // CheckLoggedIn function checks if user is logged in
 func CheckLoggedIn() bool {
+	return DefaultAccount.CheckLoggedIn()
+}
+
+// CheckLoggedIn checks if the account is logged in
+func (a *Account) CheckLoggedIn() bool {
 	// Check if credentials.json exists
-	_, err := GetJIOTVCredentials()
+	_, err := a.Credentials()
 	if err != nil {
 		Log.Println(err)
 		return false
@@ -340,14 +370,19 @@ func CheckLoggedIn() bool {
 
 // Logout function deletes credentials file
 func Logout() error {
+	return DefaultAccount.Logout()
+}
+
+// Logout logs the account out and deletes its credentials
+func (a *Account) Logout() error {
 	// Perform server-side logout first
-	if err := PerformServerLogout(); err != nil {
+	if err := a.PerformServerLogout(); err != nil {
 		// Log the error but continue with local logout
 		Log.Printf("PerformServerLogout failed: %v", err)
 	}
 
 	// Delete all key-value pairs from the store using batch operations
-	return ExecuteBatchStoreOperations(BatchStoreOperations{
+	return executeBatchStoreOperations(a.store(), BatchStoreOperations{
 		Deletes: []string{
 			"ssoToken",
 			"crm",
@@ -362,9 +397,14 @@ func Logout() error {
 
 // PerformServerLogout attempts to log out the user from the JioTV servers.
 func PerformServerLogout() error {
+	return DefaultAccount.PerformServerLogout()
+}
+
+// PerformServerLogout attempts to log out the account from the JioTV servers.
+func (a *Account) PerformServerLogout() error {
 	Log.Println("Attempting server-side logout...")
 
-	creds, err := GetJIOTVCredentials()
+	creds, err := a.Credentials()
 	if err != nil {
 		Log.Printf("Error getting credentials for server logout: %v\n", err)
 		// Depending on the error, we might still proceed if critical info like refreshToken is available
@@ -374,7 +414,7 @@ func PerformServerLogout() error {
 		}
 	}
 
-	deviceID := GetDeviceID()
+	deviceID := a.DeviceID()
 	if deviceID == "" {
 		Log.Println("Device ID is empty, cannot perform server logout.")
 		return fmt.Errorf("deviceId is empty")
@@ -442,29 +482,36 @@ func PerformServerLogout() error {
 func GetRequestClient() *fasthttp.Client {
 	// The function shall return a fasthttp.client with proxy if given
 	proxy := config.Cfg.Proxy
-
-	if proxy != "" {
-		Log.Println("Using proxy: " + proxy)
-		// check if given proxy is socks5 or http
-		if strings.HasPrefix(proxy, "socks5://") {
-			// socks5 proxy
-			return &fasthttp.Client{
-				Dial: fasthttpproxy.FasthttpSocksDialerDualStack(proxy),
-			}
-		} else {
-			// http proxy
-			return &fasthttp.Client{
-				Dial: fasthttpproxy.FasthttpHTTPDialerDualStackTimeout(proxy, 10*time.Second),
-			}
+	rules := config.Cfg.ProxyRules
+
+	if len(rules) > 0 {
+		Log.Printf("Using proxy: %s with %d domain rules", redactProxy(proxy), len(rules))
+		return &fasthttp.Client{
+			Dial:      newRoutingDialer(defaultProxyDialer(), rules),
+			Transport: requestTransport,
+			TLSConfig: UpstreamTLSConfig(),
 		}
 	}
+	if proxy != "" {
+		Log.Println("Using proxy: " + redactProxy(proxy))
+	}
+	// defaultProxyDialer picks a socks5, http or failover dialer based on the proxy config
 	return &fasthttp.Client{
-		Dial: fasthttp.DialFunc(func(addr string) (netConn net.Conn, err error) {
-			return fasthttp.DialDualStackTimeout(addr, 5*time.Second)
-		}),
+		Dial:      defaultProxyDialer(),
+		Transport: requestTransport,
+		TLSConfig: UpstreamTLSConfig(),
 	}
 }
 
+// requestTransport wraps the requests of clients returned by GetRequestClient, nil for the default transport
+var requestTransport fasthttp.RoundTripper
+
+// SetRequestTransport sets the transport of clients returned by GetRequestClient from now on.
+// It is used to record upstream requests for debugging.
+func SetRequestTransport(transport fasthttp.RoundTripper) {
+	requestTransport = transport
+}
+
 // FileExists function check if given file exists
 func FileExists(filename string) bool {
 	// check if given file exists
@@ -501,12 +548,17 @@ func ContainsString(item string, slice []string) bool {
 
 // GenerateRandomString generates a random 16-character hexadecimal string.
 func GenerateRandomString() error {
+	return DefaultAccount.generateDeviceID()
+}
+
+// generateDeviceID saves a random 16-character hexadecimal device ID in the account's store if it has none.
+func (a *Account) generateDeviceID() error {
 	bytes := make([]byte, 8) // 8 bytes will result in a 16-character hex string
 	if _, err := rand.Read(bytes); err != nil {
 		return err
 	}
-	if _, err := store.Get("deviceId"); err != nil {
-		store.Set("deviceId", hex.EncodeToString(bytes))
+	if _, err := a.store().Get("deviceId"); err != nil {
+		a.store().Set("deviceId", hex.EncodeToString(bytes))
 	}
 	return nil
 }
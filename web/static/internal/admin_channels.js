@@ -0,0 +1,189 @@
+// Custom channels admin page: import and export custom channels with /api/admin/channels
+
+const ADMIN_TOKEN_KEY = "jiotv_admin_token";
+
+const MESSAGE_CLASSES = {
+  info: "alert-info",
+  success: "alert-success",
+  error: "alert-error",
+};
+
+/**
+ * Returns the URL of the import API
+ * @param {string} mode - "preview", "merge" or "replace"
+ * @param {string} fileName - Name of the uploaded file, used to detect its format
+ * @returns {string} URL of the import API
+ */
+function importURL(mode, fileName) {
+  const params = new URLSearchParams({ mode: mode });
+  if (fileName) {
+    params.set("name", fileName);
+  }
+  return "/api/admin/channels/import?" + params.toString();
+}
+
+/**
+ * Returns the message shown after an import
+ * @param {Object} response - Response of the import API
+ * @returns {string} Summary of the import
+ */
+function importSummary(response) {
+  const channels = response.channels.length;
+  const issues = response.issues.length;
+  let summary = channels + (channels === 1 ? " channel" : " channels") + " read from the " + response.format.toUpperCase() + " file";
+  if (issues > 0) {
+    summary += ", " + issues + (issues === 1 ? " problem" : " problems");
+  }
+  if (response.result) {
+    const result = response.result;
+    summary += ". " + result.added + " added";
+    if (result.skipped > 0) {
+      summary += ", " + result.skipped + " already present";
+    }
+    summary += ", " + result.total + " custom channels in total.";
+  } else {
+    summary += ". Nothing was changed yet.";
+  }
+  return summary;
+}
+
+/**
+ * Returns the name of the file of a download from its Content-Disposition header
+ * @param {string|null} disposition - Content-Disposition header
+ * @param {string} fallback - Name used when the header has none
+ * @returns {string} File name
+ */
+function downloadFileName(disposition, fallback) {
+  const match = /filename="?([^";]+)"?/.exec(disposition || "");
+  return match ? match[1] : fallback;
+}
+
+function adminToken() {
+  const input = document.getElementById("admin-token");
+  const token = input.value.trim();
+  sessionStorage.setItem(ADMIN_TOKEN_KEY, token);
+  return token;
+}
+
+function showAdminMessage(text, level) {
+  const message = document.getElementById("admin-message");
+  message.textContent = text;
+  Object.values(MESSAGE_CLASSES).forEach((name) => message.classList.remove(name));
+  message.classList.add(MESSAGE_CLASSES[level] || MESSAGE_CLASSES.info);
+  setElementVisibility(message, true);
+}
+
+async function errorMessage(response) {
+  try {
+    const body = await response.json();
+    return body.message || response.statusText;
+  } catch (e) {
+    return response.statusText;
+  }
+}
+
+function renderImport(response) {
+  const issues = document.getElementById("import-issues");
+  issues.replaceChildren(
+    ...response.issues.map((issue) =>
+      createElement("li", { class: issue.level === "error" ? "text-error" : "text-warning" }, formatIssue(issue))
+    )
+  );
+  const rows = document.getElementById("import-channels");
+  rows.replaceChildren(
+    ...response.channels.map((channel) => {
+      const row = document.createElement("tr");
+      [channel.id, channel.name, channel.category || "", channel.language || "", channel.url].forEach((value, i) => {
+        row.appendChild(createElement("td", i === 4 ? { class: "truncate max-w-xs" } : {}, String(value)));
+      });
+      return row;
+    })
+  );
+}
+
+/**
+ * Formats a problem of an imported channel like the validation API does
+ * @param {Object} issue - Issue of the import API
+ * @returns {string} Problem with the channel index and ID
+ */
+function formatIssue(issue) {
+  const position = [];
+  if (issue.line) {
+    position.push("line " + issue.line);
+  }
+  if (issue.channel_id) {
+    position.push('id "' + issue.channel_id + '"');
+  }
+  let where = "channels[" + issue.index + "]";
+  if (position.length > 0) {
+    where += " (" + position.join(", ") + ")";
+  }
+  return where + ": " + issue.message;
+}
+
+async function importChannels(mode) {
+  const file = document.getElementById("import-file").files[0];
+  if (!file) {
+    showAdminMessage("Choose a file to import first.", "error");
+    return;
+  }
+  if (mode === "replace" && !confirm("Replace all custom channels with the channels of " + file.name + "?")) {
+    return;
+  }
+  showAdminMessage("Reading " + file.name + "...", "info");
+  try {
+    const response = await fetch(importURL(mode, file.name), {
+      method: "POST",
+      headers: { Authorization: "Bearer " + adminToken() },
+      body: await file.text(),
+    });
+    if (!response.ok) {
+      showAdminMessage(await errorMessage(response), "error");
+      return;
+    }
+    const body = await response.json();
+    renderImport(body);
+    showAdminMessage(importSummary(body), body.result ? "success" : "info");
+  } catch (e) {
+    showAdminMessage("The import failed: " + e.message, "error");
+  }
+}
+
+async function exportChannels(format) {
+  try {
+    const response = await fetch("/api/admin/channels/export?format=" + encodeURIComponent(format), {
+      headers: { Authorization: "Bearer " + adminToken() },
+    });
+    if (!response.ok) {
+      showAdminMessage(await errorMessage(response), "error");
+      return;
+    }
+    const url = URL.createObjectURL(await response.blob());
+    const link = createElement("a", {
+      href: url,
+      download: downloadFileName(response.headers.get("Content-Disposition"), "custom-channels." + format),
+    });
+    document.body.appendChild(link);
+    link.click();
+    link.remove();
+    URL.revokeObjectURL(url);
+  } catch (e) {
+    showAdminMessage("The export failed: " + e.message, "error");
+  }
+}
+
+if (typeof document !== "undefined" && document.getElementById("admin-channels")) {
+  document.addEventListener("DOMContentLoaded", () => {
+    document.getElementById("admin-token").value = sessionStorage.getItem(ADMIN_TOKEN_KEY) || "";
+  });
+}
+
+// Export functions for use in other files (if module system is available)
+if (typeof module !== "undefined" && module.exports) {
+  module.exports = {
+    importURL,
+    importSummary,
+    downloadFileName,
+    formatIssue,
+  };
+}
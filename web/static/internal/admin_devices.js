@@ -0,0 +1,153 @@
+// Devices admin page: create and revoke the playback tokens of devices with /api/admin/devices
+
+const ADMIN_TOKEN_KEY = "jiotv_admin_token";
+
+const MESSAGE_CLASSES = {
+  info: "alert-info",
+  success: "alert-success",
+  error: "alert-error",
+};
+
+/**
+ * Returns when a device last used its token
+ * @param {Object} device - Device of the devices API
+ * @param {Date} now - Current time
+ * @returns {string} "Never", or the time since the last use and the client address
+ */
+function lastUsedLabel(device, now) {
+  const lastUsed = new Date(device.last_used);
+  if (!device.last_used || lastUsed.getFullYear() <= 1) {
+    return "Never";
+  }
+  const minutes = Math.max(0, Math.floor((now - lastUsed) / 60000));
+  let ago;
+  if (minutes < 1) {
+    ago = "just now";
+  } else if (minutes < 60) {
+    ago = minutes + " min ago";
+  } else if (minutes < 48 * 60) {
+    ago = Math.floor(minutes / 60) + " h ago";
+  } else {
+    ago = Math.floor(minutes / (24 * 60)) + " days ago";
+  }
+  return device.last_ip ? ago + " from " + device.last_ip : ago;
+}
+
+function adminToken() {
+  const input = document.getElementById("admin-token");
+  const token = input.value.trim();
+  sessionStorage.setItem(ADMIN_TOKEN_KEY, token);
+  return token;
+}
+
+function showAdminMessage(text, level) {
+  const message = document.getElementById("admin-message");
+  message.textContent = text;
+  Object.values(MESSAGE_CLASSES).forEach((name) => message.classList.remove(name));
+  message.classList.add(MESSAGE_CLASSES[level] || MESSAGE_CLASSES.info);
+  setElementVisibility(message, true);
+}
+
+async function errorMessage(response) {
+  try {
+    const body = await response.json();
+    return body.message || response.statusText;
+  } catch (e) {
+    return response.statusText;
+  }
+}
+
+function renderDevices(list) {
+  const rows = document.getElementById("devices");
+  const now = new Date();
+  rows.replaceChildren(
+    ...list.map((device) => {
+      const row = document.createElement("tr");
+      row.appendChild(createElement("td", {}, device.name));
+      row.appendChild(createElement("td", { class: "break-all" }, device.playlist_url));
+      row.appendChild(createElement("td", {}, lastUsedLabel(device, now)));
+      const revoke = createElement("button", { class: "btn btn-sm btn-error" }, "Revoke");
+      revoke.addEventListener("click", () => revokeDevice(device));
+      const actions = createElement("td");
+      actions.appendChild(revoke);
+      row.appendChild(actions);
+      return row;
+    })
+  );
+}
+
+async function loadDevices() {
+  try {
+    const response = await fetch("/api/admin/devices", {
+      headers: { Authorization: "Bearer " + adminToken() },
+    });
+    if (!response.ok) {
+      showAdminMessage(await errorMessage(response), "error");
+      return;
+    }
+    const body = await response.json();
+    renderDevices(body.devices);
+  } catch (e) {
+    showAdminMessage("Loading the devices failed: " + e.message, "error");
+  }
+}
+
+async function createDevice() {
+  const input = document.getElementById("device-name");
+  const name = input.value.trim();
+  if (!name) {
+    showAdminMessage("Enter a name for the device first.", "error");
+    return;
+  }
+  try {
+    const response = await fetch("/api/admin/devices", {
+      method: "POST",
+      headers: { Authorization: "Bearer " + adminToken(), "Content-Type": "application/json" },
+      body: JSON.stringify({ name: name }),
+    });
+    if (!response.ok) {
+      showAdminMessage(await errorMessage(response), "error");
+      return;
+    }
+    const device = await response.json();
+    input.value = "";
+    showAdminMessage("Added " + device.name + ". Open its playlist URL in the IPTV player of the device.", "success");
+    await loadDevices();
+  } catch (e) {
+    showAdminMessage("Adding the device failed: " + e.message, "error");
+  }
+}
+
+async function revokeDevice(device) {
+  if (!confirm("Revoke " + device.name + "? Its playlist stops working.")) {
+    return;
+  }
+  try {
+    const response = await fetch("/api/admin/devices/" + encodeURIComponent(device.id), {
+      method: "DELETE",
+      headers: { Authorization: "Bearer " + adminToken() },
+    });
+    if (!response.ok) {
+      showAdminMessage(await errorMessage(response), "error");
+      return;
+    }
+    showAdminMessage("Revoked " + device.name + ".", "success");
+    await loadDevices();
+  } catch (e) {
+    showAdminMessage("Revoking the device failed: " + e.message, "error");
+  }
+}
+
+if (typeof document !== "undefined" && document.getElementById("admin-devices")) {
+  document.addEventListener("DOMContentLoaded", () => {
+    document.getElementById("admin-token").value = sessionStorage.getItem(ADMIN_TOKEN_KEY) || "";
+    loadDevices();
+  });
+}
+
+// Export functions for use in other files (if module system is available)
+if (typeof module !== "undefined" && module.exports) {
+  module.exports = {
+    lastUsedLabel,
+  };
+}
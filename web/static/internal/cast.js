@@ -0,0 +1,141 @@
+// Casting the current channel to a Chromecast or DLNA renderer, see /api/cast/*
+
+let castDeviceId = localStorage.getItem("castDevice") || "";
+
+/**
+ * Sends a cast control request and returns the JSON response
+ * @param {string} path - API path below /api/cast
+ * @param {Object} body - JSON body of the request
+ * @returns {Promise<Object>} Response data
+ */
+async function castRequest(path, body) {
+  const response = await fetch("/api/cast/" + path, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(body),
+  });
+  const data = await response.json().catch(() => ({}));
+  if (!response.ok) {
+    throw new Error(data.message || "Request failed with status " + response.status);
+  }
+  return data;
+}
+
+/**
+ * Shows a status message in the cast dialog
+ * @param {string} message - Message to show, or empty to hide it
+ * @param {boolean} isError - Whether the message is an error
+ */
+function setCastStatus(message, isError) {
+  const status = document.getElementById("cast-status");
+  if (!status) return;
+  status.textContent = message;
+  status.classList.toggle("text-error", !!isError);
+  status.classList.toggle("hidden", !message);
+}
+
+/**
+ * Fills the device select of the cast dialog
+ * @param {Array<Object>} devices - Devices from /api/cast/devices
+ */
+function renderCastDevices(devices) {
+  const select = document.getElementById("cast-device");
+  if (!select) return;
+  select.innerHTML = "";
+  devices.forEach((device) => {
+    const option = document.createElement("option");
+    option.value = device.id;
+    option.textContent =
+      device.name + (device.kind === "chromecast" ? " (Chromecast)" : " (DLNA)");
+    option.selected = device.id === castDeviceId;
+    select.appendChild(option);
+  });
+  const found = devices.length > 0;
+  select.disabled = !found;
+  ["cast-play", "cast-stop", "cast-volume"].forEach((id) => {
+    const control = document.getElementById(id);
+    if (control) control.disabled = !found;
+  });
+  if (found) {
+    castDeviceId = select.value;
+  }
+}
+
+/**
+ * Searches the network for cast devices
+ * @param {boolean} refresh - Search again instead of using the devices found recently
+ */
+async function loadCastDevices(refresh) {
+  setCastStatus("Searching for devices...");
+  try {
+    const response = await fetch("/api/cast/devices" + (refresh ? "?refresh=1" : ""));
+    const data = await response.json();
+    if (!response.ok) {
+      throw new Error(data.message || "Search failed");
+    }
+    renderCastDevices(data.devices || []);
+    setCastStatus(
+      data.devices && data.devices.length
+        ? ""
+        : "No devices found. The device must be on the same network as JioTV Go."
+    );
+  } catch (error) {
+    renderCastDevices([]);
+    setCastStatus(error.message, true);
+  }
+}
+
+function openCastDialog() {
+  const dialog = document.getElementById("cast-dialog");
+  if (!dialog) return;
+  dialog.showModal();
+  loadCastDevices(false);
+}
+
+function selectCastDevice() {
+  castDeviceId = document.getElementById("cast-device").value;
+  localStorage.setItem("castDevice", castDeviceId);
+}
+
+async function castPlay() {
+  const quality = document.getElementById("cast-quality").value;
+  setCastStatus("Casting...");
+  try {
+    const data = await castRequest("play", {
+      device: castDeviceId,
+      channel_id: channelId,
+      quality: quality === "auto" ? "" : quality,
+    });
+    setCastStatus("Playing on " + data.device.name);
+  } catch (error) {
+    setCastStatus(error.message, true);
+  }
+}
+
+async function castStop() {
+  try {
+    const data = await castRequest("stop", { device: castDeviceId });
+    setCastStatus("Stopped on " + data.device.name);
+  } catch (error) {
+    setCastStatus(error.message, true);
+  }
+}
+
+async function castVolume() {
+  const level = parseInt(document.getElementById("cast-volume").value, 10);
+  try {
+    await castRequest("volume", { device: castDeviceId, level });
+  } catch (error) {
+    setCastStatus(error.message, true);
+  }
+}
+
+// Export functions for use in other files (if module system is available)
+if (typeof module !== "undefined" && module.exports) {
+  module.exports = {
+    castRequest,
+    setCastStatus,
+    renderCastDevices,
+    loadCastDevices,
+  };
+}
@@ -0,0 +1,80 @@
+// Channel visibility page: hide and show channels with /api/v1/channels/hidden
+
+const ADMIN_TOKEN_KEY = "jiotv_admin_token";
+
+/**
+ * Reports whether a channel matches the search of the channel visibility page
+ * @param {string} name - Channel name
+ * @param {string} id - Channel ID
+ * @param {string} query - Search text
+ * @returns {boolean} Whether the name or ID contains the search text, ignoring case
+ */
+function matchesVisibilitySearch(name, id, query) {
+  const text = query.trim().toLowerCase();
+  if (!text) {
+    return true;
+  }
+  return name.toLowerCase().includes(text) || id.toLowerCase().includes(text);
+}
+
+/**
+ * Returns the body of the request that hides or shows a channel
+ * @param {string} id - Channel ID
+ * @param {boolean} visible - Whether the channel is shown
+ * @returns {string} JSON body for /api/v1/channels/hidden
+ */
+function hiddenChannelRequest(id, visible) {
+  return JSON.stringify({ channel_id: id, hidden: !visible });
+}
+
+function adminToken() {
+  const input = document.getElementById("admin-token");
+  const token = input.value.trim();
+  sessionStorage.setItem(ADMIN_TOKEN_KEY, token);
+  return token;
+}
+
+function filterVisibility(query) {
+  document.querySelectorAll(".visibility-channel").forEach((item) => {
+    const id = item.querySelector("input").dataset.id;
+    setElementVisibility(item, matchesVisibilitySearch(item.dataset.name || "", id, query));
+  });
+}
+
+async function setChannelVisible(checkbox) {
+  const error = document.getElementById("visibility-error");
+  setElementVisibility(error, false);
+  checkbox.disabled = true;
+  try {
+    const response = await fetch("/api/v1/channels/hidden", {
+      method: "POST",
+      headers: { Authorization: "Bearer " + adminToken(), "Content-Type": "application/json" },
+      body: hiddenChannelRequest(checkbox.dataset.id, checkbox.checked),
+    });
+    if (!response.ok) {
+      const body = await response.json().catch(() => ({}));
+      throw new Error(body.message || response.statusText);
+    }
+  } catch (e) {
+    // Undo the change that was not saved
+    checkbox.checked = !checkbox.checked;
+    error.textContent = "The channel could not be changed: " + e.message;
+    setElementVisibility(error, true);
+  } finally {
+    checkbox.disabled = false;
+  }
+}
+
+if (typeof document !== "undefined" && document.getElementById("channel-visibility")) {
+  document.addEventListener("DOMContentLoaded", () => {
+    document.getElementById("admin-token").value = sessionStorage.getItem(ADMIN_TOKEN_KEY) || "";
+  });
+}
+
+// Export functions for use in other files (if module system is available)
+if (typeof module !== "undefined" && module.exports) {
+  module.exports = {
+    matchesVisibilitySearch,
+    hiddenChannelRequest,
+  };
+}
@@ -104,16 +104,23 @@ function initRemoteSelectFallback(selectElement) {
   });
 }
 
+// Only shown when channels are in user-defined groups
+const groupElement = document.getElementById("portexe-group-select");
+
 initRemoteSelectFallback(qualityElement);
 initRemoteSelectFallback(categoryElement);
 initRemoteSelectFallback(languageElement);
+initRemoteSelectFallback(groupElement);
 
 catLangApplyButton.addEventListener("click", () => {
   // Apply URL parameters and reload
   updateUrlParameters({
     language: languageElement.value,
     category: categoryElement.value,
-    q: qualityElement.value
+    group: groupElement ? groupElement.value : "",
+    q: qualityElement.value,
+    // Other filters have other pages
+    page: ""
   });
 
   // Reload the page
@@ -133,6 +140,11 @@ if (category && categoryElement) {
   categoryElement.value = category;
 }
 
+const group = urlParams.get("group");
+if (group && groupElement) {
+  groupElement.value = group;
+}
+
 const onQualityChange = (elem) => {
   const quality = elem.value;
   
@@ -175,10 +187,15 @@ const scrollToTop = () => {
 };
 
 // Favorite Channels Functionality
-const FAVORITES_STORAGE_KEY = "favoriteChannels";
+// Each profile keeps its own favorites, starting with those of the config
+const favoritesProfile = document.getElementById("favorite-channels-section")?.dataset || {};
+const FAVORITES_STORAGE_KEY = favoritesProfile.profile
+  ? `favoriteChannels:${favoritesProfile.profile}`
+  : "favoriteChannels";
 
 function getFavoriteChannels() {
-  return getLocalStorageItem(FAVORITES_STORAGE_KEY, []);
+  const profileFavorites = (favoritesProfile.profileFavorites || "").split(",").filter(Boolean);
+  return getLocalStorageItem(FAVORITES_STORAGE_KEY, profileFavorites);
 }
 
 function saveFavoriteChannels(favoriteIds) {
@@ -268,7 +285,69 @@ function updateFavoriteButtonStates() {
   });
 }
 
+function formatProgrammeTime(isoTime) {
+  const date = new Date(isoTime);
+  if (isNaN(date.getTime())) {
+    return "";
+  }
+  return date.toLocaleTimeString([], { hour: "2-digit", minute: "2-digit" });
+}
+
+function nowNextText(nowNext) {
+  if (!nowNext || !nowNext.now) {
+    return "";
+  }
+  let text = `Now: ${nowNext.now.title}`;
+  if (nowNext.next) {
+    text += ` · ${formatProgrammeTime(nowNext.next.start)} ${nowNext.next.title}`;
+  }
+  return text;
+}
+
+function loadNowNext(card) {
+  const channelId = card.dataset.channelId;
+  const nowNextElement = card.querySelector(".now-next");
+  if (!channelId || !nowNextElement) {
+    return;
+  }
+
+  fetch(`/api/v1/now/${encodeURIComponent(channelId)}`)
+    .then((response) => (response.ok ? response.json() : null))
+    .then((nowNext) => {
+      const text = nowNextText(nowNext);
+      nowNextElement.textContent = text;
+      nowNextElement.title = text;
+      nowNextElement.classList.toggle("hidden", text === "");
+
+      // Refresh when the current programme ends
+      const stop = nowNext && nowNext.now ? new Date(nowNext.now.stop).getTime() : NaN;
+      if (!isNaN(stop) && stop > Date.now()) {
+        setTimeout(() => loadNowNext(card), stop - Date.now() + 1000);
+      }
+    })
+    .catch(() => {
+      // Now/next info is optional, channels without a guide just don't show it
+    });
+}
+
+function initNowNext() {
+  if (!("IntersectionObserver" in window)) {
+    return;
+  }
+  // Only fetch the guide of cards that are scrolled into view
+  const observer = new IntersectionObserver((entries) => {
+    entries.forEach((entry) => {
+      if (entry.isIntersecting) {
+        observer.unobserve(entry.target);
+        loadNowNext(entry.target);
+      }
+    });
+  });
+  document.querySelectorAll("a.card[data-channel-id]").forEach((card) => observer.observe(card));
+}
+
 document.addEventListener('DOMContentLoaded', () => {
   updateFavoriteButtonStates();
   displayFavoriteChannels(); 
+  initNowNext();
 });
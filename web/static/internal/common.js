@@ -1,25 +1,27 @@
 const htmlTag = document.getElementsByTagName("html")[0];
 
-const getCurrentTheme = () => {
-  const storedTheme = getLocalStorageItem("theme");
-  if (storedTheme) {
-    return storedTheme;
-  }
+// Cookie with the theme picked with the theme toggle, so that the server renders pages in it
+const THEME_COOKIE = "jiotv_theme";
+
+const hasThemeCookie = () =>
+  document.cookie.split(";").some((cookie) => cookie.trim().startsWith(THEME_COOKIE + "="));
 
+const setThemeCookie = (theme) => {
+  document.cookie = THEME_COOKIE + "=" + theme + "; path=/; max-age=31536000; SameSite=Lax";
+};
+
+const getCurrentTheme = () => {
+  // The server renders the theme from the cookie or the config
   const htmlTag = document.getElementsByTagName("html")[0];
   if (htmlTag.hasAttribute("data-theme")) {
-    const themeAttr = htmlTag.getAttribute("data-theme");
-    setLocalStorageItem("theme", themeAttr);
-    return themeAttr;
+    return htmlTag.getAttribute("data-theme");
   }
 
   // Return system theme preference
   const prefersDark =
     window.matchMedia &&
     window.matchMedia("(prefers-color-scheme: dark)").matches;
-  const systemTheme = prefersDark ? "dark" : "light";
-  setLocalStorageItem("theme", systemTheme);
-  return systemTheme;
+  return prefersDark ? "dark" : "light";
 };
 
 const toggleTheme = () => {
@@ -27,21 +29,25 @@ const toggleTheme = () => {
   const newTheme = getCurrentTheme() === "dark" ? "light" : "dark";
 
   setLocalStorageItem("theme", newTheme);
+  setThemeCookie(newTheme);
   htmlTag.setAttribute("data-theme", newTheme);
 };
 
 const initializeTheme = () => {
   const elements = safeGetElementsById(["sunIcon", "moonIcon"]);
   const { sunIcon, moonIcon } = elements;
+  const htmlTag = document.getElementsByTagName("html")[0];
 
-  if (getCurrentTheme() === "light") {
-    const htmlTag = document.getElementsByTagName("html")[0];
+  // Themes picked before the cookie existed were only kept in local storage
+  const storedTheme = getLocalStorageItem("theme");
+  if ((storedTheme === "dark" || storedTheme === "light") && !hasThemeCookie()) {
+    setThemeCookie(storedTheme);
+    htmlTag.setAttribute("data-theme", storedTheme);
+  }
 
-    if (sunIcon && moonIcon) {
-      sunIcon.classList.replace("swap-on", "swap-off");
-      moonIcon.classList.replace("swap-off", "swap-on");
-    }
-    htmlTag.setAttribute("data-theme", "light");
+  if (getCurrentTheme() === "light" && sunIcon && moonIcon) {
+    sunIcon.classList.replace("swap-on", "swap-off");
+    moonIcon.classList.replace("swap-off", "swap-on");
   }
 };
 
@@ -141,6 +147,57 @@ function toggleCatchupMode() {
     }
   }
 }
+function showMaintenanceBanner() {
+  const banner = document.getElementById("maintenance-banner");
+  if (!banner) return;
+  fetch("/api/maintenance")
+    .then((response) => (response.ok ? response.json() : null))
+    .then((maintenance) => {
+      const announcement = maintenance && maintenance.scheduled ? maintenance.announcement : "";
+      banner.textContent = announcement || "";
+      banner.classList.toggle("hidden", !announcement);
+    })
+    .catch(() => {
+      // The banner is optional
+    });
+}
+// How often a page served while the server was unreachable checks whether it is back
+const SERVER_RETRY_INTERVAL = 5000;
+
+function registerServiceWorker() {
+  if (!("serviceWorker" in navigator)) return;
+  // Service workers only run on HTTPS and localhost
+  navigator.serviceWorker.register("/sw.js").catch((error) => {
+    console.warn("Service worker registration failed:", error);
+  });
+}
+// Pages served by the service worker may be cached copies. Shows the offline banner while the
+// server cannot be reached and reloads the page once it answers again.
+function watchServer() {
+  const banner = document.getElementById("offline-banner");
+  if (!banner || !navigator.serviceWorker || !navigator.serviceWorker.controller) return;
+  let unreachable = false;
+  const check = () => {
+    fetch("/healthz", { cache: "no-store" })
+      .then((response) => {
+        if (!response.ok) {
+          throw new Error("Server unavailable");
+        }
+        if (unreachable) {
+          window.location.reload();
+        }
+      })
+      .catch(() => {
+        unreachable = true;
+        banner.classList.remove("hidden");
+        setTimeout(check, SERVER_RETRY_INTERVAL);
+      });
+  };
+  check();
+}
 document.addEventListener("DOMContentLoaded", function () {
   updateCatchupUI();
+  showMaintenanceBanner();
+  watchServer();
 });
+window.addEventListener("load", registerServiceWorker);
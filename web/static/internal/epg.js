@@ -228,6 +228,8 @@ function updateEPG(epgData) {
       /^\//,
       ""
     );
+    // A smaller poster is enough for the player page
+    posterUrl.search = "w=640&format=auto";
     episodePosterElement.src = posterUrl.href;
   }
 
@@ -0,0 +1,197 @@
+// TV guide page: a timeline of the programmes of all channels from /api/v1/guide
+
+const GUIDE_PX_PER_MINUTE = 4;
+const GUIDE_CHANNEL_WIDTH = 176;
+const GUIDE_HOURS = 3;
+const GUIDE_SLOT_MINUTES = 30;
+
+// Start of the timeline in milliseconds, or null for the current half hour
+let guideStart = null;
+
+/**
+ * Returns the horizontal position of a time on the timeline in pixels
+ * @param {number} time - Time in milliseconds
+ * @param {number} start - Start of the timeline in milliseconds
+ * @returns {number} Offset from the start of the timeline
+ */
+function guideOffset(time, start) {
+  return ((time - start) / 60000) * GUIDE_PX_PER_MINUTE;
+}
+
+/**
+ * Formats a time as hours and minutes in the browser's time zone
+ * @param {Date} date - Time to format
+ * @returns {string} Time like "09:30 PM"
+ */
+function formatGuideTime(date) {
+  return date.toLocaleTimeString([], { hour: "2-digit", minute: "2-digit" });
+}
+
+/**
+ * Returns where a programme leads when clicked: the live player while it airs, the catchup
+ * player after it aired on a channel with catchup, or nothing
+ * @param {Object} channel - Channel of the guide API
+ * @param {Object} programme - Programme of the channel
+ * @param {number} now - Current time in milliseconds
+ * @returns {string|null} Link of the programme
+ */
+function programmeLink(channel, programme, now) {
+  const start = Date.parse(programme.start);
+  const stop = Date.parse(programme.stop);
+  const id = encodeURIComponent(channel.id);
+  if (start <= now && now < stop) {
+    return "/play/" + id;
+  }
+  if (stop <= now && channel.catchup) {
+    return "/catchup/at/" + id + "?t=" + start;
+  }
+  return null;
+}
+
+/**
+ * Returns the start of the timeline that moves by a number of pages of GUIDE_HOURS
+ * @param {number|null} start - Current start in milliseconds, or null for now
+ * @param {number} direction - -1 for earlier, 1 for later, 0 for now
+ * @param {number} now - Current time in milliseconds
+ * @returns {number|null} New start in milliseconds, or null for now
+ */
+function nextGuideStart(start, direction, now) {
+  if (direction === 0) {
+    return null;
+  }
+  const slot = GUIDE_SLOT_MINUTES * 60000;
+  const current = start === null ? Math.floor((now - slot / 2) / slot) * slot : start;
+  return current + direction * GUIDE_HOURS * 3600000;
+}
+
+function cloneTemplate(id) {
+  return document.getElementById(id).content.firstElementChild.cloneNode(true);
+}
+
+function renderGuideTimes(start, end) {
+  const times = document.getElementById("guide-times");
+  times.innerHTML = "";
+  const spacer = document.createElement("div");
+  spacer.style.width = GUIDE_CHANNEL_WIDTH + "px";
+  spacer.style.flexShrink = "0";
+  times.appendChild(spacer);
+  const slot = GUIDE_SLOT_MINUTES * 60000;
+  for (let time = start; time < end; time += slot) {
+    const cell = cloneTemplate("guide-time-template");
+    cell.style.width = guideOffset(Math.min(time + slot, end), time) + "px";
+    cell.textContent = formatGuideTime(new Date(time));
+    times.appendChild(cell);
+  }
+}
+
+function renderGuideRow(channel, start, end, now) {
+  const row = cloneTemplate("guide-row-template");
+  const link = row.querySelector(".guide-channel");
+  link.href = "/play/" + encodeURIComponent(channel.id);
+  link.style.width = GUIDE_CHANNEL_WIDTH + "px";
+  link.title = channel.name;
+  link.querySelector("img").src = channel.logo;
+  link.querySelector("span").textContent = channel.name;
+
+  const timeline = row.querySelector(".guide-timeline");
+  timeline.style.width = guideOffset(end, start) + "px";
+  channel.programmes.forEach((programme) => {
+    const from = Math.max(Date.parse(programme.start), start);
+    const to = Math.min(Date.parse(programme.stop), end);
+    const block = cloneTemplate("guide-programme-template");
+    block.style.left = guideOffset(from, start) + "px";
+    block.style.width = Math.max(guideOffset(to, from) - 2, 0) + "px";
+    block.title = programme.title + (programme.description ? "\n" + programme.description : "");
+    block.querySelector(".guide-title").textContent = programme.title;
+    block.querySelector(".guide-time").textContent =
+      formatGuideTime(new Date(programme.start)) + " - " + formatGuideTime(new Date(programme.stop));
+
+    const href = programmeLink(channel, programme, now);
+    if (href) {
+      block.href = href;
+      block.classList.add("cursor-pointer", "hover:bg-base-300");
+    } else if (Date.parse(programme.stop) <= now) {
+      block.classList.add("opacity-60");
+    }
+    if (Date.parse(programme.start) <= now && now < Date.parse(programme.stop)) {
+      block.classList.remove("bg-base-100");
+      block.classList.add("bg-primary", "text-primary-content");
+    }
+    timeline.appendChild(block);
+  });
+  return row;
+}
+
+function showGuideError(message) {
+  const error = document.getElementById("guide-error");
+  error.textContent = message;
+  error.classList.toggle("hidden", !message);
+}
+
+async function loadGuide() {
+  const params = new URLSearchParams({ hours: GUIDE_HOURS });
+  if (guideStart !== null) {
+    params.set("start", Math.floor(guideStart / 1000));
+  }
+  const category = document.getElementById("guide-category").value;
+  const language = document.getElementById("guide-language").value;
+  if (category !== "0") params.set("category", category);
+  if (language !== "0") params.set("language", language);
+
+  document.getElementById("guide-loading").classList.remove("hidden");
+  showGuideError("");
+  try {
+    const response = await fetch("/api/v1/guide?" + params.toString());
+    const data = await response.json();
+    if (!response.ok) {
+      throw new Error(data.message || "Failed to load the guide");
+    }
+    const start = Date.parse(data.start);
+    const end = Date.parse(data.end);
+    const now = Date.now();
+    guideStart = guideStart === null ? null : start;
+
+    document.getElementById("guide-date").textContent = new Date(start).toLocaleDateString([], {
+      weekday: "long",
+      day: "numeric",
+      month: "short",
+    });
+    renderGuideTimes(start, end);
+    const rows = document.getElementById("guide-rows");
+    rows.innerHTML = "";
+    data.channels.forEach((channel) => rows.appendChild(renderGuideRow(channel, start, end, now)));
+    if (data.channels.length === 0) {
+      showGuideError("No channels have programmes in this time range.");
+    }
+
+    const nowLine = document.getElementById("guide-now");
+    const inRange = start <= now && now < end;
+    nowLine.classList.toggle("hidden", !inRange);
+    if (inRange) {
+      nowLine.style.left = GUIDE_CHANNEL_WIDTH + guideOffset(now, start) + "px";
+    }
+    document.getElementById("guide").classList.remove("hidden");
+  } catch (error) {
+    showGuideError(error.message);
+  } finally {
+    document.getElementById("guide-loading").classList.add("hidden");
+  }
+}
+
+function moveGuide(direction) {
+  guideStart = nextGuideStart(guideStart, direction, Date.now());
+  loadGuide();
+}
+
+if (typeof document !== "undefined" && document.getElementById("guide")) {
+  document.addEventListener("DOMContentLoaded", loadGuide);
+}
+
+// Export functions for use in other files (if module system is available)
+if (typeof module !== "undefined" && module.exports) {
+  module.exports = {
+    guideOffset,
+    programmeLink,
+    nextGuideStart,
+  };
+}
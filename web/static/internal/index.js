@@ -13,8 +13,24 @@ const search = (searchTerm) => {
   });
 };
 
+// pagedSearchURL returns the URL of the first page of the channels matching the search term,
+// as a paged channel list only holds the channels of one page
+const pagedSearchURL = (href, searchTerm) => {
+  const url = new URL(href);
+  const term = searchTerm.trim();
+  if (term !== '') {
+    url.searchParams.set('search', term);
+  } else {
+    url.searchParams.delete('search');
+  }
+  url.searchParams.delete('page');
+  return `${url.pathname}${url.search}`;
+};
+
 const init = () => {
   const searchInput = safeGetElementById('portexe-search-input');
+  const grid = document.getElementById('original-channels-grid');
+  const paged = grid !== null && grid.dataset.paged === 'true';
 
   // Check for search parameter on page load
   const urlParams = getCurrentUrlParams();
@@ -27,6 +43,10 @@ const init = () => {
 
   if (searchInput) {
     searchInput.addEventListener('keyup', (e) => {
+      if (paged && e.key === 'Enter') {
+        document.location.href = pagedSearchURL(window.location.href, e.target.value);
+        return;
+      }
       search(e.target.value);
     });
   }
@@ -91,3 +111,10 @@ const loginOTPVerifyClick = () => {
       alert("OTP verification failed!");
     });
 };
+
+// Export functions for use in other files (if module system is available)
+if (typeof module !== "undefined" && module.exports) {
+  module.exports = {
+    pagedSearchURL,
+  };
+}
@@ -0,0 +1,343 @@
+// In-player controls of the HLS and DRM players: stream quality, bitrate and audio track pickers
+// and buffer health stats, including the timeshift buffer of the server when the stream uses it.
+
+const STREAM_QUALITIES = ["auto", "high", "medium", "low"];
+const PLAYER_STATS_INTERVAL = 2000;
+
+/**
+ * Formats a bitrate in bits per second
+ * @param {number} bps - Bitrate
+ * @returns {string} Bitrate like "2.5 Mbps" or "800 kbps"
+ */
+function formatBitrate(bps) {
+  if (!bps || bps <= 0) {
+    return "-";
+  }
+  if (bps >= 1000000) {
+    return (bps / 1000000).toFixed(1) + " Mbps";
+  }
+  return Math.round(bps / 1000) + " kbps";
+}
+
+/**
+ * Formats a duration in seconds as minutes and seconds
+ * @param {number} seconds - Duration
+ * @returns {string} Duration like "4:05"
+ */
+function formatDuration(seconds) {
+  const total = Math.max(0, Math.round(seconds));
+  return Math.floor(total / 60) + ":" + String(total % 60).padStart(2, "0");
+}
+
+/**
+ * Returns the label of a bitrate level in the picker
+ * @param {Object} level - Level with height and bitrate
+ * @returns {string} Label like "720p · 2.5 Mbps"
+ */
+function levelLabel(level) {
+  const parts = [];
+  if (level.height) {
+    parts.push(level.height + "p");
+  }
+  if (level.bitrate) {
+    parts.push(formatBitrate(level.bitrate));
+  }
+  return parts.join(" · ") || "Level " + (level.index + 1);
+}
+
+/**
+ * Returns how many seconds are buffered ahead of the playback position
+ * @param {TimeRanges} buffered - Buffered ranges of the video
+ * @param {number} time - Playback position in seconds
+ * @returns {number} Buffered seconds ahead
+ */
+function bufferAhead(buffered, time) {
+  for (let i = 0; i < buffered.length; i++) {
+    // Small gaps between ranges are skipped by the players
+    if (buffered.start(i) <= time + 0.5 && time <= buffered.end(i)) {
+      return buffered.end(i) - time;
+    }
+  }
+  return 0;
+}
+
+/**
+ * Rates a buffer for the stats
+ * @param {number} seconds - Buffered seconds ahead
+ * @returns {string} "good", "fair" or "low"
+ */
+function bufferHealth(seconds) {
+  if (seconds >= 10) {
+    return "good";
+  }
+  if (seconds >= 3) {
+    return "fair";
+  }
+  return "low";
+}
+
+/**
+ * Returns the URL of the player page with another stream quality
+ * @param {string} pageURL - URL of the player page
+ * @param {string} quality - auto, high, medium or low
+ * @returns {string} URL with the q parameter set
+ */
+function streamQualityURL(pageURL, quality) {
+  const url = new URL(pageURL);
+  url.searchParams.set("q", quality);
+  return url.toString();
+}
+
+/**
+ * Returns the stream quality of the player page
+ * @param {string} pageURL - URL of the player page
+ * @returns {string} auto, high, medium or low
+ */
+function currentStreamQuality(pageURL) {
+  const quality = new URL(pageURL).searchParams.get("q");
+  return STREAM_QUALITIES.includes(quality) ? quality : "auto";
+}
+
+/**
+ * Adapter for the hls.js instance of Flowplayer
+ * @param {Object} player - Flowplayer instance, which holds hls.js once the stream loaded
+ * @returns {Object} Player adapter of initPlayerControls
+ */
+function hlsControlsAdapter(player) {
+  const hls = () => player.hls || null;
+  return {
+    levels: () =>
+      hls() ? hls().levels.map((level, index) => ({ index, height: level.height, bitrate: level.bitrate })) : [],
+    level: () => (hls() && !hls().autoLevelEnabled ? hls().currentLevel : -1),
+    setLevel: (index) => {
+      if (hls()) hls().currentLevel = index;
+    },
+    audioTracks: () =>
+      hls() ? hls().audioTracks.map((track, index) => ({ index, label: track.name || track.lang || "Track " + (index + 1) })) : [],
+    audioTrack: () => (hls() ? hls().audioTrack : -1),
+    setAudioTrack: (index) => {
+      if (hls()) hls().audioTrack = index;
+    },
+    bitrate: () => {
+      const instance = hls();
+      const level = instance && instance.levels[instance.currentLevel];
+      return level ? level.bitrate : 0;
+    },
+    bandwidth: () => (hls() ? hls().bandwidthEstimate : 0),
+  };
+}
+
+/**
+ * Adapter for Shaka Player
+ * @param {Object} player - shaka.Player instance
+ * @returns {Object} Player adapter of initPlayerControls
+ */
+function shakaControlsAdapter(player) {
+  const active = () => player.getVariantTracks().find((track) => track.active);
+  const audioLanguages = () => player.getAudioLanguagesAndRoles();
+  return {
+    levels: () => {
+      const current = active();
+      const seen = new Set();
+      return player
+        .getVariantTracks()
+        .filter((track) => !current || track.language === current.language)
+        .filter((track) => {
+          const key = track.height + "/" + track.bandwidth;
+          if (seen.has(key)) return false;
+          seen.add(key);
+          return true;
+        })
+        .sort((a, b) => b.bandwidth - a.bandwidth)
+        .map((track) => ({ index: track.id, height: track.height, bitrate: track.bandwidth }));
+    },
+    level: () => {
+      const current = active();
+      return player.getConfiguration().abr.enabled || !current ? -1 : current.id;
+    },
+    setLevel: (id) => {
+      if (id === -1) {
+        player.configure({ abr: { enabled: true } });
+        return;
+      }
+      const track = player.getVariantTracks().find((variant) => variant.id === id);
+      if (track) {
+        player.configure({ abr: { enabled: false } });
+        player.selectVariantTrack(track, true);
+      }
+    },
+    audioTracks: () =>
+      audioLanguages().map((audio, index) => ({
+        index,
+        label: audio.label || audio.language + (audio.role ? " (" + audio.role + ")" : ""),
+      })),
+    audioTrack: () => {
+      const current = active();
+      return current ? audioLanguages().findIndex((audio) => audio.language === current.language) : -1;
+    },
+    setAudioTrack: (index) => {
+      const audio = audioLanguages()[index];
+      if (audio) player.selectAudioLanguage(audio.language, audio.role);
+    },
+    bitrate: () => player.getStats().streamBandwidth,
+    bandwidth: () => player.getStats().estimatedBandwidth,
+  };
+}
+
+// Replaces the options of a picker, keeping it hidden when there is nothing to pick
+function fillPicker(select, options, selected) {
+  const values = options.map((option) => String(option.value)).join(",");
+  if (select.dataset.values !== values) {
+    select.innerHTML = "";
+    options.forEach((option) => {
+      const element = document.createElement("option");
+      element.value = option.value;
+      element.textContent = option.label;
+      select.appendChild(element);
+    });
+    select.dataset.values = values;
+  }
+  select.value = String(selected);
+  select.closest("label").hidden = options.length < 2;
+}
+
+function createPicker(panel, label) {
+  const wrapper = document.createElement("label");
+  wrapper.className = "player-controls-row";
+  const text = document.createElement("span");
+  text.textContent = label;
+  const select = document.createElement("select");
+  wrapper.append(text, select);
+  panel.appendChild(wrapper);
+  return select;
+}
+
+/**
+ * Adds the settings button and panel to a player
+ * @param {HTMLElement} root - Element of the player that stays visible in fullscreen
+ * @param {HTMLVideoElement} video - Video element of the player
+ * @param {Object} adapter - Adapter of the player library, see hlsControlsAdapter
+ * @param {Object} options - statsURL is the stream stats API of the server, or empty
+ */
+function initPlayerControls(root, video, adapter, options = {}) {
+  const toggle = document.createElement("button");
+  toggle.type = "button";
+  toggle.id = "player_settings_toggle";
+  toggle.className = "player-controls-toggle";
+  toggle.title = "Quality, audio and stats";
+  toggle.setAttribute("aria-expanded", "false");
+  toggle.textContent = "⚙";
+
+  const panel = document.createElement("div");
+  panel.className = "player-controls-panel";
+  panel.hidden = true;
+
+  const streamPicker = createPicker(panel, "Stream");
+  const levelPicker = createPicker(panel, "Bitrate");
+  const audioPicker = createPicker(panel, "Audio");
+  const stats = document.createElement("div");
+  stats.className = "player-controls-stats";
+  panel.appendChild(stats);
+  root.append(toggle, panel);
+
+  fillPicker(
+    streamPicker,
+    STREAM_QUALITIES.map((quality) => ({ value: quality, label: quality[0].toUpperCase() + quality.slice(1) })),
+    currentStreamQuality(window.location.href)
+  );
+  streamPicker.addEventListener("change", () => {
+    // Remembered like the quality of the channel list
+    try {
+      if (streamPicker.value === "auto") {
+        localStorage.removeItem("quality");
+      } else {
+        localStorage.setItem("quality", JSON.stringify(streamPicker.value));
+      }
+    } catch (e) {
+      // The choice then only applies to this stream
+    }
+    window.location.replace(streamQualityURL(window.location.href, streamPicker.value));
+  });
+  levelPicker.addEventListener("change", () => adapter.setLevel(Number(levelPicker.value)));
+  audioPicker.addEventListener("change", () => adapter.setAudioTrack(Number(audioPicker.value)));
+
+  let serverStats = null;
+  const refreshServerStats = () => {
+    if (!options.statsURL) return;
+    fetch(options.statsURL, { cache: "no-store" })
+      .then((response) => (response.ok ? response.json() : null))
+      .then((result) => {
+        serverStats = result;
+      })
+      .catch(() => {
+        serverStats = null;
+      });
+  };
+
+  const render = () => {
+    fillPicker(
+      levelPicker,
+      [{ value: -1, label: "Auto" }].concat(adapter.levels().map((level) => ({ value: level.index, label: levelLabel(level) }))),
+      adapter.level()
+    );
+    // Auto and a single level leave nothing to pick
+    levelPicker.closest("label").hidden = adapter.levels().length < 2;
+    fillPicker(
+      audioPicker,
+      adapter.audioTracks().map((track) => ({ value: track.index, label: track.label })),
+      adapter.audioTrack()
+    );
+
+    const buffer = bufferAhead(video.buffered, video.currentTime);
+    const quality = video.getVideoPlaybackQuality ? video.getVideoPlaybackQuality() : null;
+    const lines = [
+      "Resolution: " + (video.videoWidth ? video.videoWidth + "x" + video.videoHeight : "-"),
+      "Bitrate: " + formatBitrate(adapter.bitrate()),
+      "Bandwidth: " + formatBitrate(adapter.bandwidth()),
+      "Buffer: " + buffer.toFixed(1) + " s (" + bufferHealth(buffer) + ")",
+    ];
+    if (quality) {
+      lines.push("Dropped frames: " + quality.droppedVideoFrames + " / " + quality.totalVideoFrames);
+    }
+    if (serverStats && serverStats.timeshift) {
+      let line = "Server buffer: " + formatDuration(serverStats.buffer_seconds);
+      if (typeof serverStats.last_segment_age_seconds === "number") {
+        line += ", last segment " + Math.round(serverStats.last_segment_age_seconds) + " s ago";
+      }
+      lines.push(line);
+      if (serverStats.error) {
+        lines.push("Server error: " + serverStats.error);
+      }
+    }
+    stats.textContent = lines.join("\n");
+  };
+
+  let timer = null;
+  toggle.addEventListener("click", () => {
+    panel.hidden = !panel.hidden;
+    toggle.setAttribute("aria-expanded", panel.hidden ? "false" : "true");
+    clearInterval(timer);
+    if (!panel.hidden) {
+      refreshServerStats();
+      render();
+      timer = setInterval(() => {
+        refreshServerStats();
+        render();
+      }, PLAYER_STATS_INTERVAL);
+    }
+  });
+}
+
+// Export functions for use in other files (if module system is available)
+if (typeof module !== "undefined" && module.exports) {
+  module.exports = {
+    formatBitrate,
+    formatDuration,
+    levelLabel,
+    bufferAhead,
+    bufferHealth,
+    streamQualityURL,
+    currentStreamQuality,
+    hlsControlsAdapter,
+  };
+}
@@ -0,0 +1,329 @@
+// Navigation with the D-pad of a TV remote, for Android TV and Smart TV browsers: the arrow keys
+// move the focus to the nearest control in that direction, Back returns to the previous page,
+// the channel keys switch to the previous or next channel of the channel list while watching and
+// typing a channel number switches to that channel.
+
+// Channels in the order of the channel list, saved when a channel is opened from it
+const ZAP_CHANNELS_KEY = "zapChannels";
+const REMOTE_MESSAGE = "jiotv-remote";
+// How long to wait for another digit of a channel number
+const CHANNEL_NUMBER_DELAY = 1500;
+const CHANNEL_NUMBER_MAX_DIGITS = 4;
+
+const FOCUSABLE_SELECTOR = [
+  "a[href]",
+  "button:not([disabled])",
+  "input:not([disabled]):not([type='hidden'])",
+  "select:not([disabled])",
+  "textarea:not([disabled])",
+  "iframe",
+  "[tabindex]:not([tabindex='-1'])",
+].join(",");
+
+/**
+ * Returns the remote control action of a key
+ * @param {KeyboardEvent} event - Key event
+ * @returns {string|null} "up", "down", "left", "right", "back", "channelUp" or "channelDown"
+ */
+function remoteAction(event) {
+  const key = event.key;
+  const code = event.keyCode;
+  if (key === "ArrowUp" || code === 38) return "up";
+  if (key === "ArrowDown" || code === 40) return "down";
+  if (key === "ArrowLeft" || code === 37) return "left";
+  if (key === "ArrowRight" || code === 39) return "right";
+  // Back of Android TV, webOS (461) and Tizen (10009)
+  if (["GoBack", "BrowserBack", "Backspace"].includes(key) || [8, 461, 10009].includes(code)) return "back";
+  // Channel keys of webOS and Tizen (427, 428), page keys of other remotes
+  if (["ChannelUp", "PageUp"].includes(key) || [33, 427].includes(code)) return "channelUp";
+  if (["ChannelDown", "PageDown"].includes(key) || [34, 428].includes(code)) return "channelDown";
+  return null;
+}
+
+/**
+ * Returns the digit of a number key, including those of the number pad
+ * @param {KeyboardEvent} event - Key event
+ * @returns {string|null} Digit from "0" to "9"
+ */
+function digitOf(event) {
+  if (/^[0-9]$/.test(event.key)) return event.key;
+  const code = event.keyCode;
+  if (code >= 48 && code <= 57) return String(code - 48);
+  if (code >= 96 && code <= 105) return String(code - 96);
+  return null;
+}
+
+/**
+ * Finds the nearest rectangle in a direction, preferring ones in line with the current one
+ * @param {Object} from - Rectangle of the focused element with left, top, right and bottom
+ * @param {Object[]} rects - Rectangles of the other elements
+ * @param {string} direction - "up", "down", "left" or "right"
+ * @returns {number} Index of the nearest rectangle, or -1 if there is none in that direction
+ */
+function nextFocusIndex(from, rects, direction) {
+  const centerX = (rect) => (rect.left + rect.right) / 2;
+  const centerY = (rect) => (rect.top + rect.bottom) / 2;
+  let best = -1;
+  let bestScore = Infinity;
+  rects.forEach((rect, index) => {
+    let distance;
+    let offset;
+    switch (direction) {
+      case "up":
+        if (rect.bottom > from.top + 1) return;
+        distance = from.top - rect.bottom;
+        offset = Math.abs(centerX(rect) - centerX(from));
+        break;
+      case "down":
+        if (rect.top < from.bottom - 1) return;
+        distance = rect.top - from.bottom;
+        offset = Math.abs(centerX(rect) - centerX(from));
+        break;
+      case "left":
+        if (rect.right > from.left + 1) return;
+        distance = from.left - rect.right;
+        offset = Math.abs(centerY(rect) - centerY(from));
+        break;
+      case "right":
+        if (rect.left < from.right - 1) return;
+        distance = rect.left - from.right;
+        offset = Math.abs(centerY(rect) - centerY(from));
+        break;
+      default:
+        return;
+    }
+    const score = Math.max(distance, 0) + offset * 2;
+    if (score < bestScore) {
+      best = index;
+      bestScore = score;
+    }
+  });
+  return best;
+}
+
+/**
+ * Returns the channel to switch to with the channel keys
+ * @param {string[]} channels - Channel IDs in the order of the channel list
+ * @param {string} channelId - Channel being watched
+ * @param {number} step - 1 for the next channel, -1 for the previous one
+ * @returns {string|null} Channel ID, or null if the channel is not in the list
+ */
+function zapTarget(channels, channelId, step) {
+  const index = channels.indexOf(channelId);
+  if (index === -1 || channels.length < 2) {
+    return null;
+  }
+  return channels[(index + step + channels.length) % channels.length];
+}
+
+// Reports whether the arrow keys and Backspace edit the text of an element
+function isTextInput(element) {
+  if (!element) return false;
+  if (element.tagName === "TEXTAREA" || element.isContentEditable) return true;
+  return element.tagName === "INPUT" && !["checkbox", "radio", "range", "button", "submit"].includes(element.type);
+}
+
+// Returns the visible controls the focus can move to, within an open dialog if there is one
+function focusableElements() {
+  const root = document.querySelector("dialog[open]") || document;
+  const elements = Array.from(root.querySelectorAll(FOCUSABLE_SELECTOR)).filter(
+    (element) => element.getClientRects().length > 0 && !element.closest("[hidden]")
+  );
+  // Controls inside others, like the favourite button of a channel, are left to Tab
+  return elements.filter((element) => !elements.some((other) => other !== element && other.contains(element)));
+}
+
+function moveFocus(direction) {
+  const candidates = focusableElements();
+  const current = document.activeElement;
+  if (!current || current === document.body || !candidates.includes(current)) {
+    const first = candidates.find((element) => element.matches("a.card")) || candidates[0];
+    if (!first) return false;
+    first.focus();
+    return true;
+  }
+  const others = candidates.filter((element) => element !== current);
+  const index = nextFocusIndex(
+    current.getBoundingClientRect(),
+    others.map((element) => element.getBoundingClientRect()),
+    direction
+  );
+  if (index === -1) {
+    return false;
+  }
+  others[index].focus();
+  others[index].scrollIntoView({ block: "nearest", inline: "nearest" });
+  return true;
+}
+
+// Channel number being typed, shown in the corner until it is complete
+const channelNumberEntry = { digits: "", timer: null, element: null };
+
+function showChannelNumber(text) {
+  if (!channelNumberEntry.element) {
+    const element = document.createElement("div");
+    element.id = "channel-number-entry";
+    element.setAttribute("role", "status");
+    element.style.cssText =
+      "position:fixed;top:1rem;right:1rem;z-index:1000;padding:0.5rem 1rem;border-radius:0.5rem;" +
+      "background:rgba(0,0,0,0.75);color:#fff;font:bold 2.5rem sans-serif;";
+    document.body.appendChild(element);
+    channelNumberEntry.element = element;
+  }
+  channelNumberEntry.element.textContent = text;
+  channelNumberEntry.element.hidden = text === "";
+}
+
+// Adds a digit to the channel number and switches once no more digits follow
+function typeChannelNumber(digit) {
+  if (channelNumberEntry.digits.length >= CHANNEL_NUMBER_MAX_DIGITS) {
+    channelNumberEntry.digits = "";
+  }
+  channelNumberEntry.digits += digit;
+  showChannelNumber(channelNumberEntry.digits);
+  clearTimeout(channelNumberEntry.timer);
+  channelNumberEntry.timer = setTimeout(openChannelNumber, CHANNEL_NUMBER_DELAY);
+}
+
+function openChannelNumber() {
+  clearTimeout(channelNumberEntry.timer);
+  const number = channelNumberEntry.digits;
+  channelNumberEntry.digits = "";
+  if (!number) return;
+  fetch("/api/v1/channels/number/" + Number(number))
+    .then((response) => (response.ok ? response.json() : null))
+    .then((channel) => {
+      if (!channel) {
+        showChannelNumber("No channel " + Number(number));
+        channelNumberEntry.timer = setTimeout(() => showChannelNumber(""), CHANNEL_NUMBER_DELAY);
+        return;
+      }
+      // Keep the quality of the current stream, or the one picked on the channel list
+      const quality = new URLSearchParams(window.location.search).get("q") || getLocalStorageItem("quality", "");
+      window.location.href =
+        "/play/" + encodeURIComponent(channel.channel_id) + (quality ? "?q=" + encodeURIComponent(quality) : "");
+    })
+    .catch(() => showChannelNumber(""));
+}
+
+function zap(step) {
+  const player = document.getElementById("player");
+  const channelId = player && player.dataset.channelId;
+  if (!channelId) return false;
+  const target = zapTarget(getLocalStorageItem(ZAP_CHANNELS_KEY, []), channelId, step);
+  if (!target) return false;
+  window.location.href = "/play/" + encodeURIComponent(target) + window.location.search;
+  return true;
+}
+
+function goBack() {
+  const dialog = document.querySelector("dialog[open]");
+  if (dialog) {
+    dialog.close();
+    return true;
+  }
+  // The channel list is the start page
+  if (document.getElementById("original-channels-grid")) {
+    return false;
+  }
+  if (window.history.length > 1) {
+    window.history.back();
+  } else {
+    window.location.href = "/";
+  }
+  return true;
+}
+
+function handleRemoteAction(action, digit) {
+  switch (action) {
+    case "digit":
+      typeChannelNumber(digit);
+      return true;
+    case "channelUp":
+      return zap(1);
+    case "channelDown":
+      return zap(-1);
+    case "back":
+      return goBack();
+    default:
+      return moveFocus(action);
+  }
+}
+
+function initRemoteNavigation() {
+  // In the player frames, only the keys of the page around them are passed on
+  if (window.parent !== window) {
+    document.addEventListener("keydown", (event) => {
+      if (isTextInput(document.activeElement)) {
+        return;
+      }
+      const digit = digitOf(event);
+      const action = digit ? "digit" : remoteAction(event);
+      if (["digit", "back", "channelUp", "channelDown"].includes(action)) {
+        event.preventDefault();
+        window.parent.postMessage({ type: REMOTE_MESSAGE, action, digit }, window.location.origin);
+      }
+    });
+    return;
+  }
+
+  document.addEventListener("keydown", (event) => {
+    // Keys already handled, like those of the remote select fallback of the channel list
+    if (event.defaultPrevented || event.altKey || event.ctrlKey || event.metaKey) {
+      return;
+    }
+    const typing = isTextInput(document.activeElement);
+    const digit = typing ? null : digitOf(event);
+    if (digit) {
+      handleRemoteAction("digit", digit);
+      event.preventDefault();
+      return;
+    }
+    // Enter completes a channel number right away
+    if (channelNumberEntry.digits && (event.key === "Enter" || event.keyCode === 13)) {
+      openChannelNumber();
+      event.preventDefault();
+      return;
+    }
+    const action = remoteAction(event);
+    if (!action) {
+      return;
+    }
+    if (typing && ["left", "right", "back"].includes(action)) {
+      return;
+    }
+    if (handleRemoteAction(action)) {
+      event.preventDefault();
+    }
+  });
+
+  window.addEventListener("message", (event) => {
+    if (event.origin === window.location.origin && event.data && event.data.type === REMOTE_MESSAGE) {
+      handleRemoteAction(event.data.action, event.data.digit);
+    }
+  });
+
+  // Remember the order of the channel list for the channel keys
+  document.addEventListener("click", (event) => {
+    const card = event.target.closest && event.target.closest("a.card[data-channel-id]");
+    if (!card) return;
+    const channels = Array.from(document.querySelectorAll("a.card[data-channel-id]"))
+      .filter((element) => element.style.display !== "none")
+      .map((element) => element.dataset.channelId);
+    setLocalStorageItem(ZAP_CHANNELS_KEY, channels);
+  });
+}
+
+if (typeof document !== "undefined" && typeof module === "undefined") {
+  initRemoteNavigation();
+}
+
+// Export functions for use in other files (if module system is available)
+if (typeof module !== "undefined" && module.exports) {
+  module.exports = {
+    remoteAction,
+    digitOf,
+    nextFocusIndex,
+    zapTarget,
+  };
+}
@@ -0,0 +1,176 @@
+// Service worker of the web interface, served on /sw.js. It caches the app shell, the channel
+// list and channel logos, so that the pages still open while the server is briefly unreachable.
+// Streams and all other API calls always go to the server.
+
+// Replaced with the version of JioTV Go, so that an update replaces the cached files
+const CACHE_VERSION = "__CACHE_VERSION__";
+const CACHE_PREFIX = "jiotv-";
+const SHELL_CACHE = CACHE_PREFIX + "shell-" + CACHE_VERSION;
+const PAGES_CACHE = CACHE_PREFIX + "pages-" + CACHE_VERSION;
+const IMAGES_CACHE = CACHE_PREFIX + "images-" + CACHE_VERSION;
+const MAX_CACHED_IMAGES = 500;
+const OFFLINE_URL = "/offline";
+
+const SHELL_URLS = [
+  OFFLINE_URL,
+  "/manifest.webmanifest",
+  "/static/internal/tailwind.css",
+  "/static/internal/utils.js",
+  "/static/internal/common.js",
+  "/static/internal/index.js",
+  "/static/internal/remote.js",
+  "/static/icons/icon-medium.png",
+  "/static/icons/icon-high.svg",
+];
+
+// Pages and data that are served from the cache when the server cannot be reached
+const CACHED_PAGES = ["/", "/guide"];
+const CACHED_DATA = ["/channels", "/api/v1/guide"];
+
+/**
+ * Returns how the service worker handles a request
+ * @param {string} method - HTTP method of the request
+ * @param {URL} url - URL of the request
+ * @param {string} origin - Origin of the web interface
+ * @param {string} mode - Mode of the request, "navigate" for pages
+ * @returns {string|null} "page", "data" or "asset", or null to leave the request to the browser
+ */
+function cacheStrategy(method, url, origin, mode) {
+  if (method !== "GET" || url.origin !== origin) {
+    return null;
+  }
+  if (mode === "navigate") {
+    return "page";
+  }
+  if (CACHED_DATA.includes(url.pathname)) {
+    return "data";
+  }
+  if (url.pathname.startsWith("/static/") || url.pathname.startsWith("/jtvimage/")) {
+    return "asset";
+  }
+  return null;
+}
+
+/**
+ * Reports whether a page is kept in the cache after it was loaded
+ * @param {URL} url - URL of the page
+ * @returns {boolean} True for the channel list and the TV guide
+ */
+function isCachedPage(url) {
+  return CACHED_PAGES.includes(url.pathname);
+}
+
+// Removes the oldest entries of a cache beyond a number of entries
+async function trimCache(name, maxEntries) {
+  const cache = await caches.open(name);
+  const keys = await cache.keys();
+  for (let i = 0; i < keys.length - maxEntries; i++) {
+    await cache.delete(keys[i]);
+  }
+}
+
+// Loads a page from the server, falling back to its cached copy and then to the offline page
+async function networkFirstPage(request, url) {
+  try {
+    const response = await fetch(request);
+    if (response.ok && isCachedPage(url)) {
+      const cache = await caches.open(PAGES_CACHE);
+      await cache.put(request, response.clone());
+    }
+    return response;
+  } catch (error) {
+    const cached = await caches.match(request, { cacheName: PAGES_CACHE });
+    return cached || caches.match(OFFLINE_URL, { cacheName: SHELL_CACHE });
+  }
+}
+
+// Loads data from the server, falling back to its cached copy
+async function networkFirstData(request) {
+  try {
+    const response = await fetch(request);
+    if (response.ok) {
+      const cache = await caches.open(PAGES_CACHE);
+      await cache.put(request, response.clone());
+    }
+    return response;
+  } catch (error) {
+    const cached = await caches.match(request, { cacheName: PAGES_CACHE });
+    if (cached) {
+      return cached;
+    }
+    throw error;
+  }
+}
+
+// Serves an asset from the cache and refreshes it in the background
+async function staleWhileRevalidate(event, request, url) {
+  const cacheName = url.pathname.startsWith("/jtvimage/") ? IMAGES_CACHE : SHELL_CACHE;
+  const cache = await caches.open(cacheName);
+  const cached = await cache.match(request);
+  const refresh = fetch(request).then(async (response) => {
+    if (response.ok) {
+      await cache.put(request, response.clone());
+      if (cacheName === IMAGES_CACHE) {
+        await trimCache(IMAGES_CACHE, MAX_CACHED_IMAGES);
+      }
+    }
+    return response;
+  });
+  if (cached) {
+    event.waitUntil(refresh.catch(() => {}));
+    return cached;
+  }
+  return refresh;
+}
+
+if (typeof self !== "undefined" && typeof caches !== "undefined" && typeof self.skipWaiting === "function") {
+  self.addEventListener("install", (event) => {
+    event.waitUntil(
+      caches
+        .open(SHELL_CACHE)
+        .then((cache) => cache.addAll(SHELL_URLS))
+        .then(() => self.skipWaiting())
+    );
+  });
+
+  self.addEventListener("activate", (event) => {
+    // Remove the caches of older versions
+    const current = [SHELL_CACHE, PAGES_CACHE, IMAGES_CACHE];
+    event.waitUntil(
+      caches
+        .keys()
+        .then((names) =>
+          Promise.all(
+            names
+              .filter((name) => name.startsWith(CACHE_PREFIX) && !current.includes(name))
+              .map((name) => caches.delete(name))
+          )
+        )
+        .then(() => self.clients.claim())
+    );
+  });
+
+  self.addEventListener("fetch", (event) => {
+    const request = event.request;
+    const url = new URL(request.url);
+    switch (cacheStrategy(request.method, url, self.location.origin, request.mode)) {
+      case "page":
+        event.respondWith(networkFirstPage(request, url));
+        break;
+      case "data":
+        event.respondWith(networkFirstData(request));
+        break;
+      case "asset":
+        event.respondWith(staleWhileRevalidate(event, request, url));
+        break;
+    }
+  });
+}
+
+// Export functions for use in other files (if module system is available)
+if (typeof module !== "undefined" && module.exports) {
+  module.exports = {
+    cacheStrategy,
+    isCachedPage,
+  };
+}
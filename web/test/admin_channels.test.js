@@ -0,0 +1,53 @@
+/**
+ * @jest-environment jsdom
+ */
+
+const { importURL, importSummary, downloadFileName, formatIssue } = require("../static/internal/admin_channels.js");
+
+describe("importURL", () => {
+  test("sets the mode and the file name", () => {
+    expect(importURL("merge", "my channels.m3u")).toBe("/api/admin/channels/import?mode=merge&name=my+channels.m3u");
+  });
+
+  test("leaves out a missing file name", () => {
+    expect(importURL("preview", "")).toBe("/api/admin/channels/import?mode=preview");
+  });
+});
+
+describe("importSummary", () => {
+  test("summarizes a preview", () => {
+    const response = { format: "m3u", channels: [{ id: "a" }], issues: [{ message: "x" }, { message: "y" }] };
+    expect(importSummary(response)).toBe("1 channel read from the M3U file, 2 problems. Nothing was changed yet.");
+  });
+
+  test("summarizes a merge", () => {
+    const response = {
+      format: "json",
+      channels: [{ id: "a" }, { id: "b" }],
+      issues: [],
+      result: { added: 1, skipped: 1, total: 5 },
+    };
+    expect(importSummary(response)).toBe(
+      "2 channels read from the JSON file. 1 added, 1 already present, 5 custom channels in total."
+    );
+  });
+});
+
+describe("downloadFileName", () => {
+  test("reads the file name of the Content-Disposition header", () => {
+    expect(downloadFileName('attachment; filename="custom-channels.m3u"', "x")).toBe("custom-channels.m3u");
+  });
+
+  test("falls back without a header", () => {
+    expect(downloadFileName(null, "custom-channels.json")).toBe("custom-channels.json");
+  });
+});
+
+describe("formatIssue", () => {
+  test("formats an issue like the validation API", () => {
+    expect(formatIssue({ index: 3, line: 42, channel_id: "news", message: "url is empty" })).toBe(
+      'channels[3] (line 42, id "news"): url is empty'
+    );
+    expect(formatIssue({ index: 0, message: "id is empty" })).toBe("channels[0]: id is empty");
+  });
+});
@@ -0,0 +1,24 @@
+/**
+ * @jest-environment jsdom
+ */
+
+const { lastUsedLabel } = require("../static/internal/admin_devices.js");
+
+describe("lastUsedLabel", () => {
+  const now = new Date("2026-10-16T12:00:00Z");
+
+  test("shows devices that never used their token", () => {
+    expect(lastUsedLabel({ last_used: "0001-01-01T00:00:00Z", last_ip: "" }, now)).toBe("Never");
+  });
+
+  test("shows the minutes since the last use and the client", () => {
+    expect(lastUsedLabel({ last_used: "2026-10-16T11:45:00Z", last_ip: "192.168.1.20" }, now)).toBe(
+      "15 min ago from 192.168.1.20"
+    );
+  });
+
+  test("shows hours and days", () => {
+    expect(lastUsedLabel({ last_used: "2026-10-16T07:00:00Z" }, now)).toBe("5 h ago");
+    expect(lastUsedLabel({ last_used: "2026-10-10T12:00:00Z" }, now)).toBe("6 days ago");
+  });
+});
@@ -0,0 +1,65 @@
+/**
+ * @jest-environment jsdom
+ */
+
+const { castRequest, renderCastDevices } = require("../static/internal/cast.js");
+
+describe("renderCastDevices", () => {
+  beforeEach(() => {
+    document.body.innerHTML = `
+      <select id="cast-device" disabled></select>
+      <button id="cast-play" disabled></button>
+      <button id="cast-stop" disabled></button>
+      <input id="cast-volume" type="range" disabled />
+    `;
+  });
+
+  test("lists the devices and enables the controls", () => {
+    renderCastDevices([
+      { id: "chromecast-1", name: "Living Room", kind: "chromecast" },
+      { id: "dlna-2", name: "Bedroom", kind: "dlna" },
+    ]);
+    const options = document.querySelectorAll("#cast-device option");
+    expect(options).toHaveLength(2);
+    expect(options[0].textContent).toBe("Living Room (Chromecast)");
+    expect(options[1].textContent).toBe("Bedroom (DLNA)");
+    expect(document.getElementById("cast-device").disabled).toBe(false);
+    expect(document.getElementById("cast-play").disabled).toBe(false);
+  });
+
+  test("disables the controls without devices", () => {
+    renderCastDevices([]);
+    expect(document.querySelectorAll("#cast-device option")).toHaveLength(0);
+    expect(document.getElementById("cast-play").disabled).toBe(true);
+    expect(document.getElementById("cast-volume").disabled).toBe(true);
+  });
+});
+
+describe("castRequest", () => {
+  afterEach(() => {
+    delete global.fetch;
+  });
+
+  test("posts the body as JSON", async () => {
+    global.fetch = jest.fn().mockResolvedValue({
+      ok: true,
+      json: () => Promise.resolve({ device: { name: "Living Room" } }),
+    });
+    const data = await castRequest("stop", { device: "chromecast-1" });
+    expect(global.fetch).toHaveBeenCalledWith("/api/cast/stop", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ device: "chromecast-1" }),
+    });
+    expect(data.device.name).toBe("Living Room");
+  });
+
+  test("throws the error message of the server", async () => {
+    global.fetch = jest.fn().mockResolvedValue({
+      ok: false,
+      status: 404,
+      json: () => Promise.resolve({ message: "Cast device not found, search for devices again" }),
+    });
+    await expect(castRequest("play", { device: "dlna-2" })).rejects.toThrow("Cast device not found");
+  });
+});
@@ -0,0 +1,24 @@
+/**
+ * @jest-environment jsdom
+ */
+
+const { matchesVisibilitySearch, hiddenChannelRequest } = require("../static/internal/channel_visibility.js");
+
+describe("matchesVisibilitySearch", () => {
+  test("matches everything without a search", () => {
+    expect(matchesVisibilitySearch("News18 India", "143", "  ")).toBe(true);
+  });
+
+  test("matches the name or ID, ignoring case", () => {
+    expect(matchesVisibilitySearch("News18 India", "143", "india")).toBe(true);
+    expect(matchesVisibilitySearch("My Channel", "cc_mine", "CC_")).toBe(true);
+    expect(matchesVisibilitySearch("News18 India", "143", "sports")).toBe(false);
+  });
+});
+
+describe("hiddenChannelRequest", () => {
+  test("hides unchecked channels", () => {
+    expect(JSON.parse(hiddenChannelRequest("143", false))).toEqual({ channel_id: "143", hidden: true });
+    expect(JSON.parse(hiddenChannelRequest("143", true))).toEqual({ channel_id: "143", hidden: false });
+  });
+});
@@ -349,3 +349,44 @@ describe('Favorite Channels Functionality', () => {
     });
   });
 });
+
+// Define the now/next functions from channels.js for testing
+function formatProgrammeTime(isoTime) {
+  const date = new Date(isoTime);
+  if (isNaN(date.getTime())) {
+    return "";
+  }
+  return date.toLocaleTimeString([], { hour: "2-digit", minute: "2-digit" });
+}
+
+function nowNextText(nowNext) {
+  if (!nowNext || !nowNext.now) {
+    return "";
+  }
+  let text = `Now: ${nowNext.now.title}`;
+  if (nowNext.next) {
+    text += ` · ${formatProgrammeTime(nowNext.next.start)} ${nowNext.next.title}`;
+  }
+  return text;
+}
+
+describe('Now/Next overlay', () => {
+  it('should be empty without a current programme', () => {
+    expect(nowNextText(null)).toBe('');
+    expect(nowNextText({ channel_id: '143', now: null, next: null })).toBe('');
+  });
+
+  it('should show the current programme', () => {
+    expect(nowNextText({ now: { title: 'Headlines' }, next: null })).toBe('Now: Headlines');
+  });
+
+  it('should show the next programme with its start time', () => {
+    const start = '2024-01-11T10:00:00+05:30';
+    const text = nowNextText({ now: { title: 'Headlines' }, next: { title: 'Sports', start } });
+    expect(text).toBe(`Now: Headlines · ${formatProgrammeTime(start)} Sports`);
+  });
+
+  it('should not format invalid times', () => {
+    expect(formatProgrammeTime('invalid')).toBe('');
+  });
+});
@@ -28,39 +28,32 @@ Object.defineProperty(window, 'matchMedia', {
 });
 
 // Define the functions from common.js for testing
+const THEME_COOKIE = "jiotv_theme";
+
+const hasThemeCookie = () =>
+  document.cookie.split(";").some((cookie) => cookie.trim().startsWith(THEME_COOKIE + "="));
+
+const setThemeCookie = (theme) => {
+  document.cookie = THEME_COOKIE + "=" + theme + "; path=/; max-age=31536000; SameSite=Lax";
+};
+
 const getCurrentTheme = () => {
-  if (localStorage.getItem("theme")) {
-    // return local storage theme
-    return localStorage.getItem("theme");
-  } else if (document.getElementsByTagName("html")[0].hasAttribute("data-theme")) {
-    // return data-theme attribute
-    const theme = document.getElementsByTagName("html")[0].getAttribute("data-theme");
-    localStorage.setItem("theme", theme);
-    return theme;
-  } else {
-    // return system theme
-    if (
-      window.matchMedia &&
-      window.matchMedia("(prefers-color-scheme: dark)").matches
-    ) {
-      localStorage.setItem("theme", "dark");
-      return "dark";
-    }
-    localStorage.setItem("theme", "light");
-    return "light";
+  const htmlTag = document.getElementsByTagName("html")[0];
+  if (htmlTag.hasAttribute("data-theme")) {
+    return htmlTag.getAttribute("data-theme");
   }
+  const prefersDark =
+    window.matchMedia &&
+    window.matchMedia("(prefers-color-scheme: dark)").matches;
+  return prefersDark ? "dark" : "light";
 };
 
 const toggleTheme = () => {
-  // toggle or add attribute "data-theme" to html tag
   const htmlTag = document.getElementsByTagName("html")[0];
-  if (getCurrentTheme() == "dark") {
-    localStorage.setItem("theme", "light");
-    htmlTag.setAttribute("data-theme", "light");
-  } else {
-    localStorage.setItem("theme", "dark");
-    htmlTag.setAttribute("data-theme", "dark");
-  }
+  const newTheme = getCurrentTheme() === "dark" ? "light" : "dark";
+  localStorage.setItem("theme", newTheme);
+  setThemeCookie(newTheme);
+  htmlTag.setAttribute("data-theme", newTheme);
 };
 
 const initializeTheme = () => {
@@ -68,19 +61,27 @@ const initializeTheme = () => {
   const moonIcon = document.getElementById("moonIcon");
   const htmlTag = document.getElementsByTagName("html")[0];
 
-  if (getCurrentTheme() == "light") {
-    if (sunIcon && moonIcon) {
-      sunIcon.classList.replace("swap-on", "swap-off");
-      moonIcon.classList.replace("swap-off", "swap-on");
-    }
-    htmlTag.setAttribute("data-theme", "light");
+  const storedTheme = localStorage.getItem("theme");
+  if ((storedTheme === "dark" || storedTheme === "light") && !hasThemeCookie()) {
+    setThemeCookie(storedTheme);
+    htmlTag.setAttribute("data-theme", storedTheme);
   }
+
+  if (getCurrentTheme() === "light" && sunIcon && moonIcon) {
+    sunIcon.classList.replace("swap-on", "swap-off");
+    moonIcon.classList.replace("swap-off", "swap-on");
+  }
+};
+
+const clearThemeCookie = () => {
+  document.cookie = THEME_COOKIE + "=; path=/; max-age=0";
 };
 
 describe('Theme Management Functionality', () => {
   beforeEach(() => {
     localStorageMock.clear();
     jest.clearAllMocks();
+    clearThemeCookie();
     
     // Reset HTML structure
     document.body.innerHTML = '';
@@ -93,56 +94,52 @@ describe('Theme Management Functionality', () => {
   });
 
   describe('getCurrentTheme', () => {
-    it('should return theme from localStorage if available', () => {
-      localStorageMock.setItem('theme', 'dark');
-      expect(getCurrentTheme()).toBe('dark');
-    });
-
-    it('should return theme from data-theme attribute if localStorage is empty', () => {
+    it('should return the theme rendered by the server', () => {
       const htmlTag = document.getElementsByTagName("html")[0];
       htmlTag.setAttribute('data-theme', 'light');
+      mockMatchMedia.mockReturnValue({ matches: true });
       expect(getCurrentTheme()).toBe('light');
-      expect(localStorageMock.setItem).toHaveBeenCalledWith('theme', 'light');
     });
 
-    it('should return system theme preference when no stored theme', () => {
+    it('should return system theme preference when no theme is rendered', () => {
       mockMatchMedia.mockReturnValue({ matches: true });
       expect(getCurrentTheme()).toBe('dark');
-      expect(localStorageMock.setItem).toHaveBeenCalledWith('theme', 'dark');
+      expect(localStorageMock.setItem).not.toHaveBeenCalled();
     });
 
     it('should default to light theme when system preference is not dark', () => {
       mockMatchMedia.mockReturnValue({ matches: false });
       expect(getCurrentTheme()).toBe('light');
-      expect(localStorageMock.setItem).toHaveBeenCalledWith('theme', 'light');
     });
 
     it('should default to light theme when matchMedia is not available', () => {
-      Object.defineProperty(window, 'matchMedia', { value: null });
+      const matchMedia = window.matchMedia;
+      Object.defineProperty(window, 'matchMedia', { value: null, writable: true });
       expect(getCurrentTheme()).toBe('light');
-      expect(localStorageMock.setItem).toHaveBeenCalledWith('theme', 'light');
+      Object.defineProperty(window, 'matchMedia', { value: matchMedia, writable: true });
     });
   });
 
   describe('toggleTheme', () => {
-    it('should toggle from dark to light theme', () => {
-      localStorageMock.setItem('theme', 'dark');
+    it('should toggle from dark to light theme and remember it in the cookie', () => {
       const htmlTag = document.getElementsByTagName("html")[0];
+      htmlTag.setAttribute('data-theme', 'dark');
       
       toggleTheme();
       
       expect(localStorageMock.setItem).toHaveBeenCalledWith('theme', 'light');
       expect(htmlTag.getAttribute('data-theme')).toBe('light');
+      expect(document.cookie).toContain('jiotv_theme=light');
     });
 
     it('should toggle from light to dark theme', () => {
-      localStorageMock.setItem('theme', 'light');
       const htmlTag = document.getElementsByTagName("html")[0];
+      htmlTag.setAttribute('data-theme', 'light');
       
       toggleTheme();
       
-      expect(localStorageMock.setItem).toHaveBeenCalledWith('theme', 'dark');
       expect(htmlTag.getAttribute('data-theme')).toBe('dark');
+      expect(document.cookie).toContain('jiotv_theme=dark');
     });
 
     it('should toggle to dark when no theme is set initially', () => {
@@ -151,7 +148,6 @@ describe('Theme Management Functionality', () => {
       
       toggleTheme();
       
-      expect(localStorageMock.setItem).toHaveBeenCalledWith('theme', 'dark');
       expect(htmlTag.getAttribute('data-theme')).toBe('dark');
     });
   });
@@ -172,8 +168,8 @@ describe('Theme Management Functionality', () => {
     });
 
     it('should initialize light theme UI when current theme is light', () => {
-      localStorageMock.setItem('theme', 'light');
       const htmlTag = document.getElementsByTagName("html")[0];
+      htmlTag.setAttribute('data-theme', 'light');
       const sunIcon = document.getElementById("sunIcon");
       const moonIcon = document.getElementById("moonIcon");
       
@@ -183,25 +179,39 @@ describe('Theme Management Functionality', () => {
       expect(sunIcon.classList.contains('swap-on')).toBe(false);
       expect(moonIcon.classList.contains('swap-on')).toBe(true);
       expect(moonIcon.classList.contains('swap-off')).toBe(false);
-      expect(htmlTag.getAttribute('data-theme')).toBe('light');
     });
 
     it('should not modify UI when current theme is dark', () => {
-      localStorageMock.setItem('theme', 'dark');
       const htmlTag = document.getElementsByTagName("html")[0];
+      htmlTag.setAttribute('data-theme', 'dark');
       const sunIcon = document.getElementById("sunIcon");
       const moonIcon = document.getElementById("moonIcon");
       
-      // Set initial classes
-      sunIcon.classList.add('swap-on');
-      moonIcon.classList.add('swap-off');
-      
       initializeTheme();
       
-      // Should remain unchanged for dark theme
       expect(sunIcon.classList.contains('swap-on')).toBe(true);
       expect(moonIcon.classList.contains('swap-off')).toBe(true);
-      expect(htmlTag.getAttribute('data-theme')).toBeNull();
+    });
+
+    it('should move a theme from local storage to the cookie', () => {
+      localStorageMock.setItem('theme', 'light');
+      const htmlTag = document.getElementsByTagName("html")[0];
+      
+      initializeTheme();
+      
+      expect(document.cookie).toContain('jiotv_theme=light');
+      expect(htmlTag.getAttribute('data-theme')).toBe('light');
+    });
+
+    it('should prefer the cookie over local storage', () => {
+      setThemeCookie('dark');
+      localStorageMock.setItem('theme', 'light');
+      const htmlTag = document.getElementsByTagName("html")[0];
+      htmlTag.setAttribute('data-theme', 'dark');
+      
+      initializeTheme();
+      
+      expect(htmlTag.getAttribute('data-theme')).toBe('dark');
     });
 
     it('should handle missing theme icons gracefully', () => {
@@ -214,4 +224,4 @@ describe('Theme Management Functionality', () => {
       expect(() => initializeTheme()).not.toThrow();
     });
   });
-});
\ No newline at end of file
+});
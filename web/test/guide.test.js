@@ -0,0 +1,55 @@
+/**
+ * @jest-environment jsdom
+ */
+
+const { guideOffset, programmeLink, nextGuideStart } = require("../static/internal/guide.js");
+
+describe("guideOffset", () => {
+  test("places times 4 pixels per minute from the start", () => {
+    const start = Date.parse("2024-01-11T10:00:00Z");
+    expect(guideOffset(start, start)).toBe(0);
+    expect(guideOffset(start + 30 * 60000, start)).toBe(120);
+    expect(guideOffset(start - 15 * 60000, start)).toBe(-60);
+  });
+});
+
+describe("programmeLink", () => {
+  const programme = { start: "2024-01-11T10:00:00Z", stop: "2024-01-11T11:00:00Z" };
+  const channel = { id: "143", catchup: true };
+
+  test("links a programme on now to the live player", () => {
+    expect(programmeLink(channel, programme, Date.parse("2024-01-11T10:30:00Z"))).toBe("/play/143");
+  });
+
+  test("links a past programme to catchup at its start", () => {
+    expect(programmeLink(channel, programme, Date.parse("2024-01-11T12:00:00Z"))).toBe(
+      "/catchup/at/143?t=" + Date.parse(programme.start)
+    );
+  });
+
+  test("does not link past programmes of channels without catchup", () => {
+    expect(programmeLink({ id: "143", catchup: false }, programme, Date.parse("2024-01-11T12:00:00Z"))).toBeNull();
+  });
+
+  test("does not link future programmes", () => {
+    expect(programmeLink(channel, programme, Date.parse("2024-01-11T09:00:00Z"))).toBeNull();
+  });
+});
+
+describe("nextGuideStart", () => {
+  const now = Date.parse("2024-01-11T10:40:00Z");
+
+  test("moves from the current half hour by the length of the timeline", () => {
+    expect(nextGuideStart(null, 1, now)).toBe(Date.parse("2024-01-11T13:00:00Z"));
+    expect(nextGuideStart(null, -1, now)).toBe(Date.parse("2024-01-11T07:00:00Z"));
+  });
+
+  test("moves from the shown start", () => {
+    const start = Date.parse("2024-01-11T07:30:00Z");
+    expect(nextGuideStart(start, -1, now)).toBe(Date.parse("2024-01-11T04:30:00Z"));
+  });
+
+  test("returns to now", () => {
+    expect(nextGuideStart(Date.parse("2024-01-11T07:30:00Z"), 0, now)).toBeNull();
+  });
+});
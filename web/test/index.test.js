@@ -442,4 +442,23 @@ describe('Search and Login Functionality', () => {
       expect(mockFetch).not.toHaveBeenCalled();
     });
   });
-});
\ No newline at end of file
+});
+
+describe('pagedSearchURL', () => {
+  let pagedSearchURL;
+
+  beforeAll(() => {
+    // index.js runs init on load, which needs the helpers of utils.js
+    global.safeGetElementById = () => null;
+    global.getCurrentUrlParams = () => new URLSearchParams();
+    ({ pagedSearchURL } = require('../static/internal/index.js'));
+  });
+
+  it('searches from the first page and keeps the filters', () => {
+    expect(pagedSearchURL('http://localhost:5001/?language=1&page=3', ' news ')).toBe('/?language=1&search=news');
+  });
+
+  it('removes the search when the search term is empty', () => {
+    expect(pagedSearchURL('http://localhost:5001/?search=news&page=2', '')).toBe('/');
+  });
+});
@@ -0,0 +1,126 @@
+const {
+  formatBitrate,
+  formatDuration,
+  levelLabel,
+  bufferAhead,
+  bufferHealth,
+  streamQualityURL,
+  currentStreamQuality,
+  hlsControlsAdapter,
+} = require("../static/internal/player_controls.js");
+
+// Builds TimeRanges like the buffered ranges of a video element
+const timeRanges = (ranges) => ({
+  length: ranges.length,
+  start: (i) => ranges[i][0],
+  end: (i) => ranges[i][1],
+});
+
+describe("formatBitrate", () => {
+  test("formats bitrates", () => {
+    expect(formatBitrate(2500000)).toBe("2.5 Mbps");
+    expect(formatBitrate(800000)).toBe("800 kbps");
+  });
+
+  test("shows a dash without a bitrate", () => {
+    expect(formatBitrate(0)).toBe("-");
+    expect(formatBitrate(undefined)).toBe("-");
+    expect(formatBitrate(NaN)).toBe("-");
+  });
+});
+
+describe("formatDuration", () => {
+  test("formats minutes and seconds", () => {
+    expect(formatDuration(245)).toBe("4:05");
+    expect(formatDuration(59.6)).toBe("1:00");
+    expect(formatDuration(-3)).toBe("0:00");
+  });
+});
+
+describe("levelLabel", () => {
+  test("shows the height and bitrate", () => {
+    expect(levelLabel({ index: 0, height: 720, bitrate: 2500000 })).toBe("720p · 2.5 Mbps");
+    expect(levelLabel({ index: 0, bitrate: 96000 })).toBe("96 kbps");
+  });
+
+  test("numbers levels without details", () => {
+    expect(levelLabel({ index: 2 })).toBe("Level 3");
+  });
+});
+
+describe("bufferAhead", () => {
+  test("measures the range holding the playback position", () => {
+    expect(bufferAhead(timeRanges([[0, 10], [20, 35]]), 25)).toBe(10);
+    expect(bufferAhead(timeRanges([[10.2, 18]]), 10)).toBeCloseTo(8);
+  });
+
+  test("is zero outside the buffered ranges", () => {
+    expect(bufferAhead(timeRanges([[0, 10]]), 12)).toBe(0);
+    expect(bufferAhead(timeRanges([]), 0)).toBe(0);
+  });
+});
+
+describe("bufferHealth", () => {
+  test("rates the buffer", () => {
+    expect(bufferHealth(12)).toBe("good");
+    expect(bufferHealth(5)).toBe("fair");
+    expect(bufferHealth(1)).toBe("low");
+  });
+});
+
+describe("stream quality", () => {
+  test("sets the quality of the player page", () => {
+    expect(streamQualityURL("http://localhost:5001/player/143?q=low&af=1", "high")).toBe(
+      "http://localhost:5001/player/143?q=high&af=1"
+    );
+    expect(streamQualityURL("http://localhost:5001/mpd/143", "auto")).toBe("http://localhost:5001/mpd/143?q=auto");
+  });
+
+  test("reads the quality of the player page", () => {
+    expect(currentStreamQuality("http://localhost:5001/player/143?q=medium")).toBe("medium");
+    expect(currentStreamQuality("http://localhost:5001/player/143")).toBe("auto");
+    expect(currentStreamQuality("http://localhost:5001/player/143?q=ultra")).toBe("auto");
+  });
+});
+
+describe("hlsControlsAdapter", () => {
+  test("waits for hls.js", () => {
+    const adapter = hlsControlsAdapter({});
+    expect(adapter.levels()).toEqual([]);
+    expect(adapter.level()).toBe(-1);
+    expect(adapter.audioTracks()).toEqual([]);
+    expect(adapter.bitrate()).toBe(0);
+  });
+
+  test("reads and switches levels and audio tracks", () => {
+    const hls = {
+      levels: [
+        { height: 360, bitrate: 800000 },
+        { height: 720, bitrate: 2500000 },
+      ],
+      currentLevel: 1,
+      autoLevelEnabled: true,
+      audioTracks: [{ name: "Hindi" }, { lang: "en" }],
+      audioTrack: 0,
+      bandwidthEstimate: 5000000,
+    };
+    const adapter = hlsControlsAdapter({ hls });
+
+    expect(adapter.levels()).toEqual([
+      { index: 0, height: 360, bitrate: 800000 },
+      { index: 1, height: 720, bitrate: 2500000 },
+    ]);
+    expect(adapter.level()).toBe(-1);
+    expect(adapter.bitrate()).toBe(2500000);
+    expect(adapter.bandwidth()).toBe(5000000);
+    expect(adapter.audioTracks()).toEqual([
+      { index: 0, label: "Hindi" },
+      { index: 1, label: "en" },
+    ]);
+
+    adapter.setLevel(0);
+    adapter.setAudioTrack(1);
+    expect(hls.currentLevel).toBe(0);
+    expect(hls.audioTrack).toBe(1);
+  });
+});
@@ -0,0 +1,91 @@
+const { remoteAction, digitOf, nextFocusIndex, zapTarget } = require("../static/internal/remote.js");
+
+// Builds a rectangle like getBoundingClientRect
+const rect = (left, top, width = 100, height = 100) => ({ left, top, right: left + width, bottom: top + height });
+
+describe("remoteAction", () => {
+  test("maps the D-pad", () => {
+    expect(remoteAction({ key: "ArrowUp" })).toBe("up");
+    expect(remoteAction({ key: "ArrowDown" })).toBe("down");
+    expect(remoteAction({ keyCode: 37 })).toBe("left");
+    expect(remoteAction({ keyCode: 39 })).toBe("right");
+  });
+
+  test("maps the back keys of TV browsers", () => {
+    expect(remoteAction({ key: "GoBack" })).toBe("back");
+    expect(remoteAction({ key: "Backspace" })).toBe("back");
+    expect(remoteAction({ keyCode: 461 })).toBe("back");
+    expect(remoteAction({ keyCode: 10009 })).toBe("back");
+  });
+
+  test("maps the channel keys", () => {
+    expect(remoteAction({ key: "ChannelUp" })).toBe("channelUp");
+    expect(remoteAction({ keyCode: 428 })).toBe("channelDown");
+    expect(remoteAction({ key: "PageDown" })).toBe("channelDown");
+  });
+
+  test("ignores other keys", () => {
+    expect(remoteAction({ key: "Enter", keyCode: 13 })).toBeNull();
+    expect(remoteAction({ key: "a", keyCode: 65 })).toBeNull();
+  });
+});
+
+describe("digitOf", () => {
+  test("reads number keys and the number pad", () => {
+    expect(digitOf({ key: "7", keyCode: 55 })).toBe("7");
+    expect(digitOf({ key: "Unidentified", keyCode: 48 })).toBe("0");
+    expect(digitOf({ key: "Unidentified", keyCode: 105 })).toBe("9");
+  });
+
+  test("ignores other keys", () => {
+    expect(digitOf({ key: "a", keyCode: 65 })).toBeNull();
+    expect(digitOf({ key: "ArrowUp", keyCode: 38 })).toBeNull();
+  });
+});
+
+describe("nextFocusIndex", () => {
+  // A grid of channel cards, two rows of three
+  const grid = [rect(0, 0), rect(120, 0), rect(240, 0), rect(0, 120), rect(120, 120), rect(240, 120)];
+  const others = (index) => grid.filter((_, i) => i !== index);
+
+  test("moves along a row", () => {
+    expect(others(1)[nextFocusIndex(grid[1], others(1), "right")]).toBe(grid[2]);
+    expect(others(1)[nextFocusIndex(grid[1], others(1), "left")]).toBe(grid[0]);
+  });
+
+  test("moves to the card below or above in line", () => {
+    expect(others(1)[nextFocusIndex(grid[1], others(1), "down")]).toBe(grid[4]);
+    expect(others(5)[nextFocusIndex(grid[5], others(5), "up")]).toBe(grid[2]);
+  });
+
+  test("finds nothing past the edge", () => {
+    expect(nextFocusIndex(grid[2], others(2), "right")).toBe(-1);
+    expect(nextFocusIndex(grid[0], others(0), "up")).toBe(-1);
+  });
+
+  test("prefers a control in line over a nearer one off to the side", () => {
+    const from = rect(0, 0, 100, 40);
+    const rects = [rect(300, 50, 100, 40), rect(0, 150, 100, 40)];
+    expect(nextFocusIndex(from, rects, "down")).toBe(1);
+  });
+});
+
+describe("zapTarget", () => {
+  const channels = ["143", "144", "145"];
+
+  test("switches to the next and previous channel", () => {
+    expect(zapTarget(channels, "144", 1)).toBe("145");
+    expect(zapTarget(channels, "144", -1)).toBe("143");
+  });
+
+  test("wraps around the list", () => {
+    expect(zapTarget(channels, "145", 1)).toBe("143");
+    expect(zapTarget(channels, "143", -1)).toBe("145");
+  });
+
+  test("does nothing for channels outside the list", () => {
+    expect(zapTarget(channels, "999", 1)).toBeNull();
+    expect(zapTarget(["143"], "143", 1)).toBeNull();
+    expect(zapTarget([], "143", 1)).toBeNull();
+  });
+});
@@ -0,0 +1,38 @@
+const { cacheStrategy, isCachedPage } = require("../static/internal/sw.js");
+
+describe("cacheStrategy", () => {
+  const origin = "http://localhost:5001";
+  const strategy = (path, options = {}) =>
+    cacheStrategy(options.method || "GET", new URL(path, options.origin || origin), origin, options.mode || "cors");
+
+  test("loads pages from the network first", () => {
+    expect(strategy("/", { mode: "navigate" })).toBe("page");
+    expect(strategy("/play/143", { mode: "navigate" })).toBe("page");
+  });
+
+  test("caches the channel list and guide data", () => {
+    expect(strategy("/channels")).toBe("data");
+    expect(strategy("/api/v1/guide?hours=3")).toBe("data");
+  });
+
+  test("caches static files and channel logos", () => {
+    expect(strategy("/static/internal/tailwind.css")).toBe("asset");
+    expect(strategy("/jtvimage/Sony_HD.png")).toBe("asset");
+  });
+
+  test("leaves streams, other APIs and other origins to the browser", () => {
+    expect(strategy("/live/143.m3u8")).toBeNull();
+    expect(strategy("/render.ts?auth=1")).toBeNull();
+    expect(strategy("/healthz")).toBeNull();
+    expect(strategy("/api/cast/play", { method: "POST" })).toBeNull();
+    expect(strategy("/static/app.js", { origin: "https://cdn.example.com" })).toBeNull();
+  });
+});
+
+describe("isCachedPage", () => {
+  test("keeps the channel list and the TV guide", () => {
+    expect(isCachedPage(new URL("http://localhost/"))).toBe(true);
+    expect(isCachedPage(new URL("http://localhost/guide"))).toBe(true);
+    expect(isCachedPage(new URL("http://localhost/play/143"))).toBe(false);
+  });
+});
@@ -1,6 +1,18 @@
+//go:build !headless
+
 package web
 
-import "embed"
+import (
+	"embed"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/template/html/v2"
+)
+
+// Included reports whether the web interface is compiled into the binary.
+// Binaries built with the headless tag leave it out.
+const Included = true
 
 //go:embed views/*
 var viewFiles embed.FS
@@ -15,3 +27,18 @@ func GetViewFiles() embed.FS {
 func GetStaticFiles() embed.FS {
 	return staticFiles
 }
+
+// Views returns the template engine of the web interface.
+// With reload, templates are parsed again on every render.
+func Views(reload bool) fiber.Views {
+	engine := html.NewFileSystem(http.FS(viewFiles), ".html")
+	if reload {
+		engine.Reload(true)
+	}
+	return engine
+}
+
+// StaticFiles returns the static assets of the web interface.
+func StaticFiles() http.FileSystem {
+	return http.FS(staticFiles)
+}
@@ -0,0 +1,41 @@
+//go:build headless
+
+package web
+
+import (
+	"embed"
+	"io"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Included reports whether the web interface is compiled into the binary.
+// Binaries built with the headless tag leave it out.
+const Included = false
+
+// errNotIncluded is returned for web pages in headless builds
+var errNotIncluded = fiber.NewError(fiber.StatusNotFound, "The web interface is not included in this headless build. Use the playlist and API endpoints instead.")
+
+// headlessViews is a template engine without templates
+type headlessViews struct{}
+
+// Load implements fiber.Views.
+func (headlessViews) Load() error {
+	return nil
+}
+
+// Render implements fiber.Views.
+func (headlessViews) Render(io.Writer, string, interface{}, ...string) error {
+	return errNotIncluded
+}
+
+// Views returns a template engine that fails every web page with 404 Not Found.
+func Views(bool) fiber.Views {
+	return headlessViews{}
+}
+
+// StaticFiles returns an empty file system.
+func StaticFiles() http.FileSystem {
+	return http.FS(embed.FS{})
+}